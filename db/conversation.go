@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -30,13 +31,20 @@ func (db *DB) CreateConversation(title, category string) (*Conversation, error)
 	}, nil
 }
 
-// GetConversation retrieves a conversation by ID
+// GetConversation retrieves a conversation by ID. Conversations in the
+// recycle bin are not found.
 func (db *DB) GetConversation(id int64) (*Conversation, error) {
+	return db.GetConversationCtx(context.Background(), id)
+}
+
+// GetConversationCtx retrieves a conversation by ID, aborting if ctx is cancelled
+func (db *DB) GetConversationCtx(ctx context.Context, id int64) (*Conversation, error) {
 	var conv Conversation
-	err := db.conn.QueryRow(
-		"SELECT id, title, category, created_at, updated_at FROM conversations WHERE id = ?",
+	var params string
+	err := db.conn.QueryRowContext(ctx,
+		"SELECT id, title, category, COALESCE(parent_id, 0), COALESCE(system_prompt, ''), COALESCE(params_override, ''), COALESCE(pinned, 0), created_at, updated_at FROM conversations WHERE id = ? AND deleted_at IS NULL",
 		id,
-	).Scan(&conv.ID, &conv.Title, &conv.Category, &conv.CreatedAt, &conv.UpdatedAt)
+	).Scan(&conv.ID, &conv.Title, &conv.Category, &conv.ParentID, &conv.SystemPrompt, &params, &conv.Pinned, &conv.CreatedAt, &conv.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("conversation not found")
@@ -44,14 +52,18 @@ func (db *DB) GetConversation(id int64) (*Conversation, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get conversation: %w", err)
 	}
+	if conv.ParamsOverride, err = decodeConversationParams(params); err != nil {
+		return nil, err
+	}
 
 	return &conv, nil
 }
 
-// ListConversations retrieves all conversations ordered by update time
+// ListConversations retrieves all conversations not in the recycle bin, the
+// pinned ones first, each ordered by update time
 func (db *DB) ListConversations(limit, offset int) ([]*Conversation, error) {
 	rows, err := db.conn.Query(
-		"SELECT id, title, category, created_at, updated_at FROM conversations ORDER BY updated_at DESC LIMIT ? OFFSET ?",
+		"SELECT id, title, category, COALESCE(params_override, ''), COALESCE(pinned, 0), created_at, updated_at FROM conversations WHERE deleted_at IS NULL ORDER BY pinned DESC, updated_at DESC LIMIT ? OFFSET ?",
 		limit, offset,
 	)
 	if err != nil {
@@ -62,15 +74,307 @@ func (db *DB) ListConversations(limit, offset int) ([]*Conversation, error) {
 	var conversations []*Conversation
 	for rows.Next() {
 		var conv Conversation
-		if err := rows.Scan(&conv.ID, &conv.Title, &conv.Category, &conv.CreatedAt, &conv.UpdatedAt); err != nil {
+		var params string
+		if err := rows.Scan(&conv.ID, &conv.Title, &conv.Category, &params, &conv.Pinned, &conv.CreatedAt, &conv.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan conversation: %w", err)
 		}
+		if conv.ParamsOverride, err = decodeConversationParams(params); err != nil {
+			return nil, err
+		}
 		conversations = append(conversations, &conv)
 	}
 
 	return conversations, nil
 }
 
+// ForkConversation copies a conversation up to and including the message at
+// fromMessageIndex (0-based, in display order) into a new conversation whose
+// parent is the source. The copies keep their role, content, attachments,
+// token counts and timestamps. Nothing is written if any step fails.
+func (db *DB) ForkConversation(sourceID int64, fromMessageIndex int) (*Conversation, error) {
+	if fromMessageIndex < 0 {
+		return nil, fmt.Errorf("invalid message index %d", fromMessageIndex)
+	}
+	return db.copyConversation(sourceID, fromMessageIndex+1, func(source *Conversation) {
+		source.Title += " (分叉)"
+		source.ParentID = sourceID
+	})
+}
+
+// DuplicateConversation copies a conversation with all its messages, system
+// prompt and parameters into a new, unrelated conversation titled "Copy of
+// <title>". Tags and the pinned state are not copied.
+func (db *DB) DuplicateConversation(id int64) (*Conversation, error) {
+	return db.copyConversation(id, -1, func(source *Conversation) {
+		source.Title = "Copy of " + source.Title
+	})
+}
+
+// copyConversation copies a conversation with its first messageLimit
+// messages, or all of them if messageLimit is negative. adjust sets the
+// title and parent of the copy, which starts out as the source.
+func (db *DB) copyConversation(sourceID int64, messageLimit int, adjust func(*Conversation)) (*Conversation, error) {
+	// Read the source inside the transaction so a concurrent edit cannot
+	// slip in between reading and copying
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source Conversation
+	var params string
+	err = tx.QueryRow("SELECT title, category, COALESCE(system_prompt, ''), COALESCE(params_override, '') FROM conversations WHERE id = ?", sourceID).Scan(&source.Title, &source.Category, &source.SystemPrompt, &params)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("conversation not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	// SQLite treats a negative limit as no limit
+	rows, err := tx.Query(
+		"SELECT role, content, original_content, provider, model, attachments, tokens_used, created_at, metadata FROM messages WHERE conversation_id = ? ORDER BY created_at ASC LIMIT ?",
+		sourceID, messageLimit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	var messages []Message
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(&msg.Role, &msg.Content, &msg.OriginalContent, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt, &msg.Metadata); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	if messageLimit > len(messages) {
+		return nil, fmt.Errorf("message index %d out of range (conversation has %d messages)", messageLimit-1, len(messages))
+	}
+
+	now := time.Now()
+	copied := &Conversation{
+		Title:        source.Title,
+		Category:     source.Category,
+		SystemPrompt: source.SystemPrompt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	adjust(copied)
+	if copied.ParamsOverride, err = decodeConversationParams(params); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO conversations (title, category, parent_id, system_prompt, params_override, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		copied.Title, copied.Category, copied.ParentID, copied.SystemPrompt, params, copied.CreatedAt, copied.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+
+	copied.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation ID: %w", err)
+	}
+
+	for _, msg := range messages {
+		_, err := tx.Exec(
+			"INSERT INTO messages (conversation_id, role, content, original_content, provider, model, attachments, tokens_used, created_at, metadata) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			copied.ID, msg.Role, msg.Content, msg.OriginalContent, msg.Provider, msg.Model, msg.Attachments, msg.TokensUsed, msg.CreatedAt, msg.Metadata,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy message: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return copied, nil
+}
+
+// MergeConversations appends the messages of the source conversation to the
+// target and deletes the source. Messages are ordered by creation time, so
+// the moved ones are shifted to start a second after the last message of the
+// target if they are older; their spacing is kept. Nothing is changed if any
+// step fails.
+func (db *DB) MergeConversations(targetID, sourceID int64) error {
+	if targetID == sourceID {
+		return fmt.Errorf("cannot merge a conversation into itself")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	for _, id := range []int64{targetID, sourceID} {
+		err := tx.QueryRow("SELECT 1 FROM conversations WHERE id = ?", id).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("conversation not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get conversation: %w", err)
+		}
+	}
+
+	// MAX() would lose the column type and return the time as text.
+	var lastTarget time.Time
+	err = tx.QueryRow("SELECT created_at FROM messages WHERE conversation_id = ? ORDER BY created_at DESC LIMIT 1", targetID).Scan(&lastTarget)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get last message: %w", err)
+	}
+
+	rows, err := tx.Query("SELECT id, created_at FROM messages WHERE conversation_id = ? ORDER BY created_at ASC", sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+	var ids []int64
+	var times []time.Time
+	for rows.Next() {
+		var id int64
+		var createdAt time.Time
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan message: %w", err)
+		}
+		ids = append(ids, id)
+		times = append(times, createdAt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	var shift time.Duration
+	if len(times) > 0 && !lastTarget.IsZero() && !times[0].After(lastTarget) {
+		shift = lastTarget.Sub(times[0]) + time.Second
+	}
+	// The search index needs no update, see MergeDuplicateConversations
+	for i, id := range ids {
+		if _, err := tx.Exec("UPDATE messages SET conversation_id = ?, created_at = ? WHERE id = ?", targetID, times[i].Add(shift), id); err != nil {
+			return fmt.Errorf("failed to move message: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM conversations WHERE id = ?", sourceID); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE conversations SET updated_at = ? WHERE id = ?", time.Now(), targetID); err != nil {
+		return fmt.Errorf("failed to touch conversation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SplitConversation moves the messages from the user message splitAtMessageID
+// onwards into a new conversation, which gets the title of the source with
+// " (拆分)" appended and its category, system prompt and parameters. The
+// moved messages keep their IDs, so their tags and versions go with them.
+// Splitting at the first message is refused, it would leave the source
+// empty. Nothing is changed if any step fails.
+func (db *DB) SplitConversation(conversationID, splitAtMessageID int64) (*Conversation, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source Conversation
+	var params string
+	err = tx.QueryRow("SELECT title, category, COALESCE(system_prompt, ''), COALESCE(params_override, '') FROM conversations WHERE id = ?", conversationID).Scan(&source.Title, &source.Category, &source.SystemPrompt, &params)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("conversation not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	// Same order as ListMessages
+	rows, err := tx.Query("SELECT id, role FROM messages WHERE conversation_id = ? ORDER BY created_at ASC", conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	var ids []int64
+	split := -1
+	for rows.Next() {
+		var id int64
+		var role string
+		if err := rows.Scan(&id, &role); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if id == splitAtMessageID {
+			if role != "user" {
+				rows.Close()
+				return nil, fmt.Errorf("can only split at a user message")
+			}
+			split = len(ids)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	if split < 0 {
+		return nil, fmt.Errorf("message %d not found in conversation", splitAtMessageID)
+	}
+	if split == 0 {
+		return nil, fmt.Errorf("cannot split at the first message")
+	}
+
+	now := time.Now()
+	created := &Conversation{
+		Title:        source.Title + " (拆分)",
+		Category:     source.Category,
+		SystemPrompt: source.SystemPrompt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if created.ParamsOverride, err = decodeConversationParams(params); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO conversations (title, category, system_prompt, params_override, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		created.Title, created.Category, created.SystemPrompt, params, created.CreatedAt, created.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+	created.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation ID: %w", err)
+	}
+
+	for _, id := range ids[split:] {
+		if _, err := tx.Exec("UPDATE messages SET conversation_id = ? WHERE id = ?", created.ID, id); err != nil {
+			return nil, fmt.Errorf("failed to move message: %w", err)
+		}
+	}
+	if _, err := tx.Exec("UPDATE conversations SET updated_at = ? WHERE id = ?", now, conversationID); err != nil {
+		return nil, fmt.Errorf("failed to touch conversation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return created, nil
+}
+
 // UpdateConversation updates a conversation's title and/or category
 func (db *DB) UpdateConversation(id int64, title, category string) error {
 	_, err := db.conn.Exec(
@@ -83,70 +387,87 @@ func (db *DB) UpdateConversation(id int64, title, category string) error {
 	return nil
 }
 
-// DeleteConversation deletes a conversation and all its messages
+// UpdateConversationSystemPrompt sets the system prompt of a conversation,
+// "" to use the provider's default
+func (db *DB) UpdateConversationSystemPrompt(id int64, systemPrompt string) error {
+	_, err := db.conn.Exec(
+		"UPDATE conversations SET system_prompt = ? WHERE id = ?",
+		systemPrompt, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update system prompt: %w", err)
+	}
+	return nil
+}
+
+// SetConversationPinned pins a conversation to the top of the list, or unpins it
+func (db *DB) SetConversationPinned(id int64, pinned bool) error {
+	_, err := db.conn.Exec(
+		"UPDATE conversations SET pinned = ? WHERE id = ?",
+		pinned, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update pinned state: %w", err)
+	}
+	return nil
+}
+
+// DeleteConversation moves a conversation to the recycle bin. It keeps its
+// messages until it is deleted permanently, see recycle_bin.go.
 func (db *DB) DeleteConversation(id int64) error {
-	_, err := db.conn.Exec("DELETE FROM conversations WHERE id = ?", id)
+	_, err := db.conn.Exec("UPDATE conversations SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to delete conversation: %w", err)
 	}
 	return nil
 }
 
-// CountConversations returns the total number of conversations
+// CountConversations returns the number of conversations not in the recycle bin
 func (db *DB) CountConversations() (int64, error) {
 	var count int64
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM conversations").Scan(&count)
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM conversations WHERE deleted_at IS NULL").Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count conversations: %w", err)
 	}
 	return count, nil
 }
 
-// DeleteOldConversations deletes conversations older than the specified number of days
+// DeleteOldConversations permanently deletes the conversations not updated
+// in the specified number of days, with their messages. The recycle bin is
+// left to PurgeDeletedConversations.
 func (db *DB) DeleteOldConversations(daysOld int) (int64, error) {
 	cutoffTime := time.Now().AddDate(0, 0, -daysOld)
-	result, err := db.conn.Exec(
-		"DELETE FROM conversations WHERE updated_at < ?",
-		cutoffTime,
-	)
+	count, err := db.deleteConversations("deleted_at IS NULL AND updated_at < ?", cutoffTime)
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete old conversations: %w", err)
 	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get rows affected: %w", err)
-	}
-	
-	return rowsAffected, nil
+	return count, nil
 }
 
-// DeleteOldestConversations deletes the oldest conversations, keeping only the specified number
+// DeleteOldestConversations permanently deletes the oldest conversations with
+// their messages, keeping only the specified number. Conversations in the
+// recycle bin neither count toward the kept ones nor are deleted.
 func (db *DB) DeleteOldestConversations(keepCount int) (int64, error) {
-	result, err := db.conn.Exec(`
-		DELETE FROM conversations 
-		WHERE id NOT IN (
-			SELECT id FROM conversations 
-			ORDER BY updated_at DESC 
+	count, err := db.deleteConversations(`deleted_at IS NULL AND id NOT IN (
+			SELECT id FROM conversations
+			WHERE deleted_at IS NULL
+			ORDER BY updated_at DESC
 			LIMIT ?
-		)
-	`, keepCount)
-	
+		)`, keepCount)
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete oldest conversations: %w", err)
 	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get rows affected: %w", err)
-	}
-	
-	return rowsAffected, nil
+	return count, nil
 }
 
 // TouchConversation updates the conversation's updated_at timestamp
 func (db *DB) TouchConversation(id int64) error {
-	_, err := db.conn.Exec(
+	return db.TouchConversationCtx(context.Background(), id)
+}
+
+// TouchConversationCtx updates the conversation's updated_at timestamp, aborting if ctx is cancelled
+func (db *DB) TouchConversationCtx(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx,
 		"UPDATE conversations SET updated_at = ? WHERE id = ?",
 		time.Now(), id,
 	)
@@ -159,7 +480,7 @@ func (db *DB) TouchConversation(id int64) error {
 // GetCategories retrieves all unique categories from conversations
 func (db *DB) GetCategories() ([]string, error) {
 	rows, err := db.conn.Query(
-		"SELECT DISTINCT category FROM conversations WHERE category != '' ORDER BY category",
+		"SELECT DISTINCT category FROM conversations WHERE category != '' AND deleted_at IS NULL ORDER BY category",
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get categories: %w", err)
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// ConversationPair is two conversations that look like copies of each other
+type ConversationPair struct {
+	A, B            *Conversation
+	SimilarityScore float64 // Share of messages the two have in common, 0-1
+}
+
+// GetDuplicateConversations finds conversations with the same title whose
+// first user messages are identical, as left by importing an export twice.
+// Each duplicate is paired with the oldest conversation of its group.
+func (db *DB) GetDuplicateConversations() ([]*ConversationPair, error) {
+	rows, err := db.conn.Query(`
+		SELECT c.id, c.title, c.category, COALESCE(c.parent_id, 0), c.created_at, c.updated_at,
+			(SELECT content FROM messages WHERE conversation_id = c.id AND role = 'user' ORDER BY created_at ASC, id ASC LIMIT 1)
+		FROM conversations c
+		WHERE c.deleted_at IS NULL
+		ORDER BY c.created_at ASC, c.id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	groups := make(map[string][]*Conversation)
+	var keys []string
+	for rows.Next() {
+		var conv Conversation
+		var firstMessage sql.NullString
+		if err := rows.Scan(&conv.ID, &conv.Title, &conv.Category, &conv.ParentID, &conv.CreatedAt, &conv.UpdatedAt, &firstMessage); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		if !firstMessage.Valid {
+			// Nothing to compare in a conversation without user messages
+			continue
+		}
+
+		hash := sha256.Sum256([]byte(firstMessage.String))
+		key := conv.Title + "\x00" + hex.EncodeToString(hash[:])
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], &conv)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	var pairs []*ConversationPair
+	for _, key := range keys {
+		group := groups[key]
+		for _, dup := range group[1:] {
+			score, err := db.conversationSimilarity(group[0].ID, dup.ID)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, &ConversationPair{A: group[0], B: dup, SimilarityScore: score})
+		}
+	}
+	return pairs, nil
+}
+
+// conversationSimilarity returns the share of messages, by role and
+// content, that two conversations have in common
+func (db *DB) conversationSimilarity(aID, bID int64) (float64, error) {
+	a, err := db.ListMessages(aID)
+	if err != nil {
+		return 0, err
+	}
+	b, err := db.ListMessages(bID)
+	if err != nil {
+		return 0, err
+	}
+	if len(a) == 0 && len(b) == 0 {
+		return 1, nil
+	}
+
+	counts := make(map[string]int, len(a))
+	for _, msg := range a {
+		counts[msg.Role+"\x00"+msg.Content]++
+	}
+	shared := 0
+	for _, msg := range b {
+		key := msg.Role + "\x00" + msg.Content
+		if counts[key] > 0 {
+			counts[key]--
+			shared++
+		}
+	}
+	return float64(shared) / float64(max(len(a), len(b))), nil
+}
+
+// MergeDuplicateConversations moves the messages of mergeID that keepID does not
+// already have into keepID and deletes mergeID with its remaining messages
+func (db *DB) MergeDuplicateConversations(keepID, mergeID int64) error {
+	if keepID == mergeID {
+		return fmt.Errorf("cannot merge a conversation into itself")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	for _, id := range []int64{keepID, mergeID} {
+		err := tx.QueryRow("SELECT 1 FROM conversations WHERE id = ?", id).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("conversation not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get conversation: %w", err)
+		}
+	}
+
+	counts, err := messageFingerprints(tx, keepID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT id, role, content FROM messages WHERE conversation_id = ?", mergeID)
+	if err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+	var moved []int64
+	for rows.Next() {
+		var id int64
+		var role, content string
+		if err := rows.Scan(&id, &role, &content); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan message: %w", err)
+		}
+		key := role + "\x00" + content
+		if counts[key] > 0 {
+			counts[key]--
+			continue
+		}
+		moved = append(moved, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	// Messages keep their timestamps, so they sort into the conversation.
+	// Search reads conversation_id from messages, the FTS index needs no update.
+	for _, id := range moved {
+		if _, err := tx.Exec("UPDATE messages SET conversation_id = ? WHERE id = ?", keepID, id); err != nil {
+			return fmt.Errorf("failed to move message: %w", err)
+		}
+	}
+
+	// The duplicates left behind go with the conversation; the triggers drop
+	// their search entries, tags and versions
+	if _, err := tx.Exec("DELETE FROM messages WHERE conversation_id = ?", mergeID); err != nil {
+		return fmt.Errorf("failed to delete duplicate messages: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM conversations WHERE id = ?", mergeID); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE conversations SET updated_at = ? WHERE id = ?", time.Now(), keepID); err != nil {
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// messageFingerprints counts the messages of a conversation by role and content
+func messageFingerprints(tx *sql.Tx, conversationID int64) (map[string]int, error) {
+	rows, err := tx.Query("SELECT role, content FROM messages WHERE conversation_id = ?", conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var role, content string
+		if err := rows.Scan(&role, &content); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		counts[role+"\x00"+content]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	return counts, nil
+}
@@ -1,13 +1,20 @@
 package db
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"time"
 )
 
 // CreateMessage creates a new message in a conversation
 func (db *DB) CreateMessage(conversationID int64, role, content, provider, model, attachments string, tokensUsed int) (*Message, error) {
-	result, err := db.conn.Exec(
+	return db.CreateMessageCtx(context.Background(), conversationID, role, content, provider, model, attachments, tokensUsed)
+}
+
+// CreateMessageCtx creates a new message in a conversation, aborting if ctx is cancelled
+func (db *DB) CreateMessageCtx(ctx context.Context, conversationID int64, role, content, provider, model, attachments string, tokensUsed int) (*Message, error) {
+	result, err := db.conn.ExecContext(ctx,
 		"INSERT INTO messages (conversation_id, role, content, original_content, provider, model, attachments, tokens_used, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		conversationID, role, content, "", provider, model, attachments, tokensUsed, time.Now(),
 	)
@@ -21,7 +28,7 @@ func (db *DB) CreateMessage(conversationID int64, role, content, provider, model
 	}
 
 	// Update conversation's updated_at timestamp
-	if err := db.TouchConversation(conversationID); err != nil {
+	if err := db.TouchConversationCtx(ctx, conversationID); err != nil {
 		return nil, err
 	}
 
@@ -41,11 +48,17 @@ func (db *DB) CreateMessage(conversationID int64, role, content, provider, model
 
 // GetMessage retrieves a message by ID
 func (db *DB) GetMessage(id int64) (*Message, error) {
+	return db.GetMessageByID(id)
+}
+
+// GetMessageByID retrieves the full row of a message, including the
+// original content of anonymized messages
+func (db *DB) GetMessageByID(id int64) (*Message, error) {
 	var msg Message
 	err := db.conn.QueryRow(
-		"SELECT id, conversation_id, role, content, provider, model, attachments, tokens_used, created_at FROM messages WHERE id = ?",
+		"SELECT id, conversation_id, role, content, original_content, provider, model, attachments, tokens_used, created_at, metadata, COALESCE(starred, 0) FROM messages WHERE id = ?",
 		id,
-	).Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt)
+	).Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.OriginalContent, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt, &msg.Metadata, &msg.Starred)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get message: %w", err)
@@ -56,27 +69,73 @@ func (db *DB) GetMessage(id int64) (*Message, error) {
 
 // ListMessages retrieves all messages in a conversation
 func (db *DB) ListMessages(conversationID int64) ([]*Message, error) {
-	rows, err := db.conn.Query(
-		"SELECT id, conversation_id, role, content, original_content, provider, model, attachments, tokens_used, created_at FROM messages WHERE conversation_id = ? ORDER BY created_at ASC",
+	return db.ListMessagesCtx(context.Background(), conversationID)
+}
+
+// ListMessagesCtx retrieves all messages in a conversation, aborting if ctx is cancelled
+func (db *DB) ListMessagesCtx(ctx context.Context, conversationID int64) ([]*Message, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		"SELECT id, conversation_id, role, content, original_content, provider, model, attachments, tokens_used, created_at, metadata, COALESCE(starred, 0) FROM messages WHERE conversation_id = ? ORDER BY created_at ASC",
 		conversationID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list messages: %w", err)
 	}
 	defer rows.Close()
+	return scanMessages(rows)
+}
 
+// scanMessages reads the rows of a query selecting the columns of
+// ListMessagesCtx
+func scanMessages(rows *sql.Rows) ([]*Message, error) {
 	var messages []*Message
 	for rows.Next() {
 		var msg Message
-		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.OriginalContent, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.OriginalContent, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt, &msg.Metadata, &msg.Starred); err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 		messages = append(messages, &msg)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
 
 	return messages, nil
 }
 
+// ToggleMessageStar stars a message, or unstars a starred one
+func (db *DB) ToggleMessageStar(messageID int64) error {
+	result, err := db.conn.Exec(
+		"UPDATE messages SET starred = NOT COALESCE(starred, 0) WHERE id = ?",
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to toggle message star: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("message %d not found", messageID)
+	}
+	return nil
+}
+
+// ListStarredMessages retrieves the starred messages of a conversation, or
+// of all conversations outside the recycle bin if conversationID is 0
+func (db *DB) ListStarredMessages(conversationID int64) ([]*Message, error) {
+	rows, err := db.conn.Query(`
+		SELECT m.id, m.conversation_id, m.role, m.content, m.original_content, m.provider, m.model, m.attachments, m.tokens_used, m.created_at, m.metadata, COALESCE(m.starred, 0)
+		FROM messages m
+		JOIN conversations c ON m.conversation_id = c.id
+		WHERE m.starred AND (? = 0 OR m.conversation_id = ?) AND c.deleted_at IS NULL
+		ORDER BY m.created_at ASC, m.id ASC`,
+		conversationID, conversationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list starred messages: %w", err)
+	}
+	defer rows.Close()
+	return scanMessages(rows)
+}
+
 // UpdateMessageOriginalContent updates the original content of a message
 func (db *DB) UpdateMessageOriginalContent(messageID int64, originalContent string) error {
 	_, err := db.conn.Exec(
@@ -89,15 +148,118 @@ func (db *DB) UpdateMessageOriginalContent(messageID int64, originalContent stri
 	return nil
 }
 
-// UpdateMessage updates a message's content
-func (db *DB) UpdateMessage(id int64, content string) error {
+// UpdateMessageMetadata sets the JSON metadata of a message
+func (db *DB) UpdateMessageMetadata(messageID int64, metadata string) error {
 	_, err := db.conn.Exec(
-		"UPDATE messages SET content = ? WHERE id = ?",
-		content, id,
+		"UPDATE messages SET metadata = ? WHERE id = ?",
+		metadata, messageID,
 	)
 	if err != nil {
+		return fmt.Errorf("failed to update message metadata: %w", err)
+	}
+	return nil
+}
+
+// UpdateMessage updates a message's content. The previous content is kept in
+// message_versions so the edit can be compared and undone.
+func (db *DB) UpdateMessage(id int64, content string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previous string
+	if err := tx.QueryRow("SELECT content FROM messages WHERE id = ?", id).Scan(&previous); err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+	if previous == content {
+		return nil
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO message_versions (message_id, content, created_at) VALUES (?, ?, ?)",
+		id, previous, time.Now(),
+	); err != nil {
+		return fmt.Errorf("failed to archive message version: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE messages SET content = ? WHERE id = ?", content, id); err != nil {
 		return fmt.Errorf("failed to update message: %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListMessageVersions retrieves the previous contents of a message, newest first
+func (db *DB) ListMessageVersions(messageID int64) ([]*MessageVersion, error) {
+	rows, err := db.conn.Query(
+		"SELECT id, message_id, content, created_at FROM message_versions WHERE message_id = ? ORDER BY created_at DESC, id DESC",
+		messageID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*MessageVersion
+	for rows.Next() {
+		var version MessageVersion
+		if err := rows.Scan(&version.ID, &version.MessageID, &version.Content, &version.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message version: %w", err)
+		}
+		versions = append(versions, &version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list message versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// ListConversationVersions retrieves the previous contents of every edited
+// message in a conversation, newest first, keyed by message ID
+func (db *DB) ListConversationVersions(conversationID int64) (map[int64][]*MessageVersion, error) {
+	rows, err := db.conn.Query(`
+		SELECT v.id, v.message_id, v.content, v.created_at
+		FROM message_versions v
+		JOIN messages m ON m.id = v.message_id
+		WHERE m.conversation_id = ?
+		ORDER BY v.created_at DESC, v.id DESC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[int64][]*MessageVersion)
+	for rows.Next() {
+		var version MessageVersion
+		if err := rows.Scan(&version.ID, &version.MessageID, &version.Content, &version.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message version: %w", err)
+		}
+		versions[version.MessageID] = append(versions[version.MessageID], &version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list conversation versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// UpdateMessageConversation moves a message to another conversation. Its tags
+// and versions stay with it.
+func (db *DB) UpdateMessageConversation(messageID, newConversationID int64) error {
+	result, err := db.conn.Exec("UPDATE messages SET conversation_id = ? WHERE id = ?", newConversationID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to move message: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("message %d not found", messageID)
+	}
 	return nil
 }
 
@@ -109,3 +271,186 @@ func (db *DB) DeleteMessage(id int64) error {
 	}
 	return nil
 }
+
+// DeleteMessagesBefore deletes the messages of a conversation that come before
+// the one at messageIndex, in the order of ListMessages, and returns how many
+// were deleted. The message at messageIndex and the following ones are kept.
+func (db *DB) DeleteMessagesBefore(conversationID int64, messageIndex int) (int64, error) {
+	if messageIndex < 0 {
+		return 0, fmt.Errorf("invalid message index %d", messageIndex)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		"SELECT id FROM messages WHERE conversation_id = ? ORDER BY created_at ASC LIMIT ?",
+		conversationID, messageIndex+1,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list messages: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan message: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list messages: %w", err)
+	}
+	if messageIndex >= len(ids) {
+		return 0, fmt.Errorf("message index %d out of range (conversation has %d messages)", messageIndex, len(ids))
+	}
+
+	var deleted int64
+	for _, id := range ids[:messageIndex] {
+		result, err := tx.Exec("DELETE FROM messages WHERE id = ?", id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete message: %w", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get deleted rows: %w", err)
+		}
+		deleted += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return deleted, nil
+}
+
+// ReplaceMessagesWithSummary replaces the given messages with a single system
+// message holding the summary. The summary takes the creation time of the
+// earliest replaced message so it keeps their position in the conversation.
+func (db *DB) ReplaceMessagesWithSummary(conversationID int64, messageIDs []int64, summary string) (*Message, error) {
+	if len(messageIDs) == 0 {
+		return nil, fmt.Errorf("no messages to replace")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var createdAt time.Time
+	if err := tx.QueryRow("SELECT created_at FROM messages WHERE id = ?", messageIDs[0]).Scan(&createdAt); err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	for _, id := range messageIDs {
+		if _, err := tx.Exec("DELETE FROM messages WHERE id = ? AND conversation_id = ?", id, conversationID); err != nil {
+			return nil, fmt.Errorf("failed to delete message: %w", err)
+		}
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO messages (conversation_id, role, content, original_content, provider, model, attachments, tokens_used, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		conversationID, "system", summary, "", "", "", "", 0, createdAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create summary message: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message ID: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &Message{
+		ID:             id,
+		ConversationID: conversationID,
+		Role:           "system",
+		Content:        summary,
+		CreatedAt:      createdAt,
+	}, nil
+}
+
+// MessageSnapshot is a message with its tags and previous versions, which
+// deleting the message drops, taken to restore it later
+type MessageSnapshot struct {
+	Message  *Message
+	Tags     []string
+	Versions []*MessageVersion
+}
+
+// GetMessageSnapshot retrieves a message with its tags and previous versions
+func (db *DB) GetMessageSnapshot(messageID int64) (*MessageSnapshot, error) {
+	msg, err := db.GetMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := db.ListMessageTags(messageID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := db.ListMessageVersions(messageID)
+	if err != nil {
+		return nil, err
+	}
+	return &MessageSnapshot{Message: msg, Tags: tags, Versions: versions}, nil
+}
+
+// RestoreMessages adds deleted messages back to a conversation, keeping their
+// content, metadata, star, tags, versions and creation time so they return
+// to their old position.
+// It returns the new IDs in the order of snapshots.
+func (db *DB) RestoreMessages(conversationID int64, snapshots []*MessageSnapshot) ([]int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	ids := make([]int64, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		msg := snapshot.Message
+		result, err := tx.Exec(
+			"INSERT INTO messages (conversation_id, role, content, original_content, provider, model, attachments, tokens_used, created_at, metadata, starred) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			conversationID, msg.Role, msg.Content, msg.OriginalContent, msg.Provider, msg.Model, msg.Attachments, msg.TokensUsed, msg.CreatedAt, msg.Metadata, msg.Starred,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore message: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message ID: %w", err)
+		}
+		for _, tag := range snapshot.Tags {
+			if _, err := tx.Exec("INSERT OR IGNORE INTO message_tags (message_id, tag, created_at) VALUES (?, ?, ?)", id, tag, now); err != nil {
+				return nil, fmt.Errorf("failed to restore message tag: %w", err)
+			}
+		}
+		// Oldest first, so the IDs keep the order of the edits
+		for i := len(snapshot.Versions) - 1; i >= 0; i-- {
+			version := snapshot.Versions[i]
+			if _, err := tx.Exec("INSERT INTO message_versions (message_id, content, created_at) VALUES (?, ?, ?)", id, version.Content, version.CreatedAt); err != nil {
+				return nil, fmt.Errorf("failed to restore message version: %w", err)
+			}
+		}
+		ids = append(ids, id)
+	}
+
+	if _, err := tx.Exec("UPDATE conversations SET updated_at = ? WHERE id = ?", now, conversationID); err != nil {
+		return nil, fmt.Errorf("failed to update conversation: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
+}
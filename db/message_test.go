@@ -0,0 +1,770 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	database, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestListMessagesCtx_Cancelled(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("test", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		if _, err := database.CreateMessage(conv.ID, "user", "hello", "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		// Keep querying until the cancellation is observed
+		for {
+			if _, err := database.ListMessagesCtx(ctx, conv.ID); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ListMessagesCtx did not return promptly after cancellation")
+	}
+}
+
+func TestCreateMessageCtx_Cancelled(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("test", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := database.CreateMessageCtx(ctx, conv.ID, "user", "hello", "", "", "", 0); err == nil {
+		t.Error("Expected error when creating a message with a cancelled context")
+	}
+
+	messages, err := database.ListMessages(conv.ID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("Expected no messages, got %d", len(messages))
+	}
+}
+
+func TestGetMessageByID(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("test", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	created, err := database.CreateMessage(conv.ID, "user", "call [NAME_1]", "mock", "mock-1", "", 7)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	if err := database.UpdateMessageOriginalContent(created.ID, "call Alice"); err != nil {
+		t.Fatalf("UpdateMessageOriginalContent failed: %v", err)
+	}
+
+	msg, err := database.GetMessageByID(created.ID)
+	if err != nil {
+		t.Fatalf("GetMessageByID failed: %v", err)
+	}
+	if msg.ConversationID != conv.ID || msg.Content != "call [NAME_1]" || msg.OriginalContent != "call Alice" ||
+		msg.Model != "mock-1" || msg.TokensUsed != 7 {
+		t.Errorf("unexpected message %+v", msg)
+	}
+
+	if _, err := database.GetMessageByID(created.ID + 1); err == nil {
+		t.Error("expected an error for a missing message")
+	}
+}
+
+func TestReplaceMessagesWithSummary(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("test", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	var ids []int64
+	for _, content := range []string{"one", "two", "three", "four"} {
+		msg, err := database.CreateMessage(conv.ID, "user", content, "", "", "", 0)
+		if err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	if _, err := database.ReplaceMessagesWithSummary(conv.ID, ids[:3], "- summary"); err != nil {
+		t.Fatalf("ReplaceMessagesWithSummary failed: %v", err)
+	}
+
+	messages, err := database.ListMessages(conv.ID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Role != "system" || messages[0].Content != "- summary" {
+		t.Errorf("Expected summary first, got %s: %q", messages[0].Role, messages[0].Content)
+	}
+	if messages[1].Content != "four" {
+		t.Errorf("Expected latest message to be kept, got %q", messages[1].Content)
+	}
+}
+
+func TestUpdateMessage_ArchivesPreviousContent(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("test", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	msg, err := database.CreateMessage(conv.ID, "user", "v1", "", "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	for _, content := range []string{"v2", "v2", "v3"} {
+		if err := database.UpdateMessage(msg.ID, content); err != nil {
+			t.Fatalf("UpdateMessage failed: %v", err)
+		}
+	}
+
+	versions, err := database.ListMessageVersions(msg.ID)
+	if err != nil {
+		t.Fatalf("ListMessageVersions failed: %v", err)
+	}
+	// Saving unchanged content must not add a version
+	if len(versions) != 2 || versions[0].Content != "v2" || versions[1].Content != "v1" {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+
+	// The whole conversation is loaded in one go, keyed by message
+	unedited, err := database.CreateMessage(conv.ID, "assistant", "reply", "", "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	byMessage, err := database.ListConversationVersions(conv.ID)
+	if err != nil {
+		t.Fatalf("ListConversationVersions failed: %v", err)
+	}
+	if len(byMessage) != 1 || len(byMessage[unedited.ID]) != 0 || !reflect.DeepEqual(byMessage[msg.ID], versions) {
+		t.Fatalf("unexpected conversation versions: %+v", byMessage)
+	}
+
+	if err := database.DeleteMessage(msg.ID); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+	versions, err = database.ListMessageVersions(msg.ID)
+	if err != nil {
+		t.Fatalf("ListMessageVersions failed: %v", err)
+	}
+	if len(versions) != 0 {
+		t.Fatalf("versions of deleted message remain: %+v", versions)
+	}
+}
+
+func TestUpdateConversationSystemPrompt(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("test", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for _, prompt := range []string{"Talk like a pirate.", ""} {
+		if err := database.UpdateConversationSystemPrompt(conv.ID, prompt); err != nil {
+			t.Fatalf("UpdateConversationSystemPrompt failed: %v", err)
+		}
+		stored, err := database.GetConversation(conv.ID)
+		if err != nil {
+			t.Fatalf("GetConversation failed: %v", err)
+		}
+		if stored.SystemPrompt != prompt || stored.Title != "test" {
+			t.Errorf("stored conversation = %+v, want system prompt %q", stored, prompt)
+		}
+	}
+}
+
+func TestSetConversationPinned(t *testing.T) {
+	database := newTestDB(t)
+
+	var ids []int64
+	for _, title := range []string{"oldest", "middle", "newest"} {
+		conv, err := database.CreateConversation(title, "")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		ids = append(ids, conv.ID)
+		time.Sleep(10 * time.Millisecond) // Distinct update times
+	}
+	titles := func() []string {
+		conversations, err := database.ListConversations(10, 0)
+		if err != nil {
+			t.Fatalf("ListConversations failed: %v", err)
+		}
+		var titles []string
+		for _, conv := range conversations {
+			titles = append(titles, conv.Title)
+		}
+		return titles
+	}
+
+	if err := database.SetConversationPinned(ids[0], true); err != nil {
+		t.Fatalf("SetConversationPinned failed: %v", err)
+	}
+	if got := titles(); len(got) != 3 || got[0] != "oldest" || got[1] != "newest" || got[2] != "middle" {
+		t.Errorf("order with the oldest pinned = %v", got)
+	}
+	if stored, err := database.GetConversation(ids[0]); err != nil || !stored.Pinned {
+		t.Errorf("GetConversation = %+v, %v; want pinned", stored, err)
+	}
+
+	if err := database.SetConversationPinned(ids[0], false); err != nil {
+		t.Fatalf("SetConversationPinned failed: %v", err)
+	}
+	if got := titles(); got[0] != "newest" || got[2] != "oldest" {
+		t.Errorf("order after unpinning = %v", got)
+	}
+}
+
+func TestToggleMessageStar(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("stars", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	other, err := database.CreateConversation("other", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	var ids []int64
+	for _, c := range []*Conversation{conv, conv, other} {
+		msg, err := database.CreateMessage(c.ID, "user", "hello", "", "", "", 0)
+		if err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	for _, id := range []int64{ids[1], ids[2]} {
+		if err := database.ToggleMessageStar(id); err != nil {
+			t.Fatalf("ToggleMessageStar failed: %v", err)
+		}
+	}
+	starred, err := database.ListStarredMessages(conv.ID)
+	if err != nil {
+		t.Fatalf("ListStarredMessages failed: %v", err)
+	}
+	if len(starred) != 1 || starred[0].ID != ids[1] || !starred[0].Starred {
+		t.Errorf("ListStarredMessages = %+v, want message %d", starred, ids[1])
+	}
+	if all, err := database.ListStarredMessages(0); err != nil || len(all) != 2 {
+		t.Errorf("ListStarredMessages(0) = %d messages, %v; want 2", len(all), err)
+	}
+	if msg, err := database.GetMessageByID(ids[1]); err != nil || !msg.Starred {
+		t.Errorf("GetMessageByID = %+v, %v; want starred", msg, err)
+	}
+
+	// Toggling again unstars it
+	if err := database.ToggleMessageStar(ids[1]); err != nil {
+		t.Fatalf("ToggleMessageStar failed: %v", err)
+	}
+	if starred, err := database.ListStarredMessages(conv.ID); err != nil || len(starred) != 0 {
+		t.Errorf("ListStarredMessages after unstarring = %+v, %v", starred, err)
+	}
+	if err := database.ToggleMessageStar(9999); err == nil {
+		t.Error("expected an error for an unknown message")
+	}
+}
+
+func TestForkConversation(t *testing.T) {
+	database := newTestDB(t)
+
+	source, err := database.CreateConversation("source", "work")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if err := database.UpdateConversationSystemPrompt(source.ID, "Be brief."); err != nil {
+		t.Fatalf("UpdateConversationSystemPrompt failed: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	for i, content := range []string{"one", "two", "three", "four"} {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		msg, err := database.CreateMessage(source.ID, role, content, "openai", "gpt-4o", `[{"type":"image"}]`, 10+i)
+		if err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		// Distinct timestamps keep the order deterministic
+		if _, err := database.conn.Exec("UPDATE messages SET created_at = ? WHERE id = ?", base.Add(time.Duration(i)*time.Minute), msg.ID); err != nil {
+			t.Fatalf("failed to set created_at: %v", err)
+		}
+	}
+
+	fork, err := database.ForkConversation(source.ID, 2)
+	if err != nil {
+		t.Fatalf("ForkConversation failed: %v", err)
+	}
+	if fork.ParentID != source.ID || fork.Category != "work" {
+		t.Errorf("unexpected fork: %+v", fork)
+	}
+
+	stored, err := database.GetConversation(fork.ID)
+	if err != nil {
+		t.Fatalf("GetConversation failed: %v", err)
+	}
+	if stored.ParentID != source.ID {
+		t.Errorf("stored ParentID = %d, want %d", stored.ParentID, source.ID)
+	}
+	if stored.SystemPrompt != "Be brief." {
+		t.Errorf("stored SystemPrompt = %q, want the source's", stored.SystemPrompt)
+	}
+
+	messages, err := database.ListMessages(fork.ID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 copied messages, got %d", len(messages))
+	}
+	for i, want := range []string{"one", "two", "three"} {
+		msg := messages[i]
+		if msg.Content != want || msg.Attachments != `[{"type":"image"}]` || msg.TokensUsed != 10+i {
+			t.Errorf("message %d = %+v", i, msg)
+		}
+	}
+	if messages[1].Role != "assistant" {
+		t.Errorf("Expected role to be preserved, got %s", messages[1].Role)
+	}
+
+	// The source is left untouched
+	sourceMessages, err := database.ListMessages(source.ID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(sourceMessages) != 4 {
+		t.Errorf("Expected source to keep 4 messages, got %d", len(sourceMessages))
+	}
+}
+
+func TestDuplicateConversation(t *testing.T) {
+	database := newTestDB(t)
+
+	source, err := database.CreateConversation("Recipe", "cooking")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if err := database.UpdateConversationSystemPrompt(source.ID, "Be brief."); err != nil {
+		t.Fatalf("UpdateConversationSystemPrompt failed: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	for i, content := range []string{"one", "two", "three"} {
+		msg, err := database.CreateMessage(source.ID, "user", content, "", "", "", 0)
+		if err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		if _, err := database.conn.Exec("UPDATE messages SET created_at = ? WHERE id = ?", base.Add(time.Duration(i)*time.Minute), msg.ID); err != nil {
+			t.Fatalf("failed to set created_at: %v", err)
+		}
+	}
+
+	duplicate, err := database.DuplicateConversation(source.ID)
+	if err != nil {
+		t.Fatalf("DuplicateConversation failed: %v", err)
+	}
+	stored, err := database.GetConversation(duplicate.ID)
+	if err != nil {
+		t.Fatalf("GetConversation failed: %v", err)
+	}
+	if stored.Title != "Copy of Recipe" || stored.Category != "cooking" || stored.SystemPrompt != "Be brief." || stored.ParentID != 0 {
+		t.Errorf("unexpected duplicate %+v", stored)
+	}
+
+	messages, err := database.ListMessages(duplicate.ID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 3 || messages[0].Content != "one" || messages[2].Content != "three" {
+		t.Errorf("unexpected copied messages %+v", messages)
+	}
+
+	if _, err := database.DuplicateConversation(duplicate.ID + 1); err == nil {
+		t.Error("expected an error for a missing conversation")
+	}
+}
+
+func TestForkConversation_InvalidIndex(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("source", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if _, err := database.CreateMessage(conv.ID, "user", "hello", "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	before, err := database.CountConversations()
+	if err != nil {
+		t.Fatalf("CountConversations failed: %v", err)
+	}
+	for _, index := range []int{-1, 1} {
+		if _, err := database.ForkConversation(conv.ID, index); err == nil {
+			t.Errorf("Expected error for index %d", index)
+		}
+	}
+	if _, err := database.ForkConversation(conv.ID+100, 0); err == nil {
+		t.Error("Expected error for missing conversation")
+	}
+
+	// Failed forks leave no conversation behind
+	after, err := database.CountConversations()
+	if err != nil {
+		t.Fatalf("CountConversations failed: %v", err)
+	}
+	if after != before {
+		t.Errorf("Expected %d conversations, got %d", before, after)
+	}
+}
+
+func TestGetDuplicateConversations(t *testing.T) {
+	database := newTestDB(t)
+
+	create := func(title string, messages ...string) *Conversation {
+		t.Helper()
+		conv, err := database.CreateConversation(title, "")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		for i, content := range messages {
+			role := "user"
+			if i%2 == 1 {
+				role = "assistant"
+			}
+			if _, err := database.CreateMessage(conv.ID, role, content, "", "", "", 0); err != nil {
+				t.Fatalf("CreateMessage failed: %v", err)
+			}
+		}
+		return conv
+	}
+
+	original := create("Go", "hello", "hi", "bye")
+	dup1 := create("Go", "hello", "hi", "bye")
+	dup2 := create("Go", "hello", "hi")
+	create("Go", "other question")
+	create("Rust", "hello", "hi")
+	create("Go")
+
+	pairs, err := database.GetDuplicateConversations()
+	if err != nil {
+		t.Fatalf("GetDuplicateConversations failed: %v", err)
+	}
+	if len(pairs) != 2 {
+		t.Fatalf("len(pairs) = %d, want 2", len(pairs))
+	}
+	if pairs[0].A.ID != original.ID || pairs[0].B.ID != dup1.ID || pairs[0].SimilarityScore != 1 {
+		t.Errorf("pairs[0] = %d/%d %.2f, want %d/%d 1.00", pairs[0].A.ID, pairs[0].B.ID, pairs[0].SimilarityScore, original.ID, dup1.ID)
+	}
+	if pairs[1].B.ID != dup2.ID || pairs[1].SimilarityScore < 0.66 || pairs[1].SimilarityScore > 0.67 {
+		t.Errorf("pairs[1] = %d %.2f, want %d 0.67", pairs[1].B.ID, pairs[1].SimilarityScore, dup2.ID)
+	}
+}
+
+func TestMergeDuplicateConversations(t *testing.T) {
+	database := newTestDB(t)
+
+	keep, err := database.CreateConversation("Go", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	merge, err := database.CreateConversation("Go", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for _, m := range []struct {
+		convID  int64
+		role    string
+		content string
+	}{
+		{keep.ID, "user", "hello"},
+		{keep.ID, "assistant", "hi"},
+		{merge.ID, "user", "hello"},
+		{merge.ID, "assistant", "hi"},
+		{merge.ID, "user", "follow-up"},
+	} {
+		if _, err := database.CreateMessage(m.convID, m.role, m.content, "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	if err := database.MergeDuplicateConversations(keep.ID, merge.ID); err != nil {
+		t.Fatalf("MergeDuplicateConversations failed: %v", err)
+	}
+
+	messages, err := database.ListMessages(keep.ID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 3 || messages[2].Content != "follow-up" {
+		t.Fatalf("merged messages = %d, want hello, hi, follow-up", len(messages))
+	}
+	if _, err := database.GetConversation(merge.ID); err == nil {
+		t.Error("merged conversation still exists")
+	}
+	if left, err := database.ListMessages(merge.ID); err != nil || len(left) != 0 {
+		t.Errorf("%d messages remain in the merged conversation (%v)", len(left), err)
+	}
+	if results, _ := database.SearchMessages("hello", 10, false); len(results) != 1 {
+		t.Errorf("search found %d messages for hello, want only the kept one", len(results))
+	}
+
+	results, err := database.SearchMessages("follow", 10, false)
+	if err != nil {
+		t.Fatalf("SearchMessages failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ConversationID != keep.ID {
+		t.Errorf("search results = %+v, want the message in conversation %d", results, keep.ID)
+	}
+
+	if err := database.MergeDuplicateConversations(keep.ID, keep.ID); err == nil {
+		t.Error("merging a conversation into itself succeeded")
+	}
+}
+
+func TestMergeConversations(t *testing.T) {
+	database := newTestDB(t)
+
+	target, err := database.CreateConversation("Target", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	source, err := database.CreateConversation("Source", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	// The source is older than the target, its messages still go last
+	base := time.Now().Add(-time.Hour)
+	for i, m := range []struct {
+		convID  int64
+		content string
+		at      time.Duration
+	}{
+		{source.ID, "source 1", 0},
+		{source.ID, "source 2", time.Minute},
+		{target.ID, "target 1", 30 * time.Minute},
+		{target.ID, "target 2", 40 * time.Minute},
+	} {
+		msg, err := database.CreateMessage(m.convID, "user", m.content, "", "", "", 0)
+		if err != nil {
+			t.Fatalf("CreateMessage %d failed: %v", i, err)
+		}
+		if _, err := database.conn.Exec("UPDATE messages SET created_at = ? WHERE id = ?", base.Add(m.at), msg.ID); err != nil {
+			t.Fatalf("failed to set created_at: %v", err)
+		}
+	}
+
+	if err := database.MergeConversations(target.ID, source.ID); err != nil {
+		t.Fatalf("MergeConversations failed: %v", err)
+	}
+
+	messages, err := database.ListMessages(target.ID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	var contents []string
+	for _, msg := range messages {
+		contents = append(contents, msg.Content)
+	}
+	if want := "target 1,target 2,source 1,source 2"; strings.Join(contents, ",") != want {
+		t.Errorf("merged messages = %v, want %s", contents, want)
+	}
+	if gap := messages[3].CreatedAt.Sub(messages[2].CreatedAt); gap != time.Minute {
+		t.Errorf("moved messages are %v apart, want their original minute", gap)
+	}
+	if _, err := database.GetConversation(source.ID); err == nil {
+		t.Error("source conversation still exists")
+	}
+
+	if err := database.MergeConversations(target.ID, source.ID); err == nil {
+		t.Error("merging a deleted conversation succeeded")
+	}
+}
+
+func TestSplitConversation(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("Source", "work")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if err := database.UpdateConversationSystemPrompt(conv.ID, "Be brief"); err != nil {
+		t.Fatalf("UpdateConversationSystemPrompt failed: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	var ids []int64
+	for i, role := range []string{"user", "assistant", "user", "assistant"} {
+		msg, err := database.CreateMessage(conv.ID, role, fmt.Sprintf("message %d", i+1), "", "", "", 0)
+		if err != nil {
+			t.Fatalf("CreateMessage %d failed: %v", i, err)
+		}
+		if _, err := database.conn.Exec("UPDATE messages SET created_at = ? WHERE id = ?", base.Add(time.Duration(i)*time.Minute), msg.ID); err != nil {
+			t.Fatalf("failed to set created_at: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+	if err := database.TagMessage(ids[2], "important"); err != nil {
+		t.Fatalf("TagMessage failed: %v", err)
+	}
+
+	for _, id := range []int64{ids[0], ids[1], 9999} {
+		if _, err := database.SplitConversation(conv.ID, id); err == nil {
+			t.Errorf("splitting at message %d succeeded", id)
+		}
+	}
+
+	split, err := database.SplitConversation(conv.ID, ids[2])
+	if err != nil {
+		t.Fatalf("SplitConversation failed: %v", err)
+	}
+	if split.Title != "Source (拆分)" || split.Category != "work" || split.SystemPrompt != "Be brief" {
+		t.Errorf("unexpected new conversation %+v", split)
+	}
+
+	contents := func(id int64) string {
+		messages, err := database.ListMessages(id)
+		if err != nil {
+			t.Fatalf("ListMessages failed: %v", err)
+		}
+		var list []string
+		for _, msg := range messages {
+			list = append(list, msg.Content)
+		}
+		return strings.Join(list, ",")
+	}
+	if got := contents(conv.ID); got != "message 1,message 2" {
+		t.Errorf("source messages = %s", got)
+	}
+	if got := contents(split.ID); got != "message 3,message 4" {
+		t.Errorf("split messages = %s", got)
+	}
+	if tags, err := database.ListMessageTags(ids[2]); err != nil || len(tags) != 1 {
+		t.Errorf("tags of the moved message = %v, %v", tags, err)
+	}
+}
+
+func TestUpdateMessageConversation(t *testing.T) {
+	database := newTestDB(t)
+
+	a, err := database.CreateConversation("A", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	b, err := database.CreateConversation("B", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	msg, err := database.CreateMessage(a.ID, "user", "moving", "", "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	if err := database.UpdateMessageConversation(msg.ID, b.ID); err != nil {
+		t.Fatalf("UpdateMessageConversation failed: %v", err)
+	}
+	if messages, err := database.ListMessages(b.ID); err != nil || len(messages) != 1 {
+		t.Errorf("messages of B = %v, %v; want the moved message", messages, err)
+	}
+	if err := database.UpdateMessageConversation(msg.ID+1, b.ID); err == nil {
+		t.Error("expected an error for a missing message")
+	}
+}
+
+func TestDeleteMessagesBefore(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("prune", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	other, err := database.CreateConversation("other", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for _, content := range []string{"one", "two", "three", "four"} {
+		if _, err := database.CreateMessage(conv.ID, "user", content, "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		if _, err := database.CreateMessage(other.ID, "user", content, "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	for _, index := range []int{-1, 4} {
+		if _, err := database.DeleteMessagesBefore(conv.ID, index); err == nil {
+			t.Errorf("Expected error for index %d", index)
+		}
+	}
+
+	deleted, err := database.DeleteMessagesBefore(conv.ID, 2)
+	if err != nil {
+		t.Fatalf("DeleteMessagesBefore failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 deleted messages, got %d", deleted)
+	}
+	messages, err := database.ListMessages(conv.ID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Content != "three" || messages[1].Content != "four" {
+		t.Errorf("Expected three and four to be kept, got %v", messages)
+	}
+
+	// Index 0 deletes nothing, other conversations are untouched
+	if deleted, err := database.DeleteMessagesBefore(conv.ID, 0); err != nil || deleted != 0 {
+		t.Errorf("DeleteMessagesBefore(0) = %d, %v; want 0, nil", deleted, err)
+	}
+	if messages, _ := database.ListMessages(other.ID); len(messages) != 4 {
+		t.Errorf("Expected the other conversation to keep 4 messages, got %d", len(messages))
+	}
+}
@@ -4,11 +4,16 @@ import "time"
 
 // Conversation represents a chat conversation
 type Conversation struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title"`
-	Category  string    `json:"category"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             int64               `json:"id"`
+	Title          string              `json:"title"`
+	Category       string              `json:"category"`
+	ParentID       int64               `json:"parent_id,omitempty"`       // Conversation this one was forked from, 0 if none
+	SystemPrompt   string              `json:"system_prompt,omitempty"`   // Sent before the messages, "" for the provider's default
+	ParamsOverride *ConversationParams `json:"params_override,omitempty"` // Sampling settings overriding the provider's, nil if none
+	Pinned         bool                `json:"pinned,omitempty"`          // Listed before the unpinned conversations
+	DeletedAt      *time.Time          `json:"-"`                         // When it was moved to the recycle bin, set by ListDeletedConversations
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
 }
 
 // Message represents a single message in a conversation
@@ -23,6 +28,20 @@ type Message struct {
 	Attachments    string    `json:"attachments"` // JSON array
 	TokensUsed     int       `json:"tokens_used"`
 	CreatedAt      time.Time `json:"created_at"`
+
+	// Metadata is a JSON object with details about how a response was
+	// generated, like the streaming metrics of fork responses
+	Metadata string `json:"metadata,omitempty"`
+	// Starred messages are bookmarked and can be searched for
+	Starred bool `json:"starred,omitempty"`
+}
+
+// MessageVersion is a previous content of an edited message
+type MessageVersion struct {
+	ID        int64     `json:"id"`
+	MessageID int64     `json:"message_id"`
+	Content   string    `json:"content"`    // Content before the edit
+	CreatedAt time.Time `json:"created_at"` // When the edit replaced this content
 }
 
 // Setting represents a configuration setting
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ConversationParams override the sampling settings of the provider for a
+// conversation. Nil fields keep the provider's configured value. They are
+// stored as JSON in conversations.params_override.
+type ConversationParams struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   *int     `json:"max_tokens,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+}
+
+// IsEmpty reports whether params override nothing
+func (p *ConversationParams) IsEmpty() bool {
+	return p == nil || (p.Temperature == nil && p.MaxTokens == nil && p.TopP == nil)
+}
+
+// encodeConversationParams encodes params for the params_override column, ""
+// when they override nothing
+func encodeConversationParams(params *ConversationParams) (string, error) {
+	if params.IsEmpty() {
+		return "", nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode parameters: %w", err)
+	}
+	return string(data), nil
+}
+
+// decodeConversationParams decodes the params_override column, nil when it is
+// empty
+func decodeConversationParams(data string) (*ConversationParams, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var params ConversationParams
+	if err := json.Unmarshal([]byte(data), &params); err != nil {
+		return nil, fmt.Errorf("failed to decode parameters: %w", err)
+	}
+	if params.IsEmpty() {
+		return nil, nil
+	}
+	return &params, nil
+}
+
+// UpdateConversationParams sets the parameter overrides of a conversation,
+// nil to use the provider's settings
+func (db *DB) UpdateConversationParams(id int64, params *ConversationParams) error {
+	encoded, err := encodeConversationParams(params)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(
+		"UPDATE conversations SET params_override = ? WHERE id = ?",
+		encoded, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation parameters: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// PromptGoodRating is the lowest user rating counted as a good result
+const PromptGoodRating = 4
+
+// PromptVariant is one system prompt of an A/B pair
+type PromptVariant struct {
+	ID           int64  `json:"id"`
+	PairID       int64  `json:"pair_id"`
+	Label        string `json:"label"` // "A" or "B"
+	SystemPrompt string `json:"system_prompt"`
+}
+
+// PromptABPair is two system-prompt variants compared against each other
+type PromptABPair struct {
+	ID        int64          `json:"id"`
+	Name      string         `json:"name"`
+	A         *PromptVariant `json:"a"`
+	B         *PromptVariant `json:"b"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// PromptABTest is a conversation run as a test of a variant
+type PromptABTest struct {
+	ID                int64     `json:"id"`
+	PairID            int64     `json:"pair_id"`
+	PairName          string    `json:"pair_name"`
+	VariantID         int64     `json:"variant_id"`
+	Label             string    `json:"label"`
+	ConversationID    int64     `json:"conversation_id"`
+	ConversationTitle string    `json:"conversation_title"`
+	UserRating        int       `json:"user_rating"` // 1-5, 0 if not rated
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// PromptABStats summarizes the test runs of a pair
+type PromptABStats struct {
+	Pair           *PromptABPair
+	RunsA, RunsB   int
+	RatedA, RatedB int
+	GoodA, GoodB   int     // Rated runs with at least PromptGoodRating
+	MeanA, MeanB   float64 // Mean rating of the rated runs, 0 without any
+	// Rated runs of A and B are compared in the order they were recorded;
+	// a win is a run of A rated higher than its counterpart of B
+	Wins, Losses, Ties int
+	// PValue is the two-sided Fisher exact test of good vs. other ratings
+	// between the variants, 1 without rated runs
+	PValue float64
+}
+
+// CreatePromptABPair creates a pair with its two variants
+func (db *DB) CreatePromptABPair(name, promptA, promptB string) (*PromptABPair, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("name cannot be empty")
+	}
+	if strings.TrimSpace(promptA) == "" || strings.TrimSpace(promptB) == "" {
+		return nil, fmt.Errorf("both variants need a system prompt")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec("INSERT INTO prompt_ab_pairs (name, created_at) VALUES (?, ?)", name, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pair: %w", err)
+	}
+	pairID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pair ID: %w", err)
+	}
+
+	pair := &PromptABPair{ID: pairID, Name: name, CreatedAt: now}
+	for _, v := range []struct {
+		label, prompt string
+		dst           **PromptVariant
+	}{{"A", promptA, &pair.A}, {"B", promptB, &pair.B}} {
+		result, err := tx.Exec(
+			"INSERT INTO prompt_variants (pair_id, label, system_prompt) VALUES (?, ?, ?)",
+			pairID, v.label, v.prompt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create variant: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get variant ID: %w", err)
+		}
+		*v.dst = &PromptVariant{ID: id, PairID: pairID, Label: v.label, SystemPrompt: v.prompt}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pair, nil
+}
+
+// ListPromptABPairs returns all pairs with their variants, oldest first
+func (db *DB) ListPromptABPairs() ([]*PromptABPair, error) {
+	rows, err := db.conn.Query(`
+		SELECT p.id, p.name, p.created_at, v.id, v.label, v.system_prompt
+		FROM prompt_ab_pairs p
+		JOIN prompt_variants v ON v.pair_id = p.id
+		ORDER BY p.created_at ASC, p.id ASC, v.label ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt pairs: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs []*PromptABPair
+	for rows.Next() {
+		var pair PromptABPair
+		variant := &PromptVariant{}
+		if err := rows.Scan(&pair.ID, &pair.Name, &pair.CreatedAt, &variant.ID, &variant.Label, &variant.SystemPrompt); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt pair: %w", err)
+		}
+		variant.PairID = pair.ID
+
+		if len(pairs) == 0 || pairs[len(pairs)-1].ID != pair.ID {
+			pairs = append(pairs, &pair)
+		}
+		if variant.Label == "A" {
+			pairs[len(pairs)-1].A = variant
+		} else {
+			pairs[len(pairs)-1].B = variant
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list prompt pairs: %w", err)
+	}
+	return pairs, nil
+}
+
+// DeletePromptABPair deletes a pair with its variants and test runs
+func (db *DB) DeletePromptABPair(pairID int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM prompt_ab_tests WHERE variant_id IN (SELECT id FROM prompt_variants WHERE pair_id = ?)",
+		"DELETE FROM prompt_variants WHERE pair_id = ?",
+		"DELETE FROM prompt_ab_pairs WHERE id = ?",
+	} {
+		if _, err := tx.Exec(query, pairID); err != nil {
+			return fmt.Errorf("failed to delete prompt pair: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RecordPromptABTest marks a conversation as a test run of a variant. A
+// conversation tests one variant; marking it again replaces the record.
+func (db *DB) RecordPromptABTest(variantID, conversationID int64, rating int) error {
+	if rating < 0 || rating > 5 {
+		return fmt.Errorf("rating must be between 1 and 5, or 0 for none")
+	}
+	_, err := db.conn.Exec(`
+		INSERT INTO prompt_ab_tests (variant_id, conversation_id, user_rating, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(conversation_id) DO UPDATE SET variant_id = excluded.variant_id, user_rating = excluded.user_rating
+	`, variantID, conversationID, rating, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record test run: %w", err)
+	}
+	return nil
+}
+
+// RemovePromptABTest removes the test run record of a conversation
+func (db *DB) RemovePromptABTest(conversationID int64) error {
+	if _, err := db.conn.Exec("DELETE FROM prompt_ab_tests WHERE conversation_id = ?", conversationID); err != nil {
+		return fmt.Errorf("failed to remove test run: %w", err)
+	}
+	return nil
+}
+
+// GetPromptABTest returns the test run record of a conversation, nil if it
+// is not a test run
+func (db *DB) GetPromptABTest(conversationID int64) (*PromptABTest, error) {
+	tests, err := db.listPromptABTests("WHERE t.conversation_id = ?", conversationID)
+	if err != nil || len(tests) == 0 {
+		return nil, err
+	}
+	return tests[0], nil
+}
+
+// ListPromptABTests returns all test runs in the order they were recorded
+func (db *DB) ListPromptABTests() ([]*PromptABTest, error) {
+	return db.listPromptABTests("")
+}
+
+// listPromptABTests returns the test runs matching filter
+func (db *DB) listPromptABTests(filter string, args ...interface{}) ([]*PromptABTest, error) {
+	rows, err := db.conn.Query(`
+		SELECT t.id, p.id, p.name, v.id, v.label, t.conversation_id, COALESCE(c.title, ''), t.user_rating, t.created_at
+		FROM prompt_ab_tests t
+		JOIN prompt_variants v ON v.id = t.variant_id
+		JOIN prompt_ab_pairs p ON p.id = v.pair_id
+		LEFT JOIN conversations c ON c.id = t.conversation_id
+		`+filter+`
+		ORDER BY t.created_at ASC, t.id ASC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test runs: %w", err)
+	}
+	defer rows.Close()
+
+	var tests []*PromptABTest
+	for rows.Next() {
+		var test PromptABTest
+		if err := rows.Scan(&test.ID, &test.PairID, &test.PairName, &test.VariantID, &test.Label,
+			&test.ConversationID, &test.ConversationTitle, &test.UserRating, &test.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan test run: %w", err)
+		}
+		tests = append(tests, &test)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list test runs: %w", err)
+	}
+	return tests, nil
+}
+
+// GetPromptABStats returns the statistics of every pair
+func (db *DB) GetPromptABStats() ([]*PromptABStats, error) {
+	pairs, err := db.ListPromptABPairs()
+	if err != nil {
+		return nil, err
+	}
+	tests, err := db.ListPromptABTests()
+	if err != nil {
+		return nil, err
+	}
+
+	byPair := make(map[int64][]*PromptABTest)
+	for _, test := range tests {
+		byPair[test.PairID] = append(byPair[test.PairID], test)
+	}
+
+	stats := make([]*PromptABStats, 0, len(pairs))
+	for _, pair := range pairs {
+		stats = append(stats, computePromptABStats(pair, byPair[pair.ID]))
+	}
+	return stats, nil
+}
+
+// computePromptABStats summarizes the test runs of a pair
+func computePromptABStats(pair *PromptABPair, tests []*PromptABTest) *PromptABStats {
+	stats := &PromptABStats{Pair: pair}
+	var ratingsA, ratingsB []int
+	for _, test := range tests {
+		if test.Label == "A" {
+			stats.RunsA++
+			if test.UserRating > 0 {
+				ratingsA = append(ratingsA, test.UserRating)
+			}
+		} else {
+			stats.RunsB++
+			if test.UserRating > 0 {
+				ratingsB = append(ratingsB, test.UserRating)
+			}
+		}
+	}
+
+	summarize := func(ratings []int) (rated, good int, mean float64) {
+		sum := 0
+		for _, rating := range ratings {
+			sum += rating
+			if rating >= PromptGoodRating {
+				good++
+			}
+		}
+		if len(ratings) > 0 {
+			mean = float64(sum) / float64(len(ratings))
+		}
+		return len(ratings), good, mean
+	}
+	stats.RatedA, stats.GoodA, stats.MeanA = summarize(ratingsA)
+	stats.RatedB, stats.GoodB, stats.MeanB = summarize(ratingsB)
+
+	for i := 0; i < len(ratingsA) && i < len(ratingsB); i++ {
+		switch {
+		case ratingsA[i] > ratingsB[i]:
+			stats.Wins++
+		case ratingsA[i] < ratingsB[i]:
+			stats.Losses++
+		default:
+			stats.Ties++
+		}
+	}
+
+	stats.PValue = fisherExactTest(stats.GoodA, stats.RatedA-stats.GoodA, stats.GoodB, stats.RatedB-stats.GoodB)
+	return stats
+}
+
+// fisherExactTest returns the two-sided p-value of Fisher's exact test for
+// the 2x2 table [[a b] [c d]]: the probability, with the row and column sums
+// fixed, of all tables at most as likely as the observed one
+func fisherExactTest(a, b, c, d int) float64 {
+	row1, row2, col1 := a+b, c+d, a+c
+	n := row1 + row2
+	if n == 0 {
+		return 1
+	}
+
+	// Hypergeometric probability of x in the top left cell
+	logP := func(x int) float64 {
+		return logChoose(row1, x) + logChoose(row2, col1-x) - logChoose(n, col1)
+	}
+	observed := logP(a)
+
+	p := 0.0
+	for x := max(0, col1-row2); x <= min(row1, col1); x++ {
+		// Relative tolerance for tables as likely as the observed one
+		if lp := logP(x); lp <= observed+1e-7 {
+			p += math.Exp(lp)
+		}
+	}
+	return math.Min(p, 1)
+}
+
+// logChoose returns the natural logarithm of the binomial coefficient
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
@@ -0,0 +1,114 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFisherExactTest(t *testing.T) {
+	tests := []struct {
+		a, b, c, d int
+		want       float64
+	}{
+		{1, 9, 11, 3, 0.002759},
+		{3, 1, 1, 3, 0.485714},
+		{2, 2, 2, 2, 1},
+		{0, 0, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		if got := fisherExactTest(tt.a, tt.b, tt.c, tt.d); math.Abs(got-tt.want) > 1e-5 {
+			t.Errorf("fisherExactTest(%d, %d, %d, %d) = %f, want %f", tt.a, tt.b, tt.c, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestPromptABTests(t *testing.T) {
+	database := newTestDB(t)
+
+	pair, err := database.CreatePromptABPair("Tone", "Be formal.", "Be casual.")
+	if err != nil {
+		t.Fatalf("CreatePromptABPair failed: %v", err)
+	}
+	if _, err := database.CreatePromptABPair("Empty", "", "Be casual."); err == nil {
+		t.Error("expected an error for an empty variant")
+	}
+
+	// A is rated 5, 4, 2 and B 3, 4 with one unrated run
+	runs := []struct {
+		variant *PromptVariant
+		rating  int
+	}{
+		{pair.A, 5}, {pair.B, 3}, {pair.A, 4}, {pair.B, 4}, {pair.A, 2}, {pair.B, 0},
+	}
+	var convIDs []int64
+	for _, run := range runs {
+		conv, err := database.CreateConversation("run", "")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		convIDs = append(convIDs, conv.ID)
+		if err := database.RecordPromptABTest(run.variant.ID, conv.ID, run.rating); err != nil {
+			t.Fatalf("RecordPromptABTest failed: %v", err)
+		}
+	}
+
+	// Marking again replaces the record
+	if err := database.RecordPromptABTest(pair.B.ID, convIDs[4], 1); err != nil {
+		t.Fatalf("RecordPromptABTest failed: %v", err)
+	}
+	test, err := database.GetPromptABTest(convIDs[4])
+	if err != nil || test == nil {
+		t.Fatalf("GetPromptABTest failed: %v", err)
+	}
+	if test.Label != "B" || test.UserRating != 1 || test.PairName != "Tone" {
+		t.Errorf("unexpected test run %+v", test)
+	}
+	if err := database.RecordPromptABTest(pair.A.ID, convIDs[4], 2); err != nil {
+		t.Fatalf("RecordPromptABTest failed: %v", err)
+	}
+
+	stats, err := database.GetPromptABStats()
+	if err != nil {
+		t.Fatalf("GetPromptABStats failed: %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("expected stats of 1 pair, got %d", len(stats))
+	}
+	s := stats[0]
+	if s.RunsA != 3 || s.RunsB != 3 || s.RatedA != 3 || s.RatedB != 2 {
+		t.Errorf("unexpected run counts %+v", s)
+	}
+	if math.Abs(s.MeanA-11.0/3) > 1e-9 || s.MeanB != 3.5 {
+		t.Errorf("unexpected mean ratings %f, %f", s.MeanA, s.MeanB)
+	}
+	if s.Wins != 1 || s.Losses != 0 || s.Ties != 1 {
+		t.Errorf("expected 1 win and 1 tie, got %d/%d/%d", s.Wins, s.Losses, s.Ties)
+	}
+	if want := fisherExactTest(2, 1, 1, 1); s.PValue != want {
+		t.Errorf("PValue = %f, want %f", s.PValue, want)
+	}
+
+	// Deleting a conversation permanently drops its test run
+	if err := database.PermanentlyDeleteConversation(convIDs[0]); err != nil {
+		t.Fatalf("PermanentlyDeleteConversation failed: %v", err)
+	}
+	tests, err := database.ListPromptABTests()
+	if err != nil {
+		t.Fatalf("ListPromptABTests failed: %v", err)
+	}
+	if len(tests) != 5 {
+		t.Errorf("expected 5 test runs, got %d", len(tests))
+	}
+
+	if err := database.DeletePromptABPair(pair.ID); err != nil {
+		t.Fatalf("DeletePromptABPair failed: %v", err)
+	}
+	if tests, _ := database.ListPromptABTests(); len(tests) != 0 {
+		t.Errorf("expected the test runs to be deleted with the pair, got %d", len(tests))
+	}
+	if pairs, _ := database.ListPromptABPairs(); len(pairs) != 0 {
+		t.Errorf("expected no pairs, got %d", len(pairs))
+	}
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// templateVariableRegex matches a {{variable_name}} placeholder
+var templateVariableRegex = regexp.MustCompile(`\{\{\s*([\p{L}\p{N}_]+)\s*\}\}`)
+
+// PromptTemplate is a reusable prompt with {{variable}} placeholders
+type PromptTemplate struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Category  string    `json:"category"`
+	Content   string    `json:"content"`
+	Variables []string  `json:"variables"` // Placeholder names in order of appearance
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TemplateVariables returns the names of the placeholders in content, each
+// once, in the order they first appear
+func TemplateVariables(content string) []string {
+	seen := make(map[string]bool)
+	variables := []string{}
+	for _, match := range templateVariableRegex.FindAllStringSubmatch(content, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+// FillTemplate replaces the placeholders in content with values. Placeholders
+// without a value are left as they are.
+func FillTemplate(content string, values map[string]string) string {
+	return templateVariableRegex.ReplaceAllStringFunc(content, func(placeholder string) string {
+		name := templateVariableRegex.FindStringSubmatch(placeholder)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// CreatePromptTemplate saves a new template; its variables are taken from content
+func (db *DB) CreatePromptTemplate(name, category, content string) (*PromptTemplate, error) {
+	name, category = strings.TrimSpace(name), strings.TrimSpace(category)
+	if name == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("content cannot be empty")
+	}
+
+	variables := TemplateVariables(content)
+	encoded, err := json.Marshal(variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode variables: %w", err)
+	}
+
+	now := time.Now()
+	result, err := db.conn.Exec(
+		"INSERT INTO prompt_templates (name, category, content, variables, created_at) VALUES (?, ?, ?, ?, ?)",
+		name, category, content, string(encoded), now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template ID: %w", err)
+	}
+
+	return &PromptTemplate{ID: id, Name: name, Category: category, Content: content, Variables: variables, CreatedAt: now}, nil
+}
+
+// GetPromptTemplate returns a template by ID
+func (db *DB) GetPromptTemplate(id int64) (*PromptTemplate, error) {
+	row := db.conn.QueryRow("SELECT id, name, category, content, variables, created_at FROM prompt_templates WHERE id = ?", id)
+	template, err := scanPromptTemplate(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	return template, nil
+}
+
+// ListPromptTemplates returns all templates sorted by category and name
+func (db *DB) ListPromptTemplates() ([]*PromptTemplate, error) {
+	rows, err := db.conn.Query("SELECT id, name, category, content, variables, created_at FROM prompt_templates ORDER BY category COLLATE NOCASE, name COLLATE NOCASE, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*PromptTemplate
+	for rows.Next() {
+		template, err := scanPromptTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return templates, nil
+}
+
+// UpdatePromptTemplate changes a template; its variables are taken from content
+func (db *DB) UpdatePromptTemplate(id int64, name, category, content string) error {
+	name, category = strings.TrimSpace(name), strings.TrimSpace(category)
+	if name == "" {
+		return errors.New("name cannot be empty")
+	}
+	if strings.TrimSpace(content) == "" {
+		return errors.New("content cannot be empty")
+	}
+
+	encoded, err := json.Marshal(TemplateVariables(content))
+	if err != nil {
+		return fmt.Errorf("failed to encode variables: %w", err)
+	}
+	result, err := db.conn.Exec(
+		"UPDATE prompt_templates SET name = ?, category = ?, content = ?, variables = ? WHERE id = ?",
+		name, category, content, string(encoded), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("template %d not found", id)
+	}
+	return nil
+}
+
+// DeletePromptTemplate deletes a template
+func (db *DB) DeletePromptTemplate(id int64) error {
+	if _, err := db.conn.Exec("DELETE FROM prompt_templates WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+// scanPromptTemplate reads a template from a row of id, name, category,
+// content, variables and created_at
+func scanPromptTemplate(row interface{ Scan(...interface{}) error }) (*PromptTemplate, error) {
+	var template PromptTemplate
+	var variables sql.NullString
+	if err := row.Scan(&template.ID, &template.Name, &template.Category, &template.Content, &variables, &template.CreatedAt); err != nil {
+		return nil, err
+	}
+	if variables.Valid && variables.String != "" {
+		if err := json.Unmarshal([]byte(variables.String), &template.Variables); err != nil {
+			return nil, fmt.Errorf("invalid variables of template %d: %w", template.ID, err)
+		}
+	}
+	if template.Variables == nil {
+		template.Variables = []string{}
+	}
+	return &template, nil
+}
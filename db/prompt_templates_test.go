@@ -0,0 +1,70 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateVariables(t *testing.T) {
+	got := TemplateVariables("Translate {{text}} to {{ language }}, keep {{text}} short. {not} {{}}")
+	if want := []string{"text", "language"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TemplateVariables = %v, want %v", got, want)
+	}
+
+	filled := FillTemplate("{{greeting}}, {{ name }}! {{missing}}", map[string]string{"greeting": "Hello", "name": "{{greeting}}"})
+	if want := "Hello, {{greeting}}! {{missing}}"; filled != want {
+		t.Errorf("FillTemplate = %q, want %q", filled, want)
+	}
+}
+
+func TestPromptTemplates(t *testing.T) {
+	database := newTestDB(t)
+
+	if _, err := database.CreatePromptTemplate(" ", "", "content"); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+	review, err := database.CreatePromptTemplate("Review", "Code", "Review this {{language}} code:\n{{code}}")
+	if err != nil {
+		t.Fatalf("CreatePromptTemplate failed: %v", err)
+	}
+	if _, err := database.CreatePromptTemplate("Email", "", "Write a polite email."); err != nil {
+		t.Fatalf("CreatePromptTemplate failed: %v", err)
+	}
+
+	templates, err := database.ListPromptTemplates()
+	if err != nil {
+		t.Fatalf("ListPromptTemplates failed: %v", err)
+	}
+	if len(templates) != 2 || templates[0].Name != "Email" || templates[1].Name != "Review" {
+		t.Fatalf("unexpected templates %+v", templates)
+	}
+	if len(templates[0].Variables) != 0 {
+		t.Errorf("Email variables = %v", templates[0].Variables)
+	}
+	if want := []string{"language", "code"}; !reflect.DeepEqual(templates[1].Variables, want) {
+		t.Errorf("Review variables = %v, want %v", templates[1].Variables, want)
+	}
+
+	if err := database.UpdatePromptTemplate(review.ID, "Review", "Code", "Review {{code}}"); err != nil {
+		t.Fatalf("UpdatePromptTemplate failed: %v", err)
+	}
+	updated, err := database.GetPromptTemplate(review.ID)
+	if err != nil {
+		t.Fatalf("GetPromptTemplate failed: %v", err)
+	}
+	if updated.Content != "Review {{code}}" || !reflect.DeepEqual(updated.Variables, []string{"code"}) {
+		t.Errorf("unexpected updated template %+v", updated)
+	}
+
+	if err := database.DeletePromptTemplate(review.ID); err != nil {
+		t.Fatalf("DeletePromptTemplate failed: %v", err)
+	}
+	if _, err := database.GetPromptTemplate(review.ID); err == nil {
+		t.Error("expected the deleted template to be gone")
+	}
+	if err := database.UpdatePromptTemplate(review.ID, "Review", "", "x"); err == nil {
+		t.Error("expected an error updating a deleted template")
+	}
+}
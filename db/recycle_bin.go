@@ -0,0 +1,85 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// ListDeletedConversations returns the conversations in the recycle bin, the
+// most recently deleted first
+func (db *DB) ListDeletedConversations() ([]*Conversation, error) {
+	rows, err := db.conn.Query(
+		"SELECT id, title, category, COALESCE(pinned, 0), created_at, updated_at, deleted_at FROM conversations WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var conversations []*Conversation
+	for rows.Next() {
+		var conv Conversation
+		var deletedAt time.Time
+		if err := rows.Scan(&conv.ID, &conv.Title, &conv.Category, &conv.Pinned, &conv.CreatedAt, &conv.UpdatedAt, &deletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		conv.DeletedAt = &deletedAt
+		conversations = append(conversations, &conv)
+	}
+
+	return conversations, nil
+}
+
+// RestoreConversation takes a conversation out of the recycle bin
+func (db *DB) RestoreConversation(id int64) error {
+	result, err := db.conn.Exec("UPDATE conversations SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to restore conversation: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("conversation %d is not in the recycle bin", id)
+	}
+	return nil
+}
+
+// PermanentlyDeleteConversation deletes a conversation and all its messages
+func (db *DB) PermanentlyDeleteConversation(id int64) error {
+	if _, err := db.deleteConversations("id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeletedConversations permanently deletes the conversations that were
+// moved to the recycle bin before cutoff and returns how many there were
+func (db *DB) PurgeDeletedConversations(cutoff time.Time) (int64, error) {
+	count, err := db.deleteConversations("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted conversations: %w", err)
+	}
+	return count, nil
+}
+
+// deleteConversations deletes the conversations matching where together with
+// their messages. Foreign keys are not enforced, so the messages are not
+// deleted with the conversations by themselves.
+func (db *DB) deleteConversations(where string, args ...interface{}) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM messages WHERE conversation_id IN (SELECT id FROM conversations WHERE "+where+")", args...); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec("DELETE FROM conversations WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
+}
@@ -0,0 +1,174 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecycleBin(t *testing.T) {
+	database := newTestDB(t)
+
+	kept, err := database.CreateConversation("kept", "Work")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	binned, err := database.CreateConversation("binned", "Old")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if _, err := database.CreateMessage(binned.ID, "user", "searchable words", "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	if err := database.DeleteConversation(binned.ID); err != nil {
+		t.Fatalf("DeleteConversation failed: %v", err)
+	}
+
+	// The conversation is hidden from lists, search and categories but keeps its messages
+	conversations, err := database.ListConversations(10, 0)
+	if err != nil || len(conversations) != 1 || conversations[0].ID != kept.ID {
+		t.Fatalf("ListConversations = %v, %v", conversations, err)
+	}
+	if count, _ := database.CountConversations(); count != 1 {
+		t.Errorf("CountConversations = %d, want 1", count)
+	}
+	if categories, _ := database.GetCategories(); len(categories) != 1 || categories[0] != "Work" {
+		t.Errorf("GetCategories = %q", categories)
+	}
+	if results, _ := database.SearchMessages("searchable", 10, false); len(results) != 0 {
+		t.Errorf("search found %d messages of the deleted conversation", len(results))
+	}
+	if messages, _ := database.ListMessages(binned.ID); len(messages) != 1 {
+		t.Errorf("deleted conversation has %d messages, want 1", len(messages))
+	}
+
+	deleted, err := database.ListDeletedConversations()
+	if err != nil || len(deleted) != 1 || deleted[0].ID != binned.ID || deleted[0].DeletedAt == nil {
+		t.Fatalf("ListDeletedConversations = %v, %v", deleted, err)
+	}
+
+	// Restoring brings it back
+	if err := database.RestoreConversation(binned.ID); err != nil {
+		t.Fatalf("RestoreConversation failed: %v", err)
+	}
+	if conversations, _ := database.ListConversations(10, 0); len(conversations) != 2 {
+		t.Errorf("expected 2 conversations after restoring, got %d", len(conversations))
+	}
+	if results, _ := database.SearchMessages("searchable", 10, false); len(results) != 1 {
+		t.Errorf("search found %d messages after restoring, want 1", len(results))
+	}
+	if err := database.RestoreConversation(binned.ID); err == nil {
+		t.Error("expected an error restoring a conversation that is not deleted")
+	}
+
+	// Only conversations deleted before the cutoff are purged
+	if err := database.DeleteConversation(binned.ID); err != nil {
+		t.Fatalf("DeleteConversation failed: %v", err)
+	}
+	if _, err := database.conn.Exec("UPDATE conversations SET deleted_at = ? WHERE id = ?", time.Now().AddDate(0, 0, -31), binned.ID); err != nil {
+		t.Fatalf("failed to set deleted_at: %v", err)
+	}
+	if err := database.DeleteConversation(kept.ID); err != nil {
+		t.Fatalf("DeleteConversation failed: %v", err)
+	}
+	purged, err := database.PurgeDeletedConversations(time.Now().AddDate(0, 0, -30))
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeDeletedConversations = %d, %v", purged, err)
+	}
+	if _, err := database.GetConversation(binned.ID); err == nil {
+		t.Error("expected the purged conversation to be gone")
+	}
+	if messages, _ := database.ListMessages(binned.ID); len(messages) != 0 {
+		t.Errorf("purged conversation left %d messages", len(messages))
+	}
+
+	if err := database.PermanentlyDeleteConversation(kept.ID); err != nil {
+		t.Fatalf("PermanentlyDeleteConversation failed: %v", err)
+	}
+	if deleted, _ := database.ListDeletedConversations(); len(deleted) != 0 {
+		t.Errorf("recycle bin still has %d conversations", len(deleted))
+	}
+}
+
+func TestDeleteOldestConversations_IgnoresRecycleBin(t *testing.T) {
+	database := newTestDB(t)
+
+	// Oldest first; the recycled conversation is the most recently updated
+	var ids []int64
+	for i, title := range []string{"oldest", "older", "newer", "recycled"} {
+		conv, err := database.CreateConversation(title, "")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		if _, err := database.CreateMessage(conv.ID, "user", title+" message", "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		updated := time.Now().AddDate(0, 0, i-10)
+		if _, err := database.conn.Exec("UPDATE conversations SET updated_at = ? WHERE id = ?", updated, conv.ID); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, conv.ID)
+	}
+	oldest, recycled := ids[0], ids[3]
+	if err := database.DeleteConversation(recycled); err != nil {
+		t.Fatalf("DeleteConversation failed: %v", err)
+	}
+
+	if _, err := database.GetConversation(recycled); err == nil {
+		t.Error("GetConversation found a conversation in the recycle bin")
+	}
+	stats, err := database.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.ConversationCount != 3 || stats.MessageCount != 3 {
+		t.Errorf("GetStats counted %d conversations and %d messages, want 3 and 3", stats.ConversationCount, stats.MessageCount)
+	}
+	summary, err := database.ComputeConversationSummaryStats()
+	if err != nil {
+		t.Fatalf("ComputeConversationSummaryStats failed: %v", err)
+	}
+	if summary.TotalConversations != 3 || summary.TotalMessages != 3 {
+		t.Errorf("summary counted %d conversations and %d messages, want 3 and 3", summary.TotalConversations, summary.TotalMessages)
+	}
+
+	// Max history 2 deletes only the oldest live conversation
+	deleted, err := database.DeleteOldestConversations(2)
+	if err != nil {
+		t.Fatalf("DeleteOldestConversations failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("deleted %d conversations, want 1", deleted)
+	}
+	conversations, err := database.ListConversations(10, 0)
+	if err != nil || len(conversations) != 2 {
+		t.Fatalf("ListConversations = %v, %v, want 2 conversations", conversations, err)
+	}
+	if binned, _ := database.ListDeletedConversations(); len(binned) != 1 || binned[0].ID != recycled {
+		t.Errorf("recycle bin holds %v, want only %d", binned, recycled)
+	}
+	var orphans int
+	if err := database.conn.QueryRow("SELECT COUNT(*) FROM messages WHERE conversation_id = ?", oldest).Scan(&orphans); err != nil {
+		t.Fatal(err)
+	}
+	if orphans != 0 {
+		t.Errorf("%d messages of the deleted conversation remain", orphans)
+	}
+
+	// Age-based cleanup leaves the recycle bin alone too
+	deleted, err = database.DeleteOldConversations(1)
+	if err != nil {
+		t.Fatalf("DeleteOldConversations failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted %d old conversations, want 2", deleted)
+	}
+	if binned, _ := database.ListDeletedConversations(); len(binned) != 1 {
+		t.Errorf("recycle bin holds %d conversations, want 1", len(binned))
+	}
+	if messages, _ := database.ListMessages(recycled); len(messages) != 1 {
+		t.Errorf("recycled conversation has %d messages, want 1", len(messages))
+	}
+}
@@ -1,25 +1,89 @@
 package db
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// snippetContextRunes is the number of characters shown on each side of a match
+const snippetContextRunes = 50
+
+// rerankCandidates is how many times the limit of FTS results are fetched to
+// be reranked, so relevant messages ranked low by FTS can move up
+const rerankCandidates = 5
+
+// SearchRanker scores how relevant a message's content is to a query
+type SearchRanker interface {
+	Score(query, content string) float64
+}
+
+// SetSearchRanker sets the ranker used by searches with semantic ranking
+func (db *DB) SetSearchRanker(ranker SearchRanker) {
+	db.rankerMu.Lock()
+	defer db.rankerMu.Unlock()
+	db.ranker = ranker
+}
+
+// searchRanker returns the ranker, or nil when none is set
+func (db *DB) searchRanker() SearchRanker {
+	db.rankerMu.RLock()
+	defer db.rankerMu.RUnlock()
+	return db.ranker
+}
+
+// candidateLimit returns how many FTS results to fetch for a search
+func (db *DB) candidateLimit(limit int, useSemanticRanking bool) int {
+	if useSemanticRanking && db.searchRanker() != nil {
+		return limit * rerankCandidates
+	}
+	return limit
+}
+
+// rerankResults orders results by the ranker's scores, keeping the FTS order
+// for equal scores, and cuts them to limit. Without a ranker (the index is
+// still being built) the FTS order is kept.
+func (db *DB) rerankResults(query string, results []*SearchResult, limit int, useSemanticRanking bool) []*SearchResult {
+	ranker := db.searchRanker()
+	if useSemanticRanking && ranker != nil {
+		scores := make(map[*SearchResult]float64, len(results))
+		for _, result := range results {
+			scores[result] = ranker.Score(query, result.Message.Content)
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return scores[results[i]] > scores[results[j]]
+		})
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
 
 // SearchResult represents a search result
 type SearchResult struct {
-	Message        *Message
-	ConversationID int64
-	Snippet        string
+	Message           *Message
+	ConversationID    int64
+	ConversationTitle string
+	// Snippet is the matched text in context, with the match wrapped in <mark>...</mark>
+	Snippet string
 }
 
-// SearchMessages performs full-text search on messages
-func (db *DB) SearchMessages(query string, limit int) ([]*SearchResult, error) {
+// SearchMessages performs full-text search on messages. With
+// useSemanticRanking the results are reranked by the search ranker.
+func (db *DB) SearchMessages(query string, limit int, useSemanticRanking bool) ([]*SearchResult, error) {
 	rows, err := db.conn.Query(`
 		SELECT m.id, m.conversation_id, m.role, m.content, m.original_content, m.provider, m.model, m.attachments, m.tokens_used, m.created_at,
-		       snippet(messages_fts, 0, '<mark>', '</mark>', '...', 32) as snippet
+		       c.title, snippet(messages_fts, 0, '<mark>', '</mark>', '...', 32) as snippet
 		FROM messages_fts
 		JOIN messages m ON messages_fts.rowid = m.id
-		WHERE messages_fts MATCH ?
+		JOIN conversations c ON m.conversation_id = c.id
+		WHERE messages_fts MATCH ? AND c.deleted_at IS NULL
 		ORDER BY rank
 		LIMIT ?
-	`, query, limit)
+	`, query, db.candidateLimit(limit, useSemanticRanking))
 	if err != nil {
 		return nil, fmt.Errorf("failed to search messages: %w", err)
 	}
@@ -28,30 +92,36 @@ func (db *DB) SearchMessages(query string, limit int) ([]*SearchResult, error) {
 	var results []*SearchResult
 	for rows.Next() {
 		var msg Message
-		var snippet string
-		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.OriginalContent, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt, &snippet); err != nil {
+		var title, snippet string
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.OriginalContent, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt, &title, &snippet); err != nil {
 			return nil, fmt.Errorf("failed to scan search result: %w", err)
 		}
+		// Prefer a fixed-width context window; fall back to the FTS snippet when
+		// the query does not literally occur in the content (e.g. prefix queries)
+		if s := buildSnippet(msg.Content, query); s != "" {
+			snippet = s
+		}
 		results = append(results, &SearchResult{
-			Message:        &msg,
-			ConversationID: msg.ConversationID,
-			Snippet:        snippet,
+			Message:           &msg,
+			ConversationID:    msg.ConversationID,
+			ConversationTitle: title,
+			Snippet:           snippet,
 		})
 	}
 
-	return results, nil
+	return db.rerankResults(query, results, limit, useSemanticRanking), nil
 }
 
 // SearchMessagesWithFilters performs full-text search with optional filters
-func (db *DB) SearchMessagesWithFilters(query string, provider string, category string, daysAgo int, limit int) ([]*SearchResult, error) {
+func (db *DB) SearchMessagesWithFilters(query string, provider string, category string, daysAgo int, limit int, useSemanticRanking bool) ([]*SearchResult, error) {
 	// Build query with filters
 	sqlQuery := `
 		SELECT m.id, m.conversation_id, m.role, m.content, m.original_content, m.provider, m.model, m.attachments, m.tokens_used, m.created_at,
-		       snippet(messages_fts, 0, '<mark>', '</mark>', '...', 32) as snippet
+		       c.title, snippet(messages_fts, 0, '<mark>', '</mark>', '...', 32) as snippet
 		FROM messages_fts
 		JOIN messages m ON messages_fts.rowid = m.id
 		JOIN conversations c ON m.conversation_id = c.id
-		WHERE messages_fts MATCH ?`
+		WHERE messages_fts MATCH ? AND c.deleted_at IS NULL`
 	
 	args := []interface{}{query}
 	
@@ -74,7 +144,7 @@ func (db *DB) SearchMessagesWithFilters(query string, provider string, category
 	}
 	
 	sqlQuery += " ORDER BY rank LIMIT ?"
-	args = append(args, limit)
+	args = append(args, db.candidateLimit(limit, useSemanticRanking))
 	
 	rows, err := db.conn.Query(sqlQuery, args...)
 	if err != nil {
@@ -85,24 +155,254 @@ func (db *DB) SearchMessagesWithFilters(query string, provider string, category
 	var results []*SearchResult
 	for rows.Next() {
 		var msg Message
-		var snippet string
-		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.OriginalContent, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt, &snippet); err != nil {
+		var title, snippet string
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.OriginalContent, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt, &title, &snippet); err != nil {
 			return nil, fmt.Errorf("failed to scan search result: %w", err)
 		}
+		// Prefer a fixed-width context window; fall back to the FTS snippet when
+		// the query does not literally occur in the content (e.g. prefix queries)
+		if s := buildSnippet(msg.Content, query); s != "" {
+			snippet = s
+		}
 		results = append(results, &SearchResult{
-			Message:        &msg,
-			ConversationID: msg.ConversationID,
-			Snippet:        snippet,
+			Message:           &msg,
+			ConversationID:    msg.ConversationID,
+			ConversationTitle: title,
+			Snippet:           snippet,
 		})
 	}
 
+	return db.rerankResults(query, results, limit, useSemanticRanking), nil
+}
+
+// SearchQuery selects the messages of a search. Every field that is set must
+// match; a query without Text lists all messages matching the filters.
+type SearchQuery struct {
+	Text           string // Full-text query in FTS5 syntax
+	Category       string // Category of the conversation
+	ProviderFilter string
+	ModelFilter    string
+	StartDate      time.Time // Messages created at or after
+	EndDate        time.Time // Messages created before
+	Role           string    // "user", "assistant" or "system"
+	Starred        bool      // Only starred messages
+}
+
+// IsEmpty reports whether the query neither searches text nor filters
+func (q SearchQuery) IsEmpty() bool {
+	return q == SearchQuery{}
+}
+
+// SearchConversations returns a page of the messages matching q, with the
+// conversations they belong to. Text matches are ordered by relevance, a
+// query without text lists the newest messages first.
+//
+// With a search ranker, the first rerankCandidates pages of text matches are
+// cut from the top FTS matches reranked together, so the order stays stable
+// across pages of the same limit; later pages keep the FTS order.
+func (db *DB) SearchConversations(q SearchQuery, limit, offset int) ([]*SearchResult, error) {
+	text := strings.TrimSpace(q.Text)
+	window := db.candidateLimit(limit, true)
+	rerank := text != "" && window > limit && offset+limit <= window
+
+	var sqlQuery strings.Builder
+	var args []interface{}
+	sqlQuery.WriteString(`
+		SELECT m.id, m.conversation_id, m.role, m.content, m.original_content, m.provider, m.model, m.attachments, m.tokens_used, m.created_at, COALESCE(m.starred, 0),
+		       c.title, `)
+	if text != "" {
+		sqlQuery.WriteString(`snippet(messages_fts, 0, '<mark>', '</mark>', '...', 32)
+		FROM messages_fts
+		JOIN messages m ON messages_fts.rowid = m.id
+		JOIN conversations c ON m.conversation_id = c.id
+		WHERE messages_fts MATCH ? AND c.deleted_at IS NULL`)
+		args = append(args, text)
+	} else {
+		sqlQuery.WriteString(`''
+		FROM messages m
+		JOIN conversations c ON m.conversation_id = c.id
+		WHERE c.deleted_at IS NULL`)
+	}
+
+	filters := []struct {
+		column string
+		value  string
+	}{
+		{"c.category", q.Category},
+		{"m.provider", q.ProviderFilter},
+		{"m.model", q.ModelFilter},
+		{"m.role", q.Role},
+	}
+	for _, filter := range filters {
+		if filter.value != "" {
+			sqlQuery.WriteString(" AND " + filter.column + " = ?")
+			args = append(args, filter.value)
+		}
+	}
+	if q.Starred {
+		sqlQuery.WriteString(" AND m.starred")
+	}
+	if !q.StartDate.IsZero() {
+		sqlQuery.WriteString(" AND m.created_at >= ?")
+		args = append(args, q.StartDate)
+	}
+	if !q.EndDate.IsZero() {
+		sqlQuery.WriteString(" AND m.created_at < ?")
+		args = append(args, q.EndDate)
+	}
+
+	if text != "" {
+		sqlQuery.WriteString(" ORDER BY rank, m.id")
+	} else {
+		sqlQuery.WriteString(" ORDER BY m.created_at DESC, m.id DESC")
+	}
+	sqlQuery.WriteString(" LIMIT ? OFFSET ?")
+	if rerank {
+		args = append(args, window, 0)
+	} else {
+		args = append(args, limit, offset)
+	}
+
+	rows, err := db.conn.Query(sqlQuery.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*SearchResult
+	for rows.Next() {
+		var msg Message
+		var title, snippet string
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.OriginalContent, &msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt, &msg.Starred, &title, &snippet); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		if text == "" {
+			snippet = previewSnippet(msg.Content)
+		} else if s := buildSnippet(msg.Content, text); s != "" {
+			snippet = s
+		}
+		results = append(results, &SearchResult{
+			Message:           &msg,
+			ConversationID:    msg.ConversationID,
+			ConversationTitle: title,
+			Snippet:           snippet,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search conversations: %w", err)
+	}
+
+	if rerank {
+		results = db.rerankResults(text, results, len(results), true)
+		if offset >= len(results) {
+			return nil, nil
+		}
+		results = results[offset:min(offset+limit, len(results))]
+	}
 	return results, nil
 }
 
+// previewSnippet returns the start of content on one line, for results
+// without a text match
+func previewSnippet(content string) string {
+	runes := []rune(strings.Join(strings.Fields(content), " "))
+	if len(runes) <= 2*snippetContextRunes {
+		return string(runes)
+	}
+	return string(runes[:2*snippetContextRunes]) + "..."
+}
+
+// buildSnippet returns the first occurrence of the query (or one of its terms)
+// in content with up to snippetContextRunes characters of context on each side.
+// It returns an empty string when nothing matches literally.
+func buildSnippet(content, query string) string {
+	runes := []rune(content)
+	lower := lowerRunes(content)
+
+	start, end := -1, -1
+	for _, term := range snippetTerms(query) {
+		if idx := indexRunes(lower, term); idx >= 0 {
+			start, end = idx, idx+len(term)
+			break
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+
+	from := start - snippetContextRunes
+	if from < 0 {
+		from = 0
+	}
+	to := end + snippetContextRunes
+	if to > len(runes) {
+		to = len(runes)
+	}
+
+	var sb strings.Builder
+	if from > 0 {
+		sb.WriteString("...")
+	}
+	sb.WriteString(string(runes[from:start]))
+	sb.WriteString("<mark>")
+	sb.WriteString(string(runes[start:end]))
+	sb.WriteString("</mark>")
+	sb.WriteString(string(runes[end:to]))
+	if to < len(runes) {
+		sb.WriteString("...")
+	}
+	// Keep the snippet on one line
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// snippetTerms returns the lowercased phrases to look for: the whole query
+// first, then its individual terms without FTS operators and syntax
+func snippetTerms(query string) [][]rune {
+	clean := strings.Trim(strings.TrimSpace(query), "\"*")
+	terms := [][]rune{lowerRunes(clean)}
+	for _, field := range strings.Fields(query) {
+		field = strings.Trim(field, "\"*()")
+		switch field {
+		case "", "AND", "OR", "NOT", "NEAR":
+			continue
+		}
+		terms = append(terms, lowerRunes(field))
+	}
+	return terms
+}
+
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// indexRunes returns the index of the first occurrence of sub in s, or -1
+func indexRunes(s, sub []rune) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	for i := 0; i+len(sub) <= len(s); i++ {
+		match := true
+		for j := range sub {
+			if s[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
 // SearchConversationsByCategory searches conversations by category
 func (db *DB) SearchConversationsByCategory(category string) ([]*Conversation, error) {
 	rows, err := db.conn.Query(
-		"SELECT id, title, category, created_at, updated_at FROM conversations WHERE category = ? ORDER BY updated_at DESC",
+		"SELECT id, title, category, created_at, updated_at FROM conversations WHERE category = ? AND deleted_at IS NULL ORDER BY updated_at DESC",
 		category,
 	)
 	if err != nil {
@@ -0,0 +1,233 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// searchCount returns the number of messages matching query
+func searchCount(t *testing.T, database *DB, query string) int {
+	t.Helper()
+	results, err := database.SearchMessages(query, 10, false)
+	if err != nil {
+		t.Fatalf("SearchMessages(%q) failed: %v", query, err)
+	}
+	return len(results)
+}
+
+func TestSearchIndex_EditAndDelete(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("search", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	msg, err := database.CreateMessage(conv.ID, "user", "apple banana", "", "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	if err := database.UpdateMessage(msg.ID, "cherry"); err != nil {
+		t.Fatalf("UpdateMessage failed: %v", err)
+	}
+	if n := searchCount(t, database, "apple"); n != 0 {
+		t.Errorf("edited message still matches its old content (%d results)", n)
+	}
+	if n := searchCount(t, database, "cherry"); n != 1 {
+		t.Errorf("got %d results for the new content, want 1", n)
+	}
+
+	if err := database.DeleteMessage(msg.ID); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+	if n := searchCount(t, database, "cherry"); n != 0 {
+		t.Errorf("deleted message still matches (%d results)", n)
+	}
+}
+
+func TestSearchIndex_MigratesOldTriggers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	database, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	conv, err := database.CreateConversation("old", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	msg, err := database.CreateMessage(conv.ID, "user", "apple", "", "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	// Recreate the state of an older database: the old triggers, an edit that
+	// left the index stale, and a message written before the index existed
+	for _, stmt := range []string{
+		`DROP TRIGGER messages_ad`,
+		`DROP TRIGGER messages_au`,
+		`CREATE TRIGGER messages_ad AFTER DELETE ON messages BEGIN
+			DELETE FROM messages_fts WHERE rowid = old.id;
+		END`,
+		`CREATE TRIGGER messages_au AFTER UPDATE ON messages BEGIN
+			UPDATE messages_fts SET content = new.content WHERE rowid = new.id;
+		END`,
+		fmt.Sprintf(`UPDATE messages SET content = 'cherry' WHERE id = %d`, msg.ID),
+		`DROP TRIGGER messages_ai`,
+		fmt.Sprintf(`INSERT INTO messages (conversation_id, role, content) VALUES (%d, 'user', 'durian')`, conv.ID),
+	} {
+		if _, err := database.conn.Exec(stmt); err != nil {
+			t.Fatalf("failed to set up old database: %v", err)
+		}
+	}
+	database.Close()
+
+	database, err = New(path)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer database.Close()
+
+	for query, want := range map[string]int{"apple": 0, "cherry": 1, "durian": 1} {
+		if n := searchCount(t, database, query); n != want {
+			t.Errorf("got %d results for %q after the migration, want %d", n, query, want)
+		}
+	}
+}
+
+func TestSearchConversations_Filters(t *testing.T) {
+	database := newTestDB(t)
+
+	work, err := database.CreateConversation("work", "Work")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	home, err := database.CreateConversation("home", "Home")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	messages := []struct {
+		conv            *Conversation
+		role, content   string
+		provider, model string
+		day             int
+	}{
+		{work, "user", "deploy the kubernetes cluster", "", "", 0},
+		{work, "assistant", "kubernetes deploy steps", "openai", "gpt-4o", 0},
+		{work, "assistant", "kubernetes rollback", "claude", "claude-3", 5},
+		{home, "user", "kubernetes at home?", "", "", 10},
+		{home, "assistant", "a recipe for bread", "openai", "gpt-4o-mini", 10},
+	}
+	for _, m := range messages {
+		msg, err := database.CreateMessage(m.conv.ID, m.role, m.content, m.provider, m.model, "", 0)
+		if err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		if _, err := database.conn.Exec("UPDATE messages SET created_at = ? WHERE id = ?", base.AddDate(0, 0, m.day), msg.ID); err != nil {
+			t.Fatalf("failed to set created_at: %v", err)
+		}
+	}
+
+	search := func(q SearchQuery, limit, offset int) []string {
+		t.Helper()
+		results, err := database.SearchConversations(q, limit, offset)
+		if err != nil {
+			t.Fatalf("SearchConversations(%+v) failed: %v", q, err)
+		}
+		var contents []string
+		for _, result := range results {
+			contents = append(contents, result.Message.Content)
+		}
+		return contents
+	}
+	tests := []struct {
+		query SearchQuery
+		want  int
+	}{
+		{SearchQuery{Text: "kubernetes"}, 4},
+		{SearchQuery{Text: "kubernetes", Category: "Work"}, 3},
+		{SearchQuery{Text: "kubernetes", ProviderFilter: "openai"}, 1},
+		{SearchQuery{Text: "kubernetes", ModelFilter: "claude-3"}, 1},
+		{SearchQuery{Text: "kubernetes", Role: "user"}, 2},
+		{SearchQuery{Text: "kubernetes", StartDate: base.AddDate(0, 0, 1)}, 2},
+		{SearchQuery{Text: "kubernetes", EndDate: base.AddDate(0, 0, 1)}, 2},
+		{SearchQuery{Text: "kubernetes", StartDate: base.AddDate(0, 0, 1), EndDate: base.AddDate(0, 0, 6)}, 1},
+		{SearchQuery{ProviderFilter: "openai"}, 2},
+		{SearchQuery{Text: "bread", Category: "Work"}, 0},
+	}
+	for _, tt := range tests {
+		if got := search(tt.query, 10, 0); len(got) != tt.want {
+			t.Errorf("SearchConversations(%+v) = %q, want %d results", tt.query, got, tt.want)
+		}
+	}
+
+	// Without text the newest messages come first, a page at a time
+	first := search(SearchQuery{Role: "assistant"}, 2, 0)
+	rest := search(SearchQuery{Role: "assistant"}, 2, 2)
+	if len(first) != 2 || first[0] != "a recipe for bread" || first[1] != "kubernetes rollback" {
+		t.Errorf("first page = %q", first)
+	}
+	if len(rest) != 1 || rest[0] != "kubernetes deploy steps" {
+		t.Errorf("second page = %q", rest)
+	}
+
+	results, err := database.SearchConversations(SearchQuery{Text: "rollback"}, 10, 0)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("SearchConversations = %v, %v", results, err)
+	}
+	if results[0].ConversationTitle != "work" || results[0].Snippet != "kubernetes <mark>rollback</mark>" {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+
+	// Only starred messages, with or without text
+	if err := database.ToggleMessageStar(results[0].Message.ID); err != nil {
+		t.Fatalf("ToggleMessageStar failed: %v", err)
+	}
+	if got := search(SearchQuery{Text: "kubernetes", Starred: true}, 10, 0); len(got) != 1 || got[0] != "kubernetes rollback" {
+		t.Errorf("starred search = %q, want the starred message", got)
+	}
+	if got := search(SearchQuery{Text: "bread", Starred: true}, 10, 0); len(got) != 0 {
+		t.Errorf("starred search = %q, want no results", got)
+	}
+	if got := search(SearchQuery{Starred: true}, 10, 0); len(got) != 1 {
+		t.Errorf("starred listing = %q, want 1 result", got)
+	}
+}
+
+func TestSearchConversations_Reranked(t *testing.T) {
+	database := newTestDB(t)
+	conv, err := database.CreateConversation("rerank", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for _, content := range []string{"go go go go", "go", "go go go go go", "go go", "go go go"} {
+		if _, err := database.CreateMessage(conv.ID, "user", content, "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+	database.SetSearchRanker(lengthRanker{})
+
+	// Pages are cut from the same reranked candidates, shortest first
+	var got []string
+	for offset := 0; ; offset += 2 {
+		results, err := database.SearchConversations(SearchQuery{Text: "go"}, 2, offset)
+		if err != nil {
+			t.Fatalf("SearchConversations failed: %v", err)
+		}
+		for _, result := range results {
+			got = append(got, result.Message.Content)
+		}
+		if len(results) < 2 {
+			break
+		}
+	}
+	want := []string{"go", "go go", "go go go", "go go go go", "go go go go go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reranked pages = %q, want %q", got, want)
+	}
+}
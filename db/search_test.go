@@ -0,0 +1,75 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildSnippet_ContextWindow(t *testing.T) {
+	content := strings.Repeat("a", 80) + " Needle " + strings.Repeat("b", 80)
+
+	snippet := buildSnippet(content, "needle")
+
+	want := "..." + strings.Repeat("a", 49) + " <mark>Needle</mark> " + strings.Repeat("b", 49) + "..."
+	if snippet != want {
+		t.Errorf("Unexpected snippet:\n got: %q\nwant: %q", snippet, want)
+	}
+}
+
+func TestBuildSnippet_MultiByte(t *testing.T) {
+	snippet := buildSnippet("今天我们讨论数据库索引的设计", "数据库")
+
+	if snippet != "今天我们讨论<mark>数据库</mark>索引的设计" {
+		t.Errorf("Unexpected snippet: %q", snippet)
+	}
+}
+
+func TestBuildSnippet_FallsBackToTerms(t *testing.T) {
+	snippet := buildSnippet("golang and sqlite", "rust OR sqlite")
+
+	if snippet != "golang and <mark>sqlite</mark>" {
+		t.Errorf("Unexpected snippet: %q", snippet)
+	}
+	if buildSnippet("golang", "python") != "" {
+		t.Error("Expected empty snippet when nothing matches")
+	}
+}
+
+// lengthRanker scores shorter content higher
+type lengthRanker struct{}
+
+func (lengthRanker) Score(query, content string) float64 { return -float64(len(content)) }
+
+func TestRerankResults(t *testing.T) {
+	newResults := func() []*SearchResult {
+		var results []*SearchResult
+		for _, content := range []string{"medium", "longest one", "short", "tiny"} {
+			results = append(results, &SearchResult{Message: &Message{Content: content}})
+		}
+		return results
+	}
+	contents := func(results []*SearchResult) string {
+		var parts []string
+		for _, r := range results {
+			parts = append(parts, r.Message.Content)
+		}
+		return strings.Join(parts, ",")
+	}
+
+	database := &DB{}
+	// Without a ranker the FTS order is kept
+	if got := contents(database.rerankResults("q", newResults(), 3, true)); got != "medium,longest one,short" {
+		t.Errorf("unranked results = %s", got)
+	}
+
+	database.SetSearchRanker(lengthRanker{})
+	if got := contents(database.rerankResults("q", newResults(), 3, true)); got != "tiny,short,medium" {
+		t.Errorf("reranked results = %s", got)
+	}
+	if got := contents(database.rerankResults("q", newResults(), 3, false)); got != "medium,longest one,short" {
+		t.Errorf("results without semantic ranking = %s", got)
+	}
+	if database.candidateLimit(10, true) != 10*rerankCandidates || database.candidateLimit(10, false) != 10 {
+		t.Error("expected more candidates to be fetched only for semantic ranking")
+	}
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AddCustomWord adds a word to the custom spell-check dictionary
+func (db *DB) AddCustomWord(word string) error {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return fmt.Errorf("word cannot be empty")
+	}
+
+	_, err := db.conn.Exec(
+		"INSERT OR IGNORE INTO spell_custom_words (word, created_at) VALUES (?, ?)",
+		word, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add custom word: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCustomWord removes a word from the custom spell-check dictionary
+func (db *DB) DeleteCustomWord(word string) error {
+	_, err := db.conn.Exec("DELETE FROM spell_custom_words WHERE word = ?", strings.ToLower(strings.TrimSpace(word)))
+	if err != nil {
+		return fmt.Errorf("failed to delete custom word: %w", err)
+	}
+
+	return nil
+}
+
+// ListCustomWords retrieves all custom spell-check words in alphabetical order
+func (db *DB) ListCustomWords() ([]string, error) {
+	rows, err := db.conn.Query("SELECT word FROM spell_custom_words ORDER BY word")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom words: %w", err)
+	}
+	defer rows.Close()
+
+	var words []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, fmt.Errorf("failed to scan custom word: %w", err)
+		}
+		words = append(words, word)
+	}
+
+	return words, rows.Err()
+}
@@ -0,0 +1,56 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCustomWords(t *testing.T) {
+	database := newTestDB(t)
+
+	words, err := database.ListCustomWords()
+	if err != nil {
+		t.Fatalf("ListCustomWords failed: %v", err)
+	}
+	if len(words) != 0 {
+		t.Fatalf("got words %q in a new database", words)
+	}
+
+	for _, word := range []string{"kubectl", " Goroutine ", "helmfile"} {
+		if err := database.AddCustomWord(word); err != nil {
+			t.Fatalf("AddCustomWord(%q) failed: %v", word, err)
+		}
+	}
+	// Adding a word again must not create a duplicate
+	if err := database.AddCustomWord("KUBECTL"); err != nil {
+		t.Fatalf("AddCustomWord failed: %v", err)
+	}
+	if err := database.AddCustomWord("  "); err == nil {
+		t.Fatal("AddCustomWord accepted an empty word")
+	}
+
+	words, err = database.ListCustomWords()
+	if err != nil {
+		t.Fatalf("ListCustomWords failed: %v", err)
+	}
+	if want := []string{"goroutine", "helmfile", "kubectl"}; !reflect.DeepEqual(words, want) {
+		t.Fatalf("ListCustomWords = %q, want %q", words, want)
+	}
+
+	if err := database.DeleteCustomWord(" Helmfile"); err != nil {
+		t.Fatalf("DeleteCustomWord failed: %v", err)
+	}
+	// Deleting a word that is not there is not an error
+	if err := database.DeleteCustomWord("missing"); err != nil {
+		t.Fatalf("DeleteCustomWord failed: %v", err)
+	}
+	words, err = database.ListCustomWords()
+	if err != nil {
+		t.Fatalf("ListCustomWords failed: %v", err)
+	}
+	if want := []string{"goroutine", "kubectl"}; !reflect.DeepEqual(words, want) {
+		t.Fatalf("ListCustomWords after delete = %q, want %q", words, want)
+	}
+}
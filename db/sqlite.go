@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -12,8 +13,29 @@ import (
 // DB wraps the SQLite database connection
 type DB struct {
 	conn *sql.DB
+
+	// Reranks search results, set once the search index is built
+	rankerMu sync.RWMutex
+	ranker   SearchRanker
 }
 
+// Triggers removing deleted and edited messages from the search index.
+// messages_fts reads its content from messages, so the old content has to be
+// passed with the 'delete' command: by the time the triggers run, messages
+// holds the new content or nothing.
+const (
+	ftsDeleteTrigger = `CREATE TRIGGER IF NOT EXISTS messages_ad AFTER DELETE ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, content, conversation_id)
+			VALUES ('delete', old.id, old.content, old.conversation_id);
+		END`
+	ftsUpdateTrigger = `CREATE TRIGGER IF NOT EXISTS messages_au AFTER UPDATE OF content ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, content, conversation_id)
+			VALUES ('delete', old.id, old.content, old.conversation_id);
+			INSERT INTO messages_fts(rowid, content, conversation_id)
+			VALUES (new.id, new.content, new.conversation_id);
+		END`
+)
+
 // New creates a new database connection
 func New(dbPath string) (*DB, error) {
 	// Ensure the directory exists
@@ -56,6 +78,7 @@ func (db *DB) migrate() error {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			title TEXT NOT NULL,
 			category TEXT DEFAULT '',
+			parent_id INTEGER DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
@@ -71,6 +94,8 @@ func (db *DB) migrate() error {
 			model TEXT DEFAULT '',
 			attachments TEXT DEFAULT '',
 			tokens_used INTEGER DEFAULT 0,
+			metadata TEXT DEFAULT '',
+			starred BOOLEAN DEFAULT FALSE,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY(conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
 		)`,
@@ -82,6 +107,75 @@ func (db *DB) migrate() error {
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
 
+		// Custom spell-check dictionary words
+		`CREATE TABLE IF NOT EXISTS spell_custom_words (
+			word TEXT PRIMARY KEY,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Tags attached to messages
+		`CREATE TABLE IF NOT EXISTS message_tags (
+			message_id INTEGER NOT NULL,
+			tag TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY(message_id, tag),
+			FOREIGN KEY(message_id) REFERENCES messages(id) ON DELETE CASCADE
+		)`,
+
+		// Previous contents of edited messages
+		`CREATE TABLE IF NOT EXISTS message_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			message_id INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(message_id) REFERENCES messages(id) ON DELETE CASCADE
+		)`,
+
+		// Pairs of system-prompt variants compared in A/B tests
+		`CREATE TABLE IF NOT EXISTS prompt_ab_pairs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		`CREATE TABLE IF NOT EXISTS prompt_variants (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			pair_id INTEGER NOT NULL,
+			label TEXT NOT NULL,
+			system_prompt TEXT NOT NULL,
+			FOREIGN KEY(pair_id) REFERENCES prompt_ab_pairs(id) ON DELETE CASCADE
+		)`,
+
+		// Conversations run as a test of a variant, with the user's rating (0 = not rated)
+		`CREATE TABLE IF NOT EXISTS prompt_ab_tests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			variant_id INTEGER NOT NULL,
+			conversation_id INTEGER NOT NULL UNIQUE,
+			user_rating INTEGER DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(variant_id) REFERENCES prompt_variants(id) ON DELETE CASCADE,
+			FOREIGN KEY(conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+		)`,
+
+		// Frequent phrases of a conversation, see AnalyzeConversationTopics
+		`CREATE TABLE IF NOT EXISTS conversation_topics (
+			conversation_id INTEGER NOT NULL,
+			topic TEXT NOT NULL,
+			score REAL NOT NULL,
+			PRIMARY KEY(conversation_id, topic),
+			FOREIGN KEY(conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+		)`,
+
+		// Reusable prompts with {{variable}} placeholders, see prompt_templates.go
+		`CREATE TABLE IF NOT EXISTS prompt_templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			category TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL,
+			variables TEXT NOT NULL DEFAULT '[]',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
 		// FTS5 virtual table for full-text search
 		`CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
 			content,
@@ -96,18 +190,35 @@ func (db *DB) migrate() error {
 			VALUES (new.id, new.content, new.conversation_id);
 		END`,
 
-		`CREATE TRIGGER IF NOT EXISTS messages_ad AFTER DELETE ON messages BEGIN
-			DELETE FROM messages_fts WHERE rowid = old.id;
+		ftsDeleteTrigger,
+
+		// Foreign keys are not enforced, so drop the tags of deleted messages here
+		`CREATE TRIGGER IF NOT EXISTS messages_tags_ad AFTER DELETE ON messages BEGIN
+			DELETE FROM message_tags WHERE message_id = old.id;
+		END`,
+
+		`CREATE TRIGGER IF NOT EXISTS messages_versions_ad AFTER DELETE ON messages BEGIN
+			DELETE FROM message_versions WHERE message_id = old.id;
 		END`,
 
-		`CREATE TRIGGER IF NOT EXISTS messages_au AFTER UPDATE ON messages BEGIN
-			UPDATE messages_fts SET content = new.content WHERE rowid = new.id;
+		`CREATE TRIGGER IF NOT EXISTS conversations_ab_tests_ad AFTER DELETE ON conversations BEGIN
+			DELETE FROM prompt_ab_tests WHERE conversation_id = old.id;
 		END`,
 
+		`CREATE TRIGGER IF NOT EXISTS conversations_topics_ad AFTER DELETE ON conversations BEGIN
+			DELETE FROM conversation_topics WHERE conversation_id = old.id;
+		END`,
+
+		ftsUpdateTrigger,
+
 		// Indexes for better performance
 		`CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages(conversation_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_messages_conversation_created ON messages(conversation_id, created_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_conversations_updated_at ON conversations(updated_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_tags_tag ON message_tags(tag)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_versions_message_id ON message_versions(message_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_prompt_variants_pair_id ON prompt_variants(pair_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_prompt_ab_tests_variant_id ON prompt_ab_tests(variant_id)`,
 	}
 
 	for _, migration := range migrations {
@@ -124,7 +235,8 @@ func (db *DB) migrate() error {
 	return nil
 }
 
-// runAdditionalMigrations runs migrations for existing databases
+// runAdditionalMigrations runs migrations for existing databases. Progress
+// goes to stderr, stdout carries the results of batch and headless runs.
 func (db *DB) runAdditionalMigrations() error {
 	// Check if original_content column exists
 	var columnExists bool
@@ -140,7 +252,159 @@ func (db *DB) runAdditionalMigrations() error {
 		if _, err := db.conn.Exec(`ALTER TABLE messages ADD COLUMN original_content TEXT DEFAULT ''`); err != nil {
 			return fmt.Errorf("failed to add original_content column: %w", err)
 		}
-		fmt.Println("Added original_content column to messages table")
+		fmt.Fprintln(os.Stderr, "Added original_content column to messages table")
+	}
+
+	// Check if parent_id column exists
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('conversations') WHERE name = 'parent_id'
+	`).Scan(&columnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check if parent_id column exists: %w", err)
+	}
+
+	if !columnExists {
+		if _, err := db.conn.Exec(`ALTER TABLE conversations ADD COLUMN parent_id INTEGER DEFAULT 0`); err != nil {
+			return fmt.Errorf("failed to add parent_id column: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Added parent_id column to conversations table")
+	}
+
+	// Check if metadata column exists
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('messages') WHERE name = 'metadata'
+	`).Scan(&columnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check if metadata column exists: %w", err)
+	}
+
+	if !columnExists {
+		if _, err := db.conn.Exec(`ALTER TABLE messages ADD COLUMN metadata TEXT DEFAULT ''`); err != nil {
+			return fmt.Errorf("failed to add metadata column: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Added metadata column to messages table")
+	}
+
+	// Check if system_prompt column exists
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('conversations') WHERE name = 'system_prompt'
+	`).Scan(&columnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check if system_prompt column exists: %w", err)
+	}
+
+	if !columnExists {
+		if _, err := db.conn.Exec(`ALTER TABLE conversations ADD COLUMN system_prompt TEXT DEFAULT ''`); err != nil {
+			return fmt.Errorf("failed to add system_prompt column: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Added system_prompt column to conversations table")
+	}
+
+	// Check if params_override column exists
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('conversations') WHERE name = 'params_override'
+	`).Scan(&columnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check if params_override column exists: %w", err)
+	}
+
+	if !columnExists {
+		if _, err := db.conn.Exec(`ALTER TABLE conversations ADD COLUMN params_override TEXT DEFAULT ''`); err != nil {
+			return fmt.Errorf("failed to add params_override column: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Added params_override column to conversations table")
+	}
+
+	// Check if pinned column exists
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('conversations') WHERE name = 'pinned'
+	`).Scan(&columnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check if pinned column exists: %w", err)
+	}
+
+	if !columnExists {
+		if _, err := db.conn.Exec(`ALTER TABLE conversations ADD COLUMN pinned INTEGER DEFAULT 0`); err != nil {
+			return fmt.Errorf("failed to add pinned column: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Added pinned column to conversations table")
+	}
+
+	// Check if deleted_at column exists
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('conversations') WHERE name = 'deleted_at'
+	`).Scan(&columnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check if deleted_at column exists: %w", err)
+	}
+
+	if !columnExists {
+		if _, err := db.conn.Exec(`ALTER TABLE conversations ADD COLUMN deleted_at TIMESTAMP`); err != nil {
+			return fmt.Errorf("failed to add deleted_at column: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Added deleted_at column to conversations table")
+	}
+
+	// Check if starred column exists
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('messages') WHERE name = 'starred'
+	`).Scan(&columnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check if starred column exists: %w", err)
+	}
+
+	if !columnExists {
+		if _, err := db.conn.Exec(`ALTER TABLE messages ADD COLUMN starred BOOLEAN DEFAULT FALSE`); err != nil {
+			return fmt.Errorf("failed to add starred column: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Added starred column to messages table")
+	}
+
+	return db.migrateSearchIndex()
+}
+
+// migrateSearchIndex replaces the search index triggers of older databases,
+// which issued plain DELETE and UPDATE statements against messages_fts. Those
+// can't remove the old content of a message, so edited and deleted messages
+// kept matching it. The index is rebuilt when the triggers are replaced and
+// when it is missing messages, e.g. ones written before it existed.
+func (db *DB) migrateSearchIndex() error {
+	var outdated int
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'trigger' AND name IN ('messages_ad', 'messages_au') AND sql NOT LIKE '%''delete''%'
+	`).Scan(&outdated)
+	if err != nil {
+		return fmt.Errorf("failed to check search index triggers: %w", err)
+	}
+
+	if outdated > 0 {
+		for _, stmt := range []string{
+			`DROP TRIGGER IF EXISTS messages_ad`,
+			`DROP TRIGGER IF EXISTS messages_au`,
+			ftsDeleteTrigger,
+			ftsUpdateTrigger,
+		} {
+			if _, err := db.conn.Exec(stmt); err != nil {
+				return fmt.Errorf("failed to replace search index triggers: %w", err)
+			}
+		}
+		fmt.Fprintln(os.Stderr, "Replaced search index triggers")
+	}
+
+	var messages, indexed int
+	err = db.conn.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM messages), (SELECT COUNT(*) FROM messages_fts_docsize)
+	`).Scan(&messages, &indexed)
+	if err != nil {
+		return fmt.Errorf("failed to count indexed messages: %w", err)
+	}
+
+	if outdated > 0 || messages != indexed {
+		if _, err := db.conn.Exec(`INSERT INTO messages_fts(messages_fts) VALUES ('rebuild')`); err != nil {
+			return fmt.Errorf("failed to rebuild search index: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Rebuilt search index of %d messages\n", messages)
 	}
 
 	return nil
@@ -153,18 +417,19 @@ type DBStats struct {
 	DBSizeBytes       int64
 }
 
-// GetStats returns database statistics
+// GetStats returns database statistics. The counts leave out the recycle
+// bin, the size includes it.
 func (db *DB) GetStats() (*DBStats, error) {
 	stats := &DBStats{}
 	
 	// Get conversation count
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM conversations").Scan(&stats.ConversationCount)
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM conversations WHERE deleted_at IS NULL").Scan(&stats.ConversationCount)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count conversations: %w", err)
 	}
 	
 	// Get message count
-	err = db.conn.QueryRow("SELECT COUNT(*) FROM messages").Scan(&stats.MessageCount)
+	err = db.conn.QueryRow("SELECT COUNT(*) FROM messages WHERE conversation_id IN (SELECT id FROM conversations WHERE deleted_at IS NULL)").Scan(&stats.MessageCount)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count messages: %w", err)
 	}
@@ -194,3 +459,12 @@ func (db *DB) Vacuum() error {
 	}
 	return nil
 }
+
+// Backup writes a consistent snapshot of the database to destPath.
+// destPath must not exist yet.
+func (db *DB) Backup(destPath string) error {
+	if _, err := db.conn.Exec("VACUUM INTO ?", destPath); err != nil {
+		return fmt.Errorf("failed to backup database: %w", err)
+	}
+	return nil
+}
@@ -1,8 +1,11 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 // UsageStats represents token usage statistics
@@ -46,8 +49,125 @@ type MonthlyUsageStats struct {
 	MessageCount int64
 }
 
+// SummaryStats is an all-time overview of the database
+type SummaryStats struct {
+	TotalConversations         int64
+	TotalMessages              int64
+	UniqueProviders            int64
+	UniqueModels               int64
+	AvgMessagesPerConversation float64
+	LongestConversationID      int64 // 0 when there are no messages
+	MostUsedProvider           string
+	MostUsedModel              string
+	OldestConversationDate     time.Time
+	NewestConversationDate     time.Time
+}
+
+// ComputeConversationSummaryStats returns the overview in a single query.
+// Conversations in the recycle bin and their messages are left out.
+// Providers and models are counted over messages that name one; the most
+// used is the one with the most messages, ties broken alphabetically.
+func (db *DB) ComputeConversationSummaryStats() (*SummaryStats, error) {
+	query := `
+		WITH live AS (
+			SELECT id, created_at FROM conversations WHERE deleted_at IS NULL
+		),
+		live_messages AS (
+			SELECT conversation_id, provider, model FROM messages
+			WHERE conversation_id IN (SELECT id FROM live)
+		),
+		conv AS (
+			SELECT COUNT(*) AS total, MIN(created_at) AS oldest, MAX(created_at) AS newest
+			FROM live
+		),
+		msg AS (
+			SELECT COUNT(*) AS total,
+				COUNT(DISTINCT NULLIF(provider, '')) AS providers,
+				COUNT(DISTINCT NULLIF(model, '')) AS models
+			FROM live_messages
+		),
+		longest AS (
+			SELECT conversation_id FROM live_messages
+			GROUP BY conversation_id
+			ORDER BY COUNT(*) DESC, conversation_id ASC
+			LIMIT 1
+		),
+		top_provider AS (
+			SELECT provider FROM live_messages
+			WHERE provider != ''
+			GROUP BY provider
+			ORDER BY COUNT(*) DESC, provider ASC
+			LIMIT 1
+		),
+		top_model AS (
+			SELECT model FROM live_messages
+			WHERE model != ''
+			GROUP BY model
+			ORDER BY COUNT(*) DESC, model ASC
+			LIMIT 1
+		)
+		SELECT
+			conv.total,
+			msg.total,
+			msg.providers,
+			msg.models,
+			CASE WHEN conv.total > 0 THEN CAST(msg.total AS REAL) / conv.total ELSE 0 END,
+			COALESCE((SELECT conversation_id FROM longest), 0),
+			COALESCE((SELECT provider FROM top_provider), ''),
+			COALESCE((SELECT model FROM top_model), ''),
+			conv.oldest,
+			conv.newest
+		FROM conv, msg
+	`
+
+	var stats SummaryStats
+	var oldest, newest sql.NullString
+	err := db.conn.QueryRow(query).Scan(
+		&stats.TotalConversations,
+		&stats.TotalMessages,
+		&stats.UniqueProviders,
+		&stats.UniqueModels,
+		&stats.AvgMessagesPerConversation,
+		&stats.LongestConversationID,
+		&stats.MostUsedProvider,
+		&stats.MostUsedModel,
+		&oldest,
+		&newest,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute summary stats: %w", err)
+	}
+
+	// MIN and MAX lose the column type, so the driver returns the stored text
+	stats.OldestConversationDate = parseSQLiteTime(oldest.String)
+	stats.NewestConversationDate = parseSQLiteTime(newest.String)
+
+	return &stats, nil
+}
+
+// parseSQLiteTime parses a timestamp as stored by the driver, returning the
+// zero time for empty or unknown values
+func parseSQLiteTime(value string) time.Time {
+	for _, layout := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
 // GetUsageStats returns comprehensive usage statistics
 func (db *DB) GetUsageStats(startDate, endDate time.Time) (*UsageStats, error) {
+	return db.getUsageStats("created_at >= ? AND created_at <= ?", startDate, endDate)
+}
+
+// GetConversationUsageStats returns the usage statistics of one conversation
+func (db *DB) GetConversationUsageStats(conversationID int64) (*UsageStats, error) {
+	return db.getUsageStats("conversation_id = ?", conversationID)
+}
+
+// getUsageStats aggregates the messages matching the filter condition
+func (db *DB) getUsageStats(filter string, args ...interface{}) (*UsageStats, error) {
 	stats := &UsageStats{
 		ProviderStats: make(map[string]*ProviderUsageStats),
 		ModelStats:    make(map[string]*ModelUsageStats),
@@ -59,9 +179,9 @@ func (db *DB) GetUsageStats(startDate, endDate time.Time) (*UsageStats, error) {
 			COALESCE(SUM(tokens_used), 0) as total_tokens,
 			COUNT(*) as total_messages
 		FROM messages
-		WHERE created_at >= ? AND created_at <= ?
+		WHERE ` + filter + `
 	`
-	err := db.conn.QueryRow(query, startDate, endDate).Scan(&stats.TotalTokens, &stats.TotalMessages)
+	err := db.conn.QueryRow(query, args...).Scan(&stats.TotalTokens, &stats.TotalMessages)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total stats: %w", err)
 	}
@@ -73,11 +193,11 @@ func (db *DB) GetUsageStats(startDate, endDate time.Time) (*UsageStats, error) {
 			COALESCE(SUM(tokens_used), 0) as total_tokens,
 			COUNT(*) as message_count
 		FROM messages
-		WHERE created_at >= ? AND created_at <= ?
+		WHERE ` + filter + `
 		GROUP BY provider
 		ORDER BY total_tokens DESC
 	`
-	rows, err := db.conn.Query(providerQuery, startDate, endDate)
+	rows, err := db.conn.Query(providerQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get provider stats: %w", err)
 	}
@@ -106,11 +226,11 @@ func (db *DB) GetUsageStats(startDate, endDate time.Time) (*UsageStats, error) {
 			COALESCE(SUM(tokens_used), 0) as total_tokens,
 			COUNT(*) as message_count
 		FROM messages
-		WHERE created_at >= ? AND created_at <= ?
+		WHERE ` + filter + `
 		GROUP BY provider, model
 		ORDER BY total_tokens DESC
 	`
-	rows, err = db.conn.Query(modelQuery, startDate, endDate)
+	rows, err = db.conn.Query(modelQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get model stats: %w", err)
 	}
@@ -140,11 +260,11 @@ func (db *DB) GetUsageStats(startDate, endDate time.Time) (*UsageStats, error) {
 			COALESCE(SUM(tokens_used), 0) as total_tokens,
 			COUNT(*) as message_count
 		FROM messages
-		WHERE created_at >= ? AND created_at <= ?
+		WHERE ` + filter + `
 		GROUP BY DATE(created_at)
 		ORDER BY date ASC
 	`
-	rows, err = db.conn.Query(dailyQuery, startDate, endDate)
+	rows, err = db.conn.Query(dailyQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily stats: %w", err)
 	}
@@ -176,11 +296,11 @@ func (db *DB) GetUsageStats(startDate, endDate time.Time) (*UsageStats, error) {
 			COALESCE(SUM(tokens_used), 0) as total_tokens,
 			COUNT(*) as message_count
 		FROM messages
-		WHERE created_at >= ? AND created_at <= ?
+		WHERE ` + filter + `
 		GROUP BY strftime('%Y-%m', created_at)
 		ORDER BY month ASC
 	`
-	rows, err = db.conn.Query(monthlyQuery, startDate, endDate)
+	rows, err = db.conn.Query(monthlyQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get monthly stats: %w", err)
 	}
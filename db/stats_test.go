@@ -0,0 +1,119 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetConversationUsageStats(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("test", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	other, err := database.CreateConversation("other", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+
+	for _, m := range []struct {
+		convID int64
+		model  string
+		tokens int
+	}{
+		{conv.ID, "gpt-4o", 100},
+		{conv.ID, "gpt-4o-mini", 50},
+		{other.ID, "gpt-4o", 1000},
+	} {
+		if _, err := database.CreateMessage(m.convID, "assistant", "answer", "openai", m.model, "", m.tokens); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	stats, err := database.GetConversationUsageStats(conv.ID)
+	if err != nil {
+		t.Fatalf("GetConversationUsageStats failed: %v", err)
+	}
+	if stats.TotalTokens != 150 || stats.TotalMessages != 2 {
+		t.Errorf("totals = %d tokens, %d messages, want 150, 2", stats.TotalTokens, stats.TotalMessages)
+	}
+	if got := stats.ProviderStats["openai"].TotalTokens; got != 150 {
+		t.Errorf("openai tokens = %d, want 150", got)
+	}
+	if len(stats.ModelStats) != 2 {
+		t.Errorf("len(ModelStats) = %d, want 2", len(stats.ModelStats))
+	}
+	if len(stats.DailyStats) != 1 {
+		t.Errorf("len(DailyStats) = %d, want 1", len(stats.DailyStats))
+	}
+}
+
+func TestComputeConversationSummaryStats(t *testing.T) {
+	database := newTestDB(t)
+
+	empty, err := database.ComputeConversationSummaryStats()
+	if err != nil {
+		t.Fatalf("ComputeConversationSummaryStats on an empty database failed: %v", err)
+	}
+	if *empty != (SummaryStats{}) {
+		t.Errorf("empty database stats = %+v, want zero values", empty)
+	}
+
+	oldest := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	newest := time.Date(2024, 6, 7, 8, 9, 10, 0, time.UTC)
+	fixture := []struct {
+		created  time.Time
+		messages [][2]string // provider, model
+	}{
+		{oldest, [][2]string{{"", ""}, {"openai", "gpt-4o"}}},
+		{newest, [][2]string{{"", ""}, {"claude", "claude-3-haiku"}, {"", ""}, {"openai", "gpt-4o"}, {"", ""}, {"openai", "gpt-4o-mini"}}},
+		{oldest.AddDate(0, 1, 0), nil},
+	}
+	var longestID int64
+	for i, conv := range fixture {
+		c, err := database.CreateConversation("conv", "")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		if _, err := database.conn.Exec("UPDATE conversations SET created_at = ? WHERE id = ?", conv.created, c.ID); err != nil {
+			t.Fatalf("failed to set created_at: %v", err)
+		}
+		for _, m := range conv.messages {
+			if _, err := database.CreateMessage(c.ID, "user", "text", m[0], m[1], "", 0); err != nil {
+				t.Fatalf("CreateMessage failed: %v", err)
+			}
+		}
+		if i == 1 {
+			longestID = c.ID
+		}
+	}
+
+	stats, err := database.ComputeConversationSummaryStats()
+	if err != nil {
+		t.Fatalf("ComputeConversationSummaryStats failed: %v", err)
+	}
+	want := SummaryStats{
+		TotalConversations:         3,
+		TotalMessages:              8,
+		UniqueProviders:            2,
+		UniqueModels:               3,
+		AvgMessagesPerConversation: 8.0 / 3,
+		LongestConversationID:      longestID,
+		MostUsedProvider:           "openai",
+		MostUsedModel:              "gpt-4o",
+	}
+	got := *stats
+	got.OldestConversationDate, got.NewestConversationDate = time.Time{}, time.Time{}
+	if got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+	if !stats.OldestConversationDate.Equal(oldest) {
+		t.Errorf("OldestConversationDate = %v, want %v", stats.OldestConversationDate, oldest)
+	}
+	if !stats.NewestConversationDate.Equal(newest) {
+		t.Errorf("NewestConversationDate = %v, want %v", stats.NewestConversationDate, newest)
+	}
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TaggedMessage is a tagged message together with its tag and conversation title
+type TaggedMessage struct {
+	Tag               string
+	Message           *Message
+	ConversationTitle string
+}
+
+// ParseTags splits comma-separated tag input into unique, trimmed tags.
+// Both ASCII and full-width commas are accepted.
+func ParseTags(input string) []string {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == '，'
+	})
+
+	seen := make(map[string]bool)
+	var tags []string
+	for _, field := range fields {
+		tag := strings.TrimSpace(field)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// TagMessage adds tags to a message. The tag may contain several
+// comma-separated tags; tags the message already has are ignored.
+func (db *DB) TagMessage(messageID int64, tag string) error {
+	tags := ParseTags(tag)
+	if len(tags) == 0 {
+		return fmt.Errorf("tag cannot be empty")
+	}
+
+	now := time.Now()
+	for _, t := range tags {
+		_, err := db.conn.Exec(
+			"INSERT OR IGNORE INTO message_tags (message_id, tag, created_at) VALUES (?, ?, ?)",
+			messageID, t, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to tag message: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UntagMessage removes a tag from a message
+func (db *DB) UntagMessage(messageID int64, tag string) error {
+	_, err := db.conn.Exec("DELETE FROM message_tags WHERE message_id = ? AND tag = ?", messageID, strings.TrimSpace(tag))
+	if err != nil {
+		return fmt.Errorf("failed to untag message: %w", err)
+	}
+
+	return nil
+}
+
+// SetMessageTags replaces all tags of a message
+func (db *DB) SetMessageTags(messageID int64, tags []string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM message_tags WHERE message_id = ?", messageID); err != nil {
+		return fmt.Errorf("failed to clear message tags: %w", err)
+	}
+
+	now := time.Now()
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		_, err := tx.Exec(
+			"INSERT OR IGNORE INTO message_tags (message_id, tag, created_at) VALUES (?, ?, ?)",
+			messageID, tag, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to tag message: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListMessageTags retrieves the tags of a message in the order they were added
+func (db *DB) ListMessageTags(messageID int64) ([]string, error) {
+	rows, err := db.conn.Query("SELECT tag FROM message_tags WHERE message_id = ? ORDER BY created_at, tag", messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// ListConversationTags retrieves the tags of every tagged message in a conversation, keyed by message ID
+func (db *DB) ListConversationTags(conversationID int64) (map[int64][]string, error) {
+	rows, err := db.conn.Query(`
+		SELECT t.message_id, t.tag
+		FROM message_tags t
+		JOIN messages m ON m.id = t.message_id
+		WHERE m.conversation_id = ?
+		ORDER BY t.created_at, t.tag
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[int64][]string)
+	for rows.Next() {
+		var messageID int64
+		var tag string
+		if err := rows.Scan(&messageID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags[messageID] = append(tags[messageID], tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// ListTags retrieves all tags in use in alphabetical order
+func (db *DB) ListTags() ([]string, error) {
+	rows, err := db.conn.Query("SELECT DISTINCT tag FROM message_tags ORDER BY tag")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// ListTaggedMessages retrieves all tagged messages ordered by tag, newest first within a tag
+func (db *DB) ListTaggedMessages() ([]*TaggedMessage, error) {
+	rows, err := db.conn.Query(`
+		SELECT t.tag, m.id, m.conversation_id, m.role, m.content, m.original_content,
+		       m.provider, m.model, m.attachments, m.tokens_used, m.created_at, c.title
+		FROM message_tags t
+		JOIN messages m ON m.id = t.message_id
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE c.deleted_at IS NULL
+		ORDER BY t.tag, m.created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tagged messages: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*TaggedMessage
+	for rows.Next() {
+		msg := &Message{}
+		result := &TaggedMessage{Message: msg}
+		err := rows.Scan(
+			&result.Tag, &msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.OriginalContent,
+			&msg.Provider, &msg.Model, &msg.Attachments, &msg.TokensUsed, &msg.CreatedAt, &result.ConversationTitle,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tagged message: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// ListConversationIDsByTag retrieves the IDs of conversations with at least one message carrying tag
+func (db *DB) ListConversationIDsByTag(tag string) (map[int64]bool, error) {
+	rows, err := db.conn.Query(`
+		SELECT DISTINCT m.conversation_id
+		FROM message_tags t
+		JOIN messages m ON m.id = t.message_id
+		WHERE t.tag = ?
+	`, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations by tag: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation id: %w", err)
+		}
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,82 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags_SplitsAndDeduplicates(t *testing.T) {
+	got := ParseTags(" 重要 , golang，重要,, ")
+	want := []string{"重要", "golang"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseTags = %q, want %q", got, want)
+	}
+}
+
+func TestTagMessage_ListAndFilter(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("tagged", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	other, err := database.CreateConversation("other", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	msg, err := database.CreateMessage(conv.ID, "assistant", "answer", "", "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	if _, err := database.CreateMessage(other.ID, "assistant", "other answer", "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	if err := database.TagMessage(msg.ID, "go, 重要"); err != nil {
+		t.Fatalf("TagMessage failed: %v", err)
+	}
+	// Tagging again must not create duplicates
+	if err := database.TagMessage(msg.ID, "go"); err != nil {
+		t.Fatalf("TagMessage failed: %v", err)
+	}
+	if err := database.TagMessage(msg.ID, " , "); err == nil {
+		t.Fatal("TagMessage accepted an empty tag")
+	}
+
+	tags, err := database.ListMessageTags(msg.ID)
+	if err != nil {
+		t.Fatalf("ListMessageTags failed: %v", err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("got tags %q, want 2 tags", tags)
+	}
+
+	tagged, err := database.ListTaggedMessages()
+	if err != nil {
+		t.Fatalf("ListTaggedMessages failed: %v", err)
+	}
+	if len(tagged) != 2 || tagged[0].Tag != "go" || tagged[0].ConversationTitle != "tagged" {
+		t.Fatalf("unexpected tagged messages: %+v", tagged)
+	}
+
+	ids, err := database.ListConversationIDsByTag("重要")
+	if err != nil {
+		t.Fatalf("ListConversationIDsByTag failed: %v", err)
+	}
+	if len(ids) != 1 || !ids[conv.ID] {
+		t.Fatalf("got conversations %v, want only %d", ids, conv.ID)
+	}
+
+	if err := database.DeleteMessage(msg.ID); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+	all, err := database.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if len(all) != 0 {
+		t.Fatalf("tags of deleted message remain: %q", all)
+	}
+}
@@ -0,0 +1,307 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MaxConversationTopics is the number of topics kept per conversation
+const MaxConversationTopics = 10
+
+// maxTopicWords is the length limit of the phrases counted as topics
+const maxTopicWords = 4
+
+// minTopicCount is how often a phrase must occur to be a topic
+const minTopicCount = 2
+
+// properNounBoost raises the score of phrases with a capitalized word in the
+// middle of a sentence, which are most likely names
+const properNounBoost = 1.5
+
+// stopwordsData is a list of common English words, one per line, which are
+// never part of a topic
+//
+//go:embed stopwords_en.txt
+var stopwordsData []byte
+
+// stopwords is stopwordsData as a set
+var stopwords = func() map[string]bool {
+	words := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(stopwordsData))
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			words[word] = true
+		}
+	}
+	return words
+}()
+
+// ConversationTopic is a frequent phrase of a conversation
+type ConversationTopic struct {
+	Topic string
+	Score float64
+}
+
+// topicToken is a word of the analyzed text
+type topicToken struct {
+	text   string // as written, without surrounding punctuation
+	key    string // lower case
+	proper bool   // capitalized but not the first word of a sentence
+}
+
+// topicCandidate counts the occurrences of a phrase
+type topicCandidate struct {
+	text   string
+	words  int
+	count  int
+	proper bool
+}
+
+// ExtractTopics returns up to limit frequent noun phrases of text, highest
+// score first. It counts phrases of 2 to 4 words that don't contain common
+// words, plus single capitalized words (proper nouns), and keeps those that
+// occur at least twice. Fenced code blocks are ignored. The score is the
+// number of occurrences, raised for proper nouns. Only whitespace-separated
+// languages are supported.
+func ExtractTopics(text string, limit int) []ConversationTopic {
+	candidates := make(map[string]*topicCandidate)
+	for _, run := range topicRuns(text) {
+		for start := range run {
+			for n := 1; n <= maxTopicWords && start+n <= len(run); n++ {
+				words := run[start : start+n]
+				keys := make([]string, n)
+				texts := make([]string, n)
+				proper := false
+				for i, w := range words {
+					keys[i], texts[i] = w.key, w.text
+					proper = proper || w.proper
+				}
+				key := strings.Join(keys, " ")
+				c, ok := candidates[key]
+				if !ok {
+					c = &topicCandidate{text: strings.Join(texts, " "), words: n}
+					candidates[key] = c
+				}
+				c.count++
+				c.proper = c.proper || proper
+			}
+		}
+	}
+
+	var topics []ConversationTopic
+	for key, c := range candidates {
+		// Single words are too generic unless they are names
+		if c.count < minTopicCount || (c.words == 1 && !c.proper) || subsumed(key, c, candidates) {
+			continue
+		}
+		score := float64(c.count)
+		if c.proper {
+			score *= properNounBoost
+		}
+		topics = append(topics, ConversationTopic{Topic: c.text, Score: score})
+	}
+
+	sort.Slice(topics, func(i, j int) bool {
+		if topics[i].Score != topics[j].Score {
+			return topics[i].Score > topics[j].Score
+		}
+		return topics[i].Topic < topics[j].Topic
+	})
+	if len(topics) > limit {
+		topics = topics[:limit]
+	}
+	return topics
+}
+
+// subsumed reports whether a longer phrase occurs as often as c and contains
+// it, so c adds nothing ("language model" within "large language model")
+func subsumed(key string, c *topicCandidate, candidates map[string]*topicCandidate) bool {
+	for otherKey, other := range candidates {
+		if other.words > c.words && other.count >= c.count &&
+			strings.Contains(" "+otherKey+" ", " "+key+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// topicRuns splits text into runs of consecutive words a phrase may span.
+// Runs end at stopwords, sentence ends, code and words of other scripts.
+func topicRuns(text string) [][]topicToken {
+	var runs [][]topicToken
+	var run []topicToken
+	flush := func() {
+		if len(run) > 0 {
+			runs = append(runs, run)
+			run = nil
+		}
+	}
+
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			flush()
+			continue
+		}
+		if inCode {
+			continue
+		}
+
+		sentenceStart := true
+		for _, field := range strings.Fields(line) {
+			word := strings.TrimFunc(field, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			switch {
+			case !isTopicWord(word):
+				// Bullets, numbers and the like start a new sentence
+				flush()
+				sentenceStart = true
+				continue
+			case stopwords[strings.ToLower(word)]:
+				flush()
+			default:
+				run = append(run, topicToken{
+					text:   word,
+					key:    strings.ToLower(word),
+					proper: unicode.IsUpper([]rune(word)[0]) && !sentenceStart,
+				})
+			}
+
+			sentenceStart = false
+			switch field[len(field)-1] {
+			case '.', '!', '?', ';', ':':
+				flush()
+				sentenceStart = true
+			case ',', ')':
+				flush()
+			}
+		}
+		flush()
+	}
+	return runs
+}
+
+// isTopicWord reports whether word can be part of a topic: Latin letters,
+// digits, hyphens and apostrophes, starting with a letter
+func isTopicWord(word string) bool {
+	if len(word) < 2 {
+		return false
+	}
+	for i, r := range word {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '\'' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// AnalyzeConversationTopics extracts the frequent phrases of a conversation
+// with ExtractTopics, replaces its stored topics with them and returns them
+func (db *DB) AnalyzeConversationTopics(id int64) ([]string, error) {
+	messages, err := db.ListMessages(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			continue
+		}
+		text.WriteString(msg.Content)
+		text.WriteString("\n")
+	}
+	topics := ExtractTopics(text.String(), MaxConversationTopics)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM conversation_topics WHERE conversation_id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to clear conversation topics: %w", err)
+	}
+	names := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		_, err := tx.Exec(
+			"INSERT OR IGNORE INTO conversation_topics (conversation_id, topic, score) VALUES (?, ?, ?)",
+			id, topic.Topic, topic.Score,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save conversation topic: %w", err)
+		}
+		names = append(names, topic.Topic)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return names, nil
+}
+
+// ListConversationTopics returns the stored topics of a conversation, highest
+// score first
+func (db *DB) ListConversationTopics(id int64) ([]string, error) {
+	rows, err := db.conn.Query(
+		"SELECT topic FROM conversation_topics WHERE conversation_id = ? ORDER BY score DESC, topic",
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation topics: %w", err)
+	}
+	defer rows.Close()
+
+	var topics []string
+	for rows.Next() {
+		var topic string
+		if err := rows.Scan(&topic); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation topic: %w", err)
+		}
+		topics = append(topics, topic)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list conversation topics: %w", err)
+	}
+	return topics, nil
+}
+
+// ListConversationsWithoutTopics returns up to limit IDs of conversations
+// with messages but no stored topics, most recently updated first
+func (db *DB) ListConversationsWithoutTopics(limit int) ([]int64, error) {
+	rows, err := db.conn.Query(`
+		SELECT c.id FROM conversations c
+		WHERE c.deleted_at IS NULL
+		AND EXISTS (SELECT 1 FROM messages m WHERE m.conversation_id = c.id)
+		AND NOT EXISTS (SELECT 1 FROM conversation_topics t WHERE t.conversation_id = c.id)
+		ORDER BY c.updated_at DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations without topics: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list conversations without topics: %w", err)
+	}
+	return ids, nil
+}
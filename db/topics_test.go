@@ -0,0 +1,91 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractTopics_PhrasesAndProperNouns(t *testing.T) {
+	text := "How do I deploy a machine learning model with Docker?\n" +
+		"You can package the machine learning model in an image. Then run it with Docker on Kubernetes.\n" +
+		"```\ndocker build machine learning model\ndocker build machine learning model\n```\n" +
+		"Install the tools first. Install them again if Kubernetes complains."
+
+	var got []string
+	for _, topic := range ExtractTopics(text, MaxConversationTopics) {
+		got = append(got, topic.Topic)
+	}
+	// "machine learning" and "learning model" are part of the longer phrase,
+	// "Install" is only capitalized at the start of a sentence and the code
+	// block is ignored
+	want := []string{"Docker", "Kubernetes", "machine learning model"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExtractTopics = %q, want %q", got, want)
+	}
+}
+
+func TestExtractTopics_Limit(t *testing.T) {
+	text := "Alpha Beta. Alpha Beta. Gamma Delta. Gamma Delta. Epsilon Zeta. Epsilon Zeta."
+	if topics := ExtractTopics(text, 2); len(topics) != 2 {
+		t.Fatalf("got %d topics, want 2", len(topics))
+	}
+	if topics := ExtractTopics("这是一个中文对话。这是一个中文对话。", 10); len(topics) != 0 {
+		t.Fatalf("got topics %v of a text without spaces, want none", topics)
+	}
+}
+
+func TestAnalyzeConversationTopics(t *testing.T) {
+	database := newTestDB(t)
+
+	conv, err := database.CreateConversation("topics", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	empty, err := database.CreateConversation("empty", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for _, content := range []string{"Tell me about vector databases.", "Vector databases index embeddings, e.g. in Postgres with pgvector or in Postgres extensions."} {
+		if _, err := database.CreateMessage(conv.ID, "user", content, "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	pending, err := database.ListConversationsWithoutTopics(10)
+	if err != nil {
+		t.Fatalf("ListConversationsWithoutTopics failed: %v", err)
+	}
+	if !reflect.DeepEqual(pending, []int64{conv.ID}) {
+		t.Fatalf("conversations without topics = %v, want only %d (%d has no messages)", pending, conv.ID, empty.ID)
+	}
+
+	topics, err := database.AnalyzeConversationTopics(conv.ID)
+	if err != nil {
+		t.Fatalf("AnalyzeConversationTopics failed: %v", err)
+	}
+	want := []string{"Postgres", "vector databases"}
+	if !reflect.DeepEqual(topics, want) {
+		t.Fatalf("AnalyzeConversationTopics = %q, want %q", topics, want)
+	}
+
+	stored, err := database.ListConversationTopics(conv.ID)
+	if err != nil {
+		t.Fatalf("ListConversationTopics failed: %v", err)
+	}
+	if !reflect.DeepEqual(stored, want) {
+		t.Fatalf("ListConversationTopics = %q, want %q", stored, want)
+	}
+	if pending, _ := database.ListConversationsWithoutTopics(10); len(pending) != 0 {
+		t.Fatalf("conversations without topics = %v after the analysis, want none", pending)
+	}
+
+	// Topics go with the conversation when it is deleted permanently
+	if err := database.PermanentlyDeleteConversation(conv.ID); err != nil {
+		t.Fatalf("PermanentlyDeleteConversation failed: %v", err)
+	}
+	if stored, _ := database.ListConversationTopics(conv.ID); len(stored) != 0 {
+		t.Fatalf("topics %q left after deleting the conversation", stored)
+	}
+}
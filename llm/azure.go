@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// AzureOpenAIProvider implements the Provider interface for OpenAI models
+// deployed on Azure. The chat API is OpenAI's, but requests go to
+// {endpoint}/openai/deployments/{deployment}/chat/completions?api-version=...
+// and authenticate with an api-key header instead of a bearer token.
+//
+// The deployment decides the model, so the models of this provider are
+// deployment names: DeploymentName is the default one, Config.Models may list
+// further deployments of the same resource.
+type AzureOpenAIProvider struct {
+	*OpenAIProvider
+}
+
+// NewAzureOpenAIProvider creates a new Azure OpenAI provider. BaseURL is the
+// endpoint of the resource, e.g. https://{resource}.openai.azure.com.
+func NewAzureOpenAIProvider(config Config) (*AzureOpenAIProvider, error) {
+	if config.DeploymentName == "" {
+		return nil, errors.New("deployment name is required for Azure OpenAI")
+	}
+	if config.APIVersion == "" {
+		return nil, errors.New("API version is required for Azure OpenAI")
+	}
+	if config.ProviderName == "" {
+		config.ProviderName = "Azure OpenAI"
+	}
+	config.Model = config.DeploymentName
+
+	// Allow empty API key - validation happens at runtime
+	clientConfig := openai.DefaultAzureConfig(config.APIKey, config.BaseURL)
+	clientConfig.APIVersion = config.APIVersion
+	// The default mapper strips dots from model names, deployment names are
+	// used as they are
+	clientConfig.AzureModelMapperFunc = func(model string) string { return model }
+
+	base, err := newOpenAIProviderWithClientConfig(config, clientConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Azure has no token counting endpoint
+	base.tokenCountUnsupported.Store(true)
+
+	return &AzureOpenAIProvider{OpenAIProvider: base}, nil
+}
+
+// StreamChat implements streaming chat. stream_options is left out of the
+// request, older API versions reject it.
+func (p *AzureOpenAIProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	return p.streamChat(ctx, messages, &usageSink{})
+}
+
+// StreamChatWithSystemPrompt sends the system prompt as the first message.
+// It is overridden so the request goes through the Azure StreamChat.
+func (p *AzureOpenAIProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChat(ctx, PrependSystemPrompt(systemPrompt, messages))
+}
+
+// Models returns the configured deployments
+func (p *AzureOpenAIProvider) Models() []string {
+	if len(p.config.Models) > 0 {
+		return p.config.Models
+	}
+	return []string{p.config.DeploymentName}
+}
+
+// WithModel returns a copy of the provider that uses the deployment named
+// model. The HTTP client is shared.
+func (p *AzureOpenAIProvider) WithModel(model string) Provider {
+	clone := *p.OpenAIProvider
+	clone.config.Model = model
+	return &AzureOpenAIProvider{OpenAIProvider: &clone}
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params
+func (p *AzureOpenAIProvider) WithParams(params GenerationParams) Provider {
+	return &AzureOpenAIProvider{OpenAIProvider: p.OpenAIProvider.withParams(params)}
+}
+
+// ValidateConfig validates the configuration
+func (p *AzureOpenAIProvider) ValidateConfig() error {
+	if p.config.APIKey == "" {
+		return errors.New("API key is required")
+	}
+	if p.config.BaseURL == "" {
+		return errors.New("endpoint (base URL) is required")
+	}
+	return nil
+}
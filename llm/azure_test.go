@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureOpenAIProvider_StreamChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt-4o.prod/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2024-02-01" {
+			t.Errorf("api-version = %q", got)
+		}
+		if got := r.Header.Get("api-key"); got != "test-key" {
+			t.Errorf("api-key header = %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]json.RawMessage
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		if _, ok := req["stream_options"]; ok {
+			t.Errorf("stream_options sent: %s", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+			"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := NewAzureOpenAIProvider(Config{
+		APIKey:         "test-key",
+		BaseURL:        server.URL,
+		DeploymentName: "gpt-4o.prod",
+		APIVersion:     "2024-02-01",
+	})
+	if err != nil {
+		t.Fatalf("NewAzureOpenAIProvider failed: %v", err)
+	}
+	if models := provider.Models(); len(models) != 1 || models[0] != "gpt-4o.prod" {
+		t.Errorf("Models = %v, want the deployment", models)
+	}
+
+	stream, err := provider.WithParams(GenerationParams{}).StreamChat(context.Background(), []Message{{Role: "user", Content: "Hello"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, last := collectStream(t, stream)
+	if content != "Hi" || !last.Done {
+		t.Errorf("unexpected stream: %q, last chunk %+v", content, last)
+	}
+}
+
+func TestNewAzureOpenAIProvider_RequiresDeployment(t *testing.T) {
+	tests := []Config{
+		{APIKey: "key", BaseURL: "https://example.openai.azure.com", APIVersion: "2024-02-01"},
+		{APIKey: "key", BaseURL: "https://example.openai.azure.com", DeploymentName: "gpt-4o"},
+	}
+	for _, config := range tests {
+		if _, err := NewAzureOpenAIProvider(config); err == nil {
+			t.Errorf("expected an error for %+v", config)
+		}
+	}
+}
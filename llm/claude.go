@@ -47,10 +47,23 @@ type ClaudeRequest struct {
 	Messages    []ClaudeMessage `json:"messages"`
 	MaxTokens   int             `json:"max_tokens"`
 	Temperature float64         `json:"temperature,omitempty"`
+	TopP        float64         `json:"top_p,omitempty"`
 	Stream      bool            `json:"stream"`
 	System      string          `json:"system,omitempty"`
 }
 
+// ClaudeTokenCountRequest represents a request to Claude's token counting API
+type ClaudeTokenCountRequest struct {
+	Model    string          `json:"model"`
+	Messages []ClaudeMessage `json:"messages"`
+	System   string          `json:"system,omitempty"`
+}
+
+// ClaudeTokenCountResponse represents a response of Claude's token counting API
+type ClaudeTokenCountResponse struct {
+	InputTokens int `json:"input_tokens"`
+}
+
 // ClaudeResponse represents a response from Claude API
 type ClaudeResponse struct {
 	ID      string `json:"id"`
@@ -76,12 +89,19 @@ type ClaudeStreamEvent struct {
 	Delta struct {
 		Type string `json:"type"`
 		Text string `json:"text"`
+		// Sent with message_delta events
+		StopReason   string `json:"stop_reason"`
+		StopSequence string `json:"stop_sequence"`
 	} `json:"delta,omitempty"`
 	Message      *ClaudeResponse `json:"message,omitempty"`
 	ContentBlock struct {
 		Type string `json:"type"`
 		Text string `json:"text"`
 	} `json:"content_block,omitempty"`
+	// Usage is sent with message_delta events and holds the output token count
+	Usage *struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
 }
 
 // NewClaudeProvider creates a new Claude provider
@@ -105,28 +125,40 @@ func NewClaudeProvider(config Config) (*ClaudeProvider, error) {
 		config.ProviderName = "Claude"
 	}
 
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ClaudeProvider{
 		apiKey:  config.APIKey,
 		baseURL: baseURL,
 		config:  config,
-		client:  &http.Client{},
+		client:  &http.Client{Transport: newLoggingTransport(transport, config.RequestLogger)},
 	}, nil
 }
 
 // StreamChat implements streaming chat
 func (p *ClaudeProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChatWithSystemPrompt(ctx, "", messages)
+}
+
+// StreamChatWithSystemPrompt sends the system prompt in the System field of
+// the request, followed by the system messages of the history
+func (p *ClaudeProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
 	responseChan := make(chan StreamResponse)
 
 	// Convert messages to Claude format and extract system message
-	claudeMessages, systemPrompt := p.convertMessages(messages)
+	claudeMessages, historySystemPrompt := p.convertMessages(messages)
 
 	req := ClaudeRequest{
 		Model:       p.config.Model,
 		Messages:    claudeMessages,
 		MaxTokens:   p.config.MaxTokens,
 		Temperature: p.config.Temperature,
+		TopP:        p.config.TopP,
 		Stream:      true,
-		System:      systemPrompt,
+		System:      joinSystemPrompts(systemPrompt, historySystemPrompt),
 	}
 
 	go func() {
@@ -150,6 +182,7 @@ func (p *ClaudeProvider) Chat(ctx context.Context, messages []Message) (string,
 		Messages:    claudeMessages,
 		MaxTokens:   p.config.MaxTokens,
 		Temperature: p.config.Temperature,
+		TopP:        p.config.TopP,
 		Stream:      false,
 		System:      systemPrompt,
 	}
@@ -210,6 +243,21 @@ func (p *ClaudeProvider) Models() []string {
 	}
 }
 
+// WithModel returns a copy of the provider that uses model. The HTTP client is shared.
+func (p *ClaudeProvider) WithModel(model string) Provider {
+	clone := *p
+	clone.config.Model = model
+	return &clone
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params. The HTTP client is shared.
+func (p *ClaudeProvider) WithParams(params GenerationParams) Provider {
+	clone := *p
+	clone.config = params.apply(clone.config)
+	return &clone
+}
+
 // GenerateTitle generates a short title based on the conversation
 func (p *ClaudeProvider) GenerateTitle(ctx context.Context, messages []Message) (string, error) {
 	// Build a prompt to generate a title
@@ -246,6 +294,36 @@ func (p *ClaudeProvider) GenerateTitle(ctx context.Context, messages []Message)
 	return title, nil
 }
 
+// CountTokens counts the input tokens of messages with the
+// /messages/count_tokens API, falling back to EstimateTokens for servers
+// without it
+func (p *ClaudeProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	claudeMessages, systemPrompt := p.convertMessages(messages)
+	if len(claudeMessages) == 0 {
+		return EstimateTokens(messages), nil
+	}
+
+	req := ClaudeTokenCountRequest{
+		Model:    p.config.Model,
+		Messages: claudeMessages,
+		System:   systemPrompt,
+	}
+	var result ClaudeTokenCountResponse
+	err := countTokensRequest(ctx, p.client, p.baseURL+"/messages/count_tokens", p.setHeaders, req, &result)
+	if errors.Is(err, errTokenCountUnsupported) {
+		return EstimateTokens(messages), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	return result.InputTokens, nil
+}
+
+// ContextWindowSize returns the known context window of the model
+func (p *ClaudeProvider) ContextWindowSize() int {
+	return ContextWindow(p.config.Model)
+}
+
 // ValidateConfig validates the configuration
 func (p *ClaudeProvider) ValidateConfig() error {
 	if p.apiKey == "" {
@@ -349,6 +427,11 @@ func (p *ClaudeProvider) streamRequest(ctx context.Context, req ClaudeRequest, r
 		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
+	// Input tokens arrive with message_start, output tokens and the stop
+	// reason with message_delta
+	var usage *Usage
+	metadata := make(map[string]interface{})
+
 	// Read SSE stream
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
@@ -363,7 +446,7 @@ func (p *ClaudeProvider) streamRequest(ctx context.Context, req ClaudeRequest, r
 
 		// Skip [DONE] message
 		if data == "[DONE]" {
-			responseChan <- StreamResponse{Done: true}
+			responseChan <- newDoneResponse(usage, metadata)
 			return nil
 		}
 
@@ -379,8 +462,25 @@ func (p *ClaudeProvider) streamRequest(ctx context.Context, req ClaudeRequest, r
 			if event.Delta.Text != "" {
 				responseChan <- StreamResponse{Content: event.Delta.Text}
 			}
+		case "message_start":
+			if event.Message != nil {
+				usage = &Usage{PromptTokens: event.Message.Usage.InputTokens}
+			}
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				metadata[MetadataStopReason] = event.Delta.StopReason
+			}
+			if event.Delta.StopSequence != "" {
+				metadata[MetadataStopSequence] = event.Delta.StopSequence
+			}
+			if event.Usage != nil {
+				if usage == nil {
+					usage = &Usage{}
+				}
+				usage.CompletionTokens = event.Usage.OutputTokens
+			}
 		case "message_stop":
-			responseChan <- StreamResponse{Done: true}
+			responseChan <- newDoneResponse(usage, metadata)
 			return nil
 		case "error":
 			return fmt.Errorf("stream error: %s", data)
@@ -391,6 +491,6 @@ func (p *ClaudeProvider) streamRequest(ctx context.Context, req ClaudeRequest, r
 		return fmt.Errorf("stream read error: %w", err)
 	}
 
-	responseChan <- StreamResponse{Done: true}
+	responseChan <- newDoneResponse(usage, metadata)
 	return nil
 }
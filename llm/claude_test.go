@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newRequestCaptureServer answers every request with an empty event stream
+// and decodes the request body into body
+func newRequestCaptureServer(t *testing.T, body *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+	}))
+}
+
+func TestClaudeProvider_StreamChatWithSystemPrompt(t *testing.T) {
+	var body map[string]interface{}
+	server := newRequestCaptureServer(t, &body)
+	defer server.Close()
+
+	provider, err := NewClaudeProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClaudeProvider failed: %v", err)
+	}
+
+	messages := []Message{
+		{Role: "system", Content: "Answer in English."},
+		{Role: "user", Content: "Hi"},
+	}
+	stream, err := provider.StreamChatWithSystemPrompt(context.Background(), "Be brief.", messages)
+	if err != nil {
+		t.Fatalf("StreamChatWithSystemPrompt failed: %v", err)
+	}
+	streamError(stream) // Only the request matters
+
+	if body["system"] != "Be brief.\n\nAnswer in English." {
+		t.Errorf("Expected the prompt in the system field, got: %q", body["system"])
+	}
+	if sent, _ := body["messages"].([]interface{}); len(sent) != 1 {
+		t.Errorf("Expected only the user message, got: %v", body["messages"])
+	}
+}
+
+func TestClaudeProvider_StreamStopReason(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"usage":{"input_tokens":12,"output_tokens":1}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Once upon"}}`,
+		`{"type":"message_delta","delta":{"stop_reason":"max_tokens","stop_sequence":null},"usage":{"output_tokens":4}}`,
+		`{"type":"message_stop"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			w.Write([]byte("data: " + event + "\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewClaudeProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClaudeProvider failed: %v", err)
+	}
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "Tell a story"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, last := collectStream(t, stream)
+
+	if !strings.HasPrefix(content, "Once upon") {
+		t.Errorf("Unexpected content: %q", content)
+	}
+	if last.Metadata[MetadataStopReason] != "max_tokens" {
+		t.Errorf("Expected the stop reason on the final chunk, got: %v", last.Metadata)
+	}
+	if _, ok := last.Metadata[MetadataStopSequence]; ok {
+		t.Errorf("Expected no stop sequence, got: %v", last.Metadata)
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 16 {
+		t.Errorf("Expected 16 total tokens, got: %+v", last.Usage)
+	}
+}
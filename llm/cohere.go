@@ -0,0 +1,342 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// cohereDefaultBaseURL is the endpoint of Cohere's v2 API
+const cohereDefaultBaseURL = "https://api.cohere.com/v2"
+
+// CohereProvider implements the Provider interface for Cohere's v2 Chat API.
+// Attachments are not sent, the base chat API takes text only.
+type CohereProvider struct {
+	apiKey  string
+	baseURL string
+	config  Config
+	client  *http.Client
+}
+
+// CohereMessage represents a message in Cohere's format
+type CohereMessage struct {
+	Role    string `json:"role"` // "system", "user" or "assistant"
+	Content string `json:"content"`
+}
+
+// CohereRequest represents a request to Cohere's /chat API
+type CohereRequest struct {
+	Model       string          `json:"model"`
+	Messages    []CohereMessage `json:"messages"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Temperature float64         `json:"temperature,omitempty"`
+	P           float64         `json:"p,omitempty"` // Top-p
+	Stream      bool            `json:"stream"`
+}
+
+// CohereUsage represents the token usage of a Cohere response
+type CohereUsage struct {
+	Tokens struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"tokens"`
+}
+
+// CohereResponse represents a non-streaming response of Cohere's /chat API
+type CohereResponse struct {
+	ID           string `json:"id"`
+	FinishReason string `json:"finish_reason"`
+	Message      struct {
+		Role    string `json:"role"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"message"`
+	Usage *CohereUsage `json:"usage,omitempty"`
+}
+
+// CohereStreamEvent represents a streaming event of Cohere's /chat API
+type CohereStreamEvent struct {
+	Type  string `json:"type"` // "message-start", "content-delta", "message-end", ...
+	Delta struct {
+		Message struct {
+			Content struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"message"`
+		// Sent with message-end events
+		FinishReason string       `json:"finish_reason"`
+		Usage        *CohereUsage `json:"usage,omitempty"`
+	} `json:"delta"`
+}
+
+// NewCohereProvider creates a new Cohere provider
+func NewCohereProvider(config Config) (*CohereProvider, error) {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = cohereDefaultBaseURL
+	}
+
+	// Set defaults
+	if config.MaxTokens == 0 {
+		config.MaxTokens = 4096
+	}
+	if config.Temperature == 0 {
+		config.Temperature = 0.7
+	}
+	if config.Model == "" {
+		config.Model = "command-r-plus"
+	}
+	if config.ProviderName == "" {
+		config.ProviderName = "Cohere"
+	}
+
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CohereProvider{
+		apiKey:  config.APIKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		config:  config,
+		client:  &http.Client{Transport: newLoggingTransport(transport, config.RequestLogger)},
+	}, nil
+}
+
+// StreamChat implements streaming chat
+func (p *CohereProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChatWithSystemPrompt(ctx, "", messages)
+}
+
+// StreamChatWithSystemPrompt sends the system prompt as the first message
+func (p *CohereProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	responseChan := make(chan StreamResponse)
+
+	req := p.newRequest(PrependSystemPrompt(systemPrompt, messages))
+	req.Stream = true
+
+	go func() {
+		defer close(responseChan)
+
+		if err := p.streamRequest(ctx, req, responseChan); err != nil {
+			responseChan <- StreamResponse{Error: err}
+		}
+	}()
+
+	return responseChan, nil
+}
+
+// Chat implements non-streaming chat
+func (p *CohereProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	resp, err := p.send(ctx, p.newRequest(messages))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var cohereResp CohereResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cohereResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range cohereResp.Message.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", errors.New("no content in response")
+	}
+	return text.String(), nil
+}
+
+// Name returns the provider name
+func (p *CohereProvider) Name() string {
+	return p.config.ProviderName
+}
+
+// Models returns supported models
+func (p *CohereProvider) Models() []string {
+	if len(p.config.Models) > 0 {
+		return p.config.Models
+	}
+	return []string{
+		"command-r-plus",
+		"command-r",
+		"command-a-03-2025",
+		"command-r7b-12-2024",
+	}
+}
+
+// WithModel returns a copy of the provider that uses model. The HTTP client is shared.
+func (p *CohereProvider) WithModel(model string) Provider {
+	clone := *p
+	clone.config.Model = model
+	return &clone
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params. The HTTP client is shared.
+func (p *CohereProvider) WithParams(params GenerationParams) Provider {
+	clone := *p
+	clone.config = params.apply(clone.config)
+	return &clone
+}
+
+// GenerateTitle generates a short title based on the conversation. The chat
+// API has no title option, so the model is asked for one like with the other
+// providers.
+func (p *CohereProvider) GenerateTitle(ctx context.Context, messages []Message) (string, error) {
+	titlePrompt := []Message{
+		{
+			Role:    "system",
+			Content: "You are a helpful assistant that generates short, concise titles for conversations. Generate a title in the same language as the conversation (Chinese or English). The title should be 3-8 words, descriptive, and capture the main topic. Only output the title, nothing else.",
+		},
+	}
+
+	// Add the first few messages for context (limit to avoid token issues)
+	maxMessages := 4
+	for i, msg := range messages {
+		if i >= maxMessages {
+			break
+		}
+		titlePrompt = append(titlePrompt, msg)
+	}
+
+	titlePrompt = append(titlePrompt, Message{
+		Role:    "user",
+		Content: "Based on the above conversation, generate a short title (3-8 words):",
+	})
+
+	title, err := p.Chat(ctx, titlePrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate title: %w", err)
+	}
+
+	return cleanTitle(title), nil
+}
+
+// CountTokens estimates the tokens of messages. Cohere's tokenize endpoint
+// belongs to the v1 API and counts single texts, not chat requests.
+func (p *CohereProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	return EstimateTokens(messages), nil
+}
+
+// ContextWindowSize returns the known context window of the model
+func (p *CohereProvider) ContextWindowSize() int {
+	return ContextWindow(p.config.Model)
+}
+
+// ValidateConfig validates the configuration
+func (p *CohereProvider) ValidateConfig() error {
+	if p.apiKey == "" {
+		return errors.New("API key is required")
+	}
+	return nil
+}
+
+// newRequest returns a non-streaming chat request for messages
+func (p *CohereProvider) newRequest(messages []Message) CohereRequest {
+	cohereMessages := make([]CohereMessage, 0, len(messages))
+	for _, msg := range messages {
+		cohereMessages = append(cohereMessages, CohereMessage{Role: msg.Role, Content: msg.Content})
+	}
+
+	return CohereRequest{
+		Model:       p.config.Model,
+		Messages:    cohereMessages,
+		MaxTokens:   p.config.MaxTokens,
+		Temperature: p.config.Temperature,
+		P:           p.config.TopP,
+	}
+}
+
+// send posts req to the chat API and returns the response if it succeeded
+func (p *CohereProvider) send(ctx context.Context, req CohereRequest) (*http.Response, error) {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
+
+// streamRequest handles the streaming request to Cohere's chat API
+func (p *CohereProvider) streamRequest(ctx context.Context, req CohereRequest, responseChan chan<- StreamResponse) error {
+	resp, err := p.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Usage and the finish reason arrive with message-end
+	var usage *Usage
+	metadata := make(map[string]interface{})
+
+	// Read SSE stream; the event type is repeated in the data
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var event CohereStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			// Skip malformed events
+			continue
+		}
+
+		switch event.Type {
+		case "content-delta":
+			if text := event.Delta.Message.Content.Text; text != "" {
+				responseChan <- StreamResponse{Content: text}
+			}
+		case "message-end":
+			if event.Delta.FinishReason != "" {
+				metadata[MetadataStopReason] = event.Delta.FinishReason
+			}
+			if u := event.Delta.Usage; u != nil {
+				usage = &Usage{PromptTokens: u.Tokens.InputTokens, CompletionTokens: u.Tokens.OutputTokens}
+			}
+			responseChan <- newDoneResponse(usage, metadata)
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("stream read error: %w", err)
+	}
+
+	responseChan <- newDoneResponse(usage, metadata)
+	return nil
+}
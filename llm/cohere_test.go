@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCohereProvider_StreamChat(t *testing.T) {
+	var req CohereRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: message-start\n" +
+			"data: {\"id\":\"1\",\"type\":\"message-start\",\"delta\":{\"message\":{\"role\":\"assistant\"}}}\n\n" +
+			"event: content-delta\n" +
+			"data: {\"type\":\"content-delta\",\"index\":0,\"delta\":{\"message\":{\"content\":{\"text\":\"Hello\"}}}}\n\n" +
+			"event: content-delta\n" +
+			"data: {\"type\":\"content-delta\",\"index\":0,\"delta\":{\"message\":{\"content\":{\"text\":\" there\"}}}}\n\n" +
+			"event: message-end\n" +
+			"data: {\"type\":\"message-end\",\"delta\":{\"finish_reason\":\"MAX_TOKENS\",\"usage\":{\"billed_units\":{\"input_tokens\":4,\"output_tokens\":2},\"tokens\":{\"input_tokens\":70,\"output_tokens\":2}}}}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := NewCohereProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewCohereProvider failed: %v", err)
+	}
+	stream, err := provider.StreamChatWithSystemPrompt(context.Background(), "Be brief", []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, last := collectStream(t, stream)
+
+	if content != "Hello there" {
+		t.Errorf("content = %q", content)
+	}
+	if !last.Done || last.Usage == nil || last.Usage.TotalTokens != 72 || last.Metadata[MetadataStopReason] != "MAX_TOKENS" {
+		t.Errorf("unexpected last chunk %+v", last)
+	}
+	want := []CohereMessage{{Role: "system", Content: "Be brief"}, {Role: "user", Content: "Hi"}}
+	if len(req.Messages) != len(want) || req.Messages[0] != want[0] || req.Messages[1] != want[1] {
+		t.Errorf("messages = %+v, want %+v", req.Messages, want)
+	}
+	if !req.Stream || req.Model != "command-r-plus" {
+		t.Errorf("unexpected request %+v", req)
+	}
+}
+
+func TestCohereProvider_Chat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"1","finish_reason":"COMPLETE","message":{"role":"assistant","content":[{"type":"text","text":"\"Go basics\""}]}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewCohereProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewCohereProvider failed: %v", err)
+	}
+	title, err := provider.GenerateTitle(context.Background(), []Message{{Role: "user", Content: "What is Go?"}})
+	if err != nil {
+		t.Fatalf("GenerateTitle failed: %v", err)
+	}
+	if title != "Go basics" {
+		t.Errorf("title = %q", title)
+	}
+}
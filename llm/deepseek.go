@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// deepseekDefaultBaseURL is the OpenAI-compatible endpoint of DeepSeek
+const deepseekDefaultBaseURL = "https://api.deepseek.com/v1"
+
+// Tags around the chain of thought in the streamed content, as rendered by
+// the chat view's thinking sections
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// DeepSeekProvider implements the Provider interface for DeepSeek. The chat
+// API is OpenAI-compatible; deepseek-reasoner also streams its chain of
+// thought in a reasoning_content field, which is passed on wrapped in
+// <think></think> before the answer.
+type DeepSeekProvider struct {
+	*OpenAIProvider
+}
+
+// NewDeepSeekProvider creates a new DeepSeek provider
+func NewDeepSeekProvider(config Config) (*DeepSeekProvider, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = deepseekDefaultBaseURL
+	}
+	if config.ProviderName == "" {
+		config.ProviderName = "DeepSeek"
+	}
+	if config.Model == "" {
+		config.Model = "deepseek-chat"
+	}
+
+	base, err := NewOpenAIProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeepSeekProvider{OpenAIProvider: base}, nil
+}
+
+// StreamChat implements streaming chat. Reasoning chunks are sent as content
+// inside a <think> section that is closed when the answer starts.
+func (p *DeepSeekProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	responseChan := make(chan StreamResponse)
+
+	openaiMessages := make([]openai.ChatCompletionMessage, 0, len(messages))
+	for _, msg := range messages {
+		// DeepSeek asks for earlier reasoning to be left out of the history
+		if msg.Role == "assistant" {
+			msg.Content = stripThinking(msg.Content)
+		}
+		openaiMessages = append(openaiMessages, p.convertMessage(msg))
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model:       p.config.Model,
+		Messages:    openaiMessages,
+		MaxTokens:   p.config.MaxTokens,
+		Temperature: float32(p.config.Temperature),
+		TopP:        float32(p.config.TopP),
+		Stream:      true,
+	}
+
+	sink := &usageSink{includeUsage: true, collectReasoning: true}
+	ctx = withUsageSink(ctx, sink)
+
+	go func() {
+		defer close(responseChan)
+
+		stream, err := p.client.CreateChatCompletionStream(ctx, req)
+		if err != nil {
+			responseChan <- StreamResponse{Error: fmt.Errorf("failed to create stream: %w", err)}
+			return
+		}
+		defer stream.Close()
+
+		var finishReason openai.FinishReason
+		thinking := false
+		for {
+			response, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				if thinking {
+					responseChan <- StreamResponse{Content: thinkCloseTag}
+				}
+				responseChan <- newDoneResponse(sink.usage, sink.metadata(finishReason))
+				return
+			}
+			if err != nil {
+				responseChan <- StreamResponse{Error: fmt.Errorf("stream error: %w", err)}
+				return
+			}
+
+			// Taken for every chunk to keep the queue in step with the stream
+			reasoning := sink.nextReasoning()
+			if len(response.Choices) == 0 {
+				continue
+			}
+			if response.Choices[0].FinishReason != "" {
+				finishReason = response.Choices[0].FinishReason
+			}
+			if reasoning != "" {
+				if !thinking {
+					reasoning = thinkOpenTag + reasoning
+					thinking = true
+				}
+				responseChan <- StreamResponse{Content: reasoning}
+			}
+			if content := response.Choices[0].Delta.Content; content != "" {
+				if thinking {
+					content = thinkCloseTag + "\n\n" + content
+					thinking = false
+				}
+				responseChan <- StreamResponse{Content: content}
+			}
+		}
+	}()
+
+	return responseChan, nil
+}
+
+// StreamChatWithSystemPrompt sends the system prompt as the first message.
+// It is overridden so the reasoning is streamed as well.
+func (p *DeepSeekProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChat(ctx, PrependSystemPrompt(systemPrompt, messages))
+}
+
+// Models returns supported models
+func (p *DeepSeekProvider) Models() []string {
+	if len(p.config.Models) > 0 {
+		return p.config.Models
+	}
+	return []string{
+		"deepseek-chat",
+		"deepseek-reasoner",
+	}
+}
+
+// WithModel returns a copy of the provider that uses model. The HTTP client is shared.
+func (p *DeepSeekProvider) WithModel(model string) Provider {
+	clone := *p.OpenAIProvider
+	clone.config.Model = model
+	return &DeepSeekProvider{OpenAIProvider: &clone}
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params
+func (p *DeepSeekProvider) WithParams(params GenerationParams) Provider {
+	return &DeepSeekProvider{OpenAIProvider: p.OpenAIProvider.withParams(params)}
+}
+
+// stripThinking removes the <think></think> sections from content, along
+// with a section that was never closed
+func stripThinking(content string) string {
+	for {
+		start := strings.Index(content, thinkOpenTag)
+		if start < 0 {
+			return content
+		}
+		end := strings.Index(content[start:], thinkCloseTag)
+		if end < 0 {
+			return strings.TrimSpace(content[:start])
+		}
+		content = content[:start] + strings.TrimLeft(content[start+end+len(thinkCloseTag):], "\n")
+	}
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDeepSeekProvider_StreamsReasoning(t *testing.T) {
+	var history string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		for _, msg := range req.Messages {
+			history += msg.Role + ":" + msg.Content + "\n"
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(": keep-alive\n\n" +
+			"data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":null,\"reasoning_content\":\"Two\"}}]}\n\n" +
+			"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":null,\"reasoning_content\":\" plus two\"}}]}\n\n" +
+			"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"4\",\"reasoning_content\":null}}]}\n\n" +
+			"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\".\"},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":7,\"total_tokens\":12}}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := NewDeepSeekProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewDeepSeekProvider failed: %v", err)
+	}
+
+	messages := []Message{
+		{Role: "user", Content: "1+1?"},
+		{Role: "assistant", Content: "<think>One and one</think>\n\n2"},
+		{Role: "user", Content: "2+2?"},
+	}
+	stream, err := provider.WithModel("deepseek-reasoner").StreamChat(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, last := collectStream(t, stream)
+
+	if want := "<think>Two plus two</think>\n\n4."; content != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+	if !last.Done || last.Usage == nil || last.Usage.TotalTokens != 12 {
+		t.Errorf("unexpected last chunk %+v", last)
+	}
+	if strings.Contains(history, "One and one") {
+		t.Errorf("earlier reasoning was sent back:\n%s", history)
+	}
+}
+
+func TestDeepSeekProvider_ReasoningOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"reasoning_content\":\"Hmm\"},\"finish_reason\":\"length\"}]}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := NewDeepSeekProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewDeepSeekProvider failed: %v", err)
+	}
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "Hello"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	// The thinking section is closed even when the answer never starts
+	if content, _ := collectStream(t, stream); content != "<think>Hmm</think>" {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestStripThinking(t *testing.T) {
+	tests := map[string]string{
+		"plain":                              "plain",
+		"<think>a</think>\n\nanswer":         "answer",
+		"before <think>a</think>after":       "before after",
+		"<think>a</think>x<think>b</think>y": "xy",
+		"answer <think>cut off":              "answer",
+	}
+	for content, want := range tests {
+		if got := stripThinking(content); got != want {
+			t.Errorf("stripThinking(%q) = %q, want %q", content, got, want)
+		}
+	}
+}
@@ -46,6 +46,16 @@ type GeminiRequest struct {
 	SafetySettings   []GeminiSafetySetting   `json:"safetySettings,omitempty"`
 }
 
+// GeminiTokenCountRequest represents a request to Gemini's countTokens API
+type GeminiTokenCountRequest struct {
+	Contents []GeminiContent `json:"contents"`
+}
+
+// GeminiTokenCountResponse represents a response of Gemini's countTokens API
+type GeminiTokenCountResponse struct {
+	TotalTokens int `json:"totalTokens"`
+}
+
 // GeminiGenerationConfig represents generation configuration
 type GeminiGenerationConfig struct {
 	Temperature     float64 `json:"temperature,omitempty"`
@@ -80,6 +90,12 @@ type GeminiResponse struct {
 			Probability string `json:"probability"`
 		} `json:"safetyRatings"`
 	} `json:"promptFeedback,omitempty"`
+	// UsageMetadata is reported on the last chunk of a stream
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata,omitempty"`
 }
 
 // NewGeminiProvider creates a new Gemini provider
@@ -103,26 +119,38 @@ func NewGeminiProvider(config Config) (*GeminiProvider, error) {
 		config.ProviderName = "Gemini"
 	}
 
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &GeminiProvider{
 		apiKey:  config.APIKey,
 		baseURL: baseURL,
 		config:  config,
-		client:  &http.Client{},
+		client:  &http.Client{Transport: newLoggingTransport(transport, config.RequestLogger)},
 	}, nil
 }
 
 // StreamChat implements streaming chat
 func (p *GeminiProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChatWithSystemPrompt(ctx, "", messages)
+}
+
+// StreamChatWithSystemPrompt prepends the system prompt to the content of the
+// first user message, as Gemini has no system role
+func (p *GeminiProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
 	responseChan := make(chan StreamResponse)
 
 	// Convert messages to Gemini format
-	geminiContents := p.convertMessages(messages)
+	geminiContents := p.convertMessages(systemPrompt, messages)
 
 	req := GeminiRequest{
 		Contents: geminiContents,
 		GenerationConfig: &GeminiGenerationConfig{
 			Temperature:     p.config.Temperature,
 			MaxOutputTokens: p.config.MaxTokens,
+			TopP:            p.config.TopP,
 		},
 		SafetySettings: p.getDefaultSafetySettings(),
 	}
@@ -141,13 +169,14 @@ func (p *GeminiProvider) StreamChat(ctx context.Context, messages []Message) (<-
 // Chat implements non-streaming chat
 func (p *GeminiProvider) Chat(ctx context.Context, messages []Message) (string, error) {
 	// Convert messages to Gemini format
-	geminiContents := p.convertMessages(messages)
+	geminiContents := p.convertMessages("", messages)
 
 	req := GeminiRequest{
 		Contents: geminiContents,
 		GenerationConfig: &GeminiGenerationConfig{
 			Temperature:     p.config.Temperature,
 			MaxOutputTokens: p.config.MaxTokens,
+			TopP:            p.config.TopP,
 		},
 		SafetySettings: p.getDefaultSafetySettings(),
 	}
@@ -214,6 +243,21 @@ func (p *GeminiProvider) Models() []string {
 	}
 }
 
+// WithModel returns a copy of the provider that uses model. The HTTP client is shared.
+func (p *GeminiProvider) WithModel(model string) Provider {
+	clone := *p
+	clone.config.Model = model
+	return &clone
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params. The HTTP client is shared.
+func (p *GeminiProvider) WithParams(params GenerationParams) Provider {
+	clone := *p
+	clone.config = params.apply(clone.config)
+	return &clone
+}
+
 // GenerateTitle generates a short title based on the conversation
 func (p *GeminiProvider) GenerateTitle(ctx context.Context, messages []Message) (string, error) {
 	// Build a prompt to generate a title
@@ -250,6 +294,31 @@ func (p *GeminiProvider) GenerateTitle(ctx context.Context, messages []Message)
 	return title, nil
 }
 
+// CountTokens counts the tokens of messages with the countTokens API,
+// falling back to EstimateTokens for servers without it
+func (p *GeminiProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	contents := p.convertMessages("", messages)
+	if len(contents) == 0 {
+		return EstimateTokens(messages), nil
+	}
+
+	url := fmt.Sprintf("%s/models/%s:countTokens?key=%s", p.baseURL, p.config.Model, p.apiKey)
+	var result GeminiTokenCountResponse
+	err := countTokensRequest(ctx, p.client, url, nil, GeminiTokenCountRequest{Contents: contents}, &result)
+	if errors.Is(err, errTokenCountUnsupported) {
+		return EstimateTokens(messages), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	return result.TotalTokens, nil
+}
+
+// ContextWindowSize returns the known context window of the model
+func (p *GeminiProvider) ContextWindowSize() int {
+	return ContextWindow(p.config.Model)
+}
+
 // ValidateConfig validates the configuration
 func (p *GeminiProvider) ValidateConfig() error {
 	if p.apiKey == "" {
@@ -259,10 +328,10 @@ func (p *GeminiProvider) ValidateConfig() error {
 }
 
 // convertMessages converts our Message format to Gemini's format
-// Gemini uses a different message structure with contents and parts
-func (p *GeminiProvider) convertMessages(messages []Message) []GeminiContent {
+// Gemini uses a different message structure with contents and parts.
+// systemPrompt goes before the system messages of the history.
+func (p *GeminiProvider) convertMessages(systemPrompt string, messages []Message) []GeminiContent {
 	var geminiContents []GeminiContent
-	var systemPrompt string
 
 	// Extract system message if present
 	for _, msg := range messages {
@@ -374,6 +443,10 @@ func (p *GeminiProvider) streamRequest(ctx context.Context, req GeminiRequest, r
 		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
+	// The finish reason and safety ratings of the last chunk that has them
+	var usage *Usage
+	metadata := make(map[string]interface{})
+
 	// Read SSE stream
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
@@ -397,9 +470,28 @@ func (p *GeminiProvider) streamRequest(ctx context.Context, req GeminiRequest, r
 			continue
 		}
 
+		// Usage is cumulative, so the last reported value wins
+		if meta := geminiResp.UsageMetadata; meta != nil {
+			usage = &Usage{
+				PromptTokens:     meta.PromptTokenCount,
+				CompletionTokens: meta.CandidatesTokenCount,
+				TotalTokens:      meta.TotalTokenCount,
+			}
+		}
+
 		// Extract text from response
 		if len(geminiResp.Candidates) > 0 {
 			candidate := geminiResp.Candidates[0]
+			if candidate.FinishReason != "" {
+				metadata[MetadataStopReason] = candidate.FinishReason
+			}
+			if len(candidate.SafetyRatings) > 0 {
+				ratings := make(map[string]interface{}, len(candidate.SafetyRatings))
+				for _, rating := range candidate.SafetyRatings {
+					ratings[rating.Category] = rating.Probability
+				}
+				metadata[MetadataSafetyRatings] = ratings
+			}
 			if len(candidate.Content.Parts) > 0 {
 				text := candidate.Content.Parts[0].Text
 				if text != "" {
@@ -422,6 +514,6 @@ func (p *GeminiProvider) streamRequest(ctx context.Context, req GeminiRequest, r
 		return fmt.Errorf("stream read error: %w", err)
 	}
 
-	responseChan <- StreamResponse{Done: true}
+	responseChan <- newDoneResponse(usage, metadata)
 	return nil
 }
@@ -0,0 +1,27 @@
+package llm
+
+import "testing"
+
+func TestGeminiProvider_ConvertMessagesWithSystemPrompt(t *testing.T) {
+	provider, err := NewGeminiProvider(Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewGeminiProvider failed: %v", err)
+	}
+
+	contents := provider.convertMessages("Be brief.", []Message{
+		{Role: "system", Content: "Answer in English."},
+		{Role: "user", Content: "Hi"},
+		{Role: "assistant", Content: "Hello"},
+		{Role: "user", Content: "How are you?"},
+	})
+
+	if len(contents) != 3 {
+		t.Fatalf("Expected 3 contents without the system message, got %d", len(contents))
+	}
+	if got := contents[0].Parts[0].Text; got != "Be brief.\n\nAnswer in English.\n\nHi" {
+		t.Errorf("Expected the prompt before the first user message, got: %q", got)
+	}
+	if contents[1].Role != "model" || contents[2].Parts[0].Text != "How are you?" {
+		t.Errorf("Unexpected contents: %+v", contents)
+	}
+}
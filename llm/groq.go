@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// groqDefaultBaseURL is the OpenAI-compatible endpoint of Groq Cloud
+const groqDefaultBaseURL = "https://api.groq.com/openai/v1"
+
+// Response headers in which Groq reports how long a request queued and how
+// long it took in total
+const (
+	groqQueueTimeHeader = "x-groq-queue-time"
+	groqTotalTimeHeader = "x-groq-total-time"
+)
+
+// GroqLatencyStats are the timings Groq reported for a response. A zero
+// duration means the header was missing.
+type GroqLatencyStats struct {
+	Model     string
+	QueueTime time.Duration
+	TotalTime time.Duration
+}
+
+// groqLatency holds the stats of the last response, shared by the copies of
+// a provider made with WithModel and WithParams
+type groqLatency struct {
+	mu    sync.Mutex
+	stats GroqLatencyStats
+}
+
+// GroqProvider implements the Provider interface for Groq Cloud. The chat API
+// is OpenAI-compatible; the queue and total times of each response are read
+// from the x-groq-* response headers.
+type GroqProvider struct {
+	*OpenAIProvider
+	latency *groqLatency
+	logger  DebugLogger
+}
+
+// NewGroqProvider creates a new Groq provider
+func NewGroqProvider(config Config) (*GroqProvider, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = groqDefaultBaseURL
+	}
+	if config.ProviderName == "" {
+		config.ProviderName = "Groq"
+	}
+	if config.Model == "" {
+		config.Model = "llama3-8b-8192"
+	}
+
+	base, err := NewOpenAIProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroqProvider{OpenAIProvider: base, latency: &groqLatency{}, logger: config.Logger}, nil
+}
+
+// StreamChat implements streaming chat. The latency stats of the response are
+// recorded before the done chunk is passed on.
+func (p *GroqProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	sink := &usageSink{includeUsage: true}
+	stream, err := p.streamChat(ctx, messages, sink)
+	if err != nil {
+		return nil, err
+	}
+
+	responseChan := make(chan StreamResponse)
+	go func() {
+		defer close(responseChan)
+		for chunk := range stream {
+			if chunk.Done {
+				p.recordLatency(sink.header)
+			}
+			responseChan <- chunk
+		}
+	}()
+
+	return responseChan, nil
+}
+
+// StreamChatWithSystemPrompt sends the system prompt as the first message.
+// It is overridden so the latency stats are recorded as well.
+func (p *GroqProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChat(ctx, PrependSystemPrompt(systemPrompt, messages))
+}
+
+// recordLatency stores the timings in the response header as the last stats
+func (p *GroqProvider) recordLatency(header http.Header) {
+	stats := GroqLatencyStats{
+		Model:     p.config.Model,
+		QueueTime: parseGroqDuration(header.Get(groqQueueTimeHeader)),
+		TotalTime: parseGroqDuration(header.Get(groqTotalTimeHeader)),
+	}
+
+	p.latency.mu.Lock()
+	p.latency.stats = stats
+	p.latency.mu.Unlock()
+
+	if p.logger != nil {
+		p.logger.Debug("Groq latency for %s: queue %v, total %v", stats.Model, stats.QueueTime, stats.TotalTime)
+	}
+}
+
+// LastLatencyStats returns the timings of the last completed response
+func (p *GroqProvider) LastLatencyStats() GroqLatencyStats {
+	p.latency.mu.Lock()
+	defer p.latency.mu.Unlock()
+	return p.latency.stats
+}
+
+// parseGroqDuration parses a timing header, given in seconds like "0.0125" or
+// as a Go duration like "12.5ms". Missing or malformed values are 0.
+func parseGroqDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return d
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Models returns supported models
+func (p *GroqProvider) Models() []string {
+	if len(p.config.Models) > 0 {
+		return p.config.Models
+	}
+	return []string{
+		"llama3-8b-8192",
+		"llama3-70b-8192",
+		"mixtral-8x7b-32768",
+	}
+}
+
+// WithModel returns a copy of the provider that uses model. The HTTP client
+// and the latency stats are shared.
+func (p *GroqProvider) WithModel(model string) Provider {
+	clone := *p.OpenAIProvider
+	clone.config.Model = model
+	return &GroqProvider{OpenAIProvider: &clone, latency: p.latency, logger: p.logger}
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params. The latency stats are shared.
+func (p *GroqProvider) WithParams(params GenerationParams) Provider {
+	return &GroqProvider{OpenAIProvider: p.OpenAIProvider.withParams(params), latency: p.latency, logger: p.logger}
+}
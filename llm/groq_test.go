@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGroqProvider_LatencyStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("x-groq-queue-time", "0.0125")
+		w.Header().Set("x-groq-total-time", "180ms")
+		w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+			"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := NewGroqProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewGroqProvider failed: %v", err)
+	}
+	if got := provider.LastLatencyStats(); got != (GroqLatencyStats{}) {
+		t.Errorf("expected no stats before a response, got %+v", got)
+	}
+
+	// Copies for another model report to the same stats
+	other := provider.WithModel("llama3-70b-8192")
+	stream, err := other.StreamChat(context.Background(), []Message{{Role: "user", Content: "Hello"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, last := collectStream(t, stream)
+	if content != "Hi" || !last.Done {
+		t.Errorf("unexpected stream: %q, last chunk %+v", content, last)
+	}
+
+	want := GroqLatencyStats{Model: "llama3-70b-8192", QueueTime: 12500 * time.Microsecond, TotalTime: 180 * time.Millisecond}
+	if got := provider.LastLatencyStats(); got != want {
+		t.Errorf("LastLatencyStats = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseGroqDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"0.5":   500 * time.Millisecond,
+		"12ms":  12 * time.Millisecond,
+		"":      0,
+		"soon":  0,
+		"-1":    0,
+		" 2.0 ": 2 * time.Second,
+	}
+	for value, want := range tests {
+		if got := parseGroqDuration(value); got != want {
+			t.Errorf("parseGroqDuration(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// RequestLogger records the raw HTTP traffic of the providers
+type RequestLogger interface {
+	LogRequest(req *http.Request, body []byte)
+	LogResponse(status int, body []byte)
+}
+
+// loggingTransport passes each request and response line of the wrapped
+// transport to a RequestLogger
+type loggingTransport struct {
+	base   http.RoundTripper
+	logger RequestLogger
+}
+
+// newLoggingTransport wraps base; without a logger base is returned as is
+func newLoggingTransport(base http.RoundTripper, logger RequestLogger) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if logger == nil {
+		return base
+	}
+	return &loggingTransport{base: base, logger: logger}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		// Read a copy when possible so the request is sent untouched
+		if req.GetBody != nil {
+			if rc, err := req.GetBody(); err == nil {
+				body, _ = io.ReadAll(rc)
+				rc.Close()
+			}
+		} else {
+			data, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			body = data
+			req.Body = io.NopCloser(bytes.NewReader(data))
+		}
+	}
+	t.logger.LogRequest(req, body)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &loggingBody{ReadCloser: resp.Body, logger: t.logger, status: resp.StatusCode}
+	return resp, nil
+}
+
+// loggingBody logs a response line by line as the client reads it, so each
+// event of a streamed response becomes its own entry
+type loggingBody struct {
+	io.ReadCloser
+	logger  RequestLogger
+	status  int
+	pending []byte
+}
+
+// Read implements io.Reader
+func (b *loggingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.pending = append(b.pending, p[:n]...)
+	for {
+		i := bytes.IndexByte(b.pending, '\n')
+		if i < 0 {
+			break
+		}
+		b.logLine(b.pending[:i])
+		b.pending = b.pending[i+1:]
+	}
+	if err != nil {
+		b.flush()
+	}
+	return n, err
+}
+
+// Close logs what is left of the response and closes it
+func (b *loggingBody) Close() error {
+	b.flush()
+	return b.ReadCloser.Close()
+}
+
+// flush logs an unterminated last line
+func (b *loggingBody) flush() {
+	if len(b.pending) > 0 {
+		b.logLine(b.pending)
+		b.pending = nil
+	}
+}
+
+// logLine logs a non-empty line
+func (b *loggingBody) logLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) > 0 {
+		b.logger.LogResponse(b.status, append([]byte(nil), line...))
+	}
+}
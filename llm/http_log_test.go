@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingLogger keeps the logged requests and response lines
+type recordingLogger struct {
+	mu        sync.Mutex
+	requests  []string
+	bodies    []string
+	responses []string
+}
+
+func (l *recordingLogger) LogRequest(req *http.Request, body []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, req.Method+" "+req.URL.Path)
+	l.bodies = append(l.bodies, string(body))
+}
+
+func (l *recordingLogger) LogResponse(status int, body []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.responses = append(l.responses, string(body))
+}
+
+func TestLoggingTransport_StreamedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n"))
+		w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}` + "\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	provider, err := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-test", RequestLogger: logger})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, _ := collectStream(t, stream)
+	if content != "Hello" {
+		t.Errorf("content = %q, want %q", content, "Hello")
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.requests) != 1 || logger.requests[0] != "POST /chat/completions" {
+		t.Fatalf("unexpected requests %v", logger.requests)
+	}
+	if !strings.Contains(logger.bodies[0], `"gpt-test"`) {
+		t.Errorf("request body not logged: %s", logger.bodies[0])
+	}
+	if len(logger.responses) != 3 || logger.responses[2] != "data: [DONE]" {
+		t.Errorf("expected each event as its own response line, got %q", logger.responses)
+	}
+}
+
+func TestNewLoggingTransport_WithoutLogger(t *testing.T) {
+	if newLoggingTransport(nil, nil) != http.DefaultTransport {
+		t.Error("expected the base transport without a logger")
+	}
+}
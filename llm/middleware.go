@@ -0,0 +1,414 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProviderMiddleware wraps a provider to add behaviour around its calls
+type ProviderMiddleware func(Provider) Provider
+
+// Chain wraps p with the given middlewares. The first middleware is the
+// outermost one, so it sees each call first.
+func Chain(p Provider, middlewares ...ProviderMiddleware) Provider {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if middlewares[i] != nil {
+			p = middlewares[i](p)
+		}
+	}
+	return p
+}
+
+// AsModelRefresher returns the ModelRefresher of p, looking through the
+// middlewares wrapping it
+func AsModelRefresher(p Provider) (ModelRefresher, bool) {
+	return unwrapAs[ModelRefresher](p)
+}
+
+// AsImageProvider returns the ImageProvider of p, looking through the
+// middlewares wrapping it
+func AsImageProvider(p Provider) (ImageProvider, bool) {
+	return unwrapAs[ImageProvider](p)
+}
+
+// unwrapAs returns the first of p and the providers it wraps that implements T
+func unwrapAs[T any](p Provider) (T, bool) {
+	for p != nil {
+		if t, ok := p.(T); ok {
+			return t, true
+		}
+		wrapper, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			break
+		}
+		p = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// Logger is the logging interface used by WithLogging
+type Logger interface {
+	Info(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// loggingProvider logs the duration and outcome of each request
+type loggingProvider struct {
+	Provider
+	logger Logger
+}
+
+// WithLogging logs every chat request with its duration and result size
+func WithLogging(logger Logger) ProviderMiddleware {
+	return func(p Provider) Provider {
+		return &loggingProvider{Provider: p, logger: logger}
+	}
+}
+
+// Unwrap returns the wrapped provider
+func (p *loggingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// WithModel keeps the logging around the copy with the other model
+func (p *loggingProvider) WithModel(model string) Provider {
+	return &loggingProvider{Provider: p.Provider.WithModel(model), logger: p.logger}
+}
+
+// WithParams keeps the logging around the copy with the other parameters
+func (p *loggingProvider) WithParams(params GenerationParams) Provider {
+	return &loggingProvider{Provider: p.Provider.WithParams(params), logger: p.logger}
+}
+
+func (p *loggingProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	return p.logStream("StreamChat", len(messages), func() (<-chan StreamResponse, error) {
+		return p.Provider.StreamChat(ctx, messages)
+	})
+}
+
+// StreamChatWithSystemPrompt is logged like StreamChat
+func (p *loggingProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	return p.logStream("StreamChatWithSystemPrompt", len(messages), func() (<-chan StreamResponse, error) {
+		return p.Provider.StreamChatWithSystemPrompt(ctx, systemPrompt, messages)
+	})
+}
+
+// logStream logs the outcome of the stream that start opens for method
+func (p *loggingProvider) logStream(method string, messageCount int, start func() (<-chan StreamResponse, error)) (<-chan StreamResponse, error) {
+	begin := time.Now()
+	upstream, err := start()
+	if err != nil {
+		p.logger.Error("[%s] %s failed after %v: %v", p.Name(), method, time.Since(begin), err)
+		return nil, err
+	}
+
+	out := make(chan StreamResponse)
+	go func() {
+		defer close(out)
+		size := 0
+		for chunk := range upstream {
+			size += len(chunk.Content)
+			if chunk.Error != nil {
+				p.logger.Error("[%s] %s error after %v: %v", p.Name(), method, time.Since(begin), chunk.Error)
+			} else if chunk.Done {
+				p.logger.Info("[%s] %s completed in %v (%d messages, %d bytes)", p.Name(), method, time.Since(begin), messageCount, size)
+			}
+			out <- chunk
+		}
+	}()
+	return out, nil
+}
+
+func (p *loggingProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	start := time.Now()
+	response, err := p.Provider.Chat(ctx, messages)
+	if err != nil {
+		p.logger.Error("[%s] Chat failed after %v: %v", p.Name(), time.Since(start), err)
+		return "", err
+	}
+	p.logger.Info("[%s] Chat completed in %v (%d messages, %d bytes)", p.Name(), time.Since(start), len(messages), len(response))
+	return response, nil
+}
+
+// ResponseCache stores complete responses by request key
+type ResponseCache interface {
+	Get(key string) (string, bool)
+	Set(key, response string)
+}
+
+// MemoryResponseCache is an in-memory LRU ResponseCache
+type MemoryResponseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // front = most recently used
+}
+
+type cacheEntry struct {
+	key      string
+	response string
+}
+
+// NewMemoryResponseCache creates a cache holding at most maxEntries responses
+func NewMemoryResponseCache(maxEntries int) *MemoryResponseCache {
+	return &MemoryResponseCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the cached response for key
+func (c *MemoryResponseCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).response, true
+}
+
+// Set stores a response, evicting the least recently used one when full
+func (c *MemoryResponseCache) Set(key, response string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).response = response
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, response: response})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cachingProvider answers repeated requests from a ResponseCache
+type cachingProvider struct {
+	Provider
+	cache ResponseCache
+	model string // Set by WithModel so models do not share responses
+	// params are the encoded overrides set by WithParams, for the same reason
+	params string
+}
+
+// WithResponseCache returns cached responses for identical requests. Only
+// complete, successful responses without tool calls are cached.
+func WithResponseCache(cache ResponseCache) ProviderMiddleware {
+	return func(p Provider) Provider {
+		return &cachingProvider{Provider: p, cache: cache}
+	}
+}
+
+// Unwrap returns the wrapped provider
+func (p *cachingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// WithModel shares the cache with the copy; the model is part of its keys
+func (p *cachingProvider) WithModel(model string) Provider {
+	return &cachingProvider{Provider: p.Provider.WithModel(model), cache: p.cache, model: model, params: p.params}
+}
+
+// WithParams shares the cache with the copy; the parameters are part of its keys
+func (p *cachingProvider) WithParams(params GenerationParams) Provider {
+	encoded, _ := json.Marshal(params)
+	return &cachingProvider{Provider: p.Provider.WithParams(params), cache: p.cache, model: p.model, params: string(encoded)}
+}
+
+// skipCacheKey is the context key set by SkipResponseCache
+type skipCacheKey struct{}
+
+// SkipResponseCache makes the requests of ctx bypass cached responses, for
+// regenerating an answer. Their responses replace the cached ones.
+func SkipResponseCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipCacheKey{}, true)
+}
+
+// cachedResponse returns the cached response of key unless ctx skips the cache
+func (p *cachingProvider) cachedResponse(ctx context.Context, key string) (string, bool) {
+	if skip, _ := ctx.Value(skipCacheKey{}).(bool); skip {
+		return "", false
+	}
+	return p.cache.Get(key)
+}
+
+// cacheKey identifies a request by provider, model, parameters and message history
+func (p *cachingProvider) cacheKey(method string, messages []Message) string {
+	data, _ := json.Marshal(messages)
+	sum := sha256.Sum256(append([]byte(p.Name()+"\x00"+p.model+"\x00"+p.params+"\x00"+method+"\x00"), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+func (p *cachingProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	return p.cachedStream(ctx, p.cacheKey("chat", messages), func() (<-chan StreamResponse, error) {
+		return p.Provider.StreamChat(ctx, messages)
+	})
+}
+
+// StreamChatWithSystemPrompt is cached like StreamChat. The prompt is part of
+// the key as the first message, so both share their responses.
+func (p *cachingProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	key := p.cacheKey("chat", PrependSystemPrompt(systemPrompt, messages))
+	return p.cachedStream(ctx, key, func() (<-chan StreamResponse, error) {
+		return p.Provider.StreamChatWithSystemPrompt(ctx, systemPrompt, messages)
+	})
+}
+
+// cachedStream streams the cached response of key, or the stream start opens,
+// caching its response
+func (p *cachingProvider) cachedStream(ctx context.Context, key string, start func() (<-chan StreamResponse, error)) (<-chan StreamResponse, error) {
+	if response, ok := p.cachedResponse(ctx, key); ok {
+		out := make(chan StreamResponse, 2)
+		out <- StreamResponse{Content: response}
+		out <- StreamResponse{Done: true}
+		close(out)
+		return out, nil
+	}
+
+	upstream, err := start()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamResponse)
+	go func() {
+		defer close(out)
+		var sb strings.Builder
+		cacheable := true
+		for chunk := range upstream {
+			sb.WriteString(chunk.Content)
+			if chunk.Error != nil || len(chunk.ToolCalls) > 0 {
+				cacheable = false
+			}
+			if chunk.Done && cacheable && sb.Len() > 0 {
+				p.cache.Set(key, sb.String())
+			}
+			out <- chunk
+		}
+	}()
+	return out, nil
+}
+
+func (p *cachingProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	// Shares the key with StreamChat since both return the same completion
+	key := p.cacheKey("chat", messages)
+	if response, ok := p.cachedResponse(ctx, key); ok {
+		return response, nil
+	}
+	response, err := p.Provider.Chat(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+	if response != "" {
+		p.cache.Set(key, response)
+	}
+	return response, nil
+}
+
+// rateLimitedProvider spaces requests at least interval apart
+type rateLimitedProvider struct {
+	Provider
+	limiter *rateLimiter
+}
+
+// WithRateLimit allows at most rps requests per second; extra requests wait
+// for their turn or until their context is cancelled. rps <= 0 disables it.
+func WithRateLimit(rps int) ProviderMiddleware {
+	if rps <= 0 {
+		return nil
+	}
+	return func(p Provider) Provider {
+		return &rateLimitedProvider{
+			Provider: p,
+			limiter:  &rateLimiter{interval: time.Second / time.Duration(rps)},
+		}
+	}
+}
+
+// Unwrap returns the wrapped provider
+func (p *rateLimitedProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// WithModel shares the limiter with the copy since the limit is per provider
+func (p *rateLimitedProvider) WithModel(model string) Provider {
+	return &rateLimitedProvider{Provider: p.Provider.WithModel(model), limiter: p.limiter}
+}
+
+// WithParams shares the limiter with the copy
+func (p *rateLimitedProvider) WithParams(params GenerationParams) Provider {
+	return &rateLimitedProvider{Provider: p.Provider.WithParams(params), limiter: p.limiter}
+}
+
+func (p *rateLimitedProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	if err := p.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.Provider.StreamChat(ctx, messages)
+}
+
+// StreamChatWithSystemPrompt waits for a slot like StreamChat
+func (p *rateLimitedProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	if err := p.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.Provider.StreamChatWithSystemPrompt(ctx, systemPrompt, messages)
+}
+
+func (p *rateLimitedProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	if err := p.limiter.wait(ctx); err != nil {
+		return "", err
+	}
+	return p.Provider.Chat(ctx, messages)
+}
+
+func (p *rateLimitedProvider) GenerateTitle(ctx context.Context, messages []Message) (string, error) {
+	if err := p.limiter.wait(ctx); err != nil {
+		return "", err
+	}
+	return p.Provider.GenerateTitle(ctx, messages)
+}
+
+// rateLimiter hands out evenly spaced request slots
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the caller's slot is reached
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,285 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProvider returns a fixed response and counts calls
+type fakeProvider struct {
+	response string
+	calls    int
+	trace    *[]string
+	// systemPrompts are the prompts StreamChatWithSystemPrompt was called with
+	systemPrompts []string
+}
+
+func (p *fakeProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	p.calls++
+	out := make(chan StreamResponse, 2)
+	out <- StreamResponse{Content: p.response}
+	out <- StreamResponse{Done: true}
+	close(out)
+	return out, nil
+}
+
+func (p *fakeProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	p.systemPrompts = append(p.systemPrompts, systemPrompt)
+	return p.StreamChat(ctx, messages)
+}
+
+func (p *fakeProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	p.calls++
+	if p.trace != nil {
+		*p.trace = append(*p.trace, "provider")
+	}
+	return p.response, nil
+}
+
+func (p *fakeProvider) GenerateTitle(ctx context.Context, messages []Message) (string, error) {
+	return "title", nil
+}
+
+func (p *fakeProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	return EstimateTokens(messages), nil
+}
+
+func (p *fakeProvider) Name() string           { return "fake" }
+func (p *fakeProvider) Models() []string       { return nil }
+func (p *fakeProvider) ContextWindowSize() int { return 0 }
+func (p *fakeProvider) ValidateConfig() error  { return nil }
+
+// WithModel answers with the model name appended so tests can tell the copies apart
+func (p *fakeProvider) WithModel(model string) Provider {
+	clone := *p
+	clone.response = p.response + " from " + model
+	return &clone
+}
+
+// WithParams answers with the temperature appended, like WithModel
+func (p *fakeProvider) WithParams(params GenerationParams) Provider {
+	clone := *p
+	if params.Temperature != nil {
+		clone.response = fmt.Sprintf("%s at %g", p.response, *params.Temperature)
+	}
+	return &clone
+}
+
+// tracingMiddleware records the order in which middlewares see a call
+func tracingMiddleware(name string, trace *[]string) ProviderMiddleware {
+	return func(p Provider) Provider {
+		return &tracingProvider{Provider: p, name: name, trace: trace}
+	}
+}
+
+type tracingProvider struct {
+	Provider
+	name  string
+	trace *[]string
+}
+
+func (p *tracingProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	*p.trace = append(*p.trace, p.name)
+	return p.Provider.Chat(ctx, messages)
+}
+
+func TestChain_Order(t *testing.T) {
+	var trace []string
+	provider := Chain(&fakeProvider{response: "ok", trace: &trace},
+		tracingMiddleware("outer", &trace),
+		nil, // disabled middlewares are skipped
+		tracingMiddleware("inner", &trace),
+	)
+
+	if _, err := provider.Chat(context.Background(), nil); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if got := strings.Join(trace, ","); got != "outer,inner,provider" {
+		t.Errorf("Unexpected call order: %s", got)
+	}
+}
+
+func TestWithResponseCache(t *testing.T) {
+	base := &fakeProvider{response: "cached answer"}
+	provider := Chain(base, WithResponseCache(NewMemoryResponseCache(10)))
+	messages := []Message{{Role: "user", Content: "hello"}}
+
+	for i := 0; i < 2; i++ {
+		stream, err := provider.StreamChat(context.Background(), messages)
+		if err != nil {
+			t.Fatalf("StreamChat failed: %v", err)
+		}
+		content, last := collectStream(t, stream)
+		if content != "cached answer" || !last.Done {
+			t.Errorf("Unexpected stream result: %q (done=%v)", content, last.Done)
+		}
+	}
+	if response, _ := provider.Chat(context.Background(), messages); response != "cached answer" {
+		t.Errorf("Unexpected chat response: %q", response)
+	}
+	if base.calls != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", base.calls)
+	}
+
+	if _, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "other"}}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if base.calls != 2 {
+		t.Errorf("Expected a different request to miss the cache, got %d calls", base.calls)
+	}
+}
+
+// lineLogger keeps the formatted log lines
+type lineLogger struct {
+	lines []string
+}
+
+func (l *lineLogger) Info(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *lineLogger) Error(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestMiddlewares_ForwardSystemPrompt(t *testing.T) {
+	base := &fakeProvider{response: "brief answer"}
+	logger := &lineLogger{}
+	provider := Chain(base, WithLogging(logger), WithResponseCache(NewMemoryResponseCache(10)), WithRateLimit(1000))
+	messages := []Message{{Role: "user", Content: "hello"}}
+
+	for i := 0; i < 2; i++ {
+		stream, err := provider.StreamChatWithSystemPrompt(context.Background(), "Be brief.", messages)
+		if err != nil {
+			t.Fatalf("StreamChatWithSystemPrompt failed: %v", err)
+		}
+		if content, _ := collectStream(t, stream); content != "brief answer" {
+			t.Errorf("Unexpected stream result: %q", content)
+		}
+	}
+
+	// The wrapped provider gets the prompt as is, once since the second
+	// request is answered from the cache
+	if len(base.systemPrompts) != 1 || base.systemPrompts[0] != "Be brief." {
+		t.Errorf("Expected the prompt to reach the provider once, got %q", base.systemPrompts)
+	}
+	if len(logger.lines) != 2 || !strings.Contains(logger.lines[0], "StreamChatWithSystemPrompt completed") {
+		t.Errorf("Expected both requests to be logged, got %q", logger.lines)
+	}
+
+	// The prompt is part of the cache key
+	stream, err := provider.StreamChatWithSystemPrompt(context.Background(), "Be verbose.", messages)
+	if err != nil {
+		t.Fatalf("StreamChatWithSystemPrompt failed: %v", err)
+	}
+	collectStream(t, stream)
+	if len(base.systemPrompts) != 2 {
+		t.Errorf("Expected another prompt to miss the cache, got %q", base.systemPrompts)
+	}
+}
+
+func TestWithResponseCache_Skip(t *testing.T) {
+	base := &fakeProvider{response: "first answer"}
+	provider := Chain(base, WithResponseCache(NewMemoryResponseCache(10)))
+	messages := []Message{{Role: "user", Content: "hello"}}
+
+	if _, err := provider.Chat(context.Background(), messages); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	base.response = "regenerated answer"
+	stream, err := provider.StreamChat(SkipResponseCache(context.Background()), messages)
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	if content, _ := collectStream(t, stream); content != "regenerated answer" {
+		t.Errorf("Expected the skipped cache to ask the provider, got %q", content)
+	}
+	if base.calls != 2 {
+		t.Errorf("Expected 2 upstream calls, got %d", base.calls)
+	}
+
+	// The regenerated response replaces the cached one
+	if response, _ := provider.Chat(context.Background(), messages); response != "regenerated answer" {
+		t.Errorf("Unexpected cached response: %q", response)
+	}
+	if base.calls != 2 {
+		t.Errorf("Expected the regenerated response to be cached, got %d calls", base.calls)
+	}
+}
+
+func TestWithModel_KeepsMiddlewares(t *testing.T) {
+	provider := Chain(&fakeProvider{response: "answer"},
+		WithResponseCache(NewMemoryResponseCache(10)),
+		WithRateLimit(100),
+	)
+	messages := []Message{{Role: "user", Content: "hello"}}
+
+	if response, _ := provider.Chat(context.Background(), messages); response != "answer" {
+		t.Fatalf("Unexpected response: %q", response)
+	}
+
+	switched := provider.WithModel("other-model")
+	if _, ok := switched.(*cachingProvider); !ok {
+		t.Fatalf("Expected the copy to keep the response cache, got %T", switched)
+	}
+	// The same messages must not be answered from the first model's cache entry
+	if response, _ := switched.Chat(context.Background(), messages); response != "answer from other-model" {
+		t.Errorf("Unexpected response after switching model: %q", response)
+	}
+	if response, _ := provider.Chat(context.Background(), messages); response != "answer" {
+		t.Errorf("Expected the original provider to be unchanged, got %q", response)
+	}
+
+	// Nor from the entry of other parameters
+	temperature := 0.2
+	tuned := provider.WithParams(GenerationParams{Temperature: &temperature})
+	if response, _ := tuned.Chat(context.Background(), messages); response != "answer at 0.2" {
+		t.Errorf("Unexpected response with other parameters: %q", response)
+	}
+}
+
+func TestMemoryResponseCache_Evicts(t *testing.T) {
+	cache := NewMemoryResponseCache(2)
+	cache.Set("a", "1")
+	cache.Set("b", "2")
+	cache.Get("a")
+	cache.Set("c", "3")
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected least recently used entry to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("Expected recently used entry to be kept")
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	if WithRateLimit(0) != nil {
+		t.Error("Expected rps <= 0 to disable rate limiting")
+	}
+
+	provider := Chain(&fakeProvider{response: "ok"}, WithRateLimit(20))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := provider.Chat(context.Background(), nil); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+	}
+	// Three requests at 20 rps need at least two 50ms intervals
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Requests were not rate limited: %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	provider.Chat(context.Background(), nil) // reserve the next slot
+	if _, err := provider.Chat(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled while waiting, got: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// mistralDefaultBaseURL is the Mistral AI API endpoint
+const mistralDefaultBaseURL = "https://api.mistral.ai/v1"
+
+// MistralProvider implements the Provider interface for Mistral AI.
+// The chat API is OpenAI-compatible, except that streamed tool calls arrive
+// complete in a single chunk and usually without an index.
+type MistralProvider struct {
+	*OpenAIProvider
+}
+
+// NewMistralProvider creates a new Mistral AI provider
+func NewMistralProvider(config Config) (*MistralProvider, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = mistralDefaultBaseURL
+	}
+	if config.ProviderName == "" {
+		config.ProviderName = "Mistral AI"
+	}
+	if config.Model == "" {
+		config.Model = "mistral-small-latest"
+	}
+
+	var bodyFields map[string]json.RawMessage
+	if config.SafePrompt {
+		bodyFields = map[string]json.RawMessage{"safe_prompt": json.RawMessage("true")}
+	}
+	base, err := newOpenAIProvider(config, bodyFields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MistralProvider{OpenAIProvider: base}, nil
+}
+
+// StreamChat implements streaming chat with function calling support
+func (p *MistralProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	responseChan := make(chan StreamResponse)
+
+	openaiMessages := make([]openai.ChatCompletionMessage, 0, len(messages))
+	for _, msg := range messages {
+		openaiMessages = append(openaiMessages, p.convertMessage(msg))
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model:       p.config.Model,
+		Messages:    openaiMessages,
+		MaxTokens:   p.config.MaxTokens,
+		Temperature: float32(p.config.Temperature),
+		TopP:        float32(p.config.TopP),
+		Stream:      true,
+	}
+	if p.config.Tools != nil {
+		req.Tools = convertTools(p.config.Tools)
+		req.ToolChoice = "auto"
+	}
+
+	// Mistral reports usage on the final chunk without stream_options
+	sink := &usageSink{}
+	ctx = withUsageSink(ctx, sink)
+
+	go func() {
+		defer close(responseChan)
+
+		stream, err := p.client.CreateChatCompletionStream(ctx, req)
+		if err != nil {
+			responseChan <- StreamResponse{Error: fmt.Errorf("failed to create stream: %w", err)}
+			return
+		}
+		defer stream.Close()
+
+		var toolCalls []ToolCall
+		var finishReason openai.FinishReason
+		for {
+			response, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				done := newDoneResponse(sink.usage, sink.metadata(finishReason))
+				done.ToolCalls = toolCalls
+				responseChan <- done
+				return
+			}
+			if err != nil {
+				responseChan <- StreamResponse{Error: fmt.Errorf("stream error: %w", err)}
+				return
+			}
+
+			if len(response.Choices) == 0 {
+				continue
+			}
+
+			if response.Choices[0].FinishReason != "" {
+				finishReason = response.Choices[0].FinishReason
+			}
+			delta := response.Choices[0].Delta
+			toolCalls = mergeToolCalls(toolCalls, delta.ToolCalls)
+			if delta.Content != "" {
+				responseChan <- StreamResponse{Content: delta.Content}
+			}
+		}
+	}()
+
+	return responseChan, nil
+}
+
+// StreamChatWithSystemPrompt sends the system prompt as the first message.
+// It is overridden so the prompt goes through the tool calling StreamChat.
+func (p *MistralProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChat(ctx, PrependSystemPrompt(systemPrompt, messages))
+}
+
+// Models returns supported models
+func (p *MistralProvider) Models() []string {
+	if len(p.config.Models) > 0 {
+		return p.config.Models
+	}
+	return []string{
+		"mistral-large-latest",
+		"mistral-medium-latest",
+		"mistral-small-latest",
+		"codestral-latest",
+		"pixtral-large-latest",
+		"ministral-8b-latest",
+		"ministral-3b-latest",
+		"open-mistral-nemo",
+	}
+}
+
+// WithModel returns a copy of the provider that uses model. The HTTP client is shared.
+func (p *MistralProvider) WithModel(model string) Provider {
+	clone := *p.OpenAIProvider
+	clone.config.Model = model
+	return &MistralProvider{OpenAIProvider: &clone}
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params
+func (p *MistralProvider) WithParams(params GenerationParams) Provider {
+	return &MistralProvider{OpenAIProvider: p.OpenAIProvider.withParams(params)}
+}
+
+// convertTools converts our Tool type to the OpenAI wire format
+func convertTools(tools []Tool) []openai.Tool {
+	result := make([]openai.Tool, 0, len(tools))
+	for _, tool := range tools {
+		result = append(result, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return result
+}
+
+// mergeToolCalls adds streamed tool call fragments to the accumulated calls.
+// OpenAI streams arguments in pieces keyed by index; Mistral sends each call
+// whole and without an index, so those are matched by position in the chunk.
+func mergeToolCalls(calls []ToolCall, deltas []openai.ToolCall) []ToolCall {
+	for i, delta := range deltas {
+		idx := len(calls)
+		if delta.Index != nil {
+			idx = *delta.Index
+		} else if delta.ID == "" && i < len(calls) {
+			idx = i
+		}
+
+		for len(calls) <= idx {
+			calls = append(calls, ToolCall{})
+		}
+
+		if delta.ID != "" {
+			calls[idx].ID = delta.ID
+		}
+		if delta.Function.Name != "" {
+			calls[idx].Name = delta.Function.Name
+		}
+		calls[idx].Arguments += delta.Function.Arguments
+	}
+	return calls
+}
@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newMistralFixtureServer serves a recorded streaming response and captures the request body
+func newMistralFixtureServer(t *testing.T, fixture string, body *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write(data)
+	}))
+}
+
+func collectStream(t *testing.T, stream <-chan StreamResponse) (string, StreamResponse) {
+	t.Helper()
+	var content strings.Builder
+	var last StreamResponse
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("stream error: %v", chunk.Error)
+		}
+		content.WriteString(chunk.Content)
+		last = chunk
+	}
+	return content.String(), last
+}
+
+func TestMistralProvider_StreamToolCall(t *testing.T) {
+	var body map[string]interface{}
+	server := newMistralFixtureServer(t, "testdata/mistral_stream_tool_call.txt", &body)
+	defer server.Close()
+
+	provider, err := NewMistralProvider(Config{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+		Tools: []Tool{{
+			Name:        "get_weather",
+			Description: "Get the current weather for a city",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"city": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"city"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewMistralProvider failed: %v", err)
+	}
+
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "What's the weather in Paris?"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	_, last := collectStream(t, stream)
+
+	if body["tool_choice"] != "auto" {
+		t.Errorf("Expected tool_choice auto, got: %v", body["tool_choice"])
+	}
+	if tools, ok := body["tools"].([]interface{}); !ok || len(tools) != 1 {
+		t.Errorf("Expected 1 tool in request, got: %v", body["tools"])
+	}
+
+	if !last.Done {
+		t.Fatal("Expected final chunk to be Done")
+	}
+	if len(last.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(last.ToolCalls))
+	}
+	call := last.ToolCalls[0]
+	if call.ID != "D681PevKs" || call.Name != "get_weather" {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+	if call.Arguments != `{"city": "Paris", "unit": "celsius"}` {
+		t.Errorf("Unexpected arguments: %s", call.Arguments)
+	}
+}
+
+func TestMistralProvider_StreamTextWithoutTools(t *testing.T) {
+	var body map[string]interface{}
+	server := newMistralFixtureServer(t, "testdata/mistral_stream_text.txt", &body)
+	defer server.Close()
+
+	provider, err := NewMistralProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewMistralProvider failed: %v", err)
+	}
+
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "Salut"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, last := collectStream(t, stream)
+
+	if _, ok := body["tool_choice"]; ok {
+		t.Error("tool_choice should not be sent without tools")
+	}
+	if body["model"] != "mistral-small-latest" {
+		t.Errorf("Expected default model, got: %v", body["model"])
+	}
+	if content != "Bonjour !" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+	if len(last.ToolCalls) != 0 {
+		t.Errorf("Expected no tool calls, got: %+v", last.ToolCalls)
+	}
+	if _, ok := body["stream_options"]; ok {
+		t.Error("stream_options should not be sent to Mistral")
+	}
+	if _, ok := body["safe_prompt"]; ok {
+		t.Error("safe_prompt should not be sent unless enabled")
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 8 || last.Usage.CompletionTokens != 3 {
+		t.Errorf("Unexpected usage: %+v", last.Usage)
+	}
+}
+
+func TestMistralProvider_SafePrompt(t *testing.T) {
+	var body map[string]interface{}
+	server := newMistralFixtureServer(t, "testdata/mistral_stream_text.txt", &body)
+	defer server.Close()
+
+	provider, err := NewMistralProvider(Config{APIKey: "test-key", BaseURL: server.URL, SafePrompt: true})
+	if err != nil {
+		t.Fatalf("NewMistralProvider failed: %v", err)
+	}
+
+	// Copies for another model keep the setting
+	stream, err := provider.WithModel("mistral-large-latest").StreamChat(context.Background(), []Message{{Role: "user", Content: "Salut"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	if content, _ := collectStream(t, stream); content != "Bonjour !" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	if body["safe_prompt"] != true {
+		t.Errorf("Expected safe_prompt true, got: %v", body["safe_prompt"])
+	}
+	if body["model"] != "mistral-large-latest" {
+		t.Errorf("Expected the copy's model, got: %v", body["model"])
+	}
+}
@@ -0,0 +1,217 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrMockFailure is the error a MockProvider streams on its failing call
+var ErrMockFailure = errors.New("mock provider failure")
+
+// MockConfig configures a MockProvider
+type MockConfig struct {
+	// Responses are streamed in turn, one per StreamChat call, starting over
+	// after the last one. Chat always returns the first.
+	Responses []string
+	// StreamDelay is the pause before each streamed chunk
+	StreamDelay time.Duration
+	// ErrorAfter makes the Nth StreamChat call (1-based) stream ErrMockFailure
+	// instead of a response. 0 never fails.
+	ErrorAfter int
+	// Metadata is sent with the final chunk of every response
+	Metadata map[string]interface{}
+	// TokenCount is returned by CountTokens; 0 returns EstimateTokens
+	TokenCount int
+	// ContextWindow is returned by ContextWindowSize
+	ContextWindow int
+}
+
+// MockProvider is a Provider that answers with canned responses without any
+// network access, for tests of code that talks to providers
+type MockProvider struct {
+	config MockConfig
+	model  string
+	params GenerationParams
+	state  *mockState // Shared with the copies made by WithModel and WithParams
+}
+
+// mockState records the calls made to a MockProvider
+type mockState struct {
+	mu       sync.Mutex
+	calls    int
+	requests [][]Message
+	params   []GenerationParams
+	models   []string    // Model of each StreamChat call
+	counted  [][]Message // Messages of the CountTokens calls
+}
+
+// NewMockProvider creates a mock provider
+func NewMockProvider(config MockConfig) *MockProvider {
+	if len(config.Responses) == 0 {
+		config.Responses = []string{"mock response"}
+	}
+	return &MockProvider{
+		config: config,
+		model:  "mock-model",
+		state:  &mockState{},
+	}
+}
+
+// StreamChat streams the next response word by word
+func (p *MockProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	p.state.mu.Lock()
+	p.state.calls++
+	call := p.state.calls
+	p.state.requests = append(p.state.requests, append([]Message(nil), messages...))
+	p.state.params = append(p.state.params, p.params)
+	p.state.models = append(p.state.models, p.model)
+	p.state.mu.Unlock()
+
+	responseChan := make(chan StreamResponse)
+
+	go func() {
+		defer close(responseChan)
+
+		send := func(resp StreamResponse) bool {
+			select {
+			case responseChan <- resp:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if call == p.config.ErrorAfter {
+			send(StreamResponse{Error: ErrMockFailure})
+			return
+		}
+
+		response := p.config.Responses[(call-1)%len(p.config.Responses)]
+		chunks := strings.SplitAfter(response, " ")
+		for _, chunk := range chunks {
+			if p.config.StreamDelay > 0 {
+				select {
+				case <-time.After(p.config.StreamDelay):
+				case <-ctx.Done():
+					send(StreamResponse{Error: ctx.Err()})
+					return
+				}
+			}
+			if !send(StreamResponse{Content: chunk}) {
+				return
+			}
+		}
+
+		promptTokens := 0
+		for _, msg := range messages {
+			promptTokens += len(strings.Fields(msg.Content))
+		}
+		send(newDoneResponse(&Usage{PromptTokens: promptTokens, CompletionTokens: len(chunks)}, p.config.Metadata))
+	}()
+
+	return responseChan, nil
+}
+
+// StreamChatWithSystemPrompt streams like StreamChat with the system prompt
+// as the first message, so Requests records it
+func (p *MockProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChat(ctx, PrependSystemPrompt(systemPrompt, messages))
+}
+
+// Chat returns the first response
+func (p *MockProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return p.config.Responses[0], nil
+}
+
+// GenerateTitle returns a fixed title
+func (p *MockProvider) GenerateTitle(ctx context.Context, messages []Message) (string, error) {
+	return "Mock Conversation", nil
+}
+
+// Name returns the provider name
+func (p *MockProvider) Name() string {
+	return "Mock"
+}
+
+// Models returns the model in use
+func (p *MockProvider) Models() []string {
+	return []string{p.model}
+}
+
+// WithModel returns a copy of the provider that uses model. The copy shares
+// the responses and the call count.
+func (p *MockProvider) WithModel(model string) Provider {
+	clone := *p
+	clone.model = model
+	return &clone
+}
+
+// WithParams returns a copy of the provider that records params with its
+// requests. The copy shares the responses and the call count.
+func (p *MockProvider) WithParams(params GenerationParams) Provider {
+	clone := *p
+	clone.params = params
+	return &clone
+}
+
+// CountTokens returns MockConfig.TokenCount, or the estimate if it is 0
+func (p *MockProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	p.state.mu.Lock()
+	p.state.counted = append(p.state.counted, append([]Message(nil), messages...))
+	p.state.mu.Unlock()
+	if p.config.TokenCount > 0 {
+		return p.config.TokenCount, nil
+	}
+	return EstimateTokens(messages), nil
+}
+
+// ContextWindowSize returns MockConfig.ContextWindow
+func (p *MockProvider) ContextWindowSize() int {
+	return p.config.ContextWindow
+}
+
+// ValidateConfig always succeeds
+func (p *MockProvider) ValidateConfig() error {
+	return nil
+}
+
+// Calls returns the number of StreamChat calls
+func (p *MockProvider) Calls() int {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	return p.state.calls
+}
+
+// Requests returns the messages of each StreamChat call
+func (p *MockProvider) Requests() [][]Message {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	return append([][]Message(nil), p.state.requests...)
+}
+
+// CountRequests returns the messages of each CountTokens call
+func (p *MockProvider) CountRequests() [][]Message {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	return append([][]Message(nil), p.state.counted...)
+}
+
+// Params returns the parameter overrides of each StreamChat call
+func (p *MockProvider) Params() []GenerationParams {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	return append([]GenerationParams(nil), p.state.params...)
+}
+
+// RequestModels returns the model each StreamChat call was made with
+func (p *MockProvider) RequestModels() []string {
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	return append([]string(nil), p.state.models...)
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// streamError reads a stream to the end and returns its first error
+func streamError(stream <-chan StreamResponse) error {
+	var err error
+	for chunk := range stream {
+		if chunk.Error != nil && err == nil {
+			err = chunk.Error
+		}
+	}
+	return err
+}
+
+func TestMockProvider_StreamsResponsesInTurn(t *testing.T) {
+	p := NewMockProvider(MockConfig{Responses: []string{"first answer here", "second"}})
+	messages := []Message{{Role: "user", Content: "hello there"}}
+
+	for _, want := range []string{"first answer here", "second", "first answer here"} {
+		stream, err := p.StreamChat(context.Background(), messages)
+		if err != nil {
+			t.Fatalf("StreamChat failed: %v", err)
+		}
+		got, done := collectStream(t, stream)
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if !done.Done || done.Usage == nil || done.Usage.PromptTokens != 2 {
+			t.Errorf("unexpected done chunk: %+v", done)
+		}
+	}
+	if p.Calls() != 3 || len(p.Requests()) != 3 {
+		t.Errorf("Calls() = %d, Requests() = %d, want 3", p.Calls(), len(p.Requests()))
+	}
+
+	chat, err := p.Chat(context.Background(), messages)
+	if err != nil || chat != "first answer here" {
+		t.Errorf("Chat() = %q, %v", chat, err)
+	}
+}
+
+func TestMockProvider_StreamDelay(t *testing.T) {
+	p := NewMockProvider(MockConfig{Responses: []string{"a b c"}, StreamDelay: 10 * time.Millisecond})
+
+	start := time.Now()
+	stream, err := p.StreamChat(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	chunks := 0
+	for chunk := range stream {
+		if chunk.Content != "" {
+			chunks++
+		}
+	}
+	if chunks != 3 {
+		t.Errorf("got %d chunks, want 3", chunks)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("stream took %v, want at least 30ms", elapsed)
+	}
+}
+
+func TestMockProvider_ErrorAfter(t *testing.T) {
+	p := NewMockProvider(MockConfig{Responses: []string{"ok"}, ErrorAfter: 2})
+
+	for call, wantErr := range []bool{false, true, false} {
+		stream, err := p.StreamChat(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("StreamChat failed: %v", err)
+		}
+		err = streamError(stream)
+		if wantErr != errors.Is(err, ErrMockFailure) {
+			t.Errorf("call %d: got error %v, want failure: %v", call+1, err, wantErr)
+		}
+	}
+}
+
+func TestMockProvider_WithModelSharesCalls(t *testing.T) {
+	p := NewMockProvider(MockConfig{})
+	clone := p.WithModel("other")
+
+	if models := clone.Models(); len(models) != 1 || models[0] != "other" {
+		t.Errorf("clone Models() = %v", models)
+	}
+	stream, err := clone.StreamChat(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	collectStream(t, stream)
+	if p.Calls() != 1 {
+		t.Errorf("Calls() = %d, want 1", p.Calls())
+	}
+}
@@ -10,13 +10,27 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
+// ollamaModelsTimeout limits the request listing the installed models
+const ollamaModelsTimeout = 5 * time.Second
+
 // OllamaProvider implements the Provider interface for Ollama
 type OllamaProvider struct {
 	config Config
 	client *http.Client
+	// Models installed on the server, shared with the copies made by
+	// WithModel and WithParams
+	installed *ollamaModels
+}
+
+// ollamaModels are the models the server listed last
+type ollamaModels struct {
+	mu    sync.RWMutex
+	names []string
 }
 
 // NewOllamaProvider creates a new Ollama provider
@@ -34,28 +48,104 @@ func NewOllamaProvider(config Config) (*OllamaProvider, error) {
 
 	// For streaming responses, we don't want a global timeout
 	// Only set connection timeout via Transport
-	client := &http.Client{
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ResponseHeaderTimeout: 120 * time.Second, // Increased for slower models
-			// No IdleConnTimeout or overall timeout for streaming
-		},
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Duration(config.Timeout) * time.Second, // Loading a model can take minutes
+		// No IdleConnTimeout or overall timeout for streaming
+	}
+	if err := SetProxy(transport, config.ProxyURL); err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: newLoggingTransport(transport, config.RequestLogger)}
+
+	p := &OllamaProvider{
+		config:    config,
+		client:    client,
+		installed: &ollamaModels{},
+	}
+	// List the installed models in the background; until they arrive, or if
+	// the server can't be reached, Models returns the configured ones
+	go func() {
+		if _, err := p.RefreshModels(context.Background()); err != nil && config.Logger != nil {
+			config.Logger.Debug("Failed to list Ollama models: %v", err)
+		}
+	}()
+	return p, nil
+}
+
+// ollamaTagsResponse is the response of /api/tags
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// RefreshModels lists the models installed on the server and returns them
+// from Models. If that fails the previous list is kept.
+func (p *OllamaProvider) RefreshModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, ollamaModelsTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.config.BaseURL, "/")+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list models: %s", resp.Status)
+	}
+
+	var tags ollamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode models: %w", err)
+	}
+	names := make([]string, 0, len(tags.Models))
+	for _, model := range tags.Models {
+		if model.Name != "" {
+			names = append(names, model.Name)
+		}
 	}
 
-	return &OllamaProvider{
-		config: config,
-		client: client,
-	}, nil
+	p.installed.mu.Lock()
+	p.installed.names = names
+	p.installed.mu.Unlock()
+	return names, nil
 }
 
 type ollamaChatRequest struct {
 	Model    string          `json:"model"`
 	Messages []ollamaMessage `json:"messages"`
 	Stream   bool            `json:"stream"`
+	// Options override the model's sampling settings, see ollamaOptions
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// ollamaOptions returns the sampling settings of config as Ollama options.
+// NewProvider configures Ollama without any, so they come from WithParams;
+// unset ones keep the model's own settings.
+func ollamaOptions(config Config) map[string]interface{} {
+	options := make(map[string]interface{})
+	if config.Temperature > 0 {
+		options["temperature"] = config.Temperature
+	}
+	if config.TopP > 0 {
+		options["top_p"] = config.TopP
+	}
+	if config.MaxTokens > 0 {
+		options["num_predict"] = config.MaxTokens
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return options
 }
 
 type ollamaMessage struct {
@@ -68,6 +158,11 @@ type ollamaChatResponse struct {
 	CreatedAt string        `json:"created_at"`
 	Message   ollamaMessage `json:"message"`
 	Done      bool          `json:"done"`
+	// DoneReason is why generation stopped, reported on the final message
+	DoneReason string `json:"done_reason,omitempty"`
+	// Token counts, reported on the final message
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"`
+	EvalCount       int `json:"eval_count,omitempty"`
 }
 
 // StreamChat implements streaming chat
@@ -87,6 +182,7 @@ func (p *OllamaProvider) StreamChat(ctx context.Context, messages []Message) (<-
 		Model:    p.config.Model,
 		Messages: ollamaMessages,
 		Stream:   true,
+		Options:  ollamaOptions(p.config),
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -131,7 +227,14 @@ func (p *OllamaProvider) StreamChat(ctx context.Context, messages []Message) (<-
 			}
 
 			if chatResp.Done {
-				responseChan <- StreamResponse{Done: true}
+				metadata := make(map[string]interface{})
+				if chatResp.DoneReason != "" {
+					metadata[MetadataStopReason] = chatResp.DoneReason
+				}
+				responseChan <- newDoneResponse(&Usage{
+					PromptTokens:     chatResp.PromptEvalCount,
+					CompletionTokens: chatResp.EvalCount,
+				}, metadata)
 				return
 			}
 		}
@@ -144,6 +247,11 @@ func (p *OllamaProvider) StreamChat(ctx context.Context, messages []Message) (<-
 	return responseChan, nil
 }
 
+// StreamChatWithSystemPrompt sends the system prompt as the first message
+func (p *OllamaProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChat(ctx, PrependSystemPrompt(systemPrompt, messages))
+}
+
 // Chat implements non-streaming chat
 func (p *OllamaProvider) Chat(ctx context.Context, messages []Message) (string, error) {
 	// Convert messages to Ollama format
@@ -159,6 +267,7 @@ func (p *OllamaProvider) Chat(ctx context.Context, messages []Message) (string,
 		Model:    p.config.Model,
 		Messages: ollamaMessages,
 		Stream:   false,
+		Options:  ollamaOptions(p.config),
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -196,8 +305,15 @@ func (p *OllamaProvider) Name() string {
 	return p.config.ProviderName
 }
 
-// Models returns supported models (these are examples, actual models depend on what's installed)
+// Models returns the models installed on the server once they have been
+// listed, else the configured ones or some common examples
 func (p *OllamaProvider) Models() []string {
+	p.installed.mu.RLock()
+	installed := p.installed.names
+	p.installed.mu.RUnlock()
+	if len(installed) > 0 {
+		return installed
+	}
 	// Return models from config if available
 	if len(p.config.Models) > 0 {
 		return p.config.Models
@@ -214,6 +330,21 @@ func (p *OllamaProvider) Models() []string {
 	}
 }
 
+// WithModel returns a copy of the provider that uses model. The HTTP client is shared.
+func (p *OllamaProvider) WithModel(model string) Provider {
+	clone := *p
+	clone.config.Model = model
+	return &clone
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params. The HTTP client is shared.
+func (p *OllamaProvider) WithParams(params GenerationParams) Provider {
+	clone := *p
+	clone.config = params.apply(clone.config)
+	return &clone
+}
+
 // GenerateTitle generates a short title based on the conversation
 func (p *OllamaProvider) GenerateTitle(ctx context.Context, messages []Message) (string, error) {
 	// Build a prompt to generate a title
@@ -250,6 +381,17 @@ func (p *OllamaProvider) GenerateTitle(ctx context.Context, messages []Message)
 	return title, nil
 }
 
+// CountTokens estimates the tokens with EstimateTokens, as Ollama has no
+// counting API
+func (p *OllamaProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	return EstimateTokens(messages), nil
+}
+
+// ContextWindowSize returns the known context window of the model
+func (p *OllamaProvider) ContextWindowSize() int {
+	return ContextWindow(p.config.Model)
+}
+
 // ValidateConfig validates the configuration
 func (p *OllamaProvider) ValidateConfig() error {
 	if p.config.BaseURL == "" {
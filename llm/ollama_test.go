@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOllamaProvider_RefreshModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"models": [{"name": "llama3:8b"}, {"name": "qwen2.5:7b"}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(Config{BaseURL: server.URL, Models: []string{"configured"}})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider failed: %v", err)
+	}
+	models, err := provider.RefreshModels(context.Background())
+	if err != nil {
+		t.Fatalf("RefreshModels failed: %v", err)
+	}
+	want := []string{"llama3:8b", "qwen2.5:7b"}
+	if !reflect.DeepEqual(models, want) || !reflect.DeepEqual(provider.Models(), want) {
+		t.Errorf("models = %v, Models() = %v, want %v", models, provider.Models(), want)
+	}
+	// Copies share the list
+	if got := provider.WithModel("llama3:8b").Models(); !reflect.DeepEqual(got, want) {
+		t.Errorf("copy lists %v, want %v", got, want)
+	}
+
+	// The refresher is found behind middlewares
+	wrapped := Chain(provider, WithResponseCache(NewMemoryResponseCache(1)), WithRateLimit(10))
+	if refresher, ok := AsModelRefresher(wrapped); !ok || refresher != provider {
+		t.Errorf("AsModelRefresher = %v, %v; want the Ollama provider", refresher, ok)
+	}
+	if _, ok := AsModelRefresher(NewMockProvider(MockConfig{})); ok {
+		t.Error("AsModelRefresher found a refresher in the mock provider")
+	}
+}
+
+func TestOllamaProvider_RefreshModels_FallsBack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(Config{BaseURL: server.URL, Models: []string{"configured"}})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider failed: %v", err)
+	}
+	if _, err := provider.RefreshModels(context.Background()); err == nil {
+		t.Fatal("expected an error from a failing server")
+	}
+	if got := provider.Models(); !reflect.DeepEqual(got, []string{"configured"}) {
+		t.Errorf("Models() = %v, want the configured models", got)
+	}
+}
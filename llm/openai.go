@@ -3,9 +3,12 @@ package llm
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"sync/atomic"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -14,15 +17,42 @@ import (
 type OpenAIProvider struct {
 	client *openai.Client
 	config Config
+	// For the token counting endpoint, which go-openai doesn't know
+	httpClient *http.Client
+	baseURL    string
+	// Set once the server turned out to have no token counting endpoint;
+	// shared with the copies made by WithModel
+	tokenCountUnsupported *atomic.Bool
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
 func NewOpenAIProvider(config Config) (*OpenAIProvider, error) {
+	return newOpenAIProvider(config, nil)
+}
+
+// newOpenAIProvider creates an OpenAI provider that adds bodyFields to its
+// chat completion requests, for OpenAI compatible APIs with extra parameters
+func newOpenAIProvider(config Config, bodyFields map[string]json.RawMessage) (*OpenAIProvider, error) {
 	// Allow empty API key - validation happens at runtime
 	clientConfig := openai.DefaultConfig(config.APIKey)
 	if config.BaseURL != "" {
 		clientConfig.BaseURL = config.BaseURL
 	}
+	return newOpenAIProviderWithClientConfig(config, clientConfig, bodyFields)
+}
+
+// newOpenAIProviderWithClientConfig creates an OpenAI provider whose client
+// uses clientConfig, e.g. with Azure's URLs and authentication
+func newOpenAIProviderWithClientConfig(config Config, clientConfig openai.ClientConfig, bodyFields map[string]json.RawMessage) (*OpenAIProvider, error) {
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	// Capture token usage of streamed responses (see openai_usage.go)
+	clientConfig.HTTPClient = &http.Client{Transport: &usageTransport{
+		base:       newLoggingTransport(transport, config.RequestLogger),
+		bodyFields: bodyFields,
+	}}
 
 	client := openai.NewClientWithConfig(clientConfig)
 
@@ -39,13 +69,23 @@ func NewOpenAIProvider(config Config) (*OpenAIProvider, error) {
 	}
 
 	return &OpenAIProvider{
-		client: client,
-		config: config,
+		client:                client,
+		config:                config,
+		httpClient:            clientConfig.HTTPClient,
+		baseURL:               clientConfig.BaseURL,
+		tokenCountUnsupported: &atomic.Bool{},
 	}, nil
 }
 
 // StreamChat implements streaming chat
 func (p *OpenAIProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	// Request usage with the final chunk and capture it from the stream
+	return p.streamChat(ctx, messages, &usageSink{includeUsage: true})
+}
+
+// streamChat streams a chat completion, capturing what the client doesn't
+// decode in sink
+func (p *OpenAIProvider) streamChat(ctx context.Context, messages []Message, sink *usageSink) (<-chan StreamResponse, error) {
 	responseChan := make(chan StreamResponse)
 
 	// Convert messages to OpenAI format
@@ -60,8 +100,12 @@ func (p *OpenAIProvider) StreamChat(ctx context.Context, messages []Message) (<-
 		Messages:    openaiMessages,
 		MaxTokens:   p.config.MaxTokens,
 		Temperature: float32(p.config.Temperature),
+		TopP:        float32(p.config.TopP),
 		Stream:      true,
 	}
+	p.setResponseFormat(&req)
+
+	ctx = withUsageSink(ctx, sink)
 
 	go func() {
 		defer close(responseChan)
@@ -73,10 +117,11 @@ func (p *OpenAIProvider) StreamChat(ctx context.Context, messages []Message) (<-
 		}
 		defer stream.Close()
 
+		var finishReason openai.FinishReason
 		for {
 			response, err := stream.Recv()
 			if errors.Is(err, io.EOF) {
-				responseChan <- StreamResponse{Done: true}
+				responseChan <- newDoneResponse(sink.usage, sink.metadata(finishReason))
 				return
 			}
 			if err != nil {
@@ -85,6 +130,9 @@ func (p *OpenAIProvider) StreamChat(ctx context.Context, messages []Message) (<-
 			}
 
 			if len(response.Choices) > 0 {
+				if response.Choices[0].FinishReason != "" {
+					finishReason = response.Choices[0].FinishReason
+				}
 				content := response.Choices[0].Delta.Content
 				if content != "" {
 					responseChan <- StreamResponse{Content: content}
@@ -96,6 +144,20 @@ func (p *OpenAIProvider) StreamChat(ctx context.Context, messages []Message) (<-
 	return responseChan, nil
 }
 
+// setResponseFormat asks for a JSON object in JSON mode
+func (p *OpenAIProvider) setResponseFormat(req *openai.ChatCompletionRequest) {
+	if p.config.JSONMode {
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		}
+	}
+}
+
+// StreamChatWithSystemPrompt sends the system prompt as the first message
+func (p *OpenAIProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChat(ctx, PrependSystemPrompt(systemPrompt, messages))
+}
+
 // convertMessage converts our Message type to OpenAI format, handling attachments
 func (p *OpenAIProvider) convertMessage(msg Message) openai.ChatCompletionMessage {
 	// If no attachments, return simple text message
@@ -151,7 +213,9 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message) (string,
 		Messages:    openaiMessages,
 		MaxTokens:   p.config.MaxTokens,
 		Temperature: float32(p.config.Temperature),
+		TopP:        float32(p.config.TopP),
 	}
+	p.setResponseFormat(&req)
 
 	resp, err := p.client.CreateChatCompletion(ctx, req)
 	if err != nil {
@@ -186,6 +250,26 @@ func (p *OpenAIProvider) Models() []string {
 	}
 }
 
+// WithModel returns a copy of the provider that uses model. The HTTP client is shared.
+func (p *OpenAIProvider) WithModel(model string) Provider {
+	clone := *p
+	clone.config.Model = model
+	return &clone
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params. The HTTP client is shared.
+func (p *OpenAIProvider) WithParams(params GenerationParams) Provider {
+	return p.withParams(params)
+}
+
+// withParams is WithParams for the providers wrapping OpenAIProvider
+func (p *OpenAIProvider) withParams(params GenerationParams) *OpenAIProvider {
+	clone := *p
+	clone.config = params.apply(clone.config)
+	return &clone
+}
+
 // GenerateTitle generates a short title based on the conversation
 func (p *OpenAIProvider) GenerateTitle(ctx context.Context, messages []Message) (string, error) {
 	// Build a prompt to generate a title
@@ -222,6 +306,74 @@ func (p *OpenAIProvider) GenerateTitle(ctx context.Context, messages []Message)
 	return title, nil
 }
 
+// CountTokens asks the /models/{model}/tokens endpoint that some OpenAI
+// compatible servers offer. Servers without it get EstimateTokens, and aren't
+// asked again.
+func (p *OpenAIProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	if p.config.Model == "" || p.tokenCountUnsupported.Load() {
+		return EstimateTokens(messages), nil
+	}
+
+	req := openAITokenCountRequest{Messages: make([]openAITokenCountMessage, 0, len(messages))}
+	images := 0
+	for _, msg := range messages {
+		req.Messages = append(req.Messages, openAITokenCountMessage{Role: msg.Role, Content: msg.Content})
+		for _, att := range msg.Attachments {
+			if att.Type == "image" {
+				images++
+			}
+		}
+	}
+	setHeaders := func(httpReq *http.Request) {
+		httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	var result openAITokenCountResponse
+	err := countTokensRequest(ctx, p.httpClient, openAITokenCountURL(p.baseURL, p.config.Model), setHeaders, req, &result)
+	if errors.Is(err, errTokenCountUnsupported) {
+		p.tokenCountUnsupported.Store(true)
+		return EstimateTokens(messages), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	// The endpoint only sees the text
+	return result.Tokens + images*estimatedImageTokens, nil
+}
+
+// defaultImageSize is the size of generated images when none is asked for
+const defaultImageSize = openai.CreateImageSize1024x1024
+
+// GenerateImage generates a PNG image with DALL-E 3
+func (p *OpenAIProvider) GenerateImage(ctx context.Context, prompt string, size string) ([]byte, string, error) {
+	if size == "" {
+		size = defaultImageSize
+	}
+	resp, err := p.client.CreateImage(ctx, openai.ImageRequest{
+		Prompt:         prompt,
+		Model:          openai.CreateImageModelDallE3,
+		N:              1,
+		Size:           size,
+		ResponseFormat: openai.CreateImageResponseFormatB64JSON,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate image: %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, "", errors.New("no image in response")
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return data, "image/png", nil
+}
+
+// ContextWindowSize returns the known context window of the model
+func (p *OpenAIProvider) ContextWindowSize() int {
+	return ContextWindow(p.config.Model)
+}
+
 // ValidateConfig validates the configuration
 func (p *OpenAIProvider) ValidateConfig() error {
 	if p.config.APIKey == "" {
@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIProvider_StreamUsage(t *testing.T) {
+	var body map[string]interface{}
+	// The fixture server only checks the path, which is shared with Mistral
+	server := newMistralFixtureServer(t, "testdata/openai_stream_usage.txt", &body)
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, last := collectStream(t, stream)
+
+	options, ok := body["stream_options"].(map[string]interface{})
+	if !ok || options["include_usage"] != true {
+		t.Errorf("Expected stream_options.include_usage, got: %v", body["stream_options"])
+	}
+	if content != "Hello there" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+	if !last.Done || last.Usage == nil || last.Usage.TotalTokens != 11 {
+		t.Errorf("Expected 11 total tokens on the final chunk, got: %+v", last)
+	}
+	if last.Usage == nil || last.Usage.PromptTokens != 9 || last.Usage.CompletionTokens != 2 {
+		t.Errorf("Unexpected usage: %+v", last.Usage)
+	}
+	if last.Metadata[MetadataStopReason] != "stop" || last.Metadata[MetadataSystemFingerprint] != "fp_44709d6fcb" {
+		t.Errorf("Unexpected metadata: %v", last.Metadata)
+	}
+}
+
+func TestOpenAIProvider_WithModel(t *testing.T) {
+	var body map[string]interface{}
+	server := newMistralFixtureServer(t, "testdata/openai_stream_usage.txt", &body)
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+
+	stream, err := provider.WithModel("gpt-4o").StreamChat(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	collectStream(t, stream)
+
+	if body["model"] != "gpt-4o" {
+		t.Errorf("Expected the overridden model in the request, got: %v", body["model"])
+	}
+	if provider.config.Model != "gpt-4o-mini" {
+		t.Errorf("Expected the original provider to keep its model, got: %s", provider.config.Model)
+	}
+}
+
+func TestOpenAIProvider_WithParams(t *testing.T) {
+	var body map[string]interface{}
+	server := newMistralFixtureServer(t, "testdata/openai_stream_usage.txt", &body)
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+
+	temperature, maxTokens, topP := 0.0, 256, 0.5
+	tuned := provider.WithParams(GenerationParams{Temperature: &temperature, MaxTokens: &maxTokens, TopP: &topP})
+	stream, err := tuned.StreamChat(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	collectStream(t, stream)
+
+	// A temperature of 0 must still be sent
+	if temp, ok := body["temperature"].(float64); !ok || temp <= 0 || temp > 0.001 {
+		t.Errorf("Expected a temperature of about 0, got: %v", body["temperature"])
+	}
+	if body["max_tokens"] != float64(256) || body["top_p"] != 0.5 {
+		t.Errorf("Expected the overridden max_tokens and top_p, got: %v, %v", body["max_tokens"], body["top_p"])
+	}
+	if provider.config.MaxTokens != 4096 || provider.config.TopP != 0 {
+		t.Errorf("Expected the original provider to keep its settings, got: %+v", provider.config)
+	}
+}
+
+func TestOpenAIProvider_JSONMode(t *testing.T) {
+	var body map[string]interface{}
+	server := newMistralFixtureServer(t, "testdata/openai_stream_usage.txt", &body)
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	collectStream(t, stream)
+	if _, ok := body["response_format"]; ok {
+		t.Errorf("Expected no response_format without JSON mode, got: %v", body["response_format"])
+	}
+
+	jsonMode := true
+	stream, err = provider.WithParams(GenerationParams{JSONMode: &jsonMode}).StreamChat(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	collectStream(t, stream)
+	format, ok := body["response_format"].(map[string]interface{})
+	if !ok || format["type"] != "json_object" {
+		t.Errorf("Expected a json_object response_format, got: %v", body["response_format"])
+	}
+}
+
+func TestOpenAIProvider_GenerateImage(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nimage")
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/generations" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"created":1,"data":[{"b64_json":%q}]}`, base64.StdEncoding.EncodeToString(png))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	imageProvider, ok := AsImageProvider(WithLogging(nil)(provider))
+	if !ok {
+		t.Fatal("Expected the OpenAI provider to generate images through the middleware")
+	}
+
+	data, mimeType, err := imageProvider.GenerateImage(context.Background(), "a red panda", "")
+	if err != nil {
+		t.Fatalf("GenerateImage failed: %v", err)
+	}
+	if string(data) != string(png) || mimeType != "image/png" {
+		t.Errorf("Expected the decoded PNG, got %q (%s)", data, mimeType)
+	}
+	if body["prompt"] != "a red panda" || body["model"] != "dall-e-3" || body["size"] != "1024x1024" || body["response_format"] != "b64_json" {
+		t.Errorf("Unexpected request: %v", body)
+	}
+}
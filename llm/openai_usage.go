@@ -0,0 +1,229 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// The pinned go-openai client neither requests nor decodes token usage for
+// streamed completions. usageTransport fills that gap: it asks for usage via
+// stream_options and picks the usage object out of the SSE events while the
+// client reads them, storing it in the usageSink attached to the request context.
+// The citations Perplexity adds to its chunks, DeepSeek's reasoning_content
+// and the system fingerprint, which the client doesn't decode either, are
+// picked out the same way, the
+// response headers are kept, and vendor parameters the client doesn't know
+// are added to the request body.
+
+// usageSinkKey is the context key for the usageSink of a streaming request
+type usageSinkKey struct{}
+
+// usageSink receives the usage reported in a streamed response
+type usageSink struct {
+	// includeUsage adds stream_options.include_usage to the request body
+	includeUsage bool
+	usage        *Usage
+	citations    []string // Search result URLs reported by Perplexity
+	// systemFingerprint is reported by OpenAI with each chunk
+	systemFingerprint string
+	// header of the response, for vendor headers like Groq's timings
+	header http.Header
+	// collectReasoning queues the reasoning_content of every chunk (DeepSeek)
+	collectReasoning bool
+	reasoning        []string
+}
+
+// nextReasoning returns the reasoning_content of the next chunk the client
+// decodes. The reader runs ahead of the client, so there is one entry per
+// data line, empty when the chunk has no reasoning.
+func (s *usageSink) nextReasoning() string {
+	if len(s.reasoning) == 0 {
+		return ""
+	}
+	reasoning := s.reasoning[0]
+	s.reasoning = s.reasoning[1:]
+	return reasoning
+}
+
+// metadata returns the StreamResponse metadata of the stream with its finish
+// reason
+func (s *usageSink) metadata(finishReason openai.FinishReason) map[string]interface{} {
+	metadata := make(map[string]interface{})
+	if finishReason != "" {
+		metadata[MetadataStopReason] = string(finishReason)
+	}
+	if s.systemFingerprint != "" {
+		metadata[MetadataSystemFingerprint] = s.systemFingerprint
+	}
+	if len(s.citations) > 0 {
+		metadata[MetadataCitations] = s.citations
+	}
+	return metadata
+}
+
+// withUsageSink attaches a sink to the context of a streaming request
+func withUsageSink(ctx context.Context, sink *usageSink) context.Context {
+	return context.WithValue(ctx, usageSinkKey{}, sink)
+}
+
+// usageTransport wraps an http.RoundTripper to capture streamed token usage
+type usageTransport struct {
+	base http.RoundTripper
+	// bodyFields are added to the body of every chat completion request, for
+	// vendor parameters like Mistral's safe_prompt
+	bodyFields map[string]json.RawMessage
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *usageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sink, _ := req.Context().Value(usageSinkKey{}).(*usageSink)
+
+	fields := make(map[string]json.RawMessage)
+	if strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		for key, value := range t.bodyFields {
+			fields[key] = value
+		}
+	}
+	if sink != nil && sink.includeUsage {
+		fields["stream_options"] = json.RawMessage(`{"include_usage":true}`)
+	}
+
+	if len(fields) > 0 && req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = addBodyFields(body, fields)
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || sink == nil {
+		return resp, err
+	}
+	sink.header = resp.Header
+	resp.Body = &usageReader{ReadCloser: resp.Body, sink: sink}
+	return resp, nil
+}
+
+// addBodyFields sets fields in a JSON request body. The body is returned
+// unchanged if it cannot be decoded.
+func addBodyFields(body []byte, fields map[string]json.RawMessage) []byte {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return body
+	}
+	for key, value := range fields {
+		payload[key] = value
+	}
+	updated, err := json.Marshal(payload)
+	if err != nil {
+		return body
+	}
+	return updated
+}
+
+// usageReader scans SSE lines passing through it for a usage object
+type usageReader struct {
+	io.ReadCloser
+	sink *usageSink
+	line []byte // incomplete line carried over between reads
+}
+
+// Read implements io.Reader
+func (r *usageReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.line = append(r.line, p[:n]...)
+	for {
+		idx := bytes.IndexByte(r.line, '\n')
+		if idx < 0 {
+			break
+		}
+		r.parseLine(r.line[:idx])
+		r.line = r.line[idx+1:]
+	}
+	return n, err
+}
+
+// parseLine records the usage, citations and system fingerprint of an SSE
+// data line, if it has them
+func (r *usageReader) parseLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, []byte("data:")) {
+		return
+	}
+	if r.sink.collectReasoning {
+		r.parseReasoning(line)
+	}
+	if !bytes.Contains(line, []byte(`"usage"`)) && !bytes.Contains(line, []byte(`"citations"`)) &&
+		!bytes.Contains(line, []byte(`"system_fingerprint"`)) {
+		return
+	}
+
+	var event struct {
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+		} `json:"usage"`
+		Citations         []string `json:"citations"`
+		SystemFingerprint string   `json:"system_fingerprint"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(line[len("data:"):]), &event); err != nil {
+		return
+	}
+	// Every chunk repeats the citations found so far
+	if len(event.Citations) > 0 {
+		r.sink.citations = event.Citations
+	}
+	if event.SystemFingerprint != "" {
+		r.sink.systemFingerprint = event.SystemFingerprint
+	}
+	if event.Usage == nil {
+		return
+	}
+	r.sink.usage = &Usage{
+		PromptTokens:     event.Usage.PromptTokens,
+		CompletionTokens: event.Usage.CompletionTokens,
+		TotalTokens:      event.Usage.TotalTokens,
+	}
+}
+
+// parseReasoning queues the reasoning_content of an SSE data line. Only the
+// lines the client turns into chunks are queued: those starting with "data: "
+// that are neither [DONE] nor an error.
+func (r *usageReader) parseReasoning(line []byte) {
+	if !bytes.HasPrefix(line, []byte("data: ")) {
+		return
+	}
+	data := line[len("data: "):]
+	if string(data) == "[DONE]" || bytes.HasPrefix(data, []byte(`{"error":`)) {
+		return
+	}
+
+	var event struct {
+		Choices []struct {
+			Delta struct {
+				ReasoningContent string `json:"reasoning_content"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	var reasoning string
+	if err := json.Unmarshal(data, &event); err == nil && len(event.Choices) > 0 {
+		reasoning = event.Choices[0].Delta.ReasoningContent
+	}
+	r.sink.reasoning = append(r.sink.reasoning, reasoning)
+}
@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// perplexityDefaultBaseURL is the Perplexity AI API endpoint
+const perplexityDefaultBaseURL = "https://api.perplexity.ai"
+
+// citationsHeading starts the sources block appended to Perplexity answers
+const citationsHeading = "**Sources:**"
+
+// citationLinkRegex matches one line of the sources block: 1. [URL](URL)
+var citationLinkRegex = regexp.MustCompile(`^\d+\. \[.*\]\((\S+)\)$`)
+
+// PerplexityProvider implements the Provider interface for Perplexity AI.
+// The chat API is OpenAI-compatible and also returns the URLs of the web
+// search results the answer is based on, which are appended as a sources block.
+type PerplexityProvider struct {
+	*OpenAIProvider
+}
+
+// NewPerplexityProvider creates a new Perplexity AI provider
+func NewPerplexityProvider(config Config) (*PerplexityProvider, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = perplexityDefaultBaseURL
+	}
+	if config.ProviderName == "" {
+		config.ProviderName = "Perplexity"
+	}
+	if config.Model == "" {
+		config.Model = "sonar"
+	}
+
+	base, err := NewOpenAIProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PerplexityProvider{OpenAIProvider: base}, nil
+}
+
+// StreamChat implements streaming chat. The citations are sent as a last
+// content chunk in Markdown before the done chunk, except in JSON mode where
+// they would break the JSON object. The done chunk has them in its metadata.
+func (p *PerplexityProvider) StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error) {
+	// Perplexity reports usage and citations in the chunks without stream_options
+	stream, err := p.streamChat(ctx, messages, &usageSink{})
+	if err != nil || p.config.JSONMode {
+		return stream, err
+	}
+
+	responseChan := make(chan StreamResponse)
+	go func() {
+		defer close(responseChan)
+		for resp := range stream {
+			if citations, _ := resp.Metadata[MetadataCitations].([]string); resp.Done && len(citations) > 0 {
+				responseChan <- StreamResponse{Content: FormatCitations(citations)}
+			}
+			responseChan <- resp
+		}
+	}()
+	return responseChan, nil
+}
+
+// Chat implements non-streaming chat by collecting the stream, so the answer
+// ends with the same sources block
+func (p *PerplexityProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	stream, err := p.StreamChat(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+	var content strings.Builder
+	for resp := range stream {
+		if resp.Error != nil {
+			return "", resp.Error
+		}
+		content.WriteString(resp.Content)
+	}
+	return content.String(), nil
+}
+
+// StreamChatWithSystemPrompt sends the system prompt as the first message.
+// It is overridden so the citations are streamed as well.
+func (p *PerplexityProvider) StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error) {
+	return p.StreamChat(ctx, PrependSystemPrompt(systemPrompt, messages))
+}
+
+// Models returns supported models
+func (p *PerplexityProvider) Models() []string {
+	if len(p.config.Models) > 0 {
+		return p.config.Models
+	}
+	return []string{
+		"sonar",
+		"sonar-pro",
+		"sonar-reasoning",
+		"sonar-reasoning-pro",
+		"sonar-deep-research",
+	}
+}
+
+// WithModel returns a copy of the provider that uses model. The HTTP client is shared.
+func (p *PerplexityProvider) WithModel(model string) Provider {
+	clone := *p.OpenAIProvider
+	clone.config.Model = model
+	return &PerplexityProvider{OpenAIProvider: &clone}
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params
+func (p *PerplexityProvider) WithParams(params GenerationParams) Provider {
+	return &PerplexityProvider{OpenAIProvider: p.OpenAIProvider.withParams(params)}
+}
+
+// FormatCitations renders citation URLs as a numbered Markdown sources block
+func FormatCitations(citations []string) string {
+	var sb strings.Builder
+	sb.WriteString("\n\n" + citationsHeading + "\n")
+	for i, url := range citations {
+		fmt.Fprintf(&sb, "%d. [%s](%s)\n", i+1, url, url)
+	}
+	return sb.String()
+}
+
+// SplitCitations separates a trailing sources block written by
+// FormatCitations from the answer. It returns the content unchanged and no
+// URLs when there is no such block.
+func SplitCitations(content string) (string, []string) {
+	idx := strings.LastIndex(content, "\n"+citationsHeading+"\n")
+	if idx < 0 {
+		return content, nil
+	}
+
+	var urls []string
+	for _, line := range strings.Split(strings.TrimSpace(content[idx+len(citationsHeading)+2:]), "\n") {
+		match := citationLinkRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			// Text after the block means it was not ours
+			return content, nil
+		}
+		urls = append(urls, match[1])
+	}
+	return strings.TrimRight(content[:idx], "\n"), urls
+}
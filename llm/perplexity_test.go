@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPerplexityProvider_StreamCitations(t *testing.T) {
+	var body map[string]interface{}
+	// The fixture server only checks the path, which is shared with Mistral
+	server := newMistralFixtureServer(t, "testdata/perplexity_stream_citations.txt", &body)
+	defer server.Close()
+
+	provider, err := NewPerplexityProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewPerplexityProvider failed: %v", err)
+	}
+
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "Who made Go?"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, last := collectStream(t, stream)
+
+	if body["model"] != "sonar" {
+		t.Errorf("Expected the default model, got: %v", body["model"])
+	}
+	if !last.Done || last.Usage == nil || last.Usage.TotalTokens != 16 {
+		t.Errorf("Expected 16 total tokens on the final chunk, got: %+v", last)
+	}
+
+	answer, urls := SplitCitations(content)
+	if answer != "Go was designed at Google [1][2]." {
+		t.Errorf("Unexpected answer: %q", answer)
+	}
+	want := []string{"https://go.dev/doc/", "https://en.wikipedia.org/wiki/Go_(programming_language)"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("Unexpected citations: %v", urls)
+	}
+	if !strings.Contains(content, "1. [https://go.dev/doc/](https://go.dev/doc/)") {
+		t.Errorf("Expected a Markdown sources block, got: %q", content)
+	}
+}
+
+func TestPerplexityProvider_ChatCitations(t *testing.T) {
+	var body map[string]interface{}
+	server := newMistralFixtureServer(t, "testdata/perplexity_stream_citations.txt", &body)
+	defer server.Close()
+
+	provider, err := NewPerplexityProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewPerplexityProvider failed: %v", err)
+	}
+
+	content, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "Who made Go?"}})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if answer, urls := SplitCitations(content); answer != "Go was designed at Google [1][2]." || len(urls) != 2 {
+		t.Errorf("Unexpected answer %q with citations %v", answer, urls)
+	}
+}
+
+func TestPerplexityProvider_StreamJSONMode(t *testing.T) {
+	var body map[string]interface{}
+	server := newMistralFixtureServer(t, "testdata/perplexity_stream_citations.txt", &body)
+	defer server.Close()
+
+	provider, err := NewPerplexityProvider(Config{APIKey: "test-key", BaseURL: server.URL, JSONMode: true})
+	if err != nil {
+		t.Fatalf("NewPerplexityProvider failed: %v", err)
+	}
+
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "Who made Go?"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	content, last := collectStream(t, stream)
+
+	if format, _ := body["response_format"].(map[string]interface{}); format["type"] != "json_object" {
+		t.Errorf("Expected a JSON response format, got: %v", body["response_format"])
+	}
+	// The citations stay out of the JSON answer
+	if strings.Contains(content, citationsHeading) {
+		t.Errorf("Expected no sources block in JSON mode, got: %q", content)
+	}
+	if citations, _ := last.Metadata[MetadataCitations].([]string); len(citations) != 2 {
+		t.Errorf("Expected the citations in the metadata, got: %+v", last.Metadata)
+	}
+}
+
+func TestSplitCitations_NoSourcesBlock(t *testing.T) {
+	for _, content := range []string{
+		"Plain answer",
+		"Answer\n\n**Sources:**\nnot a list",
+		"Answer\n\n**Sources:**\n1. [a](https://a.example)\n\nMore text after the list",
+	} {
+		answer, urls := SplitCitations(content)
+		if answer != content || urls != nil {
+			t.Errorf("SplitCitations(%q) = %q, %v; want the content unchanged", content, answer, urls)
+		}
+	}
+}
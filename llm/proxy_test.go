@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// sseHandler answers chat completions with a stream saying "Hi"
+func sseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"data: [DONE]\n\n"))
+}
+
+func TestProxyURL_HTTP(t *testing.T) {
+	var proxied string
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxy gets the absolute URL of the target
+		proxied = r.URL.String()
+		sseHandler(w, r)
+	}))
+	defer proxyServer.Close()
+
+	provider, err := NewOpenAIProvider(Config{APIKey: "key", BaseURL: "http://llm.internal/v1", ProxyURL: proxyServer.URL})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "Hello"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	if content, _ := collectStream(t, stream); content != "Hi" {
+		t.Errorf("content = %q", content)
+	}
+	if proxied != "http://llm.internal/v1/chat/completions" {
+		t.Errorf("proxy got %q, want the upstream URL", proxied)
+	}
+}
+
+func TestProxyURL_SOCKS5(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(sseHandler))
+	defer upstream.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	targets := make(chan string, 1)
+	go serveSOCKS5(listener, targets)
+
+	provider, err := NewClaudeProvider(Config{APIKey: "key", BaseURL: upstream.URL, ProxyURL: "socks5://" + listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("NewClaudeProvider failed: %v", err)
+	}
+	resp, err := provider.client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("request through the proxy failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := <-targets; got != strings.TrimPrefix(upstream.URL, "http://") {
+		t.Errorf("proxy connected to %q, want %q", got, upstream.URL)
+	}
+}
+
+// serveSOCKS5 is a minimal SOCKS5 server without authentication that reports
+// the address of each CONNECT request to targets
+func serveSOCKS5(listener net.Listener, targets chan<- string) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			// Greeting: version, methods; answer "no authentication"
+			header := make([]byte, 2)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+				return
+			}
+			conn.Write([]byte{5, 0})
+
+			// Request: version, CONNECT, reserved, IPv4 address and port
+			request := make([]byte, 10)
+			if _, err := io.ReadFull(conn, request); err != nil || request[3] != 1 {
+				return
+			}
+			target := net.JoinHostPort(net.IP(request[4:8]).String(), strconv.Itoa(int(binary.BigEndian.Uint16(request[8:10]))))
+			targets <- target
+
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				return
+			}
+			defer upstream.Close()
+			conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+			go io.Copy(upstream, conn)
+			io.Copy(conn, upstream)
+		}()
+	}
+}
+
+func TestProxyURL_Invalid(t *testing.T) {
+	for _, proxyURL := range []string{"ftp://proxy:21", "://bad", "socks5://"} {
+		if _, err := NewGeminiProvider(Config{APIKey: "key", ProxyURL: proxyURL}); err == nil {
+			t.Errorf("expected an error for proxy %q", proxyURL)
+		}
+	}
+}
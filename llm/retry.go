@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Defaults of RetryPolicy, used for fields that are not set: three attempts,
+// waiting 1s and then 2s between them
+const (
+	DefaultRetryMaxAttempts  = 3
+	DefaultRetryInitialDelay = time.Second
+	DefaultRetryMultiplier   = 2.0
+	DefaultRetryMaxDelay     = 30 * time.Second
+)
+
+// RetryPolicy configures how often a failed request is sent again and how
+// long to wait in between. The wait starts at InitialDelay and is multiplied
+// by Multiplier after each retry, up to MaxDelay. Zero fields use the
+// defaults.
+type RetryPolicy struct {
+	MaxAttempts  int // Attempts including the first one
+	InitialDelay time.Duration
+	Multiplier   float64
+	MaxDelay     time.Duration
+}
+
+// withDefaults returns the policy with the unset fields filled in
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultRetryInitialDelay
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultRetryMultiplier
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryMaxDelay
+	}
+	if p.MaxDelay < p.InitialDelay {
+		p.MaxDelay = p.InitialDelay
+	}
+	return p
+}
+
+// Attempts returns how often a request is sent at most
+func (p RetryPolicy) Attempts() int {
+	return p.withDefaults().MaxAttempts
+}
+
+// Delay returns how long to wait before retry n, counting from 1
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	p = p.withDefaults()
+	delay := float64(p.InitialDelay)
+	for i := 1; i < retry && delay < float64(p.MaxDelay); i++ {
+		delay *= p.Multiplier
+	}
+	if delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// Retry calls fn until it succeeds, fails with an error that is not
+// retryable, or the attempts of the policy are used up. onRetry, if not nil,
+// is called before each wait. Waiting stops early when ctx is cancelled.
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error, onRetry func(retry int, delay time.Duration, err error)) error {
+	attempts := policy.Attempts()
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			delay := policy.Delay(attempt - 1)
+			if onRetry != nil {
+				onRetry(attempt-1, delay, err)
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if err = fn(); err == nil {
+			return nil
+		}
+		if !IsRetryableError(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("failed after %d attempts: %w", attempts, err)
+}
+
+// retryableErrors are parts of the messages of network errors that are
+// worth retrying
+var retryableErrors = []string{
+	"connection refused",
+	"connection reset",
+	"timeout",
+	"temporary failure",
+	"network",
+	"dial tcp",
+	"i/o timeout",
+	"no such host",
+	"connection timed out",
+	"eof",
+}
+
+// IsRetryableError checks if an error should trigger a retry
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	errStr := strings.ToLower(err.Error())
+	for _, retryable := range retryableErrors {
+		if strings.Contains(errStr, retryable) {
+			return true
+		}
+	}
+	return false
+}
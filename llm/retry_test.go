@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration
+	}{
+		{"defaults", RetryPolicy{}, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{
+			"capped",
+			RetryPolicy{InitialDelay: 100 * time.Millisecond, Multiplier: 3, MaxDelay: time.Second},
+			[]time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second, time.Second},
+		},
+		{"constant", RetryPolicy{InitialDelay: 500 * time.Millisecond, Multiplier: 1}, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		for i, want := range tt.want {
+			if got := tt.policy.Delay(i + 1); got != want {
+				t.Errorf("%s: Delay(%d) = %v, want %v", tt.name, i+1, got, want)
+			}
+		}
+	}
+	if got := (RetryPolicy{}).Attempts(); got != DefaultRetryMaxAttempts {
+		t.Errorf("default attempts = %d", got)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 4, InitialDelay: time.Millisecond, Multiplier: 2}
+	var delays []time.Duration
+	onRetry := func(retry int, delay time.Duration, err error) {
+		delays = append(delays, delay)
+	}
+
+	// Network errors are retried until the attempts are used up
+	calls := 0
+	err := Retry(context.Background(), policy, func() error {
+		calls++
+		return errors.New("dial tcp: connection refused")
+	}, onRetry)
+	if err == nil || calls != 4 {
+		t.Fatalf("calls = %d, err = %v", calls, err)
+	}
+	want := []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}
+	if len(delays) != len(want) {
+		t.Fatalf("delays = %v, want %v", delays, want)
+	}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Errorf("delays = %v, want %v", delays, want)
+		}
+	}
+
+	// Other errors abort immediately
+	calls, delays = 0, nil
+	apiErr := errors.New("API error (status 401): invalid key")
+	err = Retry(context.Background(), policy, func() error {
+		calls++
+		return apiErr
+	}, onRetry)
+	if !errors.Is(err, apiErr) || calls != 1 || len(delays) != 0 {
+		t.Errorf("calls = %d, delays = %v, err = %v", calls, delays, err)
+	}
+
+	// A later success ends the retries
+	calls = 0
+	err = Retry(context.Background(), policy, func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("unexpected EOF")
+		}
+		return nil
+	}, nil)
+	if err != nil || calls != 2 {
+		t.Errorf("calls = %d, err = %v", calls, err)
+	}
+}
+
+func TestRetry_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Retry(ctx, RetryPolicy{InitialDelay: time.Hour}, func() error {
+		calls++
+		return errors.New("i/o timeout")
+	}, func(int, time.Duration, error) { cancel() })
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("calls = %d, err = %v", calls, err)
+	}
+}
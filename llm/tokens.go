@@ -0,0 +1,171 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Token overheads of the chat format: every message is wrapped in a few
+// tokens, and the reply is primed with a few more (as in OpenAI's cookbook)
+const (
+	tokensPerMessage = 4
+	tokensPerReply   = 3
+)
+
+// estimatedImageTokens is what a high-detail 1024x1024 image costs with OpenAI;
+// the other providers charge about as much
+const estimatedImageTokens = 765
+
+// EstimateTokens estimates the prompt tokens of messages without calling a
+// provider, in the manner of tiktoken's cl100k encoding: about 4 characters
+// per token for ASCII text and a token per character for other scripts like
+// Chinese. It is the fallback of providers without a counting API.
+func EstimateTokens(messages []Message) int {
+	tokens := tokensPerReply
+	for _, msg := range messages {
+		tokens += tokensPerMessage + estimateTextTokens(msg.Role) + estimateTextTokens(msg.Content)
+		for _, att := range msg.Attachments {
+			switch {
+			case att.Type == "image":
+				tokens += estimatedImageTokens
+			case att.TextContent != "":
+				tokens += estimateTextTokens(att.TextContent)
+			case utf8.Valid(att.Data):
+				tokens += estimateTextTokens(string(att.Data))
+			}
+		}
+	}
+	return tokens
+}
+
+// estimateTextTokens estimates the tokens of text
+func estimateTextTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// contextWindows are the context windows of model families in tokens. Model
+// names are matched by substring in order, so longer names come first.
+var contextWindows = []struct {
+	model  string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1-mini", 128000},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4-mini", 200000},
+	{"claude", 200000},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini-1.5-flash", 1048576},
+	{"gemini-2", 1048576},
+	{"gemini-pro", 32760},
+	{"codestral", 256000},
+	{"mistral-large", 128000},
+	{"mistral-small", 32000},
+	{"open-mistral-nemo", 128000},
+	{"mixtral-8x7b", 32768},
+	{"sonar", 127072},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3", 8192},
+	{"qwen2.5", 32768},
+	{"deepseek", 65536},
+	{"grok-vision-beta", 8192},
+	{"grok", 131072},
+}
+
+// ContextWindow returns the context window of model in tokens, 0 if unknown
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	for _, w := range contextWindows {
+		if strings.Contains(model, w.model) {
+			return w.tokens
+		}
+	}
+	return 0
+}
+
+// errTokenCountUnsupported is returned by countTokensRequest when the server
+// has no token counting endpoint
+var errTokenCountUnsupported = errors.New("token counting is not supported")
+
+// countTokensRequest posts body as JSON to endpoint and decodes the response
+// into result. setHeaders, if set, adds the authentication. 404, 405 and 501
+// responses return errTokenCountUnsupported.
+func countTokensRequest(ctx context.Context, client *http.Client, endpoint string, setHeaders func(*http.Request), body, result interface{}) error {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if setHeaders != nil {
+		setHeaders(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return errTokenCountUnsupported
+	default:
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// openAITokenCountRequest is the body of the token counting endpoint offered
+// by some OpenAI compatible servers
+type openAITokenCountRequest struct {
+	Messages []openAITokenCountMessage `json:"messages"`
+}
+
+type openAITokenCountMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAITokenCountResponse is the response of the token counting endpoint
+type openAITokenCountResponse struct {
+	Tokens int `json:"tokens"`
+}
+
+// openAITokenCountURL returns the token counting endpoint of model
+func openAITokenCountURL(baseURL, model string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/models/" + url.PathEscape(model) + "/tokens"
+}
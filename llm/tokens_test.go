@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTokenCountServer answers requests to path with response and everything
+// else with 404, recording the paths and the last request body
+func newTokenCountServer(t *testing.T, path string, response interface{}, paths *[]string, body *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestEstimateTokens(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "Hello, world!"}, // 13 ASCII characters
+		{Role: "assistant", Content: "你好世界"},
+		{Role: "user", Content: "", Attachments: []Attachment{{Type: "image", Data: []byte{0xff, 0xd8}}}},
+	}
+	// Reply priming + per message overhead and role + content
+	want := tokensPerReply +
+		tokensPerMessage + 1 + 4 +
+		tokensPerMessage + 3 + 4 +
+		tokensPerMessage + 1 + estimatedImageTokens
+	if got := EstimateTokens(messages); got != want {
+		t.Errorf("EstimateTokens = %d, want %d", got, want)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := map[string]int{
+		"gpt-4o-mini":                128000,
+		"gpt-4":                      8192,
+		"claude-3-5-sonnet-20241022": 200000,
+		"models/gemini-1.5-pro":      2097152,
+		"sonar-pro":                  127072,
+		"my-local-model":             0,
+	}
+	for model, want := range tests {
+		if got := ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
+
+func TestClaudeProvider_CountTokens(t *testing.T) {
+	var paths []string
+	var body map[string]interface{}
+	server := newTokenCountServer(t, "/messages/count_tokens", ClaudeTokenCountResponse{InputTokens: 42}, &paths, &body)
+	defer server.Close()
+
+	provider, err := NewClaudeProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClaudeProvider failed: %v", err)
+	}
+	got, err := provider.CountTokens(context.Background(), []Message{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "Hi"},
+	})
+	if err != nil {
+		t.Fatalf("CountTokens failed: %v", err)
+	}
+	if got != 42 {
+		t.Errorf("CountTokens = %d, want 42", got)
+	}
+	if body["system"] != "Be brief." || body["model"] != "claude-3-5-sonnet-20241022" {
+		t.Errorf("unexpected request %v", body)
+	}
+}
+
+func TestGeminiProvider_CountTokens(t *testing.T) {
+	var paths []string
+	var body map[string]interface{}
+	server := newTokenCountServer(t, "/models/gemini-1.5-flash:countTokens", GeminiTokenCountResponse{TotalTokens: 7}, &paths, &body)
+	defer server.Close()
+
+	provider, err := NewGeminiProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewGeminiProvider failed: %v", err)
+	}
+	got, err := provider.CountTokens(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("CountTokens failed: %v", err)
+	}
+	if got != 7 {
+		t.Errorf("CountTokens = %d, want 7", got)
+	}
+	if contents, _ := body["contents"].([]interface{}); len(contents) != 1 {
+		t.Errorf("unexpected request %v", body)
+	}
+}
+
+func TestOpenAIProvider_CountTokens(t *testing.T) {
+	var paths []string
+	var body map[string]interface{}
+	server := newTokenCountServer(t, "/models/local-model/tokens", openAITokenCountResponse{Tokens: 11}, &paths, &body)
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL, Model: "local-model"})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	messages := []Message{{Role: "user", Content: "Hi"}}
+	got, err := provider.CountTokens(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("CountTokens failed: %v", err)
+	}
+	if got != 11 {
+		t.Errorf("CountTokens = %d, want 11", got)
+	}
+
+	// A server without the endpoint gets the estimate, and is asked only once
+	other := provider.WithModel("gpt-4o")
+	for i := 0; i < 2; i++ {
+		got, err := other.CountTokens(context.Background(), messages)
+		if err != nil {
+			t.Fatalf("CountTokens failed: %v", err)
+		}
+		if want := EstimateTokens(messages); got != want {
+			t.Errorf("CountTokens = %d, want the estimate %d", got, want)
+		}
+	}
+	if len(paths) != 2 {
+		t.Errorf("requests %q, want one per model", paths)
+	}
+}
@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultRequestTimeout is how long a provider waits for the API to start
+// answering when Config.Timeout is not set
+const DefaultRequestTimeout = 120 * time.Second
+
+// requestTimeout returns the timeout configured in config or the default
+func requestTimeout(config Config) time.Duration {
+	if config.Timeout > 0 {
+		return time.Duration(config.Timeout) * time.Second
+	}
+	return DefaultRequestTimeout
+}
+
+// newTransport returns the transport of a provider. The timeout applies until
+// the response headers arrive rather than to the whole request, so streamed
+// answers may take longer than that to finish.
+func newTransport(config Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = requestTimeout(config)
+	if err := SetProxy(transport, config.ProxyURL); err != nil {
+		return nil, err
+	}
+	return transport, nil
+}
@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTransport_Timeout(t *testing.T) {
+	tests := map[int]time.Duration{
+		0:   DefaultRequestTimeout,
+		600: 10 * time.Minute,
+	}
+	for seconds, want := range tests {
+		transport, err := newTransport(Config{Timeout: seconds})
+		if err != nil {
+			t.Fatalf("newTransport failed: %v", err)
+		}
+		if transport.ResponseHeaderTimeout != want {
+			t.Errorf("timeout %d: ResponseHeaderTimeout = %v, want %v", seconds, transport.ResponseHeaderTimeout, want)
+		}
+	}
+
+	provider, err := NewOllamaProvider(Config{})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider failed: %v", err)
+	}
+	if got := provider.config.Timeout; got != 300 {
+		t.Errorf("Ollama default timeout = %ds, want 300s for loading models", got)
+	}
+}
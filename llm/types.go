@@ -18,6 +18,10 @@ type Attachment struct {
 	MimeType string `json:"mime_type"` // "image/png", "text/plain", etc.
 	Data     []byte `json:"data"`      // raw data or base64 encoded
 	Filename string `json:"filename"`
+	// TextContent is the text extracted from a document attachment (e.g. .docx)
+	TextContent string `json:"text_content,omitempty"`
+	// ThumbnailData is a small JPEG preview of an image attachment, only used by the UI
+	ThumbnailData []byte `json:"thumbnail_data,omitempty"`
 }
 
 // StreamResponse represents a chunk of streaming response
@@ -25,6 +29,48 @@ type StreamResponse struct {
 	Content string
 	Done    bool
 	Error   error
+	Usage   *Usage // Token usage, set on the final chunk when the provider reports it
+	// ToolCalls requested by the model, set on the final chunk when tools are configured
+	ToolCalls []ToolCall
+	// Metadata holds provider-specific data of the response, set on the final
+	// chunk: MetadataStopReason for every provider that reports one, plus
+	// e.g. Gemini's safety ratings or OpenAI's system fingerprint
+	Metadata map[string]interface{}
+}
+
+// Keys of StreamResponse.Metadata
+const (
+	MetadataStopReason        = "stop_reason"        // Why generation stopped, as named by the provider
+	MetadataStopSequence      = "stop_sequence"      // Claude: the stop sequence that was hit
+	MetadataSafetyRatings     = "safety_ratings"     // Gemini: category -> probability
+	MetadataSystemFingerprint = "system_fingerprint" // OpenAI: backend configuration of the response
+	MetadataCitations         = "citations"          // Perplexity: search result URLs
+)
+
+// Usage represents token usage reported by a provider
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// newDoneResponse builds the final stream chunk with the reported usage and
+// metadata, if any
+func newDoneResponse(usage *Usage, metadata map[string]interface{}) StreamResponse {
+	resp := StreamResponse{Done: true}
+	if len(metadata) > 0 {
+		resp.Metadata = metadata
+	}
+	if usage == nil {
+		return resp
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	if usage.TotalTokens > 0 {
+		resp.Usage = usage
+	}
+	return resp
 }
 
 // Provider interface defines the common interface for all LLM providers
@@ -32,9 +78,19 @@ type Provider interface {
 	// StreamChat sends messages and returns a channel for streaming responses
 	StreamChat(ctx context.Context, messages []Message) (<-chan StreamResponse, error)
 
+	// StreamChatWithSystemPrompt streams like StreamChat with systemPrompt
+	// sent the way the provider expects system instructions. The prompt
+	// comes before any system messages in messages; "" sends none.
+	StreamChatWithSystemPrompt(ctx context.Context, systemPrompt string, messages []Message) (<-chan StreamResponse, error)
+
 	// Chat sends messages and returns the complete response (non-streaming)
 	Chat(ctx context.Context, messages []Message) (string, error)
 
+	// CountTokens returns the prompt tokens messages would take with the
+	// provider's model, using its counting API where there is one and
+	// EstimateTokens otherwise
+	CountTokens(ctx context.Context, messages []Message) (int, error)
+
 	// GenerateTitle generates a short title based on the conversation messages
 	GenerateTitle(ctx context.Context, messages []Message) (string, error)
 
@@ -44,10 +100,57 @@ type Provider interface {
 	// Models returns the list of supported models
 	Models() []string
 
+	// WithModel returns a copy of the provider that uses the given model
+	WithModel(model string) Provider
+
+	// ContextWindowSize returns the context window of the provider's model
+	// in tokens, 0 if unknown
+	ContextWindowSize() int
+
+	// WithParams returns a copy of the provider whose sampling settings are
+	// overridden by params
+	WithParams(params GenerationParams) Provider
+
 	// ValidateConfig validates the provider configuration
 	ValidateConfig() error
 }
 
+// ModelRefresher is implemented by providers that list their models from the
+// server, like Ollama. Use AsModelRefresher to find it behind middlewares.
+type ModelRefresher interface {
+	// RefreshModels fetches the models that Models returns afterwards
+	RefreshModels(ctx context.Context) ([]string, error)
+}
+
+// ImageProvider is implemented by providers that generate images from a
+// prompt, like OpenAI. Use AsImageProvider to find it behind middlewares.
+type ImageProvider interface {
+	// GenerateImage returns the image data and its MIME type. size is like
+	// "1024x1024"; empty uses the default size of the model.
+	GenerateImage(ctx context.Context, prompt string, size string) ([]byte, string, error)
+}
+
+// PrependSystemPrompt returns messages with systemPrompt as a leading system
+// message, the default way of sending it for providers that read system
+// messages from the history
+func PrependSystemPrompt(systemPrompt string, messages []Message) []Message {
+	if systemPrompt == "" {
+		return messages
+	}
+	return append([]Message{{Role: "system", Content: systemPrompt}}, messages...)
+}
+
+// joinSystemPrompts joins the non-empty system prompts with blank lines
+func joinSystemPrompts(prompts ...string) string {
+	var parts []string
+	for _, prompt := range prompts {
+		if prompt != "" {
+			parts = append(parts, prompt)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 // Config represents provider configuration
 type Config struct {
 	ProviderName string   // Display name for the provider
@@ -58,6 +161,76 @@ type Config struct {
 	Timeout      int      // seconds
 	MaxTokens    int
 	Temperature  float64
+	TopP         float64 // 0 leaves it to the API
+	Tools        []Tool  // Functions the model may call (only used by providers with tool support)
+	// SafePrompt has Mistral prepend its guardrail system prompt (Mistral only)
+	SafePrompt bool
+	// ReasoningEffort is low, medium or high; empty leaves it to the API (xAI only)
+	ReasoningEffort string
+	// JSONMode asks for a response that is a single JSON object
+	// (OpenAI compatible providers only)
+	JSONMode bool
+	// DeploymentName and APIVersion address an Azure OpenAI deployment (Azure only)
+	DeploymentName string
+	APIVersion     string
+	// ProxyURL is the http(s):// or socks5:// proxy to connect through, "" for none
+	ProxyURL string
+	// RequestLogger, if set, receives the raw HTTP requests and response lines
+	RequestLogger RequestLogger
+	// Logger, if set, receives debug output like Groq's latency stats
+	Logger DebugLogger
+}
+
+// GenerationParams override the sampling settings of a provider's config,
+// e.g. for one conversation. Nil fields keep the configured value.
+type GenerationParams struct {
+	Temperature *float64
+	MaxTokens   *int
+	TopP        *float64
+	JSONMode    *bool
+}
+
+// minTemperature stands in for a temperature of 0, which the request structs
+// would omit as unset
+const minTemperature = 1e-6
+
+// apply returns config with the overrides of params
+func (params GenerationParams) apply(config Config) Config {
+	if params.Temperature != nil {
+		config.Temperature = *params.Temperature
+		if config.Temperature <= 0 {
+			config.Temperature = minTemperature
+		}
+	}
+	if params.MaxTokens != nil {
+		config.MaxTokens = *params.MaxTokens
+	}
+	if params.TopP != nil {
+		config.TopP = *params.TopP
+	}
+	if params.JSONMode != nil {
+		config.JSONMode = *params.JSONMode
+	}
+	return config
+}
+
+// DebugLogger is the logger providers write debug output to
+type DebugLogger interface {
+	Debug(format string, v ...interface{})
+}
+
+// Tool describes a function the model may call
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{} // JSON Schema of the function arguments
+}
+
+// ToolCall is a function call requested by the model
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string // JSON encoded arguments
 }
 
 // cleanTitle cleans up a generated title by removing quotes and extra whitespace
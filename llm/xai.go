@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// xaiDefaultBaseURL is the OpenAI-compatible endpoint of xAI's Grok API
+const xaiDefaultBaseURL = "https://api.x.ai/v1"
+
+// ValidateReasoningEffort checks a reasoning effort for Grok: empty leaves it
+// to the API, otherwise low, medium or high
+func ValidateReasoningEffort(effort string) error {
+	switch effort {
+	case "", "low", "medium", "high":
+		return nil
+	}
+	return fmt.Errorf("invalid reasoning effort %q, must be low, medium or high", effort)
+}
+
+// XAIProvider implements the Provider interface for xAI's Grok API. The chat
+// API is OpenAI-compatible; the configured reasoning effort is added to the
+// streamed and the plain chat requests as reasoning_effort.
+type XAIProvider struct {
+	*OpenAIProvider
+}
+
+// NewXAIProvider creates a new xAI provider
+func NewXAIProvider(config Config) (*XAIProvider, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = xaiDefaultBaseURL
+	}
+	if config.ProviderName == "" {
+		config.ProviderName = "xAI"
+	}
+	if config.Model == "" {
+		config.Model = "grok-beta"
+	}
+	if err := ValidateReasoningEffort(config.ReasoningEffort); err != nil {
+		return nil, err
+	}
+
+	var bodyFields map[string]json.RawMessage
+	if config.ReasoningEffort != "" {
+		effort, err := json.Marshal(config.ReasoningEffort)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal reasoning effort: %w", err)
+		}
+		bodyFields = map[string]json.RawMessage{"reasoning_effort": effort}
+	}
+	base, err := newOpenAIProvider(config, bodyFields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &XAIProvider{OpenAIProvider: base}, nil
+}
+
+// Models returns supported models
+func (p *XAIProvider) Models() []string {
+	if len(p.config.Models) > 0 {
+		return p.config.Models
+	}
+	return []string{
+		"grok-beta",
+		"grok-vision-beta",
+	}
+}
+
+// WithModel returns a copy of the provider that uses model
+func (p *XAIProvider) WithModel(model string) Provider {
+	clone := *p.OpenAIProvider
+	clone.config.Model = model
+	return &XAIProvider{OpenAIProvider: &clone}
+}
+
+// WithParams returns a copy of the provider with the sampling settings
+// overridden by params
+func (p *XAIProvider) WithParams(params GenerationParams) Provider {
+	return &XAIProvider{OpenAIProvider: p.OpenAIProvider.withParams(params)}
+}
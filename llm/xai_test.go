@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewXAIProvider_Defaults(t *testing.T) {
+	provider, err := NewXAIProvider(Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewXAIProvider failed: %v", err)
+	}
+	if provider.baseURL != xaiDefaultBaseURL {
+		t.Errorf("got base URL %q, want %q", provider.baseURL, xaiDefaultBaseURL)
+	}
+	if provider.Name() != "xAI" || provider.config.Model != "grok-beta" {
+		t.Errorf("unexpected defaults: name %q, model %q", provider.Name(), provider.config.Model)
+	}
+	if models := provider.Models(); len(models) != 2 || models[0] != "grok-beta" || models[1] != "grok-vision-beta" {
+		t.Errorf("unexpected models: %v", models)
+	}
+
+	// Copies keep the Grok models
+	other, ok := provider.WithModel("grok-vision-beta").(*XAIProvider)
+	if !ok {
+		t.Fatal("expected WithModel to return an XAIProvider")
+	}
+	if other.config.Model != "grok-vision-beta" || len(other.Models()) != 2 {
+		t.Errorf("unexpected copy: model %q, models %v", other.config.Model, other.Models())
+	}
+}
+
+func TestXAIProvider_ReasoningEffort(t *testing.T) {
+	var body map[string]interface{}
+	server := newMistralFixtureServer(t, "testdata/openai_stream_usage.txt", &body)
+	defer server.Close()
+
+	provider, err := NewXAIProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewXAIProvider failed: %v", err)
+	}
+	stream, err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	collectStream(t, stream)
+	if _, ok := body["reasoning_effort"]; ok {
+		t.Errorf("expected no reasoning_effort by default, got %v", body["reasoning_effort"])
+	}
+
+	provider, err = NewXAIProvider(Config{APIKey: "test-key", BaseURL: server.URL, ReasoningEffort: "high"})
+	if err != nil {
+		t.Fatalf("NewXAIProvider failed: %v", err)
+	}
+	// The effort is kept by copies for other models
+	stream, err = provider.WithModel("grok-vision-beta").StreamChat(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("StreamChat failed: %v", err)
+	}
+	collectStream(t, stream)
+	if body["reasoning_effort"] != "high" || body["model"] != "grok-vision-beta" {
+		t.Errorf("unexpected request: reasoning_effort %v, model %v", body["reasoning_effort"], body["model"])
+	}
+}
+
+func TestNewXAIProvider_InvalidReasoningEffort(t *testing.T) {
+	if _, err := NewXAIProvider(Config{APIKey: "test-key", ReasoningEffort: "extreme"}); err == nil {
+		t.Error("expected an error for an unknown reasoning effort")
+	}
+}
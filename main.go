@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"light-llm-client/db"
+	"light-llm-client/llm"
 	"light-llm-client/ui"
 	"light-llm-client/utils"
 	"os"
+	"os/signal"
+	"strings"
 )
 
 var (
@@ -17,29 +22,59 @@ func main() {
 	// Parse command line flags
 	configPath := flag.String("config", "", "Path to configuration file")
 	showVersion := flag.Bool("version", false, "Show version information")
+	batchScript := flag.String("batch", "", "Run a JSON batch script without the UI and print NDJSON results")
+	deepLink := flag.String("url", "", "Open a light-llm:// URL, in the running instance if there is one")
+	headless := flag.Bool("headless", false, "Answer --prompt without the UI and print the response")
+	prompt := flag.String("prompt", "", "Prompt to send in headless mode")
+	conversationID := flag.Int64("conversation-id", 0, "Conversation to continue in headless mode (default: a new one)")
+	providerName := flag.String("provider", "", "Provider to use in headless mode (default: the conversation's or the first enabled one)")
 	flag.Parse()
 
+	// URL scheme handlers pass the URL as plain argument
+	if *deepLink == "" && flag.NArg() > 0 && strings.HasPrefix(strings.ToLower(flag.Arg(0)), utils.DeepLinkScheme+":") {
+		*deepLink = flag.Arg(0)
+	}
+
 	if *showVersion {
 		fmt.Printf("Light LLM Client v%s\n", version)
 		os.Exit(0)
 	}
 
+	if *headless && *prompt == "" {
+		fmt.Fprintln(os.Stderr, "--headless needs a --prompt")
+		os.Exit(2)
+	}
+
 	// Initialize logger
-	logger, err := utils.NewLogger(utils.GetLogPath())
+	logger, err := newLogger(utils.GetLogPath(), *batchScript != "" || *headless)
 	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Close()
 
+	// Hand the URL to the running instance instead of starting another one
+	if *deepLink != "" {
+		if _, _, err := utils.ParseLightLLMURL(*deepLink); err != nil {
+			logger.Error("Invalid URL %q: %v", *deepLink, err)
+			fmt.Fprintf(os.Stderr, "Invalid URL: %v\n", err)
+			os.Exit(1)
+		}
+		if err := utils.SendDeepLink(utils.DeepLinkSocketPath(), *deepLink); err == nil {
+			logger.Info("Passed %s to the running instance", *deepLink)
+			return
+		}
+	}
+
 	logger.Info("Starting Light LLM Client v%s", version)
 
 	// Load or create default configuration
 	var config *utils.Config
+	var configParseErr *utils.ConfigParseError
 	var actualConfigPath string
 	if *configPath != "" {
 		actualConfigPath = *configPath
-		config, err = utils.LoadConfig(actualConfigPath)
+		config, configParseErr, err = loadConfig(actualConfigPath, logger)
 		if err != nil {
 			logger.Error("Failed to load config: %v", err)
 			os.Exit(1)
@@ -53,13 +88,35 @@ func main() {
 		}
 		logger.Info("Using config file: %s", actualConfigPath)
 
-		config, err = utils.LoadConfig(actualConfigPath)
+		config, configParseErr, err = loadConfig(actualConfigPath, logger)
 		if err != nil {
 			logger.Error("Failed to load config: %v", err)
 			os.Exit(1)
 		}
 	}
 
+	// Report values that parsed but make no sense; startup continues
+	for _, configErr := range utils.ValidateConfig(config) {
+		logger.Warn("Invalid config %s: %s", configErr.Field, configErr.Message)
+	}
+
+	// Apply log rotation policy from config
+	logger.SetRotation(config.Log)
+	logger.SetRedactBeforeLog(config.Privacy.RedactBeforeLog)
+	if err := logger.SetRequestLogging(config.Log.StructuredLog, config.Log.RedactAPIKeys); err != nil {
+		logger.Warn("Request logging is not available: %v", err)
+	}
+
+	// Fetch the remote database before opening it
+	syncer := utils.NewDBSyncer(config.Sync, config.Data.DBPath, logger)
+	var syncConflict *utils.SyncConflict
+	if syncer != nil {
+		syncConflict, err = syncer.SyncOnStartup()
+		if err != nil {
+			logger.Error("WebDAV sync failed, using local database: %v", err)
+		}
+	}
+
 	// Initialize database
 	database, err := db.New(config.Data.DBPath)
 	if err != nil {
@@ -70,11 +127,134 @@ func main() {
 
 	logger.Info("Database initialized: %s", config.Data.DBPath)
 
+	// Batch mode answers a script on stdout and exits without the UI
+	if *batchScript != "" {
+		if err := runBatch(config, database, logger, *batchScript); err != nil {
+			logger.Error("Batch run failed: %v", err)
+			fmt.Fprintf(os.Stderr, "Batch run failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Headless mode prints the answer to a single prompt and exits
+	if *headless {
+		if err := runHeadless(config, database, logger, *prompt, *conversationID, *providerName); err != nil {
+			logger.Error("Headless run failed: %v", err)
+			fmt.Fprintf(os.Stderr, "Headless run failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Create and run application
 	app := ui.NewApp(config, actualConfigPath, database, logger)
 	defer app.Cleanup()
+	if err := app.SetupGlobalHotkey(config.UI.GlobalHotkey, app.ShowWindow); err != nil {
+		logger.Warn("Global hotkey is not available: %v", err)
+	}
+	app.OfferConfigRestore(configParseErr)
+	app.EnableSync(syncer, syncConflict)
+
+	// Nightly backups and the like; stopped by app.Cleanup
+	exportScheduler := utils.NewExportScheduler(database, config.Export, logger)
+	exportScheduler.Start()
+	app.EnableExportSchedule(exportScheduler)
+	topicWorker := utils.NewTopicWorker(database, logger)
+	app.EnableTopics(topicWorker)
+	topicWorker.Start()
+	app.CheckForUpdates(version)
+
+	// Later instances forward their light-llm:// URLs here
+	deepLinks, err := utils.NewDeepLinkServer(utils.DeepLinkSocketPath(), logger, app.HandleDeepLink)
+	if err != nil {
+		logger.Warn("Deep links are not available: %v", err)
+	} else {
+		defer deepLinks.Close()
+	}
+	if *deepLink != "" {
+		url := *deepLink
+		utils.SafeGo(logger, "open deep link", func() {
+			app.HandleDeepLink(url)
+		})
+	}
 
 	logger.Info("Application started")
 	app.Run()
 	logger.Info("Application stopped")
 }
+
+// newLogger creates the logger. Batch and headless mode print their results
+// on stdout, so their log lines are echoed to stderr instead.
+func newLogger(path string, cli bool) (*utils.Logger, error) {
+	logger, err := utils.NewLogger(path)
+	if err != nil {
+		return nil, err
+	}
+	if cli {
+		logger.SetConsole(os.Stderr)
+	}
+	return logger, nil
+}
+
+// loadConfig loads the config file. When it cannot be parsed but its backup
+// can, the backup is loaded instead and the parse error is returned so the
+// UI can offer to restore it.
+func loadConfig(path string, logger *utils.Logger) (*utils.Config, *utils.ConfigParseError, error) {
+	config, err := utils.LoadConfig(path)
+	var parseErr *utils.ConfigParseError
+	if err == nil || !errors.As(err, &parseErr) || !parseErr.HasBackup {
+		return config, nil, err
+	}
+
+	logger.Warn("Config file is corrupt, starting from backup: %v", err)
+	config, err = utils.LoadConfig(utils.BackupConfigPath(path))
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, parseErr, nil
+}
+
+// initProviders creates the enabled providers for the modes without the UI
+func initProviders(config *utils.Config, logger *utils.Logger) map[string]llm.Provider {
+	providers := make(map[string]llm.Provider)
+	for name, providerConfig := range config.LLMProviders {
+		if !providerConfig.Enabled {
+			continue
+		}
+		providerConfig.ProxyURL = config.ProviderProxyURL(providerConfig)
+		provider, err := utils.NewProviderWithLogger(name, providerConfig, logger)
+		if err != nil {
+			logger.Error("Failed to initialize %s provider: %v", name, err)
+			continue
+		}
+		providers[name] = llm.Chain(provider, llm.WithLogging(logger), llm.WithRateLimit(providerConfig.RateLimitRPS))
+	}
+	return providers
+}
+
+// runBatch sends the items of a batch script to the enabled providers and
+// writes the results to stdout
+func runBatch(config *utils.Config, database *db.DB, logger *utils.Logger, scriptPath string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	logger.Info("Running batch script: %s", scriptPath)
+	runner := &utils.BatchRunner{DB: database, Providers: initProviders(config, logger)}
+	return runner.RunScript(ctx, scriptPath, os.Stdout)
+}
+
+// runHeadless sends prompt to a provider and writes the response to stdout
+func runHeadless(config *utils.Config, database *db.DB, logger *utils.Logger, prompt string, conversationID int64, providerName string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	logger.Info("Running headless prompt")
+	runner := &utils.HeadlessRunner{DB: database, Providers: initProviders(config, logger), Configs: config.LLMProviders}
+	response, err := runner.Run(ctx, prompt, conversationID, providerName)
+	if err != nil {
+		return err
+	}
+	fmt.Println(response)
+	return nil
+}
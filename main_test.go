@@ -0,0 +1,130 @@
+//go:build sqlite_fts5
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"light-llm-client/db"
+	"light-llm-client/utils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newOllamaServer answers every chat request with "hello"
+func newOllamaServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "hello"}, "done": false}`)
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": ""}, "done": true, "prompt_eval_count": 3, "eval_count": 1}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// captureOutput redirects stdout and stderr to files while run runs and
+// returns what was written to them
+func captureOutput(t *testing.T, run func()) (stdout, stderr string) {
+	t.Helper()
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outFile.Close()
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer errFile.Close()
+
+	oldOut, oldErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outFile, errFile
+	defer func() { os.Stdout, os.Stderr = oldOut, oldErr }()
+	run()
+
+	out, err := os.ReadFile(outFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errOut, err := os.ReadFile(errFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), string(errOut)
+}
+
+// newCLITest returns the config, database and logger the command line modes
+// run with, talking to a local Ollama server
+func newCLITest(t *testing.T) (*utils.Config, *db.DB, *utils.Logger) {
+	t.Helper()
+	dir := t.TempDir()
+	logger, err := newLogger(filepath.Join(dir, "app.log"), true)
+	if err != nil {
+		t.Fatalf("newLogger failed: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+	database, err := db.New(filepath.Join(dir, "chat.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	config := &utils.Config{LLMProviders: map[string]utils.ProviderConfig{
+		"ollama": {BaseURL: newOllamaServer(t).URL, DefaultModel: "llama3", Enabled: true},
+	}}
+	return config, database, logger
+}
+
+func TestRunBatch_StdoutOnlyHoldsResults(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.json")
+	if err := os.WriteFile(script, []byte(`[
+		{"provider": "ollama", "messages": [{"role": "user", "content": "Hi"}]},
+		{"provider": "ollama", "messages": [{"role": "user", "content": "Hi again"}]}
+	]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := captureOutput(t, func() {
+		config, database, logger := newCLITest(t)
+		if err := runBatch(config, database, logger, script); err != nil {
+			t.Errorf("runBatch failed: %v", err)
+		}
+	})
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines on stdout, want 2:\n%s", len(lines), stdout)
+	}
+	for _, line := range lines {
+		var result utils.BatchResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Errorf("stdout line is not a JSON result: %q", line)
+		} else if result.Response != "hello" {
+			t.Errorf("response = %q, want %q", result.Response, "hello")
+		}
+	}
+	if !strings.Contains(stderr, "[INFO]") {
+		t.Errorf("log lines missing from stderr:\n%s", stderr)
+	}
+}
+
+func TestRunHeadless_StdoutOnlyHoldsResponse(t *testing.T) {
+	stdout, stderr := captureOutput(t, func() {
+		config, database, logger := newCLITest(t)
+		if err := runHeadless(config, database, logger, "Hi", 0, "ollama"); err != nil {
+			t.Errorf("runHeadless failed: %v", err)
+		}
+	})
+
+	if stdout != "hello\n" {
+		t.Errorf("stdout = %q, want only the response", stdout)
+	}
+	if !strings.Contains(stderr, "[INFO]") {
+		t.Errorf("log lines missing from stderr:\n%s", stderr)
+	}
+}
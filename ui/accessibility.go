@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AccessibleObject describes a custom widget to assistive technology. Fyne
+// has no accessibility API yet, so the built-in widgets expose nothing; the
+// custom ones implement this so screen reader support can be wired up in one
+// place once the toolkit offers it.
+type AccessibleObject interface {
+	// AccessibleName is the short name read first, such as a title
+	AccessibleName() string
+	// AccessibleDescription adds state or details to the name
+	AccessibleDescription() string
+}
+
+// The custom widgets describe themselves
+var (
+	_ AccessibleObject = (*ConversationItem)(nil)
+	_ AccessibleObject = (*FileAttachmentWidget)(nil)
+	_ AccessibleObject = (*FileUploadArea)(nil)
+	_ AccessibleObject = (*CustomTabButton)(nil)
+	_ AccessibleObject = (*ScrollableTabBar)(nil)
+)
+
+// AccessibleName returns the conversation title
+func (ci *ConversationItem) AccessibleName() string {
+	return ci.conversation.Title
+}
+
+// AccessibleDescription returns the category, the last update time and
+// whether the conversation is pinned
+func (ci *ConversationItem) AccessibleDescription() string {
+	description := "对话，更新于 " + ci.conversation.UpdatedAt.Format("2006-01-02 15:04")
+	if ci.conversation.Category != "" {
+		description += "，分类 " + ci.conversation.Category
+	}
+	if ci.conversation.Pinned {
+		description += "，已置顶"
+	}
+	return description
+}
+
+// AccessibleName returns the file name of the attachment
+func (w *FileAttachmentWidget) AccessibleName() string {
+	return w.attachment.Filename
+}
+
+// AccessibleDescription returns the kind and size of the attachment
+func (w *FileAttachmentWidget) AccessibleDescription() string {
+	kind := "文件附件"
+	if w.attachment.Type == "image" {
+		kind = "图片附件"
+	}
+	return fmt.Sprintf("%s，%.1f KB，可移除", kind, float64(len(w.attachment.Data))/1024)
+}
+
+// AccessibleName names the upload area
+func (a *FileUploadArea) AccessibleName() string {
+	return "附件"
+}
+
+// AccessibleDescription lists the attached files
+func (a *FileUploadArea) AccessibleDescription() string {
+	if len(a.attachments) == 0 {
+		return "没有附件，可添加或拖放文件"
+	}
+	names := make([]string, len(a.attachments))
+	for i, att := range a.attachments {
+		names[i] = att.Filename
+	}
+	return fmt.Sprintf("%d 个附件: %s", len(a.attachments), strings.Join(names, ", "))
+}
+
+// AccessibleName returns the tab title
+func (b *CustomTabButton) AccessibleName() string {
+	return b.Title
+}
+
+// AccessibleDescription tells whether the tab is the current one
+func (b *CustomTabButton) AccessibleDescription() string {
+	if b.IsActive {
+		return "当前标签"
+	}
+	return "标签，点击切换"
+}
+
+// AccessibleName names the tab bar
+func (stb *ScrollableTabBar) AccessibleName() string {
+	return "对话标签栏"
+}
+
+// AccessibleDescription returns the number of open tabs
+func (stb *ScrollableTabBar) AccessibleDescription() string {
+	return fmt.Sprintf("%d 个标签", len(stb.tabContainer.Objects))
+}
@@ -0,0 +1,48 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/db"
+	"light-llm-client/llm"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/test"
+)
+
+func TestAccessibleObjects(t *testing.T) {
+	test.NewTempApp(t)
+
+	updated := time.Date(2024, 5, 6, 7, 8, 0, 0, time.Local)
+	item := NewConversationItem(nil, &db.Conversation{ID: 1, Title: "Go 并发", Category: "编程", UpdatedAt: updated}, nil)
+
+	area := NewFileUploadArea(nil, nil)
+	area.attachments = append(area.attachments, &llm.Attachment{Type: "file", Filename: "notes.txt", Data: []byte("hi")})
+
+	tab := NewCustomTabButton("会话 A", nil, nil)
+	tab.IsActive = true
+
+	tests := []struct {
+		name            string
+		object          AccessibleObject
+		wantName        string
+		wantDescription string
+	}{
+		{"ConversationItem", item, "Go 并发", "2024-05-06 07:08"},
+		{"FileAttachmentWidget", NewFileAttachmentWidget(&llm.Attachment{Type: "image", Filename: "cat.png", Data: make([]byte, 2048)}, nil), "cat.png", "图片附件，2.0 KB"},
+		{"FileUploadArea", area, "附件", "notes.txt"},
+		{"CustomTabButton", tab, "会话 A", "当前标签"},
+		{"ScrollableTabBar", NewScrollableTabBar(container.NewHBox(tab, NewCustomTabButton("会话 B", nil, nil))), "对话标签栏", "2 个标签"},
+	}
+	for _, tt := range tests {
+		if got := tt.object.AccessibleName(); got != tt.wantName {
+			t.Errorf("%s: AccessibleName = %q, want %q", tt.name, got, tt.wantName)
+		}
+		if got := tt.object.AccessibleDescription(); !strings.Contains(got, tt.wantDescription) {
+			t.Errorf("%s: AccessibleDescription = %q, want it to contain %q", tt.name, got, tt.wantDescription)
+		}
+	}
+}
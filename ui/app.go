@@ -1,31 +1,36 @@
 package ui
 
 import (
+	"context"
 	"fmt"
-	"image/color"
 	"light-llm-client/db"
 	"light-llm-client/llm"
 	"light-llm-client/utils"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
 // App represents the main application
 type App struct {
-	fyneApp    fyne.App
-	window     fyne.Window
-	config     *utils.Config
-	configPath string
-	db         *db.DB
-	logger     *utils.Logger
-	providers  map[string]llm.Provider
-	anonymizer *utils.Anonymizer
+	fyneApp      fyne.App
+	window       fyne.Window
+	config       *utils.Config
+	configPath   string
+	db           *db.DB
+	logger       *utils.Logger
+	providers    map[string]llm.Provider
+	anonymizer   utils.TextAnonymizer
+	spellChecker *utils.SpellChecker
+	macros       *utils.MacroStore // nil if the macros file could not be read
+	// Shared by providers with response caching enabled
+	responseCache *llm.MemoryResponseCache
 
 	// UI components
 	sidebar               *ConversationSidebar
@@ -33,19 +38,58 @@ type App struct {
 	selectedConversationID int64
 	settingsView          *SettingsView
 	searchView            *SearchView
+	taggedView            *TaggedView
+	recycleBinView        *RecycleBinView
 	tabs                  *CustomTabs
 	
 	// Multi-tab support
 	chatViews             map[int64]*ChatView // conversationID -> ChatView
 	tabItems              map[int64]*CustomTab // conversationID -> CustomTab
 	searchTabItem         *CustomTab // Search tab
+	taggedTabItem         *CustomTab // Tagged messages tab
+	recycleBinTabItem     *CustomTab // Recycle bin tab
 	forkTabItem           *CustomTab // Fork conversation tab
+	restoringTabs         bool // Defers saving the tab order until restoreTabs is done
 	
 	// Message cache for preloading
 	messageCache          map[int64][]*db.Message // conversationID -> messages
 	uiCache               map[int64][]fyne.CanvasObject // conversationID -> UI objects
 	cacheMaxSize          int // Maximum number of conversations to cache
 	cacheAccessOrder      []int64 // LRU tracking for cache eviction
+	cacheHits             atomic.Int64 // Conversations loaded from the cache
+	cacheMisses           atomic.Int64 // Conversations loaded from the database
+
+	// loadingMutex holds a *sync.Mutex per conversation ID so only one
+	// loadMessages runs for a conversation at a time
+	loadingMutex sync.Map
+
+	// WebDAV database sync (nil when not configured)
+	syncer   *utils.DBSyncer
+	syncStop chan struct{}
+	syncDone chan struct{} // Closed when the periodic uploads have stopped
+
+	// Scheduled exports of all conversations (nil when not started)
+	exportScheduler *utils.ExportScheduler
+	// Refreshes the export status of the settings, if shown
+	exportStatusChanged func()
+
+	// Extracts the topics of conversations in the background (nil when not started)
+	topicWorker *utils.TopicWorker
+
+	// Unregisters the global hotkey (nil when none is registered)
+	unregisterHotkey func()
+
+	// Messages of showError, showSuccess and showInfo
+	notifications *NotificationCenter
+
+	// Whether the dark theme is applied, followed by the "auto" theme
+	themeIsDark bool
+	// Stops polling the OS appearance (nil when not polling)
+	themePollStop chan struct{}
+
+	// Read aloud in progress and the button that stops it (nil when idle)
+	ttsCancel context.CancelFunc
+	ttsButton *widget.Button
 }
 
 // NewApp creates a new application instance
@@ -67,14 +111,15 @@ func NewApp(config *utils.Config, configPath string, database *db.DB, logger *ut
 		db:         database,
 		logger:     logger,
 		providers:  make(map[string]llm.Provider),
-		anonymizer: utils.NewAnonymizer(config.Privacy),
+		anonymizer: utils.NewTextAnonymizer(config.Privacy),
 		chatViews:  make(map[int64]*ChatView),
 		tabItems:   make(map[int64]*CustomTab),
 		messageCache: make(map[int64][]*db.Message),
 		uiCache:      make(map[int64][]fyne.CanvasObject),
-		cacheMaxSize: 10, // Limit cache to 10 conversations
-		cacheAccessOrder: make([]int64, 0, 10),
+		cacheMaxSize: config.UI.MessageCacheSize(),
+		cacheAccessOrder: make([]int64, 0, config.UI.MessageCacheSize()),
 	}
+	application.notifications = NewNotificationCenter(application)
 
 	// Set up window resize callback to save window size
 	window.SetOnClosed(func() {
@@ -89,14 +134,27 @@ func NewApp(config *utils.Config, configPath string, database *db.DB, logger *ut
 		}
 	})
 
-	// Apply theme from config
+	// Apply theme from config and follow OS appearance changes for "auto"
 	application.applyThemeFromConfig()
+	application.watchSystemTheme()
 
 	// Initialize LLM providers
 	application.initProviders()
 
+	// Initialize spell checker with custom dictionary words
+	application.initSpellChecker()
+	application.initMacros()
+
+	// Conversations deleted a while ago leave the recycle bin
+	application.purgeRecycleBin()
+
 	// Build UI
 	application.buildUI()
+	application.restoreTabs()
+	application.buildSearchIndex()
+
+	// Files dropped from the desktop go to the active chat's upload area
+	window.SetOnDropped(application.handleDrop)
 
 	// Setup system tray
 	application.SetupSystemTray()
@@ -115,6 +173,14 @@ func (a *App) ApplyPrivacyConfig() {
 	if a.anonymizer == nil || a.config == nil {
 		return
 	}
+	a.logger.SetRedactBeforeLog(a.config.Privacy.RedactBeforeLog)
+	// Switching between the regex and the structured anonymizer replaces it;
+	// the placeholders of a request in flight can't be restored then
+	_, structured := a.anonymizer.(*utils.StructuredAnonymizer)
+	if structured != a.config.Privacy.StructuredAnonymizer {
+		a.anonymizer = utils.NewTextAnonymizer(a.config.Privacy)
+		return
+	}
 	a.anonymizer.UpdateConfig(a.config.Privacy)
 }
 
@@ -127,80 +193,14 @@ func (a *App) initProviders() {
 			continue
 		}
 
-		// Use display name if available, otherwise use config key
-		displayName := providerConfig.DisplayName
-		if displayName == "" {
-			displayName = name
-		}
-
-		// Initialize based on provider type
-		if name == "ollama" {
-			// Ollama provider (no API key required)
-			provider, err := llm.NewOllamaProvider(llm.Config{
-				ProviderName: displayName,
-				BaseURL:      providerConfig.BaseURL,
-				Model:        providerConfig.DefaultModel,
-				Models:       providerConfig.Models,
-			})
-			if err != nil {
-				a.logger.Error("Failed to initialize %s provider: %v", name, err)
-			} else {
-				a.providers[name] = provider
-				a.logger.Info("%s provider initialized successfully", name)
-			}
-		} else if name == "claude" || name == "anthropic" {
-			// Claude/Anthropic provider
-			provider, err := llm.NewClaudeProvider(llm.Config{
-				ProviderName: displayName,
-				APIKey:       providerConfig.APIKey,
-				BaseURL:      providerConfig.BaseURL,
-				Model:        providerConfig.DefaultModel,
-				Models:       providerConfig.Models,
-				MaxTokens:    providerConfig.MaxTokens,
-				Temperature:  providerConfig.Temperature,
-			})
-			if err != nil {
-				a.logger.Error("Failed to initialize %s provider: %v", name, err)
-			} else {
-				a.providers[name] = provider
-				a.logger.Info("%s provider initialized successfully", name)
-			}
-		} else if name == "gemini" {
-			// Google Gemini provider
-			provider, err := llm.NewGeminiProvider(llm.Config{
-				ProviderName: displayName,
-				APIKey:       providerConfig.APIKey,
-				BaseURL:      providerConfig.BaseURL,
-				Model:        providerConfig.DefaultModel,
-				Models:       providerConfig.Models,
-				MaxTokens:    providerConfig.MaxTokens,
-				Temperature:  providerConfig.Temperature,
-			})
-			if err != nil {
-				a.logger.Error("Failed to initialize %s provider: %v", name, err)
-			} else {
-				a.providers[name] = provider
-				a.logger.Info("%s provider initialized successfully", name)
-			}
-		} else {
-			// All other providers are treated as OpenAI-compatible
-			// No validation - let the provider itself validate
-			provider, err := llm.NewOpenAIProvider(llm.Config{
-				ProviderName: displayName,
-				APIKey:       providerConfig.APIKey,
-				BaseURL:      providerConfig.BaseURL,
-				Model:        providerConfig.DefaultModel,
-				Models:       providerConfig.Models,
-				MaxTokens:    providerConfig.MaxTokens,
-				Temperature:  providerConfig.Temperature,
-			})
-			if err != nil {
-				a.logger.Error("Failed to initialize %s provider: %v", name, err)
-			} else {
-				a.providers[name] = provider
-				a.logger.Info("%s provider initialized successfully", name)
-			}
+		providerConfig.ProxyURL = a.config.ProviderProxyURL(providerConfig)
+		provider, err := utils.NewProviderWithLogger(name, providerConfig, a.logger)
+		if err != nil {
+			a.logger.Error("Failed to initialize %s provider: %v", name, err)
+			continue
 		}
+		a.providers[name] = a.wrapProvider(provider, providerConfig)
+		a.logger.Info("%s provider initialized successfully", name)
 	}
 
 	if len(a.providers) == 0 {
@@ -208,6 +208,23 @@ func (a *App) initProviders() {
 	}
 }
 
+// responseCacheSize is the number of responses kept by the shared response cache
+const responseCacheSize = 200
+
+// wrapProvider applies the middlewares configured for a provider
+func (a *App) wrapProvider(provider llm.Provider, providerConfig utils.ProviderConfig) llm.Provider {
+	middlewares := []llm.ProviderMiddleware{llm.WithLogging(a.logger)}
+	if providerConfig.CacheResponses {
+		if a.responseCache == nil {
+			a.responseCache = llm.NewMemoryResponseCache(responseCacheSize)
+		}
+		middlewares = append(middlewares, llm.WithResponseCache(a.responseCache))
+	}
+	// Rate limiting is innermost so cache hits don't use up request slots
+	middlewares = append(middlewares, llm.WithRateLimit(providerConfig.RateLimitRPS))
+	return llm.Chain(provider, middlewares...)
+}
+
 // buildUI builds the main UI
 func (a *App) buildUI() {
 	// Create sidebar for conversation history
@@ -229,6 +246,16 @@ func (a *App) buildUI() {
 		a.showSearch()
 	})
 
+	// Create tagged messages button
+	taggedButton := widget.NewButton("🏷️ 标签", func() {
+		a.showTagged()
+	})
+
+	recycleBinButton := widget.NewButton("🗑️ 回收站", func() {
+		a.showRecycleBin()
+	})
+	recycleBinButton.Importance = widget.LowImportance
+
 	// Create custom tabs container for chat views
 	a.tabs = NewCustomTabs()
 	
@@ -240,11 +267,10 @@ func (a *App) buildUI() {
 			a.sidebar.updateHighlight(activeConvID)
 		}
 	}
+	a.tabs.OnReordered = a.saveTabOrder
 	
 	// Create import/export buttons
-	importButton := widget.NewButton("📥 导入", func() {
-		a.showImportDialog()
-	})
+	importButton := a.newImportMenuButton()
 	importButton.Importance = widget.LowImportance
 	
 	exportAllButton := widget.NewButton("📤 导出全部", func() {
@@ -269,9 +295,10 @@ func (a *App) buildUI() {
 		container.NewVBox(
 			container.NewGridWithColumns(2, importButton, exportAllButton),
 			forkButton,
-			searchButton,
-			settingsButton,
+			container.NewGridWithColumns(2, searchButton, taggedButton),
+			container.NewBorder(nil, nil, nil, a.notifications.Button(), settingsButton),
 			a.createNewChatButton(),
+			recycleBinButton,
 		),
 		nil,
 		nil,
@@ -290,79 +317,6 @@ func (a *App) buildUI() {
 	a.setupKeyboardShortcuts()
 }
 
-// setupKeyboardShortcuts sets up global keyboard shortcuts
-func (a *App) setupKeyboardShortcuts() {
-	// Ctrl+N: New conversation
-	a.window.Canvas().AddShortcut(&desktop.CustomShortcut{
-		KeyName:  fyne.KeyN,
-		Modifier: desktop.ControlModifier,
-	}, func(shortcut fyne.Shortcut) {
-		a.logger.Info("Keyboard shortcut: Ctrl+N - New conversation")
-		a.createNewConversation()
-	})
-	
-	// Ctrl+F: Search
-	a.window.Canvas().AddShortcut(&desktop.CustomShortcut{
-		KeyName:  fyne.KeyF,
-		Modifier: desktop.ControlModifier,
-	}, func(shortcut fyne.Shortcut) {
-		a.logger.Info("Keyboard shortcut: Ctrl+F - Search")
-		a.showSearch()
-	})
-	
-	// Ctrl+Comma: Settings
-	a.window.Canvas().AddShortcut(&desktop.CustomShortcut{
-		KeyName:  fyne.KeyComma,
-		Modifier: desktop.ControlModifier,
-	}, func(shortcut fyne.Shortcut) {
-		a.logger.Info("Keyboard shortcut: Ctrl+, - Settings")
-		a.showSettings()
-	})
-	
-	// Ctrl+W: Close current tab
-	a.window.Canvas().AddShortcut(&desktop.CustomShortcut{
-		KeyName:  fyne.KeyW,
-		Modifier: desktop.ControlModifier,
-	}, func(shortcut fyne.Shortcut) {
-		a.logger.Info("Keyboard shortcut: Ctrl+W - Close tab")
-		a.closeCurrentTab()
-	})
-	
-	// Ctrl+Tab: Next tab
-	a.window.Canvas().AddShortcut(&desktop.CustomShortcut{
-		KeyName:  fyne.KeyTab,
-		Modifier: desktop.ControlModifier,
-	}, func(shortcut fyne.Shortcut) {
-		a.logger.Info("Keyboard shortcut: Ctrl+Tab - Next tab")
-		a.nextTab()
-	})
-	
-	// Ctrl+Shift+Tab: Previous tab
-	a.window.Canvas().AddShortcut(&desktop.CustomShortcut{
-		KeyName:  fyne.KeyTab,
-		Modifier: desktop.ControlModifier | desktop.ShiftModifier,
-	}, func(shortcut fyne.Shortcut) {
-		a.logger.Info("Keyboard shortcut: Ctrl+Shift+Tab - Previous tab")
-		a.previousTab()
-	})
-	
-	// Ctrl+Shift+F: Fork conversation
-	a.window.Canvas().AddShortcut(&desktop.CustomShortcut{
-		KeyName:  fyne.KeyF,
-		Modifier: desktop.ControlModifier | desktop.ShiftModifier,
-	}, func(shortcut fyne.Shortcut) {
-		a.logger.Info("Keyboard shortcut: Ctrl+Shift+F - Fork conversation")
-		activeConvID := a.getActiveConversationID()
-		if activeConvID == 0 {
-			a.showError("请先选择一个对话")
-			return
-		}
-		ShowForkDialog(a, activeConvID)
-	})
-	
-	a.logger.Info("Keyboard shortcuts registered")
-}
-
 // nextTab switches to the next tab
 func (a *App) nextTab() {
 	if a.tabs.TabCount() == 0 {
@@ -475,6 +429,7 @@ func (a *App) openChatTab(conversationID int64) {
 	// Store references
 	a.chatViews[conversationID] = chatView
 	a.tabItems[conversationID] = tabItem
+	a.saveTabOrder()
 	
 	// Update sidebar highlighting
 	a.sidebar.updateHighlight(conversationID)
@@ -567,6 +522,30 @@ func (a *App) renameConversationWithData(conv *db.Conversation) {
 	dialog.Show()
 }
 
+// setConversationPinned pins a conversation to the top of the sidebar, or unpins it
+func (a *App) setConversationPinned(conversationID int64, pinned bool) {
+	if err := a.db.SetConversationPinned(conversationID, pinned); err != nil {
+		a.logger.Error("Failed to update pinned state: %v", err)
+		a.showError("置顶失败: " + err.Error())
+		return
+	}
+	a.logger.Info("Conversation %d pinned: %v", conversationID, pinned)
+	a.RefreshSidebar()
+}
+
+// duplicateConversation copies a conversation with its messages and opens the copy
+func (a *App) duplicateConversation(conversationID int64) {
+	duplicate, err := a.db.DuplicateConversation(conversationID)
+	if err != nil {
+		a.logger.Error("Failed to duplicate conversation %d: %v", conversationID, err)
+		a.showError("复制对话失败: " + err.Error())
+		return
+	}
+	a.logger.Info("Duplicated conversation %d into %d", conversationID, duplicate.ID)
+	a.RefreshSidebar()
+	a.openChatTab(duplicate.ID)
+}
+
 // deleteConversationByID deletes a conversation by ID
 func (a *App) deleteConversationByID(conversationID int64) {
 	// Get the conversation from database
@@ -612,7 +591,7 @@ func (a *App) deleteConversationWithData(conv *db.Conversation) {
 		container.NewVBox(
 			widget.NewLabel("确认删除"),
 			widget.NewLabel("确定要删除对话 \"" + conv.Title + "\" 吗？"),
-			widget.NewLabel("此操作不可撤销！"),
+			widget.NewLabel(fmt.Sprintf("对话将移至回收站，%d 天后自动永久删除。", recycleBinRetentionDays)),
 			container.NewHBox(
 				widget.NewButton("取消", func() {
 					dialog.Hide()
@@ -625,12 +604,13 @@ func (a *App) deleteConversationWithData(conv *db.Conversation) {
 						return
 					}
 					
-					a.logger.Info("Conversation deleted: %d", conv.ID)
+					a.logger.Info("Conversation moved to the recycle bin: %d", conv.ID)
 					
 					// Close chat tab if open
 					a.closeChatTab(conv.ID)
 					
 					a.RefreshSidebar()
+					a.refreshRecycleBin()
 					dialog.Hide()
 				}),
 			),
@@ -640,16 +620,56 @@ func (a *App) deleteConversationWithData(conv *db.Conversation) {
 	dialog.Show()
 }
 
+// navigateLoadTimeout limits how long NavigateToMessage waits for the messages to load
+const navigateLoadTimeout = 10 * time.Second
+
+// NavigateToMessage opens the conversation of a message and scrolls to the
+// message once the conversation's messages are shown. Must be called on the
+// UI thread.
+func (a *App) NavigateToMessage(messageID int64) error {
+	message, err := a.db.GetMessageByID(messageID)
+	if err != nil {
+		return err
+	}
+
+	a.openChatTab(message.ConversationID)
+	cv, ok := a.chatViews[message.ConversationID]
+	if !ok {
+		return fmt.Errorf("failed to open conversation %d", message.ConversationID)
+	}
+
+	utils.SafeGo(a.logger, "NavigateToMessage", func() {
+		select {
+		case <-cv.done:
+		case <-cv.ctx.Done():
+			return
+		case <-time.After(navigateLoadTimeout):
+			a.logger.Warn("Timed out waiting for conversation %d to load", message.ConversationID)
+			return
+		}
+		fyne.Do(func() {
+			cv.ScrollToMessage(messageID)
+		})
+	})
+	return nil
+}
+
 // closeChatTab closes a chat tab
 func (a *App) closeChatTab(conversationID int64) {
 	if tabItem, exists := a.tabItems[conversationID]; exists {
 		a.tabs.Remove(tabItem)
+		if cv, ok := a.chatViews[conversationID]; ok {
+			// Cancel in-flight loads for the closed tab
+			cv.Close()
+		}
 		delete(a.chatViews, conversationID)
 		delete(a.tabItems, conversationID)
+		a.saveTabOrder()
 		
 		// Clear cache for this conversation to free memory
 		delete(a.messageCache, conversationID)
 		delete(a.uiCache, conversationID)
+		a.loadingMutex.Delete(conversationID)
 		
 		// Remove from access order
 		for i, id := range a.cacheAccessOrder {
@@ -663,6 +683,46 @@ func (a *App) closeChatTab(conversationID int64) {
 	}
 }
 
+// chatTabOrder returns the conversations of the open chat tabs in display order
+func (a *App) chatTabOrder() []int64 {
+	var order []int64
+	for _, tab := range a.tabs.Tabs() {
+		for convID, tabItem := range a.tabItems {
+			if tabItem == tab {
+				order = append(order, convID)
+				break
+			}
+		}
+	}
+	return order
+}
+
+// saveTabOrder stores the order of the open chat tabs in the config
+func (a *App) saveTabOrder() {
+	if a.restoringTabs {
+		return
+	}
+	a.config.UI.TabOrder = a.chatTabOrder()
+	if err := utils.SaveConfig(a.configPath, a.config); err != nil {
+		a.logger.Error("Failed to save tab order: %v", err)
+	}
+}
+
+// restoreTabs reopens the chat tabs of the last session in their saved order.
+// The order is saved once at the end instead of for every tab.
+func (a *App) restoreTabs() {
+	a.restoringTabs = true
+	for _, convID := range a.config.UI.TabOrder {
+		if _, err := a.db.GetConversation(convID); err != nil {
+			a.logger.Warn("Skipping tab of missing conversation %d: %v", convID, err)
+			continue
+		}
+		a.openChatTab(convID)
+	}
+	a.restoringTabs = false
+	a.saveTabOrder()
+}
+
 // showSettings shows the settings window
 func (a *App) showSettings() {
 	settingsWin := a.fyneApp.NewWindow("设置")
@@ -722,6 +782,18 @@ func (a *App) closeCurrentTab() {
 		return
 	}
 	
+	// Check if it's the tagged messages tab
+	if a.taggedTabItem != nil && selectedTab == a.taggedTabItem {
+		a.closeTaggedTab()
+		return
+	}
+	
+	// Check if it's the recycle bin tab
+	if a.recycleBinTabItem != nil && selectedTab == a.recycleBinTabItem {
+		a.closeRecycleBinTab()
+		return
+	}
+	
 	// Check if it's the fork tab
 	if a.forkTabItem != nil && selectedTab == a.forkTabItem {
 		a.closeForkTab()
@@ -806,100 +878,39 @@ func (a *App) preloadConversationByID(conversationID int64) {
 	})
 }
 
-// showError shows an error dialog
+// showError shows an error dialog and keeps it in the notification center
 func (a *App) showError(message string) {
-	var dialog *widget.PopUp
-	dialog = widget.NewModalPopUp(
-		container.NewVBox(
-			widget.NewLabel("❌ Error"),
-			widget.NewLabel(message),
-			widget.NewButton("OK", func() {
-				dialog.Hide()
-			}),
-		),
-		a.window.Canvas(),
-	)
-	dialog.Show()
+	a.notifications.Add(NotificationError, message)
 }
 
-// showSuccess shows a success dialog
+// showSuccess shows a success dialog and keeps it in the notification center
 func (a *App) showSuccess(message string) {
-	var dialog *widget.PopUp
-	dialog = widget.NewModalPopUp(
-		container.NewVBox(
-			widget.NewLabel("✅ Success"),
-			widget.NewLabel(message),
-			widget.NewButton("OK", func() {
-				dialog.Hide()
-			}),
-		),
-		a.window.Canvas(),
-	)
-	dialog.Show()
+	a.notifications.Add(NotificationSuccess, message)
 }
 
-// showInfo shows an info dialog
+// showInfo shows an info dialog and keeps it in the notification center
 func (a *App) showInfo(message string) {
-	var dialog *widget.PopUp
-	dialog = widget.NewModalPopUp(
-		container.NewVBox(
-			widget.NewLabel("ℹ️ 信息"),
-			widget.NewLabel(message),
-			widget.NewButton("确定", func() {
-				dialog.Hide()
-			}),
-		),
-		a.window.Canvas(),
-	)
-	dialog.Show()
-}
-
-// lightTheme is a simple light theme
-type lightTheme struct{}
-
-func (t *lightTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
-	return theme.DefaultTheme().Color(name, theme.VariantLight)
-}
-
-func (t *lightTheme) Font(style fyne.TextStyle) fyne.Resource {
-	return theme.DefaultTheme().Font(style)
-}
-
-func (t *lightTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
-	return theme.DefaultTheme().Icon(name)
-}
-
-func (t *lightTheme) Size(name fyne.ThemeSizeName) float32 {
-	return theme.DefaultTheme().Size(name)
-}
-
-// darkTheme is a simple dark theme
-type darkTheme struct{}
-
-func (t *darkTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
-	return theme.DefaultTheme().Color(name, theme.VariantDark)
-}
-
-func (t *darkTheme) Font(style fyne.TextStyle) fyne.Resource {
-	return theme.DefaultTheme().Font(style)
-}
-
-func (t *darkTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
-	return theme.DefaultTheme().Icon(name)
-}
-
-func (t *darkTheme) Size(name fyne.ThemeSizeName) float32 {
-	return theme.DefaultTheme().Size(name)
+	a.notifications.Add(NotificationInfo, message)
 }
 
-// applyThemeFromConfig applies the theme from config
+// applyThemeFromConfig applies the theme from config. The "auto" theme
+// follows the OS appearance.
 func (a *App) applyThemeFromConfig() {
 	isDark := a.config.UI.Theme == "dark"
+	if a.config.UI.Theme == "auto" {
+		isDark = a.fyneApp.Settings().ThemeVariant() == theme.VariantDark
+	}
+	a.setTheme(isDark)
+}
+
+// setTheme applies the light or dark theme with the configured font size
+func (a *App) setTheme(isDark bool) {
 	fontSize := a.config.UI.FontSize
 	if fontSize < 10 {
 		fontSize = 14 // Default font size
 	}
 	
+	a.themeIsDark = isDark
 	customTheme := newCustomTheme(fontSize, isDark)
 	a.fyneApp.Settings().SetTheme(customTheme)
 	
@@ -917,18 +928,65 @@ func (a *App) getActiveConversationID() int64 {
 	}
 	
 	// Find which conversation tab is currently selected
-	selectedTab := a.tabs.GetActiveTab()
+	return a.conversationIDOfTab(a.tabs.GetActiveTab())
+}
+
+// conversationIDOfTab returns the conversation shown in a tab, 0 if the tab
+// isn't a chat tab
+func (a *App) conversationIDOfTab(tab *CustomTab) int64 {
 	for convID, tabItem := range a.tabItems {
-		if tabItem == selectedTab {
+		if tabItem == tab {
 			return convID
 		}
 	}
-	
 	return 0
 }
 
+// handleDrop forwards files dropped on the window to the active chat tab
+func (a *App) handleDrop(_ fyne.Position, uris []fyne.URI) {
+	chatView, ok := a.chatViews[a.getActiveConversationID()]
+	if !ok || chatView.fileUploadArea == nil {
+		a.showInfo("请先打开一个对话，再拖放文件")
+		return
+	}
+	chatView.fileUploadArea.HandleDrop(uris)
+}
+
 // exportConversation exports a conversation to a file
 func (a *App) exportConversation(conversationID int64, format utils.ExportFormat) {
+	a.exportConversationFile(conversationID, format, func(conv *db.Conversation, filepath string) error {
+		switch format {
+		case utils.FormatJSON:
+			return utils.ExportConversationToJSON(a.db, conversationID, filepath)
+		case utils.FormatMarkdown:
+			return utils.ExportConversationToMarkdown(a.db, conversationID, filepath)
+		case utils.FormatHTML:
+			return utils.ExportConversationToHTML(a.db, conversationID, filepath)
+		case utils.FormatPDF:
+			return utils.ExportConversationToPDF(a.db, conversationID, filepath)
+		case utils.FormatCSV:
+			return utils.ExportConversationToCSV(a.db, conversationID, filepath)
+		default:
+			return fmt.Errorf("unsupported export format: %s", format)
+		}
+	})
+}
+
+// exportConversationUsageReport exports a PDF usage report limited to the
+// messages of a conversation
+func (a *App) exportConversationUsageReport(conversationID int64) {
+	a.exportConversationFile(conversationID, utils.FormatPDF, func(conv *db.Conversation, filepath string) error {
+		stats, err := a.db.GetConversationUsageStats(conversationID)
+		if err != nil {
+			return err
+		}
+		return utils.ExportUsageReportToPDF(stats, conv.CreatedAt, conv.UpdatedAt, filepath)
+	})
+}
+
+// exportConversationFile writes an export of a conversation to the export
+// directory with write, named after the conversation
+func (a *App) exportConversationFile(conversationID int64, format utils.ExportFormat, write func(conv *db.Conversation, filepath string) error) {
 	// Get conversation for filename
 	conv, err := a.db.GetConversation(conversationID)
 	if err != nil {
@@ -937,7 +995,7 @@ func (a *App) exportConversation(conversationID int64, format utils.ExportFormat
 	}
 
 	// Get default export path
-	exportDir, err := utils.GetDefaultExportPath()
+	exportDir, err := utils.GetDefaultExportPath(a.config.RecentFiles)
 	if err != nil {
 		a.showError("Failed to get export directory: " + err.Error())
 		return
@@ -947,27 +1005,20 @@ func (a *App) exportConversation(conversationID int64, format utils.ExportFormat
 	filename := utils.GenerateExportFilename(conv.Title, format)
 	filepath := exportDir + "/" + filename
 
-	// Export based on format
-	var exportErr error
-	if format == utils.FormatJSON {
-		exportErr = utils.ExportConversationToJSON(a.db, conversationID, filepath)
-	} else if format == utils.FormatMarkdown {
-		exportErr = utils.ExportConversationToMarkdown(a.db, conversationID, filepath)
-	}
-
-	if exportErr != nil {
-		a.showError("Export failed: " + exportErr.Error())
+	if err := write(conv, filepath); err != nil {
+		a.showError("Export failed: " + err.Error())
 		return
 	}
 
 	a.logger.Info("Exported conversation %d to %s", conversationID, filepath)
+	a.addRecentFile(filepath)
 	a.showInfo("导出成功!\n文件保存在: " + filepath)
 }
 
 // exportAllConversations exports all conversations to a JSON file
 func (a *App) exportAllConversations() {
 	// Get default export path
-	exportDir, err := utils.GetDefaultExportPath()
+	exportDir, err := utils.GetDefaultExportPath(a.config.RecentFiles)
 	if err != nil {
 		a.showError("Failed to get export directory: " + err.Error())
 		return
@@ -984,6 +1035,7 @@ func (a *App) exportAllConversations() {
 	}
 
 	a.logger.Info("Exported all conversations to %s", filepath)
+	a.addRecentFile(filepath)
 	a.showInfo("导出成功!\n文件保存在: " + filepath)
 }
 
@@ -996,6 +1048,7 @@ func (a *App) importConversation(filepath string) {
 	}
 
 	a.logger.Info("Imported conversation: %s (ID: %d)", conv.Title, conv.ID)
+	a.addRecentFile(filepath)
 	a.RefreshSidebar()
 	a.showInfo("导入成功!\n对话: " + conv.Title)
 }
@@ -1009,48 +1062,14 @@ func (a *App) importAllConversations(filepath string) {
 	}
 
 	a.logger.Info("Imported %d conversations", count)
+	a.addRecentFile(filepath)
 	a.RefreshSidebar()
 	a.showInfo(fmt.Sprintf("导入成功!\n共导入 %d 个对话", count))
 }
 
-// showImportDialog shows a dialog to import conversations
+// showImportDialog starts the conversation import wizard
 func (a *App) showImportDialog() {
-	// Create file open dialog
-	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
-		if err != nil {
-			a.showError("Failed to open file: " + err.Error())
-			return
-		}
-		if reader == nil {
-			return // User cancelled
-		}
-		defer reader.Close()
-		
-		filepath := reader.URI().Path()
-		a.logger.Info("Importing from: %s", filepath)
-		
-		// Try to import as multiple conversations first
-		count, err := utils.ImportAllConversations(a.db, filepath)
-		if err == nil && count > 0 {
-			a.logger.Info("Imported %d conversations", count)
-			a.RefreshSidebar()
-			a.showInfo(fmt.Sprintf("导入成功!\n共导入 %d 个对话", count))
-			return
-		}
-		
-		// If that fails, try importing as single conversation
-		conv, err := utils.ImportConversation(a.db, filepath)
-		if err != nil {
-			a.showError("导入失败: " + err.Error())
-			return
-		}
-		
-		a.logger.Info("Imported conversation: %s (ID: %d)", conv.Title, conv.ID)
-		a.RefreshSidebar()
-		a.showInfo("导入成功!\n对话: " + conv.Title)
-	}, a.window)
-	
-	fileDialog.Show()
+	NewConversationImportWizard(a).Show()
 }
 
 // setCategoryForConversation shows a dialog to set category for a conversation
@@ -1183,6 +1202,16 @@ func (a *App) updateCacheAccess(conversationID int64) {
 	}
 }
 
+// setCacheMaxSize changes the number of cached conversations, evicting the
+// least recently used ones beyond it
+func (a *App) setCacheMaxSize(size int) {
+	a.cacheMaxSize = size
+	// Preloaded conversations aren't in the access order and stay
+	for len(a.messageCache) > a.cacheMaxSize && len(a.cacheAccessOrder) > 0 {
+		a.evictOldestCache()
+	}
+}
+
 // clearUnusedCache clears cache for conversations that don't have open tabs
 func (a *App) clearUnusedCache() {
 	cleared := 0
@@ -1218,9 +1247,27 @@ func (a *App) Cleanup() {
 	a.uiCache = nil
 	a.cacheAccessOrder = nil
 	
+	a.removeGlobalHotkey()
+	a.stopSystemThemePoll()
+	a.stopReadAloud()
+
+	if a.exportScheduler != nil {
+		a.exportScheduler.Stop()
+	}
+	if a.topicWorker != nil {
+		a.topicWorker.Stop()
+	}
+
+	// Upload the final state of the database before closing it
+	a.stopSync()
 	if a.db != nil {
 		a.db.Close()
 	}
+	if a.syncer != nil {
+		if err := a.syncer.MarkLocalSynced(); err != nil {
+			a.logger.Error("Failed to record sync state: %v", err)
+		}
+	}
 	if a.logger != nil {
 		a.logger.Close()
 	}
@@ -7,12 +7,16 @@ import (
 	"light-llm-client/db"
 	"light-llm-client/llm"
 	"light-llm-client/utils"
+	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -23,6 +27,10 @@ type customEntry struct {
 	onPaste     func()    // Called when Ctrl+V is pressed to handle clipboard paste
 	app         *App      // Reference to app for logging and clipboard access
 	cv          *ChatView // Reference to chat view for showing warnings
+	// Spell checking state (see spellcheck.go)
+	spellTimer       *time.Timer
+	misspellings     []utils.Misspelling
+	misspellingAreas []misspellingArea
 }
 
 // TypedShortcut handles keyboard shortcuts
@@ -51,6 +59,13 @@ func (e *customEntry) TypedShortcut(shortcut fyne.Shortcut) {
 		return
 	}
 
+	// Ctrl+Z and Ctrl+Y undo the typing, then the last send
+	switch shortcut.(type) {
+	case *fyne.ShortcutUndo, *fyne.ShortcutRedo:
+		e.undoOrRedoInput(shortcut)
+		return
+	}
+
 	// Check if it's a custom keyboard shortcut
 	if ks, ok := shortcut.(*desktop.CustomShortcut); ok {
 		// Check for Ctrl+Return or Ctrl+Enter
@@ -61,6 +76,11 @@ func (e *customEntry) TypedShortcut(shortcut fyne.Shortcut) {
 				return
 			}
 		}
+		// Alt+1 to Alt+9 insert quick prompts
+		if key, ok := quickPromptKey(ks); ok && e.cv != nil {
+			e.cv.insertQuickPromptByKey(key)
+			return
+		}
 	}
 	// Let the parent Entry handle other shortcuts
 	e.Entry.TypedShortcut(shortcut)
@@ -210,6 +230,16 @@ func byteIndexAtCursor(text string, row, col int) int {
 	return len(text)
 }
 
+// TypedRune hides follow-up suggestions and schedules a debounced spell check
+// after each typed character
+func (e *customEntry) TypedRune(r rune) {
+	e.Entry.TypedRune(r)
+	if e.cv != nil {
+		e.cv.clearFollowUpSuggestions()
+	}
+	e.scheduleSpellCheck()
+}
+
 // TypedKey intercepts key events as a fallback
 func (e *customEntry) TypedKey(key *fyne.KeyEvent) {
 	// Check for Enter/Return with Ctrl modifier
@@ -225,8 +255,16 @@ func (e *customEntry) TypedKey(key *fyne.KeyEvent) {
 			}
 		}
 	}
+	// Space or Tab after a /trigger expands its macro. The space itself
+	// is typed as a rune afterwards, the Tab is dropped.
+	if key.Name == fyne.KeySpace || key.Name == fyne.KeyTab {
+		if e.expandMacro() && key.Name == fyne.KeyTab {
+			return
+		}
+	}
 	// Let the parent Entry handle other keys
 	e.Entry.TypedKey(key)
+	e.scheduleSpellCheck()
 }
 
 // newSelectableText creates a read-only, selectable text widget.
@@ -238,6 +276,36 @@ func newSelectableText(text string) *widget.Label {
 	return label
 }
 
+// newCachedSelectableText creates a selectable text widget of a message and
+// remembers its original text. A selected wrapped label copies its visual
+// line breaks, so copy buttons read the text from the cache instead.
+func (cv *ChatView) newCachedSelectableText(text string) *widget.Label {
+	label := newSelectableText(text)
+	cv.selectableTextMu.Lock()
+	cv.selectableTextCache[label] = text
+	cv.selectableTextMu.Unlock()
+	return label
+}
+
+// selectableText returns the original text of a label created by
+// newCachedSelectableText, or the label's text for other labels
+func (cv *ChatView) selectableText(label *widget.Label) string {
+	cv.selectableTextMu.Lock()
+	defer cv.selectableTextMu.Unlock()
+	if text, ok := cv.selectableTextCache[label]; ok {
+		return text
+	}
+	return label.Text
+}
+
+// resetSelectableTextCache forgets the labels of the previous rendering
+// before all messages are built anew
+func (cv *ChatView) resetSelectableTextCache() {
+	cv.selectableTextMu.Lock()
+	cv.selectableTextCache = make(map[*widget.Label]string)
+	cv.selectableTextMu.Unlock()
+}
+
 // newSelectableCodeText creates a read-only, selectable code text widget with monospace font.
 func newSelectableCodeText(text string) *widget.Label {
 	label := widget.NewLabel(text)
@@ -252,92 +320,276 @@ type ChatView struct {
 	app               *App
 	conversationID    int64
 	currentProvider   string
-	messagesContainer *fyne.Container
+	currentModel      string // Model picked in the model dropdown ("" = provider default)
+	messagesContainer *virtualMessageList
+	messagesScroll    *container.Scroll
 	inputEntry        *customEntry
 	sendButton        *widget.Button
 	providerSelect    *widget.Select
-	fileUploadArea    *FileUploadArea
-	messages          []db.Message // Store the actual messages for reference
+	// Provider of the next message only, see provider_override.go
+	nextProviderSelect *widget.Select
+	modelSelect        *widget.Select
+	fileUploadArea     *FileUploadArea
+	messages           []db.Message // Store the actual messages for reference
 	// Track which messages are showing anonymized content (true = showing anonymized, false = showing original)
 	showAnonymized map[int]bool
+	// Index of the message last right-clicked, -1 if none. The conversation
+	// can be split there, see split.go.
+	selectedMessage int
 	// Cache for messages and UI components to prevent flickering
 	messageCache []db.Message
 	uiCache      []fyne.CanvasObject
+	// Pause streaming: while paused, pauseStream is an open channel that the
+	// stream reader blocks on; resuming closes it. nil means not paused.
+	// streamCancel stops the response being streamed, nil when there is none.
+	pauseMu      sync.Mutex
+	pauseStream  chan struct{}
+	pauseButton  *widget.Button
+	streamCancel context.CancelFunc
+	stopButton   *widget.Button
+	// Follow-up question chips shown below the latest response (see followup.go)
+	followUpContainer *fyne.Container
+	quickPromptBar    *QuickPromptBar
+	// Lifecycle context of the tab, cancelled by Close when the tab is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	// Message to scroll to once it has been rendered (0 = none)
+	pendingScrollMessageID int64
+	// done is closed once loadMessages has shown the messages for the first time
+	done     chan struct{}
+	doneOnce sync.Once
+	// In-conversation search (Ctrl+F)
+	searchBar *MessageSearchBar
+	// Topic chips of the conversation, see topics.go
+	topicsContainer *fyne.Container
+	// Collapsible settings of the conversation: the system prompt (see
+	// system_prompt.go) and the parameter overrides (see params.go)
+	settingsPanel     *widget.Accordion
+	systemPrompt      string
+	systemPromptEntry *widget.Entry
+	systemPromptItem  *widget.AccordionItem
+	paramsEditor      *paramsEditor
+	// Tokens of the next request next to the send button, see token_count.go.
+	// tokenCountSeq drops the results of outdated counts.
+	tokenLabel    *widget.Label
+	tokenMu       sync.Mutex
+	tokenTimer    *time.Timer
+	tokenCountSeq int
+	// Share of the context window above the input, see token_count.go
+	contextBar      *widget.ProgressBar
+	contextBarTheme *contextBarTheme
+	contextBarBox   *fyne.Container
+	// JSON mode asks for JSON responses and indents them, see json_mode.go
+	jsonMode      bool
+	jsonModeCheck *widget.Check
+	// Original text of the selectable message labels. Copying reads it
+	// instead of the wrapped label, see newCachedSelectableText.
+	selectableTextMu    sync.Mutex
+	selectableTextCache map[*widget.Label]string
+	// Sends that Ctrl+Z takes back and Ctrl+Y restores, see undo.go. They
+	// are kept while the tab is open.
+	undoMu    sync.Mutex
+	undoStack []*UndoAction
+	redoStack []*UndoAction
 }
 
-// streamChatWithRetry attempts to stream chat with retry logic
-func (cv *ChatView) streamChatWithRetry(ctx context.Context, provider llm.Provider, messages []llm.Message, maxRetries int) (<-chan llm.StreamResponse, error) {
-	var lastErr error
-
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff: 1s, 2s, 4s
-			waitTime := time.Duration(1<<uint(attempt-1)) * time.Second
-			cv.app.logger.Info("Retrying in %v (attempt %d/%d)...", waitTime, attempt, maxRetries)
-			time.Sleep(waitTime)
-		}
+// togglePauseStreaming pauses or resumes rendering of the current stream.
+// The provider connection stays open while paused; the unread chunks back up
+// in the stream channel and the TCP receive window.
+func (cv *ChatView) togglePauseStreaming() {
+	cv.pauseMu.Lock()
+	paused := cv.pauseStream == nil
+	if paused {
+		cv.pauseStream = make(chan struct{})
+	} else {
+		close(cv.pauseStream)
+		cv.pauseStream = nil
+	}
+	cv.pauseMu.Unlock()
 
-		stream, err := provider.StreamChat(ctx, messages)
-		if err == nil {
-			if attempt > 0 {
-				cv.app.logger.Info("Retry successful on attempt %d", attempt+1)
-			}
-			return stream, nil
-		}
+	if paused {
+		cv.app.logger.Info("Streaming paused for conversation %d", cv.conversationID)
+		cv.pauseButton.SetText("▶ 继续")
+	} else {
+		cv.app.logger.Info("Streaming resumed for conversation %d", cv.conversationID)
+		cv.pauseButton.SetText("⏸ 暂停")
+	}
+}
 
-		lastErr = err
-		cv.app.logger.Warn("Stream chat attempt %d failed: %v", attempt+1, err)
+// waitIfPaused blocks the stream reader until streaming is resumed
+func (cv *ChatView) waitIfPaused() {
+	cv.pauseMu.Lock()
+	pause := cv.pauseStream
+	cv.pauseMu.Unlock()
 
-		// Check if error is retryable (network errors, timeouts, etc.)
-		if !isRetryableError(err) {
-			cv.app.logger.Info("Error is not retryable, stopping retry attempts")
-			break
-		}
+	if pause != nil {
+		<-pause
 	}
+}
 
-	return nil, fmt.Errorf("failed after %d attempts: %w", maxRetries+1, lastErr)
+// startStreaming shows the streaming controls and returns the context of a
+// new response stream, cancelled by the stop button or by closing the view.
+// End the stream with setStreaming(false).
+func (cv *ChatView) startStreaming() context.Context {
+	ctx, cancel := context.WithCancel(cv.ctx)
+	cv.pauseMu.Lock()
+	cv.streamCancel = cancel
+	cv.pauseMu.Unlock()
+	cv.setStreaming(true)
+	return ctx
 }
 
-// isRetryableError checks if an error should trigger a retry
-func isRetryableError(err error) bool {
-	if err == nil {
-		return false
+// stopStreaming cancels the response being streamed. A paused stream is
+// resumed so that the reader sees the cancellation.
+func (cv *ChatView) stopStreaming() {
+	cv.pauseMu.Lock()
+	cancel := cv.streamCancel
+	if cv.pauseStream != nil {
+		close(cv.pauseStream)
+		cv.pauseStream = nil
 	}
+	cv.pauseMu.Unlock()
 
-	errStr := strings.ToLower(err.Error())
+	if cancel != nil {
+		cv.app.logger.Info("Streaming stopped for conversation %d", cv.conversationID)
+		cancel()
+	}
+}
 
-	// Network-related errors that should be retried
-	retryableErrors := []string{
-		"connection refused",
-		"connection reset",
-		"timeout",
-		"temporary failure",
-		"network",
-		"dial tcp",
-		"i/o timeout",
-		"no such host",
-		"connection timed out",
-		"eof",
+// setStreaming shows or hides the pause and stop buttons, disables sending
+// while streaming and resets the pause state
+func (cv *ChatView) setStreaming(streaming bool) {
+	cv.pauseMu.Lock()
+	if cv.pauseStream != nil {
+		close(cv.pauseStream)
+		cv.pauseStream = nil
+	}
+	if !streaming && cv.streamCancel != nil {
+		cv.streamCancel()
+		cv.streamCancel = nil
 	}
+	cv.pauseMu.Unlock()
 
-	for _, retryable := range retryableErrors {
-		if strings.Contains(errStr, retryable) {
-			return true
+	fyne.Do(func() {
+		if cv.pauseButton == nil {
+			return
+		}
+		cv.pauseButton.SetText("⏸ 暂停")
+		if streaming {
+			cv.pauseButton.Show()
+			cv.stopButton.Show()
+			cv.sendButton.Disable()
+		} else {
+			cv.pauseButton.Hide()
+			cv.stopButton.Hide()
+			cv.sendButton.Enable()
 		}
+	})
+}
+
+// cancelledMarker ends the saved part of a response stopped by the user
+const cancelledMarker = "[cancelled]"
+
+// saveCancelledResponse saves the partial response of a stopped stream, marked
+// as cancelled, and shows it in place of the streaming placeholder. It returns
+// the saved message, nil if saving failed.
+func (cv *ChatView) saveCancelledResponse(partial, providerName, model string) *db.Message {
+	content := strings.TrimRight(cv.app.anonymizer.Deanonymize(partial), " \n")
+	if content == "" {
+		content = cancelledMarker
+	} else {
+		content += " " + cancelledMarker
+	}
+	cv.app.anonymizer.Clear()
+
+	assistantMsg, err := cv.app.db.CreateMessage(
+		cv.conversationID,
+		"assistant",
+		content,
+		providerName,
+		model,
+		"",
+		0,
+	)
+	if err != nil {
+		cv.app.logger.Error("Failed to save cancelled response: %v", err)
+		return nil
 	}
 
-	return false
+	fyne.Do(func() {
+		dbMessages, err := cv.app.db.ListMessages(cv.conversationID)
+		if err != nil {
+			cv.app.logger.Error("Failed to load messages: %v", err)
+			return
+		}
+		cv.messages = make([]db.Message, len(dbMessages))
+		for i, msg := range dbMessages {
+			cv.messages[i] = *msg
+		}
+		cv.syncShowAnonymizedMap()
+
+		// Replace the streaming placeholder
+		if lastIndex := len(cv.messagesContainer.Objects) - 1; lastIndex >= 0 {
+			cv.messagesContainer.Objects[lastIndex] = cv.buildMessageUI(assistantMsg, len(cv.messages)-1)
+			cv.messagesContainer.Refresh()
+		}
+
+		cv.app.messageCache[cv.conversationID] = dbMessages
+		cv.app.uiCache[cv.conversationID] = append([]fyne.CanvasObject{}, cv.messagesContainer.Objects...)
+		cv.app.updateCacheAccess(cv.conversationID)
+		cv.scheduleTokenCount()
+	})
+	return assistantMsg
+}
+
+// streamChatWithRetry attempts to stream chat, retrying network errors with
+// the backoff configured for the provider
+func (cv *ChatView) streamChatWithRetry(ctx context.Context, provider llm.Provider, providerName, systemPrompt string, messages []llm.Message) (<-chan llm.StreamResponse, error) {
+	policy := cv.app.config.LLMProviders[providerName].RetryPolicy()
+	attempts := policy.Attempts()
+
+	var stream <-chan llm.StreamResponse
+	attempt := 0
+	err := llm.Retry(ctx, policy, func() error {
+		attempt++
+		var err error
+		stream, err = provider.StreamChatWithSystemPrompt(ctx, systemPrompt, messages)
+		if err != nil {
+			cv.app.logger.Warn("Stream chat attempt %d failed: %v", attempt, err)
+			if !llm.IsRetryableError(err) {
+				cv.app.logger.Info("Error is not retryable, stopping retry attempts")
+			}
+			return err
+		}
+		if attempt > 1 {
+			cv.app.logger.Info("Retry successful on attempt %d", attempt)
+		}
+		return nil
+	}, func(retry int, delay time.Duration, err error) {
+		cv.app.logger.Info("Retrying in %v (attempt %d/%d)...", delay, retry+1, attempts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
 }
 
 // NewChatView creates a new chat view
 func NewChatView(app *App) *ChatView {
+	ctx, cancel := context.WithCancel(context.Background())
 	cv := &ChatView{
+		ctx:             ctx,
+		cancel:          cancel,
 		app:             app,
 		conversationID:  0,
 		currentProvider: "",
 		showAnonymized:  make(map[int]bool),
+		selectedMessage: -1,
 		messageCache:    make([]db.Message, 0),
 		uiCache:         make([]fyne.CanvasObject, 0),
+		done:            make(chan struct{}),
+
+		selectableTextCache: make(map[*widget.Label]string),
 	}
 
 	return cv
@@ -346,9 +598,10 @@ func NewChatView(app *App) *ChatView {
 // Build builds the chat view UI
 func (cv *ChatView) Build() fyne.CanvasObject {
 	// Messages container (scrollable)
-	cv.messagesContainer = container.NewVBox()
-	messagesScroll := container.NewScroll(cv.messagesContainer)
+	cv.messagesContainer = newVirtualMessageList()
+	messagesScroll := cv.messagesContainer.NewScroll()
 	messagesScroll.SetMinSize(fyne.NewSize(600, 400))
+	cv.messagesScroll = messagesScroll
 
 	// Provider selection
 	providerOptions := []string{}
@@ -359,9 +612,25 @@ func (cv *ChatView) Build() fyne.CanvasObject {
 		providerOptions = []string{"请在配置文件中启用 LLM 提供商"}
 	}
 
+	// Model selection, filled from the models of the selected provider
+	cv.modelSelect = widget.NewSelect(nil, func(value string) {
+		if value == cv.currentModel {
+			return
+		}
+		cv.currentModel = value
+		cv.app.logger.Info("Selected model: %s", value)
+		cv.scheduleTokenCount()
+	})
+	cv.modelSelect.PlaceHolder = "默认模型"
+
 	cv.providerSelect = widget.NewSelect(providerOptions, func(value string) {
 		cv.currentProvider = value
 		cv.app.logger.Info("Selected provider: %s", value)
+		// A new provider starts with its default model
+		cv.currentModel = ""
+		cv.refreshModelOptions()
+		cv.refreshModels()
+		cv.scheduleTokenCount()
 	})
 	if len(providerOptions) > 0 && providerOptions[0] != "请在配置文件中启用 LLM 提供商" {
 		cv.providerSelect.SetSelected(providerOptions[0])
@@ -389,15 +658,30 @@ func (cv *ChatView) Build() fyne.CanvasObject {
 		// Handle clipboard paste for images and files
 		cv.fileUploadArea.HandleClipboardPaste()
 	}
+	cv.inputEntry.OnChanged = func(string) {
+		cv.scheduleTokenCount()
+	}
 	cv.inputEntry.ExtendBaseWidget(cv.inputEntry)
 
 	cv.sendButton = widget.NewButton("发送", func() {
 		cv.sendMessage()
 	})
 
+	// Stop button (only visible while a response is streaming)
+	cv.stopButton = widget.NewButton("⏹ 停止", func() {
+		cv.stopStreaming()
+	})
+	cv.stopButton.Importance = widget.DangerImportance
+	cv.stopButton.Hide()
+
+	// Token count of the next request (hidden until counted)
+	cv.tokenLabel = widget.NewLabel("")
+	cv.tokenLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	cv.tokenLabel.Hide()
+
 	// Input area with file upload
 	inputWithFiles := container.NewBorder(
-		cv.fileUploadArea,
+		container.NewVBox(cv.fileUploadArea, cv.newContextBar()),
 		nil,
 		nil,
 		nil,
@@ -408,7 +692,7 @@ func (cv *ChatView) Build() fyne.CanvasObject {
 		nil,
 		nil,
 		nil,
-		cv.sendButton,
+		container.NewVBox(cv.sendButton, cv.stopButton, cv.newNextProviderSelect(), cv.tokenLabel),
 		inputWithFiles,
 	)
 
@@ -421,29 +705,128 @@ func (cv *ChatView) Build() fyne.CanvasObject {
 		ShowForkDialog(cv.app, cv.conversationID)
 	})
 
-	// Top bar with provider selection and fork button
+	// Prompt template button
+	templateButton := widget.NewButton("📋 模板", func() {
+		cv.showTemplateDialog()
+	})
+
+	// Pause button (only visible while a response is streaming)
+	cv.pauseButton = widget.NewButton("⏸ 暂停", func() {
+		cv.togglePauseStreaming()
+	})
+	cv.pauseButton.Hide()
+
+	// Top bar with provider and model selection, pause, JSON mode, image, template and fork buttons
 	topBar := container.NewBorder(
 		nil,
 		nil,
 		widget.NewLabel("模型提供商:"),
-		forkButton,
-		cv.providerSelect,
+		container.NewHBox(cv.pauseButton, cv.newJSONModeCheck(), cv.newGenerateImageButton(), templateButton, forkButton),
+		container.NewGridWithColumns(2,
+			cv.providerSelect,
+			container.NewBorder(nil, nil, widget.NewLabel("模型:"), nil, cv.modelSelect),
+		),
 	)
 
+	// System prompt and parameters of the conversation (collapsed)
+	cv.settingsPanel = widget.NewAccordion(cv.buildSystemPromptItem(), cv.buildParamsItem())
+
+	// Topics of the conversation (hidden until it has some)
+	cv.topicsContainer = container.NewVBox()
+	cv.topicsContainer.Hide()
+
+	// Follow-up suggestions (hidden until suggestions arrive)
+	cv.followUpContainer = container.NewVBox()
+	cv.followUpContainer.Hide()
+
+	// Quick prompts (Alt+1 to Alt+9)
+	cv.quickPromptBar = NewQuickPromptBar(cv.app, cv.insertQuickPrompt)
+
+	// Search within the conversation, shown with Ctrl+F
+	cv.searchBar = NewMessageSearchBar(cv)
+
 	// Main layout
 	return container.NewBorder(
-		topBar,
-		inputContainer,
+		container.NewVBox(cv.settingsPanel, topBar, cv.topicsContainer),
+		container.NewVBox(cv.followUpContainer, cv.quickPromptBar.Build(), inputContainer),
 		nil,
 		nil,
-		messagesScroll,
+		container.NewBorder(cv.searchBar.Build(), nil, nil, nil, messagesScroll),
 	)
 }
 
+// Close cancels in-flight database work started by this view
+func (cv *ChatView) Close() {
+	// Resumes a paused stream so its reader sees the cancellation
+	cv.stopStreaming()
+	cv.cancel()
+	cv.tokenMu.Lock()
+	if cv.tokenTimer != nil {
+		cv.tokenTimer.Stop()
+	}
+	cv.tokenMu.Unlock()
+}
+
 // SetConversation sets the current conversation
 func (cv *ChatView) SetConversation(conversationID int64) {
 	cv.conversationID = conversationID
 	cv.loadMessages()
+	cv.loadTopics()
+	cv.loadSystemPrompt()
+	cv.loadParams()
+}
+
+// ScrollToMessage scrolls the message list so the given message is at the top.
+// If the message has not been rendered yet, scrolling happens once it is.
+// Must be called on the UI thread.
+func (cv *ChatView) ScrollToMessage(messageID int64) {
+	cv.pendingScrollMessageID = messageID
+	cv.applyPendingScroll()
+}
+
+// applyPendingScroll scrolls to the pending message if it is rendered
+func (cv *ChatView) applyPendingScroll() {
+	if cv.pendingScrollMessageID == 0 || cv.messagesScroll == nil {
+		return
+	}
+
+	index := -1
+	for i, msg := range cv.messages {
+		if msg.ID == cv.pendingScrollMessageID {
+			index = i
+			break
+		}
+	}
+	// Each message is rendered as one object; wait until it is in the container
+	if index < 0 || index >= len(cv.messagesContainer.Objects) {
+		return
+	}
+
+	cv.pendingScrollMessageID = 0
+	cv.messagesContainer.ScrollToRow(index)
+}
+
+// messagesRendered is called on the UI thread after loadMessages rendered the
+// messages
+func (cv *ChatView) messagesRendered() {
+	if cv.searchBar != nil {
+		cv.searchBar.Reapply()
+	}
+	cv.applyPendingScroll()
+	cv.scheduleTokenCount()
+}
+
+// markLoaded signals that the messages have been shown
+func (cv *ChatView) markLoaded() {
+	cv.doneOnce.Do(func() {
+		close(cv.done)
+	})
+}
+
+// loadingMutexFor returns the mutex that guards loading a conversation's messages
+func (a *App) loadingMutexFor(conversationID int64) *sync.Mutex {
+	mu, _ := a.loadingMutex.LoadOrStore(conversationID, &sync.Mutex{})
+	return mu.(*sync.Mutex)
 }
 
 // loadMessages loads messages for the current conversation
@@ -458,8 +841,24 @@ func (cv *ChatView) loadMessages() {
 		return
 	}
 
+	// Rapid tab switches can start a second load of the same conversation
+	// while the first is still running; it would add the messages again
+	mu := cv.app.loadingMutexFor(cv.conversationID)
+	if !mu.TryLock() {
+		cv.app.logger.Warn("Messages of conversation %d are already loading, skipping", cv.conversationID)
+		return
+	}
+	// The lock is handed to the background work when there is some
+	async := false
+	defer func() {
+		if !async {
+			mu.Unlock()
+		}
+	}()
+
 	// Check if UI is already cached (fastest path)
 	if cachedUI, cached := cv.app.uiCache[cv.conversationID]; cached {
+		cv.app.cacheHits.Add(1)
 		cv.app.updateCacheAccess(cv.conversationID) // Update LRU
 		cv.app.logger.Info("Using cached UI for conversation %d (%d objects)", cv.conversationID, len(cachedUI))
 
@@ -471,19 +870,24 @@ func (cv *ChatView) loadMessages() {
 			cv.messagesContainer.Refresh()
 
 			// Load rest progressively in background
+			async = true
 			utils.SafeGo(cv.app.logger, "progressive-render", func() {
+				defer mu.Unlock()
 				// Small delay to let UI settle
 				time.Sleep(10 * time.Millisecond)
 
 				fyne.Do(func() {
 					cv.messagesContainer.Objects = cachedUI
 					cv.messagesContainer.Refresh()
+					cv.messagesRendered()
+					cv.markLoaded()
 				})
 			})
 		} else {
 			// Small conversation, load all at once
 			cv.messagesContainer.Objects = cachedUI
 			cv.messagesContainer.Refresh()
+			cv.markLoaded()
 		}
 
 		// Update messages field from cached data
@@ -495,11 +899,13 @@ func (cv *ChatView) loadMessages() {
 			// Synchronize showAnonymized map with message indices
 			cv.syncShowAnonymizedMap()
 		}
+		cv.messagesRendered()
 		return
 	}
 
 	// Check if messages are already cached (fast path)
 	if cachedMessages, cached := cv.app.messageCache[cv.conversationID]; cached {
+		cv.app.cacheHits.Add(1)
 		cv.app.updateCacheAccess(cv.conversationID) // Update LRU
 		cv.app.logger.Info("Using cached messages for conversation %d", cv.conversationID)
 
@@ -512,10 +918,14 @@ func (cv *ChatView) loadMessages() {
 		cv.syncShowAnonymizedMap()
 
 		// Build UI from cached messages in background
+		async = true
 		utils.SafeGo(cv.app.logger, "loadMessages-cached", func() {
+			defer mu.Unlock()
+			cv.resetSelectableTextCache()
+			details := cv.loadConversationDetails()
 			uiObjects := make([]fyne.CanvasObject, 0, len(cachedMessages)*4)
 			for i, msg := range cachedMessages {
-				messageBox := cv.buildMessageUI(msg, i)
+				messageBox := cv.buildMessageUIWith(msg, i, details[msg.ID])
 				uiObjects = append(uiObjects, messageBox)
 			}
 
@@ -525,11 +935,15 @@ func (cv *ChatView) loadMessages() {
 			fyne.Do(func() {
 				cv.messagesContainer.Objects = uiObjects
 				cv.messagesContainer.Refresh()
+				cv.messagesRendered()
+				cv.markLoaded()
 			})
 		})
 		return
 	}
 
+	cv.app.cacheMisses.Add(1)
+
 	// Keep current UI visible while loading new messages
 	// Only show loading indicator if there are no current messages
 	if len(cv.messagesContainer.Objects) == 0 {
@@ -542,8 +956,15 @@ func (cv *ChatView) loadMessages() {
 	}
 
 	// Load messages asynchronously to avoid blocking UI
+	async = true
 	utils.SafeGo(cv.app.logger, "loadMessages", func() {
-		messages, err := cv.app.db.ListMessages(cv.conversationID)
+		defer mu.Unlock()
+		messages, err := cv.app.db.ListMessagesCtx(cv.ctx, cv.conversationID)
+		if cv.ctx.Err() != nil {
+			// Tab was closed while loading; don't touch its UI
+			cv.app.logger.Debug("Message loading cancelled for conversation %d", cv.conversationID)
+			return
+		}
 		if err != nil {
 			cv.app.logger.Error("Failed to load messages: %v", err)
 			fyne.Do(func() {
@@ -551,6 +972,7 @@ func (cv *ChatView) loadMessages() {
 					widget.NewLabel("❌ 加载失败: " + err.Error()),
 				}
 				cv.messagesContainer.Refresh()
+				cv.markLoaded()
 			})
 			return
 		}
@@ -569,12 +991,18 @@ func (cv *ChatView) loadMessages() {
 		cv.syncShowAnonymizedMap()
 
 		// Build all UI objects in background
+		cv.resetSelectableTextCache()
+		details := cv.loadConversationDetails()
 		uiObjects := make([]fyne.CanvasObject, 0, len(messages)*4) // Pre-allocate capacity
 		for i, msg := range messages {
-			messageBox := cv.buildMessageUI(msg, i)
+			messageBox := cv.buildMessageUIWith(msg, i, details[msg.ID])
 			uiObjects = append(uiObjects, messageBox)
 		}
 
+		if cv.ctx.Err() != nil {
+			return
+		}
+
 		// Cache the UI objects for future use
 		cv.app.uiCache[cv.conversationID] = uiObjects
 
@@ -582,6 +1010,8 @@ func (cv *ChatView) loadMessages() {
 		fyne.Do(func() {
 			cv.messagesContainer.Objects = uiObjects
 			cv.messagesContainer.Refresh()
+			cv.messagesRendered()
+			cv.markLoaded()
 		})
 	})
 }
@@ -589,13 +1019,26 @@ func (cv *ChatView) loadMessages() {
 // sendMessage handles the user's request to send a message.
 // It checks for anonymization and shows a confirmation dialog if needed.
 func (cv *ChatView) sendMessage() {
+	// Ctrl+Enter reaches here while the send button is disabled
+	if cv.sendButton.Disabled() {
+		return
+	}
+	cv.clearFollowUpSuggestions()
+
 	content := strings.TrimSpace(cv.inputEntry.Text)
 	attachments := cv.fileUploadArea.GetAttachments()
+	providerOverride := cv.nextProviderOverride()
 
 	if content == "" && len(attachments) == 0 {
 		return
 	}
 
+	// "/imagine <prompt>" generates an image instead
+	if prompt, ok := imaginePrompt(content); ok {
+		cv.generateImage(prompt)
+		return
+	}
+
 	// Combine user message with text file contents
 	fullContent := content
 	if len(attachments) > 0 {
@@ -617,19 +1060,19 @@ func (cv *ChatView) sendMessage() {
 
 		// If content is unchanged, proceed without confirmation
 		if anonymizedContent == fullContent {
-			cv.proceedWithMessage(fullContent, attachments)
+			cv.proceedWithMessage(fullContent, attachments, providerOverride)
 			return
 		}
 
-		cv.showAnonymizationConfirmation(fullContent, anonymizedContent, attachments)
+		cv.showAnonymizationConfirmation(fullContent, anonymizedContent, attachments, providerOverride)
 	} else {
 		// Otherwise, send the original message directly
-		cv.proceedWithMessage(fullContent, attachments)
+		cv.proceedWithMessage(fullContent, attachments, providerOverride)
 	}
 }
 
 // showAnonymizationConfirmation shows a dialog for the user to confirm the anonymized message
-func (cv *ChatView) showAnonymizationConfirmation(originalContent, anonymizedContent string, attachments []*llm.Attachment) {
+func (cv *ChatView) showAnonymizationConfirmation(originalContent, anonymizedContent string, attachments []*llm.Attachment, providerOverride string) {
 	originalLabel := widget.NewLabel(originalContent)
 	originalLabel.Wrapping = fyne.TextWrapWord
 
@@ -646,7 +1089,7 @@ func (cv *ChatView) showAnonymizationConfirmation(originalContent, anonymizedCon
 	})
 
 	confirmButton := widget.NewButton("确认发送", func() {
-		cv.proceedWithMessage(anonymizedContent, attachments)
+		cv.proceedWithMessage(anonymizedContent, attachments, providerOverride)
 		if popup != nil {
 			popup.Hide()
 		}
@@ -688,8 +1131,10 @@ func (cv *ChatView) showAnonymizationConfirmation(originalContent, anonymizedCon
 
 // proceedWithMessage contains the core logic to save and send a message
 // content parameter can be either original content (when anonymization is disabled)
-// or anonymized content (when anonymization is enabled)
-func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attachment) {
+// or anonymized content (when anonymization is enabled).
+// providerOverride names the provider to answer this message instead of the
+// selected one, "" for the selected provider and model.
+func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attachment, providerOverride string) {
 
 	if content == "" && len(attachments) == 0 {
 		return
@@ -776,18 +1221,26 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 		// Update cache immediately after user message
 		cv.updateCacheAfterNewMessage(*message)
 	}
+	undoAction := cv.beginUndoAction(message.ID, cv.inputEntry.Text)
 	cv.inputEntry.SetText("")
+	cv.resetNextProvider()
 
 	// Clear attachments after sending
 	cv.fileUploadArea.Clear()
 
-	// Get provider
-	provider, ok := cv.app.providers[cv.currentProvider]
+	// Get provider, switched to the model selected for this conversation,
+	// or the provider picked for this message only with its default model
+	providerName := cv.currentProvider
+	if providerOverride != "" {
+		providerName = providerOverride
+	}
+	provider, model, ok := cv.providerForMessage(providerName)
 	if !ok {
-		cv.app.logger.Error("Provider not found: %s", cv.currentProvider)
+		cv.app.logger.Error("Provider not found: %s", providerName)
 		cv.addMessageToUI("assistant", "错误: 提供商未配置", "", -1)
 		return
 	}
+	provider = cv.withJSONMode(cv.withConversationParams(provider))
 
 	// Prepare messages for LLM
 	dbMessages, err := cv.app.db.ListMessages(cv.conversationID)
@@ -798,37 +1251,17 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 
 	llmMessages := []llm.Message{}
 	for _, msg := range dbMessages {
-		// Parse attachments from JSON if present
-		var allAttachments []llm.Attachment
-		if msg.Attachments != "" {
-			if err := json.Unmarshal([]byte(msg.Attachments), &allAttachments); err != nil {
-				cv.app.logger.Warn("Failed to parse attachments for message %d: %v", msg.ID, err)
-			}
-		}
-
-		// Only include image attachments for LLM (text files are already in content)
-		var imageAttachments []llm.Attachment
-		for _, att := range allAttachments {
-			if att.Type == "image" {
-				imageAttachments = append(imageAttachments, att)
-			}
-		}
-
-		// Anonymization is now handled before calling proceedWithMessage
-		// We send the already-anonymized content to the LLM
-		anonymizedContent := msg.Content
-
-		llmMessages = append(llmMessages, llm.Message{
-			Role:        msg.Role,
-			Content:     anonymizedContent,
-			Attachments: imageAttachments,
-		})
+		// Anonymization is handled before calling proceedWithMessage, the
+		// content is already anonymized
+		llmMessages = append(llmMessages, cv.llmMessage(msg))
 	}
+	llmMessages = cv.withConversationSystemPrompt(llmMessages)
+	systemPrompt := providerSystemPrompt(cv.app.config.LLMProviders[providerName], llmMessages)
 
 	// Create placeholder for assistant response with RichText
 	assistantRichText := widget.NewRichText()
 	assistantRichText.Wrapping = fyne.TextWrapBreak
-	assistantRoleLabel := widget.NewLabel("🤖 助手 (" + cv.currentProvider + ")")
+	assistantRoleLabel := widget.NewLabel("🤖 助手 (" + providerName + ")")
 	assistantRoleLabel.TextStyle = fyne.TextStyle{Bold: true}
 
 	// Add initial "thinking" message
@@ -845,9 +1278,15 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 
 	// Send to LLM (streaming with retry) - wrapped with panic recovery
 	utils.SafeGo(cv.app.logger, "sendMessage LLM streaming", func() {
-		ctx := context.Background()
-		// Use retry mechanism with max 3 attempts
-		stream, err := cv.streamChatWithRetry(ctx, provider, llmMessages, 2)
+		ctx := cv.startStreaming()
+		defer cv.setStreaming(false)
+
+		// Retry network errors with the backoff of the provider
+		stream, err := cv.streamChatWithRetry(ctx, provider, providerName, systemPrompt, llmMessages)
+		if err != nil && ctx.Err() != nil {
+			cv.addUndoMessage(undoAction, cv.saveCancelledResponse("", providerName, model))
+			return
+		}
 		if err != nil {
 			cv.app.logger.Error("Failed to start chat: %v", err)
 			errorMsg := "**错误**: " + err.Error()
@@ -861,8 +1300,8 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 				cv.conversationID,
 				"assistant",
 				errorMsg,
-				cv.currentProvider,
-				cv.currentProvider,
+				providerName,
+				model,
 				"",
 				0,
 			)
@@ -871,6 +1310,7 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 			} else {
 				// Add the error message to cv.messages array
 				cv.addMessageToMessagesArray(*errorMsgObj)
+				cv.addUndoMessage(undoAction, errorMsgObj)
 			}
 			// Clear anonymization mappings
 			cv.app.anonymizer.Clear()
@@ -881,6 +1321,12 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 
 		var fullResponse strings.Builder
 		for chunk := range stream {
+			// Hold the chunk while paused so the displayed text stays stable
+			cv.waitIfPaused()
+			if ctx.Err() != nil {
+				break // Stopped, the rest of the stream is dropped
+			}
+
 			if chunk.Error != nil {
 				cv.app.logger.Error("Stream error: %v", chunk.Error)
 				errorMsg := "**错误**: " + chunk.Error.Error()
@@ -894,8 +1340,8 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 					cv.conversationID,
 					"assistant",
 					errorMsg,
-					cv.currentProvider,
-					cv.currentProvider,
+					providerName,
+					model,
 					"",
 					0,
 				)
@@ -904,12 +1350,13 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 				} else {
 					// Add the error message to cv.messages array
 					cv.addMessageToMessagesArray(*errorMsgObj)
+					cv.addUndoMessage(undoAction, errorMsgObj)
 				}
 				// Clear anonymization mappings
 				cv.app.anonymizer.Clear()
 				// Reload to show retry button
 				cv.loadMessages()
-				break
+				return
 			}
 
 			if chunk.Content != "" {
@@ -926,22 +1373,31 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 			if chunk.Done {
 				// Deanonymize the final response before saving
 				finalResponse := cv.app.anonymizer.Deanonymize(fullResponse.String())
+				tokensUsed := 0
+				if chunk.Usage != nil {
+					tokensUsed = chunk.Usage.TotalTokens
+				}
 
 				// Save assistant message (with original sensitive data restored)
+				// and the token usage reported by the provider
 				assistantMsg, err := cv.app.db.CreateMessage(
 					cv.conversationID,
 					"assistant",
 					finalResponse,
-					cv.currentProvider,
-					cv.currentProvider,
+					providerName,
+					model,
 					"",
-					0,
+					tokensUsed,
 				)
+				if err == nil {
+					cv.saveResponseMetadata(assistantMsg, chunk.Metadata)
+				}
 				if err != nil {
 					cv.app.logger.Error("Failed to save assistant message: %v", err)
 				} else {
 					// Add the assistant message to cv.messages array
 					cv.addMessageToMessagesArray(*assistantMsg)
+					cv.addUndoMessage(undoAction, assistantMsg)
 
 					// Update cache immediately with the new message
 					cv.updateCacheAfterNewMessage(*assistantMsg)
@@ -974,15 +1430,38 @@ func (cv *ChatView) proceedWithMessage(content string, attachments []*llm.Attach
 				// Auto-generate title if this is the first exchange
 				utils.SafeGo(cv.app.logger, "autoGenerateTitle", cv.autoGenerateTitle)
 
+				// Suggest follow-up questions (no-op unless enabled in settings)
+				response := fullResponse.String()
+				cv.startFollowUpSuggestions(provider, llmMessages, response)
+
+				// Compress older history (no-op unless auto-summarize is enabled)
+				utils.SafeGo(cv.app.logger, "autoSummarize", func() {
+					cv.summarizeIfNeeded(provider)
+				})
+
 				// 【删除这一行】：不要重新加载消息
 				// cv.loadMessages()
 
-				break
+				return
 			}
 		}
+
+		// The stream ended without finishing because it was stopped
+		if ctx.Err() != nil {
+			// The provider may still be sending, let it wind down
+			go func() {
+				for range stream {
+				}
+			}()
+			cv.addUndoMessage(undoAction, cv.saveCancelledResponse(fullResponse.String(), providerName, model))
+		}
 	})
 }
 
+// titleGenerationTimeout limits title generation for providers without a
+// configured timeout
+const titleGenerationTimeout = 30 * time.Second
+
 // autoGenerateTitle automatically generates a title for the conversation
 func (cv *ChatView) autoGenerateTitle() {
 	if cv.conversationID == 0 {
@@ -1014,7 +1493,7 @@ func (cv *ChatView) autoGenerateTitle() {
 	}
 
 	// Get provider
-	provider, ok := cv.app.providers[cv.currentProvider]
+	provider, ok := cv.selectedProvider()
 	if !ok {
 		cv.app.logger.Warn("Provider not found for title generation: %s", cv.currentProvider)
 		return
@@ -1030,7 +1509,8 @@ func (cv *ChatView) autoGenerateTitle() {
 	}
 
 	// Generate title with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	timeout := cv.app.config.LLMProviders[cv.currentProvider].RequestTimeout(titleGenerationTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	title, err := provider.GenerateTitle(ctx, llmMessages)
 	if err != nil {
@@ -1059,17 +1539,72 @@ func (cv *ChatView) autoGenerateTitle() {
 	})
 }
 
-// buildMessageUI builds a message UI component without adding it to the container
-// This is used for batch loading messages
+// messageDetails holds what is shown with a message but stored apart from it
+type messageDetails struct {
+	tags     []string
+	versions []*db.MessageVersion // Previous contents, newest first
+}
+
+// loadMessageDetails loads the details of one message
+func (cv *ChatView) loadMessageDetails(messageID int64) messageDetails {
+	var details messageDetails
+	if messageID == 0 {
+		return details
+	}
+	tags, err := cv.app.db.ListMessageTags(messageID)
+	if err != nil {
+		cv.app.logger.Error("Failed to load message tags: %v", err)
+	}
+	details.tags = tags
+	versions, err := cv.app.db.ListMessageVersions(messageID)
+	if err != nil {
+		cv.app.logger.Error("Failed to load message versions: %v", err)
+	}
+	details.versions = versions
+	return details
+}
+
+// loadConversationDetails loads the details of every message in the
+// conversation with one query each, for building all of its messages
+func (cv *ChatView) loadConversationDetails() map[int64]messageDetails {
+	details := make(map[int64]messageDetails)
+	tags, err := cv.app.db.ListConversationTags(cv.conversationID)
+	if err != nil {
+		cv.app.logger.Error("Failed to load message tags: %v", err)
+	}
+	for messageID, messageTags := range tags {
+		d := details[messageID]
+		d.tags = messageTags
+		details[messageID] = d
+	}
+	versions, err := cv.app.db.ListConversationVersions(cv.conversationID)
+	if err != nil {
+		cv.app.logger.Error("Failed to load message versions: %v", err)
+	}
+	for messageID, messageVersions := range versions {
+		d := details[messageID]
+		d.versions = messageVersions
+		details[messageID] = d
+	}
+	return details
+}
+
+// buildMessageUI builds a message UI component without adding it to the
+// container, loading the message's details
 func (cv *ChatView) buildMessageUI(msg *db.Message, messageIndex int) fyne.CanvasObject {
+	return cv.buildMessageUIWith(msg, messageIndex, cv.loadMessageDetails(msg.ID))
+}
+
+// buildMessageUIWith builds a message UI component from details loaded by
+// the caller. This is used for batch loading messages.
+func (cv *ChatView) buildMessageUIWith(msg *db.Message, messageIndex int, details messageDetails) fyne.CanvasObject {
 	var roleLabel string
 	if msg.Role == "user" {
 		roleLabel = "👤 用户"
+	} else if msg.Role == "system" {
+		roleLabel = "📝 对话摘要"
 	} else {
 		roleLabel = "🤖 助手"
-		if msg.Model != "" {
-			roleLabel += fmt.Sprintf(" (%s)", msg.Model)
-		}
 	}
 
 	// Create role label with bold style
@@ -1081,17 +1616,34 @@ func (cv *ChatView) buildMessageUI(msg *db.Message, messageIndex int) fyne.Canva
 
 	// Create role container that will hold the role label and any indicators
 	var roleContainer fyne.CanvasObject = roleWidget
+	indicators := []fyne.CanvasObject{roleWidget}
+
+	// Responses name the model that wrote them, which may differ per message
+	if msg.Role == "assistant" && msg.Model != "" {
+		indicators = append(indicators, container.NewCenter(newModelBadge(msg.Model)))
+	}
 
 	// Add anonymization indicator if message has been anonymized
 	if hasAnonymizedContent {
 		// Add subtle indicator that this message has been anonymized
 		anonymizedLabel := widget.NewLabel("🔒 已匿名化")
 		anonymizedLabel.TextStyle = fyne.TextStyle{Italic: true}
+		indicators = append(indicators, anonymizedLabel)
+	}
 
-		// Create a container with role label and anonymization indicator
-		roleContainer = container.NewHBox(roleWidget, anonymizedLabel)
+	if len(indicators) > 1 {
+		roleContainer = container.NewHBox(indicators...)
 	}
 
+	// Tag pills shown beneath the role header
+	tagPillsContainer := container.NewHBox()
+	tagPillsContainer.Objects = tagPills(details.tags)
+
+	// Previous versions of edited messages can be compared with the current content
+	versions := details.versions
+	compareContainer := container.NewVBox()
+	compareContainer.Hide()
+
 	// Determine which content to display based on user preference
 	displayContent := msg.Content
 	if hasAnonymizedContent {
@@ -1111,14 +1663,28 @@ func (cv *ChatView) buildMessageUI(msg *db.Message, messageIndex int) fyne.Canva
 	if msg.Role == "assistant" {
 		// Assistant messages: parse and render with code block copy buttons
 		contentWidget = cv.renderAssistantMessage(displayContent)
+		// Generated images, see image_generation.go
+		if images := cv.messageImages(msg); len(images) > 0 {
+			contentWidget = container.NewVBox(contentWidget, cv.newImageThumbnails(images))
+		}
 	} else {
 		// User messages use selectable text
-		userContentLabel = newSelectableText(displayContent)
+		userContentLabel = cv.newCachedSelectableText(displayContent)
 		contentWidget = userContentLabel
 	}
 
 	// Create action buttons
 	var actionButtons *fyne.Container
+	tagIdx := messageIndex
+	tagButton := widget.NewButton("🏷️", func() {
+		cv.showTagEditor(msg, tagIdx, tagPillsContainer)
+	})
+	tagButton.Importance = widget.LowImportance
+
+	// Starred messages are marked with a gold border, see star.go
+	starMarker := newStarMarker(msg.Starred)
+	starButton := cv.newStarButton(msg, messageIndex, starMarker)
+
 	if msg.Role == "assistant" {
 		// For assistant messages, provide copy, edit and regenerate options
 		copyTextButton := widget.NewButton("📋 复制文本", func() {
@@ -1153,12 +1719,15 @@ func (cv *ChatView) buildMessageUI(msg *db.Message, messageIndex int) fyne.Canva
 		})
 		regenerateButton.Importance = widget.LowImportance
 
-		actionButtons = container.NewHBox(copyTextButton, copyMarkdownButton, editButton, regenerateButton)
+		actionButtons = container.NewHBox(copyTextButton, copyMarkdownButton, editButton, regenerateButton, tagButton, starButton)
+		if cv.app.config.UI.TTSEnabled {
+			actionButtons.Add(cv.app.newReadAloudButton(cv.markdownToPlainText(displayContent)))
+		}
 	} else {
 		// For user messages, add copy, edit, and delete buttons
 		copyButton := widget.NewButton("📋 复制", func() {
 			if userContentLabel != nil {
-				cv.app.window.Clipboard().SetContent(userContentLabel.Text)
+				cv.app.window.Clipboard().SetContent(cv.selectableText(userContentLabel))
 			} else {
 				cv.app.window.Clipboard().SetContent(displayContent)
 			}
@@ -1183,6 +1752,36 @@ func (cv *ChatView) buildMessageUI(msg *db.Message, messageIndex int) fyne.Canva
 		deleteButton.Importance = widget.LowImportance
 
 		actionButtons = container.NewHBox(copyButton, editButton, deleteButton)
+		if idx > 0 {
+			deleteBeforeButton := widget.NewButton("🗑️ 删除之前的消息", func() {
+				cv.deleteMessagesBefore(idx)
+			})
+			deleteBeforeButton.Importance = widget.LowImportance
+			actionButtons.Add(deleteBeforeButton)
+		}
+		actionButtons.Add(tagButton)
+		actionButtons.Add(starButton)
+	}
+
+	// Add compare toggle for edited messages; the diff is built on first use
+	if len(versions) > 0 && actionButtons != nil {
+		messageID := msg.ID
+		currentContent := msg.Content
+		compareButton := widget.NewButton("📖 对比", nil)
+		compareButton.OnTapped = func() {
+			if compareContainer.Visible() {
+				compareContainer.Hide()
+				compareButton.SetText("📖 对比")
+				return
+			}
+			if len(compareContainer.Objects) == 0 {
+				compareContainer.Add(cv.buildCompareView(messageID, currentContent, versions))
+			}
+			compareContainer.Show()
+			compareButton.SetText("📖 收起对比")
+		}
+		compareButton.Importance = widget.LowImportance
+		actionButtons.Add(compareButton)
 	}
 
 	// Add anonymization toggle button if message has both original and anonymized content
@@ -1229,14 +1828,34 @@ func (cv *ChatView) buildMessageUI(msg *db.Message, messageIndex int) fyne.Canva
 		}
 	}
 
+	highlight, outline := newSearchHighlight(), newSearchOutline()
 	messageBox := container.NewVBox(
 		roleContainer,
-		container.NewPadded(contentWidget),
-		actionButtons,
-		widget.NewSeparator(),
+		tagPillsContainer,
+		container.NewStack(highlight, container.NewPadded(contentWidget)),
 	)
-
-	return messageBox
+	// Note why the response stopped, unless it simply ended
+	if stopReason := utils.MessageStopReason(msg.Metadata); msg.Role == "assistant" && stopReason != "" {
+		stopLabel := widget.NewLabel("停止原因: " + stopReason)
+		stopLabel.TextStyle = fyne.TextStyle{Italic: true}
+		stopLabel.SizeName = theme.SizeNameCaptionText
+		messageBox.Add(stopLabel)
+	}
+	messageBox.Add(compareContainer)
+	messageBox.Add(actionButtons)
+	if msg.TokensUsed > 0 {
+		tokensLabel := widget.NewLabel(fmt.Sprintf("~%d tokens", msg.TokensUsed))
+		tokensLabel.Importance = widget.LowImportance
+		tokensLabel.SizeName = theme.SizeNameCaptionText
+		messageBox.Add(tokensLabel)
+	}
+	messageBox.Add(widget.NewSeparator())
+
+	area := newMessageMenuArea(container.NewBorder(nil, nil, starMarker, nil, container.NewStack(messageBox, outline)), cv.app.window.Canvas(), func() *fyne.Menu {
+		return cv.buildMessageMenu(displayContent, messageIndex)
+	})
+	area.highlight, area.outline = highlight, outline
+	return area
 }
 
 // addMessageToMessagesArray safely adds a message to the messages array and initializes showAnonymized
@@ -1244,6 +1863,7 @@ func (cv *ChatView) addMessageToMessagesArray(msg db.Message) {
 	cv.messages = append(cv.messages, msg)
 	// Initialize showAnonymized for the new message
 	cv.showAnonymized[len(cv.messages)-1] = false
+	cv.scheduleTokenCount()
 }
 
 // syncShowAnonymizedMap synchronizes the showAnonymized map with the current messages array
@@ -1313,6 +1933,18 @@ func (cv *ChatView) addMessageToUIWithOriginal(role, content, originalContent, m
 
 // renderAssistantMessage renders assistant message with code block copy buttons, tables, and thinking sections
 func (cv *ChatView) renderAssistantMessage(content string) fyne.CanvasObject {
+	// Web search sources (Perplexity) are shown as links below the answer
+	if answer, urls := llm.SplitCitations(content); len(urls) > 0 {
+		return container.NewVBox(cv.renderAssistantMessage(answer), newCitationLinks(urls))
+	}
+
+	// Responses in JSON mode are shown indented
+	if cv.jsonMode {
+		if pretty, ok := prettyJSON(content); ok {
+			return newSelectableCodeText(pretty)
+		}
+	}
+
 	// Aggressive quick path: if no special markers, render as plain text
 	// This avoids expensive parsing for most messages
 	hasCodeBlock := strings.Contains(content, "```")
@@ -1394,6 +2026,24 @@ func (cv *ChatView) renderAssistantMessage(content string) fyne.CanvasObject {
 	return contentContainer
 }
 
+// newCitationLinks lists the sources of an answer as links that open in the browser
+func newCitationLinks(urls []string) fyne.CanvasObject {
+	heading := widget.NewLabel("Sources:")
+	heading.TextStyle = fyne.TextStyle{Bold: true}
+
+	links := container.NewVBox(heading)
+	for i, rawURL := range urls {
+		text := fmt.Sprintf("%d. %s", i+1, rawURL)
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			links.Add(widget.NewLabel(text))
+			continue
+		}
+		links.Add(widget.NewHyperlink(text, parsed))
+	}
+	return links
+}
+
 // createThinkingSection creates a collapsible thinking section
 func (cv *ChatView) createThinkingSection(thinkingContent string) fyne.CanvasObject {
 	// Create the thinking content widget (initially hidden)
@@ -1857,13 +2507,15 @@ func (cv *ChatView) regenerateMessage(messageIndex int) {
 
 	cv.app.logger.Info("Regenerating message at index %d", messageIndex)
 
-	// Get provider
-	provider, ok := cv.app.providers[cv.currentProvider]
+	// Get provider, switched to the model selected for this conversation
+	provider, ok := cv.selectedProvider()
 	if !ok {
 		cv.app.logger.Error("Provider not found: %s", cv.currentProvider)
 		cv.app.showError("Provider not configured: " + cv.currentProvider)
 		return
 	}
+	model := cv.selectedModel()
+	provider = cv.withJSONMode(cv.withConversationParams(provider))
 
 	// Prepare messages for LLM (exclude the message to regenerate and all after it)
 	llmMessages := []llm.Message{}
@@ -1875,6 +2527,8 @@ func (cv *ChatView) regenerateMessage(messageIndex int) {
 			Content: anonymizedContent,
 		})
 	}
+	llmMessages = cv.withConversationSystemPrompt(llmMessages)
+	systemPrompt := providerSystemPrompt(cv.app.config.LLMProviders[cv.currentProvider], llmMessages)
 
 	// Log anonymization stats if enabled
 	if cv.app.anonymizer.IsEnabled() {
@@ -1911,9 +2565,16 @@ func (cv *ChatView) regenerateMessage(messageIndex int) {
 
 	// Send to LLM (streaming with retry) - wrapped with panic recovery
 	utils.SafeGo(cv.app.logger, "regenerateMessage LLM streaming", func() {
-		ctx := context.Background()
-		// Use retry mechanism with max 3 attempts
-		stream, err := cv.streamChatWithRetry(ctx, provider, llmMessages, 2)
+		ctx := cv.startStreaming()
+		defer cv.setStreaming(false)
+
+		// Retry network errors with the backoff of the provider. The
+		// cached response is the one being regenerated, so skip it.
+		stream, err := cv.streamChatWithRetry(llm.SkipResponseCache(ctx), provider, cv.currentProvider, systemPrompt, llmMessages)
+		if err != nil && ctx.Err() != nil {
+			cv.saveCancelledResponse("", cv.currentProvider, model)
+			return
+		}
 		if err != nil {
 			cv.app.logger.Error("Failed to start chat: %v", err)
 			errorMsg := "**错误**: " + err.Error()
@@ -1928,7 +2589,7 @@ func (cv *ChatView) regenerateMessage(messageIndex int) {
 				"assistant",
 				errorMsg,
 				cv.currentProvider,
-				cv.currentProvider,
+				model,
 				"",
 				0,
 			)
@@ -1944,6 +2605,12 @@ func (cv *ChatView) regenerateMessage(messageIndex int) {
 
 		var fullResponse strings.Builder
 		for chunk := range stream {
+			// Hold the chunk while paused so the displayed text stays stable
+			cv.waitIfPaused()
+			if ctx.Err() != nil {
+				break // Stopped, the rest of the stream is dropped
+			}
+
 			if chunk.Error != nil {
 				cv.app.logger.Error("Stream error: %v", chunk.Error)
 				errorMsg := "**错误**: " + chunk.Error.Error()
@@ -1958,7 +2625,7 @@ func (cv *ChatView) regenerateMessage(messageIndex int) {
 					"assistant",
 					errorMsg,
 					cv.currentProvider,
-					cv.currentProvider,
+					model,
 					"",
 					0,
 				)
@@ -1969,7 +2636,7 @@ func (cv *ChatView) regenerateMessage(messageIndex int) {
 				cv.app.anonymizer.Clear()
 				// Reload to show retry button
 				cv.loadMessages()
-				break
+				return
 			}
 
 			if chunk.Content != "" {
@@ -1984,17 +2651,25 @@ func (cv *ChatView) regenerateMessage(messageIndex int) {
 			if chunk.Done {
 				// Deanonymize the final response before saving
 				finalResponse := cv.app.anonymizer.Deanonymize(fullResponse.String())
+				tokensUsed := 0
+				if chunk.Usage != nil {
+					tokensUsed = chunk.Usage.TotalTokens
+				}
 
 				// Save new assistant message (with original sensitive data restored)
+				// and the token usage reported by the provider
 				assistantMsg, err := cv.app.db.CreateMessage(
 					cv.conversationID,
 					"assistant",
 					finalResponse,
 					cv.currentProvider,
-					cv.currentProvider,
+					model,
 					"",
-					0,
+					tokensUsed,
 				)
+				if err == nil {
+					cv.saveResponseMetadata(assistantMsg, chunk.Metadata)
+				}
 				if err != nil {
 					cv.app.logger.Error("Failed to save assistant message: %v", err)
 				} else {
@@ -2035,12 +2710,26 @@ func (cv *ChatView) regenerateMessage(messageIndex int) {
 				// Auto-generate title if needed
 				utils.SafeGo(cv.app.logger, "autoGenerateTitle", cv.autoGenerateTitle)
 
+				// Suggest follow-up questions (no-op unless enabled in settings)
+				response := fullResponse.String()
+				cv.startFollowUpSuggestions(provider, llmMessages, response)
+
 				// 【删除这一行】：不要重新加载消息
 				// cv.loadMessages()
 
-				break
+				return
 			}
 		}
+
+		// The stream ended without finishing because it was stopped
+		if ctx.Err() != nil {
+			// The provider may still be sending, let it wind down
+			go func() {
+				for range stream {
+				}
+			}()
+			cv.saveCancelledResponse(fullResponse.String(), cv.currentProvider, model)
+		}
 	})
 }
 
@@ -2074,9 +2763,123 @@ func (cv *ChatView) RefreshProviderList() {
 	}
 
 	cv.providerSelect.Refresh()
+	cv.refreshModelOptions()
+	cv.refreshModels()
+	cv.refreshNextProviderOptions()
 	cv.app.logger.Info("Provider list refreshed, %d providers available", len(providerOptions))
 }
 
+// refreshModels fetches the models of the current provider in the background
+// if it lists them from its server, like Ollama, and shows them in the model
+// dropdown
+func (cv *ChatView) refreshModels() {
+	name := cv.currentProvider
+	provider, ok := cv.app.providers[name]
+	if !ok {
+		return
+	}
+	refresher, ok := llm.AsModelRefresher(provider)
+	if !ok {
+		return
+	}
+	utils.SafeGo(cv.app.logger, "refreshModels", func() {
+		models, err := refresher.RefreshModels(cv.ctx)
+		if err != nil {
+			cv.app.logger.Warn("Failed to fetch the models of %s: %v", name, err)
+			return
+		}
+		cv.app.logger.Info("Fetched %d models of %s", len(models), name)
+		fyne.Do(func() {
+			if cv.currentProvider == name {
+				cv.refreshModelOptions()
+			}
+		})
+	})
+}
+
+// refreshModelOptions fills the model dropdown with the models of the current
+// provider. The selected model is kept while the provider still offers it.
+func (cv *ChatView) refreshModelOptions() {
+	if cv.modelSelect == nil {
+		return
+	}
+
+	provider, ok := cv.app.providers[cv.currentProvider]
+	if !ok {
+		cv.currentModel = ""
+		cv.modelSelect.Options = nil
+		cv.modelSelect.ClearSelected()
+		return
+	}
+
+	models := provider.Models()
+	defaultModel := cv.app.config.LLMProviders[cv.currentProvider].DefaultModel
+	if defaultModel != "" && !slices.Contains(models, defaultModel) {
+		models = append([]string{defaultModel}, models...)
+	}
+	cv.modelSelect.Options = models
+
+	switch {
+	case cv.currentModel != "" && slices.Contains(models, cv.currentModel):
+		cv.modelSelect.SetSelected(cv.currentModel)
+	case defaultModel != "":
+		cv.modelSelect.SetSelected(defaultModel)
+	default:
+		// Unknown default: let the provider pick its own model
+		cv.modelSelect.ClearSelected()
+	}
+	cv.modelSelect.Refresh()
+}
+
+// selectedProvider returns the current provider switched to the selected model
+func (cv *ChatView) selectedProvider() (llm.Provider, bool) {
+	provider, ok := cv.app.providers[cv.currentProvider]
+	if !ok {
+		return nil, false
+	}
+	if cv.currentModel != "" {
+		provider = provider.WithModel(cv.currentModel)
+	}
+	return provider, true
+}
+
+// selectedModel returns the model name saved with responses
+func (cv *ChatView) selectedModel() string {
+	if cv.currentModel != "" {
+		return cv.currentModel
+	}
+	if defaultModel := cv.app.config.LLMProviders[cv.currentProvider].DefaultModel; defaultModel != "" {
+		return defaultModel
+	}
+	return cv.currentProvider
+}
+
+// saveResponseMetadata stores the provider metadata of a response with its
+// message
+func (cv *ChatView) saveResponseMetadata(msg *db.Message, metadata map[string]interface{}) {
+	encoded := utils.EncodeMessageMetadata(metadata)
+	if encoded == "" {
+		return
+	}
+	if err := cv.app.db.UpdateMessageMetadata(msg.ID, encoded); err != nil {
+		cv.app.logger.Error("Failed to save response metadata: %v", err)
+		return
+	}
+	msg.Metadata = encoded
+}
+
+// providerSystemPrompt returns the provider's default system prompt unless
+// the conversation has its own system message. The prompt is only sent with
+// StreamChatWithSystemPrompt, never saved with the conversation.
+func providerSystemPrompt(config utils.ProviderConfig, messages []llm.Message) string {
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			return ""
+		}
+	}
+	return config.SystemPrompt
+}
+
 // providerExists checks if a provider exists in the app
 func (cv *ChatView) providerExists(name string) bool {
 	_, exists := cv.app.providers[name]
@@ -2167,6 +2970,45 @@ func (cv *ChatView) editMessage(messageIndex int, currentContent string) {
 	dialog.Show()
 }
 
+// deleteMessagesBefore deletes all messages before a user message, e.g. to
+// drop noise at the start of a conversation from the context
+func (cv *ChatView) deleteMessagesBefore(messageIndex int) {
+	if cv.conversationID == 0 || messageIndex <= 0 {
+		return
+	}
+
+	var dialog *widget.PopUp
+	dialog = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel("确认删除"),
+			widget.NewLabel(fmt.Sprintf("确定要删除此消息之前的 %d 条消息吗？", messageIndex)),
+			widget.NewLabel("此操作不可撤销！"),
+			container.NewHBox(
+				widget.NewButton("取消", func() {
+					dialog.Hide()
+				}),
+				widget.NewButton("删除", func() {
+					deleted, err := cv.app.db.DeleteMessagesBefore(cv.conversationID, messageIndex)
+					if err != nil {
+						cv.app.logger.Error("Failed to delete messages: %v", err)
+						cv.app.showError("删除失败: " + err.Error())
+						return
+					}
+					cv.app.logger.Info("Deleted %d messages before index %d", deleted, messageIndex)
+
+					// The indexes of the remaining messages shift
+					cv.showAnonymized = make(map[int]bool)
+					cv.app.reloadConversation(cv.conversationID)
+
+					dialog.Hide()
+				}),
+			),
+		),
+		cv.app.window.Canvas(),
+	)
+	dialog.Show()
+}
+
 // deleteMessage deletes a message and all subsequent messages
 func (cv *ChatView) deleteMessage(messageIndex int) {
 	if cv.conversationID == 0 {
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"light-llm-client/db"
+	"light-llm-client/utils"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Background colors of changed lines in the compare view
+var (
+	diffDeletedColor  = color.NRGBA{R: 255, G: 120, B: 120, A: 70}
+	diffInsertedColor = color.NRGBA{R: 120, G: 220, B: 120, A: 70}
+)
+
+// diffRow is one row of the side-by-side diff; a nil side is left blank
+type diffRow struct {
+	left, right *utils.DiffLine
+}
+
+// sideBySideRows pairs up the lines of a diff. Deletions and insertions of the
+// same change are shown next to each other.
+func sideBySideRows(lines []utils.DiffLine) []diffRow {
+	var rows []diffRow
+	for i := 0; i < len(lines); {
+		if lines[i].Op == utils.DiffEqual {
+			rows = append(rows, diffRow{left: &lines[i], right: &lines[i]})
+			i++
+			continue
+		}
+
+		var deleted, inserted []*utils.DiffLine
+		for ; i < len(lines) && lines[i].Op == utils.DiffDelete; i++ {
+			deleted = append(deleted, &lines[i])
+		}
+		for ; i < len(lines) && lines[i].Op == utils.DiffInsert; i++ {
+			inserted = append(inserted, &lines[i])
+		}
+		for j := 0; j < len(deleted) || j < len(inserted); j++ {
+			var row diffRow
+			if j < len(deleted) {
+				row.left = deleted[j]
+			}
+			if j < len(inserted) {
+				row.right = inserted[j]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// newDiffCell renders one side of a diff row, highlighting changed lines
+func newDiffCell(line *utils.DiffLine) fyne.CanvasObject {
+	background := canvas.NewRectangle(color.Transparent)
+	label := newSelectableText("")
+	if line != nil {
+		label.SetText(line.Text)
+		switch line.Op {
+		case utils.DiffDelete:
+			background.FillColor = diffDeletedColor
+		case utils.DiffInsert:
+			background.FillColor = diffInsertedColor
+		}
+	}
+	return container.NewStack(background, label)
+}
+
+// buildCompareView shows a previous version of a message next to its current
+// content. versions are newest first.
+func (cv *ChatView) buildCompareView(messageID int64, currentContent string, versions []*db.MessageVersion) fyne.CanvasObject {
+	diffBox := container.NewVBox()
+	selected := versions[0]
+
+	renderDiff := func() {
+		rows := sideBySideRows(utils.DiffText(selected.Content, currentContent))
+		objects := make([]fyne.CanvasObject, 0, len(rows))
+		for _, row := range rows {
+			objects = append(objects, container.NewGridWithColumns(2, newDiffCell(row.left), newDiffCell(row.right)))
+		}
+		diffBox.Objects = objects
+		diffBox.Refresh()
+	}
+	renderDiff()
+
+	restoreButton := widget.NewButton("↩️ 恢复此版本", func() {
+		cv.confirmRestoreVersion(messageID, selected)
+	})
+	restoreButton.Importance = widget.LowImportance
+
+	// Older versions can be picked when the message was edited several times
+	var versionPicker fyne.CanvasObject = widget.NewLabel(versionLabel(selected))
+	if len(versions) > 1 {
+		labels := make([]string, len(versions))
+		for i, version := range versions {
+			labels[i] = versionLabel(version)
+		}
+		versionSelect := widget.NewSelect(labels, nil)
+		versionSelect.SetSelectedIndex(0)
+		versionSelect.OnChanged = func(string) {
+			selected = versions[versionSelect.SelectedIndex()]
+			renderDiff()
+		}
+		versionPicker = versionSelect
+	}
+
+	currentLabel := widget.NewLabel("当前内容")
+	currentLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	header := container.NewGridWithColumns(2,
+		container.NewBorder(nil, nil, nil, restoreButton, versionPicker),
+		currentLabel,
+	)
+
+	return container.NewVBox(widget.NewSeparator(), header, diffBox, widget.NewSeparator())
+}
+
+// versionLabel describes a previous version by when it was replaced
+func versionLabel(version *db.MessageVersion) string {
+	return fmt.Sprintf("旧版本 (修改于 %s)", version.CreatedAt.Local().Format("2006-01-02 15:04"))
+}
+
+// confirmRestoreVersion asks before replacing a message with a previous version.
+// The replaced content is archived, so restoring can itself be undone.
+func (cv *ChatView) confirmRestoreVersion(messageID int64, version *db.MessageVersion) {
+	var dialog *widget.PopUp
+	dialog = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel("恢复此版本"),
+			widget.NewLabel("确定要将消息恢复为此版本吗？当前内容会保留在历史版本中。"),
+			container.NewHBox(
+				widget.NewButton("取消", func() {
+					dialog.Hide()
+				}),
+				widget.NewButton("恢复", func() {
+					if err := cv.app.db.UpdateMessage(messageID, version.Content); err != nil {
+						cv.app.logger.Error("Failed to restore message version: %v", err)
+						cv.app.showError("恢复失败: " + err.Error())
+						return
+					}
+
+					cv.app.logger.Info("Restored message %d to version %d", messageID, version.ID)
+					dialog.Hide()
+					cv.app.reloadConversation(cv.conversationID)
+				}),
+			),
+		),
+		cv.app.window.Canvas(),
+	)
+	dialog.Show()
+}
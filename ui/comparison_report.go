@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"path/filepath"
+)
+
+// ModelComparisonReport exports the conversation of a fork view as an HTML
+// page with the responses of the models side by side
+type ModelComparisonReport struct {
+	app             *App
+	conversationID  int64
+	columnProviders []string
+}
+
+// NewModelComparisonReport creates a report of the fork view's conversation
+// with the providers of its current columns
+func NewModelComparisonReport(fv *ForkChatView) *ModelComparisonReport {
+	r := &ModelComparisonReport{app: fv.app, conversationID: fv.conversationID}
+	for _, providerSelect := range fv.providerSelects {
+		if providerSelect.Selected != "" && providerSelect.Selected != noProviderOption {
+			r.columnProviders = append(r.columnProviders, providerSelect.Selected)
+		}
+	}
+	return r
+}
+
+// Export writes the report to dir and returns its path
+func (r *ModelComparisonReport) Export(dir string) (string, error) {
+	if r.conversationID == 0 {
+		return "", fmt.Errorf("no messages to compare")
+	}
+	path := filepath.Join(dir, utils.GenerateExportFilename("fork_comparison", utils.FormatHTML))
+	if err := utils.ExportForkComparisonHTML(r.app.db, r.conversationID, r.columnProviders, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
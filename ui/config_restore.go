@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// OfferConfigRestore asks whether the backup the app was started from should
+// replace the config file that could not be parsed. Nothing is shown for a
+// nil error.
+func (a *App) OfferConfigRestore(parseErr *utils.ConfigParseError) {
+	if parseErr == nil {
+		return
+	}
+	utils.SafeGo(a.logger, "configRestore", func() {
+		fyne.Do(func() {
+			a.showConfigRestore(parseErr)
+		})
+	})
+}
+
+// showConfigRestore shows the restore prompt
+func (a *App) showConfigRestore(parseErr *utils.ConfigParseError) {
+	message := fmt.Sprintf("配置文件无法解析，已使用上次保存的备份启动。\n\n%s\n\n"+
+		"是否用备份覆盖损坏的配置文件？损坏的文件会另存为 .corrupt。", parseErr.Path)
+
+	messageLabel := widget.NewLabel(message)
+	messageLabel.Wrapping = fyne.TextWrapWord
+
+	var dialog *widget.PopUp
+	restoreButton := widget.NewButton("恢复备份", func() {
+		dialog.Hide()
+		corruptPath, err := utils.RestoreConfigBackup(parseErr.Path)
+		if err != nil {
+			a.logger.Error("Failed to restore config backup: %v", err)
+			a.showError("恢复配置失败: " + err.Error())
+			return
+		}
+		a.logger.Info("Config restored from backup, corrupt file kept at %s", corruptPath)
+		a.showSuccess("配置已从备份恢复")
+	})
+	restoreButton.Importance = widget.HighImportance
+	keepButton := widget.NewButton("暂不恢复", func() {
+		dialog.Hide()
+	})
+
+	content := container.NewVBox(
+		widget.NewLabel("⚠️ 配置文件损坏"),
+		messageLabel,
+		container.NewHBox(keepButton, restoreButton),
+	)
+	dialog = widget.NewModalPopUp(content, a.window.Canvas())
+	dialog.Resize(fyne.NewSize(500, content.MinSize().Height))
+	dialog.Show()
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"light-llm-client/utils"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// buildCrashReportSection builds the collapsible "Last Crash Report" section
+// of the Data tab, showing the last panic recovered by utils.SafeGo so users
+// can attach it to a bug report
+func (sv *SettingsView) buildCrashReportSection() fyne.CanvasObject {
+	reportLabel := widget.NewLabel("")
+	reportLabel.Wrapping = fyne.TextWrapWord
+	reportLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	reportScroll := container.NewVScroll(reportLabel)
+	reportScroll.SetMinSize(fyne.NewSize(0, 200))
+
+	copyBtn := widget.NewButton("📋 复制到剪贴板", func() {
+		if report := utils.LastPanicReport(); report != nil {
+			sv.app.window.Clipboard().SetContent(report.String())
+			sv.app.logger.Info("Crash report copied to clipboard")
+		}
+	})
+
+	refresh := func() {
+		report := utils.LastPanicReport()
+		if report == nil {
+			reportLabel.SetText("本次运行没有发生崩溃。")
+			copyBtn.Disable()
+			return
+		}
+		reportLabel.SetText(report.String())
+		copyBtn.Enable()
+	}
+	refresh()
+	refreshBtn := widget.NewButton("刷新", refresh)
+
+	note := widget.NewLabel("后台任务发生崩溃时会记录在这里，反馈问题时请附上完整内容。")
+	note.Wrapping = fyne.TextWrapWord
+	note.TextStyle = fyne.TextStyle{Italic: true}
+
+	item := widget.NewAccordionItem("最近一次崩溃报告 (Last Crash Report)",
+		container.NewBorder(note, container.NewHBox(copyBtn, refreshBtn), nil, nil, reportScroll))
+	return widget.NewAccordion(item)
+}
@@ -0,0 +1,38 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestSettingsView_CrashReportSection(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	sv := NewSettingsView(a)
+
+	utils.SafeGo(a.logger, "settings crash test", func() {
+		panic("settings crash")
+	})
+	waitUntil(t, "the panic report", func() bool {
+		report := utils.LastPanicReport()
+		return report != nil && report.Name == "settings crash test"
+	})
+
+	section := sv.buildCrashReportSection()
+	report := findObject(section, func(o fyne.CanvasObject) bool {
+		l, ok := o.(*widget.Label)
+		return ok && strings.HasPrefix(l.Text, "Panic in settings crash test")
+	})
+	if report == nil {
+		t.Fatal("expected the section to show the report")
+	}
+	if copyBtn := findButton(t, section, "📋 复制到剪贴板"); copyBtn.Disabled() {
+		t.Error("expected the copy button to be enabled")
+	}
+}
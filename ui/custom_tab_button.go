@@ -16,6 +16,10 @@ type CustomTabButton struct {
 	IsActive   bool
 	OnTapped   func()
 	OnClose    func()
+
+	// Drag callbacks used to reorder the tabs
+	OnDragged func(*fyne.DragEvent)
+	OnDragEnd func()
 	
 	background *canvas.Rectangle
 	titleLabel *canvas.Text
@@ -90,6 +94,20 @@ func (b *CustomTabButton) MouseUp(ev *desktop.MouseEvent) {
 	// Not needed for middle click functionality
 }
 
+// Dragged handles drag events
+func (b *CustomTabButton) Dragged(ev *fyne.DragEvent) {
+	if b.OnDragged != nil {
+		b.OnDragged(ev)
+	}
+}
+
+// DragEnd handles the end of a drag
+func (b *CustomTabButton) DragEnd() {
+	if b.OnDragEnd != nil {
+		b.OnDragEnd()
+	}
+}
+
 // SetActive updates the active state
 func (b *CustomTabButton) SetActive(active bool) {
 	b.IsActive = active
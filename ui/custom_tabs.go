@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"strings"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
@@ -23,8 +25,15 @@ type CustomTabs struct {
 	tabBar          *fyne.Container
 	scrollableTabBar *ScrollableTabBar
 	contentArea     *fyne.Container
+	bannerArea      *fyne.Container // Shown between the tab bar and the content
 	mainContent     *fyne.Container
 	OnChanged       func(*CustomTab)
+
+	// Called after a tab was dragged to a new position
+	OnReordered func()
+	// Tab being dragged and the drag position within the tab bar
+	dragTab *CustomTab
+	dragX   float32
 }
 
 // NewCustomTabs creates a new custom tabs container
@@ -33,11 +42,12 @@ func NewCustomTabs() *CustomTabs {
 		tabs:        []*CustomTab{},
 		tabBar:      container.NewHBox(),
 		contentArea: container.NewMax(),
+		bannerArea:  container.NewVBox(),
 	}
 	ct.ExtendBaseWidget(ct)
 	// Create scrollable tab bar with arrow buttons
 	ct.scrollableTabBar = NewScrollableTabBar(ct.tabBar)
-	ct.mainContent = container.NewBorder(ct.scrollableTabBar, nil, nil, nil, ct.contentArea)
+	ct.mainContent = container.NewBorder(container.NewVBox(ct.scrollableTabBar, ct.bannerArea), nil, nil, nil, ct.contentArea)
 	return ct
 }
 
@@ -63,6 +73,16 @@ func (ct *CustomTabs) Append(title string, content fyne.CanvasObject, onClose fu
 		}
 		ct.Remove(tab)
 	})
+	tab.tabButton.OnDragged = func(ev *fyne.DragEvent) {
+		if ct.dragTab == nil {
+			ct.dragTab = tab
+		}
+		// Drag positions are relative to the button, which stays in place while dragged
+		ct.dragX = tab.tabButton.Position().X + ev.Position.X
+	}
+	tab.tabButton.OnDragEnd = func() {
+		ct.endDrag()
+	}
 	
 	// Add tab to list
 	ct.tabs = append(ct.tabs, tab)
@@ -144,11 +164,104 @@ func (ct *CustomTabs) refreshTabBar() {
 	}
 }
 
+// endDrag moves the dragged tab to where it was dropped
+func (ct *CustomTabs) endDrag() {
+	tab := ct.dragTab
+	ct.dragTab = nil
+	if tab == nil {
+		return
+	}
+	from := ct.tabIndex(tab)
+	if from < 0 {
+		return
+	}
+	ct.MoveTab(from, ct.dropIndex(ct.dragX))
+}
+
+// dropIndex returns the index of the tab button at x in the tab bar
+func (ct *CustomTabs) dropIndex(x float32) int {
+	for i, tab := range ct.tabs {
+		if x < tab.tabButton.Position().X+tab.tabButton.Size().Width {
+			return i
+		}
+	}
+	return len(ct.tabs) - 1
+}
+
+// MoveTab swaps the tab at index from with the tab at index to
+func (ct *CustomTabs) MoveTab(from, to int) {
+	if from == to || from < 0 || to < 0 || from >= len(ct.tabs) || to >= len(ct.tabs) {
+		return
+	}
+	ct.tabs[from], ct.tabs[to] = ct.tabs[to], ct.tabs[from]
+	ct.refreshTabBar()
+
+	if ct.OnReordered != nil {
+		ct.OnReordered()
+	}
+	if ct.OnChanged != nil && ct.activeTab != nil {
+		ct.OnChanged(ct.activeTab)
+	}
+}
+
+// tabIndex returns the position of a tab, or -1 if it is not open
+func (ct *CustomTabs) tabIndex(tab *CustomTab) int {
+	for i, t := range ct.tabs {
+		if t == tab {
+			return i
+		}
+	}
+	return -1
+}
+
+// Tabs returns the open tabs in display order
+func (ct *CustomTabs) Tabs() []*CustomTab {
+	return ct.tabs
+}
+
+// SetBanner shows banner below the tab bar, or removes the banner if nil
+func (ct *CustomTabs) SetBanner(banner fyne.CanvasObject) {
+	if banner == nil {
+		ct.bannerArea.Objects = nil
+	} else {
+		ct.bannerArea.Objects = []fyne.CanvasObject{banner}
+	}
+	ct.bannerArea.Refresh()
+}
+
 // GetActiveTab returns the currently active tab
 func (ct *CustomTabs) GetActiveTab() *CustomTab {
 	return ct.activeTab
 }
 
+// FindTabByTitle returns the tab titled title, ignoring case, or else the
+// first tab whose title contains it. It returns nil if no tab matches.
+func (ct *CustomTabs) FindTabByTitle(title string) *CustomTab {
+	matches := ct.FilterTabsByTitle(title)
+	for _, tab := range matches {
+		if strings.EqualFold(tab.Title, title) {
+			return tab
+		}
+	}
+	if len(matches) > 0 {
+		return matches[0]
+	}
+	return nil
+}
+
+// FilterTabsByTitle returns the tabs whose title contains query, ignoring
+// case, in display order. An empty query matches all tabs.
+func (ct *CustomTabs) FilterTabsByTitle(query string) []*CustomTab {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var matches []*CustomTab
+	for _, tab := range ct.tabs {
+		if strings.Contains(strings.ToLower(tab.Title), query) {
+			matches = append(matches, tab)
+		}
+	}
+	return matches
+}
+
 // TabCount returns the number of tabs
 func (ct *CustomTabs) TabCount() int {
 	return len(ct.tabs)
@@ -0,0 +1,156 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"slices"
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestCustomTabs_DragReorder(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	a.window.Resize(fyne.NewSize(1200, 800))
+
+	var ids []int64
+	for _, title := range []string{"first", "second", "third"} {
+		conv, err := a.db.CreateConversation(title, "mock")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		a.openChatTab(conv.ID)
+		ids = append(ids, conv.ID)
+	}
+	if got := a.config.UI.TabOrder; !slices.Equal(got, ids) {
+		t.Fatalf("TabOrder = %v, want %v", got, ids)
+	}
+
+	changed := 0
+	onChanged := a.tabs.OnChanged
+	a.tabs.OnChanged = func(tab *CustomTab) {
+		changed++
+		onChanged(tab)
+	}
+
+	// Drag the first tab onto the third
+	first, third := a.tabItems[ids[0]].tabButton, a.tabItems[ids[2]].tabButton
+	if third.Position().X <= first.Position().X {
+		t.Fatalf("tab buttons are not laid out: %v, %v", first.Position(), third.Position())
+	}
+	drop := fyne.NewPos(third.Position().X-first.Position().X+third.Size().Width/2, first.Size().Height/2)
+	first.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(drop.X/2, drop.Y)}})
+	first.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: drop}})
+	first.DragEnd()
+
+	want := []int64{ids[2], ids[1], ids[0]}
+	if got := a.chatTabOrder(); !slices.Equal(got, want) {
+		t.Errorf("tab order = %v, want %v", got, want)
+	}
+	if got := a.config.UI.TabOrder; !slices.Equal(got, want) {
+		t.Errorf("saved TabOrder = %v, want %v", got, want)
+	}
+	if changed != 1 {
+		t.Errorf("expected OnChanged to fire once after reordering, got %d", changed)
+	}
+	if a.tabs.tabBar.Objects[0] != third {
+		t.Error("expected the tab bar to show the third tab first")
+	}
+
+	// Dropping a tab on itself keeps the order
+	a.tabItems[ids[1]].tabButton.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(1, 1)}})
+	a.tabItems[ids[1]].tabButton.DragEnd()
+	if got := a.chatTabOrder(); !slices.Equal(got, want) {
+		t.Errorf("tab order = %v after dropping in place, want %v", got, want)
+	}
+
+	// Closing the tabs and restoring them reopens them in the saved order
+	for _, id := range ids {
+		a.closeChatTab(id)
+	}
+	a.config.UI.TabOrder = append(slices.Clone(want), 9999)
+	a.restoreTabs()
+	if got := a.chatTabOrder(); !slices.Equal(got, want) {
+		t.Errorf("restored tab order = %v, want %v", got, want)
+	}
+	// The restored order is saved without the missing conversation
+	saved, err := utils.LoadConfig(a.configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := saved.UI.TabOrder; !slices.Equal(got, want) {
+		t.Errorf("saved TabOrder after restoring = %v, want %v", got, want)
+	}
+}
+
+func TestTabSearchPopup(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	a.window.Resize(fyne.NewSize(1200, 800))
+
+	for _, title := range []string{"Go generics", "Rust ownership", "go modules"} {
+		conv, err := a.db.CreateConversation(title, "mock")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		a.openChatTab(conv.ID)
+	}
+
+	if tab := a.tabs.FindTabByTitle("GO MODULES"); tab == nil || tab.Title != "go modules" {
+		t.Errorf("expected the exact title to match, got %+v", tab)
+	}
+	if tab := a.tabs.FindTabByTitle("owner"); tab == nil || tab.Title != "Rust ownership" {
+		t.Errorf("expected a title containing the query to match, got %+v", tab)
+	}
+	if tab := a.tabs.FindTabByTitle("python"); tab != nil {
+		t.Errorf("expected no match, got %+v", tab)
+	}
+
+	p := NewTabSearchPopup(a)
+	p.Show()
+	if a.window.Canvas().Focused() != p.entry {
+		t.Error("expected the search box to be focused")
+	}
+	if size := p.popup.Size(); size.Width < 300 || size.Height < 200 {
+		t.Errorf("popup is too small: %v", size)
+	}
+
+	p.entry.SetText("GO")
+	if len(p.matches) != 2 || p.list.Length() != 2 {
+		t.Fatalf("expected 2 matches for %q, got %d", "GO", len(p.matches))
+	}
+	p.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	if got := a.tabs.GetActiveTab().Title; got != "Go generics" {
+		t.Errorf("expected Enter to switch to the first match, got %q", got)
+	}
+	if p.popup.Visible() {
+		t.Error("expected the popup to close after switching")
+	}
+
+	// Clicking a result switches to it
+	p = NewTabSearchPopup(a)
+	p.Show()
+	p.entry.SetText("rust")
+	p.list.Select(0)
+	if got := a.tabs.GetActiveTab().Title; got != "Rust ownership" {
+		t.Errorf("expected the clicked tab to be selected, got %q", got)
+	}
+	if p.popup.Visible() {
+		t.Error("expected the popup to close after a click")
+	}
+
+	// Escape and losing the focus close the popup
+	p = NewTabSearchPopup(a)
+	p.Show()
+	p.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEscape})
+	if p.popup.Visible() {
+		t.Error("expected Escape to close the popup")
+	}
+	p = NewTabSearchPopup(a)
+	p.Show()
+	a.window.Canvas().Unfocus()
+	if p.popup.Visible() {
+		t.Error("expected the popup to close when it loses the focus")
+	}
+}
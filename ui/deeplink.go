@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"light-llm-client/utils"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// HandleDeepLink carries out a light-llm:// URL, e.g. one forwarded by a
+// second instance. Safe to call from any goroutine.
+func (a *App) HandleDeepLink(rawURL string) {
+	action, params, err := utils.ParseLightLLMURL(rawURL)
+	if err != nil {
+		a.logger.Warn("Ignoring deep link %q: %v", rawURL, err)
+		fyne.Do(func() {
+			a.showError("无法打开链接: " + err.Error())
+		})
+		return
+	}
+
+	fyne.Do(func() {
+		a.window.Show()
+		a.window.RequestFocus()
+
+		switch action {
+		case utils.DeepLinkOpen:
+			if rawID, ok := params["message"]; ok {
+				id, _ := strconv.ParseInt(rawID, 10, 64)
+				if err := a.NavigateToMessage(id); err != nil {
+					a.logger.Warn("Deep link to missing message %d: %v", id, err)
+					a.showError("消息不存在: " + rawID)
+				}
+				return
+			}
+			id, _ := strconv.ParseInt(params["conversation"], 10, 64)
+			if _, err := a.db.GetConversation(id); err != nil {
+				a.logger.Warn("Deep link to missing conversation %d: %v", id, err)
+				a.showError("对话不存在: " + params["conversation"])
+				return
+			}
+			a.openChatTab(id)
+		case utils.DeepLinkNew:
+			title := strings.TrimSpace(params["title"])
+			if title == "" {
+				a.createNewConversation()
+				return
+			}
+			conv, err := a.db.CreateConversation(title, "")
+			if err != nil {
+				a.logger.Error("Failed to create conversation: %v", err)
+				a.showError("Failed to create conversation: " + err.Error())
+				return
+			}
+			a.RefreshSidebar()
+			a.openChatTab(conv.ID)
+		case utils.DeepLinkSearch:
+			a.showSearch()
+			a.searchView.Search(params["q"])
+		}
+	})
+}
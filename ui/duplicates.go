@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/db"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Resolutions of a duplicate pair
+const (
+	duplicateKeepA = iota
+	duplicateKeepB
+	duplicateMerge
+	duplicateSkip
+)
+
+// duplicatesDialog lists duplicate conversations so they can be resolved
+// one pair at a time
+type duplicatesDialog struct {
+	sv      *SettingsView
+	pairs   []*db.ConversationPair
+	skipped map[[2]int64]bool
+
+	list   *widget.List
+	status *widget.Label
+	popup  *widget.PopUp
+}
+
+// showDuplicates opens the duplicate conversation list
+func (sv *SettingsView) showDuplicates() {
+	if sv.settingsWindow == nil {
+		return
+	}
+
+	d := &duplicatesDialog{
+		sv:      sv,
+		skipped: make(map[[2]int64]bool),
+		status:  widget.NewLabel(""),
+	}
+	d.list = widget.NewList(
+		func() int {
+			return len(d.pairs)
+		},
+		func() fyne.CanvasObject {
+			// Two lines so the row template has the height of a pair
+			label := widget.NewLabel("title\nconversations")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewVBox(
+				label,
+				container.NewHBox(
+					widget.NewButton("保留 A", nil),
+					widget.NewButton("保留 B", nil),
+					widget.NewButton("合并", nil),
+					widget.NewButton("跳过", nil),
+				),
+			)
+		},
+		d.updateItem,
+	)
+
+	closeButton := widget.NewButton("关闭", func() {
+		d.popup.Hide()
+	})
+
+	d.popup = widget.NewModalPopUp(
+		container.NewBorder(
+			container.NewVBox(widget.NewLabel("重复对话"), d.status, widget.NewSeparator()),
+			container.NewHBox(closeButton),
+			nil,
+			nil,
+			d.list,
+		),
+		sv.getCanvas(),
+	)
+	d.popup.Resize(fyne.NewSize(620, 480))
+
+	if !d.reload() {
+		return
+	}
+	d.popup.Show()
+}
+
+// updateItem shows one pair in a list row
+func (d *duplicatesDialog) updateItem(id widget.ListItemID, obj fyne.CanvasObject) {
+	if id >= len(d.pairs) {
+		return
+	}
+	pair := d.pairs[id]
+
+	row := obj.(*fyne.Container)
+	row.Objects[0].(*widget.Label).SetText(fmt.Sprintf(
+		"%s\nA: #%d 创建于 %s    B: #%d 创建于 %s    消息相同 %.0f%%",
+		pair.A.Title,
+		pair.A.ID, pair.A.CreatedAt.Format("2006-01-02 15:04"),
+		pair.B.ID, pair.B.CreatedAt.Format("2006-01-02 15:04"),
+		pair.SimilarityScore*100,
+	))
+
+	buttons := row.Objects[1].(*fyne.Container).Objects
+	for action, button := range buttons {
+		action := action
+		button.(*widget.Button).OnTapped = func() {
+			d.resolve(pair, action)
+		}
+	}
+}
+
+// reload fetches the duplicate pairs again, leaving out skipped ones
+func (d *duplicatesDialog) reload() bool {
+	pairs, err := d.sv.app.db.GetDuplicateConversations()
+	if err != nil {
+		d.sv.app.logger.Error("Failed to find duplicate conversations: %v", err)
+		d.sv.showError("查找重复对话失败: " + err.Error())
+		return false
+	}
+
+	d.pairs = d.pairs[:0]
+	for _, pair := range pairs {
+		if !d.skipped[[2]int64{pair.A.ID, pair.B.ID}] {
+			d.pairs = append(d.pairs, pair)
+		}
+	}
+
+	if len(d.pairs) == 0 {
+		d.status.SetText("没有发现重复对话")
+	} else {
+		d.status.SetText(fmt.Sprintf("发现 %d 组标题和首条消息相同的对话", len(d.pairs)))
+	}
+	d.list.Refresh()
+	return true
+}
+
+// resolve applies the chosen action to a pair and updates the list. The
+// list is fetched again because deleting the first conversation of a group
+// pairs its other copies with the next one.
+func (d *duplicatesDialog) resolve(pair *db.ConversationPair, action int) {
+	app := d.sv.app
+	var err error
+	var removed []int64
+	switch action {
+	case duplicateKeepA:
+		err = app.db.DeleteConversation(pair.B.ID)
+		removed = []int64{pair.B.ID}
+	case duplicateKeepB:
+		err = app.db.DeleteConversation(pair.A.ID)
+		removed = []int64{pair.A.ID}
+	case duplicateMerge:
+		err = app.db.MergeDuplicateConversations(pair.A.ID, pair.B.ID)
+		// The open tab of A would miss the merged messages
+		removed = []int64{pair.A.ID, pair.B.ID}
+	case duplicateSkip:
+		d.skipped[[2]int64{pair.A.ID, pair.B.ID}] = true
+	}
+	if err != nil {
+		app.logger.Error("Failed to resolve duplicate conversations %d and %d: %v", pair.A.ID, pair.B.ID, err)
+		d.sv.showError("操作失败: " + err.Error())
+		return
+	}
+
+	if len(removed) > 0 {
+		for _, id := range removed {
+			app.closeChatTab(id)
+		}
+		app.logger.Info("Resolved duplicate conversations %d and %d", pair.A.ID, pair.B.ID)
+		app.RefreshSidebar()
+	}
+	d.reload()
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"light-llm-client/utils"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// EnableExportSchedule lets the settings show and change the scheduled exports
+// of a running scheduler
+func (a *App) EnableExportSchedule(scheduler *utils.ExportScheduler) {
+	a.exportScheduler = scheduler
+	scheduler.OnExport = func(path string, err error) {
+		fyne.Do(func() {
+			if err != nil {
+				a.showError("定时导出失败: " + err.Error())
+			}
+			if a.exportStatusChanged != nil {
+				a.exportStatusChanged()
+			}
+		})
+	}
+	if next, ok := scheduler.Next(); ok {
+		a.logger.Info("Next scheduled export at %s", next.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// exportStatusText describes the next scheduled export
+func (a *App) exportStatusText() string {
+	if a.exportScheduler == nil {
+		return "Scheduled exports are not available"
+	}
+	next, ok := a.exportScheduler.Next()
+	if !ok {
+		return "No scheduled export"
+	}
+	return "Next scheduled export: " + next.Format("2006-01-02 15:04:05")
+}
+
+// buildExportScheduleSettings builds the scheduled export settings of the
+// Data tab
+func (sv *SettingsView) buildExportScheduleSettings() fyne.CanvasObject {
+	exportConfig := sv.app.config.Export
+
+	scheduleEntry := widget.NewEntry()
+	scheduleEntry.SetPlaceHolder("0 2 * * *")
+	scheduleEntry.SetText(exportConfig.Schedule)
+	dirEntry := widget.NewEntry()
+	dirEntry.SetPlaceHolder("默认导出目录")
+	dirEntry.SetText(exportConfig.Dir)
+	formatSelect := widget.NewSelect([]string{string(utils.FormatJSON), string(utils.FormatMarkdown)}, nil)
+	formatSelect.SetSelected(string(utils.FormatJSON))
+	if exportConfig.Format != "" {
+		formatSelect.SetSelected(string(exportConfig.Format))
+	}
+
+	note := widget.NewLabel("Cron 表达式 (分 时 * * *)，例如 \"0 2 * * *\" 表示每天 02:00 导出全部对话 (留空 = 不定时导出)")
+	note.Wrapping = fyne.TextWrapWord
+	note.TextStyle = fyne.TextStyle{Italic: true}
+
+	statusLabel := widget.NewLabel(sv.app.exportStatusText())
+	sv.app.exportStatusChanged = func() {
+		statusLabel.SetText(sv.app.exportStatusText())
+	}
+
+	saveBtn := widget.NewButton("保存导出设置", func() {
+		exportConfig := utils.ExportConfig{
+			Schedule: strings.TrimSpace(scheduleEntry.Text),
+			Dir:      strings.TrimSpace(dirEntry.Text),
+			Format:   utils.ExportFormat(formatSelect.Selected),
+		}
+		if exportConfig.Schedule != "" {
+			if _, err := utils.ParseCronSchedule(exportConfig.Schedule); err != nil {
+				sv.showError("无效的 Cron 表达式: " + err.Error())
+				return
+			}
+		}
+
+		sv.app.config.Export = exportConfig
+		if err := utils.SaveConfig(sv.app.configPath, sv.app.config); err != nil {
+			sv.app.logger.Error("Failed to save export settings: %v", err)
+			sv.showError("保存失败: " + err.Error())
+			return
+		}
+		if sv.app.exportScheduler != nil {
+			if err := sv.app.exportScheduler.SetConfig(exportConfig); err != nil {
+				sv.showError("定时导出已停用: " + err.Error())
+				return
+			}
+		}
+		statusLabel.SetText(sv.app.exportStatusText())
+
+		sv.app.logger.Info("Export schedule updated: %q", exportConfig.Schedule)
+		sv.showSuccess("导出设置已保存")
+	})
+
+	runNowBtn := widget.NewButton("Run now", nil)
+	runNowBtn.OnTapped = func() {
+		scheduler := sv.app.exportScheduler
+		if scheduler == nil {
+			return
+		}
+		runNowBtn.Disable()
+		utils.SafeGo(sv.app.logger, "exportNow", func() {
+			path, err := scheduler.RunNow()
+			fyne.Do(func() {
+				runNowBtn.Enable()
+				if err != nil {
+					sv.app.logger.Error("Export failed: %v", err)
+					sv.showError("导出失败: " + err.Error())
+					return
+				}
+				sv.app.logger.Info("Exported all conversations to %s", path)
+				sv.showSuccess("导出成功: " + path)
+			})
+		})
+	}
+	if sv.app.exportScheduler == nil {
+		saveBtn.Disable()
+		runNowBtn.Disable()
+	}
+
+	return container.NewVBox(scheduleEntry, dirEntry, formatSelect, note, statusLabel, container.NewHBox(saveBtn, runNowBtn))
+}
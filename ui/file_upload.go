@@ -3,8 +3,10 @@ package ui
 import (
 	"fmt"
 	"image/color"
+	"io"
 	"light-llm-client/llm"
 	"light-llm-client/utils"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,10 +15,18 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
+// dropFeedbackDuration is how long the drop highlight and banner stay visible
+const dropFeedbackDuration = 2 * time.Second
+
+// attachmentThumbnailSize bounds the preview image of attachments. It is twice
+// the 60px preview so it stays sharp on high-DPI screens.
+const attachmentThumbnailSize = 120
+
 // FileAttachmentWidget displays a file attachment with preview and remove button
 type FileAttachmentWidget struct {
 	widget.BaseWidget
@@ -40,11 +50,15 @@ func (w *FileAttachmentWidget) CreateRenderer() fyne.WidgetRenderer {
 	// Create icon based on file type
 	var icon fyne.CanvasObject
 	if w.attachment.Type == "image" {
-		// For images, show a thumbnail from data
-		if len(w.attachment.Data) > 0 {
+		// For images, show the thumbnail, or the full image if there is none
+		previewData := w.attachment.ThumbnailData
+		if len(previewData) == 0 {
+			previewData = w.attachment.Data
+		}
+		if len(previewData) > 0 {
 			img := canvas.NewImageFromResource(fyne.NewStaticResource(
 				w.attachment.Filename,
-				w.attachment.Data,
+				previewData,
 			))
 			img.FillMode = canvas.ImageFillContain
 			img.SetMinSize(fyne.NewSize(60, 60))
@@ -99,6 +113,10 @@ type FileUploadArea struct {
 	onChange    func([]*llm.Attachment)
 	container   *fyne.Container
 	handler     *utils.FileUploadHandler
+	// Drop feedback: border highlight and "added N files" banner
+	highlight   *canvas.Rectangle
+	dropBanner  *widget.Label
+	feedbackGen int // invalidates pending hide timers when a newer drop arrives
 }
 
 // NewFileUploadArea creates a new file upload area
@@ -126,12 +144,25 @@ func (a *FileUploadArea) CreateRenderer() fyne.WidgetRenderer {
 	// Update attachments display
 	a.updateAttachmentsDisplay(attachmentsContainer)
 
+	// Banner shown briefly after files are dropped
+	a.dropBanner = widget.NewLabel("")
+	a.dropBanner.Importance = widget.SuccessImportance
+	a.dropBanner.Hide()
+
 	a.container = container.NewVBox(
 		uploadBtn,
 		attachmentsContainer,
+		a.dropBanner,
 	)
 
-	return widget.NewSimpleRenderer(a.container)
+	// Border highlight drawn around the area while a drop is processed
+	a.highlight = canvas.NewRectangle(color.Transparent)
+	a.highlight.StrokeColor = theme.Color(theme.ColorNamePrimary)
+	a.highlight.StrokeWidth = 2
+	a.highlight.CornerRadius = 5
+	a.highlight.Hide()
+
+	return widget.NewSimpleRenderer(container.NewStack(a.container, a.highlight))
 }
 
 // showFilePicker shows a file picker dialog
@@ -153,36 +184,7 @@ func (a *FileUploadArea) showFilePicker() {
 		
 		a.app.logger.Info("Selected file: %s", filePath)
 
-		// Process file with retry for file lock issues
-		var attachment *llm.Attachment
-		var processErr error
-		maxRetries := 3
-		
-		for attempt := 0; attempt < maxRetries; attempt++ {
-			if attempt > 0 {
-				a.app.logger.Info("Retrying file processing (attempt %d/%d)...", attempt+1, maxRetries)
-				// Exponential backoff
-				// time.Sleep(time.Duration(attempt*100) * time.Millisecond)
-			}
-			
-			attachment, processErr = a.handler.ProcessFile(filePath)
-			if processErr == nil {
-				break
-			}
-			
-			// Check if error is due to file lock
-			errStr := processErr.Error()
-			if strings.Contains(errStr, "being used by another process") || 
-			   strings.Contains(errStr, "access is denied") ||
-			   strings.Contains(errStr, "The process cannot access the file") {
-				a.app.logger.Warn("File lock detected, will retry: %v", processErr)
-				continue
-			}
-			
-			// Other errors, don't retry
-			break
-		}
-		
+		attachment, processErr := a.processFileWithRetry(filePath)
 		if processErr != nil {
 			a.app.showError("处理文件失败: " + processErr.Error())
 			return
@@ -193,8 +195,157 @@ func (a *FileUploadArea) showFilePicker() {
 	}, a.app.window)
 }
 
+// processFileWithRetry processes a local file, retrying when it is locked by another process
+func (a *FileUploadArea) processFileWithRetry(filePath string) (*llm.Attachment, error) {
+	var attachment *llm.Attachment
+	var processErr error
+	maxRetries := 3
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			a.app.logger.Info("Retrying file processing (attempt %d/%d)...", attempt+1, maxRetries)
+		}
+
+		attachment, processErr = a.handler.ProcessFile(filePath)
+		if processErr == nil {
+			break
+		}
+
+		// Check if error is due to file lock
+		errStr := processErr.Error()
+		if strings.Contains(errStr, "being used by another process") ||
+			strings.Contains(errStr, "access is denied") ||
+			strings.Contains(errStr, "The process cannot access the file") {
+			a.app.logger.Warn("File lock detected, will retry: %v", processErr)
+			continue
+		}
+
+		// Other errors, don't retry
+		break
+	}
+
+	return attachment, processErr
+}
+
+// HandleDrop adds files dropped from the desktop. Non-local URIs are copied
+// to the temp directory first. Must be called on the UI thread.
+func (a *FileUploadArea) HandleDrop(uris []fyne.URI) {
+	if len(uris) == 0 {
+		return
+	}
+	a.app.logger.Info("Dropped %d item(s) on upload area", len(uris))
+	a.showDropFeedback("")
+
+	utils.SafeGo(a.app.logger, "fileDrop", func() {
+		var attachments []*llm.Attachment
+		var failures []string
+		for _, uri := range uris {
+			attachment, err := a.processDroppedURI(uri)
+			if err != nil {
+				a.app.logger.Warn("Failed to process dropped file %s: %v", uri, err)
+				failures = append(failures, fmt.Sprintf("%s: %v", uri.Name(), err))
+				continue
+			}
+			attachments = append(attachments, attachment)
+		}
+
+		fyne.Do(func() {
+			for _, attachment := range attachments {
+				a.addAttachment(attachment)
+			}
+			if len(attachments) > 0 {
+				a.showDropFeedback(fmt.Sprintf("✅ 已添加 %d 个文件", len(attachments)))
+			}
+			if len(failures) > 0 {
+				a.app.showError("无法处理文件:\n" + strings.Join(failures, "\n"))
+			}
+		})
+	})
+}
+
+// processDroppedURI resolves a dropped URI to a local file and processes it
+func (a *FileUploadArea) processDroppedURI(uri fyne.URI) (*llm.Attachment, error) {
+	uri, err := storage.ParseURI(uri.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI: %w", err)
+	}
+
+	if uri.Scheme() == "file" {
+		if canList, err := storage.CanList(uri); err == nil && canList {
+			return nil, fmt.Errorf("folders are not supported")
+		}
+		return a.processFileWithRetry(uri.Path())
+	}
+
+	// Copy non-local files so the handler can read them from disk
+	reader, err := storage.Reader(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open: %w", err)
+	}
+	defer reader.Close()
+
+	tmpFile, err := os.CreateTemp(os.TempDir(), "drop-*-"+uri.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := io.Copy(tmpFile, reader); err != nil {
+		tmpFile.Close()
+		return nil, fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	attachment, err := a.processFileWithRetry(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	attachment.Filename = uri.Name()
+	return attachment, nil
+}
+
+// showDropFeedback highlights the area and shows the banner text (if any),
+// hiding both after dropFeedbackDuration
+func (a *FileUploadArea) showDropFeedback(text string) {
+	if a.highlight == nil || a.dropBanner == nil {
+		return
+	}
+
+	a.feedbackGen++
+	gen := a.feedbackGen
+
+	a.highlight.Show()
+	if text != "" {
+		a.dropBanner.SetText(text)
+		a.dropBanner.Show()
+	}
+
+	time.AfterFunc(dropFeedbackDuration, func() {
+		fyne.Do(func() {
+			if gen != a.feedbackGen {
+				return
+			}
+			a.highlight.Hide()
+			a.dropBanner.Hide()
+		})
+	})
+}
+
 // addAttachment adds an attachment to the list
 func (a *FileUploadArea) addAttachment(att *llm.Attachment) {
+	if att.Type == "image" && len(att.ThumbnailData) == 0 {
+		thumbnail, err := a.handler.GenerateThumbnail(att, attachmentThumbnailSize, attachmentThumbnailSize)
+		if err != nil {
+			// The preview falls back to the full image
+			a.app.logger.Warn("Failed to generate thumbnail for %s: %v", att.Filename, err)
+		} else {
+			att.ThumbnailData = thumbnail
+		}
+	}
+
 	a.attachments = append(a.attachments, att)
 	a.Refresh()
 	
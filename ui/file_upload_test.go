@@ -0,0 +1,47 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+)
+
+func TestApp_HandleDrop_MultipleFiles(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	cv, _ := newTestChat(t, a)
+
+	dir := t.TempDir()
+	var uris []fyne.URI
+	for _, name := range []string{"notes.txt", "main.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		uris = append(uris, storage.NewFileURI(path))
+	}
+	// Folders are rejected, the files are still added
+	uris = append(uris, storage.NewFileURI(dir))
+
+	fyne.DoAndWait(func() {
+		a.handleDrop(fyne.NewPos(10, 10), uris)
+	})
+	waitUntil(t, "the dropped files", func() bool {
+		var count int
+		fyne.DoAndWait(func() { count = len(cv.fileUploadArea.GetAttachments()) })
+		return count == 2
+	})
+
+	attachments := cv.fileUploadArea.GetAttachments()
+	if attachments[0].Filename != "notes.txt" || attachments[1].Filename != "main.go" {
+		t.Errorf("unexpected attachments %s, %s", attachments[0].Filename, attachments[1].Filename)
+	}
+	if cv.fileUploadArea.highlight == nil || !cv.fileUploadArea.highlight.Visible() {
+		t.Error("expected the drop zone to be highlighted")
+	}
+}
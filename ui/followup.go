@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"context"
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"strings"
+	"time"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// followUpTimeout limits how long the background suggestion request may take
+const followUpTimeout = 15 * time.Second
+
+// followUpPrompt asks the provider for short follow-up questions
+const followUpPrompt = "Based on the conversation above, suggest exactly 3 short follow-up questions the user might ask next. " +
+	"Each question must be at most 10 words and written in the same language as the conversation. " +
+	"Output only the questions, one per line, without numbering or any other text."
+
+// maxFollowUpSuggestions is the number of suggestion chips shown
+const maxFollowUpSuggestions = 3
+
+// startFollowUpSuggestions requests follow-up questions in the background.
+// The messages are counted on the UI thread, after the response was added to
+// them, so that suggestions arriving after the user moved on can be dropped.
+func (cv *ChatView) startFollowUpSuggestions(provider llm.Provider, history []llm.Message, response string) {
+	fyne.Do(func() {
+		conversationID := cv.conversationID
+		messageCount := len(cv.messages)
+		utils.SafeGo(cv.app.logger, "followUpSuggestions", func() {
+			cv.requestFollowUpSuggestions(provider, history, response, conversationID, messageCount)
+		})
+	})
+}
+
+// requestFollowUpSuggestions asks the provider for follow-up questions and
+// shows them as chips when they arrive, unless the conversation changed from
+// messageCount messages in the meantime
+func (cv *ChatView) requestFollowUpSuggestions(provider llm.Provider, history []llm.Message, response string, conversationID int64, messageCount int) {
+	if !cv.app.config.UI.ShowFollowUpSuggestions || strings.TrimSpace(response) == "" {
+		return
+	}
+
+	messages := make([]llm.Message, 0, len(history)+2)
+	for _, msg := range history {
+		// Attachments are not needed to suggest follow-ups
+		messages = append(messages, llm.Message{Role: msg.Role, Content: msg.Content})
+	}
+	messages = append(messages,
+		llm.Message{Role: "assistant", Content: response},
+		llm.Message{Role: "user", Content: followUpPrompt},
+	)
+
+	ctx, cancel := context.WithTimeout(cv.ctx, followUpTimeout)
+	defer cancel()
+
+	result, err := provider.Chat(ctx, messages)
+	if err != nil {
+		cv.app.logger.Warn("Failed to get follow-up suggestions: %v", err)
+		return
+	}
+
+	suggestions := parseFollowUpSuggestions(result)
+	if len(suggestions) == 0 {
+		return
+	}
+
+	fyne.Do(func() {
+		// Drop stale suggestions if the user moved on in the meantime
+		if cv.conversationID != conversationID || len(cv.messages) != messageCount || cv.inputEntry.Text != "" {
+			return
+		}
+		cv.showFollowUpSuggestions(suggestions)
+	})
+}
+
+// showFollowUpSuggestions shows tappable chips that fill the input with a question
+func (cv *ChatView) showFollowUpSuggestions(suggestions []string) {
+	chips := make([]fyne.CanvasObject, 0, len(suggestions)+1)
+	chips = append(chips, widget.NewLabel("💡"))
+	for _, suggestion := range suggestions {
+		question := suggestion
+		chip := widget.NewButton(question, func() {
+			cv.inputEntry.SetText(question)
+			cv.clearFollowUpSuggestions()
+			cv.app.window.Canvas().Focus(cv.inputEntry)
+		})
+		chip.Importance = widget.LowImportance
+		chips = append(chips, chip)
+	}
+
+	cv.followUpContainer.Objects = []fyne.CanvasObject{container.NewHScroll(container.NewHBox(chips...))}
+	cv.followUpContainer.Show()
+	cv.followUpContainer.Refresh()
+}
+
+// clearFollowUpSuggestions removes any suggestion chips. Must be called on the UI thread.
+func (cv *ChatView) clearFollowUpSuggestions() {
+	if cv.followUpContainer == nil || len(cv.followUpContainer.Objects) == 0 {
+		return
+	}
+	cv.followUpContainer.Objects = nil
+	cv.followUpContainer.Hide()
+	cv.followUpContainer.Refresh()
+}
+
+// parseFollowUpSuggestions extracts questions from the model output, removing
+// numbering and bullet prefixes
+func parseFollowUpSuggestions(text string) []string {
+	var suggestions []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeftFunc(line, func(r rune) bool {
+			return unicode.IsDigit(r) || r == '.' || r == ')' || r == '-' || r == '*' || r == '•' || unicode.IsSpace(r)
+		})
+		line = strings.Trim(line, "\"'“”")
+		if line == "" {
+			continue
+		}
+		suggestions = append(suggestions, line)
+		if len(suggestions) == maxFollowUpSuggestions {
+			break
+		}
+	}
+	return suggestions
+}
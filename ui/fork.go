@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"light-llm-client/llm"
 	"light-llm-client/utils"
+	"slices"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -19,85 +23,127 @@ type ForkChatView struct {
 	conversationID   int64
 	inputEntry       *customEntry
 	sendButton       *widget.Button
-	columnCount      int
+	providerOptions  []string
 	providerSelects  []*widget.Select
+	columnLabels     []*widget.Label
 	columnContainers []*fyne.Container
 	columnScrolls    []*container.Scroll
+	columns          []fyne.CanvasObject
+	columnsGrid      *fyne.Container
+	addColumnButton  *widget.Button
+	removeButton     *widget.Button
+	initialColumns   int
+
+	// Benchmark metrics collected during this session
+	benchmarkVisible bool
+	benchmarkButton  *widget.Button
+	benchmarkRows    []fyne.CanvasObject
+	benchmarks       []*forkBenchmark
+	round            int
+}
+
+// Limits of the number of compared models
+const (
+	forkMinColumns = 2
+	forkMaxColumns = 4
+)
+
+// noProviderOption is shown in the selects when no provider is enabled
+const noProviderOption = "请在配置文件中启用 LLM 提供商"
+
+// forkBenchmark holds the streaming metrics of one column's response
+type forkBenchmark struct {
+	Round     int
+	Column    int
+	Provider  string
+	Model     string
+	TTFT      time.Duration // Time to first non-empty chunk
+	Total     time.Duration // Time from request start to final chunk
+	Tokens    int
+	Estimated bool // Tokens estimated from response length (provider reported no usage)
+	Failed    bool
+
+	response *fyne.Container // Response UI the metrics row is appended to
+}
+
+// TokensPerSecond returns the generation throughput
+func (b *forkBenchmark) TokensPerSecond() float64 {
+	generation := b.Total - b.TTFT
+	if generation <= 0 || b.Tokens == 0 {
+		return 0
+	}
+	return float64(b.Tokens) / generation.Seconds()
+}
+
+// Metrics returns the metrics stored with the saved response
+func (b *forkBenchmark) Metrics() utils.ResponseMetrics {
+	return utils.ResponseMetrics{
+		TTFTMs:          b.TTFT.Milliseconds(),
+		TotalMs:         b.Total.Milliseconds(),
+		Tokens:          b.Tokens,
+		TokensEstimated: b.Estimated,
+		TokensPerSecond: b.TokensPerSecond(),
+	}
+}
+
+// String formats the metrics for display below a response
+func (b *forkBenchmark) String() string {
+	if b.Failed {
+		return fmt.Sprintf("⏱ 失败 (耗时 %d ms)", b.Total.Milliseconds())
+	}
+	tokens := fmt.Sprintf("%d", b.Tokens)
+	if b.Estimated {
+		tokens = "≈" + tokens
+	}
+	return fmt.Sprintf("⏱ TTFT: %d ms | 总耗时: %.2f s | Tokens: %s | %.1f tokens/s",
+		b.TTFT.Milliseconds(), b.Total.Seconds(), tokens, b.TokensPerSecond())
 }
 
 // NewForkChatView creates a new fork chat view with specified number of columns
 func NewForkChatView(app *App, columnCount int) *ForkChatView {
-	if columnCount < 2 {
-		columnCount = 2
+	if columnCount < forkMinColumns {
+		columnCount = forkMinColumns
 	}
-	if columnCount > 4 {
-		columnCount = 4
+	if columnCount > forkMaxColumns {
+		columnCount = forkMaxColumns
 	}
 
 	fv := &ForkChatView{
-		app:              app,
-		columnCount:      columnCount,
-		providerSelects:  make([]*widget.Select, columnCount),
-		columnContainers: make([]*fyne.Container, columnCount),
-		columnScrolls:    make([]*container.Scroll, columnCount),
+		app:            app,
+		initialColumns: columnCount,
 	}
 
 	return fv
 }
 
-// Build creates the fork chat UI with side-by-side columns
+// Build creates the fork chat UI with side-by-side columns. The columns are
+// children of a grid that is updated when columns are added or removed.
 func (fv *ForkChatView) Build() fyne.CanvasObject {
 	// Get available providers
-	providerOptions := []string{}
+	fv.providerOptions = []string{}
 	for name := range fv.app.providers {
-		providerOptions = append(providerOptions, name)
+		fv.providerOptions = append(fv.providerOptions, name)
 	}
-	if len(providerOptions) == 0 {
-		providerOptions = []string{"请在配置文件中启用 LLM 提供商"}
+	if len(fv.providerOptions) == 0 {
+		fv.providerOptions = []string{noProviderOption}
 	}
 
-	// Create columns
-	columns := []fyne.CanvasObject{}
-	for i := 0; i < fv.columnCount; i++ {
-		colIdx := i
-		
-		// Provider selector for this column
-		providerLabel := widget.NewLabelWithStyle(
-			fmt.Sprintf("模型 %d:", i+1),
-			fyne.TextAlignLeading,
-			fyne.TextStyle{Bold: true},
-		)
-		
-		fv.providerSelects[i] = widget.NewSelect(providerOptions, func(value string) {
-			fv.app.logger.Info("Column %d selected provider: %s", colIdx+1, value)
-		})
-		
-		// Set default selection
-		if len(providerOptions) > i && providerOptions[i] != "请在配置文件中启用 LLM 提供商" {
-			fv.providerSelects[i].SetSelected(providerOptions[i])
-		} else if len(providerOptions) > 0 && providerOptions[0] != "请在配置文件中启用 LLM 提供商" {
-			fv.providerSelects[i].SetSelected(providerOptions[0])
-		}
-		
-		// Messages container for this column
-		fv.columnContainers[i] = container.NewVBox()
-		fv.columnScrolls[i] = container.NewScroll(fv.columnContainers[i])
-		fv.columnScrolls[i].SetMinSize(fyne.NewSize(300, 400))
-		
-		// Column layout
-		column := container.NewBorder(
-			container.NewVBox(providerLabel, fv.providerSelects[i], widget.NewSeparator()),
-			nil,
-			nil,
-			nil,
-			fv.columnScrolls[i],
-		)
-		
-		columns = append(columns, column)
-	}
+	fv.sendButton = widget.NewButton("", func() {
+		fv.sendToAllColumns()
+	})
+
+	fv.addColumnButton = widget.NewButton("添加列 +", func() {
+		fv.addColumn()
+	})
+	fv.removeButton = widget.NewButton("移除列 −", func() {
+		fv.showRemoveColumnDialog()
+	})
 
 	// Create grid for columns
-	columnsContainer := container.NewGridWithColumns(fv.columnCount, columns...)
+	fv.columnsGrid = container.NewGridWithColumns(fv.initialColumns)
+	for i := 0; i < fv.initialColumns; i++ {
+		fv.addColumn()
+	}
 
 	// Input area
 	fv.inputEntry = &customEntry{}
@@ -110,10 +156,6 @@ func (fv *ForkChatView) Build() fyne.CanvasObject {
 	}
 	fv.inputEntry.ExtendBaseWidget(fv.inputEntry)
 
-	fv.sendButton = widget.NewButton(fmt.Sprintf("发送到 %d 个模型", fv.columnCount), func() {
-		fv.sendToAllColumns()
-	})
-
 	inputContainer := container.NewBorder(
 		nil,
 		nil,
@@ -122,14 +164,156 @@ func (fv *ForkChatView) Build() fyne.CanvasObject {
 		fv.inputEntry,
 	)
 
+	// Benchmark toolbar
+	fv.benchmarkButton = widget.NewButton("📊 显示性能指标", func() {
+		fv.toggleBenchmark()
+	})
+	exportBenchmarkButton := widget.NewButton("导出 CSV", func() {
+		fv.exportBenchmarks()
+	})
+	exportComparisonButton := widget.NewButton("📊 导出对比", func() {
+		fv.exportComparison()
+	})
+	toolbar := container.NewHBox(
+		fv.benchmarkButton,
+		exportBenchmarkButton,
+		exportComparisonButton,
+		widget.NewSeparator(),
+		fv.addColumnButton,
+		fv.removeButton,
+	)
+
 	// Main layout
 	return container.NewBorder(
-		nil,
+		toolbar,
 		inputContainer,
 		nil,
 		nil,
-		columnsContainer,
+		fv.columnsGrid,
+	)
+}
+
+// addColumn appends a column with a provider select and an empty message
+// list. The existing columns keep their content.
+func (fv *ForkChatView) addColumn() {
+	if len(fv.providerSelects) >= forkMaxColumns {
+		return
+	}
+	i := len(fv.providerSelects)
+
+	// Provider selector for this column
+	providerLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+
+	var providerSelect *widget.Select
+	providerSelect = widget.NewSelect(fv.providerOptions, func(value string) {
+		fv.app.logger.Info("Column %d selected provider: %s", fv.columnIndex(providerSelect)+1, value)
+	})
+
+	// Set default selection
+	if len(fv.providerOptions) > i && fv.providerOptions[i] != noProviderOption {
+		providerSelect.SetSelected(fv.providerOptions[i])
+	} else if len(fv.providerOptions) > 0 && fv.providerOptions[0] != noProviderOption {
+		providerSelect.SetSelected(fv.providerOptions[0])
+	}
+
+	// Messages container for this column
+	messages := container.NewVBox()
+	scroll := container.NewScroll(messages)
+	scroll.SetMinSize(fyne.NewSize(300, 400))
+
+	// Column layout
+	column := container.NewBorder(
+		container.NewVBox(providerLabel, providerSelect, widget.NewSeparator()),
+		nil,
+		nil,
+		nil,
+		scroll,
 	)
+
+	fv.providerSelects = append(fv.providerSelects, providerSelect)
+	fv.columnLabels = append(fv.columnLabels, providerLabel)
+	fv.columnContainers = append(fv.columnContainers, messages)
+	fv.columnScrolls = append(fv.columnScrolls, scroll)
+	fv.columns = append(fv.columns, column)
+	fv.updateColumns()
+}
+
+// removeColumn removes the column at index and its messages
+func (fv *ForkChatView) removeColumn(index int) {
+	if len(fv.providerSelects) <= forkMinColumns || index < 0 || index >= len(fv.providerSelects) {
+		return
+	}
+
+	fv.providerSelects = slices.Delete(fv.providerSelects, index, index+1)
+	fv.columnLabels = slices.Delete(fv.columnLabels, index, index+1)
+	fv.columnContainers = slices.Delete(fv.columnContainers, index, index+1)
+	fv.columnScrolls = slices.Delete(fv.columnScrolls, index, index+1)
+	fv.columns = slices.Delete(fv.columns, index, index+1)
+	fv.updateColumns()
+}
+
+// updateColumns lays the columns out in a grid of matching width and
+// renumbers them
+func (fv *ForkChatView) updateColumns() {
+	count := len(fv.columns)
+	for i, label := range fv.columnLabels {
+		label.SetText(fmt.Sprintf("模型 %d:", i+1))
+	}
+
+	fv.columnsGrid.Layout = layout.NewGridLayoutWithColumns(count)
+	fv.columnsGrid.Objects = slices.Clone(fv.columns)
+	fv.columnsGrid.Refresh()
+
+	fv.sendButton.SetText(fmt.Sprintf("发送到 %d 个模型", count))
+	if count >= forkMaxColumns {
+		fv.addColumnButton.Disable()
+	} else {
+		fv.addColumnButton.Enable()
+	}
+	if count <= forkMinColumns {
+		fv.removeButton.Disable()
+	} else {
+		fv.removeButton.Enable()
+	}
+}
+
+// columnIndex returns the current position of the column of a select
+func (fv *ForkChatView) columnIndex(providerSelect *widget.Select) int {
+	return slices.Index(fv.providerSelects, providerSelect)
+}
+
+// showRemoveColumnDialog asks which column to remove
+func (fv *ForkChatView) showRemoveColumnDialog() {
+	if len(fv.providerSelects) <= forkMinColumns {
+		return
+	}
+
+	options := make([]string, len(fv.providerSelects))
+	for i, providerSelect := range fv.providerSelects {
+		options[i] = fmt.Sprintf("模型 %d: %s", i+1, providerSelect.Selected)
+	}
+	columnSelect := widget.NewSelect(options, nil)
+	columnSelect.SetSelectedIndex(len(options) - 1)
+
+	var dialog *widget.PopUp
+	dialog = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel("移除列"),
+			widget.NewLabel("选择要移除的模型列，其中的回复将不再显示:"),
+			columnSelect,
+			container.NewHBox(
+				widget.NewButton("取消", func() {
+					dialog.Hide()
+				}),
+				widget.NewButton("移除", func() {
+					dialog.Hide()
+					fv.removeColumn(columnSelect.SelectedIndex())
+				}),
+			),
+		),
+		fv.app.window.Canvas(),
+	)
+	dialog.Show()
 }
 
 // SetConversation sets the current conversation
@@ -142,9 +326,9 @@ func (fv *ForkChatView) SetConversation(conversationID int64) {
 func (fv *ForkChatView) loadMessages() {
 	if fv.conversationID == 0 {
 		fyne.Do(func() {
-			for i := 0; i < fv.columnCount; i++ {
-				fv.columnContainers[i].Objects = []fyne.CanvasObject{}
-				fv.columnContainers[i].Refresh()
+			for _, column := range fv.columnContainers {
+				column.Objects = []fyne.CanvasObject{}
+				column.Refresh()
 			}
 		})
 		return
@@ -158,19 +342,19 @@ func (fv *ForkChatView) loadMessages() {
 
 	fyne.Do(func() {
 		// Clear all columns
-		for i := 0; i < fv.columnCount; i++ {
-			fv.columnContainers[i].Objects = []fyne.CanvasObject{}
+		for _, column := range fv.columnContainers {
+			column.Objects = []fyne.CanvasObject{}
 		}
 		
 		// Add messages to all columns
 		for _, msg := range messages {
-			for i := 0; i < fv.columnCount; i++ {
+			for i := range fv.columnContainers {
 				fv.addMessageToColumn(i, msg.Role, msg.Content, msg.Model)
 			}
 		}
 		
-		for i := 0; i < fv.columnCount; i++ {
-			fv.columnContainers[i].Refresh()
+		for _, column := range fv.columnContainers {
+			column.Refresh()
 		}
 	})
 }
@@ -228,7 +412,7 @@ func (fv *ForkChatView) sendToAllColumns() {
 	}
 
 	// Add user message to all columns
-	for i := 0; i < fv.columnCount; i++ {
+	for i := range fv.columnContainers {
 		fv.addMessageToColumn(i, "user", content, "")
 		fv.columnContainers[i].Refresh()
 	}
@@ -250,33 +434,152 @@ func (fv *ForkChatView) sendToAllColumns() {
 	}
 
 	// Send to all columns concurrently
+	fv.round++
+	var results []*forkBenchmark
 	var wg sync.WaitGroup
-	for i := 0; i < fv.columnCount; i++ {
+	for i := 0; i < len(fv.providerSelects); i++ {
 		providerName := fv.providerSelects[i].Selected
-		if providerName == "" || providerName == "请在配置文件中启用 LLM 提供商" {
+		if providerName == "" || providerName == noProviderOption {
 			continue
 		}
-		
+
+		result := &forkBenchmark{Round: fv.round, Column: i + 1, Provider: providerName}
+		results = append(results, result)
+
 		wg.Add(1)
-		go fv.sendToColumn(i, providerName, llmMessages, &wg)
+		go fv.sendToColumn(i, fv.columnContainers[i], providerName, llmMessages, &wg, result)
 	}
 
-	// Wait for all to complete
+	// Wait for all to complete, then show the benchmark rows
 	utils.SafeGo(fv.app.logger, "fork wait for all columns", func() {
 		wg.Wait()
 		fv.app.logger.Info("Fork conversation completed for all columns")
+
+		fyne.Do(func() {
+			for _, result := range results {
+				fv.benchmarks = append(fv.benchmarks, result)
+				if result.response == nil {
+					continue
+				}
+				row := widget.NewLabelWithStyle(result.String(), fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+				if !fv.benchmarkVisible {
+					row.Hide()
+				}
+				fv.benchmarkRows = append(fv.benchmarkRows, row)
+				// Insert above the trailing separator of the response
+				objects := result.response.Objects
+				result.response.Objects = append(objects[:len(objects)-1:len(objects)-1], row, objects[len(objects)-1])
+				result.response.Refresh()
+			}
+		})
 	})
 }
 
-// sendToColumn sends the message to a specific column's provider
-func (fv *ForkChatView) sendToColumn(columnIdx int, providerName string, messages []llm.Message, wg *sync.WaitGroup) {
-	defer wg.Done()
+// toggleBenchmark shows or hides the metrics rows below the responses
+func (fv *ForkChatView) toggleBenchmark() {
+	fv.benchmarkVisible = !fv.benchmarkVisible
+	for _, row := range fv.benchmarkRows {
+		if fv.benchmarkVisible {
+			row.Show()
+		} else {
+			row.Hide()
+		}
+	}
+	if fv.benchmarkVisible {
+		fv.benchmarkButton.SetText("📊 隐藏性能指标")
+	} else {
+		fv.benchmarkButton.SetText("📊 显示性能指标")
+	}
+}
+
+// exportBenchmarks exports the session's benchmark results to a CSV file
+func (fv *ForkChatView) exportBenchmarks() {
+	if len(fv.benchmarks) == 0 {
+		fv.app.showInfo("暂无性能数据，请先发送消息")
+		return
+	}
+
+	exportDir, err := utils.GetDefaultExportPath(fv.app.config.RecentFiles)
+	if err != nil {
+		fv.app.showError("Failed to get export directory: " + err.Error())
+		return
+	}
+
+	headers := []string{"round", "column", "provider", "model", "ttft_ms", "total_ms", "tokens", "tokens_estimated", "tokens_per_second", "failed"}
+	rows := make([][]string, 0, len(fv.benchmarks))
+	for _, b := range fv.benchmarks {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", b.Round),
+			fmt.Sprintf("%d", b.Column),
+			b.Provider,
+			b.Model,
+			fmt.Sprintf("%d", b.TTFT.Milliseconds()),
+			fmt.Sprintf("%d", b.Total.Milliseconds()),
+			fmt.Sprintf("%d", b.Tokens),
+			fmt.Sprintf("%t", b.Estimated),
+			fmt.Sprintf("%.2f", b.TokensPerSecond()),
+			fmt.Sprintf("%t", b.Failed),
+		})
+	}
+
+	filepath := exportDir + "/" + utils.GenerateExportFilename("fork_benchmark", utils.FormatCSV)
+	if err := utils.ExportToCSV(filepath, headers, rows); err != nil {
+		fv.app.showError("Export failed: " + err.Error())
+		return
+	}
+
+	fv.app.logger.Info("Exported %d benchmark results to %s", len(rows), filepath)
+	fv.app.addRecentFile(filepath)
+	fv.app.showInfo("导出成功!\n文件保存在: " + filepath)
+}
+
+// exportComparison exports the session's responses side by side to an HTML
+// file
+func (fv *ForkChatView) exportComparison() {
+	if fv.conversationID == 0 {
+		fv.app.showInfo("暂无对话，请先发送消息")
+		return
+	}
+
+	exportDir, err := utils.GetDefaultExportPath(fv.app.config.RecentFiles)
+	if err != nil {
+		fv.app.showError("Failed to get export directory: " + err.Error())
+		return
+	}
+
+	filepath, err := NewModelComparisonReport(fv).Export(exportDir)
+	if err != nil {
+		fv.app.logger.Error("Failed to export fork comparison: %v", err)
+		fv.app.showError("Export failed: " + err.Error())
+		return
+	}
+
+	fv.app.logger.Info("Exported fork comparison to %s", filepath)
+	fv.app.addRecentFile(filepath)
+	fv.app.showInfo("导出成功!\n文件保存在: " + filepath)
+}
+
+// columnModel returns the model saved with a column's responses, like
+// ChatView.selectedModel does for the provider's default model
+func (fv *ForkChatView) columnModel(providerName string) string {
+	if defaultModel := fv.app.config.LLMProviders[providerName].DefaultModel; defaultModel != "" {
+		return defaultModel
+	}
+	return providerName
+}
 
+// sendToColumn sends the message to a specific column's provider. The
+// response goes to the column's message list, which stays valid if the
+// column is removed while streaming.
+func (fv *ForkChatView) sendToColumn(columnIdx int, column *fyne.Container, providerName string, messages []llm.Message, wg *sync.WaitGroup, result *forkBenchmark) {
 	provider, ok := fv.app.providers[providerName]
 	if !ok {
 		fv.app.logger.Error("Provider not found: %s", providerName)
+		wg.Done()
 		return
 	}
+	result.Model = fv.columnModel(providerName)
+	systemPrompt := providerSystemPrompt(fv.app.config.LLMProviders[providerName], messages)
 
 	// Create placeholder for assistant response
 	assistantRichText := widget.NewRichText()
@@ -286,20 +589,28 @@ func (fv *ForkChatView) sendToColumn(columnIdx int, providerName string, message
 
 	assistantRichText.ParseMarkdown("*思考中...*")
 
+	responseBox := container.NewVBox(
+		assistantRoleLabel,
+		container.NewPadded(assistantRichText),
+		widget.NewSeparator(),
+	)
+	result.response = responseBox
+
 	fyne.Do(func() {
-		fv.columnContainers[columnIdx].Add(container.NewVBox(
-			assistantRoleLabel,
-			container.NewPadded(assistantRichText),
-			widget.NewSeparator(),
-		))
-		fv.columnContainers[columnIdx].Refresh()
+		column.Add(responseBox)
+		column.Refresh()
 	})
 
 	// Stream response
 	utils.SafeGo(fv.app.logger, fmt.Sprintf("fork column %d stream %s", columnIdx, providerName), func() {
+		defer wg.Done()
+
 		ctx := context.Background()
-		stream, err := provider.StreamChat(ctx, messages)
+		start := time.Now()
+		stream, err := provider.StreamChatWithSystemPrompt(ctx, systemPrompt, messages)
 		if err != nil {
+			result.Failed = true
+			result.Total = time.Since(start)
 			fv.app.logger.Error("Failed to start chat with %s: %v", providerName, err)
 			errorMsg := "**错误**: " + err.Error()
 			fyne.Do(func() {
@@ -311,6 +622,8 @@ func (fv *ForkChatView) sendToColumn(columnIdx int, providerName string, message
 		var fullResponse strings.Builder
 		for chunk := range stream {
 			if chunk.Error != nil {
+				result.Failed = true
+				result.Total = time.Since(start)
 				fv.app.logger.Error("Stream error from %s: %v", providerName, chunk.Error)
 				errorMsg := "**错误**: " + chunk.Error.Error()
 				fyne.Do(func() {
@@ -320,6 +633,9 @@ func (fv *ForkChatView) sendToColumn(columnIdx int, providerName string, message
 			}
 
 			if chunk.Content != "" {
+				if result.TTFT == 0 {
+					result.TTFT = time.Since(start)
+				}
 				fullResponse.WriteString(chunk.Content)
 				content := fullResponse.String()
 				fyne.Do(func() {
@@ -328,22 +644,38 @@ func (fv *ForkChatView) sendToColumn(columnIdx int, providerName string, message
 			}
 
 			if chunk.Done {
+				result.Total = time.Since(start)
+				tokensUsed := 0
+				if chunk.Usage != nil {
+					tokensUsed = chunk.Usage.TotalTokens
+				}
+				if chunk.Usage != nil && chunk.Usage.CompletionTokens > 0 {
+					result.Tokens = chunk.Usage.CompletionTokens
+				} else {
+					// Rough estimate: ~4 characters per token
+					result.Tokens = (utf8.RuneCountInString(fullResponse.String()) + 3) / 4
+					result.Estimated = true
+				}
+
 				// Save the assistant response for this column
 				response := fullResponse.String()
 				if response != "" {
-					_, err := fv.app.db.CreateMessage(
+					msg, err := fv.app.db.CreateMessage(
 						fv.conversationID,
 						"assistant",
 						response,
 						providerName,
-						provider.Name(),
+						result.Model,
 						"",
-						0,
+						tokensUsed,
 					)
 					if err != nil {
 						fv.app.logger.Error("Failed to save assistant message for %s: %v", providerName, err)
 					} else {
 						fv.app.logger.Info("Saved assistant response for column %d (%s)", columnIdx+1, providerName)
+						if err := fv.app.db.UpdateMessageMetadata(msg.ID, utils.EncodeMessageMetadata(utils.WithResponseMetrics(chunk.Metadata, result.Metrics()))); err != nil {
+							fv.app.logger.Error("Failed to save metrics for %s: %v", providerName, err)
+						}
 					}
 				}
 				break
@@ -360,40 +692,55 @@ func min(a, b int) int {
 	return b
 }
 
-// ShowForkDialog creates a fork chat view in a new tab
+// ShowForkDialog asks up to which message to fork the conversation, copies
+// those messages into a new conversation and opens it in a regular chat tab
 func ShowForkDialog(app *App, currentConversationID int64) {
-	// Get the last user message from current conversation
 	messages, err := app.db.ListMessages(currentConversationID)
 	if err != nil || len(messages) == 0 {
 		app.showError("无法获取对话消息")
 		return
 	}
 
-	// Find the last user message
-	var lastUserMessage string
-	for i := len(messages) - 1; i >= 0; i-- {
-		if messages[i].Role == "user" {
-			lastUserMessage = messages[i].Content
-			break
+	// One option per message, the index is its position in the list
+	options := make([]string, len(messages))
+	for i, msg := range messages {
+		role := "🤖"
+		if msg.Role == "user" {
+			role = "👤"
 		}
+		preview := strings.Join(strings.Fields(msg.Content), " ")
+		options[i] = fmt.Sprintf("%d. %s %s", i+1, role, truncateRunes(preview, 40))
 	}
 
-	if lastUserMessage == "" {
-		app.showError("未找到用户消息")
-		return
-	}
-
-	// Create a new fork chat view with 2 columns by default
-	forkView := NewForkChatView(app, 2)
-	forkContent := forkView.Build()
-
-	// Pre-fill the input with the last user message
-	forkView.inputEntry.SetText(lastUserMessage)
-
-	// Create tab with close button
-	app.forkTabItem = app.tabs.Append("🔀 分叉对话", forkContent, func() {
-		app.closeForkTab()
-	})
-	
-	app.logger.Info("Created fork conversation tab")
+	messageSelect := widget.NewSelect(options, nil)
+	messageSelect.SetSelectedIndex(len(options) - 1)
+
+	var dialog *widget.PopUp
+	dialog = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel("🔀 分叉对话"),
+			widget.NewLabel("复制到以下消息为止（含）:"),
+			messageSelect,
+			container.NewHBox(
+				widget.NewButton("取消", func() {
+					dialog.Hide()
+				}),
+				widget.NewButton("分叉", func() {
+					fork, err := app.db.ForkConversation(currentConversationID, messageSelect.SelectedIndex())
+					if err != nil {
+						app.logger.Error("Failed to fork conversation %d: %v", currentConversationID, err)
+						app.showError("分叉对话失败: " + err.Error())
+						return
+					}
+					dialog.Hide()
+
+					app.logger.Info("Forked conversation %d into %d", currentConversationID, fork.ID)
+					app.RefreshSidebar()
+					app.openChatTab(fork.ID)
+				}),
+			),
+		),
+		app.window.Canvas(),
+	)
+	dialog.Show()
 }
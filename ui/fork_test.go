@@ -0,0 +1,134 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"os"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestForkChatView_AddRemoveColumns(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+
+	fv := NewForkChatView(a, 2)
+	fv.Build()
+
+	fv.addMessageToColumn(0, "user", "first column", "")
+	fv.addMessageToColumn(1, "user", "second column", "")
+	first, second := fv.columnContainers[0], fv.columnContainers[1]
+
+	fv.addColumn()
+	fv.addColumn()
+	if len(fv.providerSelects) != 4 || len(fv.columnsGrid.Objects) != 4 {
+		t.Fatalf("expected 4 columns, got %d selects and %d grid children", len(fv.providerSelects), len(fv.columnsGrid.Objects))
+	}
+	if len(first.Objects) != 1 || len(fv.columnContainers[2].Objects) != 0 {
+		t.Error("existing columns should keep their content and new ones start empty")
+	}
+	if !fv.addColumnButton.Disabled() {
+		t.Error("add button should be disabled at the maximum")
+	}
+	fv.addColumn()
+	if len(fv.providerSelects) != 4 {
+		t.Errorf("expected at most 4 columns, got %d", len(fv.providerSelects))
+	}
+	if fv.sendButton.Text != "发送到 4 个模型" {
+		t.Errorf("unexpected send button text %q", fv.sendButton.Text)
+	}
+
+	fv.removeColumn(0)
+	fv.removeColumn(1)
+	if len(fv.providerSelects) != 2 || len(fv.columnsGrid.Objects) != 2 {
+		t.Fatalf("expected 2 columns, got %d selects and %d grid children", len(fv.providerSelects), len(fv.columnsGrid.Objects))
+	}
+	if fv.columnContainers[0] != second {
+		t.Error("expected the second column to move to the front")
+	}
+	if fv.columnLabels[0].Text != "模型 1:" || fv.columnLabels[1].Text != "模型 2:" {
+		t.Errorf("columns not renumbered: %q, %q", fv.columnLabels[0].Text, fv.columnLabels[1].Text)
+	}
+	if !fv.removeButton.Disabled() {
+		t.Error("remove button should be disabled at the minimum")
+	}
+	fv.removeColumn(0)
+	if len(fv.providerSelects) != 2 {
+		t.Errorf("expected at least 2 columns, got %d", len(fv.providerSelects))
+	}
+}
+
+func TestForkChatView_ExportComparison(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{Responses: []string{"```go\nfunc main() {}\n```", "second answer"}}))
+
+	fv := NewForkChatView(a, 2)
+	fv.Build()
+	fv.inputEntry.SetText("compare this")
+	fv.sendToAllColumns()
+
+	messages := waitForMessages(t, a, fv.conversationID, 3)
+	waitUntil(t, "the metrics of both responses", func() bool {
+		for _, msg := range messages[1:] {
+			updated, err := a.db.GetMessageByID(msg.ID)
+			if err != nil || updated.Metadata == "" {
+				return false
+			}
+		}
+		return true
+	})
+	updated, _ := a.db.GetMessageByID(messages[1].ID)
+	if _, ok := utils.ParseResponseMetrics(updated.Metadata); !ok {
+		t.Errorf("response metadata has no metrics: %q", updated.Metadata)
+	}
+
+	report := NewModelComparisonReport(fv)
+	if len(report.columnProviders) != 2 || report.columnProviders[0] != "mock" {
+		t.Fatalf("columnProviders = %v, want mock twice", report.columnProviders)
+	}
+	path, err := report.Export(t.TempDir())
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the report: %v", err)
+	}
+	page := string(data)
+	for _, want := range []string{"compare this", "second answer", `<span class="kw">func</span>`, "Avg TTFT"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+}
+
+func TestForkChatView_BenchmarkRecordsModel(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{Responses: []string{"answer"}}))
+	a.config.LLMProviders = map[string]utils.ProviderConfig{"mock": {Enabled: true, DefaultModel: "mock-large"}}
+
+	fv := NewForkChatView(a, 2)
+	fv.Build()
+	fv.inputEntry.SetText("which model?")
+	fv.sendToAllColumns()
+
+	messages := waitForMessages(t, a, fv.conversationID, 3)
+	for _, msg := range messages[1:] {
+		if msg.Model != "mock-large" {
+			t.Errorf("saved model = %q, want mock-large", msg.Model)
+		}
+	}
+	waitUntil(t, "the benchmarks of both columns", func() bool {
+		var done int
+		fyne.DoAndWait(func() { done = len(fv.benchmarks) })
+		return done == 2
+	})
+	fyne.DoAndWait(func() {
+		for _, b := range fv.benchmarks {
+			if b.Model != "mock-large" {
+				t.Errorf("benchmark model = %q, want mock-large", b.Model)
+			}
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"net/url"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// helpDocsURL is the full documentation linked from the help overlay
+const helpDocsURL = "https://github.com/Ubastic/light-llm-client/wiki"
+
+// helpScrollStep is how far the arrow keys scroll the help overlay
+const helpScrollStep = 40
+
+// fixedShortcuts are shortcuts of the chat input and the search bar, which
+// can't be rebound
+var fixedShortcuts = []struct {
+	section, keys, description string
+}{
+	{sectionChat, "Ctrl+Enter", "发送消息"},
+	{sectionChat, "Alt+1…9", "插入对应的快捷提示词"},
+	{sectionChat, "Enter / Shift+Enter", "对话内搜索时跳到下一个 / 上一个匹配，Esc 关闭"},
+	{sectionChat, "Ctrl+V", "粘贴截图或复制的文件作为附件"},
+	{sectionChat, "Ctrl+Z / Ctrl+Y", "撤销上一次发送（删除提问和回复，问题放回输入框）/ 重做"},
+}
+
+// featureHints are shown below the shortcuts
+var featureHints = []string{
+	"📎 拖放：把文件拖到窗口上即可添加为当前对话的附件。",
+	"🔒 匿名化：在设置的隐私页开启后，发送前会把 API Key、URL、邮箱等替换为占位符，回复中的占位符会自动还原。",
+	"🔀 分叉：从任意一条消息分叉出新对话，或在对比视图中把同一问题同时发给多个模型并排比较。",
+}
+
+// ContextualHelpOverlay lists the keyboard shortcuts, taken from
+// Config.Keybindings, and feature hints over the main window. The arrow and
+// page keys scroll it, Escape closes it.
+type ContextualHelpOverlay struct {
+	widget.BaseWidget
+	app    *App
+	scroll *container.Scroll
+	popup  *widget.PopUp
+}
+
+// NewContextualHelpOverlay creates the help overlay of the main window
+func NewContextualHelpOverlay(a *App) *ContextualHelpOverlay {
+	o := &ContextualHelpOverlay{app: a}
+	o.ExtendBaseWidget(o)
+	return o
+}
+
+// CreateRenderer implements fyne.Widget
+func (o *ContextualHelpOverlay) CreateRenderer() fyne.WidgetRenderer {
+	title := widget.NewLabelWithStyle("⌨️ 快捷键与功能提示", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+
+	content := container.NewVBox()
+	for _, section := range []string{sectionChat, sectionSidebar, sectionTabs} {
+		content.Add(widget.NewLabelWithStyle(section, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		for _, sa := range o.app.shortcutActions() {
+			if sa.section == section {
+				content.Add(helpRow(shortcutLabel(o.app.config.Keybinding(sa.action)), sa.description))
+			}
+		}
+		for _, fixed := range fixedShortcuts {
+			if fixed.section == section {
+				content.Add(helpRow(fixed.keys, fixed.description))
+			}
+		}
+		content.Add(widget.NewSeparator())
+	}
+
+	content.Add(widget.NewLabelWithStyle("功能提示", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	for _, hint := range featureHints {
+		label := widget.NewLabel(hint)
+		label.Wrapping = fyne.TextWrapWord
+		content.Add(label)
+	}
+	o.scroll = container.NewVScroll(content)
+
+	docsURL, _ := url.Parse(helpDocsURL)
+	closeButton := widget.NewButton("关闭 (Esc)", o.Hide)
+	footer := container.NewBorder(nil, nil, widget.NewHyperlink("📖 打开完整文档", docsURL), closeButton)
+
+	return widget.NewSimpleRenderer(container.NewBorder(
+		container.NewVBox(title, widget.NewSeparator()),
+		container.NewVBox(widget.NewSeparator(), footer),
+		nil, nil,
+		o.scroll,
+	))
+}
+
+// helpRow shows the keys on the left and what they do on the right
+func helpRow(keys, description string) fyne.CanvasObject {
+	keysLabel := widget.NewLabelWithStyle(keys, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	descriptionLabel := widget.NewLabel(description)
+	descriptionLabel.Wrapping = fyne.TextWrapWord
+	return container.NewGridWithColumns(2, keysLabel, descriptionLabel)
+}
+
+// Show shows the overlay over the main window and focuses it for the keys
+func (o *ContextualHelpOverlay) Show() {
+	c := o.app.window.Canvas()
+	if o.popup == nil {
+		o.popup = widget.NewModalPopUp(o, c)
+	}
+	size := c.Size()
+	width, height := size.Width-80, size.Height-80
+	if width > 640 {
+		width = 640
+	}
+	o.popup.Resize(fyne.NewSize(width, height))
+	o.popup.Show()
+	c.Focus(o)
+}
+
+// Hide closes the overlay
+func (o *ContextualHelpOverlay) Hide() {
+	if o.popup != nil {
+		o.popup.Hide()
+	}
+}
+
+// FocusGained implements fyne.Focusable
+func (o *ContextualHelpOverlay) FocusGained() {}
+
+// FocusLost implements fyne.Focusable
+func (o *ContextualHelpOverlay) FocusLost() {}
+
+// TypedRune implements fyne.Focusable
+func (o *ContextualHelpOverlay) TypedRune(rune) {}
+
+// TypedKey scrolls the overlay and closes it on Escape
+func (o *ContextualHelpOverlay) TypedKey(key *fyne.KeyEvent) {
+	if o.scroll == nil {
+		return
+	}
+	offset := o.scroll.Offset.Y
+	page := o.scroll.Size().Height - helpScrollStep
+	switch key.Name {
+	case fyne.KeyEscape:
+		o.Hide()
+		return
+	case fyne.KeyDown:
+		offset += helpScrollStep
+	case fyne.KeyUp:
+		offset -= helpScrollStep
+	case fyne.KeyPageDown, fyne.KeySpace:
+		offset += page
+	case fyne.KeyPageUp:
+		offset -= page
+	case fyne.KeyHome:
+		offset = 0
+	case fyne.KeyEnd:
+		offset = o.scroll.Content.MinSize().Height
+	default:
+		return
+	}
+	o.scroll.ScrollToOffset(fyne.NewPos(0, offset))
+}
+
+// showHelp shows the keyboard shortcuts and feature hints
+func (a *App) showHelp() {
+	NewContextualHelpOverlay(a).Show()
+}
@@ -0,0 +1,97 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestParseShortcut(t *testing.T) {
+	tests := []struct {
+		keys     string
+		key      fyne.KeyName
+		modifier fyne.KeyModifier
+		label    string
+	}{
+		{"ctrl+n", fyne.KeyN, fyne.KeyModifierControl, "Ctrl+N"},
+		{"Ctrl+Shift+Tab", fyne.KeyTab, fyne.KeyModifierControl | fyne.KeyModifierShift, "Ctrl+Shift+Tab"},
+		{"ctrl+?", fyne.KeySlash, fyne.KeyModifierControl | fyne.KeyModifierShift, "Ctrl+?"},
+		{"ctrl+,", fyne.KeyComma, fyne.KeyModifierControl, "Ctrl+,"},
+		{"alt+f5", fyne.KeyF5, fyne.KeyModifierAlt, "Alt+F5"},
+		{"ctrl++", fyne.KeyEqual, fyne.KeyModifierControl | fyne.KeyModifierShift, "Ctrl++"},
+	}
+	for _, tt := range tests {
+		shortcut, err := parseShortcut(tt.keys)
+		if err != nil {
+			t.Errorf("parseShortcut(%q) failed: %v", tt.keys, err)
+			continue
+		}
+		if shortcut.KeyName != tt.key || shortcut.Modifier != tt.modifier {
+			t.Errorf("parseShortcut(%q) = %s %d, want %s %d", tt.keys, shortcut.KeyName, shortcut.Modifier, tt.key, tt.modifier)
+		}
+		if got := shortcutLabel(tt.keys); got != tt.label {
+			t.Errorf("shortcutLabel(%q) = %q, want %q", tt.keys, got, tt.label)
+		}
+	}
+
+	for _, keys := range []string{"", "n", "ctrl+", "hyper+n", "ctrl+f13", "ctrl+ü"} {
+		if _, err := parseShortcut(keys); err == nil {
+			t.Errorf("parseShortcut(%q) succeeded, want an error", keys)
+		}
+	}
+}
+
+func TestApp_ShowHelp(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	a.window.Resize(fyne.NewSize(800, 400))
+	a.config.Keybindings = map[string]string{utils.ActionNewConversation: "ctrl+t"}
+
+	a.showHelp()
+	overlay, ok := a.window.Canvas().Focused().(*ContextualHelpOverlay)
+	if !ok {
+		t.Fatalf("expected the help overlay to be focused, got %T", a.window.Canvas().Focused())
+	}
+	top := a.window.Canvas().Overlays().Top()
+	if top == nil {
+		t.Fatal("expected the help overlay to be shown")
+	}
+
+	hasLabel := func(text string) bool {
+		return findObject(top, func(o fyne.CanvasObject) bool {
+			l, ok := o.(*widget.Label)
+			return ok && l.Text == text
+		}) != nil
+	}
+	for _, text := range []string{"聊天快捷键", "侧边栏快捷键", "标签页快捷键", "功能提示", "Ctrl+T", "Ctrl+?", "Ctrl+Enter"} {
+		if !hasLabel(text) {
+			t.Errorf("help overlay does not show %q", text)
+		}
+	}
+	if hasLabel("Ctrl+N") {
+		t.Error("expected the configured keybinding to replace Ctrl+N")
+	}
+	if findObject(top, func(o fyne.CanvasObject) bool {
+		h, ok := o.(*widget.Hyperlink)
+		return ok && h.URL.String() == helpDocsURL
+	}) == nil {
+		t.Error("expected a link to the docs")
+	}
+
+	overlay.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDown})
+	if overlay.scroll.Offset.Y <= 0 {
+		t.Error("expected the down arrow to scroll")
+	}
+	overlay.TypedKey(&fyne.KeyEvent{Name: fyne.KeyHome})
+	if overlay.scroll.Offset.Y != 0 {
+		t.Error("expected Home to scroll to the top")
+	}
+	overlay.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEscape})
+	if a.window.Canvas().Overlays().Top() != nil {
+		t.Error("expected Escape to close the help overlay")
+	}
+}
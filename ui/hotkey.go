@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+
+	"fyne.io/fyne/v2"
+)
+
+// SetupGlobalHotkey registers a system-wide hotkey such as "ctrl+alt+l" that
+// runs action even when the window is hidden in the tray. It replaces the
+// previously registered hotkey; empty keys only unregister it. action runs
+// outside the UI goroutine.
+func (a *App) SetupGlobalHotkey(keys string, action func()) error {
+	// Parse first so an invalid value keeps the current hotkey working
+	var hotkey utils.Hotkey
+	if keys != "" {
+		var err error
+		if hotkey, err = utils.ParseHotkey(keys); err != nil {
+			return err
+		}
+	}
+
+	a.removeGlobalHotkey()
+	if keys == "" {
+		return nil
+	}
+
+	unregister, err := registerGlobalHotkey(hotkey, action)
+	if err != nil {
+		return fmt.Errorf("failed to register global hotkey %s: %w", hotkey, err)
+	}
+	a.unregisterHotkey = unregister
+	a.logger.Info("Global hotkey registered: %s", hotkey)
+	return nil
+}
+
+// removeGlobalHotkey unregisters the global hotkey, if any
+func (a *App) removeGlobalHotkey() {
+	if a.unregisterHotkey == nil {
+		return
+	}
+	a.unregisterHotkey()
+	a.unregisterHotkey = nil
+}
+
+// ShowWindow brings the main window to the front, e.g. from the tray or the
+// global hotkey. Safe to call from any goroutine.
+func (a *App) ShowWindow() {
+	fyne.Do(func() {
+		a.window.Show()
+		a.window.RequestFocus()
+	})
+}
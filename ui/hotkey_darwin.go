@@ -0,0 +1,18 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package ui
+
+import "golang.design/x/hotkey"
+
+// Option and Cmd take the place of Alt and Super
+const (
+	hotkeyModAlt   = hotkey.ModOption
+	hotkeyModSuper = hotkey.ModCmd
+)
+
+// globalHotkeySupported reports whether global hotkeys can be registered.
+// macOS asks for the Accessibility permission on the first registration.
+func globalHotkeySupported() bool {
+	return true
+}
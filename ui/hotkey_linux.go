@@ -0,0 +1,22 @@
+//go:build linux && cgo
+// +build linux,cgo
+
+package ui
+
+import (
+	"os"
+
+	"golang.design/x/hotkey"
+)
+
+// X11 modifiers of Alt and Super in the usual keyboard mapping
+const (
+	hotkeyModAlt   = hotkey.Mod1
+	hotkeyModSuper = hotkey.Mod4
+)
+
+// globalHotkeySupported reports whether there is an X server to grab the
+// hotkey from. Wayland has no global hotkeys, except through XWayland.
+func globalHotkeySupported() bool {
+	return os.Getenv("DISPLAY") != ""
+}
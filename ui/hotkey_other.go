@@ -0,0 +1,21 @@
+//go:build !windows && !((linux || darwin) && cgo)
+// +build !windows
+// +build !linux,!darwin !cgo
+
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"runtime"
+)
+
+// registerGlobalHotkey is not implemented on this platform
+func registerGlobalHotkey(hotkey utils.Hotkey, action func()) (func(), error) {
+	return nil, fmt.Errorf("global hotkeys are not supported on %s", runtime.GOOS)
+}
+
+// globalHotkeySupported reports whether global hotkeys can be registered
+func globalHotkeySupported() bool {
+	return false
+}
@@ -0,0 +1,80 @@
+//go:build (linux || darwin) && cgo
+// +build linux darwin
+// +build cgo
+
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+
+	"golang.design/x/hotkey"
+)
+
+// hotkeyKeys maps the key names of utils.Hotkey to key codes
+var hotkeyKeys = map[string]hotkey.Key{
+	"A": hotkey.KeyA, "B": hotkey.KeyB, "C": hotkey.KeyC, "D": hotkey.KeyD,
+	"E": hotkey.KeyE, "F": hotkey.KeyF, "G": hotkey.KeyG, "H": hotkey.KeyH,
+	"I": hotkey.KeyI, "J": hotkey.KeyJ, "K": hotkey.KeyK, "L": hotkey.KeyL,
+	"M": hotkey.KeyM, "N": hotkey.KeyN, "O": hotkey.KeyO, "P": hotkey.KeyP,
+	"Q": hotkey.KeyQ, "R": hotkey.KeyR, "S": hotkey.KeyS, "T": hotkey.KeyT,
+	"U": hotkey.KeyU, "V": hotkey.KeyV, "W": hotkey.KeyW, "X": hotkey.KeyX,
+	"Y": hotkey.KeyY, "Z": hotkey.KeyZ,
+	"0": hotkey.Key0, "1": hotkey.Key1, "2": hotkey.Key2, "3": hotkey.Key3,
+	"4": hotkey.Key4, "5": hotkey.Key5, "6": hotkey.Key6, "7": hotkey.Key7,
+	"8": hotkey.Key8, "9": hotkey.Key9,
+	"F1": hotkey.KeyF1, "F2": hotkey.KeyF2, "F3": hotkey.KeyF3, "F4": hotkey.KeyF4,
+	"F5": hotkey.KeyF5, "F6": hotkey.KeyF6, "F7": hotkey.KeyF7, "F8": hotkey.KeyF8,
+	"F9": hotkey.KeyF9, "F10": hotkey.KeyF10, "F11": hotkey.KeyF11, "F12": hotkey.KeyF12,
+
+	"Space": hotkey.KeySpace,
+}
+
+// registerGlobalHotkey registers the hotkey with golang.design/x/hotkey,
+// which grabs it from the X server on Linux and installs an event tap on
+// macOS. A goroutine runs action for each key press until unregistered.
+func registerGlobalHotkey(h utils.Hotkey, action func()) (func(), error) {
+	key, ok := hotkeyKeys[h.Key]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key %q", h.Key)
+	}
+
+	var modifiers []hotkey.Modifier
+	if h.Modifiers&utils.HotkeyCtrl != 0 {
+		modifiers = append(modifiers, hotkey.ModCtrl)
+	}
+	if h.Modifiers&utils.HotkeyAlt != 0 {
+		modifiers = append(modifiers, hotkeyModAlt)
+	}
+	if h.Modifiers&utils.HotkeyShift != 0 {
+		modifiers = append(modifiers, hotkey.ModShift)
+	}
+	if h.Modifiers&utils.HotkeySuper != 0 {
+		modifiers = append(modifiers, hotkeyModSuper)
+	}
+
+	hk := hotkey.New(modifiers, key)
+	if err := hk.Register(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-hk.Keydown():
+				action()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		hk.Unregister()
+	}, nil
+}
@@ -0,0 +1,141 @@
+//go:build windows
+// +build windows
+
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"runtime"
+	"unsafe"
+)
+
+var (
+	registerHotKey     = user32.NewProc("RegisterHotKey")
+	unregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	getMessage         = user32.NewProc("GetMessageW")
+	peekMessage        = user32.NewProc("PeekMessageW")
+	postThreadMessage  = user32.NewProc("PostThreadMessageW")
+	getCurrentThreadID = kernel32.NewProc("GetCurrentThreadId")
+)
+
+const (
+	MOD_ALT      = 0x0001
+	MOD_CONTROL  = 0x0002
+	MOD_SHIFT    = 0x0004
+	MOD_WIN      = 0x0008
+	MOD_NOREPEAT = 0x4000 // Holding the keys down does not fire again
+
+	WM_QUIT   = 0x0012
+	WM_HOTKEY = 0x0312
+	WM_USER   = 0x0400
+
+	PM_NOREMOVE = 0x0000
+
+	VK_SPACE = 0x20
+	VK_F1    = 0x70
+
+	globalHotkeyID = 1
+)
+
+// POINT structure
+type POINT struct {
+	X, Y int32
+}
+
+// MSG structure
+type MSG struct {
+	Hwnd    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      POINT
+	Private uint32
+}
+
+// registerGlobalHotkey registers the hotkey with RegisterHotKey. Hotkey
+// messages go to the registering thread, so a goroutine locked to its OS
+// thread registers it and runs a message loop until unregistered.
+func registerGlobalHotkey(hotkey utils.Hotkey, action func()) (func(), error) {
+	vk, err := virtualKeyCode(hotkey.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	modifiers := uintptr(MOD_NOREPEAT)
+	if hotkey.Modifiers&utils.HotkeyCtrl != 0 {
+		modifiers |= MOD_CONTROL
+	}
+	if hotkey.Modifiers&utils.HotkeyAlt != 0 {
+		modifiers |= MOD_ALT
+	}
+	if hotkey.Modifiers&utils.HotkeyShift != 0 {
+		modifiers |= MOD_SHIFT
+	}
+	if hotkey.Modifiers&utils.HotkeySuper != 0 {
+		modifiers |= MOD_WIN
+	}
+
+	registered := make(chan error, 1)
+	done := make(chan struct{})
+	var threadID uintptr
+
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(done)
+
+		// Make sure the thread has a message queue before WM_QUIT can be posted to it
+		var msg MSG
+		peekMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, WM_USER, WM_USER, PM_NOREMOVE)
+		threadID, _, _ = getCurrentThreadID.Call()
+
+		if ret, _, err := registerHotKey.Call(0, globalHotkeyID, modifiers, vk); ret == 0 {
+			registered <- fmt.Errorf("RegisterHotKey failed (is it used by another program?): %w", err)
+			return
+		}
+		defer unregisterHotKey.Call(0, globalHotkeyID)
+		registered <- nil
+
+		for {
+			// Returns 0 for WM_QUIT and -1 on error
+			ret, _, _ := getMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+			if int32(ret) <= 0 {
+				return
+			}
+			if msg.Message == WM_HOTKEY && msg.WParam == globalHotkeyID {
+				action()
+			}
+		}
+	}()
+
+	if err := <-registered; err != nil {
+		return nil, err
+	}
+	return func() {
+		postThreadMessage.Call(threadID, WM_QUIT, 0, 0)
+		<-done
+	}, nil
+}
+
+// globalHotkeySupported reports whether global hotkeys can be registered
+func globalHotkeySupported() bool {
+	return true
+}
+
+// virtualKeyCode maps a key name of utils.Hotkey to a Windows virtual-key code
+func virtualKeyCode(key string) (uintptr, error) {
+	if key == "Space" {
+		return VK_SPACE, nil
+	}
+	// Letters and digits use their upper-case ASCII code
+	if len(key) == 1 {
+		return uintptr(key[0]), nil
+	}
+	var n int
+	if _, err := fmt.Sscanf(key, "F%d", &n); err == nil && n >= 1 && n <= 12 {
+		return uintptr(VK_F1 + n - 1), nil
+	}
+	return 0, fmt.Errorf("unsupported key %q", key)
+}
@@ -0,0 +1,238 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"light-llm-client/db"
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// imagineCommand starts a message that generates an image instead of
+// chatting, e.g. "/imagine a red panda"
+const imagineCommand = "/imagine"
+
+// imageModel is the model saved with generated images
+const imageModel = "dall-e-3"
+
+// imageThumbnailSize is the size generated images are shown at in the chat
+const imageThumbnailSize = 256
+
+// imaginePrompt returns the prompt of an /imagine message
+func imaginePrompt(content string) (string, bool) {
+	rest, ok := strings.CutPrefix(content, imagineCommand)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\n') {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// newGenerateImageButton creates the button that generates an image from the
+// text in the input box
+func (cv *ChatView) newGenerateImageButton() *widget.Button {
+	return widget.NewButton("🎨 生成图片", func() {
+		content := strings.TrimSpace(cv.inputEntry.Text)
+		if prompt, ok := imaginePrompt(content); ok {
+			content = prompt
+		}
+		cv.generateImage(content)
+	})
+}
+
+// generateImage generates an image from prompt with the selected provider
+// and shows it as the response
+func (cv *ChatView) generateImage(prompt string) {
+	if cv.sendButton.Disabled() {
+		return
+	}
+	if prompt == "" {
+		cv.app.showError("请先在输入框中输入图片描述")
+		return
+	}
+	providerName := cv.currentProvider
+	provider, ok := cv.selectedProvider()
+	if !ok {
+		cv.app.showError("提供商未配置: " + providerName)
+		return
+	}
+	imageProvider, ok := llm.AsImageProvider(provider)
+	if !ok {
+		cv.app.showError(providerName + " 不支持生成图片")
+		return
+	}
+
+	if cv.conversationID == 0 {
+		conv, err := cv.app.db.CreateConversation("New Chat", "")
+		if err != nil {
+			cv.app.logger.Error("Failed to create conversation: %v", err)
+			cv.app.showError("Failed to create conversation: " + err.Error())
+			return
+		}
+		cv.conversationID = conv.ID
+		cv.app.RefreshSidebar()
+	}
+
+	content := imagineCommand + " " + prompt
+	message, err := cv.app.db.CreateMessage(cv.conversationID, "user", content, "", "", "", 0)
+	if err != nil {
+		cv.app.logger.Error("Failed to save user message: %v", err)
+		cv.app.showError("Failed to save user message: " + err.Error())
+		return
+	}
+	cv.addMessageToUI("user", content, "", -1)
+	cv.updateCacheAfterNewMessage(*message)
+	cv.inputEntry.SetText("")
+
+	// Only the anonymized prompt leaves the machine
+	sentPrompt := prompt
+	if cv.app.anonymizer.IsEnabled() {
+		sentPrompt = cv.app.anonymizer.Anonymize(prompt)
+		defer cv.app.anonymizer.Clear()
+	}
+
+	placeholder := container.NewVBox(
+		widget.NewLabelWithStyle("🤖 助手 ("+providerName+")", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewPadded(widget.NewLabelWithStyle("正在生成图片...", fyne.TextAlignLeading, fyne.TextStyle{Italic: true})),
+		widget.NewSeparator(),
+	)
+	cv.messagesContainer.Add(placeholder)
+	cv.messagesContainer.Refresh()
+
+	utils.SafeGo(cv.app.logger, "generateImage", func() {
+		ctx := cv.startStreaming()
+		defer cv.setStreaming(false)
+
+		data, mimeType, err := imageProvider.GenerateImage(ctx, sentPrompt, "")
+		if err != nil {
+			if ctx.Err() == nil {
+				cv.app.logger.Error("Failed to generate image: %v", err)
+			}
+			fyne.Do(func() {
+				cv.messagesContainer.Remove(placeholder)
+				cv.messagesContainer.Refresh()
+				if ctx.Err() == nil {
+					cv.app.showError("图片生成失败: " + err.Error())
+				}
+			})
+			return
+		}
+
+		assistantMsg, err := cv.saveGeneratedImage(prompt, data, mimeType, providerName)
+		if err != nil {
+			cv.app.logger.Error("Failed to save generated image: %v", err)
+			fyne.Do(func() {
+				cv.messagesContainer.Remove(placeholder)
+				cv.messagesContainer.Refresh()
+				cv.app.showError("保存图片失败: " + err.Error())
+			})
+			return
+		}
+		cv.addMessageToMessagesArray(*assistantMsg)
+		cv.updateCacheAfterNewMessage(*assistantMsg)
+
+		fyne.Do(func() {
+			messageUI := cv.buildMessageUI(assistantMsg, len(cv.messages)-1)
+			for i, obj := range cv.messagesContainer.Objects {
+				if obj == placeholder {
+					cv.messagesContainer.Objects[i] = messageUI
+				}
+			}
+			cv.messagesContainer.Refresh()
+			if cv.app.uiCache != nil {
+				cv.app.uiCache[cv.conversationID] = append([]fyne.CanvasObject{}, cv.messagesContainer.Objects...)
+			}
+		})
+	})
+}
+
+// saveGeneratedImage saves the response to an /imagine message, with the
+// image as its attachment
+func (cv *ChatView) saveGeneratedImage(prompt string, data []byte, mimeType, providerName string) (*db.Message, error) {
+	attachment := &llm.Attachment{
+		Type:     "image",
+		Filename: "image.png",
+		MimeType: mimeType,
+		Data:     data,
+	}
+	thumbnail, err := utils.NewFileUploadHandler().GenerateThumbnail(attachment, imageThumbnailSize, imageThumbnailSize)
+	if err != nil {
+		cv.app.logger.Warn("Failed to create thumbnail of generated image: %v", err)
+	} else {
+		attachment.ThumbnailData = thumbnail
+	}
+	attachments, err := json.Marshal([]*llm.Attachment{attachment})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal attachments: %w", err)
+	}
+	return cv.app.db.CreateMessage(cv.conversationID, "assistant", "🎨 "+prompt, providerName, imageModel, string(attachments), 0)
+}
+
+// messageImages returns the image attachments of a message
+func (cv *ChatView) messageImages(msg *db.Message) []*llm.Attachment {
+	if msg.Attachments == "" {
+		return nil
+	}
+	var attachments []*llm.Attachment
+	if err := json.Unmarshal([]byte(msg.Attachments), &attachments); err != nil {
+		cv.app.logger.Warn("Failed to parse attachments for message %d: %v", msg.ID, err)
+		return nil
+	}
+	var images []*llm.Attachment
+	for _, att := range attachments {
+		if att.Type == "image" {
+			images = append(images, att)
+		}
+	}
+	return images
+}
+
+// newImageThumbnails shows images as thumbnails that open the full image
+// when tapped
+func (cv *ChatView) newImageThumbnails(images []*llm.Attachment) fyne.CanvasObject {
+	box := container.NewHBox()
+	for _, att := range images {
+		box.Add(newImageThumbnail(att, func() {
+			showAttachmentPreview(cv.app, att)
+		}))
+	}
+	return box
+}
+
+// imageThumbnail is a tappable thumbnail of an image attachment
+type imageThumbnail struct {
+	widget.BaseWidget
+	image    *canvas.Image
+	onTapped func()
+}
+
+// newImageThumbnail creates the thumbnail of an image attachment
+func newImageThumbnail(att *llm.Attachment, onTapped func()) *imageThumbnail {
+	data := att.ThumbnailData
+	if len(data) == 0 {
+		data = att.Data
+	}
+	img := canvas.NewImageFromResource(fyne.NewStaticResource(att.Filename, data))
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(fyne.NewSize(imageThumbnailSize, imageThumbnailSize))
+	t := &imageThumbnail{image: img, onTapped: onTapped}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+// CreateRenderer implements fyne.Widget
+func (t *imageThumbnail) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(t.image)
+}
+
+// Tapped opens the full image
+func (t *imageThumbnail) Tapped(*fyne.PointEvent) {
+	if t.onTapped != nil {
+		t.onTapped()
+	}
+}
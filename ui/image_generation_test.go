@@ -0,0 +1,110 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"light-llm-client/llm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestImaginePrompt(t *testing.T) {
+	tests := []struct {
+		content string
+		prompt  string
+		ok      bool
+	}{
+		{"/imagine a red panda", "a red panda", true},
+		{"/imagine", "", true},
+		{"/imagined a red panda", "", false},
+		{"draw /imagine a red panda", "", false},
+	}
+	for _, tt := range tests {
+		prompt, ok := imaginePrompt(tt.content)
+		if prompt != tt.prompt || ok != tt.ok {
+			t.Errorf("imaginePrompt(%q) = %q, %v, want %q, %v", tt.content, prompt, ok, tt.prompt, tt.ok)
+		}
+	}
+}
+
+func TestChatView_GenerateImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("failed to encode image: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/generations" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"created":1,"data":[{"b64_json":%q}]}`, base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}))
+	defer server.Close()
+
+	provider, err := llm.NewOpenAIProvider(llm.Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	fyne.DoAndWait(func() {
+		sendTestMessage(cv, "/imagine a red panda")
+	})
+	messages := waitForMessages(t, a, convID, 2)
+	if messages[0].Role != "user" || messages[0].Content != "/imagine a red panda" {
+		t.Errorf("unexpected user message: %+v", messages[0])
+	}
+	var attachments []*llm.Attachment
+	if err := json.Unmarshal([]byte(messages[1].Attachments), &attachments); err != nil {
+		t.Fatalf("failed to parse attachments of the response: %v", err)
+	}
+	if len(attachments) != 1 || attachments[0].Type != "image" || !bytes.Equal(attachments[0].Data, buf.Bytes()) {
+		t.Fatalf("expected the generated image as the attachment of the response, got %+v", attachments)
+	}
+	if messages[1].Model != imageModel {
+		t.Errorf("got model %q, want %q", messages[1].Model, imageModel)
+	}
+
+	// The response shows the image
+	waitUntil(t, "the generated image in the chat", func() bool {
+		var found fyne.CanvasObject
+		fyne.DoAndWait(func() {
+			found = findObject(cv.messagesContainer, func(obj fyne.CanvasObject) bool {
+				_, ok := obj.(*imageThumbnail)
+				return ok
+			})
+		})
+		return found != nil
+	})
+
+	// The image isn't sent back to chat models
+	if msg := cv.llmMessage(messages[1]); len(msg.Attachments) != 0 {
+		t.Errorf("expected no attachments in the history, got %d", len(msg.Attachments))
+	}
+}
+
+func TestChatView_GenerateImage_Unsupported(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{Responses: []string{"An answer"}}))
+	cv, convID := newTestChat(t, a)
+
+	fyne.DoAndWait(func() {
+		sendTestMessage(cv, "/imagine a red panda")
+	})
+	messages, err := a.db.ListMessages(convID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("expected nothing to be sent to a provider without image generation, got %d messages", len(messages))
+	}
+}
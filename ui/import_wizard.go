@@ -0,0 +1,368 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// importPreviewMessages is the number of messages shown in the import preview
+const importPreviewMessages = 5
+
+// Import options offered by the wizard
+const (
+	importOptionAll    = "all"
+	importOptionCreate = "create"
+	importOptionMerge  = "merge"
+)
+
+// ConversationImportWizard guides the user through importing conversations:
+// detect the file format, preview, choose how to import, import with
+// progress, and show a summary.
+type ConversationImportWizard struct {
+	app     *App
+	popup   *widget.PopUp
+	content *fyne.Container
+
+	path     string
+	fileName string
+	source   *utils.ImportSource
+	// Index of the conversation shown in the preview and used by create/merge
+	selected int
+}
+
+// NewConversationImportWizard creates an import wizard
+func NewConversationImportWizard(app *App) *ConversationImportWizard {
+	return &ConversationImportWizard{
+		app:     app,
+		content: container.NewStack(),
+	}
+}
+
+// Show asks for a file and starts the wizard
+func (w *ConversationImportWizard) Show() {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			w.app.showError("Failed to open file: " + err.Error())
+			return
+		}
+		if reader == nil {
+			return // User cancelled
+		}
+		path := reader.URI().Path()
+		reader.Close()
+		w.ShowFile(path)
+	}, w.app.window)
+
+	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json", ".zip"}))
+	fileDialog.Show()
+}
+
+// ShowFile starts the wizard with an already chosen file, e.g. a recent file
+func (w *ConversationImportWizard) ShowFile(path string) {
+	w.path = path
+	w.fileName = filepath.Base(path)
+	w.popup = widget.NewModalPopUp(w.content, w.app.window.Canvas())
+	w.popup.Resize(fyne.NewSize(560, 440))
+	w.popup.Show()
+	w.detect(path)
+}
+
+// setStep replaces the wizard content
+func (w *ConversationImportWizard) setStep(title string, body fyne.CanvasObject, buttons ...fyne.CanvasObject) {
+	heading := widget.NewLabel(title)
+	heading.TextStyle = fyne.TextStyle{Bold: true}
+
+	w.content.Objects = []fyne.CanvasObject{
+		container.NewBorder(
+			container.NewVBox(heading, widget.NewSeparator()),
+			container.NewVBox(widget.NewSeparator(), container.NewHBox(buttons...)),
+			nil, nil,
+			body,
+		),
+	}
+	w.content.Refresh()
+}
+
+// close hides the wizard
+func (w *ConversationImportWizard) close() {
+	if w.popup != nil {
+		w.popup.Hide()
+	}
+}
+
+// detect reads the file in the background and detects its format (step 1)
+func (w *ConversationImportWizard) detect(path string) {
+	progress := widget.NewProgressBarInfinite()
+	w.setStep("导入对话 (1/4): 识别文件", container.NewVBox(
+		widget.NewLabel("正在读取 "+w.fileName+" ..."),
+		progress,
+	))
+
+	w.app.logger.Info("Importing from: %s", path)
+	utils.SafeGo(w.app.logger, "importDetect", func() {
+		source, err := utils.ParseImportFile(path)
+		fyne.Do(func() {
+			progress.Stop()
+			if err != nil {
+				w.app.logger.Error("Failed to read import file: %v", err)
+				w.showFailure("读取文件失败: " + err.Error())
+				return
+			}
+			w.app.logger.Info("Detected import format %s: %d conversations in %d files", source.Format, len(source.Conversations), source.Files)
+			if source.SkippedToolMessages > 0 {
+				w.app.logger.Warn("Skipping %d tool and plugin messages of the ChatGPT export", source.SkippedToolMessages)
+			}
+			w.source = source
+			w.selected = 0
+			if source.Format == utils.ImportFormatUnknown || len(source.Conversations) == 0 {
+				w.showFailure("无法识别的文件格式，或文件中没有可导入的对话。\n支持: 本应用导出的 JSON、ChatGPT 导出的 conversations.json，以及包含它们的 .zip 文件。")
+				return
+			}
+			w.showPreview()
+		})
+	})
+}
+
+// showPreview shows the detected format and the selected conversation (step 2)
+func (w *ConversationImportWizard) showPreview() {
+	source := w.source
+
+	summary := fmt.Sprintf("格式: %s\n共 %d 个对话，%d 条消息", importFormatName(source.Format), len(source.Conversations), source.MessageCount())
+	if source.Files > 1 {
+		summary += fmt.Sprintf("\n读取了 %d 个文件", source.Files)
+	}
+	if len(source.SkippedFiles) > 0 {
+		summary += fmt.Sprintf("\n跳过 %d 个无法识别的文件: %s", len(source.SkippedFiles), strings.Join(source.SkippedFiles, ", "))
+	}
+	if source.SkippedToolMessages > 0 {
+		summary += fmt.Sprintf("\n跳过 %d 条工具/插件消息", source.SkippedToolMessages)
+	}
+	summaryLabel := widget.NewLabel(summary)
+	summaryLabel.Wrapping = fyne.TextWrapWord
+
+	previewBox := container.NewVBox()
+	renderPreview := func() {
+		conv := source.Conversations[w.selected]
+		title := widget.NewLabel(fmt.Sprintf("%s (%d 条消息)", conv.Title, len(conv.Messages)))
+		title.TextStyle = fyne.TextStyle{Bold: true}
+		objects := []fyne.CanvasObject{title}
+		for i, msg := range conv.Messages {
+			if i == importPreviewMessages {
+				objects = append(objects, widget.NewLabel(fmt.Sprintf("... 还有 %d 条消息", len(conv.Messages)-i)))
+				break
+			}
+			role := "👤"
+			if msg.Role == "assistant" {
+				role = "🤖"
+			}
+			line := widget.NewLabel(role + " " + truncateRunes(strings.Join(strings.Fields(msg.Content), " "), 120))
+			line.Wrapping = fyne.TextWrapWord
+			objects = append(objects, line)
+		}
+		previewBox.Objects = objects
+		previewBox.Refresh()
+	}
+	renderPreview()
+
+	top := []fyne.CanvasObject{summaryLabel}
+	if len(source.Conversations) > 1 {
+		titles := make([]string, len(source.Conversations))
+		for i, conv := range source.Conversations {
+			titles[i] = fmt.Sprintf("%d. %s", i+1, conv.Title)
+		}
+		conversationSelect := widget.NewSelect(titles, nil)
+		conversationSelect.SetSelectedIndex(w.selected)
+		conversationSelect.OnChanged = func(string) {
+			w.selected = conversationSelect.SelectedIndex()
+			renderPreview()
+		}
+		top = append(top, widget.NewForm(widget.NewFormItem("预览对话", conversationSelect)))
+	}
+
+	nextButton := widget.NewButton("下一步", func() {
+		w.showOptions()
+	})
+	nextButton.Importance = widget.HighImportance
+
+	w.setStep("导入对话 (2/4): 预览",
+		container.NewBorder(container.NewVBox(top...), nil, nil, nil, container.NewVScroll(previewBox)),
+		widget.NewButton("取消", w.close),
+		nextButton,
+	)
+}
+
+// showOptions lets the user choose how to import (step 3)
+func (w *ConversationImportWizard) showOptions() {
+	source := w.source
+	conv := source.Conversations[w.selected]
+
+	labels := map[string]string{
+		importOptionAll:    fmt.Sprintf("导入全部 %d 个对话", len(source.Conversations)),
+		importOptionCreate: fmt.Sprintf("作为新对话导入「%s」", conv.Title),
+		importOptionMerge:  fmt.Sprintf("将「%s」合并到现有对话", conv.Title),
+	}
+	options := []string{}
+	if len(source.Conversations) > 1 {
+		options = append(options, labels[importOptionAll])
+	}
+	options = append(options, labels[importOptionCreate], labels[importOptionMerge])
+
+	// Existing conversations to merge into, labelled with their ID since titles may repeat
+	targetIDs := make(map[string]int64)
+	targets := []string{}
+	for _, existing := range w.app.conversations {
+		label := fmt.Sprintf("%s  #%d", existing.Title, existing.ID)
+		targetIDs[label] = existing.ID
+		targets = append(targets, label)
+	}
+	targetSelect := widget.NewSelect(targets, nil)
+	targetSelect.PlaceHolder = "选择要合并到的对话"
+	if activeID := w.app.getActiveConversationID(); activeID != 0 {
+		for label, id := range targetIDs {
+			if id == activeID {
+				targetSelect.SetSelected(label)
+			}
+		}
+	}
+	targetSelect.Disable()
+
+	optionGroup := widget.NewRadioGroup(options, func(selected string) {
+		if selected == labels[importOptionMerge] {
+			targetSelect.Enable()
+		} else {
+			targetSelect.Disable()
+		}
+	})
+	optionGroup.Required = true
+	optionGroup.SetSelected(options[0])
+
+	importButton := widget.NewButton("开始导入", func() {
+		switch optionGroup.Selected {
+		case labels[importOptionAll]:
+			w.runImport(importOptionAll, 0, "")
+		case labels[importOptionCreate]:
+			w.runImport(importOptionCreate, 0, "")
+		case labels[importOptionMerge]:
+			targetID, ok := targetIDs[targetSelect.Selected]
+			if !ok {
+				w.app.showError("请选择要合并到的对话")
+				return
+			}
+			w.runImport(importOptionMerge, targetID, targetSelect.Selected)
+		}
+	})
+	importButton.Importance = widget.HighImportance
+
+	w.setStep("导入对话 (3/4): 导入方式",
+		container.NewVBox(optionGroup, targetSelect),
+		widget.NewButton("上一步", w.showPreview),
+		widget.NewButton("取消", w.close),
+		importButton,
+	)
+}
+
+// runImport imports in the background while showing progress (step 4)
+func (w *ConversationImportWizard) runImport(option string, targetID int64, targetLabel string) {
+	progress := widget.NewProgressBar()
+	statusLabel := widget.NewLabel("正在导入...")
+	w.setStep("导入对话 (4/4): 导入中", container.NewVBox(statusLabel, progress))
+
+	conversations := w.source.Conversations
+	if option != importOptionAll {
+		conversations = conversations[w.selected : w.selected+1]
+	}
+
+	onProgress := func(done, total int) {
+		fyne.Do(func() {
+			progress.SetValue(float64(done) / float64(total))
+			statusLabel.SetText(fmt.Sprintf("正在导入... %d / %d 条消息", done, total))
+		})
+	}
+
+	utils.SafeGo(w.app.logger, "importConversations", func() {
+		var result utils.ImportResult
+		var err error
+		if option == importOptionMerge {
+			result, err = utils.MergeConversation(w.app.db, targetID, conversations[0], onProgress)
+		} else {
+			result, err = utils.ImportConversations(w.app.db, conversations, onProgress)
+		}
+		if err != nil {
+			w.app.logger.Error("Import failed after %d conversations, %d messages: %v", result.Conversations, result.Messages, err)
+		} else {
+			w.app.logger.Info("Imported %d conversations, %d messages", result.Conversations, result.Messages)
+		}
+
+		fyne.Do(func() {
+			if option == importOptionMerge {
+				w.app.reloadConversation(targetID)
+			}
+			if err == nil {
+				w.app.addRecentFile(w.path)
+			}
+			w.app.RefreshSidebar()
+			w.showSummary(option, targetLabel, result, err)
+		})
+	})
+}
+
+// showSummary reports the outcome of the import
+func (w *ConversationImportWizard) showSummary(option, targetLabel string, result utils.ImportResult, err error) {
+	var text string
+	if option == importOptionMerge {
+		text = fmt.Sprintf("已合并 %d 条消息到「%s」", result.Messages, targetLabel)
+	} else {
+		text = fmt.Sprintf("已导入 %d 个对话，共 %d 条消息", result.Conversations, result.Messages)
+	}
+
+	title := "✅ 导入完成"
+	if err != nil {
+		title = "❌ 导入未完成"
+		text += "\n\n导入失败: " + err.Error()
+	}
+
+	summaryLabel := widget.NewLabel(text)
+	summaryLabel.Wrapping = fyne.TextWrapWord
+
+	doneButton := widget.NewButton("完成", w.close)
+	doneButton.Importance = widget.HighImportance
+	w.setStep(title, summaryLabel, doneButton)
+}
+
+// showFailure shows an error that ends the wizard
+func (w *ConversationImportWizard) showFailure(message string) {
+	label := widget.NewLabel(message)
+	label.Wrapping = fyne.TextWrapWord
+	w.setStep("❌ 无法导入", label, widget.NewButton("关闭", w.close))
+}
+
+// importFormatName returns a display name for an import format
+func importFormatName(format utils.ImportFormat) string {
+	switch format {
+	case utils.ImportFormatChatGPT:
+		return "ChatGPT 导出"
+	case utils.ImportFormatSingle:
+		return "单个对话"
+	case utils.ImportFormatMulti:
+		return "多个对话"
+	default:
+		return "未知"
+	}
+}
+
+// reloadConversation drops the cached messages of a conversation and reloads its open tab
+func (a *App) reloadConversation(conversationID int64) {
+	delete(a.messageCache, conversationID)
+	delete(a.uiCache, conversationID)
+	if cv, ok := a.chatViews[conversationID]; ok {
+		cv.loadMessages()
+	}
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"encoding/json"
+	"light-llm-client/llm"
+	"strings"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// newJSONModeCheck creates the toggle of JSON mode in the provider bar
+func (cv *ChatView) newJSONModeCheck() *widget.Check {
+	cv.jsonModeCheck = widget.NewCheck("JSON 模式", func(checked bool) {
+		cv.jsonMode = checked
+		cv.app.logger.Info("JSON mode of conversation %d: %v", cv.conversationID, checked)
+	})
+	return cv.jsonModeCheck
+}
+
+// withJSONMode returns provider asking for JSON responses if JSON mode is
+// on. Only OpenAI compatible providers support it; the others ignore it.
+func (cv *ChatView) withJSONMode(provider llm.Provider) llm.Provider {
+	if !cv.jsonMode {
+		return provider
+	}
+	jsonMode := true
+	return provider.WithParams(llm.GenerationParams{JSONMode: &jsonMode})
+}
+
+// prettyJSON indents content if it is a JSON object or array, keeping the
+// order of the keys
+func prettyJSON(content string) (string, bool) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "{") && !strings.HasPrefix(content, "[") {
+		return "", false
+	}
+	if !json.Valid([]byte(content)) {
+		return "", false
+	}
+	indented, err := json.MarshalIndent(json.RawMessage(content), "", "  ")
+	if err != nil {
+		return "", false
+	}
+	return string(indented), true
+}
@@ -0,0 +1,55 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestChatView_JSONMode(t *testing.T) {
+	response := `{"name": "Go", "tags": ["fast", "simple"]}`
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{response}})
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	fyne.DoAndWait(func() {
+		cv.jsonModeCheck.SetChecked(true)
+	})
+	sendTestMessage(cv, "Describe Go as JSON")
+	waitForMessages(t, a, convID, 2)
+
+	params := provider.Params()
+	if len(params) != 1 || params[0].JSONMode == nil || !*params[0].JSONMode {
+		t.Fatalf("request params = %+v, want JSON mode", params)
+	}
+
+	// JSON responses are indented, keeping the order of the keys
+	want := "{\n  \"name\": \"Go\",\n  \"tags\": [\n    \"fast\",\n    \"simple\"\n  ]\n}"
+	var rendered fyne.CanvasObject
+	fyne.DoAndWait(func() {
+		rendered = cv.renderAssistantMessage(response)
+	})
+	label, ok := rendered.(*widget.Label)
+	if !ok || label.Text != want {
+		t.Errorf("rendered %T %+v, want the indented JSON", rendered, rendered)
+	}
+
+	// Other responses and responses without JSON mode are left alone
+	if _, ok := prettyJSON("not json {"); ok {
+		t.Error("prettyJSON accepted text")
+	}
+	if _, ok := prettyJSON("42"); ok {
+		t.Error("prettyJSON accepted a number")
+	}
+	fyne.DoAndWait(func() {
+		cv.jsonModeCheck.SetChecked(false)
+		rendered = cv.renderAssistantMessage(response)
+	})
+	if label, ok := rendered.(*widget.Label); ok && label.Text == want {
+		t.Error("response indented without JSON mode")
+	}
+}
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// Sections of the shortcuts in the help overlay
+const (
+	sectionChat    = "聊天快捷键"
+	sectionSidebar = "侧边栏快捷键"
+	sectionTabs    = "标签页快捷键"
+)
+
+// shortcutAction is a window shortcut whose keys come from Config.Keybindings
+type shortcutAction struct {
+	action      string
+	section     string
+	description string
+	run         func()
+}
+
+// shortcutActions returns the window shortcuts in the order they are listed
+// in the help overlay
+func (a *App) shortcutActions() []shortcutAction {
+	return []shortcutAction{
+		{utils.ActionSearch, sectionChat, "在当前对话中搜索（其他页面为全局搜索）", func() {
+			if cv, ok := a.chatViews[a.getActiveConversationID()]; ok && cv.searchBar != nil {
+				cv.searchBar.Show()
+				return
+			}
+			a.showSearch()
+		}},
+		{utils.ActionFork, sectionChat, "分叉当前对话", func() {
+			activeConvID := a.getActiveConversationID()
+			if activeConvID == 0 {
+				a.showError("请先选择一个对话")
+				return
+			}
+			ShowForkDialog(a, activeConvID)
+		}},
+		{utils.ActionNewConversation, sectionSidebar, "新建对话", a.createNewConversation},
+		{utils.ActionSettings, sectionSidebar, "打开设置", a.showSettings},
+		{utils.ActionHelp, sectionSidebar, "显示快捷键与功能提示", a.showHelp},
+		{utils.ActionCloseTab, sectionTabs, "关闭当前标签页", a.closeCurrentTab},
+		{utils.ActionNextTab, sectionTabs, "切换到下一个标签页", a.nextTab},
+		{utils.ActionPreviousTab, sectionTabs, "切换到上一个标签页", a.previousTab},
+		{utils.ActionSearchTabs, sectionTabs, "按标题搜索已打开的标签页", a.showTabSearch},
+	}
+}
+
+// shortcutKeyNames maps the key names of keybindings to Fyne keys
+var shortcutKeyNames = map[string]fyne.KeyName{
+	",": fyne.KeyComma, "comma": fyne.KeyComma,
+	".": fyne.KeyPeriod, "period": fyne.KeyPeriod,
+	"/": fyne.KeySlash, "slash": fyne.KeySlash,
+	"-": fyne.KeyMinus, "minus": fyne.KeyMinus,
+	"=": fyne.KeyEqual, "equal": fyne.KeyEqual,
+	";": fyne.KeySemicolon, "[": fyne.KeyLeftBracket, "]": fyne.KeyRightBracket,
+	"\\": fyne.KeyBackslash, "'": fyne.KeyApostrophe, "`": fyne.KeyBackTick,
+	"tab": fyne.KeyTab, "enter": fyne.KeyReturn, "return": fyne.KeyReturn,
+	"space": fyne.KeySpace, "escape": fyne.KeyEscape, "esc": fyne.KeyEscape,
+	"backspace": fyne.KeyBackspace, "delete": fyne.KeyDelete, "insert": fyne.KeyInsert,
+	"home": fyne.KeyHome, "end": fyne.KeyEnd, "pageup": fyne.KeyPageUp, "pagedown": fyne.KeyPageDown,
+	"up": fyne.KeyUp, "down": fyne.KeyDown, "left": fyne.KeyLeft, "right": fyne.KeyRight,
+}
+
+// parseShortcut parses a key combination like "ctrl+shift+f" into a window
+// shortcut. "?" stands for Shift+/. A modifier is required so the shortcut
+// doesn't swallow typing.
+func parseShortcut(keys string) (*desktop.CustomShortcut, error) {
+	shortcut := &desktop.CustomShortcut{}
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(keys)), "+")
+	// "ctrl++" binds the plus key
+	if strings.HasSuffix(keys, "++") {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	for i, name := range parts {
+		name = strings.TrimSpace(name)
+		if i < len(parts)-1 {
+			switch name {
+			case "ctrl", "control":
+				shortcut.Modifier |= fyne.KeyModifierControl
+			case "alt":
+				shortcut.Modifier |= fyne.KeyModifierAlt
+			case "shift":
+				shortcut.Modifier |= fyne.KeyModifierShift
+			case "super", "cmd", "win":
+				shortcut.Modifier |= fyne.KeyModifierSuper
+			default:
+				return nil, fmt.Errorf("invalid shortcut %q: unknown modifier %q", keys, name)
+			}
+			continue
+		}
+
+		switch key, ok := shortcutKeyNames[name]; {
+		case ok:
+			shortcut.KeyName = key
+		case name == "?":
+			shortcut.KeyName = fyne.KeySlash
+			shortcut.Modifier |= fyne.KeyModifierShift
+		case name == "+":
+			shortcut.KeyName = fyne.KeyEqual
+			shortcut.Modifier |= fyne.KeyModifierShift
+		case len(name) == 1 && (name[0] >= 'a' && name[0] <= 'z' || name[0] >= '0' && name[0] <= '9'):
+			shortcut.KeyName = fyne.KeyName(strings.ToUpper(name))
+		case len(name) >= 2 && name[0] == 'f' && isFunctionKey(name[1:]):
+			shortcut.KeyName = fyne.KeyName(strings.ToUpper(name))
+		default:
+			return nil, fmt.Errorf("invalid shortcut %q: unknown key %q", keys, name)
+		}
+	}
+	if shortcut.Modifier == 0 {
+		return nil, fmt.Errorf("invalid shortcut %q: a modifier is required", keys)
+	}
+	return shortcut, nil
+}
+
+// isFunctionKey reports whether n is the number of a function key (F1-F12)
+func isFunctionKey(n string) bool {
+	switch n {
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12":
+		return true
+	}
+	return false
+}
+
+// shortcutLabel formats a key combination for display, e.g. "Ctrl+Shift+F"
+func shortcutLabel(keys string) string {
+	keys = strings.TrimSpace(keys)
+	if keys == "" {
+		return "未设置"
+	}
+	parts := strings.Split(keys, "+")
+	if strings.HasSuffix(keys, "++") {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		switch strings.ToLower(part) {
+		case "ctrl", "control":
+			part = "Ctrl"
+		case "cmd", "win":
+			part = "Super"
+		case "pageup":
+			part = "PageUp"
+		case "pagedown":
+			part = "PageDown"
+		default:
+			if len(part) > 0 {
+				part = strings.ToUpper(part[:1]) + part[1:]
+			}
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "+")
+}
+
+// setupKeyboardShortcuts registers the window shortcuts with the keys of
+// Config.Keybindings. Invalid keybindings are logged and fall back to the
+// default keys.
+func (a *App) setupKeyboardShortcuts() {
+	for _, sa := range a.shortcutActions() {
+		sa := sa
+		keys := a.config.Keybinding(sa.action)
+		if keys == "" {
+			continue // Disabled
+		}
+		shortcut, err := parseShortcut(keys)
+		if err != nil {
+			a.logger.Warn("Keybinding of %s: %v, using the default", sa.action, err)
+			keys = utils.DefaultKeybindings()[sa.action]
+			if shortcut, err = parseShortcut(keys); err != nil {
+				continue
+			}
+		}
+		label := shortcutLabel(keys)
+		a.window.Canvas().AddShortcut(shortcut, func(fyne.Shortcut) {
+			a.logger.Info("Keyboard shortcut: %s - %s", label, sa.action)
+			sa.run()
+		})
+	}
+
+	// Alt+1 to Alt+9: Insert quick prompt into the active chat
+	// (handled by the input entry itself while it has focus)
+	for key := '1'; key <= '9'; key++ {
+		digit := key
+		a.window.Canvas().AddShortcut(&desktop.CustomShortcut{
+			KeyName:  fyne.KeyName(string(digit)),
+			Modifier: desktop.AltModifier,
+		}, func(shortcut fyne.Shortcut) {
+			if cv, ok := a.chatViews[a.getActiveConversationID()]; ok {
+				cv.insertQuickPromptByKey(digit)
+			}
+		})
+	}
+
+	// Ctrl+Z/Ctrl+Y: Undo/redo the last send of the active chat (the input
+	// entry handles them itself while it has focus)
+	a.window.Canvas().AddShortcut(&fyne.ShortcutUndo{}, func(fyne.Shortcut) {
+		if cv, ok := a.chatViews[a.getActiveConversationID()]; ok {
+			cv.Undo()
+		}
+	})
+	a.window.Canvas().AddShortcut(&fyne.ShortcutRedo{}, func(fyne.Shortcut) {
+		if cv, ok := a.chatViews[a.getActiveConversationID()]; ok {
+			cv.Redo()
+		}
+	})
+
+	a.logger.Info("Keyboard shortcuts registered")
+}
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// initMacros loads the input macros. Without them typed triggers stay as
+// they are.
+func (a *App) initMacros() {
+	path := a.config.Data.MacrosFile()
+	macros, err := utils.NewMacroStore(path)
+	if err != nil {
+		a.logger.Error("Failed to load macros from %s: %v", path, err)
+		return
+	}
+	a.macros = macros
+}
+
+// expandMacro replaces the /trigger word before the cursor with the
+// expansion of its macro. It reports whether there was one.
+func (e *customEntry) expandMacro() bool {
+	if e.app == nil || e.app.macros == nil || e.SelectedText() != "" {
+		return false
+	}
+
+	text := []rune(e.Text)
+	pos := e.CursorTextOffset()
+	if pos > len(text) {
+		return false
+	}
+	start := pos
+	for start > 0 && !unicode.IsSpace(text[start-1]) {
+		start--
+	}
+	trigger := string(text[start:pos])
+	if !strings.HasPrefix(trigger, "/") {
+		return false
+	}
+	expansion, ok := e.app.macros.Expand(trigger)
+	if !ok {
+		return false
+	}
+
+	// Edit like typing so the cursor ends up after the expansion, wherever
+	// the lines wrap, and Ctrl+Z in the entry can take it back
+	for range []rune(trigger) {
+		e.Entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+	}
+	for _, r := range expansion {
+		e.Entry.TypedRune(r)
+	}
+	return true
+}
+
+// buildMacroSettings builds the button that opens the macro manager
+func (sv *SettingsView) buildMacroSettings() fyne.CanvasObject {
+	return container.NewHBox(
+		widget.NewButton("Manage Macros...", sv.showMacroDialog),
+		widget.NewLabel("Type a /trigger and Space or Tab to expand it"),
+	)
+}
+
+// showMacroDialog lists the macros with buttons to add, edit and delete them
+func (sv *SettingsView) showMacroDialog() {
+	store := sv.app.macros
+	if store == nil {
+		sv.showError("Macros could not be loaded from " + sv.app.config.Data.MacrosFile() + ", see the log")
+		return
+	}
+
+	macros := store.List()
+	selected := -1
+
+	var list *widget.List
+	var editButton, deleteButton *widget.Button
+	refresh := func() {
+		macros = store.List()
+		selected = -1
+		list.UnselectAll()
+		list.Refresh()
+		editButton.Disable()
+		deleteButton.Disable()
+	}
+
+	list = widget.NewList(
+		func() int { return len(macros) },
+		func() fyne.CanvasObject {
+			trigger := widget.NewLabel("")
+			trigger.TextStyle = fyne.TextStyle{Monospace: true}
+			expansion := widget.NewLabel("")
+			expansion.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, trigger, nil, expansion)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			box := obj.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(strings.ReplaceAll(macros[id].Expansion, "\n", " ⏎ "))
+			box.Objects[1].(*widget.Label).SetText(macros[id].Trigger)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		editButton.Enable()
+		deleteButton.Enable()
+	}
+
+	addButton := widget.NewButton("Add", func() {
+		sv.showMacroEditor(utils.Macro{}, refresh)
+	})
+	editButton = widget.NewButton("Edit", func() {
+		if selected >= 0 {
+			sv.showMacroEditor(macros[selected], refresh)
+		}
+	})
+	deleteButton = widget.NewButton("Delete", func() {
+		if selected < 0 {
+			return
+		}
+		if err := store.Delete(macros[selected].Trigger); err != nil {
+			sv.app.logger.Error("Failed to delete macro: %v", err)
+			sv.showError("Failed to delete macro: " + err.Error())
+			return
+		}
+		refresh()
+	})
+	deleteButton.Importance = widget.DangerImportance
+	editButton.Disable()
+	deleteButton.Disable()
+
+	var popup *widget.PopUp
+	popup = widget.NewModalPopUp(
+		container.NewBorder(
+			widget.NewLabel("Macros"),
+			container.NewHBox(addButton, editButton, deleteButton, widget.NewButton("Close", func() {
+				popup.Hide()
+			})),
+			nil,
+			nil,
+			list,
+		),
+		sv.getCanvas(),
+	)
+	popup.Resize(fyne.NewSize(520, 400))
+	popup.Show()
+}
+
+// showMacroEditor edits a macro, or adds one if macro has no trigger.
+// Changing the trigger of a macro replaces it. onSaved runs after saving.
+func (sv *SettingsView) showMacroEditor(macro utils.Macro, onSaved func()) {
+	triggerEntry := widget.NewEntry()
+	triggerEntry.SetPlaceHolder("/code")
+	triggerEntry.SetText(macro.Trigger)
+	expansionEntry := widget.NewMultiLineEntry()
+	expansionEntry.SetPlaceHolder("Expansion text")
+	expansionEntry.SetText(macro.Expansion)
+	expansionEntry.SetMinRowsVisible(6)
+	errorLabel := widget.NewLabel("")
+	errorLabel.Importance = widget.DangerImportance
+	errorLabel.Hide()
+
+	title := "Edit Macro"
+	if macro.Trigger == "" {
+		title = "Add Macro"
+	}
+
+	var popup *widget.PopUp
+	saveButton := widget.NewButton("Save", func() {
+		trigger := strings.TrimSpace(triggerEntry.Text)
+		err := sv.app.macros.Set(trigger, expansionEntry.Text)
+		if err == nil && macro.Trigger != "" && macro.Trigger != trigger {
+			err = sv.app.macros.Delete(macro.Trigger)
+		}
+		if err != nil {
+			errorLabel.SetText(fmt.Sprintf("Failed to save macro: %v", err))
+			errorLabel.Show()
+			return
+		}
+		popup.Hide()
+		onSaved()
+	})
+	saveButton.Importance = widget.HighImportance
+
+	popup = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel(title),
+			widget.NewForm(
+				widget.NewFormItem("Trigger", triggerEntry),
+				widget.NewFormItem("Expansion", expansionEntry),
+			),
+			errorLabel,
+			container.NewHBox(
+				widget.NewButton("Cancel", func() {
+					popup.Hide()
+				}),
+				saveButton,
+			),
+		),
+		sv.getCanvas(),
+	)
+	popup.Resize(fyne.NewSize(480, popup.MinSize().Height))
+	popup.Show()
+	sv.getCanvas().Focus(triggerEntry)
+}
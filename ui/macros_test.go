@@ -0,0 +1,68 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestCustomEntry_ExpandMacro(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	macros, err := utils.NewMacroStore(filepath.Join(t.TempDir(), "macros.json"))
+	if err != nil {
+		t.Fatalf("NewMacroStore failed: %v", err)
+	}
+	if err := macros.Set("/code", "Review this code:\n```"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	a.macros = macros
+	cv, _ := newTestChat(t, a)
+	entry := cv.inputEntry
+
+	type key struct {
+		name fyne.KeyName
+		rune rune
+	}
+	typeText := func(text string, keys ...key) {
+		fyne.DoAndWait(func() {
+			entry.SetText("")
+			for _, r := range text {
+				entry.TypedRune(r)
+			}
+			for _, k := range keys {
+				entry.TypedKey(&fyne.KeyEvent{Name: k.name})
+				if k.rune != 0 {
+					entry.TypedRune(k.rune)
+				}
+			}
+		})
+	}
+
+	// Space keeps the space after the expansion
+	typeText("Please /code", key{fyne.KeySpace, ' '})
+	if want := "Please Review this code:\n``` "; entry.Text != want {
+		t.Errorf("text = %q, want %q", entry.Text, want)
+	}
+	if got := entry.CursorTextOffset(); got != len([]rune(entry.Text)) {
+		t.Errorf("cursor at %d, want after the expansion", got)
+	}
+
+	// Tab is dropped
+	typeText("/code", key{fyne.KeyTab, 0})
+	if want := "Review this code:\n```"; entry.Text != want {
+		t.Errorf("text = %q, want %q", entry.Text, want)
+	}
+
+	// Unknown triggers and paths stay as typed
+	for _, text := range []string{"/unknown", "a/code"} {
+		typeText(text, key{fyne.KeySpace, ' '})
+		if want := text + " "; entry.Text != want {
+			t.Errorf("text = %q, want %q", entry.Text, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"light-llm-client/db"
+	"light-llm-client/utils"
 	"runtime"
 
 	"fyne.io/fyne/v2"
@@ -29,6 +30,7 @@ func (mm *MemoryMonitor) Show() {
 	sysLabel := widget.NewLabel("")
 	gcLabel := widget.NewLabel("")
 	cacheLabel := widget.NewLabel("")
+	cacheStatsLabel := widget.NewLabel("")
 	
 	// Update function
 	updateStats := func() {
@@ -45,6 +47,7 @@ func (mm *MemoryMonitor) Show() {
 		openTabs := len(mm.app.chatViews)
 		cacheLabel.SetText(fmt.Sprintf("缓存对话数: %d (UI缓存: %d) | 打开标签页: %d | 缓存上限: %d", 
 			cacheSize, uiCacheSize, openTabs, mm.app.cacheMaxSize))
+		cacheStatsLabel.SetText(cacheStatsText(mm.app.cacheHits.Load(), mm.app.cacheMisses.Load(), cacheSize))
 	}
 	
 	// Initial update
@@ -85,18 +88,12 @@ func (mm *MemoryMonitor) Show() {
 	
 	// Cache size slider
 	cacheSizeLabel := widget.NewLabel(fmt.Sprintf("缓存上限: %d 个对话", mm.app.cacheMaxSize))
-	cacheSizeSlider := widget.NewSlider(3, 20)
+	cacheSizeSlider := widget.NewSlider(utils.MinCacheSize, utils.MaxCacheSize)
 	cacheSizeSlider.Value = float64(mm.app.cacheMaxSize)
 	cacheSizeSlider.Step = 1
 	cacheSizeSlider.OnChanged = func(value float64) {
-		mm.app.cacheMaxSize = int(value)
+		mm.app.saveCacheSize(int(value))
 		cacheSizeLabel.SetText(fmt.Sprintf("缓存上限: %d 个对话", mm.app.cacheMaxSize))
-		
-		// Evict if necessary
-		for len(mm.app.messageCache) > mm.app.cacheMaxSize {
-			mm.app.evictOldestCache()
-		}
-		
 		updateStats()
 	}
 	
@@ -109,6 +106,7 @@ func (mm *MemoryMonitor) Show() {
 		gcLabel,
 		widget.NewSeparator(),
 		cacheLabel,
+		cacheStatsLabel,
 		widget.NewSeparator(),
 		cacheSizeLabel,
 		cacheSizeSlider,
@@ -133,3 +131,38 @@ func (mm *MemoryMonitor) Show() {
 	win.Resize(fyne.NewSize(500, 600))
 	win.Show()
 }
+
+// cacheStatsText describes how often conversations were loaded from the cache
+func cacheStatsText(hits, misses int64, size int) string {
+	hitRate := 0.0
+	if total := hits + misses; total > 0 {
+		hitRate = float64(hits) / float64(total) * 100
+	}
+	return fmt.Sprintf("缓存命中: %d | 未命中: %d | 命中率: %.0f%% | 当前大小: %d", hits, misses, hitRate, size)
+}
+
+// saveCacheSize changes the number of cached conversations and saves it
+func (a *App) saveCacheSize(size int) {
+	a.config.UI.CacheSize = size
+	a.config.UI.CacheSize = a.config.UI.MessageCacheSize()
+	a.setCacheMaxSize(a.config.UI.CacheSize)
+	if err := utils.SaveConfig(a.configPath, a.config); err != nil {
+		a.logger.Error("Failed to save cache size: %v", err)
+	}
+}
+
+// buildCacheSizeSettings builds the slider for the number of cached
+// conversations
+func (sv *SettingsView) buildCacheSizeSettings() fyne.CanvasObject {
+	label := widget.NewLabel(fmt.Sprintf("%d conversations", sv.app.cacheMaxSize))
+	slider := widget.NewSlider(utils.MinCacheSize, utils.MaxCacheSize)
+	slider.Step = 1
+	slider.Value = float64(sv.app.cacheMaxSize)
+	slider.OnChanged = func(value float64) {
+		sv.app.saveCacheSize(int(value))
+		label.SetText(fmt.Sprintf("%d conversations", sv.app.cacheMaxSize))
+	}
+	note := widget.NewLabel("Larger caches switch conversations faster but use more memory")
+	note.TextStyle = fyne.TextStyle{Italic: true}
+	return container.NewVBox(label, slider, note)
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showMergeDialog lets the user pick the conversation that the messages of
+// sourceID are appended to. The source is deleted after merging.
+func (a *App) showMergeDialog(sourceID int64) {
+	source, err := a.db.GetConversation(sourceID)
+	if err != nil {
+		a.showError("对话不存在")
+		return
+	}
+	conversations, err := a.db.ListConversations(10000, 0) // Large limit to get all
+	if err != nil {
+		a.showError("加载对话失败: " + err.Error())
+		return
+	}
+	var targets []*db.Conversation
+	for _, conv := range conversations {
+		if conv.ID != sourceID {
+			targets = append(targets, conv)
+		}
+	}
+	if len(targets) == 0 {
+		a.showInfo("没有可以合并到的其他对话")
+		return
+	}
+
+	filtered := targets
+	var target *db.Conversation
+
+	note := widget.NewLabel(fmt.Sprintf("“%s”的全部消息将追加到所选对话的末尾，之后删除“%s”。", source.Title, source.Title))
+	note.Wrapping = fyne.TextWrapWord
+
+	var popup *widget.PopUp
+	mergeButton := widget.NewButton("合并", func() {
+		popup.Hide()
+		a.mergeConversation(target.ID, sourceID)
+	})
+	mergeButton.Importance = widget.HighImportance
+	mergeButton.Disable()
+
+	list := widget.NewList(
+		func() int {
+			return len(filtered)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(filtered[id].Title)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		target = filtered[id]
+		mergeButton.Enable()
+	}
+
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("按标题筛选")
+	filterEntry.OnChanged = func(text string) {
+		query := strings.ToLower(strings.TrimSpace(text))
+		filtered = filtered[:0:0]
+		for _, conv := range targets {
+			if strings.Contains(strings.ToLower(conv.Title), query) {
+				filtered = append(filtered, conv)
+			}
+		}
+		target = nil
+		mergeButton.Disable()
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	popup = widget.NewModalPopUp(
+		container.NewBorder(
+			container.NewVBox(widget.NewLabel("合并到…"), note, filterEntry),
+			container.NewHBox(
+				widget.NewButton("取消", func() {
+					popup.Hide()
+				}),
+				mergeButton,
+			),
+			nil,
+			nil,
+			list,
+		),
+		a.window.Canvas(),
+	)
+	popup.Resize(fyne.NewSize(480, 460))
+	popup.Show()
+	a.window.Canvas().Focus(filterEntry)
+}
+
+// mergeConversation appends the messages of sourceID to targetID, closes the
+// tab of the deleted source and shows the result
+func (a *App) mergeConversation(targetID, sourceID int64) {
+	if err := a.db.MergeConversations(targetID, sourceID); err != nil {
+		a.logger.Error("Failed to merge conversation %d into %d: %v", sourceID, targetID, err)
+		a.showError("合并失败: " + err.Error())
+		return
+	}
+	a.logger.Info("Merged conversation %d into %d", sourceID, targetID)
+
+	a.closeChatTab(sourceID)
+	a.RefreshSidebar()
+	// An open tab of the target would miss the appended messages
+	a.reloadConversation(targetID)
+	a.openChatTab(targetID)
+}
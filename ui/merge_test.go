@@ -0,0 +1,36 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"testing"
+)
+
+func TestApp_MergeConversation(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	target, err := a.db.CreateConversation("Target", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if _, err := a.db.CreateMessage(target.ID, "user", "first", "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	_, sourceID := newTestChat(t, a)
+	if _, err := a.db.CreateMessage(sourceID, "user", "second", "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	a.mergeConversation(target.ID, sourceID)
+
+	if _, open := a.chatViews[sourceID]; open {
+		t.Error("the tab of the merged conversation is still open")
+	}
+	if a.getActiveConversationID() != target.ID {
+		t.Errorf("active conversation = %d, want the target %d", a.getActiveConversationID(), target.ID)
+	}
+	messages := waitForMessages(t, a, target.ID, 2)
+	if messages[1].Content != "second" {
+		t.Errorf("unexpected merged messages %+v", messages)
+	}
+}
@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+)
+
+// messageMenuArea wraps a rendered message and shows its context menu on
+// right-click. Clicks on buttons and selectable text are handled by those
+// widgets first.
+type messageMenuArea struct {
+	widget.BaseWidget
+	content fyne.CanvasObject
+	menu    func() *fyne.Menu
+	canvas  fyne.Canvas
+
+	// Search match decorations (see MessageSearchBar), nil if not supported
+	highlight *canvas.Rectangle
+	outline   *canvas.Rectangle
+}
+
+// newMessageMenuArea wraps content; menu is built on every right-click
+func newMessageMenuArea(content fyne.CanvasObject, canvas fyne.Canvas, menu func() *fyne.Menu) *messageMenuArea {
+	area := &messageMenuArea{
+		content: content,
+		menu:    menu,
+		canvas:  canvas,
+	}
+	area.ExtendBaseWidget(area)
+	return area
+}
+
+// CreateRenderer renders the wrapped message
+func (m *messageMenuArea) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(m.content)
+}
+
+// setSearchHighlight shows or hides the background of a search match
+func (m *messageMenuArea) setSearchHighlight(on bool) {
+	setVisible(m.highlight, on)
+}
+
+// setSearchOutline shows or hides the frame of the current search match
+func (m *messageMenuArea) setSearchOutline(on bool) {
+	setVisible(m.outline, on)
+}
+
+func setVisible(obj fyne.CanvasObject, visible bool) {
+	switch {
+	case obj == nil || obj.Visible() == visible:
+	case visible:
+		obj.Show()
+	default:
+		obj.Hide()
+	}
+}
+
+// TappedSecondary shows the context menu
+func (m *messageMenuArea) TappedSecondary(pe *fyne.PointEvent) {
+	widget.ShowPopUpMenuAtPosition(m.menu(), m.canvas, pe.AbsolutePosition)
+}
+
+// buildMessageMenu builds the context menu of a message and selects the
+// message
+func (cv *ChatView) buildMessageMenu(content string, messageIndex int) *fyne.Menu {
+	cv.selectedMessage = messageIndex
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("📋 复制", func() {
+			cv.app.window.Clipboard().SetContent(content)
+		}),
+		fyne.NewMenuItem("🧪 导出为 Go 测试", func() {
+			cv.app.exportConversationAsGoTest(cv.conversationID)
+		}),
+	)
+	if cv.canSplitAtSelected() {
+		menu.Items = append(menu.Items, fyne.NewMenuItem(splitMenuLabel, cv.splitAtSelected))
+	}
+	return menu
+}
+
+// exportConversationAsGoTest writes the conversation as a Go test to the
+// export directory and opens it in the default editor
+func (a *App) exportConversationAsGoTest(conversationID int64) {
+	source, err := utils.ExportConversationAsGoTest(a.db, conversationID)
+	if err != nil {
+		a.showError("导出失败: " + err.Error())
+		return
+	}
+
+	exportDir, err := utils.GetDefaultExportPath(a.config.RecentFiles)
+	if err != nil {
+		a.showError("Failed to get export directory: " + err.Error())
+		return
+	}
+	path := filepath.Join(exportDir, fmt.Sprintf("conversation_%d_test.go", conversationID))
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		a.showError("导出失败: " + err.Error())
+		return
+	}
+
+	a.logger.Info("Exported conversation %d as Go test to %s", conversationID, path)
+	a.addRecentFile(path)
+	if err := utils.OpenWithDefaultApp(path); err != nil {
+		a.logger.Error("Failed to open %s: %v", path, err)
+		a.showInfo("导出成功!\n文件保存在: " + path)
+	}
+}
@@ -0,0 +1,256 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Colors of search matches
+var (
+	searchHighlightColor = color.NRGBA{R: 0xff, G: 0xeb, B: 0x3b, A: 0x66}
+	searchOutlineColor   = color.NRGBA{R: 0xff, G: 0x98, B: 0x00, A: 0xff}
+)
+
+// MessageSearchBar searches the messages of a chat view (Ctrl+F). Messages
+// without the query are hidden, the content of matching ones is highlighted
+// and the current match is outlined.
+type MessageSearchBar struct {
+	cv         *ChatView
+	entry      *messageSearchEntry
+	countLabel *widget.Label
+	bar        *fyne.Container
+
+	query   string
+	matches []int // Indexes of the matching messages in cv.messages
+	current int   // Index in matches of the outlined match
+}
+
+// NewMessageSearchBar creates the search bar of a chat view
+func NewMessageSearchBar(cv *ChatView) *MessageSearchBar {
+	b := &MessageSearchBar{cv: cv}
+	b.entry = newMessageSearchEntry()
+	b.entry.SetPlaceHolder("在对话中搜索... (Enter 下一个, Shift+Enter 上一个, Esc 关闭)")
+	b.entry.OnChanged = b.SetQuery
+	b.entry.onNext = b.Next
+	b.entry.onPrevious = b.Previous
+	b.entry.onEscape = b.Close
+	b.countLabel = widget.NewLabel("")
+	return b
+}
+
+// Build creates the bar, hidden until Show
+func (b *MessageSearchBar) Build() fyne.CanvasObject {
+	previousButton := widget.NewButton("▲", b.Previous)
+	previousButton.Importance = widget.LowImportance
+	nextButton := widget.NewButton("▼", b.Next)
+	nextButton.Importance = widget.LowImportance
+	closeButton := widget.NewButton("✕", b.Close)
+	closeButton.Importance = widget.LowImportance
+
+	b.bar = container.NewBorder(nil, nil, widget.NewLabel("🔍"),
+		container.NewHBox(b.countLabel, previousButton, nextButton, closeButton), b.entry)
+	b.bar.Hide()
+	return b.bar
+}
+
+// Visible reports whether the bar is shown
+func (b *MessageSearchBar) Visible() bool {
+	return b.bar != nil && b.bar.Visible()
+}
+
+// Show shows the bar and focuses its entry
+func (b *MessageSearchBar) Show() {
+	if b.bar == nil {
+		return
+	}
+	b.bar.Show()
+	if c := fyne.CurrentApp().Driver().CanvasForObject(b.entry); c != nil {
+		c.Focus(b.entry)
+	}
+}
+
+// Close clears the filter, shows all messages and hides the bar
+func (b *MessageSearchBar) Close() {
+	if b.bar == nil {
+		return
+	}
+	b.entry.SetText("") // Clears the filter through OnChanged
+	b.SetQuery("")
+	b.bar.Hide()
+	if c := fyne.CurrentApp().Driver().CanvasForObject(b.cv.inputEntry); c != nil {
+		c.Focus(b.cv.inputEntry)
+	}
+}
+
+// SetQuery filters the messages to those containing query, ignoring case
+func (b *MessageSearchBar) SetQuery(query string) {
+	b.query = strings.TrimSpace(query)
+	b.current = 0
+	b.apply()
+	b.scrollToCurrent()
+}
+
+// Reapply filters the messages again after they were rendered anew
+func (b *MessageSearchBar) Reapply() {
+	if b.query == "" {
+		return
+	}
+	b.apply()
+}
+
+// Next outlines and scrolls to the next match
+func (b *MessageSearchBar) Next() {
+	b.step(1)
+}
+
+// Previous outlines and scrolls to the previous match
+func (b *MessageSearchBar) Previous() {
+	b.step(-1)
+}
+
+func (b *MessageSearchBar) step(delta int) {
+	if len(b.matches) == 0 {
+		return
+	}
+	b.current = (b.current + delta + len(b.matches)) % len(b.matches)
+	b.apply()
+	b.scrollToCurrent()
+}
+
+// apply hides, highlights and outlines the rendered messages
+func (b *MessageSearchBar) apply() {
+	cv := b.cv
+	needle := strings.ToLower(b.query)
+	b.matches = b.matches[:0]
+
+	objects := cv.messagesContainer.Objects
+	for i, obj := range objects {
+		// Objects that aren't saved messages yet, like a streaming response, stay
+		if i >= len(cv.messages) {
+			obj.Show()
+			continue
+		}
+		match := needle == "" || strings.Contains(strings.ToLower(cv.displayedContent(i)), needle)
+		if match && needle != "" {
+			b.matches = append(b.matches, i)
+		}
+
+		if match {
+			obj.Show()
+		} else {
+			obj.Hide()
+		}
+		if area, ok := obj.(*messageMenuArea); ok {
+			area.setSearchHighlight(match && needle != "")
+			area.setSearchOutline(false)
+		}
+	}
+
+	if b.current >= len(b.matches) {
+		b.current = 0
+	}
+	if len(b.matches) > 0 {
+		if area, ok := objects[b.matches[b.current]].(*messageMenuArea); ok {
+			area.setSearchOutline(true)
+		}
+	}
+
+	switch {
+	case needle == "":
+		b.countLabel.SetText("")
+	case len(b.matches) == 0:
+		b.countLabel.SetText("无匹配")
+	default:
+		b.countLabel.SetText(fmt.Sprintf("%d/%d", b.current+1, len(b.matches)))
+	}
+	cv.messagesContainer.Refresh()
+}
+
+// scrollToCurrent scrolls the current match to the top
+func (b *MessageSearchBar) scrollToCurrent() {
+	if len(b.matches) == 0 {
+		return
+	}
+	b.cv.messagesContainer.ScrollToRow(b.matches[b.current])
+}
+
+// displayedContent returns the text message i is shown with, the original
+// content unless the anonymized one was toggled on
+func (cv *ChatView) displayedContent(i int) string {
+	msg := cv.messages[i]
+	if msg.OriginalContent == "" || cv.showAnonymized[i] {
+		return msg.Content
+	}
+	return msg.OriginalContent
+}
+
+// messageSearchEntry is the entry of the search bar. Enter and Shift+Enter
+// move between matches, Escape closes the bar.
+type messageSearchEntry struct {
+	widget.Entry
+	onNext, onPrevious, onEscape func()
+	shift                        bool
+}
+
+func newMessageSearchEntry() *messageSearchEntry {
+	e := &messageSearchEntry{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// TypedKey handles Enter and Escape
+func (e *messageSearchEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyReturn, fyne.KeyEnter:
+		if e.shift {
+			e.onPrevious()
+		} else {
+			e.onNext()
+		}
+	case fyne.KeyEscape:
+		e.onEscape()
+	default:
+		e.Entry.TypedKey(key)
+	}
+}
+
+// KeyDown tracks Shift, which Fyne doesn't pass with Enter
+func (e *messageSearchEntry) KeyDown(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shift = true
+	}
+	e.Entry.KeyDown(key)
+}
+
+// KeyUp tracks Shift
+func (e *messageSearchEntry) KeyUp(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shift = false
+	}
+	e.Entry.KeyUp(key)
+}
+
+// newSearchHighlight returns the background shown behind matching content
+func newSearchHighlight() *canvas.Rectangle {
+	highlight := canvas.NewRectangle(searchHighlightColor)
+	highlight.CornerRadius = 4
+	highlight.Hide()
+	return highlight
+}
+
+// newSearchOutline returns the frame around the current match
+func newSearchOutline() *canvas.Rectangle {
+	outline := canvas.NewRectangle(color.Transparent)
+	outline.StrokeColor = searchOutlineColor
+	outline.StrokeWidth = 2
+	outline.CornerRadius = 4
+	outline.Hide()
+	return outline
+}
@@ -0,0 +1,107 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/llm"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+)
+
+func TestMessageSearchBar(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	a.window.Resize(fyne.NewSize(800, 300))
+	conv, err := a.db.CreateConversation("search", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for i := 0; i < 12; i++ {
+		content := fmt.Sprintf("message %d", i)
+		if i%4 == 1 {
+			content += " mentions the Needle"
+		}
+		if _, err := a.db.CreateMessage(conv.ID, "user", content, "mock", "mock", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	a.openChatTab(conv.ID)
+	cv := a.chatViews[conv.ID]
+	select {
+	case <-cv.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the messages to load")
+	}
+
+	bar := cv.searchBar
+	bar.Show()
+	if !bar.Visible() {
+		t.Fatal("expected the search bar to be shown")
+	}
+	test.Type(bar.entry, "needle")
+
+	area := func(i int) *messageMenuArea {
+		return cv.messagesContainer.Objects[i].(*messageMenuArea)
+	}
+	want := []int{1, 5, 9}
+	for i := range cv.messagesContainer.Objects {
+		match := i%4 == 1
+		if area(i).Visible() != match {
+			t.Errorf("message %d visible = %v, want %v", i, area(i).Visible(), match)
+		}
+		if area(i).highlight.Visible() != match {
+			t.Errorf("message %d highlighted = %v, want %v", i, area(i).highlight.Visible(), match)
+		}
+	}
+	outlined := func() int {
+		for _, i := range want {
+			if area(i).outline.Visible() {
+				return i
+			}
+		}
+		return -1
+	}
+	if got := outlined(); got != 1 {
+		t.Errorf("outlined message = %d, want 1", got)
+	}
+	if bar.countLabel.Text != "1/3" {
+		t.Errorf("count = %q, want 1/3", bar.countLabel.Text)
+	}
+
+	// Enter moves to the next match, Shift+Enter back; both wrap around
+	bar.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	if got := outlined(); got != 5 {
+		t.Errorf("outlined message after Enter = %d, want 5", got)
+	}
+	bar.entry.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	bar.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	bar.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	bar.entry.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	if got := outlined(); got != 9 {
+		t.Errorf("outlined message after Shift+Enter twice = %d, want 9", got)
+	}
+	if cv.messagesScroll.Offset.Y <= 0 {
+		t.Error("expected the list to scroll to the last match")
+	}
+
+	test.Type(bar.entry, "-missing")
+	if bar.countLabel.Text != "无匹配" {
+		t.Errorf("count = %q, want no matches", bar.countLabel.Text)
+	}
+
+	// Escape shows all messages again
+	bar.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEscape})
+	if bar.Visible() || bar.entry.Text != "" {
+		t.Error("expected Escape to clear and hide the search bar")
+	}
+	for i := range cv.messagesContainer.Objects {
+		if !area(i).Visible() || area(i).highlight.Visible() || area(i).outline.Visible() {
+			t.Errorf("message %d is still filtered after Escape", i)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Limits of the notification list
+const (
+	notificationReadRetention = 24 * time.Hour
+	maxNotifications          = 100
+)
+
+// NotificationLevel is the severity of a notification
+type NotificationLevel int
+
+const (
+	NotificationInfo NotificationLevel = iota
+	NotificationSuccess
+	NotificationWarn
+	NotificationError
+)
+
+// Icon returns the emoji shown next to notifications of the level
+func (l NotificationLevel) Icon() string {
+	switch l {
+	case NotificationSuccess:
+		return "✅"
+	case NotificationWarn:
+		return "⚠️"
+	case NotificationError:
+		return "❌"
+	default:
+		return "ℹ️"
+	}
+}
+
+// title returns the heading of the transient popup
+func (l NotificationLevel) title() string {
+	switch l {
+	case NotificationSuccess:
+		return "✅ Success"
+	case NotificationWarn:
+		return "⚠️ 警告"
+	case NotificationError:
+		return "❌ Error"
+	default:
+		return "ℹ️ 信息"
+	}
+}
+
+// Notification is one entry of the notification center
+type Notification struct {
+	ID      int
+	Level   NotificationLevel
+	Message string
+	Time    time.Time
+	Read    bool
+}
+
+// NotificationCenter keeps the messages shown by showError, showSuccess and
+// showInfo so they can be read again after their popup is closed. It is only
+// used from the UI goroutine.
+type NotificationCenter struct {
+	app           *App
+	notifications []*Notification // Newest first
+	nextID        int
+
+	button *widget.Button
+	panel  *widget.PopUp
+	list   *fyne.Container
+}
+
+// NewNotificationCenter creates an empty notification center
+func NewNotificationCenter(app *App) *NotificationCenter {
+	nc := &NotificationCenter{
+		app:  app,
+		list: container.NewVBox(),
+	}
+	nc.button = widget.NewButton("", nc.showPanel)
+	nc.updateBadge()
+	return nc
+}
+
+// Button returns the bell button with the unread count
+func (nc *NotificationCenter) Button() *widget.Button {
+	return nc.button
+}
+
+// Add shows message in a popup and keeps it in the notification list
+func (nc *NotificationCenter) Add(level NotificationLevel, message string) {
+	nc.nextID++
+	nc.notifications = append([]*Notification{{
+		ID:      nc.nextID,
+		Level:   level,
+		Message: message,
+		Time:    time.Now(),
+	}}, nc.notifications...)
+	nc.prune(time.Now())
+	nc.refresh()
+
+	var popup *widget.PopUp
+	popup = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel(level.title()),
+			widget.NewLabel(message),
+			widget.NewButton("确定", func() {
+				popup.Hide()
+			}),
+		),
+		nc.app.window.Canvas(),
+	)
+	popup.Show()
+}
+
+// Dismiss removes a notification from the list
+func (nc *NotificationCenter) Dismiss(id int) {
+	for i, n := range nc.notifications {
+		if n.ID == id {
+			nc.notifications = append(nc.notifications[:i], nc.notifications[i+1:]...)
+			break
+		}
+	}
+	nc.refresh()
+}
+
+// UnreadCount returns the number of notifications not yet seen in the panel
+func (nc *NotificationCenter) UnreadCount() int {
+	count := 0
+	for _, n := range nc.notifications {
+		if !n.Read {
+			count++
+		}
+	}
+	return count
+}
+
+// prune drops read notifications older than a day and the oldest ones
+// beyond maxNotifications
+func (nc *NotificationCenter) prune(now time.Time) {
+	kept := nc.notifications[:0]
+	for _, n := range nc.notifications {
+		if n.Read && now.Sub(n.Time) > notificationReadRetention {
+			continue
+		}
+		kept = append(kept, n)
+	}
+	if len(kept) > maxNotifications {
+		kept = kept[:maxNotifications]
+	}
+	nc.notifications = kept
+}
+
+// updateBadge shows the unread count on the bell button
+func (nc *NotificationCenter) updateBadge() {
+	if unread := nc.UnreadCount(); unread > 0 {
+		nc.button.SetText(fmt.Sprintf("🔔 %d", unread))
+		nc.button.Importance = widget.HighImportance
+	} else {
+		nc.button.SetText("🔔")
+		nc.button.Importance = widget.LowImportance
+	}
+	nc.button.Refresh()
+}
+
+// refresh updates the badge and, while it is open, the panel
+func (nc *NotificationCenter) refresh() {
+	nc.updateBadge()
+	if nc.panel == nil || !nc.panel.Visible() {
+		return
+	}
+
+	rows := make([]fyne.CanvasObject, 0, len(nc.notifications))
+	for _, n := range nc.notifications {
+		rows = append(rows, nc.buildRow(n))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, widget.NewLabel("暂无通知"))
+	}
+	nc.list.Objects = rows
+	nc.list.Refresh()
+}
+
+// buildRow renders one notification with its dismiss button
+func (nc *NotificationCenter) buildRow(n *Notification) fyne.CanvasObject {
+	header := widget.NewLabel(n.Level.Icon() + " " + n.Time.Format("01-02 15:04:05"))
+	header.TextStyle = fyne.TextStyle{Bold: !n.Read}
+
+	message := widget.NewLabel(n.Message)
+	message.Wrapping = fyne.TextWrapWord
+
+	id := n.ID
+	dismissButton := widget.NewButton("×", func() {
+		nc.Dismiss(id)
+	})
+	dismissButton.Importance = widget.LowImportance
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, nil, dismissButton, header),
+		message,
+		widget.NewSeparator(),
+	)
+}
+
+// showPanel lists the notifications. The unread ones are highlighted once
+// and marked as read.
+func (nc *NotificationCenter) showPanel() {
+	nc.prune(time.Now())
+
+	if nc.panel == nil {
+		title := widget.NewLabel("通知")
+		title.TextStyle = fyne.TextStyle{Bold: true}
+
+		clearButton := widget.NewButton("全部清除", func() {
+			nc.notifications = nil
+			nc.refresh()
+		})
+		closeButton := widget.NewButton("关闭", func() {
+			nc.panel.Hide()
+		})
+
+		nc.panel = widget.NewModalPopUp(
+			container.NewBorder(
+				container.NewVBox(title, widget.NewSeparator()),
+				container.NewHBox(clearButton, closeButton),
+				nil,
+				nil,
+				container.NewVScroll(nc.list),
+			),
+			nc.app.window.Canvas(),
+		)
+		nc.panel.Resize(fyne.NewSize(460, 420))
+	}
+
+	nc.panel.Show()
+	nc.refresh()
+
+	// The rows were rendered as unread; the badge clears right away
+	for _, n := range nc.notifications {
+		n.Read = true
+	}
+	nc.updateBadge()
+}
@@ -0,0 +1,213 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Defaults shown for the sampling settings a provider config leaves unset
+const (
+	defaultTemperature = 0.7
+	defaultTopP        = 1.0
+)
+
+// paramSlider edits an optional sampling setting: unchecked, the provider's
+// value is shown and used
+type paramSlider struct {
+	check  *widget.Check
+	slider *widget.Slider
+	value  *widget.Label
+}
+
+// newParamSlider creates a slider for values between min and max
+func newParamSlider(label string, min, max float64) *paramSlider {
+	p := &paramSlider{
+		slider: widget.NewSlider(min, max),
+		value:  widget.NewLabel(""),
+	}
+	p.slider.Step = 0.05
+	p.slider.Disable()
+	p.slider.OnChanged = func(value float64) {
+		p.value.SetText(fmt.Sprintf("%.2f", value))
+	}
+	p.check = widget.NewCheck(label, func(checked bool) {
+		if checked {
+			p.slider.Enable()
+		} else {
+			p.slider.Disable()
+		}
+	})
+	return p
+}
+
+// set shows the override, or fallback as the provider's value if there is none
+func (p *paramSlider) set(override *float64, fallback float64) {
+	value := fallback
+	if override != nil {
+		value = *override
+	}
+	p.check.SetChecked(override != nil)
+	p.slider.SetValue(value)
+	p.value.SetText(fmt.Sprintf("%.2f", value))
+	if override != nil {
+		p.slider.Enable()
+	} else {
+		p.slider.Disable()
+	}
+}
+
+// override returns the value if it is overridden, nil otherwise
+func (p *paramSlider) override() *float64 {
+	if !p.check.Checked {
+		return nil
+	}
+	value := p.slider.Value
+	return &value
+}
+
+// row lays out the slider with its label and value
+func (p *paramSlider) row() fyne.CanvasObject {
+	return container.NewBorder(nil, nil, p.check, p.value, p.slider)
+}
+
+// paramsEditor edits the parameter overrides of a conversation
+type paramsEditor struct {
+	temperature *paramSlider
+	topP        *paramSlider
+	maxTokens   *widget.Entry
+}
+
+// buildParamsItem builds the collapsible editor of the conversation's
+// temperature, top-p and max tokens
+func (cv *ChatView) buildParamsItem() *widget.AccordionItem {
+	editor := &paramsEditor{
+		temperature: newParamSlider("温度", 0, 2),
+		topP:        newParamSlider("Top-p", 0, 1),
+		maxTokens:   widget.NewEntry(),
+	}
+	cv.paramsEditor = editor
+	cv.showParams(nil)
+
+	saveButton := widget.NewButton("保存", cv.saveParams)
+	resetButton := widget.NewButton("恢复默认", func() {
+		cv.showParams(nil)
+		cv.saveParams()
+	})
+
+	return widget.NewAccordionItem("参数", container.NewVBox(
+		editor.temperature.row(),
+		editor.topP.row(),
+		container.NewBorder(nil, nil, widget.NewLabel("最大 Token 数"), nil, editor.maxTokens),
+		container.NewHBox(saveButton, resetButton),
+	))
+}
+
+// providerParams returns the sampling settings the current provider is
+// configured with, with the defaults for unset ones
+func (cv *ChatView) providerParams() (temperature, topP float64, maxTokens int) {
+	config := cv.app.config.LLMProviders[cv.currentProvider]
+	temperature, topP, maxTokens = config.Temperature, defaultTopP, config.MaxTokens
+	if temperature == 0 {
+		temperature = defaultTemperature
+	}
+	return temperature, topP, maxTokens
+}
+
+// loadParams shows the stored parameter overrides of the conversation
+func (cv *ChatView) loadParams() {
+	conversationID := cv.conversationID
+	if conversationID == 0 {
+		return
+	}
+	utils.SafeGo(cv.app.logger, "loadParams", func() {
+		conv, err := cv.app.db.GetConversation(conversationID)
+		if err != nil {
+			cv.app.logger.Warn("Failed to load parameters of conversation %d: %v", conversationID, err)
+			return
+		}
+		fyne.Do(func() {
+			if cv.conversationID == conversationID {
+				cv.showParams(conv.ParamsOverride)
+			}
+		})
+	})
+}
+
+// showParams puts params in the editor, the provider's settings where they
+// override nothing. Must be called on the UI thread.
+func (cv *ChatView) showParams(params *db.ConversationParams) {
+	if cv.paramsEditor == nil {
+		return
+	}
+	if params == nil {
+		params = &db.ConversationParams{}
+	}
+	temperature, topP, maxTokens := cv.providerParams()
+	cv.paramsEditor.temperature.set(params.Temperature, temperature)
+	cv.paramsEditor.topP.set(params.TopP, topP)
+
+	if params.MaxTokens != nil {
+		cv.paramsEditor.maxTokens.SetText(strconv.Itoa(*params.MaxTokens))
+	} else {
+		cv.paramsEditor.maxTokens.SetText("")
+	}
+	if maxTokens > 0 {
+		cv.paramsEditor.maxTokens.SetPlaceHolder(fmt.Sprintf("提供商设置: %d", maxTokens))
+	} else {
+		cv.paramsEditor.maxTokens.SetPlaceHolder("提供商设置")
+	}
+}
+
+// saveParams stores the overrides in the editor with the conversation
+func (cv *ChatView) saveParams() {
+	if cv.conversationID == 0 {
+		cv.app.showError("请先创建对话")
+		return
+	}
+
+	params := &db.ConversationParams{
+		Temperature: cv.paramsEditor.temperature.override(),
+		TopP:        cv.paramsEditor.topP.override(),
+	}
+	if text := strings.TrimSpace(cv.paramsEditor.maxTokens.Text); text != "" {
+		maxTokens, err := strconv.Atoi(text)
+		if err != nil || maxTokens <= 0 {
+			cv.app.showError("最大 Token 数必须是正整数")
+			return
+		}
+		params.MaxTokens = &maxTokens
+	}
+
+	if err := cv.app.db.UpdateConversationParams(cv.conversationID, params); err != nil {
+		cv.app.logger.Error("Failed to save parameters: %v", err)
+		cv.app.showError("保存参数失败: " + err.Error())
+		return
+	}
+	cv.app.logger.Info("Saved parameters of conversation %d", cv.conversationID)
+}
+
+// withConversationParams returns provider with the stored parameter
+// overrides of the conversation applied
+func (cv *ChatView) withConversationParams(provider llm.Provider) llm.Provider {
+	conv, err := cv.app.db.GetConversation(cv.conversationID)
+	if err != nil {
+		cv.app.logger.Warn("Failed to get parameters of conversation %d: %v", cv.conversationID, err)
+		return provider
+	}
+	if conv.ParamsOverride.IsEmpty() {
+		return provider
+	}
+	return provider.WithParams(llm.GenerationParams{
+		Temperature: conv.ParamsOverride.Temperature,
+		MaxTokens:   conv.ParamsOverride.MaxTokens,
+		TopP:        conv.ParamsOverride.TopP,
+	})
+}
@@ -0,0 +1,338 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"light-llm-client/utils"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// promptRatingOptions are the choices of the rating select, the index is the rating
+var promptRatingOptions = []string{"未评分", "1 ★", "2 ★★", "3 ★★★", "4 ★★★★", "5 ★★★★★"}
+
+// PromptEngineeringPanel lists pairs of system-prompt variants with the
+// statistics of the conversations run as tests of them
+type PromptEngineeringPanel struct {
+	sv     *SettingsView
+	list   *fyne.Container
+	status *widget.Label
+}
+
+// NewPromptEngineeringPanel creates the A/B test panel of the settings
+func NewPromptEngineeringPanel(sv *SettingsView) *PromptEngineeringPanel {
+	return &PromptEngineeringPanel{
+		sv:     sv,
+		list:   container.NewVBox(),
+		status: widget.NewLabel(""),
+	}
+}
+
+// Build builds the panel UI
+func (p *PromptEngineeringPanel) Build() fyne.CanvasObject {
+	toolbar := container.NewHBox(
+		widget.NewButton("➕ 新建对照组", func() {
+			p.showCreateDialog()
+		}),
+		widget.NewButton("🔄 刷新", func() {
+			p.Refresh()
+		}),
+		widget.NewButton("导出 CSV", func() {
+			p.exportCSV()
+		}),
+	)
+	note := widget.NewLabel("在对话列表中右键对话，选择“标记为 A/B 测试”记录它使用的提示词和评分。评分 ≥ 4 计为好结果。")
+	note.Wrapping = fyne.TextWrapWord
+
+	p.Refresh()
+	return container.NewBorder(
+		container.NewVBox(toolbar, note, p.status, widget.NewSeparator()),
+		nil,
+		nil,
+		nil,
+		container.NewVScroll(p.list),
+	)
+}
+
+// Refresh reloads the pairs and their statistics
+func (p *PromptEngineeringPanel) Refresh() {
+	stats, err := p.sv.app.db.GetPromptABStats()
+	if err != nil {
+		p.sv.app.logger.Error("Failed to load prompt A/B statistics: %v", err)
+		p.status.SetText("加载失败: " + err.Error())
+		return
+	}
+
+	if len(stats) == 0 {
+		p.status.SetText("还没有对照组")
+	} else {
+		p.status.SetText(fmt.Sprintf("%d 个对照组", len(stats)))
+	}
+
+	p.list.Objects = p.list.Objects[:0]
+	for _, s := range stats {
+		p.list.Add(p.buildPairCard(s))
+	}
+	p.list.Refresh()
+}
+
+// buildPairCard shows the variants and statistics of a pair
+func (p *PromptEngineeringPanel) buildPairCard(s *db.PromptABStats) fyne.CanvasObject {
+	promptLabel := func(variant *db.PromptVariant) *widget.Label {
+		preview := strings.Join(strings.Fields(variant.SystemPrompt), " ")
+		label := widget.NewLabel(variant.Label + ": " + truncateRunes(preview, 80))
+		label.Truncation = fyne.TextTruncateEllipsis
+		return label
+	}
+	meanText := func(mean float64, rated int) string {
+		if rated == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f (%d 次评分)", mean, rated)
+	}
+
+	significance := ""
+	if s.RatedA > 0 && s.RatedB > 0 && s.PValue < 0.05 {
+		significance = " (显著)"
+	}
+
+	pair := s.Pair
+	deleteButton := widget.NewButton("删除", func() {
+		p.confirmDelete(pair)
+	})
+	deleteButton.Importance = widget.DangerImportance
+
+	return widget.NewCard(pair.Name, "", container.NewVBox(
+		promptLabel(pair.A),
+		promptLabel(pair.B),
+		widget.NewSeparator(),
+		widget.NewLabel(fmt.Sprintf("测试运行: A %d 次 | B %d 次", s.RunsA, s.RunsB)),
+		widget.NewLabel(fmt.Sprintf("平均评分: A %s | B %s", meanText(s.MeanA, s.RatedA), meanText(s.MeanB, s.RatedB))),
+		widget.NewLabel(fmt.Sprintf("逐次对比: A 胜 %d | B 胜 %d | 平 %d", s.Wins, s.Losses, s.Ties)),
+		widget.NewLabel(fmt.Sprintf("好结果: A %d/%d | B %d/%d | Fisher 精确检验 p = %.4f%s",
+			s.GoodA, s.RatedA, s.GoodB, s.RatedB, s.PValue, significance)),
+		container.NewHBox(deleteButton),
+	))
+}
+
+// showCreateDialog asks for the name and the two system prompts of a new pair
+func (p *PromptEngineeringPanel) showCreateDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("对照组名称")
+	promptAEntry := widget.NewMultiLineEntry()
+	promptAEntry.SetPlaceHolder("变体 A 的系统提示词")
+	promptAEntry.SetMinRowsVisible(4)
+	promptBEntry := widget.NewMultiLineEntry()
+	promptBEntry.SetPlaceHolder("变体 B 的系统提示词")
+	promptBEntry.SetMinRowsVisible(4)
+
+	var dialog *widget.PopUp
+	dialog = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel("新建提示词对照组"),
+			nameEntry,
+			widget.NewLabel("变体 A:"),
+			promptAEntry,
+			widget.NewLabel("变体 B:"),
+			promptBEntry,
+			container.NewHBox(
+				widget.NewButton("取消", func() {
+					dialog.Hide()
+				}),
+				widget.NewButton("创建", func() {
+					pair, err := p.sv.app.db.CreatePromptABPair(nameEntry.Text, promptAEntry.Text, promptBEntry.Text)
+					if err != nil {
+						p.sv.showError("创建对照组失败: " + err.Error())
+						return
+					}
+					dialog.Hide()
+					p.sv.app.logger.Info("Created prompt A/B pair %d (%s)", pair.ID, pair.Name)
+					p.Refresh()
+				}),
+			),
+		),
+		p.sv.getCanvas(),
+	)
+	dialog.Resize(fyne.NewSize(520, 0))
+	dialog.Show()
+}
+
+// confirmDelete deletes a pair with its test runs after confirmation
+func (p *PromptEngineeringPanel) confirmDelete(pair *db.PromptABPair) {
+	var dialog *widget.PopUp
+	dialog = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("删除对照组 %q 及其所有测试记录？对话本身不会被删除。", pair.Name)),
+			container.NewHBox(
+				widget.NewButton("取消", func() {
+					dialog.Hide()
+				}),
+				widget.NewButton("删除", func() {
+					dialog.Hide()
+					if err := p.sv.app.db.DeletePromptABPair(pair.ID); err != nil {
+						p.sv.app.logger.Error("Failed to delete prompt A/B pair %d: %v", pair.ID, err)
+						p.sv.showError("删除失败: " + err.Error())
+						return
+					}
+					p.Refresh()
+				}),
+			),
+		),
+		p.sv.getCanvas(),
+	)
+	dialog.Show()
+}
+
+// exportCSV exports every test run to a CSV file
+func (p *PromptEngineeringPanel) exportCSV() {
+	app := p.sv.app
+	pairs, err := app.db.ListPromptABPairs()
+	if err != nil {
+		p.sv.showError("导出失败: " + err.Error())
+		return
+	}
+	tests, err := app.db.ListPromptABTests()
+	if err != nil {
+		p.sv.showError("导出失败: " + err.Error())
+		return
+	}
+	if len(tests) == 0 {
+		p.sv.showError("暂无测试记录")
+		return
+	}
+
+	prompts := make(map[int64]string)
+	for _, pair := range pairs {
+		prompts[pair.A.ID] = pair.A.SystemPrompt
+		prompts[pair.B.ID] = pair.B.SystemPrompt
+	}
+
+	headers := []string{"pair_id", "pair_name", "variant_id", "variant", "system_prompt", "conversation_id", "conversation_title", "user_rating", "created_at"}
+	rows := make([][]string, 0, len(tests))
+	for _, test := range tests {
+		rating := ""
+		if test.UserRating > 0 {
+			rating = fmt.Sprintf("%d", test.UserRating)
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", test.PairID),
+			test.PairName,
+			fmt.Sprintf("%d", test.VariantID),
+			test.Label,
+			prompts[test.VariantID],
+			fmt.Sprintf("%d", test.ConversationID),
+			test.ConversationTitle,
+			rating,
+			test.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	exportDir, err := utils.GetDefaultExportPath(app.config.RecentFiles)
+	if err != nil {
+		p.sv.showError("Failed to get export directory: " + err.Error())
+		return
+	}
+	path := filepath.Join(exportDir, utils.GenerateExportFilename("prompt_ab_tests", utils.FormatCSV))
+	if err := utils.ExportToCSV(path, headers, rows); err != nil {
+		p.sv.showError("Export failed: " + err.Error())
+		return
+	}
+
+	app.logger.Info("Exported %d prompt A/B test runs to %s", len(rows), path)
+	app.addRecentFile(path)
+	p.sv.showSuccess("导出成功!\n文件保存在: " + path)
+}
+
+// showPromptABTestDialog marks a conversation as a test run of a prompt
+// variant and records the user's rating of it
+func (a *App) showPromptABTestDialog(conversationID int64) {
+	pairs, err := a.db.ListPromptABPairs()
+	if err != nil {
+		a.showError("加载提示词对照组失败: " + err.Error())
+		return
+	}
+	if len(pairs) == 0 {
+		a.showInfo("还没有提示词对照组，请先在 设置 → Prompt A/B 中创建")
+		return
+	}
+
+	var variants []*db.PromptVariant
+	var options []string
+	for _, pair := range pairs {
+		for _, variant := range []*db.PromptVariant{pair.A, pair.B} {
+			variants = append(variants, variant)
+			options = append(options, fmt.Sprintf("%s - 变体 %s", pair.Name, variant.Label))
+		}
+	}
+
+	promptLabel := widget.NewLabel("")
+	promptLabel.Wrapping = fyne.TextWrapWord
+	var variantSelect *widget.Select
+	variantSelect = widget.NewSelect(options, func(string) {
+		promptLabel.SetText(truncateRunes(variants[variantSelect.SelectedIndex()].SystemPrompt, 300))
+	})
+	ratingSelect := widget.NewSelect(promptRatingOptions, nil)
+
+	existing, err := a.db.GetPromptABTest(conversationID)
+	if err != nil {
+		a.logger.Warn("Failed to load test run of conversation %d: %v", conversationID, err)
+	}
+	variantSelect.SetSelectedIndex(0)
+	ratingSelect.SetSelectedIndex(0)
+	if existing != nil {
+		for i, variant := range variants {
+			if variant.ID == existing.VariantID {
+				variantSelect.SetSelectedIndex(i)
+			}
+		}
+		ratingSelect.SetSelectedIndex(existing.UserRating)
+	}
+
+	var dialog *widget.PopUp
+	buttons := container.NewHBox(
+		widget.NewButton("取消", func() {
+			dialog.Hide()
+		}),
+		widget.NewButton("复制提示词", func() {
+			a.window.Clipboard().SetContent(variants[variantSelect.SelectedIndex()].SystemPrompt)
+		}),
+		widget.NewButton("保存", func() {
+			variant := variants[variantSelect.SelectedIndex()]
+			if err := a.db.RecordPromptABTest(variant.ID, conversationID, ratingSelect.SelectedIndex()); err != nil {
+				a.logger.Error("Failed to record test run of conversation %d: %v", conversationID, err)
+				a.showError("保存失败: " + err.Error())
+				return
+			}
+			dialog.Hide()
+			a.logger.Info("Recorded conversation %d as test run of prompt variant %d", conversationID, variant.ID)
+		}),
+	)
+	if existing != nil {
+		buttons.Add(widget.NewButton("取消标记", func() {
+			if err := a.db.RemovePromptABTest(conversationID); err != nil {
+				a.showError("操作失败: " + err.Error())
+				return
+			}
+			dialog.Hide()
+		}))
+	}
+
+	dialog = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel("🧪 标记为 A/B 测试"),
+			widget.NewLabel("提示词变体:"),
+			variantSelect,
+			promptLabel,
+			widget.NewLabel("评分:"),
+			ratingSelect,
+			buttons,
+		),
+		a.window.Canvas(),
+	)
+	dialog.Resize(fyne.NewSize(480, 0))
+	dialog.Show()
+}
@@ -0,0 +1,42 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestPromptEngineeringPanel_ShowsPairStats(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+
+	pair, err := a.db.CreatePromptABPair("Tone", "Be formal.", "Be casual.")
+	if err != nil {
+		t.Fatalf("CreatePromptABPair failed: %v", err)
+	}
+	conv, err := a.db.CreateConversation("run", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if err := a.db.RecordPromptABTest(pair.A.ID, conv.ID, 5); err != nil {
+		t.Fatalf("RecordPromptABTest failed: %v", err)
+	}
+
+	panel := NewPromptEngineeringPanel(NewSettingsView(a))
+	panel.Build()
+
+	if len(panel.list.Objects) != 1 {
+		t.Fatalf("expected 1 pair card, got %d", len(panel.list.Objects))
+	}
+	found := findObject(panel.list, func(o fyne.CanvasObject) bool {
+		label, ok := o.(*widget.Label)
+		return ok && strings.HasPrefix(label.Text, "测试运行: A 1 次 | B 0 次")
+	})
+	if found == nil {
+		t.Error("run counts not shown")
+	}
+}
@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// providerHealthTimeout limits each health check, which only needs the API
+// to answer a short message
+const providerHealthTimeout = 5 * time.Second
+
+// providerHealthState is the result of a provider health check
+type providerHealthState int
+
+const (
+	healthUnknown  providerHealthState = iota // Disabled or not checked
+	healthChecking                            // Check running
+	healthOK
+	healthFailed
+)
+
+// providerHealth is the state of a provider's last health check
+type providerHealth struct {
+	state providerHealthState
+	err   error // Why the check failed
+}
+
+// checkAllProviders checks the connection of every enabled provider in the
+// background and shows the results as the dots in the provider list
+func (sv *SettingsView) checkAllProviders() {
+	sv.healthRun++
+	run := sv.healthRun
+	sv.health = make(map[string]providerHealth)
+
+	for name, config := range sv.providerConfigs {
+		if !config.Enabled {
+			continue
+		}
+		provider, ok := sv.app.providers[name]
+		if !ok {
+			sv.health[name] = providerHealth{state: healthFailed, err: errors.New("provider is not initialized, check its settings and the log")}
+			continue
+		}
+
+		sv.health[name] = providerHealth{state: healthChecking}
+		name := name
+		utils.SafeGo(sv.app.logger, "checkProviderHealth", func() {
+			err := checkProviderHealth(provider)
+			if err != nil {
+				sv.app.logger.Warn("Health check of provider %s failed: %v", name, err)
+			}
+			fyne.Do(func() {
+				// Results of an earlier run are outdated
+				if run != sv.healthRun {
+					return
+				}
+				health := providerHealth{state: healthOK}
+				if err != nil {
+					health = providerHealth{state: healthFailed, err: err}
+				}
+				sv.health[name] = health
+				if sv.providersList != nil {
+					sv.providersList.Refresh()
+				}
+			})
+		})
+	}
+
+	if sv.providersList != nil {
+		sv.providersList.Refresh()
+	}
+}
+
+// checkProviderHealth validates the provider's configuration and sends it a
+// short message
+func checkProviderHealth(provider llm.Provider) error {
+	if err := provider.ValidateConfig(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), providerHealthTimeout)
+	defer cancel()
+	_, err := provider.Chat(ctx, []llm.Message{{Role: "user", Content: "Hello"}})
+	return err
+}
+
+// statusDot is a coloured dot for the health of a provider. Hovering a failed
+// provider's dot shows the error.
+type statusDot struct {
+	widget.BaseWidget
+	circle  *canvas.Circle
+	message string
+	tooltip *widget.PopUp
+}
+
+// newStatusDot creates a grey dot
+func newStatusDot() *statusDot {
+	d := &statusDot{circle: canvas.NewCircle(color.Gray{Y: 160})}
+	d.ExtendBaseWidget(d)
+	return d
+}
+
+// SetHealth shows the health of a provider
+func (d *statusDot) SetHealth(health providerHealth) {
+	switch health.state {
+	case healthOK:
+		d.circle.FillColor = color.NRGBA{R: 46, G: 160, B: 67, A: 255}
+	case healthFailed:
+		d.circle.FillColor = color.NRGBA{R: 218, G: 54, B: 51, A: 255}
+	case healthChecking:
+		d.circle.FillColor = color.NRGBA{R: 210, G: 160, B: 40, A: 255}
+	default:
+		d.circle.FillColor = color.Gray{Y: 160}
+	}
+	d.message = ""
+	if health.err != nil {
+		d.message = health.err.Error()
+	}
+	d.circle.Refresh()
+}
+
+// CreateRenderer implements fyne.Widget
+func (d *statusDot) CreateRenderer() fyne.WidgetRenderer {
+	return &statusDotRenderer{dot: d}
+}
+
+// MouseIn shows the error of a failed check
+func (d *statusDot) MouseIn(*desktop.MouseEvent) {
+	if d.message == "" {
+		return
+	}
+	c := fyne.CurrentApp().Driver().CanvasForObject(d)
+	if c == nil {
+		return
+	}
+	label := widget.NewLabel(truncateRunes(d.message, 300))
+	label.Wrapping = fyne.TextWrapWord
+	d.tooltip = widget.NewPopUp(label, c)
+	d.tooltip.Resize(fyne.NewSize(360, label.MinSize().Height))
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(d)
+	d.tooltip.ShowAtPosition(pos.Add(fyne.NewPos(0, d.Size().Height+4)))
+}
+
+// MouseMoved implements desktop.Hoverable
+func (d *statusDot) MouseMoved(*desktop.MouseEvent) {}
+
+// MouseOut hides the error
+func (d *statusDot) MouseOut() {
+	if d.tooltip != nil {
+		d.tooltip.Hide()
+		d.tooltip = nil
+	}
+}
+
+// statusDotSize is the diameter of a status dot
+const statusDotSize = 10
+
+// statusDotRenderer centers the dot vertically in its row
+type statusDotRenderer struct {
+	dot *statusDot
+}
+
+func (r *statusDotRenderer) Layout(size fyne.Size) {
+	r.dot.circle.Resize(fyne.NewSize(statusDotSize, statusDotSize))
+	r.dot.circle.Move(fyne.NewPos((size.Width-statusDotSize)/2, (size.Height-statusDotSize)/2))
+}
+
+func (r *statusDotRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(statusDotSize+4, statusDotSize+4)
+}
+
+func (r *statusDotRenderer) Refresh() {
+	r.dot.circle.Refresh()
+}
+
+func (r *statusDotRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.dot.circle}
+}
+
+func (r *statusDotRenderer) Destroy() {}
@@ -0,0 +1,66 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"errors"
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// invalidProvider is a mock provider whose configuration is invalid
+type invalidProvider struct {
+	*llm.MockProvider
+}
+
+func (p invalidProvider) ValidateConfig() error {
+	return errors.New("API key is required")
+}
+
+func TestSettingsView_ProviderHealth(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{Responses: []string{"pong"}}))
+	a.providers["invalid"] = invalidProvider{llm.NewMockProvider(llm.MockConfig{Responses: []string{"pong"}})}
+	a.config.LLMProviders = map[string]utils.ProviderConfig{
+		"mock":    {Enabled: true},
+		"invalid": {Enabled: true},
+		"missing": {Enabled: true},
+		"off":     {},
+	}
+	sv := NewSettingsView(a)
+	window := test.NewTempWindow(t, sv.buildProvidersTab())
+
+	waitUntil(t, "the health checks", func() bool {
+		return sv.health["mock"].state == healthOK && sv.health["invalid"].state == healthFailed
+	})
+	if h := sv.health["missing"]; h.state != healthFailed || h.err == nil {
+		t.Errorf("missing provider health = %+v, want failed", h)
+	}
+	if h := sv.health["off"]; h.state != healthUnknown {
+		t.Errorf("disabled provider health = %+v, want unknown", h)
+	}
+
+	// The dot of a failed provider shows the error when hovered
+	dot := newStatusDot()
+	window.SetContent(dot)
+	dot.SetHealth(sv.health["invalid"])
+	dot.MouseIn(nil)
+	if dot.tooltip == nil || !dot.tooltip.Visible() {
+		t.Fatal("expected a tooltip for the failed provider")
+	}
+	dot.MouseOut()
+	if dot.tooltip != nil {
+		t.Error("expected the tooltip to be hidden")
+	}
+
+	// Re-checking starts over
+	sv.checkAllProviders()
+	if sv.health["mock"].state != healthChecking {
+		t.Errorf("mock provider health = %+v, want checking", sv.health["mock"])
+	}
+	waitUntil(t, "the re-check", func() bool {
+		return sv.health["mock"].state == healthOK
+	})
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"image/color"
+	"light-llm-client/llm"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// nextProviderDefault is the option of the next-message provider dropdown
+// that keeps the provider selected at the top
+const nextProviderDefault = "当前提供商"
+
+// newNextProviderSelect creates the dropdown below the send button that picks
+// a different provider for the next message only
+func (cv *ChatView) newNextProviderSelect() *widget.Select {
+	cv.nextProviderSelect = widget.NewSelect(nil, func(value string) {
+		if value != nextProviderDefault {
+			cv.app.logger.Info("Next message goes to provider: %s", value)
+		}
+	})
+	cv.refreshNextProviderOptions()
+	return cv.nextProviderSelect
+}
+
+// refreshNextProviderOptions lists the available providers in the
+// next-message dropdown, falling back to the main selection when the picked
+// one was removed
+func (cv *ChatView) refreshNextProviderOptions() {
+	if cv.nextProviderSelect == nil {
+		return
+	}
+	names := make([]string, 0, len(cv.app.providers))
+	for name := range cv.app.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cv.nextProviderSelect.Options = append([]string{nextProviderDefault}, names...)
+
+	if _, ok := cv.app.providers[cv.nextProviderSelect.Selected]; !ok {
+		cv.nextProviderSelect.SetSelected(nextProviderDefault)
+	}
+	cv.nextProviderSelect.Refresh()
+}
+
+// nextProviderOverride returns the provider picked for the next message, ""
+// to use the main selection
+func (cv *ChatView) nextProviderOverride() string {
+	if cv.nextProviderSelect == nil {
+		return ""
+	}
+	selected := cv.nextProviderSelect.Selected
+	if selected == nextProviderDefault || selected == cv.currentProvider {
+		return ""
+	}
+	return selected
+}
+
+// resetNextProvider returns the next-message dropdown to the main selection
+func (cv *ChatView) resetNextProvider() {
+	if cv.nextProviderSelect != nil {
+		cv.nextProviderSelect.SetSelected(nextProviderDefault)
+	}
+}
+
+// providerForMessage returns the provider to answer a message and the model
+// name saved with the response. The selected provider uses the selected
+// model, any other one its default model.
+func (cv *ChatView) providerForMessage(name string) (llm.Provider, string, bool) {
+	if name == cv.currentProvider {
+		provider, ok := cv.selectedProvider()
+		return provider, cv.selectedModel(), ok
+	}
+	provider, ok := cv.app.providers[name]
+	if !ok {
+		return nil, "", false
+	}
+	model := cv.app.config.LLMProviders[name].DefaultModel
+	if model == "" {
+		model = name
+	}
+	return provider, model, true
+}
+
+// newModelBadge creates the small label next to the role of a response
+// naming the model that wrote it
+func newModelBadge(model string) fyne.CanvasObject {
+	return newPill(model, color.NRGBA{R: 128, G: 128, B: 128, A: 50})
+}
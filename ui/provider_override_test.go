@@ -0,0 +1,45 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestChatView_NextMessageProviderOverride(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"main answer"}})
+	other := llm.NewMockProvider(llm.MockConfig{Responses: []string{"other answer"}})
+	a := newTestApp(t, provider)
+	a.providers["other"] = other
+	cv, convID := newTestChat(t, a)
+
+	fyne.DoAndWait(func() {
+		cv.providerSelect.SetSelected("mock")
+		cv.nextProviderSelect.SetSelected("other")
+	})
+	sendTestMessage(cv, "First question")
+	messages := waitForMessages(t, a, convID, 2)
+	if messages[1].Provider != "other" || messages[1].Content != "other answer" {
+		t.Errorf("expected the overriding provider to answer, got %+v", messages[1])
+	}
+	if len(other.Requests()) != 1 || len(provider.Requests()) != 0 {
+		t.Errorf("requests: other %d, main %d", len(other.Requests()), len(provider.Requests()))
+	}
+	if cv.nextProviderSelect.Selected != nextProviderDefault {
+		t.Errorf("override not reset after sending, selected %q", cv.nextProviderSelect.Selected)
+	}
+
+	waitUntil(t, "the response to finish", func() bool {
+		var disabled bool
+		fyne.DoAndWait(func() { disabled = cv.sendButton.Disabled() })
+		return !disabled
+	})
+	sendTestMessage(cv, "Second question")
+	messages = waitForMessages(t, a, convID, 4)
+	if messages[3].Provider != "mock" || messages[3].Content != "main answer" {
+		t.Errorf("expected the main provider to answer again, got %+v", messages[3])
+	}
+}
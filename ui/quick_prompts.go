@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// QuickPromptBar shows the configured quick prompts as a strip of buttons
+// above the input. Alt+1 to Alt+9 insert the matching prompt.
+type QuickPromptBar struct {
+	app       *App
+	onSelect  func(content string)
+	container *fyne.Container
+}
+
+// NewQuickPromptBar creates a quick prompt bar that calls onSelect with the prompt content
+func NewQuickPromptBar(app *App, onSelect func(content string)) *QuickPromptBar {
+	return &QuickPromptBar{
+		app:       app,
+		onSelect:  onSelect,
+		container: container.NewVBox(),
+	}
+}
+
+// Build builds the quick prompt bar UI
+func (qb *QuickPromptBar) Build() fyne.CanvasObject {
+	qb.showButtons()
+	return qb.container
+}
+
+// Prompt returns the quick prompt bound to key
+func (qb *QuickPromptBar) Prompt(key rune) (utils.QuickPrompt, bool) {
+	for _, prompt := range qb.app.config.UI.QuickPrompts {
+		if prompt.Key == key {
+			return prompt, true
+		}
+	}
+	return utils.QuickPrompt{}, false
+}
+
+// showButtons renders the (possibly collapsed) strip of prompt buttons
+func (qb *QuickPromptBar) showButtons() {
+	collapsed := qb.app.config.UI.QuickPromptsCollapsed
+
+	toggleText := "▾ 快捷提示"
+	if collapsed {
+		toggleText = "▸ 快捷提示"
+	}
+	toggleButton := widget.NewButton(toggleText, func() {
+		qb.setCollapsed(!qb.app.config.UI.QuickPromptsCollapsed)
+	})
+	toggleButton.Importance = widget.LowImportance
+
+	if collapsed {
+		qb.container.Objects = []fyne.CanvasObject{container.NewHBox(toggleButton)}
+		qb.container.Refresh()
+		return
+	}
+
+	buttons := make([]fyne.CanvasObject, 0, len(qb.app.config.UI.QuickPrompts))
+	for _, prompt := range qb.app.config.UI.QuickPrompts {
+		content := prompt.Content
+		label := prompt.Label
+		if prompt.Key != 0 {
+			label = fmt.Sprintf("%s (Alt+%c)", prompt.Label, prompt.Key)
+		}
+		button := widget.NewButton(label, func() {
+			qb.onSelect(content)
+		})
+		button.Importance = widget.LowImportance
+		buttons = append(buttons, button)
+	}
+
+	editButton := widget.NewButton("编辑快捷提示", func() {
+		qb.showEditor()
+	})
+	editButton.Importance = widget.LowImportance
+
+	qb.container.Objects = []fyne.CanvasObject{
+		container.NewBorder(nil, nil, toggleButton, editButton,
+			container.NewHScroll(container.NewHBox(buttons...))),
+	}
+	qb.container.Refresh()
+}
+
+// setCollapsed collapses or expands the bar and persists the state
+func (qb *QuickPromptBar) setCollapsed(collapsed bool) {
+	qb.app.config.UI.QuickPromptsCollapsed = collapsed
+	if err := utils.SaveConfig(qb.app.configPath, qb.app.config); err != nil {
+		qb.app.logger.Error("Failed to save quick prompt bar state: %v", err)
+	}
+	qb.showButtons()
+}
+
+// showEditor replaces the bar with a minimal editor, one prompt per line
+// in the form "key | label | content"
+func (qb *QuickPromptBar) showEditor() {
+	editor := widget.NewMultiLineEntry()
+	editor.SetText(formatQuickPrompts(qb.app.config.UI.QuickPrompts))
+	editor.SetMinRowsVisible(5)
+
+	hint := widget.NewLabel("每行一个: 按键(1-9) | 名称 | 内容 (内容中用 \\n 表示换行)")
+	hint.TextStyle = fyne.TextStyle{Italic: true}
+
+	saveButton := widget.NewButton("保存", func() {
+		prompts, err := parseQuickPrompts(editor.Text)
+		if err != nil {
+			qb.app.showError(err.Error())
+			return
+		}
+		qb.app.config.UI.QuickPrompts = prompts
+		if err := utils.SaveConfig(qb.app.configPath, qb.app.config); err != nil {
+			qb.app.logger.Error("Failed to save quick prompts: %v", err)
+			qb.app.showError("保存失败: " + err.Error())
+			return
+		}
+		qb.app.logger.Info("Saved %d quick prompts", len(prompts))
+		qb.app.refreshQuickPromptBars()
+	})
+	saveButton.Importance = widget.HighImportance
+
+	cancelButton := widget.NewButton("取消", func() {
+		qb.showButtons()
+	})
+
+	qb.container.Objects = []fyne.CanvasObject{
+		container.NewBorder(hint, container.NewHBox(saveButton, cancelButton), nil, nil, editor),
+	}
+	qb.container.Refresh()
+}
+
+// insertQuickPrompt appends a prompt to the input, keeping what was already typed
+func (cv *ChatView) insertQuickPrompt(content string) {
+	text := cv.inputEntry.Text
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	cv.inputEntry.SetText(text + content)
+
+	// Place the cursor at the end so the user can continue typing
+	lines := strings.Split(cv.inputEntry.Text, "\n")
+	cv.inputEntry.CursorRow = len(lines) - 1
+	cv.inputEntry.CursorColumn = len([]rune(lines[len(lines)-1]))
+	cv.inputEntry.Refresh()
+	cv.app.window.Canvas().Focus(cv.inputEntry)
+}
+
+// insertQuickPromptByKey inserts the quick prompt bound to key, if any
+func (cv *ChatView) insertQuickPromptByKey(key rune) {
+	if cv.quickPromptBar == nil {
+		return
+	}
+	if prompt, ok := cv.quickPromptBar.Prompt(key); ok {
+		cv.app.logger.Info("Inserted quick prompt Alt+%c (%s)", key, prompt.Label)
+		cv.insertQuickPrompt(prompt.Content)
+	}
+}
+
+// quickPromptKey returns the digit of an Alt+1 to Alt+9 shortcut
+func quickPromptKey(shortcut *desktop.CustomShortcut) (rune, bool) {
+	if shortcut.Modifier != desktop.AltModifier || len(shortcut.KeyName) != 1 {
+		return 0, false
+	}
+	key := rune(shortcut.KeyName[0])
+	if key < '1' || key > '9' {
+		return 0, false
+	}
+	return key, true
+}
+
+// refreshQuickPromptBars re-renders the quick prompt bar of every open chat
+func (a *App) refreshQuickPromptBars() {
+	for _, cv := range a.chatViews {
+		if cv.quickPromptBar != nil {
+			cv.quickPromptBar.showButtons()
+		}
+	}
+}
+
+// formatQuickPrompts renders prompts in the editor format
+func formatQuickPrompts(prompts []utils.QuickPrompt) string {
+	lines := make([]string, 0, len(prompts))
+	for _, prompt := range prompts {
+		key := ""
+		if prompt.Key != 0 {
+			key = string(prompt.Key)
+		}
+		content := strings.ReplaceAll(prompt.Content, "\n", "\\n")
+		lines = append(lines, fmt.Sprintf("%s | %s | %s", key, prompt.Label, content))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseQuickPrompts parses the editor format. The key may be empty for
+// prompts that are only available from the bar.
+func parseQuickPrompts(text string) ([]utils.QuickPrompt, error) {
+	prompts := []utils.QuickPrompt{}
+	used := make(map[rune]bool)
+	for i, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("第 %d 行格式错误，应为: 按键 | 名称 | 内容", i+1)
+		}
+
+		var key rune
+		keyText := strings.TrimSpace(parts[0])
+		if keyText != "" {
+			if len(keyText) != 1 || keyText[0] < '1' || keyText[0] > '9' {
+				return nil, fmt.Errorf("第 %d 行按键无效: %s (应为 1-9)", i+1, keyText)
+			}
+			key = rune(keyText[0])
+			if used[key] {
+				return nil, fmt.Errorf("第 %d 行按键重复: %s", i+1, keyText)
+			}
+			used[key] = true
+		}
+
+		label := strings.TrimSpace(parts[1])
+		if label == "" {
+			return nil, fmt.Errorf("第 %d 行缺少名称", i+1)
+		}
+
+		prompts = append(prompts, utils.QuickPrompt{
+			Key:     key,
+			Label:   label,
+			Content: strings.ReplaceAll(strings.TrimSpace(parts[2]), "\\n", "\n"),
+		})
+	}
+	return prompts, nil
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"context"
+	"light-llm-client/utils"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Labels of the read aloud button
+const (
+	readAloudLabel = "🔊 朗读"
+	stopReadLabel  = "⏹ 停止"
+)
+
+// newReadAloudButton creates the button that reads text aloud. Tapping it
+// again, or another read aloud button, stops the reading.
+func (a *App) newReadAloudButton(text string) *widget.Button {
+	var button *widget.Button
+	button = widget.NewButton(readAloudLabel, func() {
+		if a.ttsButton == button {
+			a.stopReadAloud()
+			return
+		}
+		a.readAloud(button, text)
+	})
+	button.Importance = widget.LowImportance
+	return button
+}
+
+// readAloud speaks text in the background while button shows the stop label
+func (a *App) readAloud(button *widget.Button, text string) {
+	a.stopReadAloud()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.ttsCancel = cancel
+	a.ttsButton = button
+	button.SetText(stopReadLabel)
+
+	voice := a.config.UI.TTSVoice
+	utils.SafeGo(a.logger, "readAloud", func() {
+		err := utils.SpeakText(ctx, text, voice)
+		fyne.Do(func() {
+			// A newer reading may already own the state
+			if a.ttsButton == button {
+				a.ttsButton = nil
+				a.ttsCancel = nil
+				button.SetText(readAloudLabel)
+			}
+			cancel()
+			if err != nil {
+				a.logger.Error("Read aloud failed: %v", err)
+				a.showError("朗读失败: " + err.Error())
+			}
+		})
+	})
+}
+
+// stopReadAloud stops the current reading, if any
+func (a *App) stopReadAloud() {
+	if a.ttsCancel != nil {
+		a.ttsCancel()
+	}
+	if a.ttsButton != nil {
+		a.ttsButton.SetText(readAloudLabel)
+	}
+	a.ttsCancel = nil
+	a.ttsButton = nil
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// addRecentFile records an exported or imported file in the recent files
+func (a *App) addRecentFile(path string) {
+	a.config.AddRecentFile(storage.NewFileURI(path).String())
+	if err := utils.SaveConfig(a.configPath, a.config); err != nil {
+		a.logger.Error("Failed to save recent files: %v", err)
+	}
+}
+
+// clearRecentFiles forgets all recent files
+func (a *App) clearRecentFiles() {
+	a.config.RecentFiles = nil
+	if err := utils.SaveConfig(a.configPath, a.config); err != nil {
+		a.logger.Error("Failed to save recent files: %v", err)
+		return
+	}
+	a.logger.Info("Recent files cleared")
+}
+
+// newImportMenuButton creates the import button. Fyne has no menu button, so
+// tapping it pops up the import menu below the button.
+func (a *App) newImportMenuButton() *widget.Button {
+	var button *widget.Button
+	button = widget.NewButton("📥 导入", func() {
+		// Built on every tap so the recent files are current
+		widget.ShowPopUpMenuAtRelativePosition(a.buildImportMenu(), a.window.Canvas(), fyne.NewPos(0, button.Size().Height), button)
+	})
+	return button
+}
+
+// buildImportMenu builds the import menu with the recent files submenu
+func (a *App) buildImportMenu() *fyne.Menu {
+	recentItems := a.recentFileMenuItems()
+	if len(recentItems) == 0 {
+		empty := fyne.NewMenuItem("(无)", nil)
+		empty.Disabled = true
+		recentItems = append(recentItems, empty)
+	} else {
+		recentItems = append(recentItems,
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("🗑️ 清除最近文件", a.clearRecentFiles),
+		)
+	}
+
+	recentMenu := fyne.NewMenuItem("🕘 最近文件", nil)
+	recentMenu.ChildMenu = fyne.NewMenu("", recentItems...)
+
+	return fyne.NewMenu("",
+		fyne.NewMenuItem("📂 选择文件...", a.showImportDialog),
+		recentMenu,
+	)
+}
+
+// recentFileMenuItems lists the recent files with their modification date.
+// Conversation files open in the import wizard, other exports (Markdown,
+// CSV) in their default application.
+func (a *App) recentFileMenuItems() []*fyne.MenuItem {
+	items := make([]*fyne.MenuItem, 0, len(a.config.RecentFiles))
+	for _, uri := range a.config.RecentFiles {
+		path := utils.RecentFilePath(uri)
+		name := filepath.Base(path)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			item := fyne.NewMenuItem(name+" (文件不存在)", nil)
+			item.Disabled = true
+			items = append(items, item)
+			continue
+		}
+
+		label := fmt.Sprintf("%s    %s", name, info.ModTime().Format("2006-01-02 15:04"))
+		items = append(items, fyne.NewMenuItem(label, func() {
+			a.openRecentFile(uri, path)
+		}))
+	}
+	return items
+}
+
+// openRecentFile imports a recent conversation file or opens any other file
+func (a *App) openRecentFile(uri, path string) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".zip":
+		NewConversationImportWizard(a).ShowFile(path)
+	default:
+		fileURL, err := url.Parse(uri)
+		if err != nil {
+			a.showError("无法打开文件: " + err.Error())
+			return
+		}
+		if err := a.fyneApp.OpenURL(fileURL); err != nil {
+			a.logger.Error("Failed to open %s: %v", path, err)
+			a.showError("无法打开文件: " + err.Error())
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// recycleBinRetentionDays is how long deleted conversations stay in the
+// recycle bin before they are deleted permanently on startup
+const recycleBinRetentionDays = 30
+
+// RecycleBinView lists the deleted conversations, which can be restored or
+// deleted permanently
+type RecycleBinView struct {
+	app         *App
+	statusLabel *widget.Label
+	list        *fyne.Container
+}
+
+// NewRecycleBinView creates a new recycle bin view
+func NewRecycleBinView(app *App) *RecycleBinView {
+	return &RecycleBinView{app: app}
+}
+
+// Build builds the recycle bin view UI
+func (rv *RecycleBinView) Build() fyne.CanvasObject {
+	rv.statusLabel = widget.NewLabel("")
+	rv.list = container.NewVBox()
+
+	rv.Refresh()
+
+	return container.NewBorder(
+		rv.statusLabel,
+		nil, nil, nil,
+		container.NewScroll(rv.list),
+	)
+}
+
+// Refresh reloads the deleted conversations from the database
+func (rv *RecycleBinView) Refresh() {
+	if rv.list == nil {
+		return
+	}
+
+	conversations, err := rv.app.db.ListDeletedConversations()
+	if err != nil {
+		rv.app.logger.Error("Failed to load deleted conversations: %v", err)
+		rv.statusLabel.SetText("加载失败: " + err.Error())
+		return
+	}
+
+	objects := []fyne.CanvasObject{}
+	for _, conv := range conversations {
+		objects = append(objects, rv.buildItem(conv), widget.NewSeparator())
+	}
+	if len(conversations) == 0 {
+		rv.statusLabel.SetText("回收站是空的")
+	} else {
+		rv.statusLabel.SetText(fmt.Sprintf("%d 个已删除的对话，删除 %d 天后自动永久删除", len(conversations), recycleBinRetentionDays))
+	}
+
+	rv.list.Objects = objects
+	rv.list.Refresh()
+}
+
+// buildItem builds the row of a deleted conversation
+func (rv *RecycleBinView) buildItem(conv *db.Conversation) fyne.CanvasObject {
+	titleLabel := widget.NewLabel(conv.Title)
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+	titleLabel.Truncation = fyne.TextTruncateEllipsis
+
+	details := "删除于 " + conv.DeletedAt.Local().Format("2006-01-02 15:04")
+	if conv.Category != "" {
+		details = conv.Category + " · " + details
+	}
+	detailsLabel := widget.NewLabel(details)
+	detailsLabel.Importance = widget.LowImportance
+
+	restoreButton := widget.NewButton("还原", func() {
+		rv.app.restoreConversation(conv.ID)
+	})
+	deleteButton := widget.NewButton("永久删除", func() {
+		rv.app.confirmPermanentDelete(conv)
+	})
+	deleteButton.Importance = widget.DangerImportance
+
+	return container.NewBorder(nil, nil, nil,
+		container.NewHBox(restoreButton, deleteButton),
+		container.NewVBox(titleLabel, detailsLabel),
+	)
+}
+
+// showRecycleBin shows the recycle bin tab
+func (a *App) showRecycleBin() {
+	if a.recycleBinTabItem != nil {
+		a.recycleBinView.Refresh()
+		a.tabs.SelectTab(a.recycleBinTabItem)
+		return
+	}
+
+	a.recycleBinView = NewRecycleBinView(a)
+	a.recycleBinTabItem = a.tabs.Append("🗑️ 回收站", a.recycleBinView.Build(), func() {
+		a.closeRecycleBinTab()
+	})
+	a.logger.Info("Opened recycle bin tab")
+}
+
+// closeRecycleBinTab closes the recycle bin tab
+func (a *App) closeRecycleBinTab() {
+	if a.recycleBinTabItem != nil {
+		a.tabs.Remove(a.recycleBinTabItem)
+		a.recycleBinTabItem = nil
+		a.recycleBinView = nil
+		a.logger.Info("Closed recycle bin tab")
+	}
+}
+
+// refreshRecycleBin updates the recycle bin tab if it is open
+func (a *App) refreshRecycleBin() {
+	if a.recycleBinView != nil {
+		a.recycleBinView.Refresh()
+	}
+}
+
+// restoreConversation takes a conversation out of the recycle bin
+func (a *App) restoreConversation(conversationID int64) {
+	if err := a.db.RestoreConversation(conversationID); err != nil {
+		a.logger.Error("Failed to restore conversation %d: %v", conversationID, err)
+		a.showError("还原失败: " + err.Error())
+		return
+	}
+	a.logger.Info("Restored conversation %d", conversationID)
+	a.RefreshSidebar()
+	a.refreshRecycleBin()
+}
+
+// confirmPermanentDelete asks before deleting a conversation in the recycle
+// bin for good
+func (a *App) confirmPermanentDelete(conv *db.Conversation) {
+	var popup *widget.PopUp
+	popup = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel("永久删除"),
+			widget.NewLabel("确定要永久删除对话 \""+conv.Title+"\" 吗？"),
+			widget.NewLabel("此操作不可撤销！"),
+			container.NewHBox(
+				widget.NewButton("取消", func() {
+					popup.Hide()
+				}),
+				widget.NewButton("永久删除", func() {
+					popup.Hide()
+					a.permanentlyDeleteConversation(conv.ID)
+				}),
+			),
+		),
+		a.window.Canvas(),
+	)
+	popup.Show()
+}
+
+// permanentlyDeleteConversation deletes a conversation and its messages
+func (a *App) permanentlyDeleteConversation(conversationID int64) {
+	if err := a.db.PermanentlyDeleteConversation(conversationID); err != nil {
+		a.logger.Error("Failed to delete conversation %d: %v", conversationID, err)
+		a.showError("删除失败: " + err.Error())
+		return
+	}
+	a.logger.Info("Permanently deleted conversation %d", conversationID)
+	a.refreshRecycleBin()
+}
+
+// purgeRecycleBin permanently deletes the conversations that have been in the
+// recycle bin for longer than recycleBinRetentionDays
+func (a *App) purgeRecycleBin() {
+	cutoff := time.Now().AddDate(0, 0, -recycleBinRetentionDays)
+	count, err := a.db.PurgeDeletedConversations(cutoff)
+	if err != nil {
+		a.logger.Error("Failed to purge the recycle bin: %v", err)
+		return
+	}
+	if count > 0 {
+		a.logger.Info("Purged %d conversations from the recycle bin", count)
+	}
+}
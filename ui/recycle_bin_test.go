@@ -0,0 +1,54 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestRecycleBin_RestoreAndPurge(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	conv, err := a.db.CreateConversation("Binned", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if err := a.db.DeleteConversation(conv.ID); err != nil {
+		t.Fatalf("DeleteConversation failed: %v", err)
+	}
+	a.RefreshSidebar()
+	if len(a.conversations) != 0 {
+		t.Fatalf("sidebar lists %d conversations, want none", len(a.conversations))
+	}
+
+	a.showRecycleBin()
+	if len(a.recycleBinView.list.Objects) != 2 {
+		t.Fatalf("recycle bin shows %d objects, want a row and a separator", len(a.recycleBinView.list.Objects))
+	}
+	test.Tap(findButton(t, a.recycleBinView.list, "还原"))
+	if len(a.conversations) != 1 || a.conversations[0].ID != conv.ID {
+		t.Errorf("sidebar lists %v after restoring", a.conversations)
+	}
+	if len(a.recycleBinView.list.Objects) != 0 {
+		t.Errorf("recycle bin still shows %d objects", len(a.recycleBinView.list.Objects))
+	}
+
+	// Recently deleted conversations survive the purge on startup
+	if err := a.db.DeleteConversation(conv.ID); err != nil {
+		t.Fatalf("DeleteConversation failed: %v", err)
+	}
+	a.purgeRecycleBin()
+	if deleted, _ := a.db.ListDeletedConversations(); len(deleted) != 1 {
+		t.Fatalf("purge removed a recently deleted conversation, %d left", len(deleted))
+	}
+
+	a.permanentlyDeleteConversation(conv.ID)
+	if _, err := a.db.GetConversation(conv.ID); err == nil {
+		t.Error("expected the conversation to be deleted permanently")
+	}
+	if a.recycleBinView.statusLabel.Text != "回收站是空的" {
+		t.Errorf("status = %q", a.recycleBinView.statusLabel.Text)
+	}
+}
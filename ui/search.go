@@ -1,8 +1,11 @@
 package ui
 
 import (
+	"fmt"
 	"light-llm-client/db"
+	"light-llm-client/utils"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -18,15 +21,33 @@ type SearchView struct {
 	resultsList      *widget.List
 	searchResults    []*db.SearchResult
 	statusLabel      *widget.Label
+	loadMoreButton   *widget.Button
+	// query is the search the shown results belong to, for loading more
+	query db.SearchQuery
 	
 	// Filter widgets
 	providerSelect   *widget.Select
+	modelEntry       *widget.Entry
 	categorySelect   *widget.Select
+	roleSelect       *widget.Select
 	dateRangeSelect  *widget.Select
+	startDateEntry   *widget.Entry
+	endDateEntry     *widget.Entry
+	starredCheck     *widget.Check
 	showFilters      bool
 	filtersContainer *fyne.Container
 }
 
+// searchPageSize is the number of results loaded at a time
+const searchPageSize = 50
+
+// searchRoles maps the options of the role filter to message roles
+var searchRoles = map[string]string{
+	"用户": "user",
+	"助手": "assistant",
+	"系统": "system",
+}
+
 // NewSearchView creates a new search view
 func NewSearchView(app *App) *SearchView {
 	sv := &SearchView{
@@ -61,12 +82,17 @@ func (sv *SearchView) Build() fyne.CanvasObject {
 	})
 	filterButton.Importance = widget.LowImportance
 
+	// Starred messages only; without text it lists all of them
+	sv.starredCheck = widget.NewCheck("仅显示星标", func(bool) {
+		sv.performSearch()
+	})
+
 	// Search bar
 	searchBar := container.NewBorder(
 		nil,
 		nil,
 		nil,
-		container.NewHBox(filterButton, sv.searchButton),
+		container.NewHBox(sv.starredCheck, filterButton, sv.searchButton),
 		sv.searchEntry,
 	)
 	
@@ -103,13 +129,29 @@ func (sv *SearchView) Build() fyne.CanvasObject {
 		sv.app.logger.Info("Date range filter changed: %s", value)
 	})
 	sv.dateRangeSelect.SetSelected("全部时间")
+
+	sv.modelEntry = widget.NewEntry()
+	sv.modelEntry.SetPlaceHolder("全部模型")
+
+	sv.roleSelect = widget.NewSelect([]string{"全部角色", "用户", "助手", "系统"}, nil)
+	sv.roleSelect.SetSelected("全部角色")
+
+	// A custom date range replaces the time range above
+	sv.startDateEntry = widget.NewEntry()
+	sv.startDateEntry.SetPlaceHolder("YYYY-MM-DD")
+	sv.endDateEntry = widget.NewEntry()
+	sv.endDateEntry.SetPlaceHolder("YYYY-MM-DD")
 	
 	// Filters container
 	sv.filtersContainer = container.NewVBox(
 		widget.NewForm(
 			widget.NewFormItem("提供商", sv.providerSelect),
+			widget.NewFormItem("模型", sv.modelEntry),
 			widget.NewFormItem("分类", sv.categorySelect),
+			widget.NewFormItem("角色", sv.roleSelect),
 			widget.NewFormItem("时间范围", sv.dateRangeSelect),
+			widget.NewFormItem("自定义日期", container.NewGridWithColumns(3,
+				sv.startDateEntry, widget.NewLabel("至"), sv.endDateEntry)),
 		),
 		widget.NewSeparator(),
 	)
@@ -125,9 +167,19 @@ func (sv *SearchView) Build() fyne.CanvasObject {
 			return len(sv.searchResults)
 		},
 		func() fyne.CanvasObject {
+			titleLabel := widget.NewLabel("Title")
+			titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+			dateLabel := widget.NewLabel("Date")
+			dateLabel.Importance = widget.LowImportance
+			jumpButton := widget.NewButton("跳转", nil)
+			jumpButton.Importance = widget.LowImportance
+
+			snippetText := widget.NewRichText()
+			snippetText.Wrapping = fyne.TextWrapWord
+
 			return container.NewVBox(
-				widget.NewLabel("Title"),
-				widget.NewLabel("Snippet"),
+				container.NewBorder(nil, nil, nil, container.NewHBox(dateLabel, jumpButton), titleLabel),
+				snippetText,
 				widget.NewSeparator(),
 			)
 		},
@@ -137,23 +189,31 @@ func (sv *SearchView) Build() fyne.CanvasObject {
 			}
 			result := sv.searchResults[id]
 			box := obj.(*fyne.Container)
-			
-			// Get conversation title
-			conv, err := sv.app.db.GetConversation(result.ConversationID)
-			convTitle := "Unknown"
-			if err == nil && conv != nil {
-				convTitle = conv.Title
+			header := box.Objects[0].(*fyne.Container)
+
+			// Border layout puts the center object first
+			titleLabel := header.Objects[0].(*widget.Label)
+			convTitle := result.ConversationTitle
+			if convTitle == "" {
+				convTitle = "Unknown"
+			}
+			if result.Message.Starred {
+				convTitle = "⭐ " + convTitle
 			}
-			
-			// Title with conversation info
-			titleLabel := box.Objects[0].(*widget.Label)
 			titleLabel.SetText(convTitle)
-			titleLabel.TextStyle = fyne.TextStyle{Bold: true}
-			
-			// Snippet with highlighting
-			snippetLabel := box.Objects[1].(*widget.Label)
-			snippetLabel.SetText(sv.formatSnippet(result.Snippet))
-			snippetLabel.Wrapping = fyne.TextWrapWord
+
+			actions := header.Objects[1].(*fyne.Container)
+			dateLabel := actions.Objects[0].(*widget.Label)
+			dateLabel.SetText(result.Message.CreatedAt.Local().Format("2006-01-02 15:04"))
+			jumpButton := actions.Objects[1].(*widget.Button)
+			jumpButton.OnTapped = func() {
+				sv.jumpToResult(result)
+			}
+
+			// Snippet with the matched text in bold
+			snippetText := box.Objects[1].(*widget.RichText)
+			snippetText.Segments = snippetSegments(result.Snippet)
+			snippetText.Refresh()
 		},
 	)
 
@@ -161,15 +221,20 @@ func (sv *SearchView) Build() fyne.CanvasObject {
 		if id >= len(sv.searchResults) {
 			return
 		}
-		result := sv.searchResults[id]
-		sv.app.openChatTab(result.ConversationID)
+		sv.jumpToResult(sv.searchResults[id])
 		sv.resultsList.UnselectAll()
 	}
 
+	sv.loadMoreButton = widget.NewButton("加载更多", func() {
+		sv.loadMore()
+	})
+	sv.loadMoreButton.Hide()
+
 	// Results container
 	sv.resultsContainer = container.NewVBox(
 		sv.statusLabel,
 		sv.resultsList,
+		sv.loadMoreButton,
 	)
 
 	resultsScroll := container.NewScroll(sv.resultsContainer)
@@ -198,85 +263,160 @@ func (sv *SearchView) Build() fyne.CanvasObject {
 
 // performSearch executes the search query with filters
 func (sv *SearchView) performSearch() {
-	query := strings.TrimSpace(sv.searchEntry.Text)
-	if query == "" {
-		sv.statusLabel.SetText("请输入搜索关键词")
-		sv.searchResults = []*db.SearchResult{}
+	query, err := sv.buildQuery()
+	if err != nil {
+		sv.statusLabel.SetText(err.Error())
+		return
+	}
+	sv.searchResults = []*db.SearchResult{}
+	sv.loadMoreButton.Hide()
+	if query.IsEmpty() {
+		sv.statusLabel.SetText("请输入搜索关键词或设置筛选条件")
 		sv.resultsList.Refresh()
 		return
 	}
 
 	sv.statusLabel.SetText("搜索中...")
-	
-	// Get filter values
-	provider := sv.providerSelect.Selected
-	category := sv.categorySelect.Selected
-	dateRange := sv.dateRangeSelect.Selected
-	
-	// Convert date range to days
-	daysAgo := 0
-	switch dateRange {
-	case "今天":
-		daysAgo = 1
-	case "最近7天":
-		daysAgo = 7
-	case "最近30天":
-		daysAgo = 30
-	case "最近90天":
-		daysAgo = 90
-	}
-	
-	sv.app.logger.Info("Searching for: %s (provider: %s, category: %s, days: %d)", query, provider, category, daysAgo)
+	sv.query = query
+	sv.app.logger.Info("Searching for: %s (provider: %s, model: %s, category: %s, role: %s, from: %v, to: %v, starred: %v)",
+		query.Text, query.ProviderFilter, query.ModelFilter, query.Category, query.Role, query.StartDate, query.EndDate, query.Starred)
+	sv.loadMore()
+}
 
-	// Perform search with filters
-	results, err := sv.app.db.SearchMessagesWithFilters(query, provider, category, daysAgo, 50)
+// loadMore appends the next page of results of the current search
+func (sv *SearchView) loadMore() {
+	results, err := sv.app.db.SearchConversations(sv.query, searchPageSize, len(sv.searchResults))
 	if err != nil {
 		sv.app.logger.Error("Search failed: %v", err)
 		sv.statusLabel.SetText("搜索失败: " + err.Error())
-		sv.searchResults = []*db.SearchResult{}
 		sv.resultsList.Refresh()
 		return
 	}
 
-	sv.searchResults = results
+	sv.searchResults = append(sv.searchResults, results...)
 	sv.resultsList.Refresh()
+	// A full page means there may be more
+	if len(results) == searchPageSize {
+		sv.loadMoreButton.Show()
+	} else {
+		sv.loadMoreButton.Hide()
+	}
 
-	if len(results) == 0 {
+	if len(sv.searchResults) == 0 {
 		sv.statusLabel.SetText("未找到匹配结果")
 	} else {
 		filterInfo := ""
-		if provider != "全部提供商" || category != "全部分类" || daysAgo > 0 {
+		if sv.query != (db.SearchQuery{Text: sv.query.Text}) {
 			filterInfo = " (已筛选)"
 		}
-		sv.statusLabel.SetText("找到 " + formatInt(len(results)) + " 条结果" + filterInfo)
+		sv.statusLabel.SetText("找到 " + formatInt(len(sv.searchResults)) + " 条结果" + filterInfo)
 	}
 
-	sv.app.logger.Info("Search completed: %d results", len(results))
+	sv.app.logger.Info("Search completed: %d results", len(sv.searchResults))
 }
 
-// formatSnippet formats the search result snippet with context
-func (sv *SearchView) formatSnippet(snippet string) string {
-	// Truncate long content
-	maxLength := 200
-	if len(snippet) > maxLength {
-		// Try to find a good breaking point
-		truncated := snippet[:maxLength]
-		lastSpace := strings.LastIndex(truncated, " ")
-		if lastSpace > maxLength/2 {
-			truncated = truncated[:lastSpace]
+// buildQuery reads the search text and the filters
+func (sv *SearchView) buildQuery() (db.SearchQuery, error) {
+	query := db.SearchQuery{
+		Text:        strings.TrimSpace(sv.searchEntry.Text),
+		ModelFilter: strings.TrimSpace(sv.modelEntry.Text),
+		Role:        searchRoles[sv.roleSelect.Selected],
+		Starred:     sv.starredCheck.Checked,
+	}
+	if provider := sv.providerSelect.Selected; provider != "全部提供商" {
+		query.ProviderFilter = provider
+	}
+	if category := sv.categorySelect.Selected; category != "全部分类" {
+		query.Category = category
+	}
+
+	// Convert date range to days
+	daysAgo := 0
+	switch sv.dateRangeSelect.Selected {
+	case "今天":
+		daysAgo = 1
+	case "最近7天":
+		daysAgo = 7
+	case "最近30天":
+		daysAgo = 30
+	case "最近90天":
+		daysAgo = 90
+	}
+	if daysAgo > 0 {
+		query.StartDate = time.Now().AddDate(0, 0, -daysAgo)
+	}
+
+	start, err := parseSearchDate(sv.startDateEntry.Text)
+	if err != nil {
+		return query, err
+	}
+	end, err := parseSearchDate(sv.endDateEntry.Text)
+	if err != nil {
+		return query, err
+	}
+	if !start.IsZero() {
+		query.StartDate = start
+	}
+	if !end.IsZero() {
+		// The end date is included
+		query.EndDate = end.AddDate(0, 0, 1)
+	}
+	return query, nil
+}
+
+// parseSearchDate parses a date of the date filter, zero if it is empty
+func parseSearchDate(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", text, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("日期格式应为 YYYY-MM-DD: %s", text)
+	}
+	return date, nil
+}
+
+// jumpToResult opens the conversation of a search result and scrolls to the matching message
+func (sv *SearchView) jumpToResult(result *db.SearchResult) {
+	if err := sv.app.NavigateToMessage(result.Message.ID); err != nil {
+		sv.app.logger.Error("Failed to jump to message %d: %v", result.Message.ID, err)
+		sv.app.showError("无法跳转到消息: " + err.Error())
+	}
+}
+
+// snippetSegments converts a snippet with <mark>...</mark> tags into rich text
+// segments where the marked spans are bold
+func snippetSegments(snippet string) []widget.RichTextSegment {
+	var segments []widget.RichTextSegment
+	addSegment := func(text string, bold bool) {
+		if text == "" {
+			return
+		}
+		style := widget.RichTextStyleInline
+		if bold {
+			style = widget.RichTextStyleStrong
 		}
-		snippet = truncated + "..."
+		segments = append(segments, &widget.TextSegment{Text: text, Style: style})
 	}
 
-	// Add relevance indicator based on snippet length (simple heuristic)
-	relevance := ""
-	if len(snippet) > 100 {
-		relevance = "🔥 "
-	} else if len(snippet) > 50 {
-		relevance = "⭐ "
+	for snippet != "" {
+		start := strings.Index(snippet, "<mark>")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(snippet[start:], "</mark>")
+		if end < 0 {
+			break
+		}
+		end += start
+		addSegment(snippet[:start], false)
+		addSegment(snippet[start+len("<mark>"):end], true)
+		snippet = snippet[end+len("</mark>"):]
 	}
+	addSegment(snippet, false)
 
-	return relevance + snippet
+	return segments
 }
 
 // formatInt converts an integer to string
@@ -298,5 +438,47 @@ func (sv *SearchView) Clear() {
 	sv.searchEntry.SetText("")
 	sv.searchResults = []*db.SearchResult{}
 	sv.resultsList.Refresh()
+	sv.loadMoreButton.Hide()
 	sv.statusLabel.SetText("输入关键词开始搜索")
 }
+
+// Search fills in the query and searches
+func (sv *SearchView) Search(query string) {
+	sv.searchEntry.SetText(query)
+	sv.performSearch()
+}
+
+// searchIndexPageSize is the number of conversations loaded at a time while
+// building the search index
+const searchIndexPageSize = 100
+
+// buildSearchIndex builds the TF-IDF index of all messages in the background
+// and hands it to the database to rerank search results
+func (a *App) buildSearchIndex() {
+	utils.SafeGo(a.logger, "buildSearchIndex", func() {
+		start := time.Now()
+		index := utils.NewTFIDFIndex()
+		for offset := 0; ; offset += searchIndexPageSize {
+			conversations, err := a.db.ListConversations(searchIndexPageSize, offset)
+			if err != nil {
+				a.logger.Error("Failed to build search index: %v", err)
+				return
+			}
+			for _, conv := range conversations {
+				messages, err := a.db.ListMessages(conv.ID)
+				if err != nil {
+					a.logger.Warn("Failed to index conversation %d: %v", conv.ID, err)
+					continue
+				}
+				for _, msg := range messages {
+					index.Add(msg.ID, msg.Content)
+				}
+			}
+			if len(conversations) < searchIndexPageSize {
+				break
+			}
+		}
+		a.db.SetSearchRanker(index)
+		a.logger.Info("Built search index of %d messages in %v", index.Len(), time.Since(start))
+	})
+}
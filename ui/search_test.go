@@ -0,0 +1,66 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/llm"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestSearchView_FiltersAndLoadMore(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	conv, err := a.db.CreateConversation("Many answers", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for i := 0; i < searchPageSize+10; i++ {
+		if _, err := a.db.CreateMessage(conv.ID, "user", fmt.Sprintf("question %d", i), "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		if _, err := a.db.CreateMessage(conv.ID, "assistant", fmt.Sprintf("answer %d", i), "mock", "mock-model", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	sv := NewSearchView(a)
+	sv.Build()
+
+	// Filters alone list the matching messages, a page at a time
+	sv.roleSelect.SetSelected("助手")
+	sv.modelEntry.SetText("mock-model")
+	sv.performSearch()
+	if len(sv.searchResults) != searchPageSize || !sv.loadMoreButton.Visible() {
+		t.Fatalf("first page has %d results, load more visible: %v", len(sv.searchResults), sv.loadMoreButton.Visible())
+	}
+	if sv.searchResults[0].Message.Content != fmt.Sprintf("answer %d", searchPageSize+9) {
+		t.Errorf("expected the newest answer first, got %q", sv.searchResults[0].Message.Content)
+	}
+	test.Tap(sv.loadMoreButton)
+	if len(sv.searchResults) != searchPageSize+10 || sv.loadMoreButton.Visible() {
+		t.Errorf("after loading more: %d results, load more visible: %v", len(sv.searchResults), sv.loadMoreButton.Visible())
+	}
+	for _, result := range sv.searchResults {
+		if result.Message.Role != "assistant" {
+			t.Fatalf("role filter let through %+v", result.Message)
+		}
+	}
+
+	// An invalid date is reported instead of searching
+	sv.startDateEntry.SetText("yesterday")
+	sv.performSearch()
+	if sv.statusLabel.Text != "日期格式应为 YYYY-MM-DD: yesterday" {
+		t.Errorf("status = %q", sv.statusLabel.Text)
+	}
+
+	// A date range that ends before the messages finds nothing
+	sv.startDateEntry.SetText("2000-01-01")
+	sv.endDateEntry.SetText("2000-01-31")
+	sv.searchEntry.SetText("answer")
+	sv.performSearch()
+	if len(sv.searchResults) != 0 || sv.statusLabel.Text != "未找到匹配结果" {
+		t.Errorf("%d results, status %q", len(sv.searchResults), sv.statusLabel.Text)
+	}
+}
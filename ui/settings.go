@@ -6,10 +6,12 @@ import (
 	"light-llm-client/llm"
 	"light-llm-client/utils"
 	"strconv"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -21,17 +23,23 @@ type SettingsView struct {
 	providerConfigs  map[string]*utils.ProviderConfig
 	providerNames    []string // Cached list of provider names to maintain order
 	selectedProvider string
+	health           map[string]providerHealth // Last health check of each provider
+	healthRun        int                       // Counts the health checks, to drop outdated results
 	
 	// Edit form widgets
 	nameEntry        *widget.Entry
 	displayNameEntry *widget.Entry
 	apiKeyEntry      *widget.Entry
 	baseURLEntry     *widget.Entry
-	modelEntry       *widget.Entry
+	proxyURLEntry    *widget.Entry
+	modelEntry       *widget.SelectEntry // Default model, with the provider's models to pick from
 	modelsEntry      *widget.Entry
 	enabledCheck     *widget.Check
 	maxTokensEntry   *widget.Entry
+	timeoutEntry     *widget.Entry
 	temperatureEntry *widget.Entry
+	sysPromptEntry   *widget.Entry
+	errorsLabel      *widget.Label // Validation errors of the edited provider
 	
 	// UI settings widgets
 	themeSelect      *widget.Select
@@ -74,6 +82,8 @@ func (sv *SettingsView) Build() fyne.CanvasObject {
 		container.NewTabItem("UI Settings", sv.buildUISettingsTab()),
 		container.NewTabItem("Data", sv.buildDataSettingsTab()),
 		container.NewTabItem("Usage Statistics", sv.buildUsageStatsTab()),
+		container.NewTabItem("Prompt A/B", NewPromptEngineeringPanel(sv).Build()),
+		container.NewTabItem("Templates", NewPromptTemplatePanel(sv).Build()),
 	)
 	
 	return tabs
@@ -90,6 +100,7 @@ func (sv *SettingsView) buildProvidersTab() fyne.CanvasObject {
 		},
 		func() fyne.CanvasObject {
 			return container.NewHBox(
+				newStatusDot(),
 				widget.NewLabel("Provider"),
 				widget.NewLabel(""),
 			)
@@ -99,11 +110,12 @@ func (sv *SettingsView) buildProvidersTab() fyne.CanvasObject {
 				name := sv.providerNames[id]
 				config := sv.providerConfigs[name]
 				box := obj.(*fyne.Container)
-				box.Objects[0].(*widget.Label).SetText(name)
+				box.Objects[0].(*statusDot).SetHealth(sv.health[name])
+				box.Objects[1].(*widget.Label).SetText(name)
 				if config.Enabled {
-					box.Objects[1].(*widget.Label).SetText("[Enabled]")
+					box.Objects[2].(*widget.Label).SetText("[Enabled]")
 				} else {
-					box.Objects[1].(*widget.Label).SetText("[Disabled]")
+					box.Objects[2].(*widget.Label).SetText("[Disabled]")
 				}
 			}
 		},
@@ -123,11 +135,14 @@ func (sv *SettingsView) buildProvidersTab() fyne.CanvasObject {
 	sv.addButton = widget.NewButton("Add New Provider", func() {
 		sv.showAddProviderDialog()
 	})
+	recheckButton := widget.NewButtonWithIcon("Re-check All", theme.ViewRefreshIcon(), func() {
+		sv.checkAllProviders()
+	})
 	
-	// Left panel with list and add button
+	// Left panel with list and buttons
 	leftPanel := container.NewBorder(
 		widget.NewLabel("LLM Providers"),
-		sv.addButton,
+		container.NewVBox(recheckButton, sv.addButton),
 		nil,
 		nil,
 		sv.providersList,
@@ -143,16 +158,26 @@ func (sv *SettingsView) buildProvidersTab() fyne.CanvasObject {
 	)
 	split.SetOffset(0.3)
 	
+	// Check the providers each time the settings window opens
+	sv.checkAllProviders()
+	
 	return split
 }
 
 // buildUISettingsTab builds the UI settings tab
 func (sv *SettingsView) buildUISettingsTab() fyne.CanvasObject {
 	// Theme selector
-	sv.themeSelect = widget.NewSelect([]string{"Light", "Dark"}, func(value string) {
+	// Auto follows the OS appearance
+	sv.themeSelect = widget.NewSelect([]string{"Light", "Dark", "Auto"}, nil)
+	for _, option := range sv.themeSelect.Options {
+		if strings.EqualFold(option, sv.app.config.UI.Theme) {
+			sv.themeSelect.SetSelected(option)
+		}
+	}
+	// Set after selecting the current theme so opening the settings saves nothing
+	sv.themeSelect.OnChanged = func(value string) {
 		sv.applyTheme(value)
-	})
-	sv.themeSelect.SetSelected(sv.app.config.UI.Theme)
+	}
 	
 	// Font size slider (10-24 px)
 	sv.fontSizeLabel = widget.NewLabel(fmt.Sprintf("Font Size: %d", sv.app.config.UI.FontSize))
@@ -204,6 +229,17 @@ func (sv *SettingsView) buildUISettingsTab() fyne.CanvasObject {
 		}
 	})
 	minimizeToTrayCheck.Checked = sv.app.config.UI.MinimizeToTray
+
+	// Follow-up suggestions checkbox
+	followUpCheck := widget.NewCheck("回复后显示追问建议 (额外消耗 tokens)", func(checked bool) {
+		sv.app.config.UI.ShowFollowUpSuggestions = checked
+
+		// Save config
+		if err := utils.SaveConfig(sv.app.configPath, sv.app.config); err != nil {
+			sv.app.logger.Error("Failed to save follow-up suggestions setting: %v", err)
+		}
+	})
+	followUpCheck.Checked = sv.app.config.UI.ShowFollowUpSuggestions
 	
 	// Memory monitor button
 	memoryMonitorButton := widget.NewButton("📊 内存监控", func() {
@@ -212,11 +248,23 @@ func (sv *SettingsView) buildUISettingsTab() fyne.CanvasObject {
 	})
 	memoryMonitorButton.Importance = widget.HighImportance
 	
-	uiSettingsForm := widget.NewForm(
+	uiSettingsItems := []*widget.FormItem{
 		widget.NewFormItem("Theme", sv.themeSelect),
 		widget.NewFormItem("", fontSizeContainer),
 		widget.NewFormItem("System Tray", minimizeToTrayCheck),
+	}
+	// Hidden where the hotkey could never be registered, e.g. under Wayland
+	if globalHotkeySupported() {
+		uiSettingsItems = append(uiSettingsItems, widget.NewFormItem("Global Hotkey", sv.buildGlobalHotkeySettings()))
+	}
+	uiSettingsItems = append(uiSettingsItems,
+		widget.NewFormItem("Suggestions", followUpCheck),
+		widget.NewFormItem("Read Aloud", sv.buildReadAloudSettings()),
+		widget.NewFormItem("Spell Check", sv.buildSpellCheckSettings()),
+		widget.NewFormItem("Macros", sv.buildMacroSettings()),
+		widget.NewFormItem("Message Cache Size", sv.buildCacheSizeSettings()),
 	)
+	uiSettingsForm := widget.NewForm(uiSettingsItems...)
 	
 	return container.NewVScroll(
 		container.NewVBox(
@@ -230,6 +278,143 @@ func (sv *SettingsView) buildUISettingsTab() fyne.CanvasObject {
 	)
 }
 
+// buildGlobalHotkeySettings builds the entry for the hotkey that shows the window
+func (sv *SettingsView) buildGlobalHotkeySettings() fyne.CanvasObject {
+	hotkeyEntry := widget.NewEntry()
+	hotkeyEntry.SetPlaceHolder(utils.DefaultGlobalHotkey)
+	hotkeyEntry.SetText(sv.app.config.UI.GlobalHotkey)
+
+	applyHotkey := func() {
+		keys := strings.TrimSpace(hotkeyEntry.Text)
+		if keys != "" {
+			hotkey, err := utils.ParseHotkey(keys)
+			if err != nil {
+				sv.showError("快捷键格式无效: " + err.Error())
+				return
+			}
+			keys = hotkey.String()
+			hotkeyEntry.SetText(keys)
+		}
+
+		sv.app.config.UI.GlobalHotkey = keys
+		if err := utils.SaveConfig(sv.app.configPath, sv.app.config); err != nil {
+			sv.app.logger.Error("Failed to save global hotkey: %v", err)
+		}
+
+		if err := sv.app.SetupGlobalHotkey(keys, sv.app.ShowWindow); err != nil {
+			sv.app.logger.Warn("Global hotkey is not available: %v", err)
+			sv.showError("注册全局快捷键失败: " + err.Error())
+		}
+	}
+	hotkeyEntry.OnSubmitted = func(string) { applyHotkey() }
+
+	note := widget.NewLabel("在任意位置按下快捷键即可显示窗口，例如 ctrl+alt+l；留空则禁用")
+	note.Wrapping = fyne.TextWrapWord
+	note.TextStyle = fyne.TextStyle{Italic: true}
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, nil, widget.NewButton("应用", applyHotkey), hotkeyEntry),
+		note,
+	)
+}
+
+// buildReadAloudSettings builds the text-to-speech toggle and voice entry
+func (sv *SettingsView) buildReadAloudSettings() fyne.CanvasObject {
+	ttsCheck := widget.NewCheck("为助手回复显示朗读按钮", func(checked bool) {
+		sv.app.config.UI.TTSEnabled = checked
+		if err := utils.SaveConfig(sv.app.configPath, sv.app.config); err != nil {
+			sv.app.logger.Error("Failed to save read aloud setting: %v", err)
+		}
+	})
+	ttsCheck.Checked = sv.app.config.UI.TTSEnabled
+
+	voiceEntry := widget.NewEntry()
+	voiceEntry.SetPlaceHolder("语音名称，留空使用系统默认")
+	voiceEntry.SetText(sv.app.config.UI.TTSVoice)
+	applyVoice := func() {
+		sv.app.config.UI.TTSVoice = strings.TrimSpace(voiceEntry.Text)
+		if err := utils.SaveConfig(sv.app.configPath, sv.app.config); err != nil {
+			sv.app.logger.Error("Failed to save read aloud voice: %v", err)
+		}
+	}
+	voiceEntry.OnSubmitted = func(string) { applyVoice() }
+
+	note := widget.NewLabel("使用系统语音引擎 (macOS say, Linux espeak, Windows System.Speech)，重新打开对话后按钮生效")
+	note.Wrapping = fyne.TextWrapWord
+	note.TextStyle = fyne.TextStyle{Italic: true}
+
+	return container.NewVBox(
+		ttsCheck,
+		container.NewBorder(nil, nil, nil, widget.NewButton("应用", applyVoice), voiceEntry),
+		note,
+	)
+}
+
+// buildSpellCheckSettings builds the spell-check toggle and custom dictionary editor
+func (sv *SettingsView) buildSpellCheckSettings() fyne.CanvasObject {
+	spellCheckCheck := widget.NewCheck("启用拼写检查 (英文)", func(checked bool) {
+		sv.app.config.UI.SpellCheck = checked
+		sv.app.spellChecker.SetEnabled(checked)
+
+		// Save config
+		if err := utils.SaveConfig(sv.app.configPath, sv.app.config); err != nil {
+			sv.app.logger.Error("Failed to save spell check setting: %v", err)
+		} else {
+			sv.app.logger.Info("Spell check enabled: %v", checked)
+		}
+	})
+	spellCheckCheck.Checked = sv.app.config.UI.SpellCheck
+
+	wordsLabel := widget.NewLabel("")
+	wordsLabel.Wrapping = fyne.TextWrapWord
+	refreshWords := func() {
+		words, err := sv.app.db.ListCustomWords()
+		if err != nil {
+			sv.app.logger.Error("Failed to list custom spell words: %v", err)
+			return
+		}
+		if len(words) == 0 {
+			wordsLabel.SetText("自定义词典: (空)")
+			return
+		}
+		wordsLabel.SetText("自定义词典: " + strings.Join(words, ", "))
+	}
+	refreshWords()
+
+	wordEntry := widget.NewEntry()
+	wordEntry.SetPlaceHolder("输入单词")
+
+	addButton := widget.NewButton("添加", func() {
+		word := strings.TrimSpace(wordEntry.Text)
+		if word == "" {
+			return
+		}
+		sv.app.addCustomSpellWord(word)
+		wordEntry.SetText("")
+		refreshWords()
+	})
+
+	removeButton := widget.NewButton("移除", func() {
+		word := strings.TrimSpace(wordEntry.Text)
+		if word == "" {
+			return
+		}
+		if err := sv.app.db.DeleteCustomWord(word); err != nil {
+			sv.showError("移除单词失败: " + err.Error())
+			return
+		}
+		sv.app.spellChecker.RemoveCustomWord(word)
+		wordEntry.SetText("")
+		refreshWords()
+	})
+
+	return container.NewVBox(
+		spellCheckCheck,
+		container.NewBorder(nil, nil, nil, container.NewHBox(addButton, removeButton), wordEntry),
+		wordsLabel,
+	)
+}
+
 // buildDataSettingsTab builds the data settings tab
 func (sv *SettingsView) buildDataSettingsTab() fyne.CanvasObject {
 	return container.NewVScroll(sv.buildDataSettings())
@@ -255,8 +440,11 @@ func (sv *SettingsView) buildEditForm() {
 	
 	sv.baseURLEntry = widget.NewEntry()
 	sv.baseURLEntry.SetPlaceHolder("Base URL (e.g., https://api.openai.com/v1)")
+
+	sv.proxyURLEntry = widget.NewEntry()
+	sv.proxyURLEntry.SetPlaceHolder("http://host:port or socks5://host:port (optional, empty uses the global proxy)")
 	
-	sv.modelEntry = widget.NewEntry()
+	sv.modelEntry = widget.NewSelectEntry(nil)
 	sv.modelEntry.SetPlaceHolder("Default Model")
 	
 	sv.modelsEntry = widget.NewEntry()
@@ -266,9 +454,22 @@ func (sv *SettingsView) buildEditForm() {
 	
 	sv.maxTokensEntry = widget.NewEntry()
 	sv.maxTokensEntry.SetPlaceHolder("Max Tokens (optional)")
+
+	sv.timeoutEntry = widget.NewEntry()
+	sv.timeoutEntry.SetPlaceHolder("Seconds to wait for a response (optional, default 120)")
 	
 	sv.temperatureEntry = widget.NewEntry()
 	sv.temperatureEntry.SetPlaceHolder("Temperature (0.0-2.0, optional)")
+
+	sv.sysPromptEntry = widget.NewMultiLineEntry()
+	sv.sysPromptEntry.SetPlaceHolder("Sent at the start of every conversation with this provider (optional)")
+	sv.sysPromptEntry.Wrapping = fyne.TextWrapWord
+	sv.sysPromptEntry.SetMinRowsVisible(3)
+	
+	sv.errorsLabel = widget.NewLabel("")
+	sv.errorsLabel.Importance = widget.DangerImportance
+	sv.errorsLabel.Wrapping = fyne.TextWrapWord
+	sv.errorsLabel.Hide()
 	
 	sv.saveButton = widget.NewButton("Save Changes", func() {
 		sv.saveProviderConfig()
@@ -292,10 +493,13 @@ func (sv *SettingsView) buildEditForm() {
 			widget.NewFormItem("Display Name", sv.displayNameEntry),
 			widget.NewFormItem("API Key", sv.apiKeyEntry),
 			widget.NewFormItem("Base URL", sv.baseURLEntry),
+			widget.NewFormItem("Proxy URL", sv.proxyURLEntry),
 			widget.NewFormItem("Default Model", sv.modelEntry),
 			widget.NewFormItem("Available Models", sv.modelsEntry),
 			widget.NewFormItem("Max Tokens", sv.maxTokensEntry),
+			widget.NewFormItem("Request Timeout (s)", sv.timeoutEntry),
 			widget.NewFormItem("Temperature", sv.temperatureEntry),
+			widget.NewFormItem("Default System Prompt", sv.sysPromptEntry),
 			widget.NewFormItem("", sv.enabledCheck),
 		),
 		container.NewHBox(
@@ -303,6 +507,7 @@ func (sv *SettingsView) buildEditForm() {
 			sv.testButton,
 			sv.deleteButton,
 		),
+		sv.errorsLabel,
 	)
 	
 	sv.editContainer = container.NewVBox(
@@ -317,11 +522,15 @@ func (sv *SettingsView) loadProviderConfig(name string) {
 		return
 	}
 	
+	sv.showProviderErrors(nil)
 	sv.nameEntry.SetText(name)
 	sv.displayNameEntry.SetText(config.DisplayName)
 	sv.apiKeyEntry.SetText(config.APIKey)
 	sv.baseURLEntry.SetText(config.BaseURL)
+	sv.proxyURLEntry.SetText(config.ProxyURL)
 	sv.modelEntry.SetText(config.DefaultModel)
+	sv.modelEntry.SetOptions(sv.providerModels(name))
+	sv.fetchProviderModels(name)
 	
 	// Convert models slice to comma-separated string
 	if len(config.Models) > 0 {
@@ -337,12 +546,58 @@ func (sv *SettingsView) loadProviderConfig(name string) {
 	} else {
 		sv.maxTokensEntry.SetText("")
 	}
+
+	if config.TimeoutSeconds > 0 {
+		sv.timeoutEntry.SetText(strconv.Itoa(config.TimeoutSeconds))
+	} else {
+		sv.timeoutEntry.SetText("")
+	}
 	
 	if config.Temperature > 0 {
 		sv.temperatureEntry.SetText(fmt.Sprintf("%.2f", config.Temperature))
 	} else {
 		sv.temperatureEntry.SetText("")
 	}
+
+	sv.sysPromptEntry.SetText(config.SystemPrompt)
+}
+
+// providerModels returns the models of a provider for the default model
+// dropdown: those of the running provider, else the configured ones
+func (sv *SettingsView) providerModels(name string) []string {
+	if provider, ok := sv.app.providers[name]; ok {
+		return provider.Models()
+	}
+	if config, ok := sv.providerConfigs[name]; ok {
+		return config.Models
+	}
+	return nil
+}
+
+// fetchProviderModels fetches the models of a provider that lists them from
+// its server, like Ollama, and offers them in the default model dropdown
+func (sv *SettingsView) fetchProviderModels(name string) {
+	provider, ok := sv.app.providers[name]
+	if !ok {
+		return
+	}
+	refresher, ok := llm.AsModelRefresher(provider)
+	if !ok {
+		return
+	}
+	utils.SafeGo(sv.app.logger, "fetchProviderModels", func() {
+		models, err := refresher.RefreshModels(context.Background())
+		if err != nil {
+			sv.app.logger.Warn("Failed to fetch the models of %s: %v", name, err)
+			return
+		}
+		fyne.Do(func() {
+			// The form may show another provider by now
+			if sv.selectedProvider == name {
+				sv.modelEntry.SetOptions(models)
+			}
+		})
+	})
 }
 
 // saveProviderConfig saves the current provider config
@@ -352,25 +607,45 @@ func (sv *SettingsView) saveProviderConfig() {
 		return
 	}
 	
-	config := &utils.ProviderConfig{
-		DisplayName:  sv.displayNameEntry.Text,
-		APIKey:       sv.apiKeyEntry.Text,
-		BaseURL:      sv.baseURLEntry.Text,
-		DefaultModel: sv.modelEntry.Text,
-		Enabled:      sv.enabledCheck.Checked,
-	}
+	// Start from the saved config so settings without form fields are kept
+	config := &utils.ProviderConfig{}
+	*config = sv.app.config.LLMProviders[sv.selectedProvider]
+	config.DisplayName = sv.displayNameEntry.Text
+	config.APIKey = sv.apiKeyEntry.Text
+	config.BaseURL = sv.baseURLEntry.Text
+	config.ProxyURL = strings.TrimSpace(sv.proxyURLEntry.Text)
+	config.DefaultModel = sv.modelEntry.Text
+	config.Enabled = sv.enabledCheck.Checked
+	config.SystemPrompt = strings.TrimSpace(sv.sysPromptEntry.Text)
+	config.Models = nil
+	config.MaxTokens = 0
+	config.TimeoutSeconds = 0
+	config.Temperature = 0
 	
 	// Parse models from comma-separated string
 	if sv.modelsEntry.Text != "" {
 		config.Models = parseModels(sv.modelsEntry.Text)
 	}
 	
+	var configErrors []utils.ConfigError
+	
 	// Parse optional fields
 	if sv.maxTokensEntry.Text != "" {
 		var maxTokens int
 		_, err := fmt.Sscanf(sv.maxTokensEntry.Text, "%d", &maxTokens)
 		if err == nil {
 			config.MaxTokens = maxTokens
+		} else {
+			configErrors = append(configErrors, utils.ConfigError{Field: "llm_providers." + sv.selectedProvider + ".max_tokens", Message: "not a number"})
+		}
+	}
+
+	if text := strings.TrimSpace(sv.timeoutEntry.Text); text != "" {
+		timeout, err := strconv.Atoi(text)
+		if err == nil {
+			config.TimeoutSeconds = timeout
+		} else {
+			configErrors = append(configErrors, utils.ConfigError{Field: "llm_providers." + sv.selectedProvider + ".timeout_seconds", Message: "not a number"})
 		}
 	}
 	
@@ -379,9 +654,19 @@ func (sv *SettingsView) saveProviderConfig() {
 		_, err := fmt.Sscanf(sv.temperatureEntry.Text, "%f", &temp)
 		if err == nil {
 			config.Temperature = temp
+		} else {
+			configErrors = append(configErrors, utils.ConfigError{Field: "llm_providers." + sv.selectedProvider + ".temperature", Message: "not a number"})
 		}
 	}
 	
+	// Show every problem at once instead of saving a broken provider
+	configErrors = append(configErrors, utils.ValidateProviderConfig(sv.selectedProvider, *config)...)
+	sv.showProviderErrors(configErrors)
+	if len(configErrors) > 0 {
+		sv.app.logger.Warn("Provider %s configuration has %d errors, not saved", sv.selectedProvider, len(configErrors))
+		return
+	}
+	
 	// Update in memory
 	sv.providerConfigs[sv.selectedProvider] = config
 	sv.app.config.LLMProviders[sv.selectedProvider] = *config
@@ -502,11 +787,32 @@ func (sv *SettingsView) clearForm() {
 	sv.displayNameEntry.SetText("")
 	sv.apiKeyEntry.SetText("")
 	sv.baseURLEntry.SetText("")
+	sv.proxyURLEntry.SetText("")
 	sv.modelEntry.SetText("")
+	sv.modelEntry.SetOptions(nil)
 	sv.modelsEntry.SetText("")
 	sv.maxTokensEntry.SetText("")
+	sv.timeoutEntry.SetText("")
 	sv.temperatureEntry.SetText("")
+	sv.sysPromptEntry.SetText("")
 	sv.enabledCheck.SetChecked(false)
+	sv.showProviderErrors(nil)
+}
+
+// showProviderErrors lists validation errors below the provider form, or hides the list when there are none
+func (sv *SettingsView) showProviderErrors(configErrors []utils.ConfigError) {
+	if len(configErrors) == 0 {
+		sv.errorsLabel.SetText("")
+		sv.errorsLabel.Hide()
+		return
+	}
+	
+	lines := make([]string, 0, len(configErrors))
+	for _, configErr := range configErrors {
+		lines = append(lines, "⚠ "+configErr.Error())
+	}
+	sv.errorsLabel.SetText(strings.Join(lines, "\n"))
+	sv.errorsLabel.Show()
 }
 
 // showError shows an error message
@@ -653,11 +959,7 @@ func (sv *SettingsView) applyTheme(theme string) {
 	}
 	
 	// Apply theme to app
-	if themeLower == "dark" {
-		sv.app.fyneApp.Settings().SetTheme(&darkTheme{})
-	} else {
-		sv.app.fyneApp.Settings().SetTheme(&lightTheme{})
-	}
+	sv.app.applyThemeFromConfig()
 	
 	sv.app.logger.Info("Theme changed to: %s", themeLower)
 	sv.showSuccess("Theme changed successfully")
@@ -705,6 +1007,15 @@ func (sv *SettingsView) testProviderConnection() {
 			return
 		}
 	}
+
+	var timeout int
+	if text := strings.TrimSpace(sv.timeoutEntry.Text); text != "" {
+		var err error
+		if timeout, err = strconv.Atoi(text); err != nil {
+			sv.showError("Invalid Request Timeout value")
+			return
+		}
+	}
 	
 	var temperature float64
 	if sv.temperatureEntry.Text != "" {
@@ -722,7 +1033,9 @@ func (sv *SettingsView) testProviderConnection() {
 		BaseURL:      baseURL,
 		Model:        model,
 		MaxTokens:    maxTokens,
+		Timeout:      timeout,
 		Temperature:  temperature,
+		ProxyURL:     sv.app.config.ProviderProxyURL(utils.ProviderConfig{ProxyURL: strings.TrimSpace(sv.proxyURLEntry.Text)}),
 	}
 	
 	// Try to initialize the provider
@@ -737,6 +1050,26 @@ func (sv *SettingsView) testProviderConnection() {
 		provider, err = llm.NewClaudeProvider(config)
 	} else if sv.selectedProvider == "gemini" {
 		provider, err = llm.NewGeminiProvider(config)
+	} else if sv.selectedProvider == "cohere" {
+		provider, err = llm.NewCohereProvider(config)
+	} else if sv.selectedProvider == "mistral" {
+		provider, err = llm.NewMistralProvider(config)
+	} else if sv.selectedProvider == "perplexity" {
+		provider, err = llm.NewPerplexityProvider(config)
+	} else if sv.selectedProvider == "groq" {
+		provider, err = llm.NewGroqProvider(config)
+	} else if sv.selectedProvider == "deepseek" {
+		provider, err = llm.NewDeepSeekProvider(config)
+	} else if sv.selectedProvider == "xai" || sv.selectedProvider == "grok" {
+		// The reasoning effort has no form field, use the saved one
+		config.ReasoningEffort = sv.app.config.LLMProviders[sv.selectedProvider].ReasoningEffort
+		provider, err = llm.NewXAIProvider(config)
+	} else if sv.selectedProvider == "azure" || sv.selectedProvider == "azure_openai" {
+		// The deployment has no form field, use the saved one
+		saved := sv.app.config.LLMProviders[sv.selectedProvider]
+		config.DeploymentName = saved.DeploymentName
+		config.APIVersion = saved.APIVersion
+		provider, err = llm.NewAzureOpenAIProvider(config)
 	} else {
 		// Treat as OpenAI-compatible
 		provider, err = llm.NewOpenAIProvider(config)
@@ -755,24 +1088,40 @@ func (sv *SettingsView) testProviderConnection() {
 		return
 	}
 	
-	// Try a simple test message
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-	defer cancel()
-	
-	testMessages := []llm.Message{
-		{Role: "user", Content: "Hello"},
-	}
-	
-	sv.app.logger.Info("Sending test message...")
-	_, err = provider.Chat(ctx, testMessages)
-	if err != nil {
-		sv.app.logger.Error("Test message failed: %v", err)
-		sv.showError("Connection test failed: " + err.Error())
-		return
-	}
-	
-	sv.app.logger.Info("Connection test successful for: %s", sv.selectedProvider)
-	sv.showSuccess("✅ Connection test successful!\n\nProvider: " + displayName + "\nModel: " + model)
+	// The checks can take a while, run them off the UI thread
+	name := sv.selectedProvider
+	sv.testButton.Disable()
+	utils.SafeGo(sv.app.logger, "testProviderConnection", func() {
+		defer fyne.Do(sv.testButton.Enable)
+
+		// Check the network first so a failure can be told apart from an API error
+		report := utils.RunNetworkDiagnostics(baseURL, config.ProxyURL)
+		if !report.OK() {
+			sv.app.logger.Warn("Network diagnostics for %s failed: %v", baseURL, report.Errors)
+		}
+
+		// Try a simple test message
+		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+		defer cancel()
+
+		testMessages := []llm.Message{
+			{Role: "user", Content: "Hello"},
+		}
+
+		sv.app.logger.Info("Sending test message...")
+		if _, err := provider.Chat(ctx, testMessages); err != nil {
+			sv.app.logger.Error("Test message failed: %v", err)
+			fyne.Do(func() {
+				sv.showError("Connection test failed: " + err.Error() + "\n\n" + report.String())
+			})
+			return
+		}
+
+		sv.app.logger.Info("Connection test successful for: %s", name)
+		fyne.Do(func() {
+			sv.showSuccess("✅ Connection test successful!\n\nProvider: " + displayName + "\nModel: " + model + "\n\n" + report.String())
+		})
+	})
 }
 
 // buildDataSettings builds the data settings section
@@ -841,6 +1190,10 @@ func (sv *SettingsView) buildDataSettings() *fyne.Container {
 	vacuumBtn := widget.NewButton("优化数据库", func() {
 		sv.vacuumDatabase(statsLabel)
 	})
+
+	duplicatesBtn := widget.NewButton("查找重复对话", func() {
+		sv.showDuplicates()
+	})
 	
 	refreshStatsBtn := widget.NewButton("刷新统计", func() {
 		sv.updateDBStats(statsLabel)
@@ -883,6 +1236,18 @@ func (sv *SettingsView) buildDataSettings() *fyne.Container {
 	})
 	anonymizeFilePathsCheck.Checked = sv.app.config.Privacy.AnonymizeFilePaths
 
+	structuredAnonymizerCheck := widget.NewCheck("使用分词分类匿名化（更快，误判更少）", func(checked bool) {
+		sv.app.config.Privacy.StructuredAnonymizer = checked
+		sv.savePrivacyConfig()
+	})
+	structuredAnonymizerCheck.Checked = sv.app.config.Privacy.StructuredAnonymizer
+
+	redactBeforeLogCheck := widget.NewCheck("写入日志前隐藏 API 密钥、Bearer Token 和 JWT", func(checked bool) {
+		sv.app.config.Privacy.RedactBeforeLog = checked
+		sv.savePrivacyConfig()
+	})
+	redactBeforeLogCheck.Checked = sv.app.config.Privacy.RedactBeforeLog
+
 	anonymizationContainer := container.NewVBox(
 		widget.NewLabel("匿名化设置"),
 		widget.NewSeparator(),
@@ -894,11 +1259,120 @@ func (sv *SettingsView) buildDataSettings() *fyne.Container {
 			anonymizeIPsCheck,
 			anonymizeFilePathsCheck,
 		),
+		structuredAnonymizerCheck,
+		redactBeforeLogCheck,
 	)
 
+	// Log file info
+	logInfoLabel := widget.NewLabel("")
+	logInfoLabel.Wrapping = fyne.TextWrapWord
+	updateLogInfo := func() {
+		logInfoLabel.SetText(fmt.Sprintf("日志文件: %s\n当前大小: %s",
+			sv.app.logger.Path(), utils.FormatFileSize(sv.app.logger.Size())))
+	}
+	updateLogInfo()
+
+	viewLogBtn := widget.NewButton("查看日志", func() {
+		updateLogInfo()
+		if err := utils.OpenWithDefaultApp(sv.app.logger.Path()); err != nil {
+			sv.app.logger.Error("Failed to open log file: %v", err)
+			sv.showError("无法打开日志文件: " + err.Error())
+		}
+	})
+
+	// Auto summarize settings
+	autoSummarizeCheck := widget.NewCheck("自动摘要较早的消息", nil)
+	autoSummarizeCheck.SetChecked(sv.app.config.Data.AutoSummarize)
+
+	summarizeAfterEntry := widget.NewEntry()
+	summarizeAfterEntry.SetPlaceHolder("20")
+	if sv.app.config.Data.SummarizeAfterMessages > 0 {
+		summarizeAfterEntry.SetText(strconv.Itoa(sv.app.config.Data.SummarizeAfterMessages))
+	}
+
+	summarizeNote := widget.NewLabel("消息数量超过阈值后，较早的消息会被当前模型压缩为一条摘要 (原消息将被删除)")
+	summarizeNote.Wrapping = fyne.TextWrapWord
+	summarizeNote.TextStyle = fyne.TextStyle{Italic: true}
+
+	saveSummarizeBtn := widget.NewButton("保存摘要设置", func() {
+		summarizeAfter := 0
+		if summarizeAfterEntry.Text != "" {
+			val, err := strconv.Atoi(summarizeAfterEntry.Text)
+			if err != nil || val < summaryKeepRecent+2 {
+				sv.showError(fmt.Sprintf("请输入有效的数字 (>= %d)", summaryKeepRecent+2))
+				return
+			}
+			summarizeAfter = val
+		}
+
+		sv.app.config.Data.AutoSummarize = autoSummarizeCheck.Checked
+		sv.app.config.Data.SummarizeAfterMessages = summarizeAfter
+		if err := utils.SaveConfig(sv.app.configPath, sv.app.config); err != nil {
+			sv.app.logger.Error("Failed to save summarize settings: %v", err)
+			sv.showError("保存失败: " + err.Error())
+			return
+		}
+
+		sv.app.logger.Info("Auto summarize updated: enabled=%v, after=%d", autoSummarizeCheck.Checked, summarizeAfter)
+		sv.showSuccess("摘要设置已更新")
+	})
+
+	// WebDAV sync settings
+	webdavURLEntry := widget.NewEntry()
+	webdavURLEntry.SetPlaceHolder("https://dav.example.com/llm/chat.db")
+	webdavURLEntry.SetText(sv.app.config.Sync.WebDAVURL)
+	webdavUserEntry := widget.NewEntry()
+	webdavUserEntry.SetPlaceHolder("用户名")
+	webdavUserEntry.SetText(sv.app.config.Sync.Username)
+	webdavPasswordEntry := widget.NewPasswordEntry()
+	webdavPasswordEntry.SetPlaceHolder("密码")
+	webdavPasswordEntry.SetText(sv.app.config.Sync.Password)
+	syncIntervalEntry := widget.NewEntry()
+	syncIntervalEntry.SetPlaceHolder("30")
+	if sv.app.config.Sync.SyncIntervalMinutes > 0 {
+		syncIntervalEntry.SetText(strconv.Itoa(sv.app.config.Sync.SyncIntervalMinutes))
+	}
+
+	syncNote := widget.NewLabel("启动时下载较新的远程数据库，退出时及每隔指定分钟上传 (0 = 仅启动和退出时同步)，修改后重启生效")
+	syncNote.Wrapping = fyne.TextWrapWord
+	syncNote.TextStyle = fyne.TextStyle{Italic: true}
+
+	saveSyncBtn := widget.NewButton("保存同步设置", func() {
+		interval := 0
+		if syncIntervalEntry.Text != "" {
+			val, err := strconv.Atoi(syncIntervalEntry.Text)
+			if err != nil || val < 0 {
+				sv.showError("请输入有效的数字 (>= 0)")
+				return
+			}
+			interval = val
+		}
+
+		sv.app.config.Sync.WebDAVURL = strings.TrimSpace(webdavURLEntry.Text)
+		sv.app.config.Sync.Username = webdavUserEntry.Text
+		sv.app.config.Sync.Password = webdavPasswordEntry.Text
+		sv.app.config.Sync.SyncIntervalMinutes = interval
+		if err := utils.SaveConfig(sv.app.configPath, sv.app.config); err != nil {
+			sv.app.logger.Error("Failed to save sync settings: %v", err)
+			sv.showError("保存失败: " + err.Error())
+			return
+		}
+
+		sv.app.logger.Info("WebDAV sync settings updated (enabled=%v)", sv.app.config.Sync.Enabled())
+		sv.showSuccess("同步设置已保存，重启后生效")
+	})
+
+	logRotationNote := widget.NewLabel(fmt.Sprintf("日志轮转: 超过 %d MB 时轮转，保留 %d 个备份，最长 %d 天",
+		sv.app.config.Log.MaxSizeMB, sv.app.config.Log.MaxBackups, sv.app.config.Log.MaxAgeDays))
+	logRotationNote.Wrapping = fyne.TextWrapWord
+	logRotationNote.TextStyle = fyne.TextStyle{Italic: true}
+
 	form := widget.NewForm(
 		widget.NewFormItem("数据库路径", container.NewVBox(dbPathEntry, dbPathNote)),
 		widget.NewFormItem("最大历史记录", container.NewVBox(maxHistoryEntry, maxHistoryNote, saveMaxHistoryBtn)),
+		widget.NewFormItem("自动摘要", container.NewVBox(autoSummarizeCheck, summarizeAfterEntry, summarizeNote, saveSummarizeBtn)),
+		widget.NewFormItem("WebDAV 同步", container.NewVBox(webdavURLEntry, webdavUserEntry, webdavPasswordEntry, syncIntervalEntry, syncNote, saveSyncBtn)),
+		widget.NewFormItem("定时导出", sv.buildExportScheduleSettings()),
 	)
 
 	return container.NewVBox(
@@ -913,7 +1387,12 @@ func (sv *SettingsView) buildDataSettings() *fyne.Container {
 			cleanupOldBtn,
 			applyLimitBtn,
 		),
-		container.NewHBox(vacuumBtn),
+		container.NewHBox(vacuumBtn, duplicatesBtn),
+		widget.NewLabel("日志"),
+		logInfoLabel,
+		logRotationNote,
+		container.NewHBox(viewLogBtn),
+		sv.buildCrashReportSection(),
 		widget.NewSeparator(),
 		anonymizationContainer,
 	)
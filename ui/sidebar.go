@@ -33,7 +33,12 @@ func NewConversationItem(app *App, conv *db.Conversation, onTapped func()) *Conv
 	if conv.Category != "" {
 		displayText = "[" + conv.Category + "] " + conv.Title
 	}
+	// Pinned conversations are bold and marked
+	if conv.Pinned {
+		displayText = "📌 " + displayText
+	}
 	item.label = widget.NewLabel(displayText)
+	item.label.TextStyle = fyne.TextStyle{Bold: conv.Pinned}
 	item.ExtendBaseWidget(item)
 	return item
 }
@@ -70,6 +75,22 @@ func (ci *ConversationItem) showContextMenu(pos fyne.Position) {
 		ci.app.setCategoryForConversation(ci.conversation.ID)
 	})
 	
+	pinLabel := "📌 置顶"
+	if ci.conversation.Pinned {
+		pinLabel = "取消置顶"
+	}
+	pinItem := fyne.NewMenuItem(pinLabel, func() {
+		ci.app.setConversationPinned(ci.conversation.ID, !ci.conversation.Pinned)
+	})
+
+	duplicateItem := fyne.NewMenuItem("创建副本", func() {
+		ci.app.duplicateConversation(ci.conversation.ID)
+	})
+
+	mergeItem := fyne.NewMenuItem("合并到…", func() {
+		ci.app.showMergeDialog(ci.conversation.ID)
+	})
+
 	exportJSONItem := fyne.NewMenuItem("导出为 JSON", func() {
 		ci.app.exportConversation(ci.conversation.ID, utils.FormatJSON)
 	})
@@ -77,13 +98,40 @@ func (ci *ConversationItem) showContextMenu(pos fyne.Position) {
 	exportMarkdownItem := fyne.NewMenuItem("导出为 Markdown", func() {
 		ci.app.exportConversation(ci.conversation.ID, utils.FormatMarkdown)
 	})
+
+	exportHTMLItem := fyne.NewMenuItem("导出为 HTML", func() {
+		ci.app.exportConversation(ci.conversation.ID, utils.FormatHTML)
+	})
+
+	exportPDFItem := fyne.NewMenuItem("导出为 PDF", func() {
+		ci.app.exportConversation(ci.conversation.ID, utils.FormatPDF)
+	})
+
+	exportCSVItem := fyne.NewMenuItem("导出为 CSV", func() {
+		ci.app.exportConversation(ci.conversation.ID, utils.FormatCSV)
+	})
+
+	exportUsageItem := fyne.NewMenuItem("导出用量报告 (PDF)", func() {
+		ci.app.exportConversationUsageReport(ci.conversation.ID)
+	})
 	
+	abTestItem := fyne.NewMenuItem("标记为 A/B 测试", func() {
+		ci.app.showPromptABTestDialog(ci.conversation.ID)
+	})
+
 	deleteItem := fyne.NewMenuItem("删除", func() {
 		ci.app.deleteConversationByID(ci.conversation.ID)
 	})
 	
+	items := []*fyne.MenuItem{pinItem, renameItem, categoryItem, duplicateItem, mergeItem, exportJSONItem, exportMarkdownItem, exportHTMLItem, exportPDFItem, exportCSVItem, exportUsageItem, abTestItem}
+	// The open tab of the conversation can be split at its selected message
+	if cv, ok := ci.app.chatViews[ci.conversation.ID]; ok && cv.canSplitAtSelected() {
+		items = append(items, fyne.NewMenuItem(splitMenuLabel, cv.splitAtSelected))
+	}
+	items = append(items, deleteItem)
+
 	// Create and show popup menu
-	menu := fyne.NewMenu("", renameItem, categoryItem, exportJSONItem, exportMarkdownItem, deleteItem)
+	menu := fyne.NewMenu("", items...)
 	popupMenu := widget.NewPopUpMenu(menu, ci.app.window.Canvas())
 	popupMenu.ShowAtPosition(pos)
 }
@@ -107,7 +155,7 @@ func (ci *ConversationItem) SetHighlighted(highlighted bool) {
 		if highlighted {
 			ci.label.TextStyle = fyne.TextStyle{Bold: true}
 		} else {
-			ci.label.TextStyle = fyne.TextStyle{}
+			ci.label.TextStyle = fyne.TextStyle{Bold: ci.conversation.Pinned}
 		}
 		ci.Refresh()
 	}
@@ -129,6 +177,7 @@ type ConversationSidebar struct {
 	categoryFilter *widget.Select
 	filterText     string
 	filterCategory string
+	filterTag      string
 	preloadRunning bool // Flag to prevent duplicate preloading
 }
 
@@ -151,9 +200,11 @@ func NewConversationSidebar(app *App) *ConversationSidebar {
 	
 	// Create category filter
 	sidebar.categoryFilter = widget.NewSelect([]string{"全部分类"}, func(selected string) {
-		if selected == "全部分类" {
-			sidebar.filterCategory = ""
-		} else {
+		sidebar.filterCategory = ""
+		sidebar.filterTag = ""
+		if strings.HasPrefix(selected, tagFilterPrefix) {
+			sidebar.filterTag = strings.TrimPrefix(selected, tagFilterPrefix)
+		} else if selected != "全部分类" {
 			sidebar.filterCategory = selected
 		}
 		sidebar.updateList()
@@ -201,7 +252,25 @@ func (cs *ConversationSidebar) updateList() {
 		categories = []string{}
 	}
 	categoryOptions := append([]string{"全部分类"}, categories...)
+
+	// Tags are offered after the categories
+	tags, err := cs.app.db.ListTags()
+	if err != nil {
+		cs.app.logger.Error("Failed to get tags: %v", err)
+	}
+	for _, tag := range tags {
+		categoryOptions = append(categoryOptions, tagFilterPrefix+tag)
+	}
 	cs.categoryFilter.Options = categoryOptions
+
+	// Conversations containing a message with the selected tag
+	var taggedConversations map[int64]bool
+	if cs.filterTag != "" {
+		taggedConversations, err = cs.app.db.ListConversationIDsByTag(cs.filterTag)
+		if err != nil {
+			cs.app.logger.Error("Failed to filter conversations by tag: %v", err)
+		}
+	}
 	
 	// Clear existing items
 	cs.items = []*ConversationItem{}
@@ -226,6 +295,11 @@ func (cs *ConversationSidebar) updateList() {
 				continue
 			}
 		}
+
+		// Apply tag filter
+		if cs.filterTag != "" && !taggedConversations[conv.ID] {
+			continue
+		}
 		
 		// Capture conv in closure
 		conversation := conv
@@ -305,10 +379,11 @@ func (cs *ConversationSidebar) preloadVisibleConversations() {
 			// Create a temporary ChatView just for building UI
 			tempChatView := NewChatView(cs.app)
 			tempChatView.conversationID = convID
+			details := tempChatView.loadConversationDetails()
 			
 			uiObjects := make([]fyne.CanvasObject, 0, len(messages)*4)
 			for j, msg := range messages {
-				messageBox := tempChatView.buildMessageUI(msg, j)
+				messageBox := tempChatView.buildMessageUIWith(msg, j, details[msg.ID])
 				uiObjects = append(uiObjects, messageBox)
 			}
 			
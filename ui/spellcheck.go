@@ -0,0 +1,249 @@
+package ui
+
+import (
+	"image/color"
+	"light-llm-client/utils"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// spellCheckDelay is how long typing must pause before the current text is checked
+const spellCheckDelay = 400 * time.Millisecond
+
+// maxSpellSuggestions limits the number of suggestions in the context menu
+const maxSpellSuggestions = 5
+
+var spellUnderlineColor = color.NRGBA{R: 230, G: 40, B: 40, A: 255}
+
+// misspellingArea is the on-screen area of one (possibly wrapped) misspelled word
+type misspellingArea struct {
+	misspelling utils.Misspelling
+	min, max    fyne.Position
+}
+
+// scheduleSpellCheck (re)starts the debounce timer for spell checking
+func (e *customEntry) scheduleSpellCheck() {
+	if e.app == nil || e.app.spellChecker == nil {
+		return
+	}
+
+	if e.spellTimer != nil {
+		e.spellTimer.Stop()
+	}
+	e.spellTimer = time.AfterFunc(spellCheckDelay, func() {
+		fyne.Do(e.runSpellCheck)
+	})
+}
+
+// runSpellCheck checks the whole entry text and refreshes the underlines.
+// Must be called on the UI thread.
+func (e *customEntry) runSpellCheck() {
+	if e.app == nil || e.app.spellChecker == nil {
+		return
+	}
+	e.misspellings = e.app.spellChecker.Check(e.Text)
+	e.Refresh()
+}
+
+// TappedSecondary shows spelling suggestions when right-clicking a misspelled
+// word, and the default entry menu otherwise
+func (e *customEntry) TappedSecondary(pe *fyne.PointEvent) {
+	if m, ok := e.misspellingAt(pe.Position); ok {
+		e.showSpellingMenu(m, pe.AbsolutePosition)
+		return
+	}
+	e.Entry.TappedSecondary(pe)
+}
+
+// misspellingAt finds the misspelled word drawn at the given widget position
+func (e *customEntry) misspellingAt(pos fyne.Position) (utils.Misspelling, bool) {
+	for _, area := range e.misspellingAreas {
+		if pos.X >= area.min.X && pos.X <= area.max.X && pos.Y >= area.min.Y && pos.Y <= area.max.Y {
+			return area.misspelling, true
+		}
+	}
+	return utils.Misspelling{}, false
+}
+
+// showSpellingMenu shows suggestions for a misspelled word
+func (e *customEntry) showSpellingMenu(m utils.Misspelling, at fyne.Position) {
+	var items []*fyne.MenuItem
+	for _, suggestion := range e.app.spellChecker.Suggest(m.Word, maxSpellSuggestions) {
+		replacement := suggestion
+		items = append(items, fyne.NewMenuItem(replacement, func() {
+			e.replaceMisspelling(m, replacement)
+		}))
+	}
+	if len(items) == 0 {
+		noSuggestions := fyne.NewMenuItem("(无拼写建议)", nil)
+		noSuggestions.Disabled = true
+		items = append(items, noSuggestions)
+	}
+
+	items = append(items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("添加到词典", func() {
+		e.app.addCustomSpellWord(m.Word)
+		e.runSpellCheck()
+	}))
+
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), e.app.window.Canvas(), at)
+}
+
+// replaceMisspelling replaces a misspelled word with the chosen suggestion
+func (e *customEntry) replaceMisspelling(m utils.Misspelling, replacement string) {
+	runes := []rune(e.Text)
+	if m.End > len(runes) || string(runes[m.Start:m.End]) != m.Word {
+		// Text changed since the check ran
+		e.runSpellCheck()
+		return
+	}
+	e.SetText(string(runes[:m.Start]) + replacement + string(runes[m.End:]))
+	e.runSpellCheck()
+}
+
+// CreateRenderer wraps the standard entry renderer with spelling underlines
+func (e *customEntry) CreateRenderer() fyne.WidgetRenderer {
+	return &spellCheckRenderer{
+		WidgetRenderer: e.Entry.CreateRenderer(),
+		entry:          e,
+	}
+}
+
+// spellCheckRenderer draws red lines beneath misspelled words on top of the entry
+type spellCheckRenderer struct {
+	fyne.WidgetRenderer
+	entry *customEntry
+	lines []fyne.CanvasObject
+}
+
+// Layout lays out the entry and positions the underlines
+func (r *spellCheckRenderer) Layout(size fyne.Size) {
+	r.WidgetRenderer.Layout(size)
+	r.layoutUnderlines(size)
+}
+
+// Objects returns the entry objects followed by the underlines
+func (r *spellCheckRenderer) Objects() []fyne.CanvasObject {
+	objects := r.WidgetRenderer.Objects()
+	result := make([]fyne.CanvasObject, 0, len(objects)+len(r.lines))
+	result = append(result, objects...)
+	return append(result, r.lines...)
+}
+
+// Refresh refreshes the entry and rebuilds the underlines
+func (r *spellCheckRenderer) Refresh() {
+	r.WidgetRenderer.Refresh()
+	r.layoutUnderlines(r.entry.Size())
+}
+
+// layoutUnderlines computes where each misspelled word is drawn.
+// The position is estimated from the text metrics using the same break-anywhere
+// wrapping as the entry; words scrolled out of view are not underlined.
+func (r *spellCheckRenderer) layoutUnderlines(size fyne.Size) {
+	r.lines = r.lines[:0]
+	r.entry.misspellingAreas = r.entry.misspellingAreas[:0]
+
+	if len(r.entry.misspellings) == 0 || r.entry.Text == "" || size.Width <= 0 {
+		return
+	}
+
+	th := r.entry.Theme()
+	textSize := th.Size(theme.SizeNameText)
+	padding := th.Size(theme.SizeNameInnerPadding)
+	style := r.entry.TextStyle
+	rowHeight := fyne.MeasureText("M", textSize, style).Height
+	maxWidth := size.Width - padding*2
+
+	runes := []rune(r.entry.Text)
+	next := 0 // index into misspellings
+	x, y := float32(0), float32(0)
+	var segmentStart fyne.Position
+	inWord := false
+
+	addSegment := func(m utils.Misspelling, endX float32) {
+		start := fyne.NewPos(padding+segmentStart.X, padding+segmentStart.Y)
+		end := fyne.NewPos(padding+endX, padding+segmentStart.Y+rowHeight)
+		if end.Y > size.Height || endX <= segmentStart.X {
+			return
+		}
+		line := canvas.NewLine(spellUnderlineColor)
+		line.StrokeWidth = 1
+		line.Position1 = fyne.NewPos(start.X, end.Y-1)
+		line.Position2 = fyne.NewPos(end.X, end.Y-1)
+		r.lines = append(r.lines, line)
+		r.entry.misspellingAreas = append(r.entry.misspellingAreas, misspellingArea{
+			misspelling: m,
+			min:         start,
+			max:         end,
+		})
+	}
+
+	for i := 0; i <= len(runes) && next < len(r.entry.misspellings); i++ {
+		m := r.entry.misspellings[next]
+		if i == m.End && inWord {
+			addSegment(m, x)
+			inWord = false
+			next++
+			if next < len(r.entry.misspellings) {
+				m = r.entry.misspellings[next]
+			}
+		}
+		if i == len(runes) {
+			break
+		}
+		if i == m.Start {
+			inWord = true
+			segmentStart = fyne.NewPos(x, y)
+		}
+
+		if runes[i] == '\n' {
+			x = 0
+			y += rowHeight
+			continue
+		}
+
+		w := fyne.MeasureText(string(runes[i]), textSize, style).Width
+		if x+w > maxWidth && x > 0 {
+			// Wrapped: close the current segment and continue on the next row
+			if inWord {
+				addSegment(m, x)
+			}
+			x = 0
+			y += rowHeight
+			if inWord {
+				segmentStart = fyne.NewPos(x, y)
+			}
+		}
+		x += w
+	}
+}
+
+// addCustomSpellWord stores a word in the custom dictionary
+func (a *App) addCustomSpellWord(word string) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return
+	}
+	if err := a.db.AddCustomWord(word); err != nil {
+		a.logger.Error("Failed to add custom spell word: %v", err)
+		return
+	}
+	a.spellChecker.AddCustomWord(word)
+	a.logger.Info("Added custom spell word: %s", word)
+}
+
+// initSpellChecker creates the spell checker and loads custom words from the database
+func (a *App) initSpellChecker() {
+	a.spellChecker = utils.NewSpellChecker(a.config.UI.SpellCheck)
+
+	words, err := a.db.ListCustomWords()
+	if err != nil {
+		a.logger.Error("Failed to load custom spell words: %v", err)
+		return
+	}
+	a.spellChecker.SetCustomWords(words)
+}
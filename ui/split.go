@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// splitMenuLabel is the menu item that splits a conversation at the selected
+// message
+const splitMenuLabel = "✂️ 在此处拆分"
+
+// canSplitAtSelected reports whether the conversation can be split at the
+// selected message: a user message that isn't the first one
+func (cv *ChatView) canSplitAtSelected() bool {
+	i := cv.selectedMessage
+	return cv.conversationID != 0 && i > 0 && i < len(cv.messages) && cv.messages[i].Role == "user"
+}
+
+// splitAtSelected asks to split the conversation at the selected message
+func (cv *ChatView) splitAtSelected() {
+	if !cv.canSplitAtSelected() {
+		return
+	}
+	messageID, err := cv.resolveMessageID(&cv.messages[cv.selectedMessage], cv.selectedMessage)
+	if err != nil {
+		cv.app.logger.Error("Failed to resolve message for splitting: %v", err)
+		cv.app.showError("无法找到该消息: " + err.Error())
+		return
+	}
+	cv.app.showSplitDialog(cv.conversationID, messageID)
+}
+
+// showSplitDialog asks to confirm splitting a conversation at a message,
+// showing how many messages move to the new conversation
+func (a *App) showSplitDialog(conversationID, messageID int64) {
+	conv, err := a.db.GetConversation(conversationID)
+	if err != nil {
+		a.showError("对话不存在")
+		return
+	}
+	messages, err := a.db.ListMessages(conversationID)
+	if err != nil {
+		a.showError("加载消息失败: " + err.Error())
+		return
+	}
+	index := -1
+	for i, msg := range messages {
+		if msg.ID == messageID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		a.showError("无法找到该消息")
+		return
+	}
+
+	note := widget.NewLabel(fmt.Sprintf("从这条消息起的 %d 条消息将移到新对话“%s (拆分)”，原对话保留前 %d 条消息。",
+		len(messages)-index, conv.Title, index))
+	note.Wrapping = fyne.TextWrapWord
+	preview := widget.NewLabel(truncateRunes(messages[index].Content, 120))
+	preview.Wrapping = fyne.TextWrapWord
+	preview.TextStyle = fyne.TextStyle{Italic: true}
+
+	var popup *widget.PopUp
+	splitButton := widget.NewButton("拆分", func() {
+		popup.Hide()
+		a.splitConversation(conversationID, messageID)
+	})
+	splitButton.Importance = widget.HighImportance
+
+	popup = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel("拆分对话"),
+			note,
+			preview,
+			container.NewHBox(
+				widget.NewButton("取消", func() {
+					popup.Hide()
+				}),
+				splitButton,
+			),
+		),
+		a.window.Canvas(),
+	)
+	popup.Resize(fyne.NewSize(420, popup.MinSize().Height))
+	popup.Show()
+}
+
+// splitConversation moves the messages from messageID onwards into a new
+// conversation and opens it
+func (a *App) splitConversation(conversationID, messageID int64) {
+	created, err := a.db.SplitConversation(conversationID, messageID)
+	if err != nil {
+		a.logger.Error("Failed to split conversation %d at message %d: %v", conversationID, messageID, err)
+		a.showError("拆分失败: " + err.Error())
+		return
+	}
+	a.logger.Info("Split conversation %d at message %d into %d", conversationID, messageID, created.ID)
+
+	if cv, ok := a.chatViews[conversationID]; ok {
+		cv.selectedMessage = -1
+	}
+	a.RefreshSidebar()
+	// An open tab of the source still shows the moved messages
+	a.reloadConversation(conversationID)
+	a.openChatTab(created.ID)
+}
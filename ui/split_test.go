@@ -0,0 +1,50 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestApp_SplitConversation(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{Responses: []string{"answer"}}))
+	cv, convID := newTestChat(t, a)
+
+	idle := func() bool {
+		var idle bool
+		fyne.DoAndWait(func() { idle = !cv.sendButton.Disabled() && len(cv.messages) == len(cv.messagesContainer.Objects) })
+		return idle
+	}
+	sendTestMessage(cv, "first question")
+	waitForMessages(t, a, convID, 2)
+	waitUntil(t, "sending to be enabled", idle)
+	sendTestMessage(cv, "second question")
+	messages := waitForMessages(t, a, convID, 4)
+	waitUntil(t, "sending to be enabled", idle)
+
+	// Only user messages after the first can be split at
+	for i, want := range []bool{false, false, true, false} {
+		cv.buildMessageMenu(cv.messages[i].Content, i)
+		if got := cv.canSplitAtSelected(); got != want {
+			t.Errorf("can split at message %d = %v, want %v", i, got, want)
+		}
+	}
+	cv.buildMessageMenu(cv.messages[2].Content, 2)
+
+	a.splitConversation(convID, messages[2].ID)
+
+	newID := a.getActiveConversationID()
+	if newID == convID {
+		t.Fatal("expected the new conversation to be opened")
+	}
+	if moved := waitForMessages(t, a, newID, 2); moved[0].Content != "second question" {
+		t.Errorf("unexpected moved messages %+v", moved)
+	}
+	waitForMessages(t, a, convID, 2)
+	if cv.selectedMessage != -1 {
+		t.Errorf("selected message = %d after the split, want none", cv.selectedMessage)
+	}
+}
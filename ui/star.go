@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"image/color"
+	"light-llm-client/db"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+)
+
+// starColor is the border left of starred messages
+var starColor = color.NRGBA{R: 0xf5, G: 0xb7, B: 0x01, A: 0xff}
+
+// starMarkerWidth is the width of the border of starred messages
+const starMarkerWidth = 4
+
+// starButtonText is the text of the star button of a message
+func starButtonText(starred bool) string {
+	if starred {
+		return "⭐"
+	}
+	return "☆"
+}
+
+// newStarMarker creates the gold border of a message, shown while it is
+// starred
+func newStarMarker(starred bool) *canvas.Rectangle {
+	marker := canvas.NewRectangle(starColor)
+	marker.SetMinSize(fyne.NewSize(starMarkerWidth, 0))
+	setVisible(marker, starred)
+	return marker
+}
+
+// newStarButton creates the button that stars or unstars a message and
+// shows or hides its marker
+func (cv *ChatView) newStarButton(msg *db.Message, messageIndex int, marker *canvas.Rectangle) *widget.Button {
+	button := widget.NewButton(starButtonText(msg.Starred), nil)
+	button.Importance = widget.LowImportance
+	button.OnTapped = func() {
+		if err := cv.toggleStar(msg, messageIndex); err != nil {
+			cv.app.logger.Error("Failed to star message: %v", err)
+			cv.app.showError("星标失败: " + err.Error())
+			return
+		}
+		button.SetText(starButtonText(msg.Starred))
+		setVisible(marker, msg.Starred)
+	}
+	return button
+}
+
+// toggleStar stars or unstars a message and updates the copies of it in the
+// view and the message cache
+func (cv *ChatView) toggleStar(msg *db.Message, messageIndex int) error {
+	messageID, err := cv.resolveMessageID(msg, messageIndex)
+	if err != nil {
+		return err
+	}
+	if err := cv.app.db.ToggleMessageStar(messageID); err != nil {
+		return err
+	}
+
+	starred := !msg.Starred
+	msg.ID = messageID
+	msg.Starred = starred
+	if messageIndex >= 0 && messageIndex < len(cv.messages) {
+		cv.messages[messageIndex].Starred = starred
+	}
+	for _, cached := range cv.app.messageCache[cv.conversationID] {
+		if cached.ID == messageID {
+			cached.Starred = starred
+		}
+	}
+	cv.app.logger.Info("Message %d starred: %v", messageID, starred)
+	return nil
+}
@@ -0,0 +1,70 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestChatView_StarMessage(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{Responses: []string{"An answer"}}))
+	cv, convID := newTestChat(t, a)
+	sendTestMessage(cv, "A question")
+	messages := waitForMessages(t, a, convID, 2)
+
+	waitUntil(t, "the star buttons", func() bool {
+		var found fyne.CanvasObject
+		fyne.DoAndWait(func() {
+			found = findObject(cv.messagesContainer, func(o fyne.CanvasObject) bool {
+				b, ok := o.(*widget.Button)
+				return ok && b.Text == "☆"
+			})
+		})
+		return found != nil
+	})
+	isMarker := func(o fyne.CanvasObject) bool {
+		r, ok := o.(*canvas.Rectangle)
+		return ok && r.FillColor == starColor && r.Visible()
+	}
+	if findObject(cv.messagesContainer, isMarker) != nil {
+		t.Fatal("marker shown before starring")
+	}
+
+	// The first button stars the question
+	button := findButton(t, cv.messagesContainer, "☆")
+	fyne.DoAndWait(func() {
+		test.Tap(button)
+	})
+	starred, err := a.db.ListStarredMessages(convID)
+	if err != nil || len(starred) != 1 || starred[0].ID != messages[0].ID {
+		t.Fatalf("starred messages = %+v, %v; want the question", starred, err)
+	}
+	if button.Text != "⭐" || findObject(cv.messagesContainer, isMarker) == nil {
+		t.Errorf("button %q, marker shown: %v", button.Text, findObject(cv.messagesContainer, isMarker) != nil)
+	}
+
+	// The search view lists only the starred message
+	sv := NewSearchView(a)
+	sv.Build()
+	sv.starredCheck.SetChecked(true)
+	if len(sv.searchResults) != 1 || sv.searchResults[0].Message.Content != "A question" {
+		t.Errorf("starred search found %d results", len(sv.searchResults))
+	}
+
+	// Tapping again unstars it
+	fyne.DoAndWait(func() {
+		test.Tap(button)
+	})
+	if starred, err := a.db.ListStarredMessages(convID); err != nil || len(starred) != 0 {
+		t.Errorf("starred messages after unstarring = %+v, %v", starred, err)
+	}
+	if button.Text != "☆" || findObject(cv.messagesContainer, isMarker) != nil {
+		t.Errorf("button %q after unstarring", button.Text)
+	}
+}
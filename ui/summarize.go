@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+
+	"fyne.io/fyne/v2"
+)
+
+// summaryKeepRecent is the number of latest messages kept verbatim when summarizing
+const summaryKeepRecent = 4
+
+// defaultSummarizeAfterMessages is used when auto-summarize is enabled without a threshold
+const defaultSummarizeAfterMessages = 20
+
+// summarizeIfNeeded replaces the oldest messages of the conversation with a
+// summary once it grows beyond the configured number of messages
+func (cv *ChatView) summarizeIfNeeded(provider llm.Provider) {
+	if !cv.app.config.Data.AutoSummarize || cv.conversationID == 0 {
+		return
+	}
+
+	threshold := cv.app.config.Data.SummarizeAfterMessages
+	if threshold <= 0 {
+		threshold = defaultSummarizeAfterMessages
+	}
+
+	conversationID := cv.conversationID
+	dbMessages, err := cv.app.db.ListMessagesCtx(cv.ctx, conversationID)
+	if err != nil {
+		cv.app.logger.Error("Failed to load messages for summarization: %v", err)
+		return
+	}
+	if len(dbMessages) <= threshold {
+		return
+	}
+
+	count := len(dbMessages) - summaryKeepRecent
+	if count < 2 {
+		return
+	}
+
+	toSummarize := make([]llm.Message, 0, count)
+	messageIDs := make([]int64, 0, count)
+	for _, msg := range dbMessages[:count] {
+		// Content is what the provider saw (anonymized when anonymization was on)
+		toSummarize = append(toSummarize, llm.Message{Role: msg.Role, Content: msg.Content})
+		messageIDs = append(messageIDs, msg.ID)
+	}
+
+	cv.app.logger.Info("Summarizing %d of %d messages in conversation %d", count, len(dbMessages), conversationID)
+
+	summary, err := utils.NewConversationSummarizer().Summarize(provider, toSummarize)
+	if err != nil {
+		cv.app.logger.Error("Failed to summarize conversation: %v", err)
+		return
+	}
+	if cv.ctx.Err() != nil {
+		return
+	}
+
+	if _, err := cv.app.db.ReplaceMessagesWithSummary(conversationID, messageIDs, summary); err != nil {
+		cv.app.logger.Error("Failed to save conversation summary: %v", err)
+		return
+	}
+
+	// Drop stale caches and reload so the summary replaces the old messages
+	// in the UI. The caches belong to the UI thread.
+	fyne.Do(func() {
+		delete(cv.app.messageCache, conversationID)
+		delete(cv.app.uiCache, conversationID)
+		if cv.conversationID == conversationID {
+			cv.loadMessages()
+		}
+	})
+
+	cv.app.logger.Info("Replaced %d messages with a summary in conversation %d", count, conversationID)
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/utils"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// EnableSync starts periodic uploads of the database and reports a conflict
+// detected during the startup sync
+func (a *App) EnableSync(syncer *utils.DBSyncer, conflict *utils.SyncConflict) {
+	if syncer == nil {
+		return
+	}
+	a.syncer = syncer
+	a.syncStop = make(chan struct{})
+
+	if conflict != nil {
+		utils.SafeGo(a.logger, "syncConflict", func() {
+			fyne.Do(func() {
+				a.showSyncConflict(conflict)
+			})
+		})
+	}
+
+	interval := time.Duration(a.config.Sync.SyncIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		a.logger.Info("WebDAV sync enabled (startup and exit only)")
+		return
+	}
+
+	stop := a.syncStop
+	done := make(chan struct{})
+	a.syncDone = done
+	utils.SafeGo(a.logger, "webdavSync", func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := a.syncer.Upload(a.db); err != nil {
+					a.logger.Error("Periodic WebDAV upload failed: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	})
+	a.logger.Info("WebDAV sync enabled (every %v)", interval)
+}
+
+// stopSync stops periodic uploads and uploads the database one last time,
+// after a periodic upload in progress has finished
+func (a *App) stopSync() {
+	if a.syncer == nil {
+		return
+	}
+	if a.syncStop != nil {
+		close(a.syncStop)
+		a.syncStop = nil
+	}
+	if a.syncDone != nil {
+		<-a.syncDone
+		a.syncDone = nil
+	}
+	if a.db == nil {
+		return
+	}
+	if err := a.syncer.Upload(a.db); err != nil {
+		a.logger.Error("Failed to upload database on exit: %v", err)
+	}
+}
+
+// showSyncConflict tells the user that the remote database replaced local changes
+func (a *App) showSyncConflict(conflict *utils.SyncConflict) {
+	message := fmt.Sprintf("本地和远程数据库自上次同步后都被修改，已使用远程版本。\n\n"+
+		"本地修改时间: %s\n远程修改时间: %s\n\n本地版本已保存到:\n%s\n\n如需保留本地的对话，请手动合并。",
+		conflict.LocalModTime.Local().Format("2006-01-02 15:04:05"),
+		conflict.RemoteModTime.Local().Format("2006-01-02 15:04:05"),
+		conflict.LocalCopyPath)
+
+	messageLabel := widget.NewLabel(message)
+	messageLabel.Wrapping = fyne.TextWrapWord
+
+	var dialog *widget.PopUp
+	openFolderButton := widget.NewButton("打开所在文件夹", func() {
+		if err := utils.OpenWithDefaultApp(filepath.Dir(conflict.LocalCopyPath)); err != nil {
+			a.logger.Error("Failed to open folder: %v", err)
+		}
+	})
+	okButton := widget.NewButton("确定", func() {
+		dialog.Hide()
+	})
+	okButton.Importance = widget.HighImportance
+
+	content := container.NewVBox(
+		widget.NewLabel("⚠️ 同步冲突"),
+		messageLabel,
+		container.NewHBox(openFolderButton, okButton),
+	)
+	dialog = widget.NewModalPopUp(content, a.window.Canvas())
+	dialog.Resize(fyne.NewSize(500, content.MinSize().Height))
+	dialog.Show()
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// buildSystemPromptItem builds the collapsible editor of the conversation's
+// system prompt
+func (cv *ChatView) buildSystemPromptItem() *widget.AccordionItem {
+	cv.systemPromptEntry = widget.NewMultiLineEntry()
+	cv.systemPromptEntry.SetPlaceHolder("为此对话设置系统提示词（留空则使用提供商的默认提示词）")
+	cv.systemPromptEntry.Wrapping = fyne.TextWrapWord
+	cv.systemPromptEntry.SetMinRowsVisible(3)
+
+	saveButton := widget.NewButton("保存", cv.saveSystemPrompt)
+
+	cv.systemPromptItem = widget.NewAccordionItem("系统提示词", container.NewBorder(
+		nil,
+		nil,
+		nil,
+		saveButton,
+		cv.systemPromptEntry,
+	))
+	return cv.systemPromptItem
+}
+
+// loadSystemPrompt shows the stored system prompt of the conversation
+func (cv *ChatView) loadSystemPrompt() {
+	conversationID := cv.conversationID
+	if conversationID == 0 {
+		return
+	}
+	utils.SafeGo(cv.app.logger, "loadSystemPrompt", func() {
+		conv, err := cv.app.db.GetConversation(conversationID)
+		if err != nil {
+			cv.app.logger.Warn("Failed to load system prompt of conversation %d: %v", conversationID, err)
+			return
+		}
+		fyne.Do(func() {
+			if cv.conversationID == conversationID {
+				cv.showSystemPrompt(conv.SystemPrompt)
+			}
+		})
+	})
+}
+
+// showSystemPrompt puts prompt in the editor and marks the panel title when
+// it is set. Must be called on the UI thread.
+func (cv *ChatView) showSystemPrompt(prompt string) {
+	cv.systemPrompt = prompt
+	if cv.systemPromptEntry == nil {
+		return
+	}
+	cv.systemPromptEntry.SetText(prompt)
+	if prompt != "" {
+		cv.systemPromptItem.Title = "系统提示词（已设置）"
+	} else {
+		cv.systemPromptItem.Title = "系统提示词"
+	}
+	cv.settingsPanel.Refresh()
+	cv.scheduleTokenCount()
+}
+
+// saveSystemPrompt stores the prompt in the editor with the conversation
+func (cv *ChatView) saveSystemPrompt() {
+	if cv.conversationID == 0 {
+		cv.app.showError("请先创建对话")
+		return
+	}
+	prompt := strings.TrimSpace(cv.systemPromptEntry.Text)
+	if err := cv.app.db.UpdateConversationSystemPrompt(cv.conversationID, prompt); err != nil {
+		cv.app.logger.Error("Failed to save system prompt: %v", err)
+		cv.app.showError("保存系统提示词失败: " + err.Error())
+		return
+	}
+	cv.app.logger.Info("Saved system prompt of conversation %d (%d characters)", cv.conversationID, len(prompt))
+	cv.showSystemPrompt(prompt)
+}
+
+// withConversationSystemPrompt prepends the stored system prompt of the
+// conversation to messages
+func (cv *ChatView) withConversationSystemPrompt(messages []llm.Message) []llm.Message {
+	conv, err := cv.app.db.GetConversation(cv.conversationID)
+	if err != nil {
+		cv.app.logger.Warn("Failed to get system prompt of conversation %d: %v", cv.conversationID, err)
+		return messages
+	}
+	return llm.PrependSystemPrompt(conv.SystemPrompt, messages)
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"light-llm-client/utils"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// systemThemePollInterval is how often the OS appearance is read when the
+// platform can be queried directly
+const systemThemePollInterval = 30 * time.Second
+
+// watchSystemTheme switches between the light and dark theme when the OS
+// appearance changes while the "auto" theme is selected. Fyne reports the
+// changes as a settings change; where the appearance can also be read
+// directly it is polled as well, in case no notification arrives.
+func (a *App) watchSystemTheme() {
+	a.fyneApp.Settings().AddListener(func(settings fyne.Settings) {
+		if a.config.UI.Theme != "auto" {
+			return
+		}
+		a.applyAutoTheme(settings.ThemeVariant() == theme.VariantDark)
+	})
+
+	if _, ok := systemThemeIsDark(); !ok {
+		return
+	}
+
+	stop := make(chan struct{})
+	a.themePollStop = stop
+	utils.SafeGo(a.logger, "systemThemePoll", func() {
+		ticker := time.NewTicker(systemThemePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				isDark, ok := systemThemeIsDark()
+				if !ok {
+					continue
+				}
+				fyne.Do(func() {
+					if a.config.UI.Theme == "auto" {
+						a.applyAutoTheme(isDark)
+					}
+				})
+			case <-stop:
+				return
+			}
+		}
+	})
+}
+
+// stopSystemThemePoll stops polling the OS appearance
+func (a *App) stopSystemThemePoll() {
+	if a.themePollStop != nil {
+		close(a.themePollStop)
+		a.themePollStop = nil
+	}
+}
+
+// applyAutoTheme applies the theme matching the OS appearance if it differs
+// from the current one. Setting the theme notifies the settings listeners
+// again, which then finds nothing to do.
+func (a *App) applyAutoTheme(isDark bool) {
+	if isDark == a.themeIsDark {
+		return
+	}
+	a.logger.Info("OS appearance changed, switching theme")
+	a.setTheme(isDark)
+}
@@ -0,0 +1,25 @@
+//go:build darwin
+// +build darwin
+
+package ui
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// systemThemeIsDark reads the macOS appearance. The second result is false
+// if it could not be read.
+func systemThemeIsDark() (bool, bool) {
+	out, err := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle").Output()
+	if err != nil {
+		// The key does not exist in light mode
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, true
+		}
+		return false, false
+	}
+	return strings.TrimSpace(string(out)) == "Dark", true
+}
@@ -0,0 +1,10 @@
+//go:build !darwin
+// +build !darwin
+
+package ui
+
+// systemThemeIsDark is only implemented on macOS. Elsewhere the appearance
+// is only followed through Fyne's settings notifications.
+func systemThemeIsDark() (bool, bool) {
+	return false, false
+}
@@ -40,7 +40,7 @@ func onReady() {
 			select {
 			case <-mShow.ClickedCh:
 				if globalApp != nil {
-					globalApp.window.Show()
+					globalApp.ShowWindow()
 					globalApp.logger.Info("Window shown from system tray")
 				}
 			case <-mNew.ClickedCh:
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Minimum size of the tab search popup
+const (
+	tabSearchWidth  = 360
+	tabSearchHeight = 260
+)
+
+// TabSearchPopup lists the open tabs filtered by title (Ctrl+Shift+T). Enter
+// or a click switches to a tab; Escape or clicking elsewhere closes it.
+type TabSearchPopup struct {
+	app     *App
+	entry   *tabSearchEntry
+	list    *widget.List
+	popup   *widget.PopUp
+	matches []*CustomTab
+}
+
+// NewTabSearchPopup creates the tab search popup of the main window
+func NewTabSearchPopup(a *App) *TabSearchPopup {
+	p := &TabSearchPopup{app: a}
+
+	p.entry = newTabSearchEntry()
+	p.entry.SetPlaceHolder("搜索标签页... (Enter 切换, Esc 关闭)")
+	p.entry.OnChanged = p.filter
+	p.entry.onEnter = func() {
+		if len(p.matches) > 0 {
+			p.selectTab(p.matches[0])
+		}
+	}
+	p.entry.onEscape = p.Hide
+	p.entry.onFocusLost = p.Hide
+
+	p.list = widget.NewList(
+		func() int { return len(p.matches) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < len(p.matches) {
+				obj.(*widget.Label).SetText(p.tabLabel(p.matches[id]))
+			}
+		},
+	)
+	p.list.OnSelected = func(id widget.ListItemID) {
+		p.list.UnselectAll()
+		if id < len(p.matches) {
+			p.selectTab(p.matches[id])
+		}
+	}
+
+	content := container.NewBorder(p.entry, nil, nil, nil, p.list)
+	p.popup = widget.NewPopUp(content, a.window.Canvas())
+	p.filter("")
+	return p
+}
+
+// tabLabel marks the active tab and tells chat tabs from the others
+func (p *TabSearchPopup) tabLabel(tab *CustomTab) string {
+	icon := "📄 "
+	if p.app.conversationIDOfTab(tab) != 0 {
+		icon = "💬 "
+	}
+	if tab == p.app.tabs.GetActiveTab() {
+		return icon + tab.Title + "  (当前)"
+	}
+	return icon + tab.Title
+}
+
+// filter shows the tabs whose title contains query
+func (p *TabSearchPopup) filter(query string) {
+	p.matches = p.app.tabs.FilterTabsByTitle(query)
+	p.list.Refresh()
+}
+
+// selectTab switches to tab and closes the popup
+func (p *TabSearchPopup) selectTab(tab *CustomTab) {
+	p.Hide()
+	p.app.tabs.SelectTab(tab)
+}
+
+// Show shows the popup near the top of the window and focuses the search box
+func (p *TabSearchPopup) Show() {
+	c := p.app.window.Canvas()
+	width := fyne.Max(tabSearchWidth, c.Size().Width/3)
+	p.popup.Resize(fyne.NewSize(width, tabSearchHeight))
+	p.popup.ShowAtPosition(fyne.NewPos((c.Size().Width-width)/2, 60))
+	c.Focus(p.entry)
+}
+
+// Hide closes the popup
+func (p *TabSearchPopup) Hide() {
+	p.popup.Hide()
+}
+
+// showTabSearch opens the tab search popup
+func (a *App) showTabSearch() {
+	if a.tabs == nil || a.tabs.TabCount() == 0 {
+		a.showInfo("没有打开的标签页")
+		return
+	}
+	NewTabSearchPopup(a).Show()
+}
+
+// tabSearchEntry is the search box of the tab search popup. Enter picks the
+// first match, Escape and losing the focus close the popup.
+type tabSearchEntry struct {
+	widget.Entry
+	onEnter, onEscape, onFocusLost func()
+}
+
+func newTabSearchEntry() *tabSearchEntry {
+	e := &tabSearchEntry{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// TypedKey handles Enter and Escape
+func (e *tabSearchEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyReturn, fyne.KeyEnter:
+		e.onEnter()
+	case fyne.KeyEscape:
+		e.onEscape()
+	default:
+		e.Entry.TypedKey(key)
+	}
+}
+
+// FocusLost closes the popup once the focus moves elsewhere
+func (e *tabSearchEntry) FocusLost() {
+	e.Entry.FocusLost()
+	e.onFocusLost()
+}
@@ -0,0 +1,290 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"light-llm-client/db"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// tagFilterPrefix marks tag entries in the sidebar category filter
+const tagFilterPrefix = "🏷️ "
+
+// taggedSnippetRunes limits the message preview shown in the tagged messages tab
+const taggedSnippetRunes = 160
+
+// TaggedView lists all tagged messages grouped by tag
+type TaggedView struct {
+	app              *App
+	statusLabel      *widget.Label
+	resultsContainer *fyne.Container
+}
+
+// NewTaggedView creates a new tagged messages view
+func NewTaggedView(app *App) *TaggedView {
+	return &TaggedView{app: app}
+}
+
+// Build builds the tagged messages view UI
+func (tv *TaggedView) Build() fyne.CanvasObject {
+	tv.statusLabel = widget.NewLabel("")
+	tv.resultsContainer = container.NewVBox()
+
+	refreshButton := widget.NewButton("🔄 刷新", func() {
+		tv.Refresh()
+	})
+	refreshButton.Importance = widget.LowImportance
+
+	tv.Refresh()
+
+	return container.NewBorder(
+		container.NewBorder(nil, nil, nil, refreshButton, tv.statusLabel),
+		nil, nil, nil,
+		container.NewScroll(tv.resultsContainer),
+	)
+}
+
+// Refresh reloads the tagged messages from the database
+func (tv *TaggedView) Refresh() {
+	if tv.resultsContainer == nil {
+		return
+	}
+
+	tagged, err := tv.app.db.ListTaggedMessages()
+	if err != nil {
+		tv.app.logger.Error("Failed to load tagged messages: %v", err)
+		tv.statusLabel.SetText("加载失败: " + err.Error())
+		return
+	}
+
+	objects := []fyne.CanvasObject{}
+	tagCount := 0
+	for i, item := range tagged {
+		// Results are ordered by tag, so a new tag starts a new group
+		if i == 0 || tagged[i-1].Tag != item.Tag {
+			tagCount++
+			header := widget.NewLabel(fmt.Sprintf("🏷️ %s (%d)", item.Tag, countTag(tagged[i:], item.Tag)))
+			header.TextStyle = fyne.TextStyle{Bold: true}
+			objects = append(objects, header)
+		}
+		objects = append(objects, tv.buildItem(item), widget.NewSeparator())
+	}
+
+	if len(tagged) == 0 {
+		tv.statusLabel.SetText("暂无标签消息，点击消息下方的 🏷️ 按钮添加标签")
+	} else {
+		tv.statusLabel.SetText(fmt.Sprintf("%d 个标签，%d 条标签消息", tagCount, len(tagged)))
+	}
+
+	tv.resultsContainer.Objects = objects
+	tv.resultsContainer.Refresh()
+}
+
+// buildItem builds the row of a single tagged message
+func (tv *TaggedView) buildItem(item *db.TaggedMessage) fyne.CanvasObject {
+	title := item.ConversationTitle
+	if title == "" {
+		title = "Unknown"
+	}
+	titleLabel := widget.NewLabel(title)
+	titleLabel.Truncation = fyne.TextTruncateEllipsis
+
+	dateLabel := widget.NewLabel(item.Message.CreatedAt.Local().Format("2006-01-02 15:04"))
+	dateLabel.Importance = widget.LowImportance
+
+	message := item.Message
+	jumpButton := widget.NewButton("跳转", func() {
+		if err := tv.app.NavigateToMessage(message.ID); err != nil {
+			tv.app.logger.Error("Failed to jump to message %d: %v", message.ID, err)
+			tv.app.showError("无法跳转到消息: " + err.Error())
+		}
+	})
+	jumpButton.Importance = widget.LowImportance
+
+	content := message.Content
+	if message.OriginalContent != "" {
+		content = message.OriginalContent
+	}
+	preview := widget.NewLabel(truncateRunes(strings.TrimSpace(content), taggedSnippetRunes))
+	preview.Wrapping = fyne.TextWrapWord
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(dateLabel, jumpButton), titleLabel),
+		preview,
+	)
+}
+
+// countTag counts the leading results carrying tag
+func countTag(tagged []*db.TaggedMessage, tag string) int {
+	count := 0
+	for _, item := range tagged {
+		if item.Tag != tag {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+// truncateRunes shortens text to at most n runes, adding an ellipsis
+func truncateRunes(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n]) + "..."
+}
+
+// showTagged shows the tagged messages tab
+func (a *App) showTagged() {
+	if a.taggedTabItem != nil {
+		a.taggedView.Refresh()
+		a.tabs.SelectTab(a.taggedTabItem)
+		a.logger.Info("Focused existing tagged messages tab")
+		return
+	}
+
+	a.taggedView = NewTaggedView(a)
+	a.taggedTabItem = a.tabs.Append("🏷️ 标签消息", a.taggedView.Build(), func() {
+		a.closeTaggedTab()
+	})
+
+	a.logger.Info("Opened tagged messages tab")
+}
+
+// closeTaggedTab closes the tagged messages tab
+func (a *App) closeTaggedTab() {
+	if a.taggedTabItem != nil {
+		a.tabs.Remove(a.taggedTabItem)
+		a.taggedTabItem = nil
+		a.taggedView = nil
+		a.logger.Info("Closed tagged messages tab")
+	}
+}
+
+// onTagsChanged updates the views that show tags
+func (a *App) onTagsChanged() {
+	if a.taggedView != nil {
+		a.taggedView.Refresh()
+	}
+	if a.sidebar != nil {
+		a.sidebar.updateList()
+	}
+}
+
+// newTagPill creates a small rounded label for a tag
+func newTagPill(tag string) fyne.CanvasObject {
+	return newPill(tag, color.NRGBA{R: 100, G: 150, B: 255, A: 60})
+}
+
+// newPill creates a small rounded label with the given background
+func newPill(label string, fill color.Color) fyne.CanvasObject {
+	bg := canvas.NewRectangle(fill)
+	bg.CornerRadius = 8
+
+	text := canvas.NewText(label, theme.ForegroundColor())
+	text.TextSize = theme.CaptionTextSize()
+
+	return container.NewStack(bg, container.New(&pillPadding{}, text))
+}
+
+// pillPadding lays out a single object with a small horizontal padding
+type pillPadding struct{}
+
+const pillPaddingX, pillPaddingY = 8, 2
+
+func (p *pillPadding) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	min := objects[0].MinSize()
+	return fyne.NewSize(min.Width+2*pillPaddingX, min.Height+2*pillPaddingY)
+}
+
+func (p *pillPadding) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	objects[0].Move(fyne.NewPos(pillPaddingX, pillPaddingY))
+	objects[0].Resize(fyne.NewSize(size.Width-2*pillPaddingX, size.Height-2*pillPaddingY))
+}
+
+// tagPills creates the pills for a list of tags
+func tagPills(tags []string) []fyne.CanvasObject {
+	pills := make([]fyne.CanvasObject, 0, len(tags))
+	for _, tag := range tags {
+		pills = append(pills, newTagPill(tag))
+	}
+	return pills
+}
+
+// resolveMessageID returns the database ID of the message at messageIndex.
+// Messages added during this session are rendered before they have an ID,
+// so the ID is looked up by position in that case.
+func (cv *ChatView) resolveMessageID(msg *db.Message, messageIndex int) (int64, error) {
+	if msg.ID != 0 {
+		return msg.ID, nil
+	}
+
+	dbMessages, err := cv.app.db.ListMessages(cv.conversationID)
+	if err != nil {
+		return 0, err
+	}
+	if messageIndex < 0 || messageIndex >= len(dbMessages) {
+		return 0, fmt.Errorf("message %d not found", messageIndex)
+	}
+	return dbMessages[messageIndex].ID, nil
+}
+
+// showTagEditor opens a pop-up to edit the comma-separated tags of a message
+func (cv *ChatView) showTagEditor(msg *db.Message, messageIndex int, pills *fyne.Container) {
+	messageID, err := cv.resolveMessageID(msg, messageIndex)
+	if err != nil {
+		cv.app.logger.Error("Failed to resolve message for tagging: %v", err)
+		cv.app.showError("无法找到该消息: " + err.Error())
+		return
+	}
+
+	current, err := cv.app.db.ListMessageTags(messageID)
+	if err != nil {
+		cv.app.logger.Error("Failed to load message tags: %v", err)
+	}
+
+	tagEntry := widget.NewEntry()
+	tagEntry.SetPlaceHolder("多个标签用逗号分隔，例如: 重要, golang")
+	tagEntry.SetText(strings.Join(current, ", "))
+
+	var popup *widget.PopUp
+	save := func() {
+		tags := db.ParseTags(tagEntry.Text)
+		if err := cv.app.db.SetMessageTags(messageID, tags); err != nil {
+			cv.app.logger.Error("Failed to save message tags: %v", err)
+			cv.app.showError("保存标签失败: " + err.Error())
+			return
+		}
+		cv.app.logger.Info("Set %d tags on message %d", len(tags), messageID)
+
+		pills.Objects = tagPills(tags)
+		pills.Refresh()
+		popup.Hide()
+		cv.app.onTagsChanged()
+	}
+	tagEntry.OnSubmitted = func(string) { save() }
+
+	saveButton := widget.NewButton("保存", save)
+	saveButton.Importance = widget.HighImportance
+	cancelButton := widget.NewButton("取消", func() {
+		popup.Hide()
+	})
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("🏷️ 消息标签", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		tagEntry,
+		container.NewHBox(saveButton, cancelButton),
+	)
+
+	popup = widget.NewModalPopUp(content, cv.app.window.Canvas())
+	popup.Resize(fyne.NewSize(400, content.MinSize().Height))
+	popup.Show()
+	cv.app.window.Canvas().Focus(tagEntry)
+}
@@ -0,0 +1,358 @@
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"light-llm-client/utils"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// templateLabel returns the list text of a template
+func templateLabel(template *db.PromptTemplate) string {
+	if template.Category == "" {
+		return template.Name
+	}
+	return fmt.Sprintf("[%s] %s", template.Category, template.Name)
+}
+
+// showTemplateDialog lets the user pick a prompt template and fills the input
+// with it
+func (cv *ChatView) showTemplateDialog() {
+	templates, err := cv.app.db.ListPromptTemplates()
+	if err != nil {
+		cv.app.showError("加载模板失败: " + err.Error())
+		return
+	}
+	if len(templates) == 0 {
+		cv.app.showInfo("还没有提示词模板，请先在 设置 → Templates 中添加")
+		return
+	}
+
+	preview := widget.NewLabel("")
+	preview.Wrapping = fyne.TextWrapWord
+
+	var popup *widget.PopUp
+	var selected *db.PromptTemplate
+	insertButton := widget.NewButton("使用", func() {
+		popup.Hide()
+		cv.applyTemplate(selected)
+	})
+	insertButton.Importance = widget.HighImportance
+	insertButton.Disable()
+
+	list := widget.NewList(
+		func() int {
+			return len(templates)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(templateLabel(templates[id]))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = templates[id]
+		preview.SetText(truncateRunes(selected.Content, 300))
+		insertButton.Enable()
+	}
+
+	popup = widget.NewModalPopUp(
+		container.NewBorder(
+			widget.NewLabel("选择提示词模板"),
+			container.NewVBox(
+				widget.NewSeparator(),
+				container.NewHBox(
+					widget.NewButton("取消", func() {
+						popup.Hide()
+					}),
+					insertButton,
+				),
+			),
+			nil,
+			nil,
+			container.NewVSplit(list, container.NewVScroll(preview)),
+		),
+		cv.app.window.Canvas(),
+	)
+	popup.Resize(fyne.NewSize(520, 420))
+	popup.Show()
+}
+
+// applyTemplate replaces the input with template, asking for the values of
+// its variables first
+func (cv *ChatView) applyTemplate(template *db.PromptTemplate) {
+	fill := func(values map[string]string) {
+		cv.inputEntry.SetText("")
+		cv.insertQuickPrompt(db.FillTemplate(template.Content, values))
+		cv.app.logger.Info("Inserted prompt template %d (%s)", template.ID, template.Name)
+	}
+	if len(template.Variables) == 0 {
+		fill(nil)
+		return
+	}
+
+	entries := make(map[string]*widget.Entry, len(template.Variables))
+	items := make([]*widget.FormItem, 0, len(template.Variables))
+	for _, name := range template.Variables {
+		entry := widget.NewEntry()
+		entries[name] = entry
+		items = append(items, widget.NewFormItem(name, entry))
+	}
+
+	var popup *widget.PopUp
+	form := widget.NewForm(items...)
+	form.SubmitText = "插入"
+	form.CancelText = "取消"
+	form.OnCancel = func() {
+		popup.Hide()
+	}
+	form.OnSubmit = func() {
+		popup.Hide()
+		values := make(map[string]string, len(entries))
+		for name, entry := range entries {
+			values[name] = entry.Text
+		}
+		fill(values)
+	}
+
+	popup = widget.NewModalPopUp(
+		container.NewVBox(widget.NewLabel(template.Name), form),
+		cv.app.window.Canvas(),
+	)
+	popup.Resize(fyne.NewSize(420, 0))
+	popup.Show()
+	cv.app.window.Canvas().Focus(entries[template.Variables[0]])
+}
+
+// PromptTemplatePanel is the settings tab to manage prompt templates
+type PromptTemplatePanel struct {
+	sv        *SettingsView
+	templates []*db.PromptTemplate
+	list      *widget.List
+	selected  int // Index of the selected template, -1 for none
+}
+
+// NewPromptTemplatePanel creates the prompt template panel of the settings
+func NewPromptTemplatePanel(sv *SettingsView) *PromptTemplatePanel {
+	return &PromptTemplatePanel{sv: sv, selected: -1}
+}
+
+// Build builds the panel UI
+func (p *PromptTemplatePanel) Build() fyne.CanvasObject {
+	p.list = widget.NewList(
+		func() int {
+			return len(p.templates)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(templateLabel(p.templates[id]))
+		},
+	)
+	p.list.OnSelected = func(id widget.ListItemID) {
+		p.selected = id
+	}
+	p.list.OnUnselected = func(widget.ListItemID) {
+		p.selected = -1
+	}
+
+	editButton := widget.NewButton("Edit", func() {
+		if p.selected >= 0 {
+			p.showEditDialog(p.templates[p.selected])
+		}
+	})
+	deleteButton := widget.NewButton("Delete", func() {
+		if p.selected >= 0 {
+			p.confirmDelete(p.templates[p.selected])
+		}
+	})
+	deleteButton.Importance = widget.DangerImportance
+
+	toolbar := container.NewHBox(
+		widget.NewButton("Add", func() {
+			p.showEditDialog(nil)
+		}),
+		editButton,
+		deleteButton,
+		widget.NewSeparator(),
+		widget.NewButton("Import...", func() {
+			p.importTemplates()
+		}),
+		widget.NewButton("Export", func() {
+			p.exportTemplates()
+		}),
+	)
+	note := widget.NewLabel("Use {{name}} in the content for values asked for when the template is inserted in a chat.")
+	note.Wrapping = fyne.TextWrapWord
+
+	p.Refresh()
+	return container.NewBorder(
+		container.NewVBox(toolbar, note, widget.NewSeparator()),
+		nil,
+		nil,
+		nil,
+		p.list,
+	)
+}
+
+// Refresh reloads the templates
+func (p *PromptTemplatePanel) Refresh() {
+	templates, err := p.sv.app.db.ListPromptTemplates()
+	if err != nil {
+		p.sv.app.logger.Error("Failed to load prompt templates: %v", err)
+		p.sv.showError("Failed to load templates: " + err.Error())
+		return
+	}
+	p.templates = templates
+	p.selected = -1
+	p.list.UnselectAll()
+	p.list.Refresh()
+}
+
+// showEditDialog edits template, or creates a new one if template is nil
+func (p *PromptTemplatePanel) showEditDialog(template *db.PromptTemplate) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Name")
+	categoryEntry := widget.NewEntry()
+	categoryEntry.SetPlaceHolder("Category (optional)")
+	contentEntry := widget.NewMultiLineEntry()
+	contentEntry.SetPlaceHolder("Translate the following text to {{language}}:\n\n{{text}}")
+	contentEntry.SetMinRowsVisible(8)
+	contentEntry.Wrapping = fyne.TextWrapWord
+
+	title := "New Template"
+	if template != nil {
+		title = "Edit Template"
+		nameEntry.SetText(template.Name)
+		categoryEntry.SetText(template.Category)
+		contentEntry.SetText(template.Content)
+	}
+
+	var popup *widget.PopUp
+	saveButton := widget.NewButton("Save", func() {
+		var err error
+		if template == nil {
+			_, err = p.sv.app.db.CreatePromptTemplate(nameEntry.Text, categoryEntry.Text, contentEntry.Text)
+		} else {
+			err = p.sv.app.db.UpdatePromptTemplate(template.ID, nameEntry.Text, categoryEntry.Text, contentEntry.Text)
+		}
+		if err != nil {
+			p.sv.showError("Failed to save template: " + err.Error())
+			return
+		}
+		popup.Hide()
+		p.Refresh()
+	})
+	saveButton.Importance = widget.HighImportance
+
+	popup = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel(title),
+			nameEntry,
+			categoryEntry,
+			contentEntry,
+			container.NewHBox(
+				widget.NewButton("Cancel", func() {
+					popup.Hide()
+				}),
+				saveButton,
+			),
+		),
+		p.sv.getCanvas(),
+	)
+	popup.Resize(fyne.NewSize(560, 0))
+	popup.Show()
+}
+
+// confirmDelete deletes a template after confirmation
+func (p *PromptTemplatePanel) confirmDelete(template *db.PromptTemplate) {
+	var popup *widget.PopUp
+	popup = widget.NewModalPopUp(
+		container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("Delete template %q?", template.Name)),
+			container.NewHBox(
+				widget.NewButton("Cancel", func() {
+					popup.Hide()
+				}),
+				widget.NewButton("Delete", func() {
+					popup.Hide()
+					if err := p.sv.app.db.DeletePromptTemplate(template.ID); err != nil {
+						p.sv.app.logger.Error("Failed to delete prompt template %d: %v", template.ID, err)
+						p.sv.showError("Failed to delete template: " + err.Error())
+						return
+					}
+					p.Refresh()
+				}),
+			),
+		),
+		p.sv.getCanvas(),
+	)
+	popup.Show()
+}
+
+// importTemplates adds the templates of a JSON file exported before
+func (p *PromptTemplatePanel) importTemplates() {
+	window := p.sv.settingsWindow
+	if window == nil {
+		window = p.sv.app.window
+	}
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			p.sv.showError("Failed to open file: " + err.Error())
+			return
+		}
+		if reader == nil {
+			return // User cancelled
+		}
+		path := reader.URI().Path()
+		reader.Close()
+
+		count, err := utils.ImportPromptTemplates(p.sv.app.db, path)
+		if err != nil {
+			p.sv.app.logger.Error("Failed to import prompt templates from %s: %v", path, err)
+			p.sv.showError("Import failed: " + err.Error())
+		} else {
+			p.sv.app.logger.Info("Imported %d prompt templates from %s", count, path)
+			p.sv.showSuccess(fmt.Sprintf("Imported %d templates", count))
+		}
+		p.Refresh()
+	}, window)
+	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	fileDialog.Show()
+}
+
+// exportTemplates writes all templates to a JSON file in the export directory
+func (p *PromptTemplatePanel) exportTemplates() {
+	app := p.sv.app
+	if len(p.templates) == 0 {
+		p.sv.showError("No templates to export")
+		return
+	}
+
+	exportDir, err := utils.GetDefaultExportPath(app.config.RecentFiles)
+	if err != nil {
+		p.sv.showError("Failed to get export directory: " + err.Error())
+		return
+	}
+	path := filepath.Join(exportDir, utils.GenerateExportFilename("prompt_templates", utils.FormatJSON))
+	if err := utils.ExportPromptTemplates(app.db, path); err != nil {
+		p.sv.showError("Export failed: " + err.Error())
+		return
+	}
+
+	app.logger.Info("Exported %d prompt templates to %s", len(p.templates), path)
+	app.addRecentFile(path)
+	p.sv.showSuccess("Export succeeded!\nFile saved to: " + path)
+}
@@ -0,0 +1,52 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestChatView_ApplyTemplate(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	cv, _ := newTestChat(t, a)
+
+	template, err := a.db.CreatePromptTemplate("Translate", "", "Translate to {{language}}:\n{{text}}")
+	if err != nil {
+		t.Fatalf("CreatePromptTemplate failed: %v", err)
+	}
+	cv.inputEntry.SetText("draft")
+	cv.applyTemplate(template)
+
+	form := findObject(a.window.Canvas().Overlays().Top(), func(o fyne.CanvasObject) bool {
+		_, ok := o.(*widget.Form)
+		return ok
+	})
+	if form == nil {
+		t.Fatal("expected a form for the template variables")
+	}
+	var entries []*widget.Entry
+	findObject(form, func(o fyne.CanvasObject) bool {
+		if entry, ok := o.(*widget.Entry); ok {
+			entries = append(entries, entry)
+		}
+		return false
+	})
+	if len(entries) != 2 {
+		t.Fatalf("found %d entries, want 2", len(entries))
+	}
+	test.Type(entries[0], "German")
+	test.Type(entries[1], "Good morning")
+	form.(*widget.Form).OnSubmit()
+
+	if want := "Translate to German:\nGood morning"; cv.inputEntry.Text != want {
+		t.Errorf("input = %q, want %q", cv.inputEntry.Text, want)
+	}
+	if a.window.Canvas().Overlays().Top() != nil {
+		t.Error("expected the form to be closed")
+	}
+}
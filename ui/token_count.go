@@ -0,0 +1,251 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"light-llm-client/db"
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// tokenCountDelay is how long typing must pause before the tokens are counted
+const tokenCountDelay = 800 * time.Millisecond
+
+// tokenCountTimeout bounds a counting request to the provider
+const tokenCountTimeout = 10 * time.Second
+
+// Shares of the context window at which the token count turns yellow and red
+const (
+	tokenWarningRatio = 0.80
+	tokenDangerRatio  = 0.95
+)
+
+// llmMessage converts a stored message for the provider. Only image
+// attachments are sent, the text of files is already in the content.
+func (cv *ChatView) llmMessage(msg *db.Message) llm.Message {
+	var allAttachments []llm.Attachment
+	if msg.Attachments != "" {
+		if err := json.Unmarshal([]byte(msg.Attachments), &allAttachments); err != nil {
+			cv.app.logger.Warn("Failed to parse attachments for message %d: %v", msg.ID, err)
+		}
+	}
+
+	// Images of responses were generated, models only take images from users
+	var imageAttachments []llm.Attachment
+	for _, att := range allAttachments {
+		if att.Type == "image" && msg.Role != "assistant" {
+			imageAttachments = append(imageAttachments, att)
+		}
+	}
+	return llm.Message{Role: msg.Role, Content: msg.Content, Attachments: imageAttachments}
+}
+
+// scheduleTokenCount (re)starts the debounce timer for counting the tokens of
+// the history and the draft. Safe to call from any goroutine.
+func (cv *ChatView) scheduleTokenCount() {
+	cv.tokenMu.Lock()
+	defer cv.tokenMu.Unlock()
+	if cv.tokenTimer != nil {
+		cv.tokenTimer.Stop()
+	}
+	cv.tokenTimer = time.AfterFunc(tokenCountDelay, func() {
+		fyne.Do(cv.countTokens)
+	})
+}
+
+// countTokens counts the tokens the next request would take: the system
+// prompts, the history and the draft in the input. The provider counts them
+// in the background; if it fails, the estimate is shown. With anonymization
+// enabled nothing is sent and the tokens are only estimated. Must be called
+// on the UI thread.
+func (cv *ChatView) countTokens() {
+	if cv.tokenLabel == nil {
+		return
+	}
+	provider, ok := cv.selectedProvider()
+	if !ok {
+		cv.tokenLabel.Hide()
+		return
+	}
+
+	messages := make([]llm.Message, 0, len(cv.messages)+1)
+	for i := range cv.messages {
+		messages = append(messages, cv.llmMessage(&cv.messages[i]))
+	}
+	if draft := cv.inputEntry.Text; draft != "" {
+		messages = append(messages, llm.Message{Role: "user", Content: draft})
+	}
+	messages = llm.PrependSystemPrompt(cv.systemPrompt, messages)
+	systemPrompt := providerSystemPrompt(cv.app.config.LLMProviders[cv.currentProvider], messages)
+	messages = llm.PrependSystemPrompt(systemPrompt, messages)
+	window := provider.ContextWindowSize()
+
+	cv.tokenCountSeq++
+	seq := cv.tokenCountSeq
+	// Drafts are anonymized only once the user sends them; until then they
+	// must not leave the machine
+	if cv.app.anonymizer.IsEnabled() {
+		cv.showTokenCount(llm.EstimateTokens(messages), window, true)
+		return
+	}
+	utils.SafeGo(cv.app.logger, "countTokens", func() {
+		ctx, cancel := context.WithTimeout(cv.ctx, tokenCountTimeout)
+		defer cancel()
+
+		estimated := false
+		count, err := provider.CountTokens(ctx, messages)
+		if err != nil {
+			if cv.ctx.Err() != nil {
+				return // Tab closed
+			}
+			cv.app.logger.Debug("Failed to count tokens with %s, estimating: %v", provider.Name(), err)
+			count, estimated = llm.EstimateTokens(messages), true
+		}
+		fyne.Do(func() {
+			if seq == cv.tokenCountSeq {
+				cv.showTokenCount(count, window, estimated)
+			}
+		})
+	})
+}
+
+// showTokenCount shows count against the context window of the model, coloured
+// by how full the window is. Must be called on the UI thread.
+func (cv *ChatView) showTokenCount(count, window int, estimated bool) {
+	text := formatTokenCount(count)
+	if estimated {
+		text = "≈" + text
+	}
+	if window > 0 {
+		text = fmt.Sprintf("● %s / %s tokens", text, formatTokenCount(window))
+	} else {
+		text = fmt.Sprintf("● %s tokens", text)
+	}
+	cv.tokenLabel.SetText(text)
+	cv.tokenLabel.Importance = tokenCountImportance(count, window)
+	cv.tokenLabel.Show()
+	cv.tokenLabel.Refresh()
+	cv.showContextUsage(count, window)
+}
+
+// contextBarHeight is the height of the context window usage bar
+const contextBarHeight = 4
+
+// newContextBar creates the bar showing how much of the context window the
+// next request takes. It is hidden until the tokens are counted.
+func (cv *ChatView) newContextBar() fyne.CanvasObject {
+	cv.contextBar = widget.NewProgressBar()
+	cv.contextBar.TextFormatter = func() string { return "" }
+	cv.contextBarTheme = &contextBarTheme{}
+	cv.contextBarBox = container.New(&fixedHeightLayout{height: contextBarHeight},
+		container.NewThemeOverride(cv.contextBar, cv.contextBarTheme))
+	cv.contextBarBox.Hide()
+	return cv.contextBarBox
+}
+
+// showContextUsage fills the context bar with the share of the window count
+// takes, orange from 80% and red above 95% like the token count. Unknown windows hide the bar.
+// Must be called on the UI thread.
+func (cv *ChatView) showContextUsage(count, window int) {
+	if cv.contextBar == nil {
+		return
+	}
+	if window <= 0 {
+		cv.contextBarBox.Hide()
+		return
+	}
+	switch tokenCountImportance(count, window) {
+	case widget.DangerImportance:
+		cv.contextBarTheme.fill = theme.ColorNameError
+	case widget.WarningImportance:
+		cv.contextBarTheme.fill = theme.ColorNameWarning
+	default:
+		cv.contextBarTheme.fill = ""
+	}
+	cv.contextBar.SetValue(math.Min(float64(count)/float64(window), 1))
+	cv.contextBarBox.Show()
+	cv.contextBarBox.Refresh()
+}
+
+// contextBarTheme is the current theme with the fill of progress bars, the
+// primary color, replaced by another theme color
+type contextBarTheme struct {
+	fill fyne.ThemeColorName // "" keeps the primary color
+}
+
+func (t *contextBarTheme) current() fyne.Theme {
+	return fyne.CurrentApp().Settings().Theme()
+}
+
+func (t *contextBarTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if name == theme.ColorNamePrimary && t.fill != "" {
+		name = t.fill
+	}
+	return t.current().Color(name, variant)
+}
+
+func (t *contextBarTheme) Font(style fyne.TextStyle) fyne.Resource {
+	return t.current().Font(style)
+}
+
+func (t *contextBarTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	return t.current().Icon(name)
+}
+
+func (t *contextBarTheme) Size(name fyne.ThemeSizeName) float32 {
+	return t.current().Size(name)
+}
+
+// fixedHeightLayout stretches its objects to the full width at a fixed height
+type fixedHeightLayout struct {
+	height float32
+}
+
+func (l *fixedHeightLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(0, l.height)
+}
+
+func (l *fixedHeightLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	for _, o := range objects {
+		o.Move(fyne.NewPos(0, 0))
+		o.Resize(fyne.NewSize(size.Width, l.height))
+	}
+}
+
+// tokenCountImportance colours a token count: yellow from 80% of the context
+// window, red above 95%. Unknown windows stay neutral.
+func tokenCountImportance(count, window int) widget.Importance {
+	if window <= 0 {
+		return widget.MediumImportance
+	}
+	ratio := float64(count) / float64(window)
+	switch {
+	case ratio > tokenDangerRatio:
+		return widget.DangerImportance
+	case ratio >= tokenWarningRatio:
+		return widget.WarningImportance
+	default:
+		return widget.SuccessImportance
+	}
+}
+
+// formatTokenCount shortens large counts, e.g. 128000 to "128K"
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(n)/1000000)
+	case n >= 10000:
+		return fmt.Sprintf("%dK", n/1000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
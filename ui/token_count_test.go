@@ -0,0 +1,102 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestTokenCountImportance(t *testing.T) {
+	tests := []struct {
+		count, window int
+		want          widget.Importance
+	}{
+		{100, 0, widget.MediumImportance},
+		{100, 1000, widget.SuccessImportance},
+		{800, 1000, widget.WarningImportance},
+		{950, 1000, widget.WarningImportance},
+		{951, 1000, widget.DangerImportance},
+	}
+	for _, tt := range tests {
+		if got := tokenCountImportance(tt.count, tt.window); got != tt.want {
+			t.Errorf("tokenCountImportance(%d, %d) = %v, want %v", tt.count, tt.window, got, tt.want)
+		}
+	}
+}
+
+func TestChatView_TokenCount(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{TokenCount: 9}))
+	cv, _ := newTestChat(t, a)
+
+	fyne.DoAndWait(func() { cv.inputEntry.SetText("How many tokens?") })
+	waitUntil(t, "the token count", func() bool {
+		var text string
+		fyne.DoAndWait(func() { text = cv.tokenLabel.Text })
+		return text == "● 9 tokens"
+	})
+
+	fyne.DoAndWait(func() { cv.showTokenCount(121600, 128000, true) })
+	if got := cv.tokenLabel.Text; got != "● ≈121K / 128K tokens" {
+		t.Errorf("token label = %q", got)
+	}
+	if cv.tokenLabel.Importance != widget.WarningImportance {
+		t.Errorf("importance = %v, want warning at 95%% of the window", cv.tokenLabel.Importance)
+	}
+}
+
+func TestChatView_TokenCount_AnonymizedDraftStaysLocal(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{TokenCount: 9})
+	a := newTestApp(t, provider)
+	a.anonymizer.SetEnabled(true)
+	cv, _ := newTestChat(t, a)
+
+	draft := "My key is sk-proj-4fQ9xLm2Vb7TzR8wKp3N"
+	fyne.DoAndWait(func() { cv.inputEntry.SetText(draft) })
+	var text string
+	waitUntil(t, "the token count", func() bool {
+		fyne.DoAndWait(func() { text = cv.tokenLabel.Text })
+		return text != ""
+	})
+
+	for _, messages := range provider.CountRequests() {
+		for _, msg := range messages {
+			if strings.Contains(msg.Content, "sk-proj-4fQ9xLm2Vb7TzR8wKp3N") {
+				t.Fatalf("CountTokens received the draft: %q", msg.Content)
+			}
+		}
+	}
+	if !strings.HasPrefix(text, "● ≈") {
+		t.Errorf("token label = %q, want an estimate", text)
+	}
+}
+
+func TestChatView_ContextBar(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{TokenCount: 9, ContextWindow: 10}))
+	cv, _ := newTestChat(t, a)
+
+	fyne.DoAndWait(func() { cv.inputEntry.SetText("How many tokens?") })
+	waitUntil(t, "the context bar", func() bool {
+		var visible bool
+		fyne.DoAndWait(func() { visible = cv.contextBarBox.Visible() })
+		return visible
+	})
+	if cv.contextBar.Value != 0.9 || cv.contextBarTheme.fill != theme.ColorNameWarning {
+		t.Errorf("bar value %v with fill %q, want 0.9 in the warning color", cv.contextBar.Value, cv.contextBarTheme.fill)
+	}
+
+	fyne.DoAndWait(func() { cv.showContextUsage(2000, 1000) })
+	if cv.contextBar.Value != 1 || cv.contextBarTheme.fill != theme.ColorNameError {
+		t.Errorf("bar value %v with fill %q, want a full bar in the error color", cv.contextBar.Value, cv.contextBarTheme.fill)
+	}
+
+	fyne.DoAndWait(func() { cv.showContextUsage(100, 0) })
+	if cv.contextBarBox.Visible() {
+		t.Error("expected the bar to be hidden for an unknown context window")
+	}
+}
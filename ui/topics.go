@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"light-llm-client/utils"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// EnableTopics shows the topics found by a running topic worker in the open
+// chat tabs
+func (a *App) EnableTopics(worker *utils.TopicWorker) {
+	a.topicWorker = worker
+	worker.OnTopics = func(conversationID int64, topics []string) {
+		fyne.Do(func() {
+			if cv, ok := a.chatViews[conversationID]; ok {
+				cv.showTopics(topics)
+			}
+		})
+	}
+}
+
+// searchTopic searches all conversations for a topic
+func (a *App) searchTopic(topic string) {
+	a.showSearch()
+	a.searchView.Search(topic)
+}
+
+// loadTopics shows the stored topics of the conversation
+func (cv *ChatView) loadTopics() {
+	conversationID := cv.conversationID
+	utils.SafeGo(cv.app.logger, "loadTopics", func() {
+		topics, err := cv.app.db.ListConversationTopics(conversationID)
+		if err != nil {
+			cv.app.logger.Warn("Failed to load topics of conversation %d: %v", conversationID, err)
+			return
+		}
+		fyne.Do(func() {
+			if cv.conversationID == conversationID {
+				cv.showTopics(topics)
+			}
+		})
+	})
+}
+
+// showTopics shows the topics as chips that search all conversations for
+// them. Must be called on the UI thread.
+func (cv *ChatView) showTopics(topics []string) {
+	if cv.topicsContainer == nil {
+		return
+	}
+	if len(topics) == 0 {
+		cv.topicsContainer.Objects = nil
+		cv.topicsContainer.Hide()
+		cv.topicsContainer.Refresh()
+		return
+	}
+
+	chips := make([]fyne.CanvasObject, 0, len(topics)+1)
+	chips = append(chips, widget.NewLabel("🏷 主题:"))
+	for _, t := range topics {
+		topic := t
+		chip := widget.NewButton(topic, func() {
+			cv.app.searchTopic(topic)
+		})
+		chip.Importance = widget.LowImportance
+		chips = append(chips, chip)
+	}
+
+	cv.topicsContainer.Objects = []fyne.CanvasObject{container.NewHScroll(container.NewHBox(chips...))}
+	cv.topicsContainer.Show()
+	cv.topicsContainer.Refresh()
+}
@@ -0,0 +1,52 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestChatView_TopicChips(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	cv, convID := newTestChat(t, a)
+	if cv.topicsContainer.Visible() {
+		t.Fatal("expected no topics for a new conversation")
+	}
+
+	content := "Kubernetes schedules pods. Run it with Kubernetes or try Kubernetes locally."
+	if _, err := a.db.CreateMessage(convID, "user", content, "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	worker := utils.NewTopicWorker(a.db, a.logger)
+	a.EnableTopics(worker)
+	if n := worker.RunOnce(); n != 1 {
+		t.Fatalf("worker analyzed %d conversations, want 1", n)
+	}
+	waitUntil(t, "the topic chips", func() bool {
+		var visible bool
+		fyne.DoAndWait(func() { visible = cv.topicsContainer.Visible() })
+		return visible
+	})
+
+	chip := findObject(cv.topicsContainer, func(o fyne.CanvasObject) bool {
+		b, ok := o.(*widget.Button)
+		return ok && b.Text == "Kubernetes"
+	})
+	if chip == nil {
+		t.Fatal("expected a chip for the topic")
+	}
+	test.Tap(chip.(*widget.Button))
+	if a.searchTabItem == nil {
+		t.Fatal("expected the chip to open the search tab")
+	}
+	if got := a.searchView.searchEntry.Text; got != "Kubernetes" {
+		t.Errorf("search query = %q, want the topic", got)
+	}
+}
@@ -0,0 +1,947 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newTestApp creates an App on Fyne's test driver with a temporary database
+// and provider as its only provider
+func newTestApp(t *testing.T, provider llm.Provider) *App {
+	t.Helper()
+
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	logger, err := utils.NewLogger(filepath.Join(dir, "test.log"))
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		logger.Close()
+	})
+
+	fyneApp := test.NewTempApp(t)
+	config := &utils.Config{UI: utils.UIConfig{Theme: "light", FontSize: 14, WindowWidth: 1200, WindowHeight: 800}}
+
+	a := &App{
+		fyneApp:          fyneApp,
+		window:           fyneApp.NewWindow("test"),
+		config:           config,
+		configPath:       filepath.Join(dir, "config.json"),
+		db:               database,
+		logger:           logger,
+		providers:        map[string]llm.Provider{"mock": provider},
+		anonymizer:       utils.NewTextAnonymizer(config.Privacy),
+		chatViews:        make(map[int64]*ChatView),
+		tabItems:         make(map[int64]*CustomTab),
+		messageCache:     make(map[int64][]*db.Message),
+		uiCache:          make(map[int64][]fyne.CanvasObject),
+		cacheMaxSize:     10,
+		cacheAccessOrder: make([]int64, 0, 10),
+	}
+	a.notifications = NewNotificationCenter(a)
+	a.buildUI()
+	return a
+}
+
+// newTestChat opens a new conversation and returns its chat view once it is loaded
+func newTestChat(t *testing.T, a *App) (*ChatView, int64) {
+	t.Helper()
+	a.createNewConversation()
+	convID := a.getActiveConversationID()
+	cv, ok := a.chatViews[convID]
+	if !ok {
+		t.Fatalf("no chat view opened for conversation %d", convID)
+	}
+
+	// The messages load in the background; sending before they are shown
+	// would have the empty list replace the new messages
+	waitUntil(t, "the messages to load", func() bool {
+		_, loaded := a.uiCache[convID]
+		return loaded && len(cv.messagesContainer.Objects) == 0
+	})
+	return cv, convID
+}
+
+// waitForMessages waits until the conversation has count messages and returns them
+func waitForMessages(t *testing.T, a *App, convID int64, count int) []*db.Message {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		messages, err := a.db.ListMessages(convID)
+		if err != nil {
+			t.Fatalf("ListMessages failed: %v", err)
+		}
+		if len(messages) == count {
+			return messages
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d messages, have %d", count, len(messages))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitUntil polls cond until it holds
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// findObject returns the first object in the tree of obj that matches
+func findObject(obj fyne.CanvasObject, match func(fyne.CanvasObject) bool) fyne.CanvasObject {
+	if obj == nil {
+		return nil
+	}
+	if match(obj) {
+		return obj
+	}
+
+	var children []fyne.CanvasObject
+	switch o := obj.(type) {
+	case *fyne.Container:
+		children = o.Objects
+	case *virtualMessageList:
+		// All rows, not only the ones scrolled into view
+		children = o.Objects
+	case *container.Scroll:
+		children = []fyne.CanvasObject{o.Content}
+	case *widget.PopUp:
+		children = []fyne.CanvasObject{o.Content}
+	case fyne.Widget:
+		children = test.WidgetRenderer(o).Objects()
+	}
+	for _, child := range children {
+		if found := findObject(child, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findButton returns the first button labelled text in the tree of obj
+func findButton(t *testing.T, obj fyne.CanvasObject, text string) *widget.Button {
+	t.Helper()
+	found := findObject(obj, func(o fyne.CanvasObject) bool {
+		b, ok := o.(*widget.Button)
+		return ok && b.Text == text
+	})
+	if found == nil {
+		t.Fatalf("button %q not found", text)
+	}
+	return found.(*widget.Button)
+}
+
+// sendTestMessage types content into the chat and taps send
+func sendTestMessage(cv *ChatView, content string) {
+	cv.inputEntry.SetText(content)
+	test.Tap(cv.sendButton)
+}
+
+func TestChatView_SendMessage(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"Hello from the mock"}, StreamDelay: time.Millisecond})
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Hi there")
+
+	messages := waitForMessages(t, a, convID, 2)
+	if messages[0].Role != "user" || messages[0].Content != "Hi there" {
+		t.Errorf("unexpected user message: %+v", messages[0])
+	}
+	if messages[1].Role != "assistant" || messages[1].Content != "Hello from the mock" {
+		t.Errorf("unexpected assistant message: %+v", messages[1])
+	}
+	if cv.inputEntry.Text != "" {
+		t.Errorf("input not cleared: %q", cv.inputEntry.Text)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 1 || len(requests[0]) != 1 || requests[0][0].Content != "Hi there" {
+		t.Errorf("unexpected requests: %+v", requests)
+	}
+
+	// The first exchange names the conversation
+	waitUntil(t, "the generated title", func() bool {
+		conv, err := a.db.GetConversation(convID)
+		return err == nil && conv.Title == "Mock Conversation"
+	})
+}
+
+func TestChatView_SendMessage_StreamError(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{ErrorAfter: 1})
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Hi there")
+
+	// The error is kept as the answer so it can be retried
+	messages := waitForMessages(t, a, convID, 2)
+	if messages[1].Role != "assistant" || messages[1].Content != "**错误**: "+llm.ErrMockFailure.Error() {
+		t.Errorf("unexpected assistant message: %+v", messages[1])
+	}
+}
+
+func TestChatView_SendMessage_Stop(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"one two three four five"}, StreamDelay: 50 * time.Millisecond})
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Count to five")
+	waitUntil(t, "the first chunk", func() bool {
+		var started bool
+		fyne.DoAndWait(func() {
+			started = findObject(cv.messagesContainer, func(o fyne.CanvasObject) bool {
+				r, ok := o.(*widget.RichText)
+				return ok && strings.HasPrefix(r.String(), "one")
+			}) != nil
+		})
+		return started
+	})
+	if !cv.stopButton.Visible() || !cv.sendButton.Disabled() {
+		t.Fatal("expected the stop button and a disabled send button while streaming")
+	}
+	test.Tap(cv.stopButton)
+
+	// The partial answer is kept, marked as cancelled
+	messages := waitForMessages(t, a, convID, 2)
+	content := messages[1].Content
+	if !strings.HasPrefix(content, "one") || !strings.HasSuffix(content, " [cancelled]") || strings.Contains(content, "five") {
+		t.Errorf("unexpected assistant message: %q", content)
+	}
+	waitUntil(t, "sending to be enabled", func() bool {
+		var idle bool
+		fyne.DoAndWait(func() { idle = !cv.stopButton.Visible() && !cv.sendButton.Disabled() })
+		return idle
+	})
+}
+
+func TestChatView_Close_StopsPausedStream(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"one two three four five"}, StreamDelay: 50 * time.Millisecond})
+	a := newTestApp(t, provider)
+	cv, _ := newTestChat(t, a)
+
+	sendTestMessage(cv, "Count to five")
+	waitUntil(t, "streaming to start", func() bool {
+		var streaming bool
+		fyne.DoAndWait(func() { streaming = cv.stopButton.Visible() })
+		return streaming
+	})
+	fyne.DoAndWait(func() {
+		cv.togglePauseStreaming()
+		cv.Close()
+	})
+
+	// The paused reader sees the cancellation and the stream ends
+	waitUntil(t, "the stream to end", func() bool {
+		cv.pauseMu.Lock()
+		defer cv.pauseMu.Unlock()
+		return cv.streamCancel == nil
+	})
+}
+
+func TestChatView_SendMessage_ProviderSystemPrompt(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"Ahoy"}})
+	a := newTestApp(t, provider)
+	a.config.LLMProviders = map[string]utils.ProviderConfig{"mock": {SystemPrompt: "Talk like a pirate."}}
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Hi there")
+
+	// The prompt is sent first but not saved with the conversation
+	messages := waitForMessages(t, a, convID, 2)
+	if messages[0].Role != "user" {
+		t.Errorf("expected the user message first, got %+v", messages[0])
+	}
+	requests := provider.Requests()
+	if len(requests) != 1 || len(requests[0]) != 2 {
+		t.Fatalf("unexpected requests: %+v", requests)
+	}
+	if got := requests[0][0]; got.Role != "system" || got.Content != "Talk like a pirate." {
+		t.Errorf("expected the provider system prompt first, got %+v", got)
+	}
+
+	// A system message of the conversation takes precedence
+	own := []llm.Message{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "Hi"}}
+	if got := providerSystemPrompt(a.config.LLMProviders["mock"], own); got != "" {
+		t.Errorf("expected no provider system prompt, got %q", got)
+	}
+}
+
+func TestChatView_SendMessage_ConversationSystemPrompt(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"Ahoy"}})
+	a := newTestApp(t, provider)
+	a.config.LLMProviders = map[string]utils.ProviderConfig{"mock": {SystemPrompt: "Be helpful."}}
+	cv, convID := newTestChat(t, a)
+
+	fyne.DoAndWait(func() {
+		cv.systemPromptEntry.SetText("  Talk like a pirate.\n")
+		cv.saveSystemPrompt()
+	})
+	if conv, err := a.db.GetConversation(convID); err != nil || conv.SystemPrompt != "Talk like a pirate." {
+		t.Fatalf("stored conversation = %+v, %v", conv, err)
+	}
+
+	sendTestMessage(cv, "Hi there")
+
+	// The conversation's prompt replaces the provider's and is not saved as a message
+	messages := waitForMessages(t, a, convID, 2)
+	if messages[0].Role != "user" {
+		t.Errorf("expected the user message first, got %+v", messages[0])
+	}
+	requests := provider.Requests()
+	if len(requests) != 1 || len(requests[0]) != 2 {
+		t.Fatalf("unexpected requests: %+v", requests)
+	}
+	if got := requests[0][0]; got.Role != "system" || got.Content != "Talk like a pirate." {
+		t.Errorf("expected the conversation system prompt first, got %+v", got)
+	}
+}
+
+func TestChatView_SendMessage_ConversationParams(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"Done"}})
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	fyne.DoAndWait(func() {
+		cv.paramsEditor.temperature.check.SetChecked(true)
+		cv.paramsEditor.temperature.slider.SetValue(0.2)
+		cv.paramsEditor.maxTokens.SetText("256")
+		cv.saveParams()
+	})
+	conv, err := a.db.GetConversation(convID)
+	if err != nil || conv.ParamsOverride.IsEmpty() {
+		t.Fatalf("stored conversation = %+v, %v", conv, err)
+	}
+
+	sendTestMessage(cv, "Hi there")
+	waitForMessages(t, a, convID, 2)
+
+	params := provider.Params()
+	if len(params) != 1 {
+		t.Fatalf("got %d requests, want 1", len(params))
+	}
+	got := params[0]
+	if got.Temperature == nil || *got.Temperature != 0.2 || got.MaxTokens == nil || *got.MaxTokens != 256 || got.TopP != nil {
+		t.Errorf("request params = %+v, want temperature 0.2 and 256 max tokens", got)
+	}
+
+	// Invalid max tokens are rejected and keep the stored overrides
+	fyne.DoAndWait(func() {
+		cv.paramsEditor.maxTokens.SetText("many")
+		cv.saveParams()
+	})
+	if conv, err := a.db.GetConversation(convID); err != nil || conv.ParamsOverride.MaxTokens == nil || *conv.ParamsOverride.MaxTokens != 256 {
+		t.Errorf("stored conversation after invalid input = %+v, %v", conv, err)
+	}
+}
+
+func TestChatView_SendMessage_ModelSwitch(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"Done"}})
+	a := newTestApp(t, provider)
+	a.config.LLMProviders = map[string]utils.ProviderConfig{"mock": {DefaultModel: "mock-model"}}
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Hi there")
+	waitForMessages(t, a, convID, 2)
+	waitUntil(t, "sending to be enabled", func() bool {
+		var idle bool
+		fyne.DoAndWait(func() { idle = !cv.sendButton.Disabled() })
+		return idle
+	})
+
+	// Later responses are saved with the model picked mid-conversation
+	fyne.DoAndWait(func() {
+		cv.modelSelect.Options = append(cv.modelSelect.Options, "mock-large")
+		cv.modelSelect.SetSelected("mock-large")
+	})
+	sendTestMessage(cv, "Once more")
+	messages := waitForMessages(t, a, convID, 4)
+
+	if messages[1].Model != "mock-model" || messages[3].Model != "mock-large" {
+		t.Errorf("response models = %q, %q, want mock-model, mock-large", messages[1].Model, messages[3].Model)
+	}
+	if got := provider.RequestModels(); len(got) != 2 || got[1] != "mock-large" {
+		t.Errorf("request models = %q, want the second one to be mock-large", got)
+	}
+}
+
+func TestChatView_LoadMessages_Concurrent(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	cv, convID := newTestChat(t, a)
+	for _, content := range []string{"one", "two", "three"} {
+		if _, err := a.db.CreateMessage(convID, "user", content, "mock", "mock", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+	delete(a.messageCache, convID)
+	delete(a.uiCache, convID)
+
+	// While a load is running another one is skipped
+	mu := a.loadingMutexFor(convID)
+	mu.Lock()
+	cv.loadMessages()
+	if _, loaded := a.messageCache[convID]; loaded {
+		t.Error("expected loadMessages to skip while the conversation is loading")
+	}
+	mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cv.loadMessages()
+		}()
+	}
+	wg.Wait()
+
+	waitUntil(t, "the messages to load", func() bool {
+		if !mu.TryLock() {
+			return false
+		}
+		mu.Unlock()
+		var count int
+		fyne.DoAndWait(func() { count = len(cv.messagesContainer.Objects) })
+		return count == 3
+	})
+	seen := make(map[fyne.CanvasObject]bool)
+	for _, obj := range cv.messagesContainer.Objects {
+		if seen[obj] {
+			t.Fatal("found a message shown twice")
+		}
+		seen[obj] = true
+	}
+}
+
+func TestChatView_LoadMessages_Tags(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	cv, convID := newTestChat(t, a)
+	var messages []*db.Message
+	for _, content := range []string{"one", "two"} {
+		msg, err := a.db.CreateMessage(convID, "user", content, "mock", "mock", "", 0)
+		if err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := a.db.SetMessageTags(messages[1].ID, []string{"urgent"}); err != nil {
+		t.Fatalf("SetMessageTags failed: %v", err)
+	}
+	delete(a.messageCache, convID)
+	delete(a.uiCache, convID)
+
+	// The tags loaded for the whole conversation go to their messages
+	cv.loadMessages()
+	var objects []fyne.CanvasObject
+	waitUntil(t, "the messages to load", func() bool {
+		fyne.DoAndWait(func() { objects = slices.Clone(cv.messagesContainer.Objects) })
+		return len(objects) == 2
+	})
+	hasTag := func(obj fyne.CanvasObject) bool {
+		return findObject(obj, func(o fyne.CanvasObject) bool {
+			text, ok := o.(*canvas.Text)
+			return ok && text.Text == "urgent"
+		}) != nil
+	}
+	if hasTag(objects[0]) || !hasTag(objects[1]) {
+		t.Error("expected only the second message to show the urgent tag")
+	}
+}
+
+func TestApp_NavigateToMessage(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	a.window.Resize(fyne.NewSize(1200, 800))
+	conv, err := a.db.CreateConversation("target", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	var last *db.Message
+	for i := 0; i < 30; i++ {
+		if last, err = a.db.CreateMessage(conv.ID, "user", fmt.Sprintf("message %d", i), "mock", "mock", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	if err := a.NavigateToMessage(last.ID); err != nil {
+		t.Fatalf("NavigateToMessage failed: %v", err)
+	}
+	if a.getActiveConversationID() != conv.ID {
+		t.Errorf("expected conversation %d to be active, got %d", conv.ID, a.getActiveConversationID())
+	}
+	cv := a.chatViews[conv.ID]
+	select {
+	case <-cv.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the messages to load")
+	}
+	waitUntil(t, "the scroll to the message", func() bool {
+		var offset float32
+		fyne.DoAndWait(func() { offset = cv.messagesScroll.Offset.Y })
+		return offset > 0
+	})
+	if len(cv.messagesContainer.Objects) != 30 {
+		t.Errorf("expected 30 messages, got %d", len(cv.messagesContainer.Objects))
+	}
+
+	if err := a.NavigateToMessage(last.ID + 100); err == nil {
+		t.Error("expected an error for a missing message")
+	}
+}
+
+func TestChatView_RegenerateMessage(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"first answer", "second answer"}})
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Question")
+	waitForMessages(t, a, convID, 2)
+	waitUntil(t, "the answer to be rendered", func() bool {
+		return findObject(cv.messagesContainer, func(o fyne.CanvasObject) bool {
+			b, ok := o.(*widget.Button)
+			return ok && b.Text == "🔄 重新生成"
+		}) != nil
+	})
+
+	test.Tap(findButton(t, cv.messagesContainer, "🔄 重新生成"))
+
+	waitUntil(t, "the regenerated answer", func() bool {
+		messages, err := a.db.ListMessages(convID)
+		return err == nil && len(messages) > 0 && messages[len(messages)-1].Content == "second answer"
+	})
+
+	// The answer being regenerated is not sent back to the provider
+	requests := provider.Requests()
+	if len(requests) != 2 || len(requests[1]) != 1 || requests[1][0].Content != "Question" {
+		t.Errorf("unexpected regenerate request: %+v", requests)
+	}
+}
+
+func TestChatView_RegenerateMessage_SkipsResponseCache(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"first answer", "second answer"}})
+	a := newTestApp(t, llm.Chain(provider, llm.WithResponseCache(llm.NewMemoryResponseCache(10))))
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Question")
+	waitForMessages(t, a, convID, 2)
+	waitUntil(t, "the answer to be rendered", func() bool {
+		return findObject(cv.messagesContainer, func(o fyne.CanvasObject) bool {
+			b, ok := o.(*widget.Button)
+			return ok && b.Text == "🔄 重新生成"
+		}) != nil
+	})
+
+	test.Tap(findButton(t, cv.messagesContainer, "🔄 重新生成"))
+
+	waitUntil(t, "the regenerated answer", func() bool {
+		messages, err := a.db.ListMessages(convID)
+		return err == nil && len(messages) > 0 && messages[len(messages)-1].Content == "second answer"
+	})
+	if provider.Calls() != 2 {
+		t.Errorf("expected regenerate to ask the provider again, got %d calls", provider.Calls())
+	}
+}
+
+func TestChatView_EditMessage(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"answer"}})
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Original question")
+	waitForMessages(t, a, convID, 2)
+	waitUntil(t, "the answer to be rendered", func() bool {
+		return findObject(cv.messagesContainer, func(o fyne.CanvasObject) bool {
+			b, ok := o.(*widget.Button)
+			return ok && b.Text == "🔄 重新生成"
+		}) != nil
+	})
+
+	// The first edit button belongs to the user message
+	test.Tap(findButton(t, cv.messagesContainer, "✏️ 编辑"))
+
+	dialog := a.window.Canvas().Overlays().Top()
+	if dialog == nil {
+		t.Fatal("edit dialog not shown")
+	}
+	entry, ok := findObject(dialog, func(o fyne.CanvasObject) bool {
+		_, ok := o.(*widget.Entry)
+		return ok
+	}).(*widget.Entry)
+	if !ok {
+		t.Fatal("edit entry not found")
+	}
+	if entry.Text != "Original question" {
+		t.Errorf("edit entry = %q, want the current content", entry.Text)
+	}
+
+	entry.SetText("Edited question")
+	test.Tap(findButton(t, dialog, "保存"))
+
+	message, err := a.db.GetMessage(waitForMessages(t, a, convID, 2)[0].ID)
+	if err != nil {
+		t.Fatalf("GetMessage failed: %v", err)
+	}
+	if message.Content != "Edited question" {
+		t.Errorf("message content = %q, want the edited content", message.Content)
+	}
+	if a.window.Canvas().Overlays().Top() != nil {
+		t.Error("edit dialog still shown after saving")
+	}
+}
+
+func TestChatView_DeleteMessagesBefore(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	conv, err := a.db.CreateConversation("prune", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := a.db.CreateMessage(conv.ID, "user", fmt.Sprintf("message %d", i), "mock", "mock", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	a.openChatTab(conv.ID)
+	cv := a.chatViews[conv.ID]
+	select {
+	case <-cv.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the messages to load")
+	}
+	if _, cached := a.uiCache[conv.ID]; !cached {
+		t.Fatal("expected the conversation UI to be cached")
+	}
+
+	// The first message has nothing before it
+	isDeleteBefore := func(o fyne.CanvasObject) bool {
+		b, ok := o.(*widget.Button)
+		return ok && b.Text == "🗑️ 删除之前的消息"
+	}
+	if findObject(cv.messagesContainer.Objects[0], isDeleteBefore) != nil {
+		t.Error("the first message should not offer deleting the messages before it")
+	}
+	findObject(cv.messagesContainer.Objects[2], isDeleteBefore).(*widget.Button).OnTapped()
+	findButton(t, a.window.Canvas().Overlays().Top(), "删除").OnTapped()
+
+	messages := waitForMessages(t, a, conv.ID, 2)
+	if messages[0].Content != "message 2" {
+		t.Errorf("expected message 2 to be kept first, got %q", messages[0].Content)
+	}
+	waitUntil(t, "the reloaded messages", func() bool {
+		var count int
+		fyne.DoAndWait(func() { count = len(cv.messagesContainer.Objects) })
+		return count == 2
+	})
+	if cached := a.messageCache[conv.ID]; len(cached) != 2 {
+		t.Errorf("expected the message cache to hold 2 messages, got %d", len(cached))
+	}
+}
+
+func TestChatView_SelectableTextCache(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	conv, err := a.db.CreateConversation("copy", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	content := strings.Repeat("a long line that wraps in the message label ", 20) + "\n\n  indented second paragraph"
+	if _, err := a.db.CreateMessage(conv.ID, "user", content, "mock", "mock", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	a.openChatTab(conv.ID)
+	cv := a.chatViews[conv.ID]
+	select {
+	case <-cv.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the messages to load")
+	}
+
+	// The test window hands out a new clipboard on each call, so check what
+	// the copy button reads
+	label, _ := findObject(cv.messagesContainer.Objects[0], func(o fyne.CanvasObject) bool {
+		l, ok := o.(*widget.Label)
+		return ok && l.Selectable
+	}).(*widget.Label)
+	if label == nil {
+		t.Fatal("message label not found")
+	}
+	label.Text = strings.ReplaceAll(content, " wraps ", " wraps\n")
+	if got := cv.selectableText(label); got != content {
+		t.Errorf("expected the original text to be copied, got %q", got)
+	}
+
+	// Rebuilding the messages forgets the labels of the previous rendering
+	a.reloadConversation(conv.ID)
+	waitUntil(t, "the reloaded messages", func() bool {
+		var loaded bool
+		fyne.DoAndWait(func() { loaded = len(cv.messagesContainer.Objects) == 1 })
+		return loaded
+	})
+	cv.selectableTextMu.Lock()
+	cached := len(cv.selectableTextCache)
+	cv.selectableTextMu.Unlock()
+	if cached != 1 {
+		t.Errorf("expected 1 cached label after the reload, got %d", cached)
+	}
+}
+
+func TestChatView_SendMessage_TokensUsed(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{Responses: []string{"Hello there"}}))
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Hi")
+
+	messages := waitForMessages(t, a, convID, 2)
+	tokens := messages[1].TokensUsed
+	if tokens <= 0 {
+		t.Fatalf("expected the usage of the response to be saved, got %d tokens", tokens)
+	}
+	waitUntil(t, "the token usage note", func() bool {
+		var found bool
+		fyne.DoAndWait(func() {
+			found = findObject(cv.messagesContainer, func(o fyne.CanvasObject) bool {
+				l, ok := o.(*widget.Label)
+				return ok && l.Text == fmt.Sprintf("~%d tokens", tokens)
+			}) != nil
+		})
+		return found
+	})
+}
+
+func TestChatView_SendMessage_StopReason(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{
+		Responses: []string{"A cut off answer"},
+		Metadata:  map[string]interface{}{llm.MetadataStopReason: "max_tokens"},
+	})
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "Tell a long story")
+
+	messages := waitForMessages(t, a, convID, 2)
+	if got := utils.MessageStopReason(messages[1].Metadata); got != "max_tokens" {
+		t.Errorf("expected the stop reason to be saved, got metadata %q", messages[1].Metadata)
+	}
+	waitUntil(t, "the stop reason note", func() bool {
+		var found bool
+		fyne.DoAndWait(func() {
+			found = findObject(cv.messagesContainer, func(o fyne.CanvasObject) bool {
+				l, ok := o.(*widget.Label)
+				return ok && l.Text == "停止原因: max_tokens"
+			}) != nil
+		})
+		return found
+	})
+}
+
+func TestRefreshModels_Ollama(t *testing.T) {
+	var tagRequests int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		tagRequests++
+		mu.Unlock()
+		w.Write([]byte(`{"models": [{"name": "llama3:8b"}, {"name": "qwen2.5:7b"}]}`))
+	}))
+	defer server.Close()
+
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	cv, _ := newTestChat(t, a)
+	ollama, err := llm.NewOllamaProvider(llm.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider failed: %v", err)
+	}
+	a.providers["ollama"] = a.wrapProvider(ollama, utils.ProviderConfig{})
+	a.config.LLMProviders = map[string]utils.ProviderConfig{"ollama": {Enabled: true}}
+
+	// Refreshing the provider list fetches the models of the selected provider
+	fyne.DoAndWait(func() {
+		cv.currentProvider = "ollama"
+		cv.RefreshProviderList()
+	})
+	waitUntil(t, "the fetched models in the chat", func() bool {
+		var options []string
+		fyne.DoAndWait(func() {
+			options = cv.modelSelect.Options
+		})
+		return slices.Equal(options, []string{"llama3:8b", "qwen2.5:7b"})
+	})
+	mu.Lock()
+	requests := tagRequests
+	mu.Unlock()
+	// One request when the provider was created, one for the refresh
+	if requests < 2 {
+		t.Errorf("got %d model list requests, want a refetch", requests)
+	}
+
+	// The default model of the provider form offers them too
+	sv := NewSettingsView(a)
+	sv.buildEditForm()
+	fyne.DoAndWait(func() {
+		sv.selectedProvider = "ollama"
+		sv.loadProviderConfig("ollama")
+	})
+	window := test.NewTempWindow(t, sv.modelEntry)
+	waitUntil(t, "the fetched models in the settings", func() bool {
+		found := false
+		fyne.DoAndWait(func() {
+			// The dropdown button of the entry lists the options
+			test.Tap(sv.modelEntry.ActionItem.(fyne.Tappable))
+			overlay := window.Canvas().Overlays().Top()
+			if overlay == nil {
+				return
+			}
+			found = findObject(overlay, func(o fyne.CanvasObject) bool {
+				text, ok := o.(*canvas.Text)
+				return ok && text.Text == "qwen2.5:7b"
+			}) != nil
+			window.Canvas().Overlays().Remove(overlay)
+		})
+		return found
+	})
+}
+
+func TestSettingsView_GlobalHotkeyOnlyWhereSupported(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	tab := NewSettingsView(a).buildUISettingsTab()
+
+	shown := findObject(tab, func(o fyne.CanvasObject) bool {
+		form, ok := o.(*widget.Form)
+		if !ok {
+			return false
+		}
+		for _, item := range form.Items {
+			if item.Text == "Global Hotkey" {
+				return true
+			}
+		}
+		return false
+	}) != nil
+	if shown != globalHotkeySupported() {
+		t.Errorf("Global Hotkey shown = %v, want %v", shown, globalHotkeySupported())
+	}
+}
+
+func TestApp_SaveCacheSize(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+	for id := int64(1); id <= 8; id++ {
+		a.messageCache[id] = nil
+		a.updateCacheAccess(id)
+	}
+
+	a.saveCacheSize(6)
+	if a.cacheMaxSize != 6 || len(a.messageCache) != 6 {
+		t.Fatalf("got cache size %d with %d conversations, want 6", a.cacheMaxSize, len(a.messageCache))
+	}
+	// The least recently used conversations were evicted
+	for _, id := range []int64{1, 2} {
+		if _, ok := a.messageCache[id]; ok {
+			t.Errorf("conversation %d is still cached", id)
+		}
+	}
+	config, err := utils.LoadConfig(a.configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.UI.CacheSize != 6 {
+		t.Errorf("saved cache size = %d, want 6", config.UI.CacheSize)
+	}
+
+	// Sizes below the minimum are raised to it
+	a.saveCacheSize(1)
+	if a.cacheMaxSize != utils.MinCacheSize || len(a.messageCache) != utils.MinCacheSize {
+		t.Errorf("got cache size %d with %d conversations, want %d", a.cacheMaxSize, len(a.messageCache), utils.MinCacheSize)
+	}
+}
+
+func TestChatView_FollowUpSuggestions(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{Responses: []string{"What about tomorrow?"}}))
+	a.config.UI.ShowFollowUpSuggestions = true
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "What is the weather today?")
+	waitForMessages(t, a, convID, 2)
+
+	// The suggestions are shown once the response is among the messages
+	waitUntil(t, "the follow-up suggestions", func() bool {
+		var visible bool
+		fyne.DoAndWait(func() {
+			visible = cv.followUpContainer.Visible() && len(cv.followUpContainer.Objects) > 0
+		})
+		return visible
+	})
+}
+
+func TestChatView_SummarizeIfNeeded(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"A summary"}})
+	a := newTestApp(t, provider)
+	a.config.Data.AutoSummarize = true
+	a.config.Data.SummarizeAfterMessages = 5
+	cv, convID := newTestChat(t, a)
+
+	for i := 0; i < 6; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		if _, err := a.db.CreateMessage(convID, role, fmt.Sprintf("message %d", i), "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+	fyne.DoAndWait(func() {
+		a.messageCache[convID] = nil
+		a.uiCache[convID] = nil
+	})
+
+	// Runs in the background after a response, like in sendMessage
+	cv.summarizeIfNeeded(provider)
+
+	messages, err := a.db.ListMessages(convID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != summaryKeepRecent+1 {
+		t.Fatalf("got %d messages, want the summary and the %d latest", len(messages), summaryKeepRecent)
+	}
+	// The stale caches are dropped; reloading may cache the new messages
+	waitUntil(t, "the stale caches to be dropped", func() bool {
+		var stale bool
+		fyne.DoAndWait(func() {
+			cached, ok := a.messageCache[convID]
+			stale = ok && len(cached) == 0
+		})
+		return !stale
+	})
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"light-llm-client/db"
+
+	"fyne.io/fyne/v2"
+)
+
+// maxUndoActions is how many sends of a chat can be undone
+const maxUndoActions = 50
+
+// UndoAction is a send-and-response cycle of a chat that Ctrl+Z can take back
+type UndoAction struct {
+	// MessageIDs are the user message and the response saved for it
+	MessageIDs []int64
+	// Input is the text of the input box when the message was sent
+	Input string
+	// messages are the deleted messages with their tags and versions once
+	// the action was undone, which redo adds back
+	messages []*db.MessageSnapshot
+}
+
+// beginUndoAction records a sent user message. The response is added to the
+// returned action once it is saved. A new send ends the redo history.
+func (cv *ChatView) beginUndoAction(userMessageID int64, input string) *UndoAction {
+	action := &UndoAction{MessageIDs: []int64{userMessageID}, Input: input}
+
+	cv.undoMu.Lock()
+	defer cv.undoMu.Unlock()
+	cv.undoStack = append(cv.undoStack, action)
+	if len(cv.undoStack) > maxUndoActions {
+		cv.undoStack = cv.undoStack[len(cv.undoStack)-maxUndoActions:]
+	}
+	cv.redoStack = nil
+	return action
+}
+
+// addUndoMessage adds a saved response to action
+func (cv *ChatView) addUndoMessage(action *UndoAction, msg *db.Message) {
+	if action == nil || msg == nil {
+		return
+	}
+	cv.undoMu.Lock()
+	defer cv.undoMu.Unlock()
+	action.MessageIDs = append(action.MessageIDs, msg.ID)
+}
+
+// Undo deletes the messages of the last send and puts its text back into the
+// input box. Nothing happens while a response is streaming.
+func (cv *ChatView) Undo() {
+	if cv.conversationID == 0 || cv.sendButton.Disabled() {
+		return
+	}
+
+	cv.undoMu.Lock()
+	if len(cv.undoStack) == 0 {
+		cv.undoMu.Unlock()
+		return
+	}
+	action := cv.undoStack[len(cv.undoStack)-1]
+	cv.undoStack = cv.undoStack[:len(cv.undoStack)-1]
+	cv.undoMu.Unlock()
+
+	// Keep the messages for redo; ones deleted in the meantime are skipped
+	action.messages = action.messages[:0]
+	for _, id := range action.MessageIDs {
+		msg, err := cv.app.db.GetMessageSnapshot(id)
+		if err != nil {
+			continue
+		}
+		if err := cv.app.db.DeleteMessage(id); err != nil {
+			cv.app.logger.Error("Failed to undo message %d: %v", id, err)
+			cv.app.showError("撤销失败: " + err.Error())
+			cv.app.reloadConversation(cv.conversationID)
+			return
+		}
+		action.messages = append(action.messages, msg)
+	}
+	cv.app.logger.Info("Undid send of %d message(s) in conversation %d", len(action.messages), cv.conversationID)
+
+	cv.undoMu.Lock()
+	cv.redoStack = append(cv.redoStack, action)
+	cv.undoMu.Unlock()
+
+	cv.showAnonymized = make(map[int]bool)
+	cv.app.reloadConversation(cv.conversationID)
+	cv.inputEntry.SetText(action.Input)
+	cv.app.window.Canvas().Focus(cv.inputEntry)
+}
+
+// Redo adds back the messages of the last undone send. The input box is
+// cleared unless it was changed since the undo.
+func (cv *ChatView) Redo() {
+	if cv.conversationID == 0 || cv.sendButton.Disabled() {
+		return
+	}
+
+	cv.undoMu.Lock()
+	if len(cv.redoStack) == 0 {
+		cv.undoMu.Unlock()
+		return
+	}
+	action := cv.redoStack[len(cv.redoStack)-1]
+	cv.redoStack = cv.redoStack[:len(cv.redoStack)-1]
+	cv.undoMu.Unlock()
+
+	ids, err := cv.app.db.RestoreMessages(cv.conversationID, action.messages)
+	if err != nil {
+		cv.app.logger.Error("Failed to redo send in conversation %d: %v", cv.conversationID, err)
+		cv.app.showError("重做失败: " + err.Error())
+		return
+	}
+	cv.app.logger.Info("Redid send of %d message(s) in conversation %d", len(ids), cv.conversationID)
+
+	cv.undoMu.Lock()
+	cv.undoStack = append(cv.undoStack, &UndoAction{MessageIDs: ids, Input: action.Input})
+	cv.undoMu.Unlock()
+
+	if cv.inputEntry.Text == action.Input {
+		cv.inputEntry.SetText("")
+	}
+	cv.showAnonymized = make(map[int]bool)
+	cv.app.reloadConversation(cv.conversationID)
+}
+
+// undoOrRedoInput runs an undo or redo shortcut typed in the input box. It
+// undoes the typing first and only takes back a send once there is nothing
+// left to undo in the text.
+func (e *customEntry) undoOrRedoInput(shortcut fyne.Shortcut) {
+	before := e.Text
+	e.Entry.TypedShortcut(shortcut)
+	if e.Text != before || e.cv == nil {
+		return
+	}
+	if _, ok := shortcut.(*fyne.ShortcutUndo); ok {
+		e.cv.Undo()
+	} else {
+		e.cv.Redo()
+	}
+}
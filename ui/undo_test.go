@@ -0,0 +1,76 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestChatView_UndoRedoSend(t *testing.T) {
+	provider := llm.NewMockProvider(llm.MockConfig{Responses: []string{"first answer", "second answer"}})
+	a := newTestApp(t, provider)
+	cv, convID := newTestChat(t, a)
+
+	sendTestMessage(cv, "First question")
+	waitForMessages(t, a, convID, 2)
+	sendTestMessage(cv, "Second question")
+	waitForMessages(t, a, convID, 4)
+	waitUntil(t, "the response to finish", func() bool {
+		var disabled bool
+		fyne.DoAndWait(func() { disabled = cv.sendButton.Disabled() })
+		return !disabled
+	})
+
+	// Tags and edits of the undone messages come back with them
+	answer := waitForMessages(t, a, convID, 4)[3]
+	if err := a.db.SetMessageTags(answer.ID, []string{"keep"}); err != nil {
+		t.Fatalf("SetMessageTags failed: %v", err)
+	}
+	if err := a.db.UpdateMessage(answer.ID, "edited answer"); err != nil {
+		t.Fatalf("UpdateMessage failed: %v", err)
+	}
+
+	// Without typing to undo, Ctrl+Z in the input takes back the last send
+	fyne.DoAndWait(func() { cv.inputEntry.TypedShortcut(&fyne.ShortcutUndo{}) })
+	messages := waitForMessages(t, a, convID, 2)
+	if messages[1].Content != "first answer" {
+		t.Errorf("expected the first exchange to remain, got %+v", messages[1])
+	}
+	if cv.inputEntry.Text != "Second question" {
+		t.Errorf("input = %q, want the undone question", cv.inputEntry.Text)
+	}
+	waitUntil(t, "the undone messages to disappear", func() bool {
+		var rows int
+		fyne.DoAndWait(func() { rows = len(cv.messagesContainer.Objects) })
+		return rows == 2
+	})
+
+	fyne.DoAndWait(func() { cv.inputEntry.TypedShortcut(&fyne.ShortcutRedo{}) })
+	messages = waitForMessages(t, a, convID, 4)
+	if messages[2].Content != "Second question" || messages[3].Content != "edited answer" {
+		t.Errorf("unexpected restored messages %+v, %+v", messages[2], messages[3])
+	}
+	if tags, err := a.db.ListMessageTags(messages[3].ID); err != nil || len(tags) != 1 || tags[0] != "keep" {
+		t.Errorf("restored tags = %q, %v; want keep", tags, err)
+	}
+	if versions, err := a.db.ListMessageVersions(messages[3].ID); err != nil || len(versions) != 1 || versions[0].Content != answer.Content {
+		t.Errorf("restored versions = %+v, %v; want the content before the edit", versions, err)
+	}
+	if cv.inputEntry.Text != "" {
+		t.Errorf("input = %q, want it cleared by redo", cv.inputEntry.Text)
+	}
+
+	// The restored send can be undone again, then the one before it
+	fyne.DoAndWait(cv.Undo)
+	fyne.DoAndWait(cv.Undo)
+	waitForMessages(t, a, convID, 0)
+	if cv.inputEntry.Text != "First question" {
+		t.Errorf("input = %q, want the first question", cv.inputEntry.Text)
+	}
+	if len(provider.Requests()) != 2 {
+		t.Errorf("undo and redo sent %d requests, want 2", len(provider.Requests()))
+	}
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"light-llm-client/utils"
+	"net/url"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Repository whose GitHub releases are checked for updates
+const (
+	updateRepoOwner = "Ubastic"
+	updateRepoName  = "light-llm-client"
+)
+
+// updateCheckTimeout limits the release lookup
+const updateCheckTimeout = 30 * time.Second
+
+// CheckForUpdates looks up the latest release in the background once the
+// configured interval has passed since the last check. A release newer than
+// currentVersion that was not dismissed is announced in a banner.
+func (a *App) CheckForUpdates(currentVersion string) {
+	if !a.config.Update.CheckDue(time.Now()) {
+		return
+	}
+
+	checker := &utils.UpdateChecker{
+		RepoOwner:      updateRepoOwner,
+		RepoName:       updateRepoName,
+		CurrentVersion: currentVersion,
+	}
+	utils.SafeGo(a.logger, "checkForUpdates", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+		defer cancel()
+		release, err := checker.CheckLatestRelease(ctx)
+
+		fyne.Do(func() {
+			if err != nil {
+				// Not recorded as checked so the next start tries again
+				a.logger.Warn("Update check failed: %v", err)
+				return
+			}
+
+			a.config.Update.LastCheck = time.Now()
+			if err := utils.SaveConfig(a.configPath, a.config); err != nil {
+				a.logger.Error("Failed to save update check time: %v", err)
+			}
+
+			if release == nil {
+				a.logger.Info("No update available (v%s)", currentVersion)
+				return
+			}
+			if release.TagName == a.config.Update.DismissedVersion {
+				a.logger.Info("Update %s was dismissed", release.TagName)
+				return
+			}
+			a.logger.Info("Update available: %s", release.TagName)
+			a.showUpdateBanner(release)
+		})
+	})
+}
+
+// showUpdateBanner shows a release below the tab bar with a link to its
+// release notes
+func (a *App) showUpdateBanner(release *utils.Release) {
+	row := container.NewHBox(widget.NewLabel(fmt.Sprintf("Update available: %s —", release.TagName)))
+
+	if notesURL, err := url.Parse(release.HTMLURL); err == nil && release.HTMLURL != "" {
+		row.Add(widget.NewHyperlink("Release notes", notesURL))
+		row.Add(widget.NewLabel("|"))
+	}
+
+	dismissButton := widget.NewButton("Dismiss", func() {
+		a.dismissUpdate(release.TagName)
+	})
+	dismissButton.Importance = widget.LowImportance
+	row.Add(dismissButton)
+
+	// Translucent so the text stays readable in the dark theme
+	background := canvas.NewRectangle(color.NRGBA{R: 255, G: 204, B: 0, A: 90})
+	a.tabs.SetBanner(container.NewStack(background, row))
+}
+
+// dismissUpdate hides the update banner and stops announcing version
+func (a *App) dismissUpdate(version string) {
+	a.tabs.SetBanner(nil)
+	a.config.Update.DismissedVersion = version
+	if err := utils.SaveConfig(a.configPath, a.config); err != nil {
+		a.logger.Error("Failed to save dismissed update: %v", err)
+		return
+	}
+	a.logger.Info("Update %s dismissed", version)
+}
@@ -0,0 +1,33 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestUpdateBanner_Dismiss(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+
+	a.showUpdateBanner(&utils.Release{TagName: "v9.0.0", HTMLURL: "https://github.com/Ubastic/light-llm-client/releases/tag/v9.0.0"})
+	if len(a.tabs.bannerArea.Objects) != 1 {
+		t.Fatalf("expected the banner to be shown, got %d objects", len(a.tabs.bannerArea.Objects))
+	}
+
+	test.Tap(findButton(t, a.tabs.bannerArea, "Dismiss"))
+
+	if len(a.tabs.bannerArea.Objects) != 0 {
+		t.Error("banner still shown after dismissing")
+	}
+	saved, err := utils.LoadConfig(a.configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if saved.Update.DismissedVersion != "v9.0.0" {
+		t.Errorf("DismissedVersion = %q, want v9.0.0", saved.Update.DismissedVersion)
+	}
+}
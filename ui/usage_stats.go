@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"image/color"
 	"light-llm-client/db"
+	"light-llm-client/utils"
+	"path/filepath"
 	"sort"
 	"time"
 
@@ -16,7 +18,7 @@ import (
 // UsageStatsView represents the usage statistics interface
 type UsageStatsView struct {
 	app                    *App
-	statsLabel             *widget.Label
+	overviewGrid           *fyne.Container
 	providerList           *widget.List
 	modelList              *widget.List
 	providerStatsContainer *fyne.Container
@@ -45,9 +47,8 @@ func NewUsageStatsView(app *App) *UsageStatsView {
 
 // Build builds the usage statistics view UI
 func (usv *UsageStatsView) Build() fyne.CanvasObject {
-	// Overall statistics - create this FIRST
-	usv.statsLabel = widget.NewLabel("Loading statistics...")
-	usv.statsLabel.Wrapping = fyne.TextWrapWord
+	// Overview - create this FIRST
+	usv.overviewGrid = container.NewGridWithColumns(4, widget.NewLabel("Loading statistics..."))
 	
 	// Date range selector
 	usv.dateRangeSelect = widget.NewSelect(
@@ -63,15 +64,18 @@ func (usv *UsageStatsView) Build() fyne.CanvasObject {
 		usv.refreshStats()
 	})
 	
+	exportBtn := widget.NewButton("📄 Export Report (PDF)", usv.exportReport)
+	exportCSVBtn := widget.NewButton("📊 Export CSV", usv.exportCSV)
+
 	// Header with date range and refresh
 	header := container.NewBorder(
 		nil, nil,
 		widget.NewLabel("Date Range:"),
-		refreshBtn,
+		container.NewHBox(refreshBtn, exportBtn, exportCSVBtn),
 		usv.dateRangeSelect,
 	)
 	
-	overallCard := usv.createCard("Overall Statistics", usv.statsLabel)
+	overviewCard := usv.createCard("Overview", usv.overviewGrid)
 	
 	// Provider statistics - use VBox for flat layout instead of List
 	usv.providerStatsContainer = container.NewVBox()
@@ -87,7 +91,6 @@ func (usv *UsageStatsView) Build() fyne.CanvasObject {
 	
 	// Layout - use VBox with better spacing
 	leftPanel := container.NewVBox(
-		overallCard,
 		providerCard,
 	)
 	
@@ -100,7 +103,7 @@ func (usv *UsageStatsView) Build() fyne.CanvasObject {
 	content.SetOffset(0.4)
 	
 	mainContent := container.NewBorder(
-		header,
+		container.NewVBox(header, overviewCard),
 		nil, nil, nil,
 		content,
 	)
@@ -154,30 +157,19 @@ func (usv *UsageStatsView) refreshStats() {
 	stats, err := usv.app.db.GetUsageStats(usv.startDate, usv.endDate)
 	if err != nil {
 		usv.app.logger.Error("Failed to get usage stats: %v", err)
-		usv.statsLabel.SetText("Failed to load statistics")
+		usv.overviewGrid.Objects = []fyne.CanvasObject{widget.NewLabel("Failed to load statistics")}
+		usv.overviewGrid.Refresh()
 		return
 	}
 	
 	usv.currentStats = stats
 	
-	// Update overall stats
-	totalCost := 0.0
-	for _, ps := range stats.ProviderStats {
-		totalCost += ps.EstimatedCost
+	// The summary covers all time; a failure only hides that part
+	summary, err := usv.app.db.ComputeConversationSummaryStats()
+	if err != nil {
+		usv.app.logger.Error("Failed to get summary stats: %v", err)
 	}
-	
-	overallText := fmt.Sprintf(
-		"Total Tokens: %s\n"+
-		"Total Messages: %d\n"+
-		"Estimated Total Cost: $%.4f\n"+
-		"Date Range: %s to %s",
-		formatNumber(stats.TotalTokens),
-		stats.TotalMessages,
-		totalCost,
-		usv.startDate.Format("2006-01-02"),
-		usv.endDate.Format("2006-01-02"),
-	)
-	usv.statsLabel.SetText(overallText)
+	usv.updateOverview(summary)
 	
 	// Update provider stats
 	usv.updateProviderStats()
@@ -189,6 +181,67 @@ func (usv *UsageStatsView) refreshStats() {
 	usv.updateChart()
 }
 
+// updateOverview shows the totals of the date range and the all-time summary
+func (usv *UsageStatsView) updateOverview(summary *db.SummaryStats) {
+	totalCost := 0.0
+	for _, ps := range usv.currentStats.ProviderStats {
+		totalCost += ps.EstimatedCost
+	}
+
+	items := []string{
+		"Date Range", usv.startDate.Format("2006-01-02") + " to " + usv.endDate.Format("2006-01-02"),
+		"Tokens", formatNumber(usv.currentStats.TotalTokens),
+		"Messages", formatNumber(usv.currentStats.TotalMessages),
+		"Estimated Cost", fmt.Sprintf("$%.4f", totalCost),
+	}
+	if summary != nil {
+		longest := "-"
+		if summary.LongestConversationID != 0 {
+			longest = fmt.Sprintf("#%d", summary.LongestConversationID)
+			if conv, err := usv.app.db.GetConversation(summary.LongestConversationID); err == nil {
+				longest += " " + conv.Title
+			}
+		}
+		items = append(items,
+			"Conversations (all time)", formatNumber(summary.TotalConversations),
+			"Messages (all time)", formatNumber(summary.TotalMessages),
+			"Avg Messages / Conversation", fmt.Sprintf("%.1f", summary.AvgMessagesPerConversation),
+			"Longest Conversation", longest,
+			"Providers Used", fmt.Sprintf("%d (most: %s)", summary.UniqueProviders, orDash(summary.MostUsedProvider)),
+			"Models Used", fmt.Sprintf("%d (most: %s)", summary.UniqueModels, orDash(summary.MostUsedModel)),
+			"First Conversation", formatOverviewDate(summary.OldestConversationDate),
+			"Latest Conversation", formatOverviewDate(summary.NewestConversationDate),
+		)
+	}
+
+	objects := make([]fyne.CanvasObject, 0, len(items))
+	for i := 0; i < len(items); i += 2 {
+		name := widget.NewLabel(items[i])
+		name.TextStyle = fyne.TextStyle{Bold: true}
+		value := widget.NewLabel(items[i+1])
+		value.Truncation = fyne.TextTruncateEllipsis
+		objects = append(objects, name, value)
+	}
+	usv.overviewGrid.Objects = objects
+	usv.overviewGrid.Refresh()
+}
+
+// orDash returns s, or a dash when it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// formatOverviewDate formats a date of the overview, a dash for none
+func formatOverviewDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02")
+}
+
 // updateProviderStats updates the provider statistics display
 func (usv *UsageStatsView) updateProviderStats() {
 	usv.providerStatsContainer.Objects = nil
@@ -334,6 +387,46 @@ func (usv *UsageStatsView) createBarChart(dailyStats []*db.DailyUsageStats) fyne
 	return container.NewHScroll(bars)
 }
 
+// exportReport writes the shown statistics to a PDF in the export directory
+func (usv *UsageStatsView) exportReport() {
+	usv.exportStats(utils.FormatPDF, func(path string) error {
+		return utils.ExportUsageReportToPDF(usv.currentStats, usv.startDate, usv.endDate, path)
+	})
+}
+
+// exportCSV writes the shown statistics to a CSV in the export directory
+func (usv *UsageStatsView) exportCSV() {
+	usv.exportStats(utils.FormatCSV, func(path string) error {
+		return utils.ExportUsageStatsToCSV(usv.currentStats, path)
+	})
+}
+
+// exportStats writes the shown statistics with write to a file in the
+// export directory
+func (usv *UsageStatsView) exportStats(format utils.ExportFormat, write func(path string) error) {
+	if usv.currentStats == nil {
+		usv.app.showError("No statistics loaded")
+		return
+	}
+
+	exportDir, err := utils.GetDefaultExportPath(usv.app.config.RecentFiles)
+	if err != nil {
+		usv.app.showError("Failed to get export directory: " + err.Error())
+		return
+	}
+	path := filepath.Join(exportDir, utils.GenerateExportFilename("usage_report", format))
+
+	if err := write(path); err != nil {
+		usv.app.logger.Error("Failed to export usage report: %v", err)
+		usv.app.showError("Export failed: " + err.Error())
+		return
+	}
+
+	usv.app.logger.Info("Exported usage report to %s", path)
+	usv.app.addRecentFile(path)
+	usv.app.showInfo("导出成功!\n文件保存在: " + path)
+}
+
 // getSortedProviders returns provider names sorted by token usage
 func (usv *UsageStatsView) getSortedProviders() []string {
 	if usv.currentStats == nil {
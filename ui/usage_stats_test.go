@@ -0,0 +1,43 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"light-llm-client/llm"
+	"testing"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestUsageStatsView_Overview(t *testing.T) {
+	a := newTestApp(t, llm.NewMockProvider(llm.MockConfig{}))
+
+	conv, err := a.db.CreateConversation("Overview test", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for _, role := range []string{"user", "assistant"} {
+		if _, err := a.db.CreateMessage(conv.ID, role, "text", "mock", "mock-model", "", 10); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	usv := NewUsageStatsView(a)
+	usv.Build()
+
+	labels := make(map[string]string)
+	objects := usv.overviewGrid.Objects
+	for i := 0; i+1 < len(objects); i += 2 {
+		labels[objects[i].(*widget.Label).Text] = objects[i+1].(*widget.Label).Text
+	}
+	for name, want := range map[string]string{
+		"Conversations (all time)": "1",
+		"Messages (all time)":      "2",
+		"Longest Conversation":     "#1 Overview test",
+		"Models Used":              "1 (most: mock-model)",
+	} {
+		if got := labels[name]; got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
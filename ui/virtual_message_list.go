@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// virtualListOverscan is how far above and below the visible area rows are
+// still laid out and drawn, so scrolling doesn't uncover empty space
+const virtualListOverscan float32 = 600
+
+// estimatedRowHeight is used for rows that have not been visible yet. It is
+// fixed rather than an average of the measured rows so that the offsets of
+// rows don't move while the list is scrolled.
+const estimatedRowHeight float32 = 80
+
+// virtualMessageList shows the messages of a chat like a VBox, but only the
+// rows in the visible part of its scroll container and an overscan around it
+// are measured, laid out and drawn. A VBox measures every message on each
+// refresh, which freezes the window for conversations with hundreds of
+// messages.
+//
+// Rows are measured when they first come into view; until then they count
+// with estimatedRowHeight, so the scroll bar is only exact once the whole
+// list was scrolled through.
+type virtualMessageList struct {
+	widget.BaseWidget
+
+	// Objects are the rows, one per message. Like the objects of a
+	// fyne.Container they may be changed directly, followed by Refresh.
+	Objects []fyne.CanvasObject
+
+	scroll   *container.Scroll
+	renderer *virtualMessageListRenderer
+	heights  map[fyne.CanvasObject]float32 // Measured row heights
+	width    float32                       // Widest row measured so far
+	// relayout is set while the scroll container is refreshed because
+	// measuring rows changed the size of the list
+	relayout bool
+}
+
+// newVirtualMessageList creates an empty message list
+func newVirtualMessageList() *virtualMessageList {
+	l := &virtualMessageList{heights: make(map[fyne.CanvasObject]float32)}
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+// NewScroll returns the scroll container to show the list in. The list lays
+// out the rows of the part that is scrolled into view.
+func (l *virtualMessageList) NewScroll() *container.Scroll {
+	l.scroll = container.NewScroll(l)
+	l.scroll.OnScrolled = func(fyne.Position) {
+		l.layoutViewport()
+	}
+	return l.scroll
+}
+
+// ScrollToRow scrolls the list so row index is at the top
+func (l *virtualMessageList) ScrollToRow(index int) {
+	if l.scroll == nil {
+		return
+	}
+	// Lay out new rows first, the offset is limited to the content size
+	l.scroll.Refresh()
+	l.scroll.ScrollToOffset(fyne.NewPos(0, l.RowOffset(index)))
+	// ScrollToOffset doesn't report the change to OnScrolled
+	l.layoutViewport()
+}
+
+// layoutViewport lays out the rows of the viewport after a scroll. Unlike
+// Refresh it leaves the rows that stay in view alone.
+func (l *virtualMessageList) layoutViewport() {
+	if l.renderer == nil {
+		return
+	}
+	l.renderer.Layout(l.Size())
+	canvas.Refresh(l)
+}
+
+// Add appends a row. Call Refresh to show it.
+func (l *virtualMessageList) Add(obj fyne.CanvasObject) {
+	l.Objects = append(l.Objects, obj)
+}
+
+// Remove removes a row. Call Refresh to update the list.
+func (l *virtualMessageList) Remove(obj fyne.CanvasObject) {
+	for i, row := range l.Objects {
+		if row == obj {
+			l.Objects = append(l.Objects[:i:i], l.Objects[i+1:]...)
+			delete(l.heights, obj)
+			return
+		}
+	}
+}
+
+// RowOffset returns the vertical position of row index within the list
+func (l *virtualMessageList) RowOffset(index int) float32 {
+	var y float32
+	for _, obj := range l.Objects[:index] {
+		if obj.Visible() {
+			y += l.rowHeight(obj) + theme.Padding()
+		}
+	}
+	return y
+}
+
+// rowHeight returns the measured or estimated height of a row
+func (l *virtualMessageList) rowHeight(obj fyne.CanvasObject) float32 {
+	if height, ok := l.heights[obj]; ok {
+		return height
+	}
+	return estimatedRowHeight
+}
+
+// viewport returns the range of the list that is laid out
+func (l *virtualMessageList) viewport() (top, bottom float32) {
+	if l.scroll == nil {
+		// Not scrolled, everything is visible
+		return 0, l.Size().Height
+	}
+	height := l.scroll.Size().Height
+	if min := l.scroll.MinSize().Height; height < min {
+		// Not laid out yet
+		height = min
+	}
+	return l.scroll.Offset.Y - virtualListOverscan, l.scroll.Offset.Y + height + virtualListOverscan
+}
+
+// CreateRenderer implements fyne.Widget
+func (l *virtualMessageList) CreateRenderer() fyne.WidgetRenderer {
+	l.renderer = &virtualMessageListRenderer{list: l, shown: make(map[fyne.CanvasObject]bool)}
+	return l.renderer
+}
+
+// virtualMessageListRenderer renders the rows in the viewport of a list
+type virtualMessageListRenderer struct {
+	list    *virtualMessageList
+	objects []fyne.CanvasObject
+	shown   map[fyne.CanvasObject]bool // The rows in objects
+}
+
+// Layout measures and places the rows in the viewport
+func (r *virtualMessageListRenderer) Layout(size fyne.Size) {
+	l := r.list
+	top, bottom := l.viewport()
+	padding := theme.Padding()
+
+	objects := make([]fyne.CanvasObject, 0, len(r.objects))
+	shown := make(map[fyne.CanvasObject]bool, len(r.shown))
+	resized := false
+	var y float32
+	for _, obj := range l.Objects {
+		if !obj.Visible() {
+			continue
+		}
+		height := l.rowHeight(obj)
+		if y+height >= top && y <= bottom {
+			if !r.shown[obj] {
+				// The row may have changed while it was out of view
+				obj.Refresh()
+			}
+			min := obj.MinSize()
+			if min.Height != height {
+				l.heights[obj] = min.Height
+				height = min.Height
+				resized = true
+			}
+			if min.Width > l.width {
+				l.width = min.Width
+				resized = true
+			}
+			obj.Move(fyne.NewPos(0, y))
+			obj.Resize(fyne.NewSize(size.Width, height))
+			objects = append(objects, obj)
+			shown[obj] = true
+		}
+		y += height + padding
+	}
+	r.objects = objects
+	r.shown = shown
+
+	// Let the scroll container pick up the new size. The rows are measured
+	// now, so laying out again doesn't resize the list any further.
+	if resized && !l.relayout && l.scroll != nil {
+		l.relayout = true
+		l.scroll.Refresh()
+		l.relayout = false
+	}
+}
+
+// MinSize returns the size of all rows, estimated for the unmeasured ones
+func (r *virtualMessageListRenderer) MinSize() fyne.Size {
+	l := r.list
+	var height float32
+	rows := 0
+	for _, obj := range l.Objects {
+		if obj.Visible() {
+			height += l.rowHeight(obj)
+			rows++
+		}
+	}
+	if rows > 1 {
+		height += theme.Padding() * float32(rows-1)
+	}
+	return fyne.NewSize(l.width, height)
+}
+
+// Refresh lays out the rows of the current viewport and redraws them
+func (r *virtualMessageListRenderer) Refresh() {
+	l := r.list
+	// Forget the heights of rows that were replaced
+	if len(l.heights) > 2*len(l.Objects) {
+		current := make(map[fyne.CanvasObject]float32, len(l.Objects))
+		for _, obj := range l.Objects {
+			if height, ok := l.heights[obj]; ok {
+				current[obj] = height
+			}
+		}
+		l.heights = current
+	}
+
+	// Rows that come into view are refreshed by Layout
+	for _, obj := range r.objects {
+		obj.Refresh()
+	}
+	r.Layout(l.Size())
+	canvas.Refresh(l)
+}
+
+// Objects returns the rows in the viewport
+func (r *virtualMessageListRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+// Destroy implements fyne.WidgetRenderer
+func (r *virtualMessageListRenderer) Destroy() {}
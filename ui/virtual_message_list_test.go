@@ -0,0 +1,61 @@
+//go:build sqlite_fts5
+
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestVirtualMessageList_RendersViewportOnly(t *testing.T) {
+	test.NewTempApp(t)
+
+	list := newVirtualMessageList()
+	for i := 0; i < 1000; i++ {
+		list.Add(widget.NewLabel(fmt.Sprintf("message %d", i)))
+	}
+	scroll := list.NewScroll()
+	window := test.NewTempWindow(t, scroll)
+	window.Resize(fyne.NewSize(400, 300))
+	list.Refresh()
+
+	rendered := func() []fyne.CanvasObject {
+		return test.WidgetRenderer(list).Objects()
+	}
+	if n := len(rendered()); n == 0 || n > 100 {
+		t.Fatalf("rendered %d of 1000 rows, want only the viewport and overscan", n)
+	}
+	if rendered()[0] != list.Objects[0] {
+		t.Error("expected the first row to be rendered at the top")
+	}
+	if scroll.Content.Size().Height < 1000*estimatedRowHeight/2 {
+		t.Errorf("list height %v doesn't account for the rows out of view", scroll.Content.Size().Height)
+	}
+
+	list.ScrollToRow(900)
+	found := false
+	for _, obj := range rendered() {
+		if obj == list.Objects[900] {
+			found = true
+			if obj.Position().Y != list.RowOffset(900) {
+				t.Errorf("row 900 at %v, want %v", obj.Position().Y, list.RowOffset(900))
+			}
+		}
+		if obj == list.Objects[0] {
+			t.Error("the first row is still rendered after scrolling to the end")
+		}
+	}
+	if !found {
+		t.Error("row 900 not rendered after scrolling to it")
+	}
+
+	// Hidden rows take no space
+	list.Objects[0].Hide()
+	if list.RowOffset(1) != 0 {
+		t.Errorf("hidden row takes %v", list.RowOffset(1))
+	}
+}
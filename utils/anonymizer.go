@@ -11,6 +11,7 @@ import (
 	"strings"
 	"sync"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Anonymizer handles sensitive data anonymization and restoration
@@ -29,33 +30,96 @@ type AnonymizationPattern struct {
 	Regex       *regexp.Regexp
 	Replacement string // Template for replacement, e.g., "URL_%d", "API_KEY_%d"
 	Priority    int    // Higher priority patterns are processed first
+
+	// Optional hints that let findPatternMatches skip most of a long text.
+	// Patterns without hints are matched against the whole text.
+	keywords []string                 // Every match starts with one of these (lowercase ASCII)
+	anchored *regexp.Regexp           // Regex anchored at the start, tried at each keyword
+	windows  func(text string) []span // Every match lies within one of these byte ranges
+}
+
+// span is a byte range of a text
+type span struct {
+	start, end int
 }
 
 var placeholderTokenRegex = regexp.MustCompile(`\b[A-Z0-9][A-Z0-9_\-]*_[0-9a-f]{8}\b`)
 
+// placeholderPattern matches a whole placeholder (PREFIX_hash)
+var placeholderPattern = regexp.MustCompile(`^[A-Z_]+_[0-9a-f]{8}$`)
+
+// Key-value patterns used by anonymizeKeyValuePairs
+var (
+	kvDoubleQuotedRegex = regexp.MustCompile(`"([\w\-]+)"\s*:\s*"([^"]+)"`)
+	kvSingleQuotedRegex = regexp.MustCompile(`'([\w\-]+)'\s*:\s*'([^']+)'`)
+	kvJSObjectRegex     = regexp.MustCompile(`\b([a-zA-Z][\w\-]*)\s*:\s*'([^']+)'`)
+	kvYAMLRegex         = regexp.MustCompile(`(?m)^[\s]*([a-zA-Z][\w\-]*)\s*:\s*([^\s,\n'"]{10,})`)
+	kvAssignRegex       = regexp.MustCompile(`([a-zA-Z][\w\-]*)=([^\s&,;'"]{10,})`)
+
+	// kvYAMLLineRegex is kvYAMLRegex anchored, tried at each line start
+	kvYAMLLineRegex = anchoredRegex(kvYAMLRegex)
+)
+
+// Patterns used by the detection helpers
+var (
+	// 支持更多特殊字符：下划线、连字符、点、斜杠、加号、等号
+	highEntropyRegex = regexp.MustCompile(`[a-zA-Z0-9_\-\.\/+=:]{16,}`)
+	hexRegex         = regexp.MustCompile(`(?i)\b[a-f0-9]{32,}\b`)
+	longNumberRegex  = regexp.MustCompile(`\b\d{6,}\b`)
+	westernNameRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z'\-]+(?:\s+[A-Za-z][A-Za-z'\-]+)+$`)
+	jsonObjRegex     = regexp.MustCompile(`\{[^{}]*(?:\{[^{}]*\}[^{}]*)*\}`)
+)
+
+// defaultPatterns are compiled once and copied into every anonymizer
+var defaultPatterns = newDefaultPatterns()
+
 // NewAnonymizer creates a new anonymizer with default patterns
 func NewAnonymizer(config PrivacyConfig) *Anonymizer {
-	a := &Anonymizer{
+	return &Anonymizer{
 		mapping:        make(map[string]string),
 		reverseMapping: make(map[string]string),
 		config:         config,
+		patterns:       append([]AnonymizationPattern(nil), defaultPatterns...),
 	}
+}
 
-	// Initialize default patterns (ordered by priority)
-	a.patterns = []AnonymizationPattern{
+// keywordPattern builds a pattern whose matches start with one of keywords
+func keywordPattern(pattern AnonymizationPattern, keywords ...string) AnonymizationPattern {
+	pattern.keywords = keywords
+	pattern.anchored = anchoredRegex(pattern.Regex)
+	return pattern
+}
+
+// anchoredRegex returns re anchored at the start of the text
+func anchoredRegex(re *regexp.Regexp) *regexp.Regexp {
+	return regexp.MustCompile(`^(?:` + re.String() + `)`)
+}
+
+// tokenPattern builds a pattern that never matches whitespace. Only the tokens
+// accepted by accept are searched.
+func tokenPattern(pattern AnonymizationPattern, accept func(token string) bool) AnonymizationPattern {
+	pattern.windows = func(text string) []span {
+		return tokenSpans(text, accept)
+	}
+	return pattern
+}
+
+// newDefaultPatterns returns the default patterns ordered by priority
+func newDefaultPatterns() []AnonymizationPattern {
+	return []AnonymizationPattern{
 		// API Keys and Tokens (highest priority)
-		{
+		keywordPattern(AnonymizationPattern{
 			Name:        "Bearer Token",
 			Regex:       regexp.MustCompile(`(?i)bearer\s+([a-zA-Z0-9_\-\.]{20,})`),
 			Replacement: "BEARER_TOKEN_%s",
 			Priority:    100,
-		},
-		{
+		}, "bearer"),
+		keywordPattern(AnonymizationPattern{
 			Name:        "API Key",
 			Regex:       regexp.MustCompile(`(?i)(api[_-]?key|apikey|access[_-]?key|secret[_-]?key)[\s:=]+([a-zA-Z0-9_\-]{20,})`),
 			Replacement: "API_KEY_%s",
 			Priority:    95,
-		},
+		}, "api", "access", "secret"),
 		{
 			Name:        "JWT Token",
 			Regex:       regexp.MustCompile(`eyJ[a-zA-Z0-9_\-]+\.eyJ[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]+`),
@@ -86,68 +150,80 @@ func NewAnonymizer(config PrivacyConfig) *Anonymizer {
 		},
 
 		// Credentials
-		{
+		keywordPattern(AnonymizationPattern{
 			Name:        "Password",
 			Regex:       regexp.MustCompile(`(?i)(password|passwd|pwd)[\s:=]+([^\s,\)\"\']+)`),
 			Replacement: "PASSWORD_%s",
 			Priority:    70,
-		},
-		{
+		}, "password", "passwd", "pwd"),
+		keywordPattern(AnonymizationPattern{
 			Name:        "Username",
 			Regex:       regexp.MustCompile(`(?i)(username|user)[\s:=]+([^\s,\)\"\']+)`),
 			Replacement: "USERNAME_%s",
 			Priority:    65,
-		},
+		}, "user"),
 
 		// Network Information
-		{
+		tokenPattern(AnonymizationPattern{
 			Name:        "IPv4 Address",
 			Regex:       regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\b`),
 			Replacement: "IP_ADDRESS_%s",
 			Priority:    60,
-		},
-		{
+		}, func(token string) bool { return strings.Count(token, ".") >= 3 }),
+		tokenPattern(AnonymizationPattern{
 			Name:        "IPv6 Address",
 			Regex:       regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b`),
 			Replacement: "IPV6_ADDRESS_%s",
 			Priority:    59,
-		},
-		{
+		}, func(token string) bool { return strings.Count(token, ":") >= 7 }),
+		tokenPattern(AnonymizationPattern{
 			Name:        "MAC Address",
 			Regex:       regexp.MustCompile(`(?i)\b(?:[0-9a-f]{2}[:-]){5}[0-9a-f]{2}\b`),
 			Replacement: "MAC_ADDRESS_%s",
 			Priority:    58,
-		},
+		}, func(token string) bool { return strings.Count(token, ":")+strings.Count(token, "-") >= 5 }),
 
 		// Email and Contact
-		{
+		tokenPattern(AnonymizationPattern{
 			Name:        "Email",
 			Regex:       regexp.MustCompile(`\b[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}\b`),
 			Replacement: "EMAIL_%s",
 			Priority:    55,
-		},
+		}, func(token string) bool { return strings.IndexByte(token, '@') >= 0 }),
 		{
 			Name:        "Phone Number",
 			Regex:       regexp.MustCompile(`(?:\+?86)?[-\s]?1[3-9]\d{9}\b`),
 			Replacement: "PHONE_%s",
 			Priority:    50,
+			windows:     phoneNumberSpans,
 		},
 
 		// Database and Connection Strings
-		{
+		tokenPattern(AnonymizationPattern{
 			Name:        "Database Connection String",
 			Regex:       regexp.MustCompile(`(?i)(mongodb|mysql|postgresql|redis|postgres)://[^\s\)\"\']+`),
 			Replacement: "DB_CONNECTION_%s",
 			Priority:    45,
-		},
+		}, func(token string) bool {
+			if !strings.Contains(token, "://") {
+				return false
+			}
+			lower := asciiLower(token)
+			for _, scheme := range []string{"mongodb://", "mysql://", "postgres://", "postgresql://", "redis://"} {
+				if strings.Contains(lower, scheme) {
+					return true
+				}
+			}
+			return containsFoldRunes(token)
+		}),
 
 		// File Paths (Windows and Unix)
-		{
+		tokenPattern(AnonymizationPattern{
 			Name:        "Windows Path",
 			Regex:       regexp.MustCompile(`[a-zA-Z]:\\(?:[^\s\)\"\'<>|*?]+\\)*[^\s\)\"\'<>|*?]+`),
 			Replacement: "WIN_PATH_%s",
 			Priority:    40,
-		},
+		}, func(token string) bool { return strings.Contains(token, `:\`) }),
 		{
 			Name:        "Unix Path",
 			Regex:       regexp.MustCompile(`/(?:home|root|usr|var|etc|opt)/[^\s\)\"\'<>]+`),
@@ -156,29 +232,29 @@ func NewAnonymizer(config PrivacyConfig) *Anonymizer {
 		},
 
 		// AWS and Cloud Credentials
-		{
+		tokenPattern(AnonymizationPattern{
 			Name:        "AWS Access Key",
 			Regex:       regexp.MustCompile(`(?i)AKIA[0-9A-Z]{16}`),
 			Replacement: "AWS_ACCESS_KEY_%s",
 			Priority:    35,
-		},
-		{
+		}, func(token string) bool {
+			return len(token) >= 20 && (strings.Contains(asciiLower(token), "akia") || containsFoldRunes(token))
+		}),
+		keywordPattern(AnonymizationPattern{
 			Name:        "AWS Secret Key",
 			Regex:       regexp.MustCompile(`(?i)aws[_-]?secret[_-]?access[_-]?key[\s:=]+([a-zA-Z0-9/+=]{40})`),
 			Replacement: "AWS_SECRET_KEY_%s",
 			Priority:    34,
-		},
+		}, "aws"),
 
 		// Generic Secrets
-		{
+		keywordPattern(AnonymizationPattern{
 			Name:        "Generic Secret",
 			Regex:       regexp.MustCompile(`(?i)(secret|token|key)[\s:=]+([a-zA-Z0-9_\-]{16,})`),
 			Replacement: "SECRET_%s",
 			Priority:    30,
-		},
+		}, "secret", "token", "key"),
 	}
-
-	return a
 }
 
 // generatePlaceholder creates a consistent placeholder for a value
@@ -205,48 +281,50 @@ func (a *Anonymizer) Anonymize(text string) string {
 	result = a.anonymizeKeyValuePairs(result)
 
 	// Step 2: Process regex patterns in priority order (highest first)
+	result = a.replacePatterns(result, true)
+
+	// Step 3: Anonymize high-entropy strings (likely tokens/keys)
+	result = a.anonymizeHighEntropyStrings(result)
+
+	// Step 4: Anonymize generic identifiers (hex strings, long numbers)
+	result = a.anonymizeGenericIdentifiers(result)
+
+	return result
+}
+
+// replacePatterns replaces the matches of the enabled patterns with
+// placeholders. With remember the originals are kept so Deanonymize can
+// restore them. Must be called with a.mu held.
+func (a *Anonymizer) replacePatterns(text string, remember bool) string {
 	for _, pattern := range a.patterns {
 		if !a.isPatternEnabled(pattern) {
 			continue
 		}
-		matches := pattern.Regex.FindAllStringSubmatch(result, -1)
-
-		for _, match := range matches {
-			if len(match) == 0 {
-				continue
-			}
 
-			original := match[0]
+		replacements := make(map[string]string)
+		for _, loc := range findPatternMatches(pattern, text) {
+			original := text[loc[0]:loc[1]]
 
 			// Avoid creating nested placeholders by matching already-anonymized content.
 			if placeholderTokenRegex.MatchString(original) {
 				continue
 			}
 
-			// Skip if already anonymized
-			if _, exists := a.reverseMapping[original]; exists {
-				continue
+			// Reuse the placeholder of values anonymized earlier, e.g. in a
+			// previous message of the same request
+			placeholder, exists := a.reverseMapping[original]
+			if !exists {
+				placeholder = a.generatePlaceholder(pattern.Replacement, original)
+				if remember {
+					a.mapping[placeholder] = original
+					a.reverseMapping[original] = placeholder
+				}
 			}
-
-			// Generate placeholder
-			placeholder := a.generatePlaceholder(pattern.Replacement, original)
-
-			// Store mapping
-			a.mapping[placeholder] = original
-			a.reverseMapping[original] = placeholder
-
-			// Replace in text
-			result = strings.ReplaceAll(result, original, placeholder)
+			replacements[original] = placeholder
 		}
+		text = replaceAll(text, replacements)
 	}
-
-	// Step 3: Anonymize high-entropy strings (likely tokens/keys)
-	result = a.anonymizeHighEntropyStrings(result)
-
-	// Step 4: Anonymize generic identifiers (hex strings, long numbers)
-	result = a.anonymizeGenericIdentifiers(result)
-
-	return result
+	return text
 }
 
 // Deanonymize restores original sensitive information in the text
@@ -369,7 +447,6 @@ func (a *Anonymizer) anonymizeJSON(text string) string {
 
 	// Try to find embedded JSON objects
 	result := text
-	jsonObjRegex := regexp.MustCompile(`\{[^{}]*(?:\{[^{}]*\}[^{}]*)*\}`)
 	matches := jsonObjRegex.FindAllString(text, -1)
 
 	for _, match := range matches {
@@ -475,184 +552,115 @@ func (a *Anonymizer) anonymizeKeyValuePairs(text string) string {
 	result := text
 
 	// Pattern 1: "key": "value" (double quotes) - any length
-	kvRegex1 := regexp.MustCompile(`"([\w\-]+)"\s*:\s*"([^"]+)"`)
-	matches := kvRegex1.FindAllStringSubmatch(result, -1)
-	for _, match := range matches {
-		if len(match) >= 3 {
-			key := match[1]
-			value := match[2]
-			lowerKey := strings.ToLower(key)
-			
-			// Check if it's a sensitive key or looks like a personal name
-			shouldAnonymize := false
-			var prefix string
-			
-			if a.isSensitiveKey(lowerKey) {
-				shouldAnonymize = true
-				prefix = "KV_" + strings.ToUpper(key)
-			} else if a.isNameKey(lowerKey) && a.looksLikePersonalName(value) {
-				shouldAnonymize = true
-				prefix = "KV_" + strings.ToUpper(key)
-			}
-			
-			if shouldAnonymize {
-				placeholder := a.anonymizeValue(value, prefix)
-				result = strings.Replace(result, match[0],
-					`"`+key+`": "`+placeholder+`"`, 1)
-			}
+	matches := kvDoubleQuotedRegex.FindAllStringSubmatchIndex(result, -1)
+	result = replaceSubmatches(result, matches, func(match []string) string {
+		key, value := match[1], match[2]
+		if !a.shouldAnonymizeKeyValue(key, value) {
+			return match[0]
 		}
-	}
+		placeholder := a.anonymizeValue(value, "KV_"+strings.ToUpper(key))
+		return `"` + key + `": "` + placeholder + `"`
+	})
 
 	// Pattern 2: 'key': 'value' (single quotes) - any length
-	kvRegex2 := regexp.MustCompile(`'([\w\-]+)'\s*:\s*'([^']+)'`)
-	matches = kvRegex2.FindAllStringSubmatch(result, -1)
-	for _, match := range matches {
-		if len(match) >= 3 {
-			key := match[1]
-			value := match[2]
-			lowerKey := strings.ToLower(key)
-			
-			shouldAnonymize := false
-			var prefix string
-			
-			if a.isSensitiveKey(lowerKey) {
-				shouldAnonymize = true
-				prefix = "KV_" + strings.ToUpper(key)
-			} else if a.isNameKey(lowerKey) && a.looksLikePersonalName(value) {
-				shouldAnonymize = true
-				prefix = "KV_" + strings.ToUpper(key)
-			}
-			
-			if shouldAnonymize {
-				placeholder := a.anonymizeValue(value, prefix)
-				result = strings.Replace(result, match[0],
-					`'`+key+`': '`+placeholder+`'`, 1)
-			}
+	matches = kvSingleQuotedRegex.FindAllStringSubmatchIndex(result, -1)
+	result = replaceSubmatches(result, matches, func(match []string) string {
+		key, value := match[1], match[2]
+		if !a.shouldAnonymizeKeyValue(key, value) {
+			return match[0]
 		}
-	}
+		placeholder := a.anonymizeValue(value, "KV_"+strings.ToUpper(key))
+		return `'` + key + `': '` + placeholder + `'`
+	})
 
 	// Pattern 3: key: 'value' (unquoted key, quoted value in JS objects)
-	kvRegex3 := regexp.MustCompile(`\b([a-zA-Z][\w\-]*)\s*:\s*'([^']+)'`)
-	matches = kvRegex3.FindAllStringSubmatch(result, -1)
-	for _, match := range matches {
-		if len(match) >= 3 {
-			key := match[1]
-			value := match[2]
-			lowerKey := strings.ToLower(key)
-			
-			// Skip if this is already part of a quoted key pattern
-			if strings.Contains(match[0], `'`+key+`'`) {
-				continue
-			}
-			
-			shouldAnonymize := false
-			var prefix string
-			
-			if a.isSensitiveKey(lowerKey) {
-				shouldAnonymize = true
-				prefix = "KV_" + strings.ToUpper(key)
-			} else if a.isNameKey(lowerKey) && a.looksLikePersonalName(value) {
-				shouldAnonymize = true
-				prefix = "KV_" + strings.ToUpper(key)
-			}
-			
-			if shouldAnonymize {
-				placeholder := a.anonymizeValue(value, prefix)
-				result = strings.Replace(result, match[0],
-					key+`: '`+placeholder+`'`, 1)
-			}
-		}
+	matches = nil
+	if strings.Contains(result, "'") {
+		matches = kvJSObjectRegex.FindAllStringSubmatchIndex(result, -1)
 	}
+	result = replaceSubmatches(result, matches, func(match []string) string {
+		key, value := match[1], match[2]
+		// Skip if this is already part of a quoted key pattern
+		if strings.Contains(match[0], `'`+key+`'`) || !a.shouldAnonymizeKeyValue(key, value) {
+			return match[0]
+		}
+		placeholder := a.anonymizeValue(value, "KV_"+strings.ToUpper(key))
+		return key + `: '` + placeholder + `'`
+	})
 
 	// Pattern 4: key: value (without quotes in YAML/config style)
-	kvRegex4 := regexp.MustCompile(`(?m)^[\s]*([a-zA-Z][\w\-]*)\s*:\s*([^\s,\n'"]{10,})`)
-	matches = kvRegex4.FindAllStringSubmatch(result, -1)
-	for _, match := range matches {
-		if len(match) >= 3 {
-			key := match[1]
-			value := match[2]
-			if a.isSensitiveKey(strings.ToLower(key)) {
-				placeholder := a.anonymizeValue(value, "KV_"+strings.ToUpper(key))
-				result = strings.Replace(result, match[0],
-					strings.Replace(match[0], value, placeholder, 1), 1)
-			}
+	matches = findAnchoredMatches(kvYAMLLineRegex, result, lineStarts(result))
+	result = replaceSubmatches(result, matches, func(match []string) string {
+		key, value := match[1], match[2]
+		if !a.isSensitiveKey(strings.ToLower(key)) {
+			return match[0]
 		}
-	}
+		placeholder := a.anonymizeValue(value, "KV_"+strings.ToUpper(key))
+		return strings.Replace(match[0], value, placeholder, 1)
+	})
 
 	// Pattern 5: key=value
-	kvRegex5 := regexp.MustCompile(`([a-zA-Z][\w\-]*)=([^\s&,;'"]{10,})`)
-	matches = kvRegex5.FindAllStringSubmatch(result, -1)
-	for _, match := range matches {
-		if len(match) >= 3 {
-			key := match[1]
-			value := match[2]
-			if a.isSensitiveKey(strings.ToLower(key)) {
-				placeholder := a.anonymizeValue(value, "KV_"+strings.ToUpper(key))
-				result = strings.Replace(result, match[0], key+"="+placeholder, 1)
-			}
+	matches = findTokenMatches(kvAssignRegex, result, func(token string) bool {
+		return strings.IndexByte(token, '=') >= 0
+	})
+	result = replaceSubmatches(result, matches, func(match []string) string {
+		key, value := match[1], match[2]
+		if !a.isSensitiveKey(strings.ToLower(key)) {
+			return match[0]
 		}
-	}
+		return key + "=" + a.anonymizeValue(value, "KV_"+strings.ToUpper(key))
+	})
 
 	return result
 }
 
+// shouldAnonymizeKeyValue checks if a quoted value should be anonymized:
+// either its key is sensitive or it looks like a personal name
+func (a *Anonymizer) shouldAnonymizeKeyValue(key, value string) bool {
+	lowerKey := strings.ToLower(key)
+	return a.isSensitiveKey(lowerKey) || (a.isNameKey(lowerKey) && a.looksLikePersonalName(value))
+}
+
 // anonymizeHighEntropyStrings detects and anonymizes high-entropy strings (likely tokens)
 func (a *Anonymizer) anonymizeHighEntropyStrings(text string) string {
-	result := text
-
 	// Find long alphanumeric strings with high entropy
 	// Pattern: strings with 20+ chars containing mixed case, numbers, special chars
-	// 支持更多特殊字符：下划线、连字符、点、斜杠、加号、等号
-	highEntropyRegex := regexp.MustCompile(`[a-zA-Z0-9_\-\.\/+=:]{16,}`)
-	matches := highEntropyRegex.FindAllString(result, -1)
+	replacements := make(map[string]string)
+	for _, loc := range findTokenMatches(highEntropyRegex, text, runOf(16, isHighEntropyByte)) {
+		match := text[loc[0]:loc[1]]
 
-	for _, match := range matches {
-		// Skip if already anonymized
-		if _, exists := a.reverseMapping[match]; exists {
+		// Values anonymized earlier keep their placeholder
+		if placeholder, exists := a.reverseMapping[match]; exists {
+			replacements[match] = placeholder
 			continue
 		}
 
 		// 降低熵值要求，使用更智能的检测
 		if a.looksLikeSensitiveValue(match) {
-			placeholder := a.anonymizeValue(match, "HIGH_ENTROPY_TOKEN")
-			result = strings.ReplaceAll(result, match, placeholder)
+			replacements[match] = a.anonymizeValue(match, "HIGH_ENTROPY_TOKEN")
 		}
 	}
 
-	return result
+	return replaceAll(text, replacements)
 }
 
 func (a *Anonymizer) anonymizeGenericIdentifiers(text string) string {
-	result := text
-
-	hexRegex := regexp.MustCompile(`(?i)\b[a-f0-9]{32,}\b`)
-	longNumberRegex := regexp.MustCompile(`\b\d{6,}\b`)
-
-	hexMatches := hexRegex.FindAllString(result, -1)
-	for _, match := range hexMatches {
-		if _, exists := a.reverseMapping[match]; exists {
-			continue
-		}
-		if a.isPlaceholder(match) {
-			continue
-		}
-		placeholder := a.anonymizeValue(match, "HEX")
-		result = strings.ReplaceAll(result, match, placeholder)
-	}
+	result := a.anonymizeIdentifiers(text, hexRegex, runOf(32, isHexDigit), "HEX")
+	return a.anonymizeIdentifiers(result, longNumberRegex, runOf(6, isDigit), "NUMBER")
+}
 
-	numberMatches := longNumberRegex.FindAllString(result, -1)
-	for _, match := range numberMatches {
-		if _, exists := a.reverseMapping[match]; exists {
-			continue
-		}
+// anonymizeIdentifiers replaces every match of re in the tokens accepted by
+// accept with a placeholder
+func (a *Anonymizer) anonymizeIdentifiers(text string, re *regexp.Regexp, accept func(token string) bool, prefix string) string {
+	replacements := make(map[string]string)
+	for _, loc := range findTokenMatches(re, text, accept) {
+		match := text[loc[0]:loc[1]]
 		if a.isPlaceholder(match) {
 			continue
 		}
-		placeholder := a.anonymizeValue(match, "NUMBER")
-		result = strings.ReplaceAll(result, match, placeholder)
+		replacements[match] = a.anonymizeValue(match, prefix)
 	}
-
-	return result
+	return replaceAll(text, replacements)
 }
 
 // calculateEntropy calculates Shannon entropy of a string
@@ -661,20 +669,36 @@ func (a *Anonymizer) calculateEntropy(s string) float64 {
 		return 0
 	}
 
-	freq := make(map[rune]int)
+	// Count ASCII characters in an array, it is called for every token
+	var ascii [utf8.RuneSelf]int
+	var freq map[rune]int
+	runes := 0
 	for _, c := range s {
+		runes++
+		if c < utf8.RuneSelf {
+			ascii[c]++
+			continue
+		}
+		if freq == nil {
+			freq = make(map[rune]int)
+		}
 		freq[c]++
 	}
 
 	var entropy float64
-	length := float64(len([]rune(s)))
-
-	for _, count := range freq {
+	length := float64(runes)
+	addEntropy := func(count int) {
 		p := float64(count) / length
 		if p > 0 {
 			entropy -= p * math.Log2(p)
 		}
 	}
+	for _, count := range ascii {
+		addEntropy(count)
+	}
+	for _, count := range freq {
+		addEntropy(count)
+	}
 
 	return entropy
 }
@@ -804,7 +828,6 @@ func (a *Anonymizer) looksLikePersonalName(value string) bool {
 
 	// Western name heuristic: 2+ words with letters, apostrophes, hyphens.
 	if len(trimmed) <= 60 && strings.Contains(trimmed, " ") {
-		westernNameRegex := regexp.MustCompile(`^[A-Za-z][A-Za-z'\-]+(?:\s+[A-Za-z][A-Za-z'\-]+)+$`)
 		return westernNameRegex.MatchString(trimmed)
 	}
 
@@ -888,6 +911,255 @@ func (a *Anonymizer) isPlaceholder(value string) bool {
 	}
 
 	// Check if it matches placeholder pattern (PREFIX_hash)
-	placeholderPattern := regexp.MustCompile(`^[A-Z_]+_[0-9a-f]{8}$`)
 	return placeholderPattern.MatchString(value)
 }
+
+// containsFoldRunes reports whether text contains the Kelvin sign or the long
+// s. Case-insensitive regexps match them as 'k' and 's' but the ASCII search
+// of keywordStarts does not.
+func containsFoldRunes(text string) bool {
+	return strings.Contains(text, "\u212a") || strings.Contains(text, "\u017f")
+}
+
+// findPatternMatches returns the submatch indexes of all matches of pattern in
+// text. Go's regexp has no DFA, so scanning a long message with every pattern
+// is slow; the hints of the default patterns narrow the scan down to the parts
+// of the text that can match. The matches are the same as a full scan.
+func findPatternMatches(pattern AnonymizationPattern, text string) [][]int {
+	switch {
+	case pattern.windows != nil:
+		return findSpanMatches(pattern.Regex, text, pattern.windows(text))
+	case pattern.keywords != nil && !containsFoldRunes(text):
+		return findAnchoredMatches(pattern.anchored, text, keywordStarts(text, pattern.keywords))
+	default:
+		return pattern.Regex.FindAllStringSubmatchIndex(text, -1)
+	}
+}
+
+// findTokenMatches matches re against every whitespace-separated token of text
+// accepted by accept. re must not match whitespace (\s) nor use ^ or $.
+func findTokenMatches(re *regexp.Regexp, text string, accept func(token string) bool) [][]int {
+	return findSpanMatches(re, text, tokenSpans(text, accept))
+}
+
+// findSpanMatches matches re within each of spans, which are in order. A span
+// overlapping the previous match only starts after it, like a full scan would.
+func findSpanMatches(re *regexp.Regexp, text string, spans []span) [][]int {
+	var matches [][]int
+	end := 0
+	for _, s := range spans {
+		start := s.start
+		if start < end {
+			start = end
+		}
+		if start >= s.end {
+			continue
+		}
+
+		for _, loc := range re.FindAllStringSubmatchIndex(text[start:s.end], -1) {
+			for i := range loc {
+				if loc[i] >= 0 {
+					loc[i] += start
+				}
+			}
+			matches = append(matches, loc)
+			end = loc[1]
+		}
+	}
+	return matches
+}
+
+// tokenSpans returns the whitespace-separated tokens of text accepted by accept
+func tokenSpans(text string, accept func(token string) bool) []span {
+	var spans []span
+	for start := 0; start < len(text); {
+		if isRegexpSpace(text[start]) {
+			start++
+			continue
+		}
+		end := start + 1
+		for end < len(text) && !isRegexpSpace(text[end]) {
+			end++
+		}
+
+		if accept(text[start:end]) {
+			spans = append(spans, span{start, end})
+		}
+		start = end
+	}
+	return spans
+}
+
+// phoneNumberSpans returns where phone numbers can be: a phone number ends with
+// the last 11 digits of a run of digits and is at most 15 bytes long. The byte
+// after the run is included for the \b check.
+func phoneNumberSpans(text string) []span {
+	var spans []span
+	for i := 0; i < len(text); {
+		if !isDigit(text[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(text) && isDigit(text[i]) {
+			i++
+		}
+
+		if i-start >= 11 {
+			spans = append(spans, span{max(i-15, 0), min(i+1, len(text))})
+		}
+	}
+	return spans
+}
+
+// runOf accepts tokens with at least n consecutive bytes of class
+func runOf(n int, class func(byte) bool) func(string) bool {
+	return func(token string) bool {
+		run := 0
+		for i := 0; i < len(token); i++ {
+			if !class(token[i]) {
+				run = 0
+				continue
+			}
+			if run++; run >= n {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// isDigit reports whether b is matched by \d
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// isHighEntropyByte reports whether b is in the character class of highEntropyRegex
+func isHighEntropyByte(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || isDigit(b) || strings.IndexByte("_-./+=:", b) >= 0
+}
+
+// isHexDigit reports whether b is a hexadecimal digit
+func isHexDigit(b byte) bool {
+	return isDigit(b) || ('a' <= b && b <= 'f') || ('A' <= b && b <= 'F')
+}
+
+// isRegexpSpace reports whether b is matched by \s
+func isRegexpSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\f' || b == '\r'
+}
+
+// keywordStarts returns the sorted offsets of all occurrences of keywords in
+// text, ignoring ASCII case
+func keywordStarts(text string, keywords []string) []int {
+	lower := asciiLower(text)
+	var starts []int
+	for _, keyword := range keywords {
+		for offset := 0; ; {
+			i := strings.Index(lower[offset:], keyword)
+			if i < 0 {
+				break
+			}
+			starts = append(starts, offset+i)
+			offset += i + 1
+		}
+	}
+	sort.Ints(starts)
+	return starts
+}
+
+// lineStarts returns the offsets of the lines of text
+func lineStarts(text string) []int {
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// findAnchoredMatches tries anchored at each of the sorted starts. It finds
+// the same matches as a full scan when every match begins at one of them.
+func findAnchoredMatches(anchored *regexp.Regexp, text string, starts []int) [][]int {
+	var matches [][]int
+	end := 0
+	for _, start := range starts {
+		if start < end {
+			continue
+		}
+		loc := anchored.FindStringSubmatchIndex(text[start:])
+		if loc == nil {
+			continue
+		}
+		for i := range loc {
+			if loc[i] >= 0 {
+				loc[i] += start
+			}
+		}
+		matches = append(matches, loc)
+		end = loc[1]
+	}
+	return matches
+}
+
+// asciiLower lowercases the ASCII letters of s, keeping byte offsets intact
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// replaceAll replaces every occurrence of the keys of replacements in a single
+// pass. At the same position longer keys win over shorter ones.
+func replaceAll(text string, replacements map[string]string) string {
+	if len(replacements) == 0 {
+		return text
+	}
+
+	originals := make([]string, 0, len(replacements))
+	for original := range replacements {
+		originals = append(originals, original)
+	}
+	sort.Slice(originals, func(i, j int) bool {
+		if len(originals[i]) != len(originals[j]) {
+			return len(originals[i]) > len(originals[j])
+		}
+		return originals[i] < originals[j]
+	})
+
+	pairs := make([]string, 0, 2*len(originals))
+	for _, original := range originals {
+		pairs = append(pairs, original, replacements[original])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// replaceSubmatches rebuilds text with every match located by locs replaced
+// by what replace returns for its submatches
+func replaceSubmatches(text string, locs [][]int, replace func(match []string) string) string {
+	if len(locs) == 0 {
+		return text
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(text))
+	last := 0
+	for _, loc := range locs {
+		match := make([]string, len(loc)/2)
+		for i := range match {
+			if loc[2*i] >= 0 {
+				match[i] = text[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		sb.WriteString(text[last:loc[0]])
+		sb.WriteString(replace(match))
+		last = loc[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
@@ -0,0 +1,781 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+	_ "embed"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// TextAnonymizer is implemented by Anonymizer and StructuredAnonymizer
+type TextAnonymizer interface {
+	Anonymize(text string) string
+	Deanonymize(text string) string
+	Clear()
+	UpdateConfig(config PrivacyConfig)
+	SetEnabled(enabled bool)
+	IsEnabled() bool
+	GetMappingCount() int
+}
+
+// NewTextAnonymizer returns the anonymizer selected by
+// config.StructuredAnonymizer
+func NewTextAnonymizer(config PrivacyConfig) TextAnonymizer {
+	if config.StructuredAnonymizer {
+		return NewStructuredAnonymizer(config)
+	}
+	return NewAnonymizer(config)
+}
+
+// piiTrainingData holds the labeled tokens the decision tree is trained on,
+// one "token,previous,label" line each. previous is the token before it in
+// the text, label is 1 for sensitive tokens.
+//
+//go:embed dict/pii_tokens.csv
+var piiTrainingData []byte
+
+// StructuredAnonymizer replaces sensitive tokens with placeholders. Instead of
+// running every regex over the whole text it splits the text into tokens once
+// and classifies each token: structured values (URLs, emails, IPs, paths) are
+// recognized by their syntax, everything else by a decision tree over the
+// token's features. Natural language is left alone because dictionary words
+// and low-entropy tokens fall on the "not sensitive" side of the tree.
+type StructuredAnonymizer struct {
+	mu             sync.RWMutex
+	mapping        map[string]string // anonymized -> original
+	reverseMapping map[string]string // original -> anonymized
+	config         PrivacyConfig
+}
+
+// NewStructuredAnonymizer creates a structured anonymizer. The classifier is
+// trained once, on first use.
+func NewStructuredAnonymizer(config PrivacyConfig) *StructuredAnonymizer {
+	return &StructuredAnonymizer{
+		mapping:        make(map[string]string),
+		reverseMapping: make(map[string]string),
+		config:         config,
+	}
+}
+
+// Anonymize replaces sensitive tokens in the text
+func (s *StructuredAnonymizer) Anonymize(text string) string {
+	if text == "" {
+		return text
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.config.AnonymizeSensitiveData {
+		return text
+	}
+
+	model := tokenModel()
+	var sb strings.Builder
+	last := 0
+	prev := ""
+	for _, tok := range splitPIITokens(text) {
+		value := text[tok.start:tok.end]
+		if prefix := s.classify(model, value, prev); prefix != "" {
+			sb.WriteString(text[last:tok.start])
+			sb.WriteString(s.placeholderFor(prefix, value))
+			last = tok.end
+		}
+		prev = value
+	}
+	if last == 0 {
+		return text
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// Deanonymize restores the original values of the placeholders in the text
+func (s *StructuredAnonymizer) Deanonymize(text string) string {
+	if text == "" {
+		return text
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.mapping) == 0 {
+		return text
+	}
+	return placeholderTokenRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if original, ok := s.mapping[placeholder]; ok {
+			return original
+		}
+		return placeholder
+	})
+}
+
+// Clear clears all stored mappings
+func (s *StructuredAnonymizer) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mapping = make(map[string]string)
+	s.reverseMapping = make(map[string]string)
+}
+
+// UpdateConfig updates the anonymizer configuration at runtime
+func (s *StructuredAnonymizer) UpdateConfig(config PrivacyConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+}
+
+// SetEnabled enables or disables anonymization
+func (s *StructuredAnonymizer) SetEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.AnonymizeSensitiveData = enabled
+}
+
+// IsEnabled returns whether anonymization is enabled
+func (s *StructuredAnonymizer) IsEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.AnonymizeSensitiveData
+}
+
+// GetMappingCount returns the number of anonymized values
+func (s *StructuredAnonymizer) GetMappingCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.mapping)
+}
+
+// placeholderFor returns the placeholder of value, creating it on first use.
+// s.mu must be held.
+func (s *StructuredAnonymizer) placeholderFor(prefix, value string) string {
+	if placeholder, ok := s.reverseMapping[value]; ok {
+		return placeholder
+	}
+	hash := md5.Sum([]byte(value))
+	placeholder := prefix + "_" + hex.EncodeToString(hash[:])[:8]
+	s.mapping[placeholder] = value
+	s.reverseMapping[value] = placeholder
+	return placeholder
+}
+
+// classify returns the placeholder prefix for a sensitive token, or "" to
+// keep it. s.mu must be held.
+func (s *StructuredAnonymizer) classify(model *piiModel, token, prev string) string {
+	if _, ok := s.mapping[token]; ok || placeholderPattern.MatchString(token) {
+		return ""
+	}
+
+	// Values with a syntax of their own are recognized by it and only
+	// anonymized if their category is enabled
+	if category, enabled := s.structuredCategory(token); category != "" {
+		if enabled {
+			return category
+		}
+		return ""
+	}
+
+	if !model.isSensitive(token, prev) {
+		return ""
+	}
+	switch {
+	case isDigitString(token):
+		if model.bloom.contains(shapeKey(token)) && len(token) <= 14 {
+			return "PHONE"
+		}
+		return "NUMBER"
+	case len(token) >= 32 && isHexString(token):
+		return "HEX"
+	case !s.config.AnonymizeAPIKeys:
+		return ""
+	case isSecretKeyword(prev):
+		return "SECRET"
+	default:
+		return "HIGH_ENTROPY_TOKEN"
+	}
+}
+
+// structuredCategory recognizes URLs, emails, addresses and paths. It returns
+// the placeholder prefix and whether the category is enabled, or "" if token
+// has none of those syntaxes.
+func (s *StructuredAnonymizer) structuredCategory(token string) (string, bool) {
+	c := s.config
+	if scheme, rest, ok := strings.Cut(token, "://"); ok && scheme != "" && rest != "" {
+		authority, _, _ := strings.Cut(rest, "/")
+		switch strings.ToLower(scheme) {
+		case "postgres", "postgresql", "mysql", "mongodb", "mongodb+srv", "redis", "amqp":
+			return "DB_CONNECTION", c.AnonymizeURLs
+		}
+		if strings.Contains(authority, "@") {
+			return "URL_WITH_AUTH", c.AnonymizeURLs
+		}
+		return "URL", c.AnonymizeURLs
+	}
+	if strings.HasPrefix(token, "eyJ") && strings.Count(token, ".") == 2 {
+		return "JWT_TOKEN", c.AnonymizeAPIKeys
+	}
+	if len(token) == 20 && (strings.HasPrefix(token, "AKIA") || strings.HasPrefix(token, "ASIA")) && isUpperAlnum(token[4:]) {
+		return "AWS_ACCESS_KEY", c.AnonymizeAPIKeys
+	}
+	if local, domain, ok := strings.Cut(token, "@"); ok && local != "" && strings.Contains(domain, ".") && !strings.ContainsAny(domain, "@/") {
+		return "EMAIL", c.AnonymizeEmails
+	}
+	if strings.ContainsAny(token, ".:") {
+		if len(token) == 17 && strings.Count(token, ":") == 5 {
+			if _, err := net.ParseMAC(token); err == nil {
+				return "MAC_ADDRESS", c.AnonymizeIPAddresses
+			}
+		}
+		if ip := net.ParseIP(token); ip != nil {
+			if ip.To4() != nil && strings.Count(token, ".") == 3 {
+				return "IP_ADDRESS", c.AnonymizeIPAddresses
+			}
+			if strings.Count(token, ":") >= 2 {
+				return "IPV6_ADDRESS", c.AnonymizeIPAddresses
+			}
+		}
+	}
+	if len(token) > 1 && token[0] == '/' && strings.Count(token, "/") >= 2 {
+		return "UNIX_PATH", c.AnonymizeFilePaths
+	}
+	if len(token) > 3 && isASCIILetter(token[0]) && token[1] == ':' && token[2] == '\\' {
+		return "WIN_PATH", c.AnonymizeFilePaths
+	}
+	return "", false
+}
+
+// piiToken is the byte range of a token in the text
+type piiToken struct {
+	start, end int
+}
+
+// splitPIITokens splits text into tokens at whitespace, brackets, quotes and
+// other separators. "=" separates a key from its value except inside URLs.
+// Trailing sentence punctuation is not part of a token. Non-ASCII characters
+// separate tokens too, so a key directly after Chinese text is found.
+func splitPIITokens(text string) []piiToken {
+	var tokens []piiToken
+	add := func(start, end int) {
+		for end > start && strings.IndexByte(".:!?", text[end-1]) >= 0 {
+			end--
+		}
+		if end > start {
+			tokens = append(tokens, piiToken{start, end})
+		}
+	}
+	emit := func(start, end int) {
+		word := text[start:end]
+		if strings.IndexByte(word, '=') < 0 || strings.Contains(word, "://") {
+			add(start, end)
+			return
+		}
+		for {
+			i := strings.IndexByte(text[start:end], '=')
+			if i < 0 {
+				add(start, end)
+				return
+			}
+			add(start, start+i)
+			start += i + 1
+		}
+	}
+
+	start := -1
+	for i := 0; i < len(text); i++ {
+		if isPIISeparator(text[i]) {
+			if start >= 0 {
+				emit(start, i)
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		emit(start, len(text))
+	}
+	return tokens
+}
+
+// isPIISeparator reports whether a byte separates tokens. Every byte of a
+// multi-byte character does.
+func isPIISeparator(c byte) bool {
+	if c >= utf8.RuneSelf {
+		return true
+	}
+	switch c {
+	case ' ', '\t', '\n', '\r', '\f', '\v', '"', '\'', '`', '(', ')', '[', ']', '{', '}', '<', '>', ',', ';', '|':
+		return true
+	}
+	return false
+}
+
+// Token features of the decision tree
+const (
+	featLength = iota
+	featEntropy
+	featDigits
+	featUpper
+	featLower
+	featSymbols
+	featVowels
+	featCaseChanges
+	featClasses
+	featWords
+	featAfterSecretKey
+	featKnownPattern
+	numTokenFeatures
+)
+
+// tokenFeatures are the features of a token, see the feat* constants
+type tokenFeatures [numTokenFeatures]float64
+
+// piiModel is the trained classifier shared by all structured anonymizers
+type piiModel struct {
+	bloom *bloomFilter
+	tree  *decisionNode
+	words *SpellChecker
+}
+
+var (
+	piiModelOnce sync.Once
+	sharedModel  *piiModel
+)
+
+// tokenModel returns the classifier, training it on first use
+func tokenModel() *piiModel {
+	piiModelOnce.Do(func() {
+		model, err := trainPIIModel(piiTrainingData)
+		if err != nil {
+			// The training data is embedded, so this is a programming error
+			panic(fmt.Sprintf("failed to train PII model: %v", err))
+		}
+		sharedModel = model
+	})
+	return sharedModel
+}
+
+// knownSecretPrefixes start the keys of common services
+var knownSecretPrefixes = []string{
+	"sk-", "sk-proj-", "sk-ant-", "sk_live_", "sk_test_", "pk_live_", "pk_test_",
+	"rk_live_", "rk_test_", "ghp_", "gho_", "ghu_", "ghs_", "ghr_", "github_pat_",
+	"glpat-", "xoxb-", "xoxp-", "xoxa-", "xapp-", "AIza", "ya29.", "npm_", "SG.",
+	"whsec_", "hf_", "AKIA", "ASIA", "eyJ",
+}
+
+// knownPIIShapes are the shapes (see tokenShape) of phone, card and ID numbers
+var knownPIIShapes = []string{
+	"d11", "+d11", "+d12", "+d13", "d3-d4-d4", "d3-d3-d4", "+d2-d3-d4-d4",
+	"d15", "d16", "d17", "d18", "d19", "d17a1", "d4-d4-d4-d4",
+}
+
+// trainPIIModel builds the bloom filter of known patterns and trains the
+// decision tree on the labeled tokens of a "token,previous,label" CSV
+func trainPIIModel(data []byte) (*piiModel, error) {
+	model := &piiModel{
+		bloom: newBloomFilter(1024, 4),
+		words: NewSpellChecker(true),
+	}
+	for _, prefix := range knownSecretPrefixes {
+		model.bloom.add("^" + prefix)
+	}
+	for _, shape := range knownPIIShapes {
+		model.bloom.add("#" + shape)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read training data: %w", err)
+	}
+	var samples []treeSample
+	for i, record := range records {
+		if i == 0 || len(record) != 3 {
+			continue // header
+		}
+		label, err := strconv.Atoi(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid label on line %d: %w", i+1, err)
+		}
+		samples = append(samples, treeSample{
+			features:  model.features(record[0], record[1]),
+			sensitive: label == 1,
+		})
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no training samples")
+	}
+	model.tree = buildDecisionTree(samples, 0)
+	return model, nil
+}
+
+// isSensitive classifies a token that has no structured syntax
+func (m *piiModel) isSensitive(token, prev string) bool {
+	// Short tokens are only secrets as the value of a key
+	if len(token) < 6 && !(len(token) >= 4 && isSecretKeyword(prev)) {
+		return false
+	}
+	return m.tree.classify(m.features(token, prev))
+}
+
+// features computes the features of a token in a single pass
+func (m *piiModel) features(token, prev string) tokenFeatures {
+	var f tokenFeatures
+	var digits, upper, lower, symbols, vowels, changes int
+	lastCase := 0 // 1 lower, 2 upper
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c >= 'A' && c <= 'Z':
+			upper++
+			if lastCase == 1 {
+				changes++
+			}
+			lastCase = 2
+		case c >= 'a' && c <= 'z':
+			lower++
+			if lastCase == 2 {
+				changes++
+			}
+			lastCase = 1
+			if strings.IndexByte("aeiou", c) >= 0 {
+				vowels++
+			}
+		default:
+			symbols++
+		}
+	}
+	n := float64(len(token))
+	f[featLength] = n
+	f[featEntropy] = shannonEntropy(token)
+	f[featDigits] = float64(digits) / n
+	f[featUpper] = float64(upper) / n
+	f[featLower] = float64(lower) / n
+	f[featSymbols] = float64(symbols) / n
+	if letters := upper + lower; letters > 0 {
+		f[featVowels] = float64(vowels) / float64(letters)
+	}
+	f[featCaseChanges] = float64(changes)
+	for _, count := range []int{digits, upper, lower, symbols} {
+		if count > 0 {
+			f[featClasses]++
+		}
+	}
+	f[featWords] = m.wordRatio(token)
+	if isSecretKeyword(prev) {
+		f[featAfterSecretKey] = 1
+	}
+	if m.matchesKnownPattern(token) {
+		f[featKnownPattern] = 1
+	}
+	return f
+}
+
+// wordRatio returns the share of the token's letters that belong to
+// dictionary words. Words are runs of letters, split at camelCase humps.
+func (m *piiModel) wordRatio(token string) float64 {
+	letters, known := 0, 0
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := token[start:end]
+		letters += len(word)
+		if len(word) >= 3 && m.words.IsCorrect(word) {
+			known += len(word)
+		}
+		start = -1
+	}
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		if !isASCIILetter(c) {
+			flush(i)
+			continue
+		}
+		// A hump starts a new word, "userName" -> "user", "Name"
+		if start >= 0 && c >= 'A' && c <= 'Z' && token[i-1] >= 'a' && token[i-1] <= 'z' {
+			flush(i)
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(token))
+	if letters == 0 {
+		return 0
+	}
+	return float64(known) / float64(letters)
+}
+
+// matchesKnownPattern reports whether the token starts with a known key
+// prefix or has the shape of a phone, card or ID number
+func (m *piiModel) matchesKnownPattern(token string) bool {
+	for n := 2; n <= 11 && n <= len(token); n++ {
+		if m.bloom.contains("^" + token[:n]) {
+			return true
+		}
+	}
+	return m.bloom.contains(shapeKey(token))
+}
+
+// shapeKey is the bloom filter key of the shape of a token
+func shapeKey(token string) string {
+	return "#" + tokenShape(token)
+}
+
+// tokenShape describes a token by its runs of digits and letters, e.g.
+// "138-0013-8000" is "d3-d4-d4". Other characters are kept as they are.
+func tokenShape(token string) string {
+	var sb strings.Builder
+	for i := 0; i < len(token); {
+		class := byte(0)
+		switch c := token[i]; {
+		case c >= '0' && c <= '9':
+			class = 'd'
+		case isASCIILetter(c):
+			class = 'a'
+		}
+		if class == 0 {
+			sb.WriteByte(token[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(token) && classOf(token[j]) == class {
+			j++
+		}
+		sb.WriteByte(class)
+		sb.WriteString(strconv.Itoa(j - i))
+		i = j
+	}
+	return sb.String()
+}
+
+func classOf(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return 'd'
+	case isASCIILetter(c):
+		return 'a'
+	}
+	return 0
+}
+
+// secretKeywords are keys whose value is a secret
+var secretKeywords = map[string]bool{
+	"password": true, "passwd": true, "pwd": true, "pass": true, "passphrase": true,
+	"secret": true, "token": true, "key": true, "apikey": true, "auth": true,
+	"bearer": true, "basic": true, "authorization": true, "credential": true,
+	"credentials": true,
+}
+
+// isSecretKeyword reports whether a key names a secret, e.g. "password",
+// "api_key" or "X-Auth-Token"
+func isSecretKeyword(key string) bool {
+	if key == "" || len(key) > 40 {
+		return false
+	}
+	key = strings.ToLower(key)
+	if secretKeywords[key] {
+		return true
+	}
+	for _, part := range strings.FieldsFunc(key, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		if secretKeywords[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// shannonEntropy returns the Shannon entropy of a string in bits per byte
+func shannonEntropy(s string) float64 {
+	return (&Anonymizer{}).calculateEntropy(s)
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigitString reports whether s is a number, allowing a leading "+" and
+// "-" between digit groups
+func isDigitString(s string) bool {
+	digits := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '+' && i == 0, c == '-' && i > 0:
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+func isHexString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isHexDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isUpperAlnum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomFilter is a fixed-size bloom filter using double hashing of FNV-1a
+type bloomFilter struct {
+	bits   []uint64
+	hashes int
+}
+
+func newBloomFilter(bits, hashes int) *bloomFilter {
+	return &bloomFilter{bits: make([]uint64, (bits+63)/64), hashes: hashes}
+}
+
+func (b *bloomFilter) add(key string) {
+	h1, h2 := bloomHashes(key)
+	m := uint32(len(b.bits) * 64)
+	for i := 0; i < b.hashes; i++ {
+		bit := (h1 + uint32(i)*h2) % m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// contains reports whether key was added, with a small false positive rate
+func (b *bloomFilter) contains(key string) bool {
+	h1, h2 := bloomHashes(key)
+	m := uint32(len(b.bits) * 64)
+	for i := 0; i < b.hashes; i++ {
+		bit := (h1 + uint32(i)*h2) % m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes splits the 64-bit FNV-1a hash of key into two hashes
+func bloomHashes(key string) (uint32, uint32) {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return uint32(h), uint32(h>>32) | 1
+}
+
+// Limits of buildDecisionTree
+const (
+	maxTreeDepth   = 8
+	minTreeSamples = 2
+)
+
+// treeSample is a labeled training token
+type treeSample struct {
+	features  tokenFeatures
+	sensitive bool
+}
+
+// decisionNode is a node of a binary decision tree. Inner nodes send tokens
+// with feature <= threshold to left; leaves hold the decision.
+type decisionNode struct {
+	feature     int
+	threshold   float64
+	left, right *decisionNode
+	sensitive   bool
+}
+
+// classify walks the tree down to a leaf
+func (n *decisionNode) classify(f tokenFeatures) bool {
+	for n.left != nil {
+		if f[n.feature] <= n.threshold {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return n.sensitive
+}
+
+// buildDecisionTree trains a CART tree, splitting on the feature and
+// threshold with the lowest Gini impurity
+func buildDecisionTree(samples []treeSample, depth int) *decisionNode {
+	positives := 0
+	for _, sample := range samples {
+		if sample.sensitive {
+			positives++
+		}
+	}
+	// Ties are not sensitive, keeping text is the cheaper mistake
+	leaf := &decisionNode{sensitive: positives*2 > len(samples)}
+	if positives == 0 || positives == len(samples) || depth >= maxTreeDepth || len(samples) < minTreeSamples*2 {
+		return leaf
+	}
+
+	bestFeature, bestThreshold := -1, 0.0
+	bestImpurity := gini(positives, len(samples))
+	sorted := make([]treeSample, len(samples))
+	copy(sorted, samples)
+	for feature := 0; feature < numTokenFeatures; feature++ {
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].features[feature] < sorted[j].features[feature] })
+		leftPositives := 0
+		for i := 0; i < len(sorted)-1; i++ {
+			if sorted[i].sensitive {
+				leftPositives++
+			}
+			value, next := sorted[i].features[feature], sorted[i+1].features[feature]
+			if value == next || i+1 < minTreeSamples || len(sorted)-i-1 < minTreeSamples {
+				continue
+			}
+			leftCount, rightCount := i+1, len(sorted)-i-1
+			impurity := (float64(leftCount)*gini(leftPositives, leftCount) +
+				float64(rightCount)*gini(positives-leftPositives, rightCount)) / float64(len(sorted))
+			if impurity < bestImpurity-1e-9 {
+				bestFeature, bestThreshold, bestImpurity = feature, (value+next)/2, impurity
+			}
+		}
+	}
+	if bestFeature < 0 {
+		return leaf
+	}
+
+	var left, right []treeSample
+	for _, sample := range samples {
+		if sample.features[bestFeature] <= bestThreshold {
+			left = append(left, sample)
+		} else {
+			right = append(right, sample)
+		}
+	}
+	return &decisionNode{
+		feature:   bestFeature,
+		threshold: bestThreshold,
+		left:      buildDecisionTree(left, depth+1),
+		right:     buildDecisionTree(right, depth+1),
+	}
+}
+
+// gini returns the Gini impurity of a set with positives of count samples
+func gini(positives, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	p := float64(positives) / float64(count)
+	return 2 * p * (1 - p)
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -290,3 +291,57 @@ func TestAnonymizer_DatabaseConnectionString(t *testing.T) {
 		t.Errorf("Deanonymization failed. Expected: %s, Got: %s", text, deanonymized)
 	}
 }
+
+func TestAnonymizer_ValueRepeatedInLaterMessage(t *testing.T) {
+	a := NewAnonymizer(testPrivacyConfig(true))
+
+	first := a.Anonymize("请访问 https://api.example.com/v1/users")
+	second := a.Anonymize("再次访问 https://api.example.com/v1/users")
+
+	if strings.Contains(second, "api.example.com") {
+		t.Errorf("URL already anonymized in an earlier message should be anonymized again, got: %s", second)
+	}
+	if strings.TrimPrefix(first, "请访问 ") != strings.TrimPrefix(second, "再次访问 ") {
+		t.Errorf("Same URL should keep its placeholder. Got: %s and %s", first, second)
+	}
+}
+
+func TestFindPatternMatches_SameAsFullScan(t *testing.T) {
+	texts := []string{
+		anonymizerBenchmarkText(),
+		"call 13800138000, +86 13912345678 or 8613700137000x and 86\n13600136000",
+		"user  :  bob\nPASSWORD=hunter2 pwd:\n  s3cret token = abcdefghijklmnop1234",
+		"Kelvin \u212aey: abcdefghijklmnopqrstuvwxyz and \u017fecret: abcdefghijklmnopqrstuvwxyz A\u212aIA1234567890ABCDEF",
+		"PostgreSQL://u:p@h/db,redis://cache:6379 aa:bb:cc:dd:ee:ff fe80:0:0:0:0:0:0:1 C:\\Users\\me\\x.txt x@y.io",
+	}
+
+	for _, text := range texts {
+		for _, pattern := range defaultPatterns {
+			got := findPatternMatches(pattern, text)
+			want := pattern.Regex.FindAllStringSubmatchIndex(text, -1)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: got %v, want %v in %q", pattern.Name, got, want, text)
+			}
+		}
+
+		got := findAnchoredMatches(kvYAMLLineRegex, text, lineStarts(text))
+		want := kvYAMLRegex.FindAllStringSubmatchIndex(text, -1)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("key-value lines: got %v, want %v in %q", got, want, text)
+		}
+	}
+}
+
+func BenchmarkAnonymizer(b *testing.B) {
+	var result BenchmarkResult
+	for i := 0; i < b.N; i++ {
+		result = AnonymizerBenchmark()
+	}
+
+	b.ReportMetric(result.AvgLatencyMs, "avg-ms")
+	b.ReportMetric(result.P99LatencyMs, "p99-ms")
+	b.ReportMetric(result.MaxLatencyMs, "max-ms")
+	if result.P99LatencyMs >= 5 {
+		b.Fatalf("p99 latency of %d byte messages is %.2fms, want under 5ms", result.InputBytes, result.P99LatencyMs)
+	}
+}
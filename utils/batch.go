@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"light-llm-client/db"
+	"light-llm-client/llm"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BatchItem is one conversation of a batch script. The messages are sent as
+// the history and the last one is the message being answered.
+type BatchItem struct {
+	Provider string        `json:"provider"`
+	Messages []llm.Message `json:"messages"`
+}
+
+// BatchResult is written as one NDJSON line per batch item
+type BatchResult struct {
+	InputMessage string `json:"input_message"`
+	Response     string `json:"response"`
+	Tokens       int    `json:"tokens"` // 0 when the provider does not report usage
+	DurationMs   int64  `json:"duration_ms"`
+	Error        string `json:"error,omitempty"`
+}
+
+// BatchRunner runs scripted conversations without the UI, e.g. to check
+// prompt quality in CI
+type BatchRunner struct {
+	// DB is optional; when set, every answered item is saved as a conversation
+	DB        *db.DB
+	Providers map[string]llm.Provider
+}
+
+// LoadBatchScript reads a batch script: a JSON array of BatchItem
+func LoadBatchScript(scriptPath string) ([]BatchItem, error) {
+	data, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch script: %w", err)
+	}
+
+	var items []BatchItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse batch script: %w", err)
+	}
+	for i, item := range items {
+		if len(item.Messages) == 0 {
+			return nil, fmt.Errorf("batch item %d has no messages", i)
+		}
+	}
+	return items, nil
+}
+
+// RunScript sends every item of the script to its provider and writes one
+// BatchResult line per item to out. A failed item is reported in its line
+// and the remaining items still run; the returned error counts the failures.
+func (r *BatchRunner) RunScript(ctx context.Context, scriptPath string, out io.Writer) error {
+	items, err := LoadBatchScript(scriptPath)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	failed := 0
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result := r.runItem(ctx, item)
+		if result.Error != "" {
+			failed++
+		} else if r.DB != nil {
+			title := fmt.Sprintf("[Batch] %s #%d", filepath.Base(scriptPath), i+1)
+			if err := r.saveItem(title, item, result); err != nil {
+				return err
+			}
+		}
+
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("failed to write batch result: %w", err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batch items failed", failed, len(items))
+	}
+	return nil
+}
+
+// runItem gets the response to one item. It streams so the token usage
+// reported on the final chunk is available.
+func (r *BatchRunner) runItem(ctx context.Context, item BatchItem) (result BatchResult) {
+	result.InputMessage = item.Messages[len(item.Messages)-1].Content
+
+	provider, ok := r.Providers[item.Provider]
+	if !ok {
+		result.Error = fmt.Sprintf("provider %q is not configured", item.Provider)
+		return result
+	}
+
+	start := time.Now()
+	defer func() {
+		result.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	stream, err := provider.StreamChat(ctx, item.Messages)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var response strings.Builder
+	for chunk := range stream {
+		if chunk.Error != nil {
+			result.Error = chunk.Error.Error()
+			// Drain so the provider's goroutine can finish
+			continue
+		}
+		response.WriteString(chunk.Content)
+		if chunk.Done && chunk.Usage != nil {
+			result.Tokens = chunk.Usage.TotalTokens
+		}
+	}
+	result.Response = response.String()
+	return result
+}
+
+// saveItem stores the messages and response of an item as a conversation
+func (r *BatchRunner) saveItem(title string, item BatchItem, result BatchResult) error {
+	conv, err := r.DB.CreateConversation(title, "")
+	if err != nil {
+		return fmt.Errorf("failed to create batch conversation: %w", err)
+	}
+	for _, msg := range item.Messages {
+		if _, err := r.DB.CreateMessage(conv.ID, msg.Role, msg.Content, "", "", "", 0); err != nil {
+			return fmt.Errorf("failed to save batch message: %w", err)
+		}
+	}
+	if _, err := r.DB.CreateMessage(conv.ID, "assistant", result.Response, item.Provider, "", "", result.Tokens); err != nil {
+		return fmt.Errorf("failed to save batch response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"light-llm-client/llm"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newOllamaMockServer streams a reply that echoes the number of messages it
+// received, so tests can tell that the whole history was sent
+func newOllamaMockServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		var request struct {
+			Messages []llm.Message `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"message": {"role": "assistant", "content": "got %d "}, "done": false}`+"\n", len(request.Messages))
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "messages"}, "done": false}`)
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": ""}, "done": true, "prompt_eval_count": 12, "eval_count": 3}`)
+	}))
+}
+
+func writeBatchScript(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "script.json")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestBatchRunner_RunScript(t *testing.T) {
+	server := newOllamaMockServer(t)
+	defer server.Close()
+
+	provider, err := NewProvider("ollama", ProviderConfig{BaseURL: server.URL, DefaultModel: "llama3"})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	runner := &BatchRunner{Providers: map[string]llm.Provider{"ollama": provider}}
+
+	script := writeBatchScript(t, `[
+		{"provider": "ollama", "messages": [{"role": "user", "content": "Hi"}]},
+		{"provider": "ollama", "messages": [
+			{"role": "user", "content": "What is Go?"},
+			{"role": "assistant", "content": "A language."},
+			{"role": "user", "content": "Who made it?"}
+		]}
+	]`)
+
+	var out bytes.Buffer
+	if err := runner.RunScript(context.Background(), script, &out); err != nil {
+		t.Fatalf("RunScript failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d result lines, want 2: %s", len(lines), out.String())
+	}
+	want := []BatchResult{
+		{InputMessage: "Hi", Response: "got 1 messages", Tokens: 15},
+		{InputMessage: "Who made it?", Response: "got 3 messages", Tokens: 15},
+	}
+	for i, line := range lines {
+		var result BatchResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+		result.DurationMs = 0
+		if result != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, result, want[i])
+		}
+	}
+}
+
+func TestBatchRunner_RunScript_ReportsFailures(t *testing.T) {
+	runner := &BatchRunner{Providers: map[string]llm.Provider{}}
+	script := writeBatchScript(t, `[{"provider": "missing", "messages": [{"role": "user", "content": "Hi"}]}]`)
+
+	var out bytes.Buffer
+	if err := runner.RunScript(context.Background(), script, &out); err == nil {
+		t.Fatal("expected an error for the failed item")
+	}
+
+	var result BatchResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	if result.InputMessage != "Hi" || !strings.Contains(result.Error, "missing") {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestLoadBatchScript_RejectsEmptyItems(t *testing.T) {
+	script := writeBatchScript(t, `[{"provider": "ollama", "messages": []}]`)
+	if _, err := LoadBatchScript(script); err == nil {
+		t.Fatal("expected an error for an item without messages")
+	}
+}
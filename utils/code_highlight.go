@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"html"
+	"strings"
+)
+
+// codeLanguage describes the lexical syntax highlightCode needs
+type codeLanguage struct {
+	lineComments []string
+	blockComment [2]string // Start and end, empty if the language has none
+	quotes       string    // Characters that delimit strings
+	keywords     map[string]bool
+}
+
+// words builds a keyword set
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(list) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	cLikeKeywords = "if else for while do switch case default break continue return goto " +
+		"struct enum union typedef const static extern void int char long short float double " +
+		"unsigned signed sizeof class public private protected new delete this try catch throw " +
+		"namespace using template typename virtual bool true false null nullptr import package " +
+		"final abstract interface extends implements fn let mut pub impl trait match mod use self " +
+		"crate where loop as ref move async await dyn"
+
+	codeLanguages = map[string]*codeLanguage{
+		"go": {
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'`",
+			keywords: words("break case chan const continue default defer else fallthrough for func go goto " +
+				"if import interface map package range return select struct switch type var " +
+				"true false nil iota"),
+		},
+		"js": {
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'`",
+			keywords: words("var let const function return if else for while do switch case default break " +
+				"continue new delete typeof instanceof in of class extends super this import export from " +
+				"async await yield try catch finally throw true false null undefined interface type enum"),
+		},
+		"python": {
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+			keywords: words("def class return if elif else for while break continue pass import from as " +
+				"with try except finally raise lambda yield global nonlocal in is not and or " +
+				"True False None async await"),
+		},
+		"shell": {
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+			keywords: words("if then else elif fi for while until do done case esac in function " +
+				"return export local echo exit"),
+		},
+		"sql": {
+			lineComments: []string{"--"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "'\"",
+			keywords: words("select from where insert into values update set delete create table drop alter " +
+				"index join left right inner outer on group by order having limit offset and or not null " +
+				"as distinct union primary key foreign references default SELECT FROM WHERE INSERT INTO " +
+				"VALUES UPDATE SET DELETE CREATE TABLE DROP ALTER INDEX JOIN LEFT RIGHT INNER OUTER ON " +
+				"GROUP BY ORDER HAVING LIMIT OFFSET AND OR NOT NULL AS DISTINCT UNION PRIMARY KEY " +
+				"FOREIGN REFERENCES DEFAULT"),
+		},
+		"c": {
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'",
+			keywords:     words(cLikeKeywords),
+		},
+	}
+
+	// codeLanguageAliases maps the names used after ``` to codeLanguages
+	codeLanguageAliases = map[string]string{
+		"golang": "go", "javascript": "js", "jsx": "js", "ts": "js", "typescript": "js", "tsx": "js",
+		"py": "python", "python3": "python", "sh": "shell", "bash": "shell", "zsh": "shell",
+		"cpp": "c", "c++": "c", "h": "c", "hpp": "c", "java": "c", "cs": "c", "csharp": "c",
+		"rust": "c", "rs": "c", "kotlin": "c", "kt": "c", "swift": "c",
+	}
+)
+
+// lookupCodeLanguage returns the syntax of a code block language, nil if it
+// is unknown
+func lookupCodeLanguage(name string) *codeLanguage {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := codeLanguageAliases[name]; ok {
+		name = alias
+	}
+	return codeLanguages[name]
+}
+
+// highlightCode returns code as escaped HTML with comments, strings, numbers
+// and keywords wrapped in spans of the classes com, str, num and kw. Code in
+// an unknown language is only escaped.
+func highlightCode(language, code string) string {
+	lang := lookupCodeLanguage(language)
+	if lang == nil {
+		return html.EscapeString(code)
+	}
+
+	var sb strings.Builder
+	span := func(class, text string) {
+		sb.WriteString(`<span class="` + class + `">`)
+		sb.WriteString(html.EscapeString(text))
+		sb.WriteString(`</span>`)
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+
+		if end := lang.commentEnd(rest); end > 0 {
+			span("com", rest[:end])
+			i += end
+			continue
+		}
+
+		c := code[i]
+		switch {
+		case strings.IndexByte(lang.quotes, c) >= 0:
+			end := stringEnd(rest)
+			span("str", rest[:end])
+			i += end
+		case isDigit(c) && (i == 0 || !isIdentByte(code[i-1])):
+			end := 1
+			for end < len(rest) && (isIdentByte(rest[end]) || rest[end] == '.') {
+				end++
+			}
+			span("num", rest[:end])
+			i += end
+		case isIdentByte(c):
+			end := 1
+			for end < len(rest) && isIdentByte(rest[end]) {
+				end++
+			}
+			if word := rest[:end]; lang.keywords[word] {
+				span("kw", word)
+			} else {
+				sb.WriteString(html.EscapeString(word))
+			}
+			i += end
+		default:
+			sb.WriteString(html.EscapeString(string(c)))
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// commentEnd returns the length of the comment text starts with, 0 if it
+// doesn't start with one
+func (l *codeLanguage) commentEnd(text string) int {
+	for _, prefix := range l.lineComments {
+		if strings.HasPrefix(text, prefix) {
+			if end := strings.IndexByte(text, '\n'); end >= 0 {
+				return end
+			}
+			return len(text)
+		}
+	}
+	if start := l.blockComment[0]; start != "" && strings.HasPrefix(text, start) {
+		if end := strings.Index(text[len(start):], l.blockComment[1]); end >= 0 {
+			return len(start) + end + len(l.blockComment[1])
+		}
+		return len(text)
+	}
+	return 0
+}
+
+// stringEnd returns the length of the string literal text starts with. A
+// string that isn't closed ends at the end of the line, except raw strings
+// delimited by backquotes.
+func stringEnd(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && quote != '`':
+			i++
+		case text[i] == quote:
+			return i + 1
+		case text[i] == '\n' && quote != '`':
+			return i
+		}
+	}
+	return len(text)
+}
+
+// isIdentByte reports whether b can be part of an identifier. Bytes of
+// multibyte characters are written through unchanged.
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= 0x80 || isDigit(b) || (b|0x20 >= 'a' && b|0x20 <= 'z')
+}
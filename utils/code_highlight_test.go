@@ -0,0 +1,22 @@
+package utils
+
+import "testing"
+
+func TestHighlightCode(t *testing.T) {
+	tests := []struct {
+		language, code, want string
+	}{
+		{"go", "return x1 + 42", `<span class="kw">return</span> x1 + <span class="num">42</span>`},
+		{"golang", "s := `a\nb` /* c */", `s := <span class="str">` + "`a\nb`" + `</span> <span class="com">/* c */</span>`},
+		{"py", "def f(): # 'x'\n  return 'a\\'b'", `<span class="kw">def</span> f(): <span class="com"># &#39;x&#39;</span>` + "\n  " + `<span class="kw">return</span> <span class="str">&#39;a\&#39;b&#39;</span>`},
+		{"sql", "SELECT 1 -- one", `<span class="kw">SELECT</span> <span class="num">1</span> <span class="com">-- one</span>`},
+		{"", "if a < b { return }", "if a &lt; b { return }"},
+		{"brainfuck", "+[<>]", "+[&lt;&gt;]"},
+		{"js", "const 名字 = \"x", `<span class="kw">const</span> 名字 = <span class="str">&#34;x</span>`},
+	}
+	for _, tt := range tests {
+		if got := highlightCode(tt.language, tt.code); got != tt.want {
+			t.Errorf("highlightCode(%q, %q)\n got %s\nwant %s", tt.language, tt.code, got, tt.want)
+		}
+	}
+}
@@ -3,8 +3,11 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"light-llm-client/llm"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // Config represents the application configuration
@@ -14,6 +17,15 @@ type Config struct {
 	Data         DataConfig                `json:"data"`
 	Proxy        ProxyConfig               `json:"proxy"`
 	Privacy      PrivacyConfig             `json:"privacy"`
+	Log          RotationConfig            `json:"log"`
+	Sync         SyncConfig                `json:"sync"`
+	Update       UpdateConfig              `json:"update"`
+	Export       ExportConfig              `json:"export"`
+	// RecentFiles are the URIs of recently exported or imported files, most recent first
+	RecentFiles []string `json:"recent_files,omitempty"`
+	// Keybindings override the key combinations of the window shortcuts,
+	// e.g. {"new_conversation": "ctrl+t"} (see keybindings.go)
+	Keybindings map[string]string `json:"keybindings,omitempty"`
 }
 
 // ProviderConfig represents LLM provider configuration
@@ -26,6 +38,30 @@ type ProviderConfig struct {
 	Enabled      bool     `json:"enabled"`
 	MaxTokens    int      `json:"max_tokens,omitempty"`
 	Temperature  float64  `json:"temperature,omitempty"`
+	// TimeoutSeconds is how long to wait for the API to start answering;
+	// 0 uses the default (120 s for chat, 30 s for title generation)
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// Retries of requests that fail with network errors; 0 uses the defaults
+	// of 3 attempts, waiting 1 s and doubling the wait up to 30 s
+	RetryMaxAttempts    int     `json:"retry_max_attempts,omitempty"`
+	RetryInitialDelayMs int     `json:"retry_initial_delay_ms,omitempty"`
+	RetryMultiplier     float64 `json:"retry_multiplier,omitempty"`
+	RetryMaxDelayMs     int     `json:"retry_max_delay_ms,omitempty"`
+	// Middlewares applied around the provider
+	RateLimitRPS   int  `json:"rate_limit_rps,omitempty"`  // Max requests per second (0 = unlimited)
+	CacheResponses bool `json:"cache_responses,omitempty"` // Reuse responses for identical requests
+	// SystemPrompt is sent before the messages of conversations without a system message
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	// SafePrompt enables Mistral's safe_prompt guardrails (mistral only)
+	SafePrompt bool `json:"safe_prompt,omitempty"`
+	// ReasoningEffort is how hard Grok thinks: low, medium or high (xai/grok only)
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+	// ProxyURL is the http(s):// or socks5:// proxy this provider connects
+	// through; empty uses the global proxy, if it is enabled
+	ProxyURL string `json:"proxy_url,omitempty"`
+	// DeploymentName and APIVersion address the Azure OpenAI deployment (azure only)
+	DeploymentName string `json:"deployment_name,omitempty"`
+	APIVersion     string `json:"api_version,omitempty"`
 }
 
 // UIConfig represents UI configuration
@@ -35,12 +71,112 @@ type UIConfig struct {
 	WindowWidth    int    `json:"window_width"`
 	WindowHeight   int    `json:"window_height"`
 	MinimizeToTray bool   `json:"minimize_to_tray"`
+	SpellCheck     bool   `json:"spell_check"`
+	// ShowFollowUpSuggestions asks the provider for follow-up questions after each response
+	ShowFollowUpSuggestions bool `json:"show_follow_up_suggestions"`
+	// QuickPrompts are inserted with Alt+<Key> or from the quick prompt bar
+	QuickPrompts          []QuickPrompt `json:"quick_prompts"`
+	QuickPromptsCollapsed bool          `json:"quick_prompts_collapsed"`
+	// GlobalHotkey shows the window from anywhere, e.g. "ctrl+alt+l"; empty disables it
+	GlobalHotkey string `json:"global_hotkey"`
+	// TTSEnabled shows a read aloud button on assistant messages
+	TTSEnabled bool `json:"tts_enabled"`
+	// TTSVoice is the voice of the OS speech engine; empty uses the default
+	TTSVoice string `json:"tts_voice"`
+	// TabOrder lists the conversations of the open chat tabs in display order
+	TabOrder []int64 `json:"tab_order,omitempty"`
+	// CacheSize is the number of conversations whose messages are kept in
+	// memory; 0 uses DefaultCacheSize
+	CacheSize int `json:"cache_size,omitempty"`
+}
+
+// Limits of UIConfig.CacheSize
+const (
+	DefaultCacheSize = 10
+	MinCacheSize     = 5
+	MaxCacheSize     = 100
+)
+
+// MessageCacheSize returns the configured cache size, limited to
+// MinCacheSize..MaxCacheSize
+func (c UIConfig) MessageCacheSize() int {
+	switch {
+	case c.CacheSize == 0:
+		return DefaultCacheSize
+	case c.CacheSize < MinCacheSize:
+		return MinCacheSize
+	case c.CacheSize > MaxCacheSize:
+		return MaxCacheSize
+	}
+	return c.CacheSize
+}
+
+// QuickPrompt is a preset prompt triggered by Alt+Key (Key is '1' to '9')
+type QuickPrompt struct {
+	Key     rune
+	Label   string
+	Content string
+}
+
+// quickPromptJSON stores the key as a string so the config stays readable
+type quickPromptJSON struct {
+	Key     string `json:"key"`
+	Label   string `json:"label"`
+	Content string `json:"content"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (q QuickPrompt) MarshalJSON() ([]byte, error) {
+	key := ""
+	if q.Key != 0 {
+		key = string(q.Key)
+	}
+	return json.Marshal(quickPromptJSON{Key: key, Label: q.Label, Content: q.Content})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (q *QuickPrompt) UnmarshalJSON(data []byte) error {
+	var raw quickPromptJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	q.Key = 0
+	if runes := []rune(raw.Key); len(runes) > 0 {
+		q.Key = runes[0]
+	}
+	q.Label = raw.Label
+	q.Content = raw.Content
+	return nil
+}
+
+// DefaultQuickPrompts returns the built-in quick prompts
+func DefaultQuickPrompts() []QuickPrompt {
+	return []QuickPrompt{
+		{Key: '1', Label: "翻译", Content: "请将以下内容翻译成英文：\n"},
+		{Key: '2', Label: "总结", Content: "请用要点总结以下内容：\n"},
+		{Key: '3', Label: "解释代码", Content: "请逐步解释以下代码的作用：\n"},
+		{Key: '4', Label: "润色", Content: "请润色以下文字，使其更通顺：\n"},
+	}
 }
 
 // DataConfig represents data storage configuration
 type DataConfig struct {
 	DBPath     string `json:"db_path"`
 	MaxHistory int    `json:"max_history"`
+	// AutoSummarize replaces older messages with an LLM-generated summary
+	AutoSummarize          bool `json:"auto_summarize"`
+	SummarizeAfterMessages int  `json:"summarize_after_messages,omitempty"`
+	// MacrosPath is the JSON file of the input macros, see macros.go. Empty
+	// keeps them next to the database.
+	MacrosPath string `json:"macros_path,omitempty"`
+}
+
+// MacrosFile returns the path of the macros file
+func (d DataConfig) MacrosFile() string {
+	if d.MacrosPath != "" {
+		return d.MacrosPath
+	}
+	return filepath.Join(filepath.Dir(d.DBPath), "macros.json")
 }
 
 // ProxyConfig represents proxy configuration
@@ -49,6 +185,38 @@ type ProxyConfig struct {
 	URL     string `json:"url"`
 }
 
+// RequestTimeout returns the configured request timeout of the provider, or
+// fallback if none is set
+func (c ProviderConfig) RequestTimeout(fallback time.Duration) time.Duration {
+	if c.TimeoutSeconds > 0 {
+		return time.Duration(c.TimeoutSeconds) * time.Second
+	}
+	return fallback
+}
+
+// RetryPolicy returns the retry settings of the provider, unset fields use
+// the defaults of llm.RetryPolicy
+func (c ProviderConfig) RetryPolicy() llm.RetryPolicy {
+	return llm.RetryPolicy{
+		MaxAttempts:  c.RetryMaxAttempts,
+		InitialDelay: time.Duration(c.RetryInitialDelayMs) * time.Millisecond,
+		Multiplier:   c.RetryMultiplier,
+		MaxDelay:     time.Duration(c.RetryMaxDelayMs) * time.Millisecond,
+	}
+}
+
+// ProviderProxyURL returns the proxy a provider connects through: its own
+// proxy_url, else the global proxy if it is enabled, else "" for none
+func (c *Config) ProviderProxyURL(providerConfig ProviderConfig) string {
+	if providerConfig.ProxyURL != "" {
+		return providerConfig.ProxyURL
+	}
+	if c.Proxy.Enabled {
+		return c.Proxy.URL
+	}
+	return ""
+}
+
 // PrivacyConfig represents privacy and anonymization configuration
 type PrivacyConfig struct {
 	AnonymizeSensitiveData bool `json:"anonymize_sensitive_data"`
@@ -57,6 +225,73 @@ type PrivacyConfig struct {
 	AnonymizeEmails        bool `json:"anonymize_emails"`
 	AnonymizeIPAddresses   bool `json:"anonymize_ip_addresses"`
 	AnonymizeFilePaths     bool `json:"anonymize_file_paths"`
+
+	// StructuredAnonymizer selects the token classifier instead of the
+	// regex patterns
+	StructuredAnonymizer bool `json:"structured_anonymizer"`
+
+	// RedactBeforeLog replaces API keys, bearer tokens and JWTs in log
+	// messages with placeholders, independently of AnonymizeSensitiveData
+	RedactBeforeLog bool `json:"redact_before_log"`
+}
+
+// SyncConfig represents WebDAV synchronization of the database file
+type SyncConfig struct {
+	WebDAVURL           string `json:"webdav_url"` // Full URL of the remote database file
+	Username            string `json:"username"`
+	Password            string `json:"password"`
+	SyncIntervalMinutes int    `json:"sync_interval_minutes"` // 0 = only sync on startup and exit
+}
+
+// Enabled returns whether WebDAV sync is configured
+func (c SyncConfig) Enabled() bool {
+	return c.WebDAVURL != ""
+}
+
+// DefaultUpdateCheckIntervalDays is how often new releases are looked up by default
+const DefaultUpdateCheckIntervalDays = 7
+
+// UpdateConfig represents the check for new releases
+type UpdateConfig struct {
+	UpdateCheckIntervalDays int       `json:"update_check_interval_days"` // 0 = never check
+	LastCheck               time.Time `json:"last_check"`
+	// DismissedVersion is the release whose update banner was dismissed
+	DismissedVersion string `json:"dismissed_version,omitempty"`
+}
+
+// ExportConfig represents scheduled exports of all conversations
+type ExportConfig struct {
+	Schedule string       `json:"schedule,omitempty"` // Cron expression, e.g. "0 2 * * *" (empty = no scheduled exports)
+	Dir      string       `json:"dir,omitempty"`      // Empty = the default export directory
+	Format   ExportFormat `json:"format,omitempty"`   // json or markdown, default json
+}
+
+// CheckDue returns whether the interval since the last check has passed
+func (c UpdateConfig) CheckDue(now time.Time) bool {
+	if c.UpdateCheckIntervalDays <= 0 {
+		return false
+	}
+	return now.Sub(c.LastCheck) >= time.Duration(c.UpdateCheckIntervalDays)*24*time.Hour
+}
+
+// maxRecentFiles is the number of files kept in Config.RecentFiles
+const maxRecentFiles = 10
+
+// AddRecentFile moves uri to the front of the recent files, dropping the
+// oldest entries beyond maxRecentFiles
+func (c *Config) AddRecentFile(uri string) {
+	files := []string{uri}
+	for _, file := range c.RecentFiles {
+		if file != uri && len(files) < maxRecentFiles {
+			files = append(files, file)
+		}
+	}
+	c.RecentFiles = files
+}
+
+// RecentFilePath returns the local path of a file URI stored in RecentFiles
+func RecentFilePath(uri string) string {
+	return strings.TrimPrefix(uri, "file://")
 }
 
 // LoadConfig loads configuration from file
@@ -66,20 +301,41 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	// Configs written before the global hotkey, the update check and log
+	// rotation existed keep their defaults; an explicit "" or 0 disables them
 	var config Config
+	config.UI.GlobalHotkey = DefaultGlobalHotkey
+	config.Update.UpdateCheckIntervalDays = DefaultUpdateCheckIntervalDays
+	config.Log = DefaultRotationConfig()
 	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
+		backup, backupErr := os.ReadFile(BackupConfigPath(configPath))
+		return nil, &ConfigParseError{
+			Path:      configPath,
+			HasBackup: backupErr == nil && json.Valid(backup),
+			Err:       err,
+		}
 	}
 
 	// Expand paths
 	if config.Data.DBPath != "" {
 		config.Data.DBPath = expandPath(config.Data.DBPath)
 	}
+	if config.Data.MacrosPath != "" {
+		config.Data.MacrosPath = expandPath(config.Data.MacrosPath)
+	}
+
+	// Configs written before quick prompts existed get the defaults;
+	// an empty list means the user removed them all
+	if config.UI.QuickPrompts == nil {
+		config.UI.QuickPrompts = DefaultQuickPrompts()
+	}
 
 	return &config, nil
 }
 
-// SaveConfig saves configuration to file
+// SaveConfig saves configuration to file. The file is replaced atomically so
+// a crash while saving leaves the previous config in place, and a copy is
+// kept as a backup for LoadConfig failures.
 func SaveConfig(configPath string, config *Config) error {
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
@@ -92,13 +348,101 @@ func SaveConfig(configPath string, config *Config) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := writeFileAtomic(configPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	if err := writeFileAtomic(BackupConfigPath(configPath), data, 0644); err != nil {
+		return fmt.Errorf("failed to write config backup: %w", err)
+	}
+
+	return nil
+}
+
+// beforeRename is called with the written temporary file before it replaces
+// the destination; tests use it to simulate a crash
+var beforeRename func(tmpPath string) error
+
+// writeFileAtomic writes data to a temporary file in the directory of path,
+// flushes it to disk and renames it over path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// Removing fails harmlessly once the file has been renamed
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if beforeRename != nil {
+		if err := beforeRename(tmpPath); err != nil {
+			return err
+		}
+	}
 
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
 	return nil
 }
 
+// BackupConfigPath returns where SaveConfig keeps a copy of the config
+func BackupConfigPath(configPath string) string {
+	return configPath + ".bak"
+}
+
+// ConfigParseError is returned by LoadConfig for a config file that is not
+// valid JSON. HasBackup tells whether RestoreConfigBackup can replace it.
+type ConfigParseError struct {
+	Path      string
+	HasBackup bool
+	Err       error
+}
+
+func (e *ConfigParseError) Error() string {
+	return fmt.Sprintf("failed to parse config: %v", e.Err)
+}
+
+func (e *ConfigParseError) Unwrap() error {
+	return e.Err
+}
+
+// RestoreConfigBackup replaces an unreadable config file with its backup.
+// The broken file is kept next to it with a .corrupt suffix, whose path is
+// returned.
+func RestoreConfigBackup(configPath string) (string, error) {
+	data, err := os.ReadFile(BackupConfigPath(configPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to read config backup: %w", err)
+	}
+	if !json.Valid(data) {
+		return "", fmt.Errorf("config backup is not valid JSON")
+	}
+
+	corruptPath := configPath + ".corrupt"
+	if err := os.Rename(configPath, corruptPath); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to keep corrupt config: %w", err)
+	}
+	if err := writeFileAtomic(configPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to restore config: %w", err)
+	}
+	return corruptPath, nil
+}
+
 // expandPath expands ~ and relative paths
 func expandPath(path string) string {
 	if len(path) == 0 {
@@ -198,6 +542,75 @@ func EnsureDefaultConfig() (string, error) {
 				Temperature: 0.7,
 				Enabled:     false,
 			},
+			"mistral": {
+				DisplayName:  "Mistral AI",
+				APIKey:       "",
+				BaseURL:      "https://api.mistral.ai/v1",
+				DefaultModel: "mistral-small-latest",
+				Models: []string{
+					"mistral-large-latest",
+					"mistral-small-latest",
+					"codestral-latest",
+				},
+				MaxTokens:   4096,
+				Temperature: 0.7,
+				Enabled:     false,
+			},
+			"perplexity": {
+				DisplayName:  "Perplexity",
+				APIKey:       "",
+				BaseURL:      "https://api.perplexity.ai",
+				DefaultModel: "sonar",
+				Models: []string{
+					"sonar",
+					"sonar-pro",
+					"sonar-reasoning",
+				},
+				MaxTokens:   4096,
+				Temperature: 0.7,
+				Enabled:     false,
+			},
+			"groq": {
+				DisplayName:  "Groq",
+				APIKey:       "",
+				BaseURL:      "https://api.groq.com/openai/v1",
+				DefaultModel: "llama3-8b-8192",
+				Models: []string{
+					"llama3-8b-8192",
+					"llama3-70b-8192",
+					"mixtral-8x7b-32768",
+				},
+				MaxTokens:   4096,
+				Temperature: 0.7,
+				Enabled:     false,
+			},
+			"cohere": {
+				DisplayName:  "Cohere",
+				APIKey:       "",
+				BaseURL:      "https://api.cohere.com/v2",
+				DefaultModel: "command-r-plus",
+				Models: []string{
+					"command-r-plus",
+					"command-r",
+					"command-a-03-2025",
+				},
+				MaxTokens:   4096,
+				Temperature: 0.7,
+				Enabled:     false,
+			},
+			"deepseek": {
+				DisplayName:  "DeepSeek",
+				APIKey:       "",
+				BaseURL:      "https://api.deepseek.com/v1",
+				DefaultModel: "deepseek-chat",
+				Models: []string{
+					"deepseek-chat",
+					"deepseek-reasoner",
+				},
+				MaxTokens:   4096,
+				Temperature: 0.7,
+				Enabled:     false,
+			},
 		},
 		UI: UIConfig{
 			Theme:          "light",
@@ -205,10 +618,13 @@ func EnsureDefaultConfig() (string, error) {
 			WindowWidth:    1200,
 			WindowHeight:   800,
 			MinimizeToTray: true,
+			QuickPrompts:   DefaultQuickPrompts(),
+			GlobalHotkey:   DefaultGlobalHotkey,
 		},
 		Data: DataConfig{
-			DBPath:     "./data/chat.db",
-			MaxHistory: 1000,
+			DBPath:                 "./data/chat.db",
+			MaxHistory:             1000,
+			SummarizeAfterMessages: 20,
 		},
 		Proxy: ProxyConfig{
 			Enabled: false,
@@ -222,6 +638,13 @@ func EnsureDefaultConfig() (string, error) {
 			AnonymizeIPAddresses:   true,
 			AnonymizeFilePaths:     true,
 		},
+		Sync: SyncConfig{
+			SyncIntervalMinutes: 30,
+		},
+		Update: UpdateConfig{
+			UpdateCheckIntervalDays: DefaultUpdateCheckIntervalDays,
+		},
+		Log: DefaultRotationConfig(),
 	}
 
 	if err := SaveConfig(configPath, defaultConfig); err != nil {
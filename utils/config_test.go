@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuickPrompt_JSONRoundTrip(t *testing.T) {
+	prompts := []QuickPrompt{
+		{Key: '3', Label: "总结", Content: "请总结：\n"},
+		{Label: "无快捷键", Content: "hello"},
+	}
+
+	data, err := json.Marshal(prompts)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"key":"3"`) {
+		t.Errorf("Expected key to be stored as a string, got: %s", data)
+	}
+
+	var decoded []QuickPrompt
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0] != prompts[0] || decoded[1] != prompts[1] {
+		t.Errorf("Round trip mismatch: %+v", decoded)
+	}
+}
+
+func TestConfig_AddRecentFile(t *testing.T) {
+	var config Config
+	for i := 0; i < maxRecentFiles+2; i++ {
+		config.AddRecentFile(fmt.Sprintf("file:///exports/%d.json", i))
+	}
+	config.AddRecentFile("file:///exports/5.json")
+
+	if len(config.RecentFiles) != maxRecentFiles {
+		t.Fatalf("got %d recent files, want %d", len(config.RecentFiles), maxRecentFiles)
+	}
+	if config.RecentFiles[0] != "file:///exports/5.json" || config.RecentFiles[1] != "file:///exports/11.json" {
+		t.Errorf("expected the re-added file first, got %v", config.RecentFiles)
+	}
+	seen := make(map[string]bool)
+	for _, file := range config.RecentFiles {
+		if seen[file] {
+			t.Errorf("duplicate recent file %s", file)
+		}
+		seen[file] = true
+	}
+	if seen["file:///exports/0.json"] || seen["file:///exports/1.json"] {
+		t.Errorf("expected the oldest files to be dropped, got %v", config.RecentFiles)
+	}
+}
+
+func TestGetDefaultExportPath_PrefersRecentDirectory(t *testing.T) {
+	dir := t.TempDir()
+	recent := []string{
+		"file://" + filepath.Join(dir, "missing", "a.json"),
+		"file://" + filepath.Join(dir, "b.md"),
+	}
+
+	exportDir, err := GetDefaultExportPath(recent)
+	if err != nil {
+		t.Fatalf("GetDefaultExportPath failed: %v", err)
+	}
+	if exportDir != dir {
+		t.Errorf("got %s, want the directory of the most recent existing path %s", exportDir, dir)
+	}
+}
+
+func TestSaveConfig_CrashKeepsOriginal(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	original := &Config{}
+	original.UI.Theme = "dark"
+	if err := SaveConfig(configPath, original); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	// Crash after the temporary file was partly written, before the rename
+	crash := errors.New("simulated crash")
+	beforeRename = func(tmpPath string) error {
+		if err := os.Truncate(tmpPath, 10); err != nil {
+			t.Fatalf("Truncate failed: %v", err)
+		}
+		return crash
+	}
+	defer func() { beforeRename = nil }()
+
+	updated := &Config{}
+	updated.UI.Theme = "light"
+	if err := SaveConfig(configPath, updated); !errors.Is(err, crash) {
+		t.Fatalf("SaveConfig error = %v, want simulated crash", err)
+	}
+
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if loaded.UI.Theme != "dark" {
+		t.Errorf("Theme = %q, want the original dark", loaded.UI.Theme)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(configPath))
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("temporary file %s was left behind", entry.Name())
+		}
+	}
+}
+
+func TestLoadConfig_LogRotationDefaults(t *testing.T) {
+	dir := t.TempDir()
+
+	// Configs from before log rotation rotate with the defaults
+	configPath := filepath.Join(dir, "old.json")
+	if err := os.WriteFile(configPath, []byte(`{"ui": {"theme": "dark"}}`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if loaded.Log != DefaultRotationConfig() {
+		t.Errorf("Log = %+v, want the defaults %+v", loaded.Log, DefaultRotationConfig())
+	}
+
+	// An explicit 0 still turns rotation off, other fields keep their defaults
+	configPath = filepath.Join(dir, "explicit.json")
+	if err := os.WriteFile(configPath, []byte(`{"log": {"max_size_mb": 0}}`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	loaded, err = LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if loaded.Log.MaxSizeMB != 0 || loaded.Log.MaxBackups != 5 || loaded.Log.MaxAgeDays != 30 {
+		t.Errorf("Log = %+v, want rotation off with the default retention", loaded.Log)
+	}
+}
+
+func TestLoadConfig_CorruptFileOffersBackup(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	config := &Config{}
+	config.UI.Theme = "dark"
+	if err := SaveConfig(configPath, config); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(`{"ui": {"theme": "da`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	_, err := LoadConfig(configPath)
+	var parseErr *ConfigParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("LoadConfig error = %v, want ConfigParseError", err)
+	}
+	if !parseErr.HasBackup {
+		t.Fatal("HasBackup = false, want true")
+	}
+
+	corruptPath, err := RestoreConfigBackup(configPath)
+	if err != nil {
+		t.Fatalf("RestoreConfigBackup failed: %v", err)
+	}
+	if data, err := os.ReadFile(corruptPath); err != nil || !strings.Contains(string(data), `"da`) {
+		t.Errorf("corrupt copy = %q, %v", data, err)
+	}
+
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig after restore failed: %v", err)
+	}
+	if loaded.UI.Theme != "dark" {
+		t.Errorf("Theme = %q, want dark", loaded.UI.Theme)
+	}
+}
+
+func TestLoadConfig_CorruptFileWithoutBackup(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte("not json"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	_, err := LoadConfig(configPath)
+	var parseErr *ConfigParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("LoadConfig error = %v, want ConfigParseError", err)
+	}
+	if parseErr.HasBackup {
+		t.Error("HasBackup = true without a backup file")
+	}
+}
+
+func TestConfig_ProviderProxyURL(t *testing.T) {
+	config := &Config{Proxy: ProxyConfig{URL: "http://global:8080"}}
+	if got := config.ProviderProxyURL(ProviderConfig{}); got != "" {
+		t.Errorf("disabled global proxy used: %q", got)
+	}
+
+	config.Proxy.Enabled = true
+	if got := config.ProviderProxyURL(ProviderConfig{}); got != "http://global:8080" {
+		t.Errorf("got %q, want the global proxy", got)
+	}
+	if got := config.ProviderProxyURL(ProviderConfig{ProxyURL: "socks5://local:1080"}); got != "socks5://local:1080" {
+		t.Errorf("got %q, want the provider proxy", got)
+	}
+}
+
+func TestProviderConfig_RequestTimeout(t *testing.T) {
+	if got := (ProviderConfig{}).RequestTimeout(30 * time.Second); got != 30*time.Second {
+		t.Errorf("got %v, want the fallback", got)
+	}
+	if got := (ProviderConfig{TimeoutSeconds: 600}).RequestTimeout(30 * time.Second); got != 10*time.Minute {
+		t.Errorf("got %v, want the configured timeout", got)
+	}
+}
+
+func TestUIConfig_MessageCacheSize(t *testing.T) {
+	tests := []struct {
+		size int
+		want int
+	}{
+		{0, DefaultCacheSize},
+		{1, MinCacheSize},
+		{25, 25},
+		{1000, MaxCacheSize},
+	}
+	for _, tt := range tests {
+		if got := (UIConfig{CacheSize: tt.size}).MessageCacheSize(); got != tt.want {
+			t.Errorf("MessageCacheSize() with %d = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"fmt"
+	"light-llm-client/llm"
+	"math"
+	"net/url"
+	"os"
+	"sort"
+)
+
+// Limits checked by ValidateConfig
+const (
+	minWindowWidth  = 400
+	minWindowHeight = 300
+	minTemperature  = 0.0
+	maxTemperature  = 2.0
+)
+
+// ConfigError describes a semantic problem with a configuration field.
+// Field is the JSON path of the field, e.g. "llm_providers.ollama.api_key".
+type ConfigError struct {
+	Field   string
+	Message string
+}
+
+// Error implements the error interface
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidateConfig checks the configuration for values that parse but make no
+// sense. Nothing is changed; the caller decides how to report the errors.
+func ValidateConfig(c *Config) []ConfigError {
+	var errs []ConfigError
+
+	// Sort provider names so the errors come out in a stable order
+	names := make([]string, 0, len(c.LLMProviders))
+	for name := range c.LLMProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, ValidateProviderConfig(name, c.LLMProviders[name])...)
+	}
+
+	errs = append(errs, validateUIConfig(c.UI)...)
+	errs = append(errs, validateDataConfig(c.Data)...)
+
+	if c.Proxy.Enabled {
+		if c.Proxy.URL == "" {
+			errs = append(errs, ConfigError{"proxy.url", "proxy is enabled but no URL is set"})
+		} else if msg := checkURL(c.Proxy.URL, "http", "https", "socks5", "socks5h"); msg != "" {
+			errs = append(errs, ConfigError{"proxy.url", msg})
+		}
+	}
+
+	if c.Sync.WebDAVURL != "" {
+		if msg := checkURL(c.Sync.WebDAVURL, "http", "https"); msg != "" {
+			errs = append(errs, ConfigError{"sync.webdav_url", msg})
+		}
+	}
+	if c.Sync.SyncIntervalMinutes < 0 {
+		errs = append(errs, ConfigError{"sync.sync_interval_minutes", "must not be negative"})
+	}
+	if c.Update.UpdateCheckIntervalDays < 0 {
+		errs = append(errs, ConfigError{"update.update_check_interval_days", "must not be negative"})
+	}
+
+	if c.Export.Schedule != "" {
+		if _, err := ParseCronSchedule(c.Export.Schedule); err != nil {
+			errs = append(errs, ConfigError{"export.schedule", err.Error()})
+		}
+	}
+	if err := checkScheduledExportFormat(c.Export.Format); err != nil {
+		errs = append(errs, ConfigError{"export.format", err.Error()})
+	}
+
+	if c.Log.MaxSizeMB < 0 {
+		errs = append(errs, ConfigError{"log.max_size_mb", "must not be negative"})
+	}
+	if c.Log.MaxBackups < 0 {
+		errs = append(errs, ConfigError{"log.max_backups", "must not be negative"})
+	}
+	if c.Log.MaxAgeDays < 0 {
+		errs = append(errs, ConfigError{"log.max_age_days", "must not be negative"})
+	}
+
+	return errs
+}
+
+// ValidateProviderConfig checks a single provider configuration
+func ValidateProviderConfig(name string, p ProviderConfig) []ConfigError {
+	var errs []ConfigError
+	field := func(key string) string {
+		return fmt.Sprintf("llm_providers.%s.%s", name, key)
+	}
+
+	if name == "ollama" && p.APIKey != "" {
+		errs = append(errs, ConfigError{field("api_key"), "Ollama does not use an API key"})
+	}
+
+	if name == "azure" || name == "azure_openai" {
+		if p.DeploymentName == "" {
+			errs = append(errs, ConfigError{field("deployment_name"), "must be set for Azure OpenAI"})
+		}
+		if p.APIVersion == "" {
+			errs = append(errs, ConfigError{field("api_version"), "must be set for Azure OpenAI"})
+		}
+	}
+
+	if err := llm.ValidateReasoningEffort(p.ReasoningEffort); err != nil {
+		errs = append(errs, ConfigError{field("reasoning_effort"), err.Error()})
+	}
+
+	if p.BaseURL != "" {
+		if msg := checkURL(p.BaseURL, "http", "https"); msg != "" {
+			errs = append(errs, ConfigError{field("base_url"), msg})
+		}
+	}
+	if p.ProxyURL != "" {
+		if msg := checkURL(p.ProxyURL, "http", "https", "socks5", "socks5h"); msg != "" {
+			errs = append(errs, ConfigError{field("proxy_url"), msg})
+		}
+	}
+
+	if math.IsNaN(p.Temperature) || p.Temperature < minTemperature || p.Temperature > maxTemperature {
+		errs = append(errs, ConfigError{field("temperature"),
+			fmt.Sprintf("must be between %.1f and %.1f, got %v", minTemperature, maxTemperature, p.Temperature)})
+	}
+
+	if p.MaxTokens < 0 {
+		errs = append(errs, ConfigError{field("max_tokens"), "must not be negative"})
+	}
+	if p.TimeoutSeconds < 0 {
+		errs = append(errs, ConfigError{field("timeout_seconds"), "must not be negative"})
+	}
+	if p.RateLimitRPS < 0 {
+		errs = append(errs, ConfigError{field("rate_limit_rps"), "must not be negative"})
+	}
+
+	for i, model := range p.Models {
+		if model == "" {
+			errs = append(errs, ConfigError{field(fmt.Sprintf("models[%d]", i)), "model name is empty"})
+		}
+	}
+
+	return errs
+}
+
+// validateUIConfig checks the UI configuration
+func validateUIConfig(ui UIConfig) []ConfigError {
+	var errs []ConfigError
+
+	switch ui.Theme {
+	case "", "light", "dark", "auto":
+	default:
+		errs = append(errs, ConfigError{"ui.theme", fmt.Sprintf("unknown theme %q (expected light, dark or auto)", ui.Theme)})
+	}
+
+	if ui.FontSize < 0 {
+		errs = append(errs, ConfigError{"ui.font_size", "must not be negative"})
+	}
+
+	// Zero means the size was never saved and the default is used
+	if ui.WindowWidth != 0 && ui.WindowWidth < minWindowWidth {
+		errs = append(errs, ConfigError{"ui.window_width", fmt.Sprintf("must be at least %d, got %d", minWindowWidth, ui.WindowWidth)})
+	}
+	if ui.WindowHeight != 0 && ui.WindowHeight < minWindowHeight {
+		errs = append(errs, ConfigError{"ui.window_height", fmt.Sprintf("must be at least %d, got %d", minWindowHeight, ui.WindowHeight)})
+	}
+
+	used := make(map[rune]bool)
+	for i, prompt := range ui.QuickPrompts {
+		if prompt.Key == 0 {
+			continue
+		}
+		field := fmt.Sprintf("ui.quick_prompts[%d].key", i)
+		if prompt.Key < '1' || prompt.Key > '9' {
+			errs = append(errs, ConfigError{field, fmt.Sprintf("must be 1-9, got %q", prompt.Key)})
+		} else if used[prompt.Key] {
+			errs = append(errs, ConfigError{field, fmt.Sprintf("key %c is used by another prompt", prompt.Key)})
+		}
+		used[prompt.Key] = true
+	}
+
+	if ui.GlobalHotkey != "" {
+		if _, err := ParseHotkey(ui.GlobalHotkey); err != nil {
+			errs = append(errs, ConfigError{"ui.global_hotkey", err.Error()})
+		}
+	}
+
+	return errs
+}
+
+// validateDataConfig checks the data configuration
+func validateDataConfig(data DataConfig) []ConfigError {
+	var errs []ConfigError
+
+	if data.DBPath == "" {
+		errs = append(errs, ConfigError{"data.db_path", "database path is required"})
+	} else if stat, err := os.Stat(data.DBPath); err == nil && stat.IsDir() {
+		errs = append(errs, ConfigError{"data.db_path", "points to a directory, not a database file"})
+	}
+
+	if data.MaxHistory < 0 {
+		errs = append(errs, ConfigError{"data.max_history", "must not be negative"})
+	}
+	if data.SummarizeAfterMessages < 0 {
+		errs = append(errs, ConfigError{"data.summarize_after_messages", "must not be negative"})
+	}
+
+	return errs
+}
+
+// checkURL returns a description of what is wrong with rawURL, or "" if it
+// is an absolute URL with one of the given schemes
+func checkURL(rawURL string, schemes ...string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Sprintf("invalid URL: %v", err)
+	}
+	if u.Host == "" {
+		return fmt.Sprintf("URL %q has no host", rawURL)
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return ""
+		}
+	}
+	return fmt.Sprintf("unsupported URL scheme %q", u.Scheme)
+}
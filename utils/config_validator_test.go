@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func validTestConfig(t *testing.T) *Config {
+	t.Helper()
+	return &Config{
+		LLMProviders: map[string]ProviderConfig{
+			"openai": {APIKey: "sk-test", BaseURL: "https://api.openai.com/v1", Temperature: 0.7, Enabled: true},
+			"ollama": {BaseURL: "http://localhost:11434"},
+		},
+		UI:   UIConfig{Theme: "light", FontSize: 14, WindowWidth: 1200, WindowHeight: 800, QuickPrompts: DefaultQuickPrompts()},
+		Data: DataConfig{DBPath: t.TempDir() + "/chat.db", MaxHistory: 1000},
+	}
+}
+
+func TestValidateConfig_Valid(t *testing.T) {
+	if errs := ValidateConfig(validTestConfig(t)); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func TestValidateConfig_Theme(t *testing.T) {
+	for theme, wantErr := range map[string]bool{"light": false, "dark": false, "auto": false, "": false, "blue": true} {
+		config := validTestConfig(t)
+		config.UI.Theme = theme
+		if errs := ValidateConfig(config); (len(errs) != 0) != wantErr {
+			t.Errorf("theme %q: got errors %v, want error: %v", theme, errs, wantErr)
+		}
+	}
+}
+
+func TestValidateConfig_ReportsSemanticErrors(t *testing.T) {
+	config := validTestConfig(t)
+	config.LLMProviders["ollama"] = ProviderConfig{APIKey: "unused"}
+	config.LLMProviders["openai"] = ProviderConfig{BaseURL: "api.openai.com", Temperature: 2.5, TimeoutSeconds: -1}
+	config.LLMProviders["azure"] = ProviderConfig{BaseURL: "https://example.openai.azure.com"}
+	config.LLMProviders["claude"] = ProviderConfig{APIKey: "key", ProxyURL: "ftp://proxy:21"}
+	config.LLMProviders["xai"] = ProviderConfig{APIKey: "key", ReasoningEffort: "max"}
+	config.UI.WindowWidth = 200
+	config.UI.WindowHeight = 100
+	config.UI.GlobalHotkey = "l"
+	config.Data.DBPath = t.TempDir()
+	config.Update.UpdateCheckIntervalDays = -1
+
+	want := map[string]bool{
+		"llm_providers.ollama.api_key":         true,
+		"llm_providers.openai.base_url":        true,
+		"llm_providers.openai.temperature":     true,
+		"llm_providers.openai.timeout_seconds": true,
+		"llm_providers.azure.deployment_name":  true,
+		"llm_providers.azure.api_version":      true,
+		"llm_providers.claude.proxy_url":       true,
+		"llm_providers.xai.reasoning_effort":   true,
+		"ui.window_width":                      true,
+		"ui.window_height":                     true,
+		"ui.global_hotkey":                     true,
+		"data.db_path":                         true,
+		"update.update_check_interval_days":    true,
+	}
+
+	errs := ValidateConfig(config)
+	got := make(map[string]bool)
+	for _, err := range errs {
+		got[err.Field] = true
+	}
+	for field := range want {
+		if !got[field] {
+			t.Errorf("missing error for %s", field)
+		}
+	}
+	if len(errs) != len(want) {
+		t.Errorf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+}
+
+func FuzzValidateProviderConfig(f *testing.F) {
+	f.Add("openai", "sk-test", "https://api.openai.com/v1", "gpt-4", 4096, 0.7, 0, true)
+	f.Add("ollama", "key", "http://[::1", "", -1, math.NaN(), -5, false)
+	f.Add("", "", "%zz", "model", 0, math.Inf(1), 1, true)
+
+	f.Fuzz(func(t *testing.T, name, apiKey, baseURL, model string, maxTokens int, temperature float64, rps int, enabled bool) {
+		config := ProviderConfig{
+			APIKey:       apiKey,
+			BaseURL:      baseURL,
+			DefaultModel: model,
+			Models:       []string{model, ""},
+			Enabled:      enabled,
+			MaxTokens:    maxTokens,
+			Temperature:  temperature,
+			RateLimitRPS: rps,
+		}
+		for _, err := range ValidateProviderConfig(name, config) {
+			if err.Field == "" || err.Message == "" {
+				t.Fatalf("incomplete error: %+v", err)
+			}
+		}
+	})
+}
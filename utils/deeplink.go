@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DeepLinkScheme is the URL scheme other apps use to control the client
+const DeepLinkScheme = "light-llm"
+
+// Actions of light-llm:// URLs
+const (
+	DeepLinkOpen   = "open"   // light-llm://open?conversation=123 or ?message=456
+	DeepLinkNew    = "new"    // light-llm://new[?title=...]
+	DeepLinkSearch = "search" // light-llm://search?q=...
+)
+
+// maxDeepLinkSize limits the URL read from a connection
+const maxDeepLinkSize = 8 * 1024
+
+// ParseLightLLMURL parses a light-llm:// URL into its action and query
+// parameters, checking the parameters each action needs
+func ParseLightLLMURL(rawURL string) (action string, params map[string]string, err error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if !strings.EqualFold(u.Scheme, DeepLinkScheme) {
+		return "", nil, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+
+	// light-llm://open?... has the action as host, light-llm:open?... as opaque part
+	action = u.Host
+	if action == "" {
+		action = u.Opaque
+	}
+	if action == "" {
+		action = strings.Trim(u.Path, "/")
+	}
+	action = strings.ToLower(action)
+
+	params = make(map[string]string)
+	for key, values := range u.Query() {
+		if len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+
+	switch action {
+	case DeepLinkOpen:
+		key := "conversation"
+		if _, ok := params["message"]; ok {
+			key = "message"
+		}
+		id, err := strconv.ParseInt(params[key], 10, 64)
+		if err != nil || id <= 0 {
+			return "", nil, fmt.Errorf("open needs a conversation or message ID, got %q", params[key])
+		}
+	case DeepLinkNew:
+	case DeepLinkSearch:
+		if strings.TrimSpace(params["q"]) == "" {
+			return "", nil, fmt.Errorf("search needs a query")
+		}
+	default:
+		return "", nil, fmt.Errorf("unknown action %q", action)
+	}
+	return action, params, nil
+}
+
+// DeepLinkSocketPath returns the path of the socket the running instance
+// receives URLs on
+func DeepLinkSocketPath() string {
+	return filepath.Join(filepath.Dir(GetConfigPath()), "deeplink.sock")
+}
+
+// SendDeepLink passes a URL to the instance listening on socketPath
+func SendDeepLink(socketPath, rawURL string) error {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to connect to running instance: %w", err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Write([]byte(rawURL + "\n")); err != nil {
+		return fmt.Errorf("failed to send URL: %w", err)
+	}
+	return nil
+}
+
+// DeepLinkServer receives the URLs sent by instances launched later
+type DeepLinkServer struct {
+	listener net.Listener
+	handler  func(rawURL string)
+	logger   *Logger
+	wg       sync.WaitGroup
+}
+
+// NewDeepLinkServer listens on socketPath and calls handler with every URL
+// received. A socket left behind by a crashed instance is replaced; if
+// another instance is still listening an error is returned.
+func NewDeepLinkServer(socketPath string, logger *Logger, handler func(rawURL string)) (*DeepLinkServer, error) {
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if _, err := os.Stat(socketPath); err == nil {
+		if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another instance is listening on %s", socketPath)
+		}
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+
+	s := &DeepLinkServer{listener: listener, handler: handler, logger: logger}
+	s.wg.Add(1)
+	SafeGo(logger, "deep link server", s.serve)
+	return s, nil
+}
+
+// serve accepts connections until the listener is closed
+func (s *DeepLinkServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("Deep link server stopped: %v", err)
+			}
+			return
+		}
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		scanner := bufio.NewScanner(conn)
+		scanner.Buffer(make([]byte, 0, 1024), maxDeepLinkSize)
+		if scanner.Scan() {
+			if rawURL := strings.TrimSpace(scanner.Text()); rawURL != "" {
+				s.logger.Info("Received deep link: %s", rawURL)
+				s.handler(rawURL)
+			}
+		}
+		conn.Close()
+	}
+}
+
+// Close stops listening and removes the socket
+func (s *DeepLinkServer) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
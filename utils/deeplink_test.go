@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLightLLMURL(t *testing.T) {
+	tests := []struct {
+		url        string
+		wantAction string
+		wantParams map[string]string
+		wantErr    bool
+	}{
+		{"light-llm://open?conversation=123", "open", map[string]string{"conversation": "123"}, false},
+		{"LIGHT-LLM://Open?conversation=7", "open", map[string]string{"conversation": "7"}, false},
+		{"light-llm:open?conversation=7", "open", map[string]string{"conversation": "7"}, false},
+		{"light-llm://open?message=42", "open", map[string]string{"message": "42"}, false},
+		{"light-llm://new", "new", map[string]string{}, false},
+		{"light-llm://new?title=Go%20%E5%AD%A6%E4%B9%A0", "new", map[string]string{"title": "Go 学习"}, false},
+		{"light-llm://search?q=sqlite+fts5", "search", map[string]string{"q": "sqlite fts5"}, false},
+		{"light-llm://open", "", nil, true},
+		{"light-llm://open?conversation=abc", "", nil, true},
+		{"light-llm://open?message=0", "", nil, true},
+		{"light-llm://search?q=", "", nil, true},
+		{"light-llm://delete?conversation=1", "", nil, true},
+		{"https://open?conversation=1", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		action, params, err := ParseLightLLMURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLightLLMURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if action != tt.wantAction || (!tt.wantErr && !reflect.DeepEqual(params, tt.wantParams)) {
+			t.Errorf("ParseLightLLMURL(%q) = %q, %v, want %q, %v", tt.url, action, params, tt.wantAction, tt.wantParams)
+		}
+	}
+}
+
+func TestDeepLinkServer(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewLogger(filepath.Join(dir, "test.log"))
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	defer logger.Close()
+
+	socketPath := filepath.Join(dir, "deeplink.sock")
+	if err := SendDeepLink(socketPath, "light-llm://new"); err == nil {
+		t.Fatal("expected an error without a running instance")
+	}
+
+	// A socket file left behind by a crashed instance is replaced
+	if err := os.WriteFile(socketPath, nil, 0644); err != nil {
+		t.Fatalf("failed to create stale socket: %v", err)
+	}
+
+	received := make(chan string, 1)
+	server, err := NewDeepLinkServer(socketPath, logger, func(rawURL string) {
+		received <- rawURL
+	})
+	if err != nil {
+		t.Fatalf("NewDeepLinkServer failed: %v", err)
+	}
+	defer server.Close()
+
+	if _, err := NewDeepLinkServer(socketPath, logger, func(string) {}); err == nil {
+		t.Error("expected an error while another instance is listening")
+	}
+
+	if err := SendDeepLink(socketPath, "light-llm://open?conversation=123"); err != nil {
+		t.Fatalf("SendDeepLink failed: %v", err)
+	}
+	select {
+	case got := <-received:
+		if got != "light-llm://open?conversation=123" {
+			t.Errorf("received %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("URL was not received")
+	}
+}
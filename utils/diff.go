@@ -0,0 +1,100 @@
+package utils
+
+import "strings"
+
+// DiffOp is the kind of change of a diff line
+type DiffOp int
+
+const (
+	DiffEqual  DiffOp = iota // Line is in both texts
+	DiffDelete               // Line is only in the old text
+	DiffInsert               // Line is only in the new text
+)
+
+// DiffLine is one line of a line-based diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// DiffText computes a line diff between two texts
+func DiffText(oldText, newText string) []DiffLine {
+	return DiffLines(strings.Split(oldText, "\n"), strings.Split(newText, "\n"))
+}
+
+// DiffLines computes a shortest edit script turning a into b using the
+// Myers O(ND) algorithm. Deletions come before insertions within a change.
+func DiffLines(a, b []string) []DiffLine {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	// v[offset+k] is the furthest x reached on diagonal k = x - y.
+	// trace[d] keeps the diagonals -d..d of v as they were before round d,
+	// the only ones backtracking reads, at index k+d.
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // step down: insertion
+			} else {
+				x = v[offset+k-1] + 1 // step right: deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk back from the end, collecting the script in reverse
+	var lines []DiffLine
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[d+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, DiffLine{Op: DiffEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: b[y-1]})
+		} else {
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
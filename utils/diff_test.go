@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// applyDiff rebuilds the old and new texts from a diff
+func applyDiff(lines []DiffLine) (oldLines, newLines []string) {
+	for _, line := range lines {
+		if line.Op != DiffInsert {
+			oldLines = append(oldLines, line.Text)
+		}
+		if line.Op != DiffDelete {
+			newLines = append(newLines, line.Text)
+		}
+	}
+	return oldLines, newLines
+}
+
+func TestDiffLines_ShortestEditScript(t *testing.T) {
+	// The example from Myers' paper: the shortest edit script has 5 edits
+	a := strings.Split("ABCABBA", "")
+	b := strings.Split("CBABAC", "")
+
+	lines := DiffLines(a, b)
+	edits := 0
+	for _, line := range lines {
+		if line.Op != DiffEqual {
+			edits++
+		}
+	}
+	if edits != 5 {
+		t.Errorf("got %d edits, want 5: %+v", edits, lines)
+	}
+
+	oldLines, newLines := applyDiff(lines)
+	if !reflect.DeepEqual(oldLines, a) || !reflect.DeepEqual(newLines, b) {
+		t.Errorf("diff does not rebuild the inputs: %+v", lines)
+	}
+}
+
+func TestDiffLines_Random(t *testing.T) {
+	// lcs is the length of the longest common subsequence of a and b
+	lcs := func(a, b []string) int {
+		prev := make([]int, len(b)+1)
+		for i := range a {
+			cur := make([]int, len(b)+1)
+			for j := range b {
+				switch {
+				case a[i] == b[j]:
+					cur[j+1] = prev[j] + 1
+				case prev[j+1] > cur[j]:
+					cur[j+1] = prev[j+1]
+				default:
+					cur[j+1] = cur[j]
+				}
+			}
+			prev = cur
+		}
+		return prev[len(b)]
+	}
+	random := func(r *rand.Rand) []string {
+		lines := make([]string, r.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.Intn(4)))
+		}
+		return lines
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		a, b := random(r), random(r)
+		lines := DiffLines(a, b)
+		edits := 0
+		for _, line := range lines {
+			if line.Op != DiffEqual {
+				edits++
+			}
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); edits != want {
+			t.Fatalf("DiffLines(%q, %q) has %d edits, want %d", a, b, edits, want)
+		}
+		oldLines, newLines := applyDiff(lines)
+		if strings.Join(oldLines, "") != strings.Join(a, "") || strings.Join(newLines, "") != strings.Join(b, "") {
+			t.Fatalf("DiffLines(%q, %q) does not rebuild the inputs: %+v", a, b, lines)
+		}
+	}
+}
+
+func TestDiffText(t *testing.T) {
+	lines := DiffText("first\nsecond\nthird", "first\nchanged\nthird\nfourth")
+	want := []DiffLine{
+		{DiffEqual, "first"},
+		{DiffDelete, "second"},
+		{DiffInsert, "changed"},
+		{DiffEqual, "third"},
+		{DiffInsert, "fourth"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got %+v, want %+v", lines, want)
+	}
+}
+
+func TestDiffLines_EmptyInputs(t *testing.T) {
+	if lines := DiffLines(nil, nil); len(lines) != 0 {
+		t.Errorf("expected no lines, got %+v", lines)
+	}
+
+	lines := DiffLines(nil, []string{"a", "b"})
+	if len(lines) != 2 || lines[0].Op != DiffInsert || lines[1].Op != DiffInsert {
+		t.Errorf("expected two insertions, got %+v", lines)
+	}
+
+	lines = DiffLines([]string{"a"}, nil)
+	if len(lines) != 1 || lines[0].Op != DiffDelete {
+		t.Errorf("expected one deletion, got %+v", lines)
+	}
+}
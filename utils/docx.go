@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DocxMimeType is the MIME type of Word documents
+const DocxMimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// docxNamespace is the WordprocessingML namespace of the document elements
+const docxNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// maxDocxXMLSize limits the uncompressed document.xml read from an upload
+const maxDocxXMLSize = 50 * 1024 * 1024
+
+// ExtractDocxText returns the plain text of a .docx file. It reads the main
+// document part (word/document.xml) directly: paragraphs become lines, tabs
+// and breaks are kept and formatting is dropped. Headers, footers and
+// comments are not included.
+func ExtractDocxText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open document: %w", err)
+	}
+
+	var part *zip.File
+	for _, f := range archive.File {
+		if f.Name == "word/document.xml" {
+			part = f
+			break
+		}
+	}
+	if part == nil {
+		return "", fmt.Errorf("document has no word/document.xml")
+	}
+
+	r, err := part.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to read document: %w", err)
+	}
+	defer r.Close()
+
+	var sb strings.Builder
+	decoder := xml.NewDecoder(io.LimitReader(r, maxDocxXMLSize))
+	inText := false
+	inTabStops := false // <w:tabs> of the paragraph properties also holds <w:tab> elements
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse document: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != docxNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tabs":
+				inTabStops = true
+			case "tab":
+				if !inTabStops {
+					sb.WriteByte('\t')
+				}
+			case "br", "cr":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name.Space != docxNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "tabs":
+				inTabStops = false
+			case "p":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
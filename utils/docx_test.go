@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestDocx builds a minimal Word document around the body XML
+func newTestDocx(t *testing.T, body string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatalf("failed to create document part: %v", err)
+	}
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to write document: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractDocxText(t *testing.T) {
+	data := newTestDocx(t, `
+		<w:p><w:r><w:rPr><w:b/></w:rPr><w:t>Hello</w:t></w:r><w:r><w:t xml:space="preserve"> world</w:t></w:r></w:p>
+		<w:p><w:pPr><w:tabs><w:tab w:val="left" w:pos="720"/></w:tabs></w:pPr><w:r><w:t>a</w:t><w:tab/><w:t>b</w:t><w:br/><w:t>c &amp; d</w:t></w:r></w:p>
+		<w:p><w:r><w:t>第三段</w:t></w:r></w:p>`)
+
+	text, err := ExtractDocxText(data)
+	if err != nil {
+		t.Fatalf("ExtractDocxText failed: %v", err)
+	}
+	want := "Hello world\na\tb\nc & d\n第三段"
+	if text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestExtractDocxText_Invalid(t *testing.T) {
+	if _, err := ExtractDocxText([]byte("not a zip")); err == nil {
+		t.Error("expected an error for data that is not a document")
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	zw.Create("other.xml")
+	zw.Close()
+	if _, err := ExtractDocxText(buf.Bytes()); err == nil {
+		t.Error("expected an error for an archive without word/document.xml")
+	}
+}
+
+func TestProcessFile_Docx(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.docx")
+	if err := os.WriteFile(path, newTestDocx(t, `<w:p><w:r><w:t>Meeting notes</w:t></w:r></w:p>`), 0644); err != nil {
+		t.Fatalf("failed to write document: %v", err)
+	}
+
+	att, err := NewFileUploadHandler().ProcessFile(path)
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if att.Type != "file" || att.MimeType != DocxMimeType {
+		t.Errorf("unexpected attachment type %q / %q", att.Type, att.MimeType)
+	}
+	if got := GetTextContent(att); got != "Meeting notes" {
+		t.Errorf("GetTextContent = %q, want %q", got, "Meeting notes")
+	}
+}
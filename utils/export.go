@@ -1,11 +1,15 @@
 package utils
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"html"
 	"light-llm-client/db"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,6 +20,9 @@ type ExportFormat string
 const (
 	FormatJSON     ExportFormat = "json"
 	FormatMarkdown ExportFormat = "markdown"
+	FormatCSV      ExportFormat = "csv"
+	FormatPDF      ExportFormat = "pdf"
+	FormatHTML     ExportFormat = "html"
 )
 
 // ConversationExport represents a conversation export structure
@@ -27,6 +34,9 @@ type ConversationExport struct {
 	UpdatedAt time.Time          `json:"updated_at"`
 	Messages  []MessageExport    `json:"messages"`
 	Metadata  map[string]string  `json:"metadata,omitempty"`
+	// ParamsOverride are the conversation's sampling settings, if it has any
+	ParamsOverride *db.ConversationParams `json:"params_override,omitempty"`
+	Pinned         bool                   `json:"pinned,omitempty"`
 }
 
 // MessageExport represents a message export structure
@@ -38,6 +48,7 @@ type MessageExport struct {
 	Model       string    `json:"model"`
 	Attachments string    `json:"attachments,omitempty"`
 	TokensUsed  int       `json:"tokens_used,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
@@ -55,14 +66,21 @@ func ExportConversationToJSON(database *db.DB, conversationID int64, filepath st
 		return fmt.Errorf("failed to get messages: %w", err)
 	}
 
+	tags, err := database.ListConversationTags(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get message tags: %w", err)
+	}
+
 	// Build export structure
 	export := ConversationExport{
-		ID:        conv.ID,
-		Title:     conv.Title,
-		Category:  conv.Category,
-		CreatedAt: conv.CreatedAt,
-		UpdatedAt: conv.UpdatedAt,
-		Messages:  make([]MessageExport, 0, len(messages)),
+		ID:             conv.ID,
+		Title:          conv.Title,
+		Category:       conv.Category,
+		CreatedAt:      conv.CreatedAt,
+		UpdatedAt:      conv.UpdatedAt,
+		Messages:       make([]MessageExport, 0, len(messages)),
+		ParamsOverride: conv.ParamsOverride,
+		Pinned:         conv.Pinned,
 		Metadata: map[string]string{
 			"export_version": "1.0",
 			"export_date":    time.Now().Format(time.RFC3339),
@@ -79,6 +97,7 @@ func ExportConversationToJSON(database *db.DB, conversationID int64, filepath st
 			Model:       msg.Model,
 			Attachments: msg.Attachments,
 			TokensUsed:  msg.TokensUsed,
+			Tags:        tags[msg.ID],
 			CreatedAt:   msg.CreatedAt,
 		})
 	}
@@ -126,16 +145,7 @@ func ExportConversationToMarkdown(database *db.DB, conversationID int64, filepat
 	// Messages
 	for i, msg := range messages {
 		// Role header
-		roleIcon := "👤"
-		roleName := "用户"
-		if msg.Role == "assistant" {
-			roleIcon = "🤖"
-			roleName = "助手"
-		} else if msg.Role == "system" {
-			roleIcon = "⚙️"
-			roleName = "系统"
-		}
-
+		roleIcon, roleName := roleLabel(msg.Role)
 		sb.WriteString(fmt.Sprintf("## %s %s\n\n", roleIcon, roleName))
 		
 		// Metadata
@@ -166,6 +176,155 @@ func ExportConversationToMarkdown(database *db.DB, conversationID int64, filepat
 	return nil
 }
 
+// roleLabel returns the icon and the name shown for a message role
+func roleLabel(role string) (icon, name string) {
+	switch role {
+	case "assistant":
+		return "🤖", "助手"
+	case "system":
+		return "⚙️", "系统"
+	default:
+		return "👤", "用户"
+	}
+}
+
+// ExportConversationToHTML exports a conversation as a single HTML file that
+// needs nothing else to display: the stylesheet and the script of the copy
+// buttons on code blocks are inline. User messages are shown as bubbles on
+// the right, the others on the left, and a table of contents at the top links
+// to each message.
+func ExportConversationToHTML(database *db.DB, conversationID int64, filepath string) error {
+	conv, err := database.GetConversation(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	messages, err := database.ListMessages(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	content, err := renderConversationHTML(conv, messages)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// tocExcerptRunes is the length of a message's entry in the table of contents
+const tocExcerptRunes = 60
+
+// renderConversationHTML renders the page of an HTML export
+func renderConversationHTML(conv *db.Conversation, messages []*db.Message) (string, error) {
+	markdown := newHTMLMarkdown()
+	title := html.EscapeString(conv.Title)
+
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n", title)
+	sb.WriteString(conversationHTMLStyle)
+	sb.WriteString("</head>\n<body>\n")
+
+	// Header
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n<p class=\"meta\">", title)
+	if conv.Category != "" {
+		fmt.Fprintf(&sb, "分类: %s | ", html.EscapeString(conv.Category))
+	}
+	fmt.Fprintf(&sb, "创建时间: %s | 更新时间: %s</p>\n",
+		conv.CreatedAt.Format("2006-01-02 15:04:05"), conv.UpdatedAt.Format("2006-01-02 15:04:05"))
+
+	// Table of contents
+	sb.WriteString("<nav class=\"toc\">\n<h2>目录</h2>\n<ol>\n")
+	for i, msg := range messages {
+		icon, name := roleLabel(msg.Role)
+		excerpt := strings.Join(strings.Fields(msg.Content), " ")
+		fmt.Fprintf(&sb, "<li><a href=\"#msg-%d\">%s %s: %s</a></li>\n",
+			i+1, icon, name, html.EscapeString(truncateRunes(excerpt, tocExcerptRunes)))
+	}
+	sb.WriteString("</ol>\n</nav>\n")
+
+	// Messages
+	for i, msg := range messages {
+		content, err := markdown(msg.Content)
+		if err != nil {
+			return "", err
+		}
+		side := "left"
+		if msg.Role == "user" {
+			side = "right"
+		}
+		icon, name := roleLabel(msg.Role)
+
+		fmt.Fprintf(&sb, "<div class=\"message %s %s\" id=\"msg-%d\">\n<div class=\"bubble\">\n", side, html.EscapeString(msg.Role), i+1)
+		fmt.Fprintf(&sb, "<div class=\"role\">%s %s", icon, name)
+		if msg.Role == "assistant" && msg.Model != "" {
+			fmt.Fprintf(&sb, " <span class=\"model\">%s</span>", html.EscapeString(msg.Model))
+		}
+		sb.WriteString("</div>\n")
+		sb.WriteString(content)
+		sb.WriteString("</div>\n</div>\n")
+	}
+
+	// Footer
+	fmt.Fprintf(&sb, "<p class=\"meta footer\">导出时间: %s | 导出工具: Light LLM Client</p>\n", time.Now().Format("2006-01-02 15:04:05"))
+	sb.WriteString(conversationHTMLScript)
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String(), nil
+}
+
+// conversationHTMLStyle is the stylesheet of the HTML export
+const conversationHTMLStyle = `<style>
+body { font-family: -apple-system, "Segoe UI", "Microsoft YaHei", sans-serif; max-width: 900px; margin: 24px auto; padding: 0 16px; color: #222; background: #fafafa; }
+h1 { font-size: 1.5em; margin-bottom: 4px; }
+.meta { color: #777; margin-top: 0; font-size: 0.9em; }
+.footer { margin-top: 32px; text-align: center; }
+.toc { background: #fff; border: 1px solid #e3e3e3; border-radius: 8px; padding: 8px 16px; margin-bottom: 24px; }
+.toc h2 { font-size: 1.1em; }
+.toc li { margin: 2px 0; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+.toc a { color: #0366d6; text-decoration: none; }
+.toc a:hover { text-decoration: underline; }
+.message { display: flex; margin: 12px 0; }
+.message.right { justify-content: flex-end; }
+.message.left { justify-content: flex-start; }
+.bubble { max-width: 80%; padding: 8px 14px; border-radius: 12px; overflow-wrap: anywhere; }
+.message.right .bubble { background: #dcf0ff; border-bottom-right-radius: 2px; }
+.message.left .bubble { background: #fff; border: 1px solid #e3e3e3; border-bottom-left-radius: 2px; }
+.message.system .bubble { background: #f3f3f3; color: #555; }
+.role { font-weight: bold; font-size: 0.85em; margin-bottom: 4px; }
+.model { font-weight: normal; color: #777; margin-left: 4px; }
+pre { position: relative; background: #f6f8fa; padding: 10px; border-radius: 4px; overflow-x: auto; }
+code { font-family: Consolas, Menlo, monospace; font-size: 0.9em; }
+.copy { position: absolute; top: 4px; right: 4px; font-size: 0.75em; padding: 2px 6px; border: 1px solid #ccc; border-radius: 4px; background: #fff; cursor: pointer; }
+.kw { color: #d73a49; font-weight: bold; }
+.str { color: #032f62; }
+.com { color: #6a737d; font-style: italic; }
+.num { color: #005cc5; }
+</style>
+`
+
+// conversationHTMLScript adds a copy button to each code block of the export
+const conversationHTMLScript = `<script>
+document.querySelectorAll("pre > code").forEach(function (code) {
+  var button = document.createElement("button");
+  button.className = "copy";
+  button.textContent = "复制";
+  button.onclick = function () {
+    navigator.clipboard.writeText(code.innerText).then(function () {
+      button.textContent = "已复制";
+      setTimeout(function () { button.textContent = "复制"; }, 1500);
+    });
+  };
+  code.parentNode.appendChild(button);
+});
+</script>
+`
+
 // ExportAllConversations exports all conversations to a single JSON file
 func ExportAllConversations(database *db.DB, filepath string) error {
 	// Get all conversations
@@ -183,13 +342,20 @@ func ExportAllConversations(database *db.DB, filepath string) error {
 			return fmt.Errorf("failed to get messages for conversation %d: %w", conv.ID, err)
 		}
 
+		tags, err := database.ListConversationTags(conv.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get message tags for conversation %d: %w", conv.ID, err)
+		}
+
 		export := ConversationExport{
-			ID:        conv.ID,
-			Title:     conv.Title,
-			Category:  conv.Category,
-			CreatedAt: conv.CreatedAt,
-			UpdatedAt: conv.UpdatedAt,
-			Messages:  make([]MessageExport, 0, len(messages)),
+			ID:             conv.ID,
+			Title:          conv.Title,
+			Category:       conv.Category,
+			CreatedAt:      conv.CreatedAt,
+			UpdatedAt:      conv.UpdatedAt,
+			Messages:       make([]MessageExport, 0, len(messages)),
+			ParamsOverride: conv.ParamsOverride,
+			Pinned:         conv.Pinned,
 		}
 
 		for _, msg := range messages {
@@ -201,6 +367,7 @@ func ExportAllConversations(database *db.DB, filepath string) error {
 				Model:       msg.Model,
 				Attachments: msg.Attachments,
 				TokensUsed:  msg.TokensUsed,
+				Tags:        tags[msg.ID],
 				CreatedAt:   msg.CreatedAt,
 			})
 		}
@@ -256,25 +423,14 @@ func ImportConversation(database *db.DB, filepath string) (*db.Conversation, err
 	}
 
 	// Create new conversation (don't use the original ID)
-	conv, err := database.CreateConversation(export.Title, export.Category)
+	conv, err := createImportedConversation(database, export)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create conversation: %w", err)
 	}
 
 	// Import messages
-	for _, msgExport := range export.Messages {
-		_, err := database.CreateMessage(
-			conv.ID,
-			msgExport.Role,
-			msgExport.Content,
-			msgExport.Provider,
-			msgExport.Model,
-			msgExport.Attachments,
-			msgExport.TokensUsed,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create message: %w", err)
-		}
+	if err := importMessages(database, conv.ID, export.Messages, nil); err != nil {
+		return nil, err
 	}
 
 	return conv, nil
@@ -320,25 +476,14 @@ func ImportAllConversations(database *db.DB, filepath string) (int, error) {
 		}
 
 		// Create conversation
-		conv, err := database.CreateConversation(export.Title, export.Category)
+		conv, err := createImportedConversation(database, export)
 		if err != nil {
 			return count, fmt.Errorf("failed to create conversation: %w", err)
 		}
 
 		// Import messages
-		for _, msgExport := range export.Messages {
-			_, err := database.CreateMessage(
-				conv.ID,
-				msgExport.Role,
-				msgExport.Content,
-				msgExport.Provider,
-				msgExport.Model,
-				msgExport.Attachments,
-				msgExport.TokensUsed,
-			)
-			if err != nil {
-				return count, fmt.Errorf("failed to create message: %w", err)
-			}
+		if err := importMessages(database, conv.ID, export.Messages, nil); err != nil {
+			return count, err
 		}
 
 		count++
@@ -347,6 +492,28 @@ func ImportAllConversations(database *db.DB, filepath string) (int, error) {
 	return count, nil
 }
 
+// createImportedConversation creates the conversation of an export with its
+// parameter overrides
+func createImportedConversation(database *db.DB, export ConversationExport) (*db.Conversation, error) {
+	conv, err := database.CreateConversation(export.Title, export.Category)
+	if err != nil {
+		return nil, err
+	}
+	if !export.ParamsOverride.IsEmpty() {
+		if err := database.UpdateConversationParams(conv.ID, export.ParamsOverride); err != nil {
+			return nil, err
+		}
+		conv.ParamsOverride = export.ParamsOverride
+	}
+	if export.Pinned {
+		if err := database.SetConversationPinned(conv.ID, true); err != nil {
+			return nil, err
+		}
+		conv.Pinned = true
+	}
+	return conv, nil
+}
+
 // GenerateExportFilename generates a filename for export
 func GenerateExportFilename(title string, format ExportFormat) string {
 	// Sanitize title for filename
@@ -372,8 +539,116 @@ func GenerateExportFilename(title string, format ExportFormat) string {
 	return fmt.Sprintf("%s_%s.%s", sanitized, timestamp, ext)
 }
 
-// GetDefaultExportPath returns the default export directory
-func GetDefaultExportPath() (string, error) {
+// ExportToCSV writes a header row and data rows to a CSV file
+func ExportToCSV(filepath string, headers []string, rows [][]string) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	// UTF-8 BOM so spreadsheet applications detect the encoding of non-ASCII text
+	if _, err := file.WriteString("\ufeff"); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(headers); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+
+	return nil
+}
+
+// ExportConversationToCSV exports the messages of a conversation to a CSV
+// file, one row per message. Newlines in the content are written as \n so
+// each message stays on one line.
+func ExportConversationToCSV(database *db.DB, conversationID int64, filepath string) error {
+	messages, err := database.ListMessages(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	headers := []string{"id", "role", "content", "provider", "model", "tokens_used", "created_at"}
+	rows := make([][]string, 0, len(messages))
+	for _, msg := range messages {
+		rows = append(rows, []string{
+			strconv.FormatInt(msg.ID, 10),
+			msg.Role,
+			escapeCSVNewlines(msg.Content),
+			msg.Provider,
+			msg.Model,
+			strconv.Itoa(msg.TokensUsed),
+			msg.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return ExportToCSV(filepath, headers, rows)
+}
+
+// escapeCSVNewlines replaces line breaks with the two characters \n
+func escapeCSVNewlines(text string) string {
+	return strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(text)
+}
+
+// ExportUsageStatsToCSV exports usage statistics to a CSV file. The type
+// column tells the rows apart: the total, then the providers and models by
+// name, then the days and months in order. The cost is only known for
+// providers and models.
+func ExportUsageStatsToCSV(stats *db.UsageStats, filepath string) error {
+	headers := []string{"type", "name", "provider", "total_tokens", "message_count", "estimated_cost_usd"}
+	rows := [][]string{
+		{"total", "", "", strconv.FormatInt(stats.TotalTokens, 10), strconv.FormatInt(stats.TotalMessages, 10), ""},
+	}
+
+	providers := make([]string, 0, len(stats.ProviderStats))
+	for name := range stats.ProviderStats {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+	for _, name := range providers {
+		p := stats.ProviderStats[name]
+		rows = append(rows, []string{"provider", p.Provider, p.Provider, strconv.FormatInt(p.TotalTokens, 10),
+			strconv.FormatInt(p.MessageCount, 10), strconv.FormatFloat(p.EstimatedCost, 'f', 4, 64)})
+	}
+
+	models := make([]string, 0, len(stats.ModelStats))
+	for key := range stats.ModelStats {
+		models = append(models, key)
+	}
+	sort.Strings(models)
+	for _, key := range models {
+		m := stats.ModelStats[key]
+		rows = append(rows, []string{"model", m.Model, m.Provider, strconv.FormatInt(m.TotalTokens, 10),
+			strconv.FormatInt(m.MessageCount, 10), strconv.FormatFloat(m.EstimatedCost, 'f', 4, 64)})
+	}
+
+	for _, d := range stats.DailyStats {
+		rows = append(rows, []string{"day", d.Date.Format("2006-01-02"), "", strconv.FormatInt(d.TotalTokens, 10),
+			strconv.FormatInt(d.MessageCount, 10), ""})
+	}
+	for _, m := range stats.MonthlyStats {
+		rows = append(rows, []string{"month", m.Month, "", strconv.FormatInt(m.TotalTokens, 10),
+			strconv.FormatInt(m.MessageCount, 10), ""})
+	}
+
+	return ExportToCSV(filepath, headers, rows)
+}
+
+// GetDefaultExportPath returns the default export directory. The directory
+// of the most recent file is preferred while it exists, so exports go where
+// the user last put them.
+func GetDefaultExportPath(recentFiles []string) (string, error) {
+	for _, uri := range recentFiles {
+		dir := filepath.Dir(RecentFilePath(uri))
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -0,0 +1,285 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"light-llm-client/db"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// ResponseMetrics are the streaming metrics of a response, stored as JSON in
+// the metadata of the message
+type ResponseMetrics struct {
+	TTFTMs          int64   `json:"ttft_ms"`  // Time to the first chunk
+	TotalMs         int64   `json:"total_ms"` // Time to the final chunk
+	Tokens          int     `json:"tokens"`
+	TokensEstimated bool    `json:"tokens_estimated,omitempty"` // Estimated from the response length
+	TokensPerSecond float64 `json:"tokens_per_second"`
+}
+
+// Encode returns the metrics as message metadata
+func (m ResponseMetrics) Encode() string {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// String formats the metrics of a response
+func (m ResponseMetrics) String() string {
+	tokens := fmt.Sprintf("%d", m.Tokens)
+	if m.TokensEstimated {
+		tokens = "≈" + tokens
+	}
+	return fmt.Sprintf("TTFT: %d ms | Total: %.2f s | Tokens: %s | %.1f tokens/s",
+		m.TTFTMs, float64(m.TotalMs)/1000, tokens, m.TokensPerSecond)
+}
+
+// ParseResponseMetrics reads the metrics from message metadata. It returns
+// false if the metadata has none.
+func ParseResponseMetrics(metadata string) (ResponseMetrics, bool) {
+	var fields map[string]json.RawMessage
+	if metadata == "" || json.Unmarshal([]byte(metadata), &fields) != nil || fields["total_ms"] == nil {
+		return ResponseMetrics{}, false
+	}
+	var m ResponseMetrics
+	if err := json.Unmarshal([]byte(metadata), &m); err != nil {
+		return ResponseMetrics{}, false
+	}
+	return m, true
+}
+
+// forkComparisonRound is a user prompt and the response of each column
+type forkComparisonRound struct {
+	prompt    *db.Message
+	responses []*db.Message // By column, nil if the column has no response
+}
+
+// ExportForkComparisonHTML exports a conversation of the fork view as an HTML
+// table comparing the models side by side. Each prompt spans a row, followed
+// by a row with the response of each column. Responses are assigned to the
+// columns by provider, in order, so several columns may use the same
+// provider. The sticky header shows each column's average TTFT and
+// throughput from the message metadata.
+func ExportForkComparisonHTML(database *db.DB, conversationID int64, columnProviders []string, filePath string) error {
+	if len(columnProviders) == 0 {
+		return fmt.Errorf("no columns to compare")
+	}
+
+	conv, err := database.GetConversation(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+	messages, err := database.ListMessages(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	rounds := groupForkResponses(messages, columnProviders)
+	content, err := renderForkComparison(conv.Title, columnProviders, rounds)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// groupForkResponses splits messages into rounds at each user message and
+// assigns the assistant responses of a round to the columns
+func groupForkResponses(messages []*db.Message, columnProviders []string) []forkComparisonRound {
+	var rounds []forkComparisonRound
+	for _, msg := range messages {
+		if msg.Role == "user" {
+			rounds = append(rounds, forkComparisonRound{
+				prompt:    msg,
+				responses: make([]*db.Message, len(columnProviders)),
+			})
+			continue
+		}
+		if msg.Role != "assistant" || len(rounds) == 0 {
+			continue
+		}
+
+		// The first column of the provider that has no response yet
+		round := rounds[len(rounds)-1]
+		for i, provider := range columnProviders {
+			if provider == msg.Provider && round.responses[i] == nil {
+				round.responses[i] = msg
+				break
+			}
+		}
+	}
+	return rounds
+}
+
+// newHTMLMarkdown returns a function rendering message content to HTML, with
+// highlighted code blocks. Raw HTML in the content is escaped.
+func newHTMLMarkdown() func(content string) (string, error) {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(
+			renderer.WithNodeRenderers(util.Prioritized(codeBlockRenderer{}, 100)),
+		),
+	)
+	return func(content string) (string, error) {
+		var buf bytes.Buffer
+		if err := md.Convert([]byte(content), &buf); err != nil {
+			return "", fmt.Errorf("failed to render markdown: %w", err)
+		}
+		return buf.String(), nil
+	}
+}
+
+// renderForkComparison renders the comparison page
+func renderForkComparison(title string, columnProviders []string, rounds []forkComparisonRound) (string, error) {
+	markdown := newHTMLMarkdown()
+
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n", html.EscapeString(title))
+	sb.WriteString(forkComparisonStyle)
+	sb.WriteString("</head>\n<body>\n")
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n", html.EscapeString(title))
+	fmt.Fprintf(&sb, "<p class=\"meta\">Exported: %s</p>\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	sb.WriteString("<table>\n<thead>\n<tr>")
+	for i, provider := range columnProviders {
+		fmt.Fprintf(&sb, "<th>Model %d: %s%s</th>", i+1, html.EscapeString(provider), html.EscapeString(columnModel(rounds, i)))
+	}
+	sb.WriteString("</tr>\n<tr class=\"metrics\">")
+	for i := range columnProviders {
+		fmt.Fprintf(&sb, "<th>%s</th>", html.EscapeString(averageMetrics(rounds, i)))
+	}
+	sb.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+	for _, round := range rounds {
+		prompt, err := markdown(round.prompt.Content)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "<tr class=\"prompt\"><td colspan=\"%d\"><div class=\"role\">👤 User</div>%s</td></tr>\n", len(columnProviders), prompt)
+
+		sb.WriteString("<tr class=\"responses\">")
+		for _, response := range round.responses {
+			if response == nil {
+				sb.WriteString("<td class=\"missing\">No response</td>")
+				continue
+			}
+			content, err := markdown(response.Content)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString("<td>")
+			sb.WriteString(content)
+			if m, ok := ParseResponseMetrics(response.Metadata); ok {
+				fmt.Fprintf(&sb, "<div class=\"metric\">%s</div>", html.EscapeString(m.String()))
+			}
+			sb.WriteString("</td>")
+		}
+		sb.WriteString("</tr>\n")
+	}
+
+	sb.WriteString("</tbody>\n</table>\n</body>\n</html>\n")
+	return sb.String(), nil
+}
+
+// columnModel returns " (model)" for the model of a column's first response
+func columnModel(rounds []forkComparisonRound, column int) string {
+	for _, round := range rounds {
+		if response := round.responses[column]; response != nil && response.Model != "" {
+			return " (" + response.Model + ")"
+		}
+	}
+	return ""
+}
+
+// averageMetrics formats a column's average TTFT and throughput
+func averageMetrics(rounds []forkComparisonRound, column int) string {
+	var ttft, throughput float64
+	count := 0
+	for _, round := range rounds {
+		if response := round.responses[column]; response != nil {
+			if m, ok := ParseResponseMetrics(response.Metadata); ok {
+				ttft += float64(m.TTFTMs)
+				throughput += m.TokensPerSecond
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return "No metrics"
+	}
+	return fmt.Sprintf("Avg TTFT: %.0f ms | Avg %.1f tokens/s (%d responses)", ttft/float64(count), throughput/float64(count), count)
+}
+
+// codeBlockRenderer renders code blocks with highlightCode
+type codeBlockRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer
+func (r codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderCodeBlock)
+	reg.Register(ast.KindCodeBlock, r.renderCodeBlock)
+}
+
+func (r codeBlockRenderer) renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	language := ""
+	if fenced, ok := node.(*ast.FencedCodeBlock); ok {
+		language = string(fenced.Language(source))
+	}
+	var code strings.Builder
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		code.Write(line.Value(source))
+	}
+
+	w.WriteString("<pre><code")
+	if language != "" {
+		fmt.Fprintf(w, " class=\"language-%s\"", html.EscapeString(language))
+	}
+	w.WriteString(">")
+	w.WriteString(highlightCode(language, code.String()))
+	w.WriteString("</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// forkComparisonStyle is the stylesheet of the comparison page
+const forkComparisonStyle = `<style>
+body { font-family: -apple-system, "Segoe UI", "Microsoft YaHei", sans-serif; margin: 24px; color: #222; }
+h1 { font-size: 1.5em; margin-bottom: 4px; }
+.meta { color: #777; margin-top: 0; }
+table { border-collapse: collapse; width: 100%; table-layout: fixed; }
+th, td { border: 1px solid #ddd; padding: 8px 12px; vertical-align: top; text-align: left; overflow-wrap: anywhere; }
+thead th { position: sticky; background: #f5f7fa; z-index: 1; }
+thead tr:first-child th { top: 0; height: 20px; }
+thead tr.metrics th { top: 37px; font-weight: normal; font-size: 0.85em; color: #555; }
+tr.prompt td { background: #eef5ff; }
+.role { font-weight: bold; margin-bottom: 4px; }
+.missing { color: #999; font-style: italic; }
+.metric { margin-top: 8px; font-size: 0.85em; color: #666; font-style: italic; }
+pre { background: #f6f8fa; padding: 10px; border-radius: 4px; overflow-x: auto; }
+code { font-family: Consolas, Menlo, monospace; font-size: 0.9em; }
+.kw { color: #d73a49; font-weight: bold; }
+.str { color: #032f62; }
+.com { color: #6a737d; font-style: italic; }
+.num { color: #005cc5; }
+</style>
+`
@@ -0,0 +1,85 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"light-llm-client/db"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportForkComparisonHTML(t *testing.T) {
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer database.Close()
+
+	conv, err := database.CreateConversation("Fork: <compare>", "fork")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	create := func(role, content, provider, metadata string) {
+		msg, err := database.CreateMessage(conv.ID, role, content, provider, provider+"-model", "", 0)
+		if err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		if metadata != "" {
+			if err := database.UpdateMessageMetadata(msg.ID, metadata); err != nil {
+				t.Fatalf("UpdateMessageMetadata failed: %v", err)
+			}
+		}
+	}
+	metrics := ResponseMetrics{TTFTMs: 120, TotalMs: 2000, Tokens: 50, TokensPerSecond: 26.3}.Encode()
+	create("user", "Write <b>hello</b> in Go", "", "")
+	// The columns of the same provider get its responses in order
+	create("assistant", "```go\nfmt.Println(\"hi\") // print\n```", "openai", metrics)
+	create("assistant", "second openai answer", "openai", "")
+	create("assistant", "claude answer", "claude", metrics)
+	create("user", "And now?", "", "")
+	create("assistant", "only claude answered", "claude", "")
+
+	path := filepath.Join(dir, "comparison.html")
+	if err := ExportForkComparisonHTML(database, conv.ID, []string{"openai", "openai", "claude"}, path); err != nil {
+		t.Fatalf("ExportForkComparisonHTML failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	page := string(data)
+
+	for _, want := range []string{
+		"<h1>Fork: &lt;compare&gt;</h1>",
+		"Model 1: openai (openai-model)",
+		"Model 3: claude (claude-model)",
+		"Avg TTFT: 120 ms | Avg 26.3 tokens/s (1 responses)",
+		`<span class="str">&#34;hi&#34;</span>`,
+		`<span class="com">// print</span>`,
+		"TTFT: 120 ms | Total: 2.00 s | Tokens: 50 | 26.3 tokens/s",
+		`<td colspan="3">`,
+		`<td class="missing">No response</td>`,
+		"position: sticky",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("export does not contain %q", want)
+		}
+	}
+	if strings.Contains(page, "<b>hello</b>") {
+		t.Error("raw HTML in messages should not be rendered")
+	}
+
+	// Responses appear in the column order of their round
+	first := strings.Index(page, "fmt")
+	second := strings.Index(page, "second openai answer")
+	claude := strings.Index(page, "claude answer")
+	if !(first < second && second < claude) {
+		t.Errorf("responses are not in column order: %d, %d, %d", first, second, claude)
+	}
+	if strings.Count(page, `<tr class="responses">`) != 2 {
+		t.Errorf("expected 2 response rows")
+	}
+}
@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"strings"
+	"time"
+)
+
+// Layout of the conversation PDF pages
+const (
+	convPDFMargin     = 50.0
+	convPDFBodyTop    = 80.0
+	convPDFBodyBottom = pdfPageHeight - 60
+	convPDFBoxPadding = 8.0
+	convPDFBoxGap     = 10.0
+	convPDFTextSize   = 10.0
+	convPDFCodeSize   = 9.0
+)
+
+// conversationPDFLine is a line of a message box
+type conversationPDFLine struct {
+	text string
+	mono bool // Code, drawn smaller
+}
+
+// height returns the line height of the line
+func (l conversationPDFLine) height() float64 {
+	if l.mono {
+		return convPDFCodeSize + 3
+	}
+	return convPDFTextSize + 4
+}
+
+// conversationPDF renders a conversation into a PDF document
+type conversationPDF struct {
+	doc      *pdfDocument
+	conv     *db.Conversation
+	messages []*db.Message
+	y        float64 // Top of the next box on the current page
+}
+
+// ExportConversationToPDF exports a conversation as a PDF: a title page with
+// the conversation's details, then each message in a box, light blue for
+// the user and light green for the assistant, under its role in bold. Code
+// blocks are set in a smaller size. Text is set in the embedded unifont, so
+// Chinese and other non-Latin text prints as written.
+func ExportConversationToPDF(database *db.DB, conversationID int64, filePath string) error {
+	conv, err := database.GetConversation(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+	messages, err := database.ListMessages(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	r := &conversationPDF{doc: newPDFDocument(), conv: conv, messages: messages}
+	r.titlePage()
+	r.bodyPage()
+	for _, msg := range messages {
+		r.message(msg)
+	}
+	r.footers(time.Now())
+
+	return r.doc.WriteFile(filePath)
+}
+
+// titlePage writes the title and the details of the conversation
+func (r *conversationPDF) titlePage() {
+	r.doc.AddPage()
+	r.doc.Text(convPDFMargin, 200, 28, true, "Light LLM Client")
+	r.doc.Text(convPDFMargin, 240, 20, false, "Conversation Export")
+	r.doc.Line(convPDFMargin, 260, pdfPageWidth-convPDFMargin, 260)
+
+	y := 295.0
+	for _, line := range wrapPDFText(r.conv.Title, r.textWidth(), r.measure(18)) {
+		r.doc.Text(convPDFMargin, y, 18, true, line)
+		y += 24
+	}
+
+	y += 16
+	details := []string{}
+	if r.conv.Category != "" {
+		details = append(details, "Category: "+r.conv.Category)
+	}
+	details = append(details,
+		"Created: "+r.conv.CreatedAt.Format("2006-01-02 15:04:05"),
+		"Updated: "+r.conv.UpdatedAt.Format("2006-01-02 15:04:05"),
+		fmt.Sprintf("Messages: %d", len(r.messages)),
+	)
+	for _, line := range details {
+		r.doc.Text(convPDFMargin, y, 12, false, line)
+		y += 22
+	}
+}
+
+// bodyPage starts a page for messages with the conversation title as header
+func (r *conversationPDF) bodyPage() {
+	r.doc.AddPage()
+	title := wrapPDFText(r.conv.Title, pdfPageWidth-2*convPDFMargin-r.doc.TextWidth(10, "..."), r.measure(10))
+	header := title[0]
+	if len(title) > 1 {
+		header += "..."
+	}
+	r.doc.Text(convPDFMargin, 50, 10, true, header)
+	r.doc.Line(convPDFMargin, 58, pdfPageWidth-convPDFMargin, 58)
+	r.y = convPDFBodyTop
+}
+
+// textWidth returns the width of the text in a message box
+func (r *conversationPDF) textWidth() float64 {
+	return pdfPageWidth - 2*convPDFMargin - 2*convPDFBoxPadding
+}
+
+// measure returns a function measuring text at the font size
+func (r *conversationPDF) measure(size float64) func(string) float64 {
+	return func(text string) float64 {
+		return r.doc.TextWidth(size, text)
+	}
+}
+
+// message draws a message box, continuing it on new pages as needed
+func (r *conversationPDF) message(msg *db.Message) {
+	role, fill := pdfRoleStyle(msg.Role)
+	details := msg.CreatedAt.Format("2006-01-02 15:04:05")
+	if msg.Model != "" {
+		details = msg.Model + "  " + details
+	}
+
+	lines := r.contentLines(msg.Content)
+	header := convPDFTextSize + 8
+
+	// Start on a new page unless the header and the first line fit
+	needed := 2*convPDFBoxPadding + header
+	if len(lines) > 0 {
+		needed += lines[0].height()
+	}
+	if r.y+needed > convPDFBodyBottom && r.y > convPDFBodyTop {
+		r.bodyPage()
+	}
+
+	for withHeader := true; withHeader || len(lines) > 0; withHeader = false {
+		top := r.y
+		available := convPDFBodyBottom - top - 2*convPDFBoxPadding
+		if withHeader {
+			available -= header
+		}
+
+		// Take the lines that fit on the page, at least one
+		count, height := 0, 0.0
+		for count < len(lines) && (count == 0 || height+lines[count].height() <= available) {
+			height += lines[count].height()
+			count++
+		}
+
+		boxHeight := 2*convPDFBoxPadding + height
+		if withHeader {
+			boxHeight += header
+		}
+		r.doc.Rect(convPDFMargin, top, pdfPageWidth-2*convPDFMargin, boxHeight, fill[0], fill[1], fill[2])
+
+		y := top + convPDFBoxPadding
+		x := convPDFMargin + convPDFBoxPadding
+		if withHeader {
+			r.doc.Text(x, y+convPDFTextSize, convPDFTextSize, true, role)
+			r.doc.Text(x+r.doc.TextWidth(convPDFTextSize, role)+12, y+convPDFTextSize, 8, false, details)
+			y += header
+		}
+		for _, line := range lines[:count] {
+			// Baselines sit 3pt above the bottom of the line
+			y += line.height()
+			if line.mono {
+				r.doc.MonoText(x, y-3, convPDFCodeSize, line.text)
+			} else {
+				r.doc.Text(x, y-3, convPDFTextSize, false, line.text)
+			}
+		}
+		lines = lines[count:]
+
+		r.y = top + boxHeight + convPDFBoxGap
+		if len(lines) > 0 {
+			r.bodyPage()
+		}
+	}
+}
+
+// contentLines splits message content into wrapped lines, marking the lines
+// of fenced code blocks as code. The fences themselves are left out.
+func (r *conversationPDF) contentLines(content string) []conversationPDFLine {
+	measureText := r.measure(convPDFTextSize)
+	measureCode := r.measure(convPDFCodeSize)
+
+	var lines []conversationPDFLine
+	inCode := false
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			line = strings.ReplaceAll(line, "\t", "    ")
+			for _, part := range wrapPDFText(line, r.textWidth(), measureCode) {
+				lines = append(lines, conversationPDFLine{text: part, mono: true})
+			}
+			continue
+		}
+		for _, part := range wrapPDFText(line, r.textWidth(), measureText) {
+			lines = append(lines, conversationPDFLine{text: part})
+		}
+	}
+	return lines
+}
+
+// footers writes the export time and page number on every page
+func (r *conversationPDF) footers(exportedAt time.Time) {
+	exported := "Exported " + exportedAt.Format("2006-01-02 15:04:05")
+	total := r.doc.PageCount()
+	for i := 0; i < total; i++ {
+		r.doc.SetPage(i)
+		page := fmt.Sprintf("Page %d of %d", i+1, total)
+		r.doc.Line(convPDFMargin, pdfPageHeight-45, pdfPageWidth-convPDFMargin, pdfPageHeight-45)
+		r.doc.Text(convPDFMargin, pdfPageHeight-30, 8, false, exported)
+		r.doc.Text(pdfPageWidth-convPDFMargin-r.doc.TextWidth(8, page), pdfPageHeight-30, 8, false, page)
+	}
+}
+
+// pdfRoleStyle returns the label and the box color (RGB) of a message role
+func pdfRoleStyle(role string) (string, [3]uint8) {
+	switch role {
+	case "assistant":
+		return "Assistant", [3]uint8{220, 245, 220}
+	case "system":
+		return "System", [3]uint8{235, 235, 235}
+	default:
+		return "User", [3]uint8{220, 235, 255}
+	}
+}
+
+// wrapPDFText breaks text into lines at most maxWidth wide as measured by
+// width, at spaces where possible. A line holds at least one character.
+// Empty text is a single empty line.
+func wrapPDFText(text string, maxWidth float64, width func(string) float64) []string {
+	runes := []rune(text)
+	var lines []string
+	for {
+		fit, used := 0, 0.0
+		for fit < len(runes) {
+			used += width(string(runes[fit]))
+			if used > maxWidth {
+				break
+			}
+			fit++
+		}
+		fit = max(fit, 1)
+		if fit >= len(runes) {
+			break
+		}
+		cut := fit
+		for i := fit; i > fit/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(runes[:cut]), " "))
+		runes = runes[cut:]
+		if len(runes) > 0 && runes[0] == ' ' {
+			runes = runes[1:]
+		}
+	}
+	return append(lines, string(runes))
+}
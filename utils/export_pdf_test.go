@@ -0,0 +1,123 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"bytes"
+	"light-llm-client/db"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportConversationToPDF(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	conv, err := database.CreateConversation("Go 入门 (basics)", "Learning")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if _, err := database.CreateMessage(conv.ID, "user", "How do I print in Go? 怎么打印？", "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	// A long answer continues on further pages
+	answer := "Use fmt:\n\n```go\nfmt.Println(\"hi\")\n```\n" + strings.Repeat("More details.\n", 120)
+	if _, err := database.CreateMessage(conv.ID, "assistant", answer, "openai", "gpt-4o", "", 10); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), GenerateExportFilename(conv.Title, FormatPDF))
+	if !strings.HasSuffix(path, ".pdf") {
+		t.Errorf("filename %s has no .pdf extension", path)
+	}
+	if err := ExportConversationToPDF(database, conv.ID, path); err != nil {
+		t.Fatalf("ExportConversationToPDF failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("output is not framed as a PDF")
+	}
+
+	// Title page and three pages of messages
+	if !bytes.Contains(data, []byte("/Count 4")) {
+		t.Error("expected 4 pages")
+	}
+	content := pdfContent(t, data)
+	for _, want := range []string{
+		"Go 入门 (basics)",
+		"Category: Learning",
+		"User",
+		"Assistant",
+		"怎么打印？",
+		`fmt.Println("hi")`,
+		"Page 4 of 4",
+	} {
+		if !strings.Contains(content, pdfString(want)) {
+			t.Errorf("PDF does not contain %s", want)
+		}
+	}
+	if !strings.Contains(content, "2 Tr") {
+		t.Error("no bold text was drawn")
+	}
+	if strings.Contains(content, pdfString("```")) {
+		t.Error("code fences were written")
+	}
+
+	// Light blue for the user, light green for the assistant, which has a
+	// box on each of its pages
+	if n := countPDFRects(content, "0.863 0.922 1.000"); n != 1 {
+		t.Errorf("drew %d user boxes, want 1", n)
+	}
+	if n := countPDFRects(content, "0.863 0.961 0.863"); n != 3 {
+		t.Errorf("drew %d assistant boxes, want 3", n)
+	}
+}
+
+func TestWrapPDFText(t *testing.T) {
+	// Characters one unit wide, except CJK which is two like in unifont
+	width := func(text string) float64 {
+		if text >= "\u2e80" {
+			return 2
+		}
+		return 1
+	}
+	got := wrapPDFText("the quick brown fox", 10, width)
+	if want := []string{"the quick", "brown fox"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapPDFText = %q, want %q", got, want)
+	}
+	if got := wrapPDFText("abcdefghij", 4, width); strings.Join(got, "|") != "abcd|efgh|ij" {
+		t.Errorf("wrapPDFText = %q", got)
+	}
+	if got := wrapPDFText("中文换行测试", 4, width); strings.Join(got, "|") != "中文|换行|测试" {
+		t.Errorf("wrapPDFText of CJK text = %q", got)
+	}
+	if got := wrapPDFText("中", 1, width); strings.Join(got, "|") != "中" {
+		t.Errorf("wrapPDFText of a character wider than the line = %q", got)
+	}
+	if got := wrapPDFText("", 4, width); len(got) != 1 || got[0] != "" {
+		t.Errorf("wrapPDFText of empty text = %q", got)
+	}
+}
+
+func TestLoadPDFFont_DecompressesOnce(t *testing.T) {
+	first, err := loadPDFFont()
+	if err != nil {
+		t.Fatalf("loadPDFFont failed: %v", err)
+	}
+	second, err := loadPDFFont()
+	if err != nil {
+		t.Fatalf("loadPDFFont failed: %v", err)
+	}
+	if len(first) == 0 || &first[0] != &second[0] {
+		t.Error("expected every export to share the decompressed font")
+	}
+}
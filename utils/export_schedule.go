@@ -0,0 +1,319 @@
+package utils
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression. Only the minute and hour fields
+// are supported, day of month, month and day of week must be "*", so a
+// schedule fires at the same times every day.
+type CronSchedule struct {
+	minutes [60]bool
+	hours   [24]bool
+}
+
+// ParseCronSchedule parses a five-field cron expression like "0 2 * * *".
+// Minutes and hours accept "*", numbers, ranges ("1-5"), lists ("0,30") and
+// steps ("*/15").
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+	for i, name := range []string{"day of month", "month", "day of week"} {
+		if fields[i+2] != "*" {
+			return nil, fmt.Errorf("unsupported %s field %q, only \"*\" is supported", name, fields[i+2])
+		}
+	}
+
+	s := &CronSchedule{}
+	if err := parseCronField(fields[0], s.minutes[:]); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if err := parseCronField(fields[1], s.hours[:]); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	return s, nil
+}
+
+// parseCronField sets the values of a field in set, whose length is the
+// number of values the field can take
+func parseCronField(field string, set []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		low, high := 0, len(set)-1
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(from); err != nil {
+				return fmt.Errorf("invalid value %q", from)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(to); err != nil {
+					return fmt.Errorf("invalid value %q", to)
+				}
+			} else if hasStep {
+				high = len(set) - 1
+			}
+		}
+		if low < 0 || high >= len(set) || low > high {
+			return fmt.Errorf("%q is out of range 0-%d", part, len(set)-1)
+		}
+		for v := low; v <= high; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// Next returns the first time after t the schedule fires, in t's location
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	// Every schedule fires at least once a day; two days cover a DST shift
+	for limit := next.Add(48 * time.Hour); next.Before(limit); next = next.Add(time.Minute) {
+		if s.hours[next.Hour()] && s.minutes[next.Minute()] {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// ExportScheduler exports all conversations to a directory on a cron schedule
+type ExportScheduler struct {
+	db     *db.DB
+	logger *Logger
+
+	mu       sync.Mutex
+	config   ExportConfig
+	schedule *CronSchedule
+	next     time.Time
+	runMu    sync.Mutex // serializes exports
+
+	wake    chan struct{}
+	stop    chan struct{}
+	stopped sync.Once
+
+	// OnExport is called after each scheduled export with the exported path
+	// or the error
+	OnExport func(path string, err error)
+}
+
+// NewExportScheduler creates a scheduler for config. An invalid schedule is
+// logged and disables the scheduled exports.
+func NewExportScheduler(database *db.DB, config ExportConfig, logger *Logger) *ExportScheduler {
+	s := &ExportScheduler{
+		db:     database,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+	if err := s.SetConfig(config); err != nil {
+		logger.Warn("Scheduled exports are disabled: %v", err)
+	}
+	return s
+}
+
+// SetConfig replaces the export settings and reschedules the next export.
+// On error the scheduled exports are disabled.
+func (s *ExportScheduler) SetConfig(config ExportConfig) error {
+	var schedule *CronSchedule
+	var err error
+	if strings.TrimSpace(config.Schedule) != "" {
+		schedule, err = ParseCronSchedule(config.Schedule)
+	}
+	if err == nil {
+		err = checkScheduledExportFormat(config.Format)
+	}
+	if err != nil {
+		schedule = nil
+	}
+
+	s.mu.Lock()
+	s.config = config
+	s.schedule = schedule
+	s.next = time.Time{}
+	if schedule != nil {
+		s.next = schedule.Next(time.Now())
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return err
+}
+
+// Next returns the time of the next scheduled export, false if none is
+// scheduled
+func (s *ExportScheduler) Next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next, !s.next.IsZero()
+}
+
+// Start runs the scheduled exports until Stop
+func (s *ExportScheduler) Start() {
+	SafeGo(s.logger, "exportScheduler", s.run)
+}
+
+// Stop ends the scheduled exports. It waits for a running export, so the
+// database can be closed afterwards.
+func (s *ExportScheduler) Stop() {
+	s.stopped.Do(func() {
+		close(s.stop)
+	})
+	s.runMu.Lock()
+	s.runMu.Unlock()
+}
+
+func (s *ExportScheduler) run() {
+	for {
+		next, scheduled := s.Next()
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if scheduled {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+		stopTimer := func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+
+		select {
+		case <-s.stop:
+			stopTimer()
+			return
+		case <-s.wake:
+			// The config changed, wait for the new next time
+			stopTimer()
+		case <-fire:
+			path, ran, err := s.runScheduled()
+			if !ran {
+				return
+			}
+			if err != nil {
+				s.logger.Error("Scheduled export failed: %v", err)
+			} else {
+				s.logger.Info("Scheduled export written to %s", path)
+			}
+			if s.OnExport != nil {
+				s.OnExport(path, err)
+			}
+			s.mu.Lock()
+			if s.schedule != nil {
+				s.next = s.schedule.Next(time.Now())
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// runScheduled runs a due export unless the scheduler was stopped, in which
+// case Stop may already have returned and the database be closed
+func (s *ExportScheduler) runScheduled() (path string, ran bool, err error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	select {
+	case <-s.stop:
+		return "", false, nil
+	default:
+	}
+	path, err = s.export()
+	return path, true, err
+}
+
+// RunNow exports all conversations right away and returns the path of the
+// export. JSON exports are a single file, Markdown exports a directory with
+// one file per conversation.
+func (s *ExportScheduler) RunNow() (string, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	return s.export()
+}
+
+// export writes the export, the caller holds runMu
+func (s *ExportScheduler) export() (string, error) {
+	s.mu.Lock()
+	config := s.config
+	s.mu.Unlock()
+
+	dir := config.Dir
+	if dir == "" {
+		var err error
+		if dir, err = GetDefaultExportPath(nil); err != nil {
+			return "", fmt.Errorf("failed to get export directory: %w", err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	name := "conversations_" + time.Now().Format("20060102_150405")
+	switch config.Format {
+	case "", FormatJSON:
+		path := filepath.Join(dir, name+".json")
+		if err := ExportAllConversations(s.db, path); err != nil {
+			return "", err
+		}
+		return path, nil
+	case FormatMarkdown:
+		path := filepath.Join(dir, name)
+		if err := exportAllConversationsToMarkdown(s.db, path); err != nil {
+			return "", err
+		}
+		return path, nil
+	default:
+		return "", checkScheduledExportFormat(config.Format)
+	}
+}
+
+// checkScheduledExportFormat reports formats that can't export all
+// conversations
+func checkScheduledExportFormat(format ExportFormat) error {
+	switch format {
+	case "", FormatJSON, FormatMarkdown:
+		return nil
+	}
+	return fmt.Errorf("unsupported export format for scheduled exports: %s", format)
+}
+
+// exportAllConversationsToMarkdown exports each conversation to its own
+// Markdown file in dir
+func exportAllConversationsToMarkdown(database *db.DB, dir string) error {
+	conversations, err := database.ListConversations(10000, 0) // Large limit to get all
+	if err != nil {
+		return fmt.Errorf("failed to list conversations: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	for _, conv := range conversations {
+		// The ID keeps conversations with the same title apart
+		name := fmt.Sprintf("%d_%s", conv.ID, GenerateExportFilename(conv.Title, FormatMarkdown))
+		if err := ExportConversationToMarkdown(database, conv.ID, filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("failed to export conversation %d: %w", conv.ID, err)
+		}
+	}
+	return nil
+}
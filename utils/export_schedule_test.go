@@ -0,0 +1,164 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"encoding/json"
+	"light-llm-client/db"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	now := time.Date(2024, 1, 15, 23, 30, 10, 0, time.Local)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 2 * * *", time.Date(2024, 1, 16, 2, 0, 0, 0, time.Local)},
+		{"*/15 * * * *", time.Date(2024, 1, 15, 23, 45, 0, 0, time.Local)},
+		{"30 23 * * *", time.Date(2024, 1, 16, 23, 30, 0, 0, time.Local)},
+		{"0,45 22-23 * * *", time.Date(2024, 1, 15, 23, 45, 0, 0, time.Local)},
+		{"5 */6 * * *", time.Date(2024, 1, 16, 0, 5, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseCronSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCronSchedule(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := schedule.Next(now); !got.Equal(tt.want) {
+			t.Errorf("%q: Next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"", "0 2 * *", "60 2 * * *", "0 24 * * *", "0 2 1 * *", "0 2 * * MON", "*/0 * * * *", "5-1 * * * *", "x * * * *"} {
+		if _, err := ParseCronSchedule(expr); err == nil {
+			t.Errorf("ParseCronSchedule(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestExportScheduler(t *testing.T) {
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer database.Close()
+	logger, err := NewLogger(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	defer logger.Close()
+
+	for _, title := range []string{"first", "first"} {
+		conv, err := database.CreateConversation(title, "")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		if _, err := database.CreateMessage(conv.ID, "user", "hello", "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	exportDir := filepath.Join(dir, "exports")
+	scheduler := NewExportScheduler(database, ExportConfig{Schedule: "0 2 * * *", Dir: exportDir}, logger)
+	next, ok := scheduler.Next()
+	if !ok || next.Hour() != 2 || next.Minute() != 0 || !next.After(time.Now()) {
+		t.Errorf("Next = %v, %v; want the next 02:00", next, ok)
+	}
+
+	path, err := scheduler.RunNow()
+	if err != nil {
+		t.Fatalf("RunNow failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	var export struct {
+		Conversations []ConversationExport `json:"conversations"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("export is not JSON: %v", err)
+	}
+	if len(export.Conversations) != 2 {
+		t.Errorf("exported %d conversations, want 2", len(export.Conversations))
+	}
+
+	// Markdown exports a file per conversation, even with the same title
+	if err := scheduler.SetConfig(ExportConfig{Dir: exportDir, Format: FormatMarkdown}); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if _, ok := scheduler.Next(); ok {
+		t.Error("expected no scheduled export without a schedule")
+	}
+	path, err = scheduler.RunNow()
+	if err != nil {
+		t.Fatalf("RunNow failed: %v", err)
+	}
+	if entries, err := os.ReadDir(path); err != nil || len(entries) != 2 {
+		t.Errorf("Markdown export has %d files (%v), want 2", len(entries), err)
+	}
+
+	if err := scheduler.SetConfig(ExportConfig{Schedule: "0 2 * * *", Format: FormatPDF}); err == nil {
+		t.Error("expected an error for PDF exports")
+	}
+	if _, ok := scheduler.Next(); ok {
+		t.Error("expected an invalid config to disable the schedule")
+	}
+
+	// A due export runs on the scheduler goroutine
+	exported := make(chan string, 1)
+	scheduler.OnExport = func(path string, err error) {
+		if err != nil {
+			t.Errorf("scheduled export failed: %v", err)
+		}
+		exported <- path
+	}
+	if err := scheduler.SetConfig(ExportConfig{Schedule: "* * * * *", Dir: exportDir}); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	scheduler.mu.Lock()
+	scheduler.next = time.Now()
+	scheduler.mu.Unlock()
+	scheduler.Start()
+	defer scheduler.Stop()
+	select {
+	case path := <-exported:
+		if filepath.Ext(path) != ".json" {
+			t.Errorf("scheduled export = %q, want a JSON file", path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled export did not run")
+	}
+}
+
+func TestExportScheduler_NoExportAfterStop(t *testing.T) {
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer database.Close()
+	logger, err := NewLogger(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	defer logger.Close()
+
+	// The timer fired just as Stop ran: the export must not start after
+	// Stop returned
+	exportDir := filepath.Join(dir, "exports")
+	scheduler := NewExportScheduler(database, ExportConfig{Schedule: "* * * * *", Dir: exportDir}, logger)
+	scheduler.Stop()
+	if _, ran, _ := scheduler.runScheduled(); ran {
+		t.Error("scheduled export ran after Stop")
+	}
+	if _, err := os.Stat(exportDir); !os.IsNotExist(err) {
+		t.Errorf("export directory created after Stop: %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"encoding/csv"
+	"light-llm-client/db"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImport_KeepsConversationSettings(t *testing.T) {
+	newDB := func(name string) *db.DB {
+		database, err := db.New(filepath.Join(t.TempDir(), name))
+		if err != nil {
+			t.Fatalf("failed to create database: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
+		return database
+	}
+	source := newDB("source.db")
+
+	conv, err := source.CreateConversation("Tuned", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	temperature, maxTokens := 0.0, 512
+	params := &db.ConversationParams{Temperature: &temperature, MaxTokens: &maxTokens}
+	if err := source.UpdateConversationParams(conv.ID, params); err != nil {
+		t.Fatalf("UpdateConversationParams failed: %v", err)
+	}
+	if err := source.SetConversationPinned(conv.ID, true); err != nil {
+		t.Fatalf("SetConversationPinned failed: %v", err)
+	}
+	if _, err := source.CreateMessage(conv.ID, "user", "hi", "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	if _, err := source.CreateConversation("Default", ""); err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+
+	single := filepath.Join(t.TempDir(), "single.json")
+	if err := ExportConversationToJSON(source, conv.ID, single); err != nil {
+		t.Fatalf("ExportConversationToJSON failed: %v", err)
+	}
+	all := filepath.Join(t.TempDir(), "all.json")
+	if err := ExportAllConversations(source, all); err != nil {
+		t.Fatalf("ExportAllConversations failed: %v", err)
+	}
+
+	target := newDB("target.db")
+	imported, err := ImportConversation(target, single)
+	if err != nil {
+		t.Fatalf("ImportConversation failed: %v", err)
+	}
+	if _, err := ImportAllConversations(target, all); err != nil {
+		t.Fatalf("ImportAllConversations failed: %v", err)
+	}
+
+	conversations, err := target.ListConversations(10, 0)
+	if err != nil {
+		t.Fatalf("ListConversations failed: %v", err)
+	}
+	// The conversation without messages is skipped by the import
+	if len(conversations) != 2 {
+		t.Fatalf("got %d conversations, want 2", len(conversations))
+	}
+	for _, c := range conversations {
+		got := c.ParamsOverride
+		if got == nil || got.Temperature == nil || *got.Temperature != 0 || got.MaxTokens == nil || *got.MaxTokens != 512 || got.TopP != nil {
+			t.Errorf("conversation %d has params %+v, want the exported ones", c.ID, got)
+		}
+		if !c.Pinned {
+			t.Errorf("conversation %d lost its pin", c.ID)
+		}
+	}
+	if stored, err := target.GetConversation(imported.ID); err != nil || stored.ParamsOverride.IsEmpty() {
+		t.Errorf("GetConversation = %+v, %v; want the imported params", stored, err)
+	}
+}
+
+func TestExportConversationToHTML(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	conv, err := database.CreateConversation("HTML <export>", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if _, err := database.CreateMessage(conv.ID, "user", "Print <b>hi</b> in Go", "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	if _, err := database.CreateMessage(conv.ID, "assistant", "```go\nfmt.Println(\"hi\")\n```", "openai", "gpt-4o", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), GenerateExportFilename(conv.Title, FormatHTML))
+	if !strings.HasSuffix(path, ".html") {
+		t.Errorf("export filename %q has no .html extension", path)
+	}
+	if err := ExportConversationToHTML(database, conv.ID, path); err != nil {
+		t.Fatalf("ExportConversationToHTML failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	page := string(data)
+
+	for _, want := range []string{
+		"<h1>HTML &lt;export&gt;</h1>",
+		"<style>",
+		`<a href="#msg-1">👤 用户: Print &lt;b&gt;hi&lt;/b&gt; in Go</a>`,
+		`<a href="#msg-2">🤖 助手: `,
+		`<div class="message right user" id="msg-1">`,
+		`<div class="message left assistant" id="msg-2">`,
+		`<span class="model">gpt-4o</span>`,
+		`<pre><code class="language-go">`,
+		"navigator.clipboard.writeText",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("export is missing %q", want)
+		}
+	}
+	// Raw HTML in messages is escaped, and nothing is loaded from elsewhere
+	for _, unwanted := range []string{"<b>hi</b>", "<link", "src="} {
+		if strings.Contains(page, unwanted) {
+			t.Errorf("export contains %q", unwanted)
+		}
+	}
+}
+
+func TestImportFromChatGPTExport(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	path := filepath.Join(t.TempDir(), "conversations.json")
+	if err := os.WriteFile(path, []byte(chatGPTExport), 0644); err != nil {
+		t.Fatalf("failed to write export: %v", err)
+	}
+	count, err := ImportFromChatGPTExport(database, path)
+	if err != nil || count != 1 {
+		t.Fatalf("ImportFromChatGPTExport = %d, %v; want 1 conversation", count, err)
+	}
+
+	conversations, err := database.ListConversations(10, 0)
+	if err != nil || len(conversations) != 1 || conversations[0].Title != "Go question" {
+		t.Fatalf("ListConversations = %+v, %v", conversations, err)
+	}
+	messages, err := database.ListMessages(conversations[0].ID)
+	if err != nil || len(messages) != 2 || messages[1].Model != "gpt-4o" {
+		t.Errorf("ListMessages = %+v, %v", messages, err)
+	}
+
+	other := filepath.Join(t.TempDir(), "single.json")
+	if err := os.WriteFile(other, []byte(singleExport), 0644); err != nil {
+		t.Fatalf("failed to write export: %v", err)
+	}
+	if _, err := ImportFromChatGPTExport(database, other); err == nil {
+		t.Error("expected an error for an export of this app")
+	}
+}
+
+func TestExportConversationToCSV(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	conv, err := database.CreateConversation("CSV export", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if _, err := database.CreateMessage(conv.ID, "user", "第一行\r\n第二行, \"quoted\"", "", "", "", 0); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	if _, err := database.CreateMessage(conv.ID, "assistant", "Hi\n\nthere", "openai", "gpt-4o", "", 12); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), GenerateExportFilename(conv.Title, FormatCSV))
+	if err := ExportConversationToCSV(database, conv.ID, path); err != nil {
+		t.Fatalf("ExportConversationToCSV failed: %v", err)
+	}
+	records := readCSV(t, path)
+
+	if len(records) != 3 {
+		t.Fatalf("got %d rows, want a header and 2 messages", len(records))
+	}
+	if got := strings.Join(records[0], ","); got != "id,role,content,provider,model,tokens_used,created_at" {
+		t.Errorf("header = %q", got)
+	}
+	if records[1][2] != `第一行\n第二行, "quoted"` {
+		t.Errorf("user content = %q", records[1][2])
+	}
+	want := []string{"assistant", `Hi\n\nthere`, "openai", "gpt-4o", "12"}
+	if got := records[2][1:6]; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("assistant row = %q, want %q", got, want)
+	}
+}
+
+func TestExportUsageStatsToCSV(t *testing.T) {
+	stats := &db.UsageStats{
+		TotalTokens:   3000,
+		TotalMessages: 40,
+		ProviderStats: map[string]*db.ProviderUsageStats{
+			"openai": {Provider: "openai", TotalTokens: 2000, MessageCount: 25, EstimatedCost: 0.02},
+			"ollama": {Provider: "ollama", TotalTokens: 1000, MessageCount: 15},
+		},
+		ModelStats: map[string]*db.ModelUsageStats{
+			"openai:gpt-4o": {Model: "gpt-4o", Provider: "openai", TotalTokens: 2000, MessageCount: 25, EstimatedCost: 0.02},
+		},
+		DailyStats:   []*db.DailyUsageStats{{Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), TotalTokens: 3000, MessageCount: 40}},
+		MonthlyStats: []*db.MonthlyUsageStats{{Month: "2024-01", TotalTokens: 3000, MessageCount: 40}},
+	}
+
+	path := filepath.Join(t.TempDir(), "usage.csv")
+	if err := ExportUsageStatsToCSV(stats, path); err != nil {
+		t.Fatalf("ExportUsageStatsToCSV failed: %v", err)
+	}
+
+	var got []string
+	for _, record := range readCSV(t, path) {
+		got = append(got, strings.Join(record, ","))
+	}
+	want := []string{
+		"type,name,provider,total_tokens,message_count,estimated_cost_usd",
+		"total,,,3000,40,",
+		"provider,ollama,ollama,1000,15,0.0000",
+		"provider,openai,openai,2000,25,0.0200",
+		"model,gpt-4o,openai,2000,25,0.0200",
+		"day,2024-01-02,,3000,40,",
+		"month,2024-01,,3000,40,",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("rows:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// readCSV reads an exported CSV file without its byte order mark
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	return records
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
@@ -113,3 +115,25 @@ func CopyFile(src, dst string) error {
 
 	return nil
 }
+
+// OpenWithDefaultApp opens a file with the operating system's default application
+func OpenWithDefaultApp(filePath string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", filePath)
+	case "darwin":
+		cmd = exec.Command("open", filePath)
+	default:
+		cmd = exec.Command("xdg-open", filePath)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+
+	// Reap the launcher process in the background
+	go cmd.Wait()
+
+	return nil
+}
@@ -15,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/nfnt/resize"
+	"golang.org/x/image/draw"
 )
 
 // FileUploadHandler handles file uploads and processing
@@ -53,6 +54,8 @@ func NewFileUploadHandler() *FileUploadHandler {
 			"text/x-c":          true,
 			"text/x-c++":        true,
 			"text/x-javascript": true,
+			// Documents
+			DocxMimeType: true,
 		},
 	}
 }
@@ -84,6 +87,8 @@ func (h *FileUploadHandler) ProcessFile(filePath string) (*llm.Attachment, error
 	// Process based on type
 	if strings.HasPrefix(mimeType, "image/") {
 		return h.processImage(filePath, mimeType)
+	} else if mimeType == DocxMimeType {
+		return h.processDocxFile(filePath)
 	} else if strings.HasPrefix(mimeType, "text/") || strings.Contains(mimeType, "json") || strings.Contains(mimeType, "xml") {
 		return h.processTextFile(filePath, mimeType)
 	}
@@ -95,6 +100,10 @@ func (h *FileUploadHandler) ProcessFile(filePath string) (*llm.Attachment, error
 func (h *FileUploadHandler) detectMimeType(filePath string) (string, error) {
 	// First try by extension
 	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == ".docx" {
+		// Not in Go's built-in table, the system one may lack it
+		return DocxMimeType, nil
+	}
 	mimeType := mime.TypeByExtension(ext)
 	
 	if mimeType != "" {
@@ -251,6 +260,45 @@ func (h *FileUploadHandler) ProcessImageData(img image.Image, filename string) (
 	}, nil
 }
 
+// GenerateThumbnail creates a JPEG preview of an image attachment that fits
+// within maxWidth x maxHeight, keeping the aspect ratio. Images that already
+// fit are re-encoded at their own size.
+func (h *FileUploadHandler) GenerateThumbnail(att *llm.Attachment, maxWidth, maxHeight int) ([]byte, error) {
+	if att == nil || att.Type != "image" {
+		return nil, fmt.Errorf("attachment is not an image")
+	}
+	if maxWidth <= 0 || maxHeight <= 0 {
+		return nil, fmt.Errorf("invalid thumbnail size %dx%d", maxWidth, maxHeight)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(att.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+
+	// Scale by the dimension that exceeds its limit the most
+	scale := min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height), 1)
+	thumbWidth := max(int(float64(width)*scale), 1)
+	thumbHeight := max(int(float64(height)*scale), 1)
+
+	// JPEG has no alpha, so transparent areas are drawn over white
+	dst := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.BiLinear.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: h.imageQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 // processTextFile processes a text file
 func (h *FileUploadHandler) processTextFile(filePath string, mimeType string) (*llm.Attachment, error) {
 	// Read file content
@@ -267,6 +315,28 @@ func (h *FileUploadHandler) processTextFile(filePath string, mimeType string) (*
 	}, nil
 }
 
+// processDocxFile processes a Word document. The extracted text is kept in
+// the attachment, the original data is kept for saving the file again.
+func (h *FileUploadHandler) processDocxFile(filePath string) (*llm.Attachment, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	text, err := ExtractDocxText(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract text from %s: %w", filepath.Base(filePath), err)
+	}
+
+	return &llm.Attachment{
+		Type:        "file",
+		MimeType:    DocxMimeType,
+		Data:        data,
+		Filename:    filepath.Base(filePath),
+		TextContent: text,
+	}, nil
+}
+
 // AttachmentToBase64 converts an attachment to base64 string
 func AttachmentToBase64(att *llm.Attachment) string {
 	return base64.StdEncoding.EncodeToString(att.Data)
@@ -301,11 +371,15 @@ func GetImageDataURL(att *llm.Attachment) string {
 	return fmt.Sprintf("data:%s;base64,%s", att.MimeType, b64)
 }
 
-// GetTextContent returns the text content of a text file attachment
+// GetTextContent returns the text content of a file attachment: the
+// extracted text of documents, the data itself of text files
 func GetTextContent(att *llm.Attachment) string {
 	if att.Type != "file" {
 		return ""
 	}
+	if att.TextContent != "" {
+		return att.TextContent
+	}
 	return string(att.Data)
 }
 
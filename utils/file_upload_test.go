@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"light-llm-client/llm"
+	"testing"
+)
+
+func newTestImageAttachment(t *testing.T, width, height int) *llm.Attachment {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x += 100 {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	return &llm.Attachment{Type: "image", MimeType: "image/png", Data: buf.Bytes(), Filename: "test.png"}
+}
+
+func TestGenerateThumbnail(t *testing.T) {
+	handler := NewFileUploadHandler()
+
+	tests := []struct {
+		name                  string
+		width, height         int
+		maxWidth, maxHeight   int
+		wantWidth, wantHeight int
+	}{
+		{"landscape", 4000, 3000, 200, 150, 200, 150},
+		{"portrait", 3000, 4000, 200, 150, 112, 150},
+		{"wide", 4000, 1000, 200, 150, 200, 50},
+		{"small image keeps its size", 100, 80, 200, 150, 100, 80},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			att := newTestImageAttachment(t, tt.width, tt.height)
+
+			data, err := handler.GenerateThumbnail(att, tt.maxWidth, tt.maxHeight)
+			if err != nil {
+				t.Fatalf("GenerateThumbnail failed: %v", err)
+			}
+
+			thumb, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("thumbnail is not a JPEG: %v", err)
+			}
+			bounds := thumb.Bounds()
+			if bounds.Dx() != tt.wantWidth || bounds.Dy() != tt.wantHeight {
+				t.Errorf("thumbnail size = %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestGenerateThumbnail_Errors(t *testing.T) {
+	handler := NewFileUploadHandler()
+
+	if _, err := handler.GenerateThumbnail(&llm.Attachment{Type: "file", Data: []byte("text")}, 200, 150); err == nil {
+		t.Error("expected error for non-image attachment")
+	}
+	if _, err := handler.GenerateThumbnail(&llm.Attachment{Type: "image", Data: []byte("not an image")}, 200, 150); err == nil {
+		t.Error("expected error for invalid image data")
+	}
+	if _, err := handler.GenerateThumbnail(newTestImageAttachment(t, 10, 10), 0, 150); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"light-llm-client/db"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// maxGoTestKeywords limits the keywords the generated test asserts on
+const maxGoTestKeywords = 5
+
+// goTestStopWords are common English words that make poor assertions
+var goTestStopWords = map[string]bool{
+	"about": true, "also": true, "been": true, "but": true, "can": true, "could": true,
+	"does": true, "each": true, "for": true, "from": true, "have": true, "here": true,
+	"into": true, "just": true, "like": true, "more": true, "most": true, "only": true,
+	"other": true, "same": true, "should": true, "some": true, "such": true, "than": true,
+	"that": true, "the": true, "their": true, "them": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "those": true, "used": true, "using": true,
+	"very": true, "want": true, "were": true, "what": true, "when": true, "where": true,
+	"which": true, "while": true, "will": true, "with": true, "would": true, "your": true,
+}
+
+// goTestTemplate is the generated fixture. Strings are inserted as quoted Go
+// literals and the result is gofmt'ed.
+var goTestTemplate = template.Must(template.New("gotest").Parse(`package fixtures
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"light-llm-client/llm"
+	"light-llm-client/utils"
+)
+
+// TestConversation_{{.ID}} replays conversation {{.ID}} ({{printf "%q" .Title}}),
+// exported on {{.Date}}. Set LLM_API_KEY and optionally LLM_BASE_URL to run it.
+func TestConversation_{{.ID}}(t *testing.T) {
+	apiKey := os.Getenv("LLM_API_KEY")
+{{- if .NeedsKey}}
+	if apiKey == "" {
+		t.Skip("LLM_API_KEY is not set")
+	}
+{{- end}}
+
+	provider, err := utils.NewProvider({{printf "%q" .Provider}}, utils.ProviderConfig{
+		APIKey:       apiKey,
+		BaseURL:      os.Getenv("LLM_BASE_URL"),
+		DefaultModel: {{printf "%q" .Model}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	messages := []llm.Message{
+{{- range .Messages}}
+		{Role: {{printf "%q" .Role}}, Content: {{printf "%q" .Content}}},
+{{- end}}
+	}
+
+	reply, err := provider.Chat(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	// Keywords of the recorded answer
+	for _, keyword := range []string{ {{- range $i, $k := .Keywords}}{{if $i}}, {{end}}{{printf "%q" $k}}{{end -}} } {
+		if !strings.Contains(strings.ToLower(reply), keyword) {
+			t.Errorf("reply does not mention %q:\n%s", keyword, reply)
+		}
+	}
+}
+`))
+
+// ExportConversationAsGoTest generates a Go test that sends the conversation
+// up to its last answer to the same provider and model, and checks that the
+// reply contains keywords of the recorded answer
+func ExportConversationAsGoTest(database *db.DB, conversationID int64) (string, error) {
+	conv, err := database.GetConversation(conversationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get conversation: %w", err)
+	}
+	messages, err := database.ListMessages(conversationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	last := -1
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" {
+			last = i
+			break
+		}
+	}
+	if last <= 0 {
+		return "", fmt.Errorf("conversation has no answered message")
+	}
+	answer := messages[last]
+
+	data := struct {
+		ID       int64
+		Title    string
+		Date     string
+		Provider string
+		NeedsKey bool
+		Model    string
+		Messages []*db.Message
+		Keywords []string
+	}{
+		ID:       conversationID,
+		Title:    conv.Title,
+		Date:     time.Now().Format("2006-01-02"),
+		Provider: answer.Provider,
+		Model:    answer.Model,
+		Messages: messages[:last],
+		Keywords: ExtractKeywords(answer.Content, maxGoTestKeywords),
+	}
+	if data.Provider == "" {
+		data.Provider = "openai"
+	}
+	data.NeedsKey = data.Provider != "ollama"
+
+	var buf bytes.Buffer
+	if err := goTestTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to generate test: %w", err)
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to format test: %w", err)
+	}
+	return string(source), nil
+}
+
+// ExtractKeywords returns up to max lowercase words of text, most frequent
+// first. Words of Latin script need four letters and must not be stop words;
+// text without such words (e.g. Chinese) yields its most frequent pairs of
+// Han characters instead.
+func ExtractKeywords(text string, max int) []string {
+	counts := make(map[string]int)
+	var order []string
+	add := func(word string) {
+		if counts[word] == 0 {
+			order = append(order, word)
+		}
+		counts[word]++
+	}
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 4 && !goTestStopWords[word] && !unicode.Is(unicode.Han, []rune(word)[0]) {
+			add(word)
+		}
+	}
+
+	if len(order) == 0 {
+		runes := []rune(text)
+		for i := 0; i+1 < len(runes); i++ {
+			if unicode.Is(unicode.Han, runes[i]) && unicode.Is(unicode.Han, runes[i+1]) {
+				add(string(runes[i : i+2]))
+			}
+		}
+	}
+
+	// Most frequent first, ties in order of appearance
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+	if len(order) > max {
+		order = order[:max]
+	}
+	return order
+}
@@ -0,0 +1,83 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"go/parser"
+	"go/token"
+	"light-llm-client/db"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportConversationAsGoTest(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer database.Close()
+
+	conv, err := database.CreateConversation("Goroutines", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	for _, m := range []struct{ role, content string }{
+		{"user", "What is a \"goroutine\"?\nAnswer briefly."},
+		{"assistant", "A goroutine is a lightweight thread managed by the Go runtime. Goroutine scheduling is cheap."},
+	} {
+		if _, err := database.CreateMessage(conv.ID, m.role, m.content, "ollama", "llama3.2", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	source, err := ExportConversationAsGoTest(database, conv.ID)
+	if err != nil {
+		t.Fatalf("ExportConversationAsGoTest failed: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "conversation_test.go", source, 0); err != nil {
+		t.Fatalf("generated test does not parse: %v\n%s", err, source)
+	}
+	for _, want := range []string{
+		"func TestConversation_",
+		`utils.NewProvider("ollama"`,
+		`DefaultModel: "llama3.2"`,
+		`Content: "What is a \"goroutine\"?\nAnswer briefly."`,
+		`"goroutine"`,
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("generated test does not contain %s:\n%s", want, source)
+		}
+	}
+	if strings.Contains(source, "lightweight thread managed") {
+		t.Error("the recorded answer must not be sent to the provider")
+	}
+	if strings.Contains(source, "t.Skip") {
+		t.Error("Ollama needs no API key, the test should not skip")
+	}
+
+	empty, err := database.CreateConversation("Empty", "")
+	if err != nil {
+		t.Fatalf("CreateConversation failed: %v", err)
+	}
+	if _, err := ExportConversationAsGoTest(database, empty.ID); err == nil {
+		t.Error("expected an error for a conversation without answers")
+	}
+}
+
+func TestExtractKeywords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"The goroutine runs; goroutine scheduling is cheap with channels.", []string{"goroutine", "runs", "scheduling", "cheap", "channels"}},
+		{"并发是一种结构，并发不是并行。", []string{"并发", "发是", "是一", "一种", "种结"}},
+		{"ok", nil},
+	}
+	for _, tt := range tests {
+		if got := ExtractKeywords(tt.text, 5); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractKeywords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"light-llm-client/db"
+	"light-llm-client/llm"
+	"sort"
+	"strings"
+)
+
+// headlessTitleLength is the length of the titles of conversations started
+// by HeadlessRunner, taken from the prompt
+const headlessTitleLength = 50
+
+// HeadlessRunner answers a single prompt without the UI and saves the round
+// trip like a chat would
+type HeadlessRunner struct {
+	DB        *db.DB
+	Providers map[string]llm.Provider
+	Configs   map[string]ProviderConfig
+}
+
+// Run sends prompt to a provider and returns the response. With a
+// conversationID the prompt continues that conversation and the history is
+// sent along; otherwise a new conversation is saved. providerName picks the
+// provider; without it the provider of the conversation's last response is
+// used, or else the first enabled provider by name. The messages are only
+// saved when the provider answers.
+func (r *HeadlessRunner) Run(ctx context.Context, prompt string, conversationID int64, providerName string) (string, error) {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return "", errors.New("prompt is empty")
+	}
+
+	var conv *db.Conversation
+	var history []*db.Message
+	if conversationID != 0 {
+		var err error
+		conv, err = r.DB.GetConversation(conversationID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get conversation %d: %w", conversationID, err)
+		}
+		history, err = r.DB.ListMessages(conversationID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get messages: %w", err)
+		}
+	}
+
+	name, err := r.pickProvider(providerName, history)
+	if err != nil {
+		return "", err
+	}
+	provider := r.Providers[name]
+	config := r.Configs[name]
+
+	messages := make([]llm.Message, 0, len(history)+2)
+	for _, msg := range history {
+		messages = append(messages, llm.Message{Role: msg.Role, Content: msg.Content})
+	}
+	messages = append(messages, llm.Message{Role: "user", Content: prompt})
+	// The conversation's system prompt takes precedence over the provider's
+	systemPrompt := config.SystemPrompt
+	if conv != nil && conv.SystemPrompt != "" {
+		systemPrompt = conv.SystemPrompt
+	}
+	messages = llm.PrependSystemPrompt(systemPrompt, messages)
+
+	response, err := provider.Chat(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("%s request failed: %w", name, err)
+	}
+
+	if conv == nil {
+		conv, err = r.DB.CreateConversation(truncateRunes(prompt, headlessTitleLength), "")
+		if err != nil {
+			return "", fmt.Errorf("failed to create conversation: %w", err)
+		}
+	}
+	if _, err := r.DB.CreateMessage(conv.ID, "user", prompt, "", "", "", 0); err != nil {
+		return "", fmt.Errorf("failed to save prompt: %w", err)
+	}
+	model := config.DefaultModel
+	if model == "" {
+		model = name
+	}
+	if _, err := r.DB.CreateMessage(conv.ID, "assistant", response, name, model, "", 0); err != nil {
+		return "", fmt.Errorf("failed to save response: %w", err)
+	}
+	return response, nil
+}
+
+// pickProvider returns the name of the provider to answer with
+func (r *HeadlessRunner) pickProvider(name string, history []*db.Message) (string, error) {
+	if name != "" {
+		if _, ok := r.Providers[name]; !ok {
+			return "", fmt.Errorf("provider %q is not enabled or failed to initialize", name)
+		}
+		return name, nil
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if msg := history[i]; msg.Role == "assistant" && msg.Provider != "" {
+			if _, ok := r.Providers[msg.Provider]; ok {
+				return msg.Provider, nil
+			}
+			break
+		}
+	}
+
+	names := make([]string, 0, len(r.Providers))
+	for name := range r.Providers {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "", errors.New("no provider is enabled")
+	}
+	sort.Strings(names)
+	return names[0], nil
+}
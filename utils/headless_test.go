@@ -0,0 +1,80 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"context"
+	"light-llm-client/db"
+	"light-llm-client/llm"
+	"path/filepath"
+	"testing"
+)
+
+func TestHeadlessRunner_Run(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	runner := &HeadlessRunner{
+		DB: database,
+		Providers: map[string]llm.Provider{
+			"beta":  llm.NewMockProvider(llm.MockConfig{Responses: []string{"from beta"}}),
+			"alpha": llm.NewMockProvider(llm.MockConfig{Responses: []string{"from alpha"}}),
+		},
+		Configs: map[string]ProviderConfig{"beta": {DefaultModel: "beta-1"}},
+	}
+
+	// Without a conversation a new one is saved, answered by the first provider
+	response, err := runner.Run(context.Background(), "What is Go?", 0, "")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if response != "from alpha" {
+		t.Errorf("response = %q, want %q", response, "from alpha")
+	}
+	convs, err := database.ListConversations(10, 0)
+	if err != nil || len(convs) != 1 {
+		t.Fatalf("got %d conversations (%v), want 1", len(convs), err)
+	}
+	conv := convs[0]
+	if conv.Title != "What is Go?" {
+		t.Errorf("title = %q, want the prompt", conv.Title)
+	}
+
+	// Continuing it with another provider appends to it
+	if _, err := runner.Run(context.Background(), "And Rust?", conv.ID, "beta"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	// Without a provider the conversation's last one answers
+	response, err = runner.Run(context.Background(), "Thanks", conv.ID, "")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if response != "from beta" {
+		t.Errorf("response = %q, want the conversation's provider", response)
+	}
+
+	messages, err := database.ListMessages(conv.ID)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(messages) != 6 {
+		t.Fatalf("got %d messages, want 6", len(messages))
+	}
+	last := messages[5]
+	if last.Role != "assistant" || last.Provider != "beta" || last.Model != "beta-1" {
+		t.Errorf("last message = %+v, want a beta-1 response", last)
+	}
+
+	if _, err := runner.Run(context.Background(), "Hi", 0, "missing"); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+	if _, err := runner.Run(context.Background(), "Hi", 9999, ""); err == nil {
+		t.Error("expected an error for an unknown conversation")
+	}
+	if convs, _ := database.ListConversations(10, 0); len(convs) != 1 {
+		t.Errorf("failed runs saved a conversation, got %d", len(convs))
+	}
+}
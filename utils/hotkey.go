@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultGlobalHotkey shows the main window from anywhere
+const DefaultGlobalHotkey = "ctrl+alt+l"
+
+// HotkeyModifier is a set of modifier keys of a hotkey
+type HotkeyModifier int
+
+const (
+	HotkeyCtrl HotkeyModifier = 1 << iota
+	HotkeyAlt
+	HotkeyShift
+	HotkeySuper // Windows key on Windows, Cmd on macOS
+)
+
+// Hotkey is a parsed key combination such as Ctrl+Alt+L
+type Hotkey struct {
+	Modifiers HotkeyModifier
+	// Key is "A"-"Z", "0"-"9", "F1"-"F12" or "Space"
+	Key string
+}
+
+// String formats the hotkey the way ParseHotkey accepts it
+func (h Hotkey) String() string {
+	var parts []string
+	if h.Modifiers&HotkeyCtrl != 0 {
+		parts = append(parts, "ctrl")
+	}
+	if h.Modifiers&HotkeyAlt != 0 {
+		parts = append(parts, "alt")
+	}
+	if h.Modifiers&HotkeyShift != 0 {
+		parts = append(parts, "shift")
+	}
+	if h.Modifiers&HotkeySuper != 0 {
+		parts = append(parts, "super")
+	}
+	return strings.Join(append(parts, strings.ToLower(h.Key)), "+")
+}
+
+// ParseHotkey parses a key combination like "ctrl+alt+l". Names are case
+// insensitive and at least one modifier is required so the hotkey does not
+// swallow normal typing.
+func ParseHotkey(keys string) (Hotkey, error) {
+	var hotkey Hotkey
+	for _, part := range strings.Split(keys, "+") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		var modifier HotkeyModifier
+		switch name {
+		case "":
+			return Hotkey{}, fmt.Errorf("invalid hotkey %q: empty key name", keys)
+		case "ctrl", "control":
+			modifier = HotkeyCtrl
+		case "alt":
+			modifier = HotkeyAlt
+		case "shift":
+			modifier = HotkeyShift
+		case "super", "win", "cmd", "meta":
+			modifier = HotkeySuper
+		}
+
+		if modifier != 0 {
+			if hotkey.Modifiers&modifier != 0 {
+				return Hotkey{}, fmt.Errorf("invalid hotkey %q: %s is repeated", keys, name)
+			}
+			hotkey.Modifiers |= modifier
+			continue
+		}
+
+		if hotkey.Key != "" {
+			return Hotkey{}, fmt.Errorf("invalid hotkey %q: only one non-modifier key is allowed", keys)
+		}
+		key, ok := hotkeyKeyName(name)
+		if !ok {
+			return Hotkey{}, fmt.Errorf("invalid hotkey %q: unknown key %q", keys, part)
+		}
+		hotkey.Key = key
+	}
+
+	if hotkey.Key == "" {
+		return Hotkey{}, fmt.Errorf("invalid hotkey %q: missing key", keys)
+	}
+	if hotkey.Modifiers == 0 {
+		return Hotkey{}, fmt.Errorf("invalid hotkey %q: at least one of ctrl, alt, shift or super is required", keys)
+	}
+	return hotkey, nil
+}
+
+// hotkeyKeyName normalizes a lower-case key name
+func hotkeyKeyName(name string) (string, bool) {
+	if name == "space" {
+		return "Space", true
+	}
+	if len(name) == 1 && (name[0] >= 'a' && name[0] <= 'z' || name[0] >= '0' && name[0] <= '9') {
+		return strings.ToUpper(name), true
+	}
+	var n int
+	if _, err := fmt.Sscanf(name, "f%d", &n); err == nil && n >= 1 && n <= 12 && name == fmt.Sprintf("f%d", n) {
+		return fmt.Sprintf("F%d", n), true
+	}
+	return "", false
+}
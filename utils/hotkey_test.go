@@ -0,0 +1,47 @@
+package utils
+
+import "testing"
+
+func TestParseHotkey(t *testing.T) {
+	tests := []struct {
+		keys string
+		want Hotkey
+	}{
+		{"ctrl+alt+l", Hotkey{HotkeyCtrl | HotkeyAlt, "L"}},
+		{"Control + Shift + F12", Hotkey{HotkeyCtrl | HotkeyShift, "F12"}},
+		{"win+space", Hotkey{HotkeySuper, "Space"}},
+		{"alt+1", Hotkey{HotkeyAlt, "1"}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseHotkey(tt.keys)
+		if err != nil {
+			t.Errorf("ParseHotkey(%q) failed: %v", tt.keys, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseHotkey(%q) = %+v, want %+v", tt.keys, got, tt.want)
+		}
+		if again, err := ParseHotkey(got.String()); err != nil || again != got {
+			t.Errorf("String() of %q does not parse back: %q", tt.keys, got.String())
+		}
+	}
+}
+
+func TestParseHotkey_Invalid(t *testing.T) {
+	for _, keys := range []string{
+		"",
+		"l",           // no modifier
+		"ctrl+alt",    // no key
+		"ctrl+a+b",    // two keys
+		"ctrl+ctrl+l", // repeated modifier
+		"ctrl++l",     // empty part
+		"ctrl+f13",    // out of range
+		"ctrl+f01",    // not a key name
+		"ctrl+escape", // unsupported key
+	} {
+		if _, err := ParseHotkey(keys); err == nil {
+			t.Errorf("ParseHotkey(%q) should fail", keys)
+		}
+	}
+}
@@ -0,0 +1,433 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"light-llm-client/db"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ImportFormat identifies the layout of an import file
+type ImportFormat string
+
+const (
+	ImportFormatChatGPT ImportFormat = "chatgpt" // conversations.json from a ChatGPT data export
+	ImportFormatSingle  ImportFormat = "single"  // one conversation exported by this app
+	ImportFormatMulti   ImportFormat = "multi"   // "export all" file of this app
+	ImportFormatUnknown ImportFormat = "unknown"
+)
+
+// maxImportEntrySize limits the size of a single JSON file read from a zip archive
+const maxImportEntrySize = 512 << 20
+
+// ImportSource is the parsed content of an import file
+type ImportSource struct {
+	Format        ImportFormat
+	Conversations []ConversationExport
+	// Files is the number of JSON files read; SkippedFiles were not recognized
+	Files        int
+	SkippedFiles []string
+	// SkippedToolMessages counts the tool and plugin messages of ChatGPT
+	// exports, which are not imported
+	SkippedToolMessages int
+}
+
+// MessageCount returns the total number of messages in the source
+func (s *ImportSource) MessageCount() int {
+	count := 0
+	for _, conv := range s.Conversations {
+		count += len(conv.Messages)
+	}
+	return count
+}
+
+// ImportResult summarizes a finished import
+type ImportResult struct {
+	Conversations int
+	Messages      int
+}
+
+// ParseImportFile reads a JSON file or a .zip archive of JSON files and
+// detects their format. Files in an archive may use different formats; the
+// source then reports ImportFormatMulti.
+func ParseImportFile(path string) (*ImportSource, error) {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		return parseImportZip(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	format, conversations, skipped, err := parseImportData(data)
+	if err != nil {
+		return nil, err
+	}
+	source := &ImportSource{Format: format, Conversations: conversations, Files: 1, SkippedToolMessages: skipped}
+	if format == ImportFormatUnknown {
+		source.SkippedFiles = []string{filepath.Base(path)}
+	}
+	return source, nil
+}
+
+// parseImportZip parses every JSON file in a zip archive
+func parseImportZip(path string) (*ImportSource, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	defer archive.Close()
+
+	source := &ImportSource{Format: ImportFormatUnknown}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !strings.EqualFold(filepath.Ext(file.Name), ".json") {
+			continue
+		}
+
+		data, err := readZipEntry(file)
+		if err != nil {
+			return nil, err
+		}
+		source.Files++
+
+		format, conversations, skipped, err := parseImportData(data)
+		if err != nil || format == ImportFormatUnknown {
+			source.SkippedFiles = append(source.SkippedFiles, file.Name)
+			continue
+		}
+		source.SkippedToolMessages += skipped
+
+		switch source.Format {
+		case ImportFormatUnknown:
+			source.Format = format
+		case format:
+		default:
+			source.Format = ImportFormatMulti
+		}
+		source.Conversations = append(source.Conversations, conversations...)
+	}
+
+	// Several single-conversation files together are a multi-conversation import
+	if source.Format == ImportFormatSingle && len(source.Conversations) > 1 {
+		source.Format = ImportFormatMulti
+	}
+
+	return source, nil
+}
+
+// readZipEntry reads a file from a zip archive
+func readZipEntry(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > maxImportEntrySize {
+		return nil, fmt.Errorf("file %s in archive is too large", file.Name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in archive: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxImportEntrySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s in archive: %w", file.Name, err)
+	}
+	return data, nil
+}
+
+// ParseImportData detects the format of a JSON document and extracts its
+// conversations. Unrecognized documents return ImportFormatUnknown without an error.
+func ParseImportData(data []byte) (ImportFormat, []ConversationExport, error) {
+	format, conversations, _, err := parseImportData(data)
+	return format, conversations, err
+}
+
+// parseImportData is ParseImportData that also returns the number of
+// skipped tool and plugin messages
+func parseImportData(data []byte) (ImportFormat, []ConversationExport, int, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	if len(data) == 0 {
+		return ImportFormatUnknown, nil, 0, nil
+	}
+
+	// ChatGPT exports are an array of conversations with a message mapping
+	if data[0] == '[' {
+		var chatGPT []chatGPTConversation
+		if err := json.Unmarshal(data, &chatGPT); err != nil {
+			return ImportFormatUnknown, nil, 0, nil
+		}
+		if len(chatGPT) == 0 || chatGPT[0].Mapping == nil {
+			return ImportFormatUnknown, nil, 0, nil
+		}
+		conversations := make([]ConversationExport, 0, len(chatGPT))
+		skipped := 0
+		for _, conv := range chatGPT {
+			export, toolMessages := conv.toExport()
+			skipped += toolMessages
+			if len(export.Messages) > 0 {
+				conversations = append(conversations, export)
+			}
+		}
+		return ImportFormatChatGPT, conversations, skipped, nil
+	}
+
+	var probe struct {
+		Conversations json.RawMessage `json:"conversations"`
+		Title         string          `json:"title"`
+		Messages      json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return ImportFormatUnknown, nil, 0, nil
+	}
+
+	switch {
+	case probe.Conversations != nil:
+		var conversations []ConversationExport
+		if err := json.Unmarshal(probe.Conversations, &conversations); err != nil {
+			return ImportFormatUnknown, nil, 0, fmt.Errorf("failed to parse conversations: %w", err)
+		}
+		valid := conversations[:0]
+		for _, conv := range conversations {
+			if conv.Title != "" && len(conv.Messages) > 0 {
+				valid = append(valid, conv)
+			}
+		}
+		return ImportFormatMulti, valid, 0, nil
+
+	case probe.Title != "" && probe.Messages != nil:
+		var conv ConversationExport
+		if err := json.Unmarshal(data, &conv); err != nil {
+			return ImportFormatUnknown, nil, 0, fmt.Errorf("failed to parse conversation: %w", err)
+		}
+		if len(conv.Messages) == 0 {
+			return ImportFormatSingle, nil, 0, nil
+		}
+		return ImportFormatSingle, []ConversationExport{conv}, 0, nil
+	}
+
+	return ImportFormatUnknown, nil, 0, nil
+}
+
+// chatGPTConversation is a conversation in a ChatGPT conversations.json export
+type chatGPTConversation struct {
+	Title       string                 `json:"title"`
+	CreateTime  float64                `json:"create_time"`
+	UpdateTime  float64                `json:"update_time"`
+	CurrentNode string                 `json:"current_node"`
+	Mapping     map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	ID      string          `json:"id"`
+	Parent  string          `json:"parent"`
+	Message *chatGPTMessage `json:"message"`
+}
+
+type chatGPTMessage struct {
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	// Recipient is "all" for messages to the user; assistant messages to a
+	// tool or plugin name it
+	Recipient  string  `json:"recipient"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string        `json:"content_type"`
+		Parts       []interface{} `json:"parts"`
+	} `json:"content"`
+	Metadata struct {
+		ModelSlug string `json:"model_slug"`
+	} `json:"metadata"`
+}
+
+// toExport converts the conversation to the export structure of this app.
+// Only the branch ending at current_node is kept, as shown in ChatGPT. Tool
+// and plugin calls and their results are left out and counted.
+func (c chatGPTConversation) toExport() (ConversationExport, int) {
+	export := ConversationExport{
+		Title:     c.Title,
+		CreatedAt: unixSeconds(c.CreateTime),
+		UpdatedAt: unixSeconds(c.UpdateTime),
+	}
+	if export.Title == "" {
+		export.Title = "ChatGPT 对话"
+	}
+
+	skipped := 0
+	for _, node := range c.branch() {
+		msg := node.Message
+		if msg == nil {
+			continue
+		}
+		if msg.isToolMessage() {
+			skipped++
+			continue
+		}
+		if msg.Author.Role != "user" && msg.Author.Role != "assistant" {
+			continue
+		}
+
+		var parts []string
+		for _, part := range msg.Content.Parts {
+			if text, ok := part.(string); ok && strings.TrimSpace(text) != "" {
+				parts = append(parts, text)
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		message := MessageExport{
+			Role:      msg.Author.Role,
+			Content:   strings.Join(parts, "\n"),
+			CreatedAt: unixSeconds(msg.CreateTime),
+		}
+		if msg.Author.Role == "assistant" {
+			message.Provider = "ChatGPT"
+			message.Model = msg.Metadata.ModelSlug
+		}
+		export.Messages = append(export.Messages, message)
+	}
+
+	return export, skipped
+}
+
+// isToolMessage reports whether the message is the output of a tool or
+// plugin, or an assistant message calling one
+func (m *chatGPTMessage) isToolMessage() bool {
+	if m.Author.Role == "tool" {
+		return true
+	}
+	return m.Author.Role == "assistant" && m.Recipient != "" && m.Recipient != "all"
+}
+
+// branch returns the nodes from the root to the current node. Exports
+// without current_node fall back to all nodes in creation order.
+func (c chatGPTConversation) branch() []chatGPTNode {
+	var nodes []chatGPTNode
+	if node, ok := c.Mapping[c.CurrentNode]; ok {
+		seen := make(map[string]bool)
+		for ok && !seen[node.ID] {
+			seen[node.ID] = true
+			nodes = append(nodes, node)
+			node, ok = c.Mapping[node.Parent]
+		}
+		for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+			nodes[i], nodes[j] = nodes[j], nodes[i]
+		}
+		return nodes
+	}
+
+	for _, node := range c.Mapping {
+		if node.Message != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Message.CreateTime < nodes[j].Message.CreateTime
+	})
+	return nodes
+}
+
+// unixSeconds converts a fractional Unix timestamp, zero staying zero
+func unixSeconds(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// ImportFromChatGPTExport imports the conversations of a ChatGPT data
+// export, given as conversations.json or as the exported .zip archive. It
+// returns the number of imported conversations.
+func ImportFromChatGPTExport(database *db.DB, filePath string) (int, error) {
+	source, err := ParseImportFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+	if source.Format != ImportFormatChatGPT {
+		return 0, fmt.Errorf("not a ChatGPT export: %s", filepath.Base(filePath))
+	}
+
+	result, err := ImportConversations(database, source.Conversations, nil)
+	return result.Conversations, err
+}
+
+// ImportConversations creates a new conversation for each export. progress,
+// if set, is called after each imported message with the running total.
+func ImportConversations(database *db.DB, conversations []ConversationExport, progress func(done, total int)) (ImportResult, error) {
+	total := 0
+	for _, conv := range conversations {
+		total += len(conv.Messages)
+	}
+
+	var result ImportResult
+	for _, export := range conversations {
+		conv, err := createImportedConversation(database, export)
+		if err != nil {
+			return result, fmt.Errorf("failed to create conversation: %w", err)
+		}
+		result.Conversations++
+
+		if err := importMessages(database, conv.ID, export.Messages, func() {
+			result.Messages++
+			if progress != nil {
+				progress(result.Messages, total)
+			}
+		}); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// MergeConversation appends the messages of an export to an existing conversation
+func MergeConversation(database *db.DB, conversationID int64, export ConversationExport, progress func(done, total int)) (ImportResult, error) {
+	if _, err := database.GetConversation(conversationID); err != nil {
+		return ImportResult{}, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	var result ImportResult
+	err := importMessages(database, conversationID, export.Messages, func() {
+		result.Messages++
+		if progress != nil {
+			progress(result.Messages, len(export.Messages))
+		}
+	})
+	return result, err
+}
+
+// importMessages creates the exported messages and their tags in a conversation
+func importMessages(database *db.DB, conversationID int64, messages []MessageExport, onMessage func()) error {
+	for _, msgExport := range messages {
+		msg, err := database.CreateMessage(
+			conversationID,
+			msgExport.Role,
+			msgExport.Content,
+			msgExport.Provider,
+			msgExport.Model,
+			msgExport.Attachments,
+			msgExport.TokensUsed,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create message: %w", err)
+		}
+		if len(msgExport.Tags) > 0 {
+			if err := database.SetMessageTags(msg.ID, msgExport.Tags); err != nil {
+				return fmt.Errorf("failed to restore message tags: %w", err)
+			}
+		}
+		if onMessage != nil {
+			onMessage()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const chatGPTExport = `[{
+	"title": "Go question",
+	"create_time": 1700000000.5,
+	"current_node": "c",
+	"mapping": {
+		"root": {"id": "root", "parent": null, "message": null},
+		"s": {"id": "s", "parent": "root", "message": {"author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]}}},
+		"a": {"id": "a", "parent": "s", "message": {"author": {"role": "user"}, "create_time": 1700000001, "content": {"content_type": "text", "parts": ["What is a goroutine?"]}}},
+		"b-old": {"id": "b-old", "parent": "a", "message": {"author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["regenerated away"]}}},
+		"c": {"id": "c", "parent": "a", "message": {"author": {"role": "assistant"}, "create_time": 1700000002, "content": {"content_type": "text", "parts": ["A lightweight thread."]}, "metadata": {"model_slug": "gpt-4o"}}}
+	}
+}]`
+
+const singleExport = `{"id": 1, "title": "Single", "messages": [{"role": "user", "content": "hi"}, {"role": "assistant", "content": "hello"}]}`
+
+const multiExport = `{"metadata": {"export_version": "1.0"}, "conversations": [
+	{"title": "First", "messages": [{"role": "user", "content": "one"}]},
+	{"title": "Empty", "messages": []},
+	{"title": "Second", "messages": [{"role": "user", "content": "two"}]}
+]}`
+
+func TestParseImportData_DetectsFormats(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		format        ImportFormat
+		conversations int
+	}{
+		{"chatgpt", chatGPTExport, ImportFormatChatGPT, 1},
+		{"single", singleExport, ImportFormatSingle, 1},
+		{"multi", multiExport, ImportFormatMulti, 2},
+		{"unknown object", `{"foo": 1}`, ImportFormatUnknown, 0},
+		{"unknown array", `[1, 2, 3]`, ImportFormatUnknown, 0},
+		{"not json", `hello`, ImportFormatUnknown, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, conversations, err := ParseImportData([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseImportData failed: %v", err)
+			}
+			if format != tt.format || len(conversations) != tt.conversations {
+				t.Fatalf("got %s with %d conversations, want %s with %d", format, len(conversations), tt.format, tt.conversations)
+			}
+		})
+	}
+}
+
+func TestParseImportData_ChatGPTFollowsCurrentBranch(t *testing.T) {
+	_, conversations, err := ParseImportData([]byte(chatGPTExport))
+	if err != nil {
+		t.Fatalf("ParseImportData failed: %v", err)
+	}
+
+	messages := conversations[0].Messages
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2: %+v", len(messages), messages)
+	}
+	if messages[0].Role != "user" || messages[1].Content != "A lightweight thread." || messages[1].Model != "gpt-4o" {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+}
+
+func TestParseImportFile_SkipsChatGPTToolMessages(t *testing.T) {
+	const export = `[{
+		"title": "Browsing",
+		"current_node": "d",
+		"mapping": {
+			"a": {"id": "a", "parent": null, "message": {"author": {"role": "user"}, "content": {"content_type": "text", "parts": ["Weather in Paris?"]}}},
+			"b": {"id": "b", "parent": "a", "message": {"author": {"role": "assistant"}, "recipient": "browser", "content": {"content_type": "code", "parts": ["search('paris weather')"]}}},
+			"c": {"id": "c", "parent": "b", "message": {"author": {"role": "tool"}, "recipient": "all", "content": {"content_type": "text", "parts": ["Sunny, 21°C"]}}},
+			"d": {"id": "d", "parent": "c", "message": {"author": {"role": "assistant"}, "recipient": "all", "content": {"content_type": "text", "parts": ["It is sunny."]}}}
+		}
+	}]`
+	path := filepath.Join(t.TempDir(), "conversations.json")
+	if err := os.WriteFile(path, []byte(export), 0644); err != nil {
+		t.Fatalf("failed to write export: %v", err)
+	}
+
+	source, err := ParseImportFile(path)
+	if err != nil {
+		t.Fatalf("ParseImportFile failed: %v", err)
+	}
+	if source.SkippedToolMessages != 2 {
+		t.Errorf("SkippedToolMessages = %d, want 2", source.SkippedToolMessages)
+	}
+	messages := source.Conversations[0].Messages
+	if len(messages) != 2 || messages[0].Content != "Weather in Paris?" || messages[1].Content != "It is sunny." {
+		t.Errorf("unexpected messages: %+v", messages)
+	}
+}
+
+func TestParseImportFile_Zip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exports.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	archive := zip.NewWriter(file)
+	for name, content := range map[string]string{
+		"a.json":     singleExport,
+		"b.json":     singleExport,
+		"notes.json": `{"foo": 1}`,
+		"readme.txt": "ignored",
+	} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to write zip: %v", err)
+	}
+	file.Close()
+
+	source, err := ParseImportFile(path)
+	if err != nil {
+		t.Fatalf("ParseImportFile failed: %v", err)
+	}
+	if source.Format != ImportFormatMulti || len(source.Conversations) != 2 || source.MessageCount() != 4 {
+		t.Fatalf("unexpected source: %+v", source)
+	}
+	if source.Files != 3 || len(source.SkippedFiles) != 1 || source.SkippedFiles[0] != "notes.json" {
+		t.Fatalf("got %d files, skipped %v", source.Files, source.SkippedFiles)
+	}
+}
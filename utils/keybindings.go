@@ -0,0 +1,46 @@
+package utils
+
+// Actions of the window shortcuts, the keys of Config.Keybindings
+const (
+	ActionNewConversation = "new_conversation"
+	ActionSearch          = "search"
+	ActionSettings        = "settings"
+	ActionCloseTab        = "close_tab"
+	ActionNextTab         = "next_tab"
+	ActionPreviousTab     = "previous_tab"
+	ActionSearchTabs      = "search_tabs"
+	ActionFork            = "fork_conversation"
+	ActionHelp            = "help"
+)
+
+// defaultKeybindings are the key combinations of actions not in
+// Config.Keybindings
+var defaultKeybindings = map[string]string{
+	ActionNewConversation: "ctrl+n",
+	ActionSearch:          "ctrl+f",
+	ActionSettings:        "ctrl+,",
+	ActionCloseTab:        "ctrl+w",
+	ActionNextTab:         "ctrl+tab",
+	ActionPreviousTab:     "ctrl+shift+tab",
+	ActionSearchTabs:      "ctrl+shift+t",
+	ActionFork:            "ctrl+shift+f",
+	ActionHelp:            "ctrl+?",
+}
+
+// DefaultKeybindings returns the default key combination of each action
+func DefaultKeybindings() map[string]string {
+	keybindings := make(map[string]string, len(defaultKeybindings))
+	for action, keys := range defaultKeybindings {
+		keybindings[action] = keys
+	}
+	return keybindings
+}
+
+// Keybinding returns the key combination of an action, the configured one or
+// the default. An empty configured value disables the shortcut.
+func (c *Config) Keybinding(action string) string {
+	if keys, ok := c.Keybindings[action]; ok {
+		return keys
+	}
+	return defaultKeybindings[action]
+}
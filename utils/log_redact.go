@@ -0,0 +1,37 @@
+package utils
+
+// logRedactPatterns are the default patterns applied to log lines. They only
+// match credentials, so they don't depend on AnonymizeSensitiveData.
+var logRedactPatterns = map[string]bool{
+	"API Key":      true,
+	"Bearer Token": true,
+	"JWT Token":    true,
+}
+
+// newLogRedactor creates the anonymizer that scrubs log lines
+func newLogRedactor() *Anonymizer {
+	a := NewAnonymizer(PrivacyConfig{AnonymizeSensitiveData: true, AnonymizeAPIKeys: true})
+	a.patterns = nil
+	for _, pattern := range defaultPatterns {
+		if logRedactPatterns[pattern.Name] {
+			a.patterns = append(a.patterns, pattern)
+		}
+	}
+	return a
+}
+
+// redact replaces the matches of the patterns with placeholders. Unlike
+// Anonymize the originals are not kept, so they can't be restored.
+func (a *Anonymizer) redact(text string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.replacePatterns(text, false)
+}
+
+// SetRedactBeforeLog sets whether API keys, bearer tokens and JWTs are
+// replaced with placeholders in log messages before they are written
+func (l *Logger) SetRedactBeforeLog(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.redactBeforeLog = enabled
+}
@@ -2,16 +2,65 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
+// backupPrefix is the file name prefix of rotated log files
+const backupPrefix = "light-llm-client-"
+
+// backupTimeFormat is used in rotated log file names.
+// Colons are not allowed in Windows file names, so the time part uses dashes.
+const backupTimeFormat = "2006-01-02T15-04-05"
+
+// RotationConfig controls when log files are rotated and how many are kept
+type RotationConfig struct {
+	MaxSizeMB  int `json:"max_size_mb"`  // Rotate when the file reaches this size (0 = never)
+	MaxBackups int `json:"max_backups"`  // Maximum number of rotated files to keep (0 = unlimited)
+	MaxAgeDays int `json:"max_age_days"` // Delete rotated files older than this (0 = never)
+
+	// StructuredLog writes the raw provider requests and responses as JSON
+	// lines to requests.jsonl in the log directory
+	StructuredLog bool `json:"structured_log"`
+	// RedactAPIKeys replaces API keys in the logged headers and URLs with "***"
+	RedactAPIKeys bool `json:"redact_api_keys"`
+}
+
+// DefaultRotationConfig rotates at 10 MB and keeps 5 rotated files for up to
+// 30 days
+func DefaultRotationConfig() RotationConfig {
+	return RotationConfig{
+		MaxSizeMB:     10,
+		MaxBackups:    5,
+		MaxAgeDays:    30,
+		RedactAPIKeys: true,
+	}
+}
+
 // Logger provides logging functionality
 type Logger struct {
-	file   *os.File
-	logger *log.Logger
+	mu       sync.Mutex
+	file     *os.File
+	logger   *log.Logger
+	path     string
+	size     int64
+	rotation RotationConfig
+	console  io.Writer // Echoes log lines, nil for none
+
+	// Structured request log, nil unless enabled (see request_log.go)
+	requestMu   sync.Mutex
+	requestFile *os.File
+	redactKeys  bool
+
+	// Scrubs credentials from log messages when redactBeforeLog is set
+	redactor        *Anonymizer
+	redactBeforeLog bool
 }
 
 // NewLogger creates a new logger
@@ -22,54 +71,187 @@ func NewLogger(logPath string) (*Logger, error) {
 		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 
-	// Open log file
-	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	l := &Logger{path: logPath, redactor: newLogRedactor(), console: os.Stdout}
+	if err := l.openFile(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// openFile opens (or creates) the log file and records its current size
+func (l *Logger) openFile() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+		return fmt.Errorf("failed to open log file: %w", err)
 	}
 
-	logger := log.New(file, "", log.LstdFlags)
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
 
-	return &Logger{
-		file:   file,
-		logger: logger,
-	}, nil
+	l.file = file
+	l.size = info.Size()
+	l.logger = log.New(file, "", log.LstdFlags)
+	return nil
+}
+
+// SetRotation sets the rotation policy and removes expired backups
+func (l *Logger) SetRotation(rotation RotationConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rotation = rotation
+	l.cleanupBackups()
+}
+
+// SetConsole sets where log lines are echoed besides the log file, nil
+// turns the echo off. The command line modes use stderr so that their
+// results on stdout stay parseable.
+func (l *Logger) SetConsole(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.console = w
+}
+
+// Path returns the path of the current log file
+func (l *Logger) Path() string {
+	return l.path
+}
+
+// Size returns the size in bytes of the current log file
+func (l *Logger) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size
 }
 
 // Close closes the logger
 func (l *Logger) Close() error {
+	l.SetRequestLogging(false, false)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	if l.file != nil {
 		return l.file.Close()
 	}
 	return nil
 }
 
+// write writes a message to the log file, rotating it first if it is full
+func (l *Logger) write(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	maxSize := int64(l.rotation.MaxSizeMB) * 1024 * 1024
+	if maxSize > 0 && l.size >= maxSize {
+		if err := l.rotate(); err != nil {
+			l.echo(fmt.Sprintf("[ERROR] Failed to rotate log file: %v", err))
+		}
+	}
+
+	l.logger.Println(msg)
+	// Timestamp prefix (log.LstdFlags) + message + newline
+	l.size += int64(len("2006/01/02 15:04:05 ") + len(msg) + 1)
+	l.echo(msg)
+}
+
+// echo writes a message to the console. Must be called with l.mu held.
+func (l *Logger) echo(msg string) {
+	if l.console != nil {
+		fmt.Fprintln(l.console, msg)
+	}
+}
+
+// rotate renames the current log file to a timestamped backup and opens a new
+// one. Must be called with l.mu held.
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	backupPath := filepath.Join(filepath.Dir(l.path), backupPrefix+time.Now().Format(backupTimeFormat)+".log")
+	if err := os.Rename(l.path, backupPath); err != nil {
+		// Keep logging to the old file rather than losing messages
+		if openErr := l.openFile(); openErr != nil {
+			return fmt.Errorf("failed to reopen log file: %w", openErr)
+		}
+		return fmt.Errorf("failed to rename log file: %w", err)
+	}
+
+	if err := l.openFile(); err != nil {
+		return err
+	}
+
+	l.cleanupBackups()
+	return nil
+}
+
+// cleanupBackups deletes rotated files older than MaxAgeDays and keeps at most
+// MaxBackups of the newest ones. Must be called with l.mu held.
+func (l *Logger) cleanupBackups() {
+	backups, err := filepath.Glob(filepath.Join(filepath.Dir(l.path), backupPrefix+"*.log"))
+	if err != nil || len(backups) == 0 {
+		return
+	}
+
+	// Newest first; the timestamp in the name sorts chronologically
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	cutoff := time.Now().AddDate(0, 0, -l.rotation.MaxAgeDays)
+	kept := 0
+	for _, backup := range backups {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(backup), backupPrefix), ".log")
+		rotatedAt, err := time.ParseInLocation(backupTimeFormat, name, time.Local)
+		if err != nil {
+			continue // Not one of our backups
+		}
+
+		expired := l.rotation.MaxAgeDays > 0 && rotatedAt.Before(cutoff)
+		tooMany := l.rotation.MaxBackups > 0 && kept >= l.rotation.MaxBackups
+		if expired || tooMany {
+			if err := os.Remove(backup); err != nil {
+				l.echo(fmt.Sprintf("[ERROR] Failed to remove old log file %s: %v", backup, err))
+			}
+			continue
+		}
+		kept++
+	}
+}
+
+// print writes a message to the log file and the console
+func (l *Logger) print(msg string) {
+	l.mu.Lock()
+	redact := l.redactBeforeLog
+	l.mu.Unlock()
+	if redact {
+		msg = l.redactor.redact(msg)
+	}
+
+	l.write(msg)
+}
+
 // Info logs an info message
 func (l *Logger) Info(format string, v ...interface{}) {
-	msg := fmt.Sprintf("[INFO] "+format, v...)
-	l.logger.Println(msg)
-	fmt.Println(msg)
+	l.print(fmt.Sprintf("[INFO] "+format, v...))
 }
 
 // Error logs an error message
 func (l *Logger) Error(format string, v ...interface{}) {
-	msg := fmt.Sprintf("[ERROR] "+format, v...)
-	l.logger.Println(msg)
-	fmt.Println(msg)
+	l.print(fmt.Sprintf("[ERROR] "+format, v...))
 }
 
 // Debug logs a debug message
 func (l *Logger) Debug(format string, v ...interface{}) {
-	msg := fmt.Sprintf("[DEBUG] "+format, v...)
-	l.logger.Println(msg)
-	fmt.Println(msg)
+	l.print(fmt.Sprintf("[DEBUG] "+format, v...))
 }
 
 // Warn logs a warning message
 func (l *Logger) Warn(format string, v ...interface{}) {
-	msg := fmt.Sprintf("[WARN] "+format, v...)
-	l.logger.Println(msg)
-	fmt.Println(msg)
+	l.print(fmt.Sprintf("[WARN] "+format, v...))
 }
 
 // GetLogPath returns the default log path
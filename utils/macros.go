@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Macro is a snippet the input box expands: typing Trigger followed by Space
+// or Tab replaces it with Expansion
+type Macro struct {
+	Trigger   string `json:"trigger"`
+	Expansion string `json:"expansion"`
+}
+
+// MacroStore keeps the macros in a JSON file. It is safe for concurrent use.
+type MacroStore struct {
+	path   string
+	mu     sync.RWMutex
+	macros map[string]string // Trigger -> expansion
+}
+
+// NewMacroStore loads the macros of the file at path. A missing file is an
+// empty store; the file is created when a macro is added.
+func NewMacroStore(path string) (*MacroStore, error) {
+	s := &MacroStore{path: path, macros: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read macros: %w", err)
+	}
+	var macros []Macro
+	if err := json.Unmarshal(data, &macros); err != nil {
+		return nil, fmt.Errorf("failed to parse macros: %w", err)
+	}
+	for _, m := range macros {
+		s.macros[m.Trigger] = m.Expansion
+	}
+	return s, nil
+}
+
+// ValidateMacroTrigger checks that a trigger is a '/' followed by at least
+// one character and contains no whitespace
+func ValidateMacroTrigger(trigger string) error {
+	if !strings.HasPrefix(trigger, "/") || len(trigger) < 2 {
+		return errors.New("trigger must start with / followed by a name, e.g. /code")
+	}
+	if strings.IndexFunc(trigger, unicode.IsSpace) >= 0 {
+		return errors.New("trigger must not contain spaces")
+	}
+	return nil
+}
+
+// Expand returns the expansion of trigger
+func (s *MacroStore) Expand(trigger string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	expansion, ok := s.macros[trigger]
+	return expansion, ok
+}
+
+// List returns the macros sorted by trigger
+func (s *MacroStore) List() []Macro {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedLocked()
+}
+
+// Set adds a macro or changes the expansion of an existing trigger and
+// saves the file
+func (s *MacroStore) Set(trigger, expansion string) error {
+	if err := ValidateMacroTrigger(trigger); err != nil {
+		return err
+	}
+	if expansion == "" {
+		return errors.New("expansion must not be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, existed := s.macros[trigger]
+	s.macros[trigger] = expansion
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.macros[trigger] = previous
+		} else {
+			delete(s.macros, trigger)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes a macro and saves the file. Unknown triggers are ignored.
+func (s *MacroStore) Delete(trigger string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expansion, ok := s.macros[trigger]
+	if !ok {
+		return nil
+	}
+	delete(s.macros, trigger)
+	if err := s.saveLocked(); err != nil {
+		s.macros[trigger] = expansion
+		return err
+	}
+	return nil
+}
+
+func (s *MacroStore) sortedLocked() []Macro {
+	macros := make([]Macro, 0, len(s.macros))
+	for trigger, expansion := range s.macros {
+		macros = append(macros, Macro{Trigger: trigger, Expansion: expansion})
+	}
+	sort.Slice(macros, func(i, j int) bool { return macros[i].Trigger < macros[j].Trigger })
+	return macros
+}
+
+// saveLocked replaces the file atomically, like SaveConfig
+func (s *MacroStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.sortedLocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal macros: %w", err)
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create macros directory: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write macros: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save macros: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMacroStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "macros.json")
+	store, err := NewMacroStore(path)
+	if err != nil {
+		t.Fatalf("NewMacroStore failed: %v", err)
+	}
+	if len(store.List()) != 0 {
+		t.Fatal("expected an empty store without a file")
+	}
+
+	for _, trigger := range []string{"code", "/", "/two words"} {
+		if err := store.Set(trigger, "x"); err == nil {
+			t.Errorf("Set(%q) succeeded", trigger)
+		}
+	}
+	if err := store.Set("/code", "Review this code:\n"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := store.Set("/tr", "Translate to English:"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := store.Delete("/tr"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// The file keeps the macros
+	reloaded, err := NewMacroStore(path)
+	if err != nil {
+		t.Fatalf("NewMacroStore failed: %v", err)
+	}
+	if expansion, ok := reloaded.Expand("/code"); !ok || expansion != "Review this code:\n" {
+		t.Errorf("Expand(/code) = %q, %v", expansion, ok)
+	}
+	if _, ok := reloaded.Expand("/tr"); ok {
+		t.Error("deleted macro still expands")
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMacroStore(path); err == nil {
+		t.Error("expected an error for a broken file")
+	}
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"encoding/json"
+	"light-llm-client/llm"
+)
+
+// normalStopReasons are the stop reasons of a response that simply ended,
+// which aren't worth showing
+var normalStopReasons = map[string]bool{
+	"stop":     true, // OpenAI compatible, Ollama
+	"end_turn": true, // Claude
+	"STOP":     true, // Gemini
+	"COMPLETE": true, // Cohere
+}
+
+// EncodeMessageMetadata serializes the metadata of a response for
+// db.Message.Metadata. It returns "" if there is none.
+func EncodeMessageMetadata(metadata map[string]interface{}) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// WithResponseMetrics returns the metadata of a response with the fields of
+// the metrics added, so both are stored together
+func WithResponseMetrics(metadata map[string]interface{}, m ResponseMetrics) map[string]interface{} {
+	merged := make(map[string]interface{}, len(metadata)+5)
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(m.Encode()), &fields); err == nil {
+		for k, v := range fields {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// MessageStopReason returns the stop reason stored in message metadata, ""
+// if it has none or the response simply ended
+func MessageStopReason(metadata string) string {
+	if metadata == "" {
+		return ""
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(metadata), &fields); err != nil {
+		return ""
+	}
+	reason, _ := fields[llm.MetadataStopReason].(string)
+	if normalStopReasons[reason] {
+		return ""
+	}
+	return reason
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"light-llm-client/llm"
+	"testing"
+)
+
+func TestMessageStopReason(t *testing.T) {
+	tests := []struct {
+		metadata string
+		want     string
+	}{
+		{"", ""},
+		{"not json", ""},
+		{`{"stop_reason":"max_tokens"}`, "max_tokens"},
+		{`{"stop_reason":"end_turn"}`, ""},
+		{`{"stop_reason":"STOP","safety_ratings":{}}`, ""},
+		{`{"ttft_ms":10,"total_ms":20}`, ""},
+	}
+	for _, tt := range tests {
+		if got := MessageStopReason(tt.metadata); got != tt.want {
+			t.Errorf("MessageStopReason(%q) = %q, want %q", tt.metadata, got, tt.want)
+		}
+	}
+}
+
+func TestWithResponseMetrics(t *testing.T) {
+	metadata := map[string]interface{}{llm.MetadataStopReason: "length"}
+	encoded := EncodeMessageMetadata(WithResponseMetrics(metadata, ResponseMetrics{TTFTMs: 120, TotalMs: 900, Tokens: 42}))
+
+	if got := MessageStopReason(encoded); got != "length" {
+		t.Errorf("expected the stop reason to be kept, got %q", got)
+	}
+	m, ok := ParseResponseMetrics(encoded)
+	if !ok || m.TTFTMs != 120 || m.Tokens != 42 {
+		t.Errorf("expected the metrics to be stored, got %+v, %v", m, ok)
+	}
+	if len(metadata) != 1 {
+		t.Errorf("the metadata of the response should not change, got %v", metadata)
+	}
+	if EncodeMessageMetadata(nil) != "" {
+		t.Error("expected no metadata to encode to an empty string")
+	}
+}
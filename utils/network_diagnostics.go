@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"light-llm-client/llm"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Timeouts of the diagnostic steps
+const (
+	diagnosticsDNSTimeout  = 3 * time.Second
+	diagnosticsTCPTimeout  = 3 * time.Second
+	diagnosticsTLSTimeout  = 3 * time.Second
+	diagnosticsHTTPTimeout = 5 * time.Second
+)
+
+// DiagnosticReport is the result of RunNetworkDiagnostics. A step that was
+// not run because an earlier one failed has no latency.
+type DiagnosticReport struct {
+	URL   string
+	Proxy string // Proxy the connection goes through, "" for a direct one
+	Host  string // Host of the first hop: the proxy, or the server
+	Port  string
+	TLS   bool // Whether the URL is https
+
+	Addresses  []string // Resolved IP addresses
+	HTTPStatus int
+
+	DNSLatency  time.Duration
+	TCPLatency  time.Duration
+	TLSLatency  time.Duration // Zero for plain http URLs
+	HTTPLatency time.Duration
+
+	Errors []string
+}
+
+// OK reports whether every step succeeded
+func (r DiagnosticReport) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// String returns a step-by-step report for the user
+func (r DiagnosticReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Network diagnostics for %s\n", r.URL)
+	if r.Proxy != "" {
+		fmt.Fprintf(&sb, "Through proxy %s\n", r.Proxy)
+	}
+	if r.Host == "" {
+		sb.WriteString("❌ " + strings.Join(r.Errors, "\n❌ ") + "\n")
+		return sb.String()
+	}
+
+	failed := false
+	step := func(name string, latency time.Duration, ok string, errPrefix string) {
+		switch {
+		case failed:
+			fmt.Fprintf(&sb, "⏭ %s: skipped\n", name)
+		case latency > 0 && r.errorFor(errPrefix) == "":
+			fmt.Fprintf(&sb, "✅ %s: %s (%s)\n", name, ok, latency.Round(time.Millisecond))
+		default:
+			fmt.Fprintf(&sb, "❌ %s: %s\n", name, r.errorFor(errPrefix))
+			failed = true
+		}
+	}
+	hop := ""
+	if r.Proxy != "" {
+		hop = "Proxy "
+	}
+	step(hop+"DNS", r.DNSLatency, "resolved to "+strings.Join(r.Addresses, ", "), "DNS")
+	step(hop+"TCP", r.TCPLatency, "connected to port "+r.Port, "TCP")
+	// Through a proxy the handshake is part of the HTTP step
+	if r.TLS && r.Proxy == "" {
+		step("TLS", r.TLSLatency, "certificate chain verified for "+r.Host, "TLS")
+	}
+	step("HTTP", r.HTTPLatency, fmt.Sprintf("GET / returned %d", r.HTTPStatus), "HTTP")
+	return sb.String()
+}
+
+// errorFor returns the error recorded for a step
+func (r DiagnosticReport) errorFor(prefix string) string {
+	for _, err := range r.Errors {
+		if strings.HasPrefix(err, prefix+": ") {
+			return strings.TrimPrefix(err, prefix+": ")
+		}
+	}
+	return ""
+}
+
+// RunNetworkDiagnostics checks the connection to a provider's base URL step
+// by step: DNS resolution of the host, a TCP connection, the TLS handshake
+// with certificate verification (https only) and an HTTP GET of "/". It stops
+// at the first failing step. With a proxy URL (see Config.ProviderProxyURL)
+// DNS and TCP are checked for the proxy, and the GET goes through it.
+func RunNetworkDiagnostics(baseURL, proxyURL string) DiagnosticReport {
+	return runNetworkDiagnostics(baseURL, proxyURL, nil)
+}
+
+// runNetworkDiagnostics is RunNetworkDiagnostics with the TLS config used to
+// verify certificates; nil uses the system roots
+func runNetworkDiagnostics(baseURL, proxyURL string, tlsConfig *tls.Config) DiagnosticReport {
+	report := DiagnosticReport{URL: baseURL}
+	fail := func(step string, err error) DiagnosticReport {
+		report.Errors = append(report.Errors, step+": "+err.Error())
+		return report
+	}
+
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || u.Hostname() == "" {
+		if err == nil {
+			err = fmt.Errorf("no host in URL")
+		}
+		return fail("URL", err)
+	}
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	if err := llm.SetProxy(transport, proxyURL); err != nil {
+		return fail("Proxy", err)
+	}
+	useTLS := !strings.EqualFold(u.Scheme, "http")
+	report.TLS = useTLS
+	report.Host = u.Hostname()
+	report.Port = defaultPort(u)
+
+	// The first hop is the proxy, the server is reached through it
+	proxied := proxyURL != ""
+	if proxied {
+		p, _ := url.Parse(proxyURL)
+		report.Proxy = p.Redacted()
+		report.Host = p.Hostname()
+		report.Port = defaultPort(p)
+	}
+
+	// DNS
+	ctx, cancel := context.WithTimeout(context.Background(), diagnosticsDNSTimeout)
+	start := time.Now()
+	addresses, err := net.DefaultResolver.LookupHost(ctx, report.Host)
+	cancel()
+	if err != nil {
+		return fail("DNS", err)
+	}
+	report.DNSLatency = nonZero(time.Since(start))
+	report.Addresses = addresses
+
+	// TCP
+	start = time.Now()
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(addresses[0], report.Port), diagnosticsTCPTimeout)
+	if err != nil {
+		return fail("TCP", err)
+	}
+	report.TCPLatency = nonZero(time.Since(start))
+
+	// TLS
+	if useTLS && !proxied {
+		config := &tls.Config{}
+		if tlsConfig != nil {
+			config = tlsConfig.Clone()
+		}
+		config.ServerName = report.Host
+		tlsConn := tls.Client(conn, config)
+		tlsConn.SetDeadline(time.Now().Add(diagnosticsTLSTimeout))
+		start = time.Now()
+		err = tlsConn.Handshake()
+		tlsConn.Close()
+		if err != nil {
+			return fail("TLS", err)
+		}
+		report.TLSLatency = nonZero(time.Since(start))
+	} else {
+		conn.Close()
+	}
+
+	// HTTP
+	root := url.URL{Scheme: "https", Host: u.Host, Path: "/"}
+	if !useTLS {
+		root.Scheme = "http"
+	}
+	client := &http.Client{Timeout: diagnosticsHTTPTimeout, Transport: transport}
+	defer client.CloseIdleConnections()
+	start = time.Now()
+	resp, err := client.Get(root.String())
+	if err != nil {
+		return fail("HTTP", err)
+	}
+	resp.Body.Close()
+	report.HTTPLatency = nonZero(time.Since(start))
+	report.HTTPStatus = resp.StatusCode
+	return report
+}
+
+// defaultPort returns the port of u, or the default one of its scheme
+func defaultPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		return "80"
+	case "socks5", "socks5h":
+		return "1080"
+	}
+	return "443"
+}
+
+// nonZero keeps a measured latency from being mistaken for a skipped step
+func nonZero(d time.Duration) time.Duration {
+	if d <= 0 {
+		return time.Nanosecond
+	}
+	return d
+}
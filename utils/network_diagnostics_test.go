@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunNetworkDiagnostics(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+	trusted := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	report := runNetworkDiagnostics(server.URL+"/v1", "", trusted)
+	if !report.OK() {
+		t.Fatalf("diagnostics failed: %v", report.Errors)
+	}
+	if report.DNSLatency <= 0 || report.TCPLatency <= 0 || report.TLSLatency <= 0 || report.HTTPLatency <= 0 {
+		t.Errorf("expected every step to have a latency: %+v", report)
+	}
+	if report.HTTPStatus != http.StatusNotFound {
+		t.Errorf("HTTPStatus = %d, want 404", report.HTTPStatus)
+	}
+	if text := report.String(); strings.Count(text, "✅") != 4 || !strings.Contains(text, "GET / returned 404") {
+		t.Errorf("unexpected report:\n%s", text)
+	}
+
+	// The test server's certificate is not trusted by the system
+	report = runNetworkDiagnostics(server.URL, "", &tls.Config{})
+	if report.OK() || report.errorFor("TLS") == "" || report.HTTPLatency != 0 {
+		t.Errorf("expected the TLS step to fail: %+v", report)
+	}
+	if text := report.String(); !strings.Contains(text, "❌ TLS") || !strings.Contains(text, "⏭ HTTP: skipped") {
+		t.Errorf("unexpected report:\n%s", text)
+	}
+
+	// Plain http skips TLS
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	report = RunNetworkDiagnostics(plain.URL, "")
+	if !report.OK() || report.TLS || report.TLSLatency != 0 {
+		t.Errorf("unexpected report for http: %+v", report)
+	}
+	if strings.Contains(report.String(), "TLS") {
+		t.Errorf("http report mentions TLS:\n%s", report.String())
+	}
+}
+
+func TestRunNetworkDiagnostics_ConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	report := RunNetworkDiagnostics("https://"+addr, "")
+	if report.errorFor("TCP") == "" || report.DNSLatency <= 0 {
+		t.Errorf("expected DNS to pass and TCP to fail: %+v", report)
+	}
+	if text := report.String(); !strings.Contains(text, "⏭ TLS: skipped") {
+		t.Errorf("unexpected report:\n%s", text)
+	}
+
+	if report := RunNetworkDiagnostics("not a url", ""); report.OK() || report.errorFor("URL") == "" {
+		t.Errorf("expected an invalid URL error: %+v", report)
+	}
+}
+
+func TestRunNetworkDiagnostics_Proxy(t *testing.T) {
+	// The server name does not resolve, only the proxy can reach it
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer proxy.Close()
+
+	report := RunNetworkDiagnostics("http://llm.invalid/v1", proxy.URL)
+	if !report.OK() {
+		t.Fatalf("diagnostics failed: %v", report.Errors)
+	}
+	if proxied != "http://llm.invalid/" || report.HTTPStatus != http.StatusTeapot {
+		t.Errorf("GET did not go through the proxy: proxied %q, status %d", proxied, report.HTTPStatus)
+	}
+	if _, port, _ := net.SplitHostPort(proxy.Listener.Addr().String()); report.Port != port || report.Host != "127.0.0.1" {
+		t.Errorf("first hop = %s:%s, want the proxy", report.Host, report.Port)
+	}
+	if text := report.String(); !strings.Contains(text, "Through proxy "+proxy.URL) || !strings.Contains(text, "✅ Proxy TCP") {
+		t.Errorf("unexpected report:\n%s", text)
+	}
+
+	if report := RunNetworkDiagnostics("http://llm.invalid", "ftp://proxy"); report.errorFor("Proxy") == "" {
+		t.Errorf("expected an invalid proxy error: %+v", report)
+	}
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// A4 page size in PDF points
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+)
+
+// pdfFontFamily is the name unifont is registered under
+const pdfFontFamily = "unifont"
+
+// pdfBoldStroke is the outline width of bold text relative to the font size.
+// Unifont has no bold face, so bold glyphs are filled and stroked.
+const pdfBoldStroke = 0.04
+
+// unifontGz is GNU Unifont, which covers the Basic Multilingual Plane
+// including CJK. PDFs embed only the glyphs they use. See fonts/README.md
+// for its license.
+//
+//go:embed fonts/unifont-13.0.03.ttf.gz
+var unifontGz []byte
+
+// pdfFont holds the decompressed font, loaded by the first export
+var pdfFont struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// pdfDocument draws reports with gofpdf: text in unifont, filled
+// rectangles and lines. Coordinates are in points with the origin at the
+// top left of the page.
+type pdfDocument struct {
+	pdf *gofpdf.Fpdf
+}
+
+// newPDFDocument creates an empty A4 document. A font that fails to load
+// is reported by WriteFile.
+func newPDFDocument() *pdfDocument {
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	font, err := loadPDFFont()
+	if err != nil {
+		pdf.SetError(err)
+	} else {
+		pdf.AddUTF8FontFromBytes(pdfFontFamily, "", font)
+	}
+	return &pdfDocument{pdf: pdf}
+}
+
+// loadPDFFont returns the embedded font, decompressing it once. gofpdf only
+// reads the bytes, so every document shares them.
+func loadPDFFont() ([]byte, error) {
+	pdfFont.once.Do(func() {
+		pdfFont.data, pdfFont.err = decompressPDFFont()
+	})
+	return pdfFont.data, pdfFont.err
+}
+
+// decompressPDFFont decompresses the embedded font
+func decompressPDFFont() ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(unifontGz))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF font: %w", err)
+	}
+	defer zr.Close()
+	font, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF font: %w", err)
+	}
+	return font, nil
+}
+
+// AddPage starts a new page and draws on it
+func (d *pdfDocument) AddPage() {
+	d.pdf.AddPage()
+}
+
+// SetPage draws on an existing page again, numbered from 0
+func (d *pdfDocument) SetPage(page int) {
+	d.pdf.SetPage(page + 1)
+}
+
+// PageCount returns the number of pages
+func (d *pdfDocument) PageCount() int {
+	return d.pdf.PageCount()
+}
+
+// Text draws text with its baseline at y
+func (d *pdfDocument) Text(x, y, size float64, bold bool, text string) {
+	d.pdf.SetFont(pdfFontFamily, "", size)
+	if bold {
+		d.pdf.SetLineWidth(size * pdfBoldStroke)
+		d.pdf.SetDrawColor(0, 0, 0)
+		d.pdf.SetTextRenderingMode(2)
+		defer d.pdf.SetTextRenderingMode(0)
+	}
+	d.pdf.Text(x, y, text)
+}
+
+// MonoText draws code with its baseline at y. Unifont glyphs are a fixed
+// half or full em wide, so code lines up without a separate font.
+func (d *pdfDocument) MonoText(x, y, size float64, text string) {
+	d.Text(x, y, size, false, text)
+}
+
+// TextWidth returns the width of text at the font size
+func (d *pdfDocument) TextWidth(size float64, text string) float64 {
+	d.pdf.SetFont(pdfFontFamily, "", size)
+	return d.pdf.GetStringWidth(text)
+}
+
+// Rect draws a rectangle filled with an RGB color (components 0-255)
+func (d *pdfDocument) Rect(x, y, w, h float64, r, g, b uint8) {
+	d.pdf.SetFillColor(int(r), int(g), int(b))
+	d.pdf.Rect(x, y, w, h, "F")
+}
+
+// Line draws a thin gray line
+func (d *pdfDocument) Line(x1, y1, x2, y2 float64) {
+	d.pdf.SetDrawColor(153, 153, 153)
+	d.pdf.SetLineWidth(0.5)
+	d.pdf.Line(x1, y1, x2, y2)
+}
+
+// WriteFile writes the document to path
+func (d *pdfDocument) WriteFile(path string) error {
+	if err := d.pdf.OutputFileAndClose(path); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"light-llm-client/db"
+	"os"
+	"time"
+)
+
+// PromptTemplateExport is a prompt template in an exported templates file
+type PromptTemplateExport struct {
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+	Content  string `json:"content"`
+}
+
+// promptTemplatesFile is the layout of an exported templates file
+type promptTemplatesFile struct {
+	Metadata  map[string]string      `json:"metadata"`
+	Templates []PromptTemplateExport `json:"templates"`
+}
+
+// ExportPromptTemplates exports all prompt templates to a JSON file
+func ExportPromptTemplates(database *db.DB, filepath string) error {
+	templates, err := database.ListPromptTemplates()
+	if err != nil {
+		return err
+	}
+
+	file := promptTemplatesFile{
+		Metadata: map[string]string{
+			"export_version": "1.0",
+			"export_date":    time.Now().Format(time.RFC3339),
+			"app_name":       "Light LLM Client",
+			"total_count":    fmt.Sprintf("%d", len(templates)),
+		},
+		Templates: make([]PromptTemplateExport, 0, len(templates)),
+	}
+	for _, template := range templates {
+		file.Templates = append(file.Templates, PromptTemplateExport{
+			Name:     template.Name,
+			Category: template.Category,
+			Content:  template.Content,
+		})
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := os.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// ImportPromptTemplates adds the templates of an exported templates file and
+// returns how many were added. Templates with the same name and content as an
+// existing one are skipped, so importing a file twice adds nothing.
+func ImportPromptTemplates(database *db.DB, filepath string) (int, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var file promptTemplatesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	if file.Templates == nil {
+		return 0, fmt.Errorf("invalid export: missing templates array")
+	}
+
+	existing, err := database.ListPromptTemplates()
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[PromptTemplateExport]bool, len(existing))
+	for _, template := range existing {
+		known[PromptTemplateExport{Name: template.Name, Content: template.Content}] = true
+	}
+
+	count := 0
+	for _, template := range file.Templates {
+		key := PromptTemplateExport{Name: template.Name, Content: template.Content}
+		if known[key] {
+			continue
+		}
+		if _, err := database.CreatePromptTemplate(template.Name, template.Category, template.Content); err != nil {
+			return count, fmt.Errorf("failed to import template %q: %w", template.Name, err)
+		}
+		known[key] = true
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,53 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"light-llm-client/db"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportImportPromptTemplates(t *testing.T) {
+	newDB := func(name string) *db.DB {
+		database, err := db.New(filepath.Join(t.TempDir(), name))
+		if err != nil {
+			t.Fatalf("failed to create database: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
+		return database
+	}
+	source := newDB("source.db")
+	if _, err := source.CreatePromptTemplate("Translate", "Language", "Translate to {{language}}:\n{{text}}"); err != nil {
+		t.Fatalf("CreatePromptTemplate failed: %v", err)
+	}
+	if _, err := source.CreatePromptTemplate("Summarize", "", "Summarize this."); err != nil {
+		t.Fatalf("CreatePromptTemplate failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "templates.json")
+	if err := ExportPromptTemplates(source, path); err != nil {
+		t.Fatalf("ExportPromptTemplates failed: %v", err)
+	}
+
+	target := newDB("target.db")
+	count, err := ImportPromptTemplates(target, path)
+	if err != nil {
+		t.Fatalf("ImportPromptTemplates failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("imported %d templates, want 2", count)
+	}
+	templates, err := target.ListPromptTemplates()
+	if err != nil {
+		t.Fatalf("ListPromptTemplates failed: %v", err)
+	}
+	if len(templates) != 2 || templates[1].Category != "Language" || len(templates[1].Variables) != 2 {
+		t.Errorf("unexpected imported templates %+v", templates)
+	}
+
+	// Importing the same file again adds nothing
+	if count, err := ImportPromptTemplates(target, path); err != nil || count != 0 {
+		t.Errorf("second import = %d, %v; want 0, nil", count, err)
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import "light-llm-client/llm"
+
+// NewProvider creates the LLM provider configured under name. The name picks
+// the API: ollama, claude/anthropic, gemini, cohere, mistral, perplexity,
+// groq, deepseek, xai/grok and azure/azure_openai have their own clients, everything
+// else is treated as OpenAI-compatible.
+func NewProvider(name string, providerConfig ProviderConfig) (llm.Provider, error) {
+	return NewProviderWithLogger(name, providerConfig, nil)
+}
+
+// NewProviderWithLogger creates a provider like NewProvider whose HTTP
+// traffic goes to the structured request log of logger, if it is enabled
+func NewProviderWithLogger(name string, providerConfig ProviderConfig, logger *Logger) (llm.Provider, error) {
+	// Use display name if available, otherwise use config key
+	displayName := providerConfig.DisplayName
+	if displayName == "" {
+		displayName = name
+	}
+
+	config := llm.Config{
+		ProviderName:    displayName,
+		APIKey:          providerConfig.APIKey,
+		BaseURL:         providerConfig.BaseURL,
+		Model:           providerConfig.DefaultModel,
+		Models:          providerConfig.Models,
+		MaxTokens:       providerConfig.MaxTokens,
+		Timeout:         providerConfig.TimeoutSeconds,
+		Temperature:     providerConfig.Temperature,
+		SafePrompt:      providerConfig.SafePrompt,
+		ReasoningEffort: providerConfig.ReasoningEffort,
+		ProxyURL:        providerConfig.ProxyURL,
+		DeploymentName:  providerConfig.DeploymentName,
+		APIVersion:      providerConfig.APIVersion,
+	}
+	if logger != nil {
+		config.RequestLogger = logger
+		config.Logger = logger
+	}
+
+	switch name {
+	case "ollama":
+		// Ollama needs no API key and uses the model's own sampling settings
+		return llm.NewOllamaProvider(llm.Config{
+			ProviderName:  config.ProviderName,
+			BaseURL:       config.BaseURL,
+			Model:         config.Model,
+			Models:        config.Models,
+			ProxyURL:      config.ProxyURL,
+			Timeout:       config.Timeout,
+			RequestLogger: config.RequestLogger,
+		})
+	case "claude", "anthropic":
+		return llm.NewClaudeProvider(config)
+	case "gemini":
+		return llm.NewGeminiProvider(config)
+	case "cohere":
+		return llm.NewCohereProvider(config)
+	case "mistral":
+		// OpenAI-compatible with tool call differences
+		return llm.NewMistralProvider(config)
+	case "perplexity":
+		// OpenAI-compatible with web search citations
+		return llm.NewPerplexityProvider(config)
+	case "groq":
+		// OpenAI-compatible with queue and processing times in the headers
+		return llm.NewGroqProvider(config)
+	case "deepseek":
+		// OpenAI-compatible with the chain of thought in reasoning_content
+		return llm.NewDeepSeekProvider(config)
+	case "xai", "grok":
+		// OpenAI-compatible with an optional reasoning effort
+		return llm.NewXAIProvider(config)
+	case "azure", "azure_openai":
+		// OpenAI's API behind deployment URLs and an api-key header
+		return llm.NewAzureOpenAIProvider(config)
+	default:
+		// No validation - let the provider itself validate
+		return llm.NewOpenAIProvider(config)
+	}
+}
@@ -1,15 +1,70 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
 )
 
-// RecoverFromPanic recovers from panics and logs them
+// PanicReport describes a panic recovered by RecoverFromPanic
+type PanicReport struct {
+	GoroutineID int64       // 0 if it couldn't be read from the stack
+	Name        string      // Context passed to SafeGo
+	Value       interface{} // Value passed to panic
+	Stack       string
+	Time        time.Time
+}
+
+// String formats the report for a bug report
+func (r *PanicReport) String() string {
+	return fmt.Sprintf("Panic in %s (goroutine %d) at %s: %v\n\n%s",
+		r.Name, r.GoroutineID, r.Time.Format("2006-01-02 15:04:05"), r.Value, r.Stack)
+}
+
+var (
+	lastPanicMu     sync.Mutex
+	lastPanicReport *PanicReport
+)
+
+// LastPanicReport returns the most recent recovered panic, nil if none
+func LastPanicReport() *PanicReport {
+	lastPanicMu.Lock()
+	defer lastPanicMu.Unlock()
+	return lastPanicReport
+}
+
+// goroutineID reads the goroutine ID from the first line of a stack trace,
+// "goroutine 42 [running]:"
+func goroutineID(stack []byte) int64 {
+	line := bytes.TrimPrefix(stack, []byte("goroutine "))
+	if end := bytes.IndexByte(line, ' '); end > 0 {
+		if id, err := strconv.ParseInt(string(line[:end]), 10, 64); err == nil {
+			return id
+		}
+	}
+	return 0
+}
+
+// RecoverFromPanic recovers from panics, logs them with the stack trace and
+// keeps them as the LastPanicReport
 func RecoverFromPanic(logger *Logger, context string) {
 	if r := recover(); r != nil {
 		stack := debug.Stack()
-		logger.Error("Panic recovered in %s: %v\nStack trace:\n%s", context, r, string(stack))
+		report := &PanicReport{
+			GoroutineID: goroutineID(stack),
+			Name:        context,
+			Value:       r,
+			Stack:       string(stack),
+			Time:        time.Now(),
+		}
+		lastPanicMu.Lock()
+		lastPanicReport = report
+		lastPanicMu.Unlock()
+
+		logger.Error("Panic recovered in %s (goroutine %d): %v\nStack trace:\n%s", context, report.GoroutineID, r, report.Stack)
 	}
 }
 
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSafeGo_PanicReport(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	logger, err := NewLogger(logPath)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	defer logger.Close()
+
+	done := make(chan struct{})
+	SafeGo(logger, "exploding worker", func() {
+		defer close(done)
+		panic("boom")
+	})
+	<-done
+
+	// RecoverFromPanic runs after fn has closed done
+	var report *PanicReport
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if report = LastPanicReport(); report != nil && report.Name == "exploding worker" {
+			break
+		}
+	}
+	if report == nil || report.Name != "exploding worker" {
+		t.Fatalf("expected the panic to be reported, got %+v", report)
+	}
+	if report.Value != "boom" || report.GoroutineID == 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if !strings.Contains(report.Stack, "TestSafeGo_PanicReport") {
+		t.Errorf("expected the stack trace of the panicking goroutine, got:\n%s", report.Stack)
+	}
+	if !strings.HasPrefix(report.String(), "Panic in exploding worker (goroutine ") {
+		t.Errorf("unexpected report text: %q", report.String())
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read the log: %v", err)
+	}
+	if !strings.Contains(string(data), "Stack trace:\n") || !strings.Contains(string(data), "TestSafeGo_PanicReport") {
+		t.Errorf("expected the stack trace in the log, got:\n%s", data)
+	}
+}
+
+func TestGoroutineID(t *testing.T) {
+	if got := goroutineID([]byte("goroutine 42 [running]:\nmain.main()")); got != 42 {
+		t.Errorf("goroutineID = %d, want 42", got)
+	}
+	if got := goroutineID([]byte("not a stack")); got != 0 {
+		t.Errorf("goroutineID = %d, want 0", got)
+	}
+}
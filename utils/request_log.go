@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// RequestLogName is the file the structured request log is written to
+const RequestLogName = "requests.jsonl"
+
+// maxRequestLogBody limits the logged preview of a request or response body
+const maxRequestLogBody = 4096
+
+// redacted replaces API keys in the request log
+const redacted = "***"
+
+// requestLogEntry is one line of the request log
+type requestLogEntry struct {
+	Time     string            `json:"time"`
+	Type     string            `json:"type"` // "request" or "response"
+	Method   string            `json:"method,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Status   int               `json:"status,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	BodySize int               `json:"body_size"`
+}
+
+// SetRequestLogging opens or closes the structured request log next to the
+// log file. With redactAPIKeys the keys in headers and URLs are replaced.
+func (l *Logger) SetRequestLogging(enabled, redactAPIKeys bool) error {
+	l.requestMu.Lock()
+	defer l.requestMu.Unlock()
+
+	l.redactKeys = redactAPIKeys
+	if !enabled {
+		if l.requestFile != nil {
+			err := l.requestFile.Close()
+			l.requestFile = nil
+			return err
+		}
+		return nil
+	}
+	if l.requestFile != nil {
+		return nil
+	}
+
+	path := filepath.Join(filepath.Dir(l.path), RequestLogName)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open request log: %w", err)
+	}
+	l.requestFile = file
+	return nil
+}
+
+// LogRequest writes an outgoing provider request to the request log
+func (l *Logger) LogRequest(req *http.Request, body []byte) {
+	l.requestMu.Lock()
+	enabled, redact := l.requestFile != nil, l.redactKeys
+	l.requestMu.Unlock()
+	if !enabled {
+		return
+	}
+
+	headers := make(map[string]string, len(req.Header))
+	for name, values := range req.Header {
+		value := strings.Join(values, ", ")
+		if redact && isAPIKeyHeader(name) {
+			value = redactHeaderValue(value)
+		}
+		headers[name] = value
+	}
+
+	rawURL := req.URL.String()
+	if redact {
+		rawURL = redactURL(req.URL)
+	}
+
+	l.writeRequestLog(requestLogEntry{
+		Type:     "request",
+		Method:   req.Method,
+		URL:      rawURL,
+		Headers:  headers,
+		Body:     bodyPreview(body),
+		BodySize: len(body),
+	})
+}
+
+// LogResponse writes a response, or one line of a streamed response, to the
+// request log
+func (l *Logger) LogResponse(status int, body []byte) {
+	l.writeRequestLog(requestLogEntry{
+		Type:     "response",
+		Status:   status,
+		Body:     bodyPreview(body),
+		BodySize: len(body),
+	})
+}
+
+// writeRequestLog appends an entry as a JSON line if the request log is open
+func (l *Logger) writeRequestLog(entry requestLogEntry) {
+	l.requestMu.Lock()
+	defer l.requestMu.Unlock()
+
+	if l.requestFile == nil {
+		return
+	}
+	entry.Time = time.Now().Format(time.RFC3339Nano)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if _, err := l.requestFile.Write(append(line, '\n')); err != nil {
+		l.Error("Failed to write request log: %v", err)
+	}
+}
+
+// isAPIKeyHeader reports whether a header carries credentials
+func isAPIKeyHeader(name string) bool {
+	name = strings.ToLower(name)
+	return name == "authorization" || name == "proxy-authorization" ||
+		strings.Contains(name, "api-key") || strings.Contains(name, "token")
+}
+
+// redactHeaderValue replaces a credential, keeping the auth scheme
+func redactHeaderValue(value string) string {
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " " + redacted
+	}
+	return redacted
+}
+
+// redactURL replaces API keys passed as query parameters (e.g. Gemini's key=)
+func redactURL(u *url.URL) string {
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		switch strings.ToLower(name) {
+		case "key", "api_key", "apikey", "access_token", "token":
+			params[i] = name + "=" + redacted
+		}
+	}
+	copied := *u
+	copied.RawQuery = strings.Join(params, "&")
+	return copied.String()
+}
+
+// bodyPreview returns the start of a body, cut at a character boundary
+func bodyPreview(body []byte) string {
+	if len(body) <= maxRequestLogBody {
+		return string(body)
+	}
+	cut := maxRequestLogBody
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return string(body[:cut]) + "..."
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readRequestLog(t *testing.T, dir string) []requestLogEntry {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, RequestLogName))
+	if err != nil {
+		t.Fatalf("failed to read request log: %v", err)
+	}
+	var entries []requestLogEntry
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		var entry requestLogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLogger_RequestLog(t *testing.T) {
+	for _, redact := range []bool{true, false} {
+		dir := t.TempDir()
+		logger, err := NewLogger(filepath.Join(dir, "app.log"))
+		if err != nil {
+			t.Fatalf("NewLogger failed: %v", err)
+		}
+
+		req, _ := http.NewRequest("POST", "https://example.com/v1/models/m:streamGenerateContent?alt=sse&key=secret-key", nil)
+		req.Header.Set("Authorization", "Bearer secret-key")
+		req.Header.Set("X-Api-Key", "secret-key")
+		req.Header.Set("Content-Type", "application/json")
+
+		// Nothing is written before the log is enabled
+		logger.LogRequest(req, []byte(`{"model":"m"}`))
+		if err := logger.SetRequestLogging(true, redact); err != nil {
+			t.Fatalf("SetRequestLogging failed: %v", err)
+		}
+		logger.LogRequest(req, []byte(`{"model":"m"}`))
+		logger.LogResponse(200, []byte(strings.Repeat("x", maxRequestLogBody+10)))
+		logger.Close()
+
+		entries := readRequestLog(t, dir)
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, got %d", len(entries))
+		}
+		request, response := entries[0], entries[1]
+		if request.Type != "request" || request.Method != "POST" || request.Body != `{"model":"m"}` {
+			t.Errorf("unexpected request entry %+v", request)
+		}
+		if request.Headers["Content-Type"] != "application/json" {
+			t.Errorf("unexpected headers %v", request.Headers)
+		}
+
+		line, _ := json.Marshal(request)
+		if leaked := strings.Contains(string(line), "secret-key"); leaked == redact {
+			t.Errorf("redact=%v, but logged request is %s", redact, line)
+		}
+		if redact {
+			if request.Headers["Authorization"] != "Bearer ***" || request.Headers["X-Api-Key"] != "***" {
+				t.Errorf("headers not redacted: %v", request.Headers)
+			}
+			if !strings.HasSuffix(request.URL, "?alt=sse&key=***") {
+				t.Errorf("URL not redacted: %s", request.URL)
+			}
+		}
+
+		if response.Type != "response" || response.Status != 200 || response.BodySize != maxRequestLogBody+10 {
+			t.Errorf("unexpected response entry %+v", response)
+		}
+		if len(response.Body) != maxRequestLogBody+len("...") {
+			t.Errorf("response body not cut to a preview: %d bytes", len(response.Body))
+		}
+	}
+}
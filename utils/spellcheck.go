@@ -0,0 +1,296 @@
+package utils
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// defaultDictionary is a Hunspell-compatible word list (first line is the
+// word count, each following line is "word[/FLAGS]") with every inflected
+// form listed, most common words first. See dict/README.md.
+//
+//go:embed dict/en_US.dic
+var defaultDictionary string
+
+// Misspelling describes a misspelled word inside a text, using rune offsets
+type Misspelling struct {
+	Word  string
+	Start int // rune offset of the first character
+	End   int // rune offset after the last character
+}
+
+// SpellChecker checks English words against a dictionary plus user-defined words
+type SpellChecker struct {
+	mu          sync.RWMutex
+	words       map[string]int // word -> rank, lower is more common
+	customWords map[string]struct{}
+	enabled     bool
+}
+
+// NewSpellChecker creates a spell checker loaded with the embedded dictionary
+func NewSpellChecker(enabled bool) *SpellChecker {
+	sc := &SpellChecker{
+		words:       make(map[string]int),
+		customWords: make(map[string]struct{}),
+		enabled:     enabled,
+	}
+	sc.LoadDictionary(strings.NewReader(defaultDictionary))
+	return sc
+}
+
+// LoadDictionary adds all words from a Hunspell .dic formatted reader. Affix
+// flags are ignored, so inflected forms must be listed. Words are ranked by
+// their order, earlier words are suggested first.
+func (sc *SpellChecker) LoadDictionary(r io.Reader) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if first {
+			first = false
+			// The first line of a .dic file is the approximate word count
+			if isAllDigits(line) {
+				continue
+			}
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if idx := strings.Index(line, "/"); idx >= 0 {
+			line = line[:idx]
+		}
+		word := strings.ToLower(line)
+		if _, ok := sc.words[word]; !ok {
+			sc.words[word] = len(sc.words)
+		}
+	}
+	return scanner.Err()
+}
+
+// SetEnabled enables or disables spell checking
+func (sc *SpellChecker) SetEnabled(enabled bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.enabled = enabled
+}
+
+// IsEnabled returns whether spell checking is enabled
+func (sc *SpellChecker) IsEnabled() bool {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.enabled
+}
+
+// AddCustomWord adds a user-defined word that is always considered correct
+func (sc *SpellChecker) AddCustomWord(word string) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.customWords[word] = struct{}{}
+}
+
+// RemoveCustomWord removes a user-defined word
+func (sc *SpellChecker) RemoveCustomWord(word string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.customWords, strings.ToLower(strings.TrimSpace(word)))
+}
+
+// SetCustomWords replaces all user-defined words
+func (sc *SpellChecker) SetCustomWords(words []string) {
+	sc.mu.Lock()
+	sc.customWords = make(map[string]struct{}, len(words))
+	sc.mu.Unlock()
+	for _, w := range words {
+		sc.AddCustomWord(w)
+	}
+}
+
+// IsCorrect reports whether a single word is spelled correctly.
+// Words containing digits or non-ASCII letters (e.g. Chinese) are always accepted.
+func (sc *SpellChecker) IsCorrect(word string) bool {
+	if len([]rune(word)) <= 1 {
+		return true
+	}
+	for _, r := range word {
+		if r > unicode.MaxASCII || unicode.IsDigit(r) {
+			return true
+		}
+	}
+
+	lower := strings.ToLower(word)
+
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	if sc.known(lower) {
+		return true
+	}
+	// Possessives of any known word: "user's"
+	stem, ok := strings.CutSuffix(lower, "'s")
+	return ok && sc.known(stem)
+}
+
+// known must be called with sc.mu held
+func (sc *SpellChecker) known(word string) bool {
+	if _, ok := sc.words[word]; ok {
+		return true
+	}
+	_, ok := sc.customWords[word]
+	return ok
+}
+
+// Check returns all misspelled words in text. It returns nil when disabled.
+func (sc *SpellChecker) Check(text string) []Misspelling {
+	if !sc.IsEnabled() {
+		return nil
+	}
+
+	var result []Misspelling
+	runes := []rune(text)
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		isWordRune := i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) ||
+			(runes[i] == '\'' && start >= 0 && i+1 < len(runes) && unicode.IsLetter(runes[i+1])))
+		if isWordRune {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			word := string(runes[start:i])
+			if !sc.IsCorrect(word) {
+				result = append(result, Misspelling{Word: word, Start: start, End: i})
+			}
+			start = -1
+		}
+	}
+	return result
+}
+
+// Suggest returns up to max dictionary words closest to the given word,
+// ordered by edit distance, then by how common they are and then
+// alphabetically. Custom words rank after dictionary words.
+func (sc *SpellChecker) Suggest(word string, max int) []string {
+	lower := strings.ToLower(word)
+
+	type candidate struct {
+		word     string
+		distance int
+		rank     int
+	}
+	var candidates []candidate
+	add := func(w string, rank int) {
+		// Cheap length filter before computing the full edit distance
+		if diff := len(w) - len(lower); diff > 2 || diff < -2 {
+			return
+		}
+		if d := editDistance(lower, w); d <= 2 {
+			candidates = append(candidates, candidate{word: w, distance: d, rank: rank})
+		}
+	}
+
+	sc.mu.RLock()
+	for w, rank := range sc.words {
+		add(w, rank)
+	}
+	for w := range sc.customWords {
+		add(w, len(sc.words))
+	}
+	sc.mu.RUnlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		if candidates[i].rank != candidates[j].rank {
+			return candidates[i].rank < candidates[j].rank
+		}
+		return candidates[i].word < candidates[j].word
+	})
+
+	suggestions := make([]string, 0, max)
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if len(suggestions) >= max {
+			break
+		}
+		if seen[c.word] {
+			continue
+		}
+		seen[c.word] = true
+		suggestions = append(suggestions, matchCase(word, c.word))
+	}
+	return suggestions
+}
+
+// matchCase applies the capitalization of original to suggestion
+func matchCase(original, suggestion string) string {
+	if original == strings.ToUpper(original) && len(original) > 1 {
+		return strings.ToUpper(suggestion)
+	}
+	runes := []rune(original)
+	if len(runes) > 0 && unicode.IsUpper(runes[0]) {
+		s := []rune(suggestion)
+		s[0] = unicode.ToUpper(s[0])
+		return string(s)
+	}
+	return suggestion
+}
+
+// editDistance computes the Damerau-Levenshtein (optimal string alignment) distance
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = minInt(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSpellChecker_IsCorrect(t *testing.T) {
+	sc := NewSpellChecker(true)
+
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"hello", true},
+		{"Hello", true},
+		{"HELLO", true},
+		{"necessary", true},
+		{"accommodate", true},
+		{"rhythm", true},
+		{"don't", true},
+		{"goroutine", true},
+		{"cafe", true},
+		// Inflections
+		{"running", true},
+		{"tries", true},
+		{"occurred", true},
+		{"labeled", true},
+		{"user's", true},
+		{"goroutine's", true},
+		// Never checked
+		{"x", true},
+		{"utf8", true},
+		{"你好", true},
+		// Misspellings
+		{"teh", false},
+		{"recieve", false},
+		{"seperate", false},
+		{"occured", false},
+		{"thier", false},
+		{"definately", false},
+		{"teh's", false},
+	}
+	for _, tt := range tests {
+		if got := sc.IsCorrect(tt.word); got != tt.want {
+			t.Errorf("IsCorrect(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestSpellChecker_Check(t *testing.T) {
+	sc := NewSpellChecker(true)
+
+	text := "I don't recieve teh 中文 messages, it's weird"
+	got := sc.Check(text)
+	want := []Misspelling{
+		{Word: "recieve", Start: 8, End: 15},
+		{Word: "teh", Start: 16, End: 19},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Check = %+v, want %+v", got, want)
+	}
+	runes := []rune(text)
+	for _, m := range got {
+		if string(runes[m.Start:m.End]) != m.Word {
+			t.Errorf("offsets of %q point at %q", m.Word, string(runes[m.Start:m.End]))
+		}
+	}
+
+	if got := sc.Check("The quick brown fox jumps over the lazy dog."); got != nil {
+		t.Errorf("Check of a correct sentence = %+v", got)
+	}
+
+	sc.SetEnabled(false)
+	if got := sc.Check(text); got != nil {
+		t.Errorf("Check while disabled = %+v, want nil", got)
+	}
+}
+
+func TestSpellChecker_CustomWords(t *testing.T) {
+	sc := NewSpellChecker(true)
+	if sc.IsCorrect("kubectl") {
+		t.Fatal("kubectl is already in the dictionary")
+	}
+
+	sc.AddCustomWord(" Kubectl ")
+	if !sc.IsCorrect("kubectl") || !sc.IsCorrect("KUBECTL") {
+		t.Error("custom word not accepted")
+	}
+	sc.RemoveCustomWord("kubectl")
+	if sc.IsCorrect("kubectl") {
+		t.Error("removed custom word still accepted")
+	}
+
+	sc.SetCustomWords([]string{"kubectl", "helmfile"})
+	if !sc.IsCorrect("kubectl") || !sc.IsCorrect("helmfile") {
+		t.Error("SetCustomWords words not accepted")
+	}
+	sc.SetCustomWords(nil)
+	if sc.IsCorrect("helmfile") {
+		t.Error("SetCustomWords did not replace the previous words")
+	}
+}
+
+func TestSpellChecker_Suggest(t *testing.T) {
+	sc := NewSpellChecker(true)
+
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"recieve", "receive"},
+		{"teh", "the"},
+		{"seperate", "separate"},
+		{"langauge", "language"},
+	}
+	for _, tt := range tests {
+		got := sc.Suggest(tt.word, 10)
+		if len(got) == 0 || len(got) > 10 {
+			t.Errorf("Suggest(%q) returned %d suggestions", tt.word, len(got))
+			continue
+		}
+		found := false
+		for _, s := range got {
+			found = found || s == tt.want
+		}
+		if !found {
+			t.Errorf("Suggest(%q) = %q, want it to include %q", tt.word, got, tt.want)
+		}
+	}
+
+	// Closer words come first
+	if got := sc.Suggest("recieve", 1); !reflect.DeepEqual(got, []string{"receive"}) {
+		t.Errorf("Suggest(recieve, 1) = %q", got)
+	}
+	// Common words come before rare ones that are as close
+	if got := sc.Suggest("teh", 1); !reflect.DeepEqual(got, []string{"the"}) {
+		t.Errorf("Suggest(teh, 1) = %q", got)
+	}
+	// The capitalization of the word is kept
+	if got := sc.Suggest("Recieve", 1); !reflect.DeepEqual(got, []string{"Receive"}) {
+		t.Errorf("Suggest(Recieve, 1) = %q", got)
+	}
+	if got := sc.Suggest("RECIEVE", 1); !reflect.DeepEqual(got, []string{"RECEIVE"}) {
+		t.Errorf("Suggest(RECIEVE, 1) = %q", got)
+	}
+	if got := sc.Suggest("qzxwvjk", 5); len(got) != 0 {
+		t.Errorf("Suggest of gibberish = %q, want none", got)
+	}
+}
+
+func TestSpellChecker_LoadDictionary(t *testing.T) {
+	sc := NewSpellChecker(true)
+	dic := "3\nkubectl/S\n# comment\n\nHelmfile\n"
+	if err := sc.LoadDictionary(strings.NewReader(dic)); err != nil {
+		t.Fatalf("LoadDictionary failed: %v", err)
+	}
+	for _, word := range []string{"kubectl", "helmfile"} {
+		if !sc.IsCorrect(word) {
+			t.Errorf("IsCorrect(%q) = false after loading it", word)
+		}
+	}
+	// Affix flags are not expanded
+	if sc.IsCorrect("kubectls") {
+		t.Error("IsCorrect(kubectls) = true, want flags to be ignored")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"thier", "their", 1}, // transposition
+		{"recieve", "receive", 1},
+		{"中文", "中午", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
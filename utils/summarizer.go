@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"light-llm-client/llm"
+	"strings"
+	"time"
+)
+
+// summarizePrompt instructs the model how to compress the conversation history
+const summarizePrompt = `Summarize the conversation above so it can replace the original messages as context for the rest of the conversation.
+Requirements:
+- Write in the same language as the conversation
+- Use concise bullet points ("- ")
+- Preserve key facts, numbers, names, code identifiers, decisions and open questions
+- Do not add information that is not in the conversation
+Output only the bullet-point summary.`
+
+// ConversationSummarizer compresses message history into a short summary using an LLM provider
+type ConversationSummarizer struct {
+	Timeout time.Duration
+}
+
+// NewConversationSummarizer creates a new conversation summarizer
+func NewConversationSummarizer() *ConversationSummarizer {
+	return &ConversationSummarizer{
+		Timeout: 120 * time.Second,
+	}
+}
+
+// Summarize asks the provider for a bullet-point summary of the given messages
+func (s *ConversationSummarizer) Summarize(provider llm.Provider, messages []llm.Message) (string, error) {
+	if len(messages) == 0 {
+		return "", fmt.Errorf("no messages to summarize")
+	}
+
+	prompt := make([]llm.Message, 0, len(messages)+1)
+	for _, msg := range messages {
+		role := msg.Role
+		if role == "system" {
+			// Earlier summaries are passed as regular context
+			role = "user"
+		}
+		prompt = append(prompt, llm.Message{Role: role, Content: msg.Content})
+	}
+	prompt = append(prompt, llm.Message{Role: "user", Content: summarizePrompt})
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	summary, err := provider.Chat(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return "", fmt.Errorf("provider returned an empty summary")
+	}
+
+	return summary, nil
+}
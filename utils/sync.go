@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"light-llm-client/db"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// syncTimeout limits a single download or upload of the database
+const syncTimeout = 5 * time.Minute
+
+// SyncState records what was synchronized last, stored next to the database
+type SyncState struct {
+	RemoteVersion string    `json:"remote_version"`
+	LocalModTime  time.Time `json:"local_mod_time"`
+	LastSyncAt    time.Time `json:"last_sync_at"`
+}
+
+// SyncConflict describes a database that changed both locally and remotely.
+// The remote file has been used and the local file kept at LocalCopyPath.
+type SyncConflict struct {
+	LocalModTime  time.Time
+	RemoteModTime time.Time
+	LocalCopyPath string
+}
+
+// DBSyncer synchronizes the SQLite database file with a WebDAV server
+type DBSyncer struct {
+	client    *WebDAVClient
+	dbPath    string
+	statePath string
+	logger    *Logger
+	uploadMu  sync.Mutex // serializes uploads
+}
+
+// NewDBSyncer creates a syncer for the database at dbPath, or returns nil when
+// sync is not configured
+func NewDBSyncer(config SyncConfig, dbPath string, logger *Logger) *DBSyncer {
+	if !config.Enabled() {
+		return nil
+	}
+	return &DBSyncer{
+		client:    NewWebDAVClient(config.WebDAVURL, config.Username, config.Password),
+		dbPath:    dbPath,
+		statePath: dbPath + ".sync.json",
+		logger:    logger,
+	}
+}
+
+// SyncOnStartup downloads the remote database if it changed since the last
+// sync. It must be called before the database is opened. When both copies
+// changed, the remote one wins and the returned conflict describes where the
+// local copy was kept.
+func (s *DBSyncer) SyncOnStartup() (*SyncConflict, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	remote, err := s.client.Stat(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !remote.Exists {
+		s.logger.Info("Remote database does not exist yet, it will be uploaded on exit")
+		return nil, nil
+	}
+
+	state, err := s.loadState()
+	if err != nil {
+		s.logger.Warn("Failed to load sync state: %v", err)
+	}
+
+	localStat, err := os.Stat(s.dbPath)
+	localExists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to stat local database: %w", err)
+	}
+
+	var remoteChanged, localChanged bool
+	switch {
+	case !localExists:
+		remoteChanged = true
+	case state == nil:
+		// Never synced: take the remote file only if it is newer
+		remoteChanged = remote.LastModified.After(localStat.ModTime())
+	default:
+		remoteChanged = remote.Version() != state.RemoteVersion
+		localChanged = localStat.ModTime().After(state.LocalModTime)
+	}
+
+	if !remoteChanged {
+		s.logger.Info("Remote database unchanged, using local database")
+		return nil, nil
+	}
+
+	var conflict *SyncConflict
+	if localChanged {
+		conflict = &SyncConflict{
+			LocalModTime:  localStat.ModTime(),
+			RemoteModTime: remote.LastModified,
+			LocalCopyPath: fmt.Sprintf("%s.conflict-%s", s.dbPath, time.Now().Format(backupTimeFormat)),
+		}
+		if err := os.Rename(s.dbPath, conflict.LocalCopyPath); err != nil {
+			return nil, fmt.Errorf("failed to keep local database: %w", err)
+		}
+		s.logger.Warn("Sync conflict: local database kept at %s", conflict.LocalCopyPath)
+	}
+
+	if err := s.download(ctx); err != nil {
+		if conflict != nil {
+			// Restore the local database so nothing is lost
+			os.Rename(conflict.LocalCopyPath, s.dbPath)
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Downloaded remote database (%s)", remote.Version())
+	return conflict, nil
+}
+
+// download replaces the local database with the remote file
+func (s *DBSyncer) download(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(s.dbPath), 0755); err != nil {
+		return fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	tmpPath := s.dbPath + ".download"
+	info, err := s.client.Download(ctx, tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Journal files belong to the database being replaced
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		os.Remove(s.dbPath + suffix)
+	}
+	if err := os.Rename(tmpPath, s.dbPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace local database: %w", err)
+	}
+
+	return s.saveState(info.Version())
+}
+
+// Upload uploads a snapshot of the open database. Concurrent uploads wait
+// for each other so the state file records the last one.
+func (s *DBSyncer) Upload(database *db.DB) error {
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	snapshotPath := fmt.Sprintf("%s.upload-%d", s.dbPath, time.Now().UnixNano())
+	if err := database.Backup(snapshotPath); err != nil {
+		return err
+	}
+	defer os.Remove(snapshotPath)
+
+	info, err := s.client.Upload(ctx, snapshotPath)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Uploaded database to WebDAV (%s)", info.Version())
+	return s.saveState(info.Version())
+}
+
+// MarkLocalSynced records the current local modification time as synced.
+// Call it after the database is closed following the final upload.
+func (s *DBSyncer) MarkLocalSynced() error {
+	state, err := s.loadState()
+	if err != nil || state == nil {
+		return err
+	}
+	return s.saveState(state.RemoteVersion)
+}
+
+// saveState records the remote version together with the local modification time
+func (s *DBSyncer) saveState(remoteVersion string) error {
+	state := SyncState{
+		RemoteVersion: remoteVersion,
+		LastSyncAt:    time.Now(),
+	}
+	if stat, err := os.Stat(s.dbPath); err == nil {
+		state.LocalModTime = stat.ModTime()
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync state: %w", err)
+	}
+	if err := os.WriteFile(s.statePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// loadState returns the last sync state, or nil if never synced
+func (s *DBSyncer) loadState() (*SyncState, error) {
+	data, err := os.ReadFile(s.statePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	var state SyncState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	return &state, nil
+}
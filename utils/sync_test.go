@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+func newTestSyncer(t *testing.T) (*DBSyncer, *WebDAVClient) {
+	t.Helper()
+	dir := t.TempDir()
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	t.Cleanup(server.Close)
+
+	logger, err := NewLogger(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+
+	config := SyncConfig{WebDAVURL: server.URL + "/chat.db"}
+	syncer := NewDBSyncer(config, filepath.Join(dir, "data", "chat.db"), logger)
+	return syncer, NewWebDAVClient(config.WebDAVURL, "", "")
+}
+
+// putRemote replaces the remote database with the given content
+func putRemote(t *testing.T, client *WebDAVClient, content string) {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), "remote.db")
+	if err := os.WriteFile(src, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := client.Upload(context.Background(), src); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestNewDBSyncer_Disabled(t *testing.T) {
+	if NewDBSyncer(SyncConfig{}, "chat.db", nil) != nil {
+		t.Error("Expected nil syncer when no WebDAV URL is configured")
+	}
+}
+
+func TestDBSyncer_DownloadsRemote(t *testing.T) {
+	syncer, client := newTestSyncer(t)
+
+	conflict, err := syncer.SyncOnStartup()
+	if err != nil || conflict != nil {
+		t.Fatalf("Expected no-op without a remote file, got conflict=%v err=%v", conflict, err)
+	}
+
+	putRemote(t, client, "remote v1")
+
+	conflict, err = syncer.SyncOnStartup()
+	if err != nil {
+		t.Fatalf("SyncOnStartup failed: %v", err)
+	}
+	if conflict != nil {
+		t.Errorf("Unexpected conflict: %+v", conflict)
+	}
+	if got := readFile(t, syncer.dbPath); got != "remote v1" {
+		t.Errorf("Expected remote content, got %q", got)
+	}
+
+	// Local changes without remote changes are kept
+	if err := os.WriteFile(syncer.dbPath, []byte("local edit"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := syncer.SyncOnStartup(); err != nil {
+		t.Fatalf("SyncOnStartup failed: %v", err)
+	}
+	if got := readFile(t, syncer.dbPath); got != "local edit" {
+		t.Errorf("Expected local content to be kept, got %q", got)
+	}
+}
+
+func TestDBSyncer_ConflictPrefersRemote(t *testing.T) {
+	syncer, client := newTestSyncer(t)
+
+	putRemote(t, client, "remote v1")
+	if _, err := syncer.SyncOnStartup(); err != nil {
+		t.Fatalf("SyncOnStartup failed: %v", err)
+	}
+
+	// Modify both copies after the sync
+	if err := os.WriteFile(syncer.dbPath, []byte("local edit"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(syncer.dbPath, future, future)
+	putRemote(t, client, "remote v2")
+
+	conflict, err := syncer.SyncOnStartup()
+	if err != nil {
+		t.Fatalf("SyncOnStartup failed: %v", err)
+	}
+	if conflict == nil {
+		t.Fatal("Expected a conflict")
+	}
+	if got := readFile(t, syncer.dbPath); got != "remote v2" {
+		t.Errorf("Expected remote content to win, got %q", got)
+	}
+	if got := readFile(t, conflict.LocalCopyPath); got != "local edit" {
+		t.Errorf("Expected local copy to be kept, got %q", got)
+	}
+}
@@ -0,0 +1,71 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"light-llm-client/db"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+func TestDBSyncer_UploadsOneAtATime(t *testing.T) {
+	dir := t.TempDir()
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	var active, maxActive atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				old := maxActive.Load()
+				if n <= old || maxActive.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	logger, err := NewLogger(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	defer logger.Close()
+	dbPath := filepath.Join(dir, "chat.db")
+	database, err := db.New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	syncer := NewDBSyncer(SyncConfig{WebDAVURL: server.URL + "/chat.db"}, dbPath, logger)
+
+	// A periodic upload running when the app exits and the final upload
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := syncer.Upload(database); err != nil {
+				t.Errorf("Upload failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := maxActive.Load(); n != 1 {
+		t.Errorf("%d uploads ran at the same time, want 1", n)
+	}
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// TFIDFIndex is an in-memory inverted index of documents (messages) used to
+// rank search results by TF-IDF. Terms that occur in few documents weigh more
+// than terms found everywhere.
+type TFIDFIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[int64]int // term -> document -> occurrences
+	docTerms map[int64][]string       // document -> distinct terms, to remove it again
+}
+
+// NewTFIDFIndex creates an empty index
+func NewTFIDFIndex() *TFIDFIndex {
+	return &TFIDFIndex{
+		postings: make(map[string]map[int64]int),
+		docTerms: make(map[int64][]string),
+	}
+}
+
+// Add indexes the text of a document, replacing an earlier version of it
+func (idx *TFIDFIndex) Add(docID int64, text string) {
+	counts := termCounts(TokenizeForIndex(text))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(docID)
+	terms := make([]string, 0, len(counts))
+	for term, count := range counts {
+		docs := idx.postings[term]
+		if docs == nil {
+			docs = make(map[int64]int)
+			idx.postings[term] = docs
+		}
+		docs[docID] = count
+		terms = append(terms, term)
+	}
+	idx.docTerms[docID] = terms
+}
+
+// Remove drops a document from the index
+func (idx *TFIDFIndex) Remove(docID int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(docID)
+}
+
+func (idx *TFIDFIndex) removeLocked(docID int64) {
+	terms, ok := idx.docTerms[docID]
+	if !ok {
+		return
+	}
+	for _, term := range terms {
+		docs := idx.postings[term]
+		delete(docs, docID)
+		if len(docs) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.docTerms, docID)
+}
+
+// Len returns the number of indexed documents
+func (idx *TFIDFIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docTerms)
+}
+
+// idfLocked returns the inverse document frequency of a term. Terms not in
+// the index get the highest weight.
+func (idx *TFIDFIndex) idfLocked(term string) float64 {
+	// Smoothed so that unknown terms don't divide by zero; a term found in
+	// every document weighs nothing
+	n := float64(len(idx.docTerms))
+	df := float64(len(idx.postings[term]))
+	return math.Log((n + 1) / (df + 1))
+}
+
+// Score returns the TF-IDF relevance of content for a search query. The term
+// frequencies come from content itself, so messages added after the index
+// was built are scored too. Term frequencies are dampened logarithmically so
+// repeating a common word doesn't outweigh a single rare one.
+func (idx *TFIDFIndex) Score(query, content string) float64 {
+	queryTerms := termCounts(TokenizeForIndex(stripSearchOperators(query)))
+	if len(queryTerms) == 0 {
+		return 0
+	}
+	counts := termCounts(TokenizeForIndex(content))
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	score := 0.0
+	for term := range queryTerms {
+		if count := counts[term]; count > 0 {
+			tf := 1 + math.Log(float64(count))
+			score += tf * idx.idfLocked(term)
+		}
+	}
+	return score
+}
+
+// TokenizeForIndex splits text into lowercase terms. Letters and digits form
+// words; Han, Hiragana, Katakana and Hangul characters are terms of their own
+// since those scripts don't separate words with spaces.
+func TokenizeForIndex(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// stripSearchOperators removes the FTS5 operators from a search query
+func stripSearchOperators(query string) string {
+	fields := strings.Fields(query)
+	kept := fields[:0]
+	for _, field := range fields {
+		switch field {
+		case "AND", "OR", "NOT", "NEAR":
+			continue
+		}
+		kept = append(kept, field)
+	}
+	return strings.Join(kept, " ")
+}
+
+func termCounts(tokens []string) map[string]int {
+	counts := make(map[string]int, len(tokens))
+	for _, token := range tokens {
+		counts[token]++
+	}
+	return counts
+}
@@ -0,0 +1,108 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeForIndex(t *testing.T) {
+	got := TokenizeForIndex("Hello, World! Go1.21 使用缓存")
+	want := []string{"hello", "world", "go1", "21", "使", "用", "缓", "存"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TokenizeForIndex = %q, want %q", got, want)
+	}
+}
+
+func TestTFIDFIndex_Score(t *testing.T) {
+	index := NewTFIDFIndex()
+	index.Add(1, "the cache is warm")
+	index.Add(2, "the answer is the answer")
+	index.Add(3, "the end")
+	if index.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", index.Len())
+	}
+
+	// "the" is in every document, "cache" only in one
+	if common, rare := index.Score("the", "the cache"), index.Score("cache", "the cache"); rare <= common {
+		t.Errorf("rare term scored %f, not above common term %f", rare, common)
+	}
+	if score := index.Score("cache OR missing", "nothing relevant"); score != 0 {
+		t.Errorf("Score of unrelated content = %f, want 0", score)
+	}
+
+	// Removing the only document with a term makes the term rarer still
+	before := index.Score("cache", "cache")
+	index.Remove(1)
+	if after := index.Score("cache", "cache"); after <= before {
+		t.Errorf("Score after Remove = %f, want above %f", after, before)
+	}
+	if index.Len() != 2 {
+		t.Errorf("Len after Remove = %d, want 2", index.Len())
+	}
+}
+
+func TestTFIDFIndex_RerankSearch(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer database.Close()
+
+	add := func(title, content string) int64 {
+		conv, err := database.CreateConversation(title, "")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		if _, err := database.CreateMessage(conv.ID, "assistant", content, "mock", "mock", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		return conv.ID
+	}
+
+	// Generic answers mention "please" and "help" over and over; only one
+	// long conversation is about the rare term "kubernetes", mentioning it once
+	for i := 0; i < 8; i++ {
+		add(fmt.Sprintf("generic %d", i), "Please let me know, I am happy to help, please ask, please help, please")
+	}
+	details := strings.Repeat("The rollout replaces the pods of the service one at a time. ", 20)
+	target := add("deployment", "Deploy it with kubernetes. "+details)
+
+	index := NewTFIDFIndex()
+	conversations, err := database.ListConversations(100, 0)
+	if err != nil {
+		t.Fatalf("ListConversations failed: %v", err)
+	}
+	for _, conv := range conversations {
+		messages, err := database.ListMessages(conv.ID)
+		if err != nil {
+			t.Fatalf("ListMessages failed: %v", err)
+		}
+		for _, msg := range messages {
+			index.Add(msg.ID, msg.Content)
+		}
+	}
+	database.SetSearchRanker(index)
+
+	results, err := database.SearchMessages("please OR kubernetes", 3, true)
+	if err != nil {
+		t.Fatalf("SearchMessages failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].ConversationID != target {
+		t.Errorf("expected the kubernetes conversation first, got %q", results[0].ConversationTitle)
+	}
+	for _, result := range results[1:] {
+		if index.Score("please OR kubernetes", result.Message.Content) >= index.Score("please OR kubernetes", results[0].Message.Content) {
+			t.Errorf("result %q scored as high as the first result", result.ConversationTitle)
+		}
+	}
+
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"light-llm-client/db"
+	"sync"
+	"time"
+)
+
+// Timing of the topic worker: it waits a little after startup, then looks
+// for conversations without topics at every interval
+const (
+	topicWorkerStartDelay = 10 * time.Second
+	topicWorkerInterval   = 10 * time.Minute
+	topicWorkerBatchSize  = 20
+)
+
+// TopicWorker extracts the topics of conversations that have none in the
+// background, see db.AnalyzeConversationTopics
+type TopicWorker struct {
+	db     *db.DB
+	logger *Logger
+
+	mu sync.Mutex
+	// Conversations analyzed without finding any topic, skipped until restart
+	empty map[int64]bool
+	runMu sync.Mutex // serializes passes
+
+	stop    chan struct{}
+	stopped sync.Once
+
+	// OnTopics is called with the topics of each analyzed conversation
+	OnTopics func(conversationID int64, topics []string)
+}
+
+// NewTopicWorker creates a topic worker for database
+func NewTopicWorker(database *db.DB, logger *Logger) *TopicWorker {
+	return &TopicWorker{
+		db:     database,
+		logger: logger,
+		empty:  make(map[int64]bool),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start analyzes conversations in the background until Stop
+func (w *TopicWorker) Start() {
+	SafeGo(w.logger, "topicWorker", w.run)
+}
+
+// Stop ends the background analysis. It waits for a running pass, so the
+// database can be closed afterwards.
+func (w *TopicWorker) Stop() {
+	w.stopped.Do(func() {
+		close(w.stop)
+	})
+	w.runMu.Lock()
+	w.runMu.Unlock()
+}
+
+func (w *TopicWorker) run() {
+	timer := time.NewTimer(topicWorkerStartDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-timer.C:
+			w.RunOnce()
+			timer.Reset(topicWorkerInterval)
+		}
+	}
+}
+
+// RunOnce analyzes a batch of conversations without topics and returns how
+// many it analyzed
+func (w *TopicWorker) RunOnce() int {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+
+	w.mu.Lock()
+	limit := topicWorkerBatchSize + len(w.empty)
+	w.mu.Unlock()
+	ids, err := w.db.ListConversationsWithoutTopics(limit)
+	if err != nil {
+		w.logger.Warn("Topic worker: %v", err)
+		return 0
+	}
+
+	analyzed := 0
+	for _, id := range ids {
+		select {
+		case <-w.stop:
+			return analyzed
+		default:
+		}
+
+		w.mu.Lock()
+		skip := w.empty[id]
+		w.mu.Unlock()
+		if skip {
+			continue
+		}
+
+		topics, err := w.db.AnalyzeConversationTopics(id)
+		if err != nil {
+			w.logger.Warn("Topic worker: failed to analyze conversation %d: %v", id, err)
+			continue
+		}
+		analyzed++
+		if len(topics) == 0 {
+			w.mu.Lock()
+			w.empty[id] = true
+			w.mu.Unlock()
+			continue
+		}
+		if w.OnTopics != nil {
+			w.OnTopics(id, topics)
+		}
+	}
+	if analyzed > 0 {
+		w.logger.Info("Topic worker analyzed %d conversations", analyzed)
+	}
+	return analyzed
+}
@@ -0,0 +1,61 @@
+//go:build sqlite_fts5
+
+package utils
+
+import (
+	"light-llm-client/db"
+	"path/filepath"
+	"testing"
+)
+
+func TestTopicWorker_RunOnce(t *testing.T) {
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer database.Close()
+	logger, err := NewLogger(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	defer logger.Close()
+
+	contents := map[string]string{
+		"rust":  "Cargo workspaces share a lock file. Cargo workspaces build faster, so try Cargo workspaces.",
+		"hello": "hello",
+	}
+	ids := make(map[string]int64)
+	for title, content := range contents {
+		conv, err := database.CreateConversation(title, "")
+		if err != nil {
+			t.Fatalf("CreateConversation failed: %v", err)
+		}
+		if _, err := database.CreateMessage(conv.ID, "user", content, "", "", "", 0); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		ids[title] = conv.ID
+	}
+
+	worker := NewTopicWorker(database, logger)
+	got := make(map[int64][]string)
+	worker.OnTopics = func(conversationID int64, topics []string) {
+		got[conversationID] = topics
+	}
+
+	if n := worker.RunOnce(); n != 2 {
+		t.Fatalf("first pass analyzed %d conversations, want 2", n)
+	}
+	if topics := got[ids["rust"]]; len(topics) != 1 || topics[0] != "Cargo workspaces" {
+		t.Errorf("topics of rust = %q, want [\"Cargo workspaces\"]", topics)
+	}
+	if _, ok := got[ids["hello"]]; ok {
+		t.Error("OnTopics was called for a conversation without topics")
+	}
+
+	// Conversations with topics and those without any aren't analyzed again
+	if n := worker.RunOnce(); n != 0 {
+		t.Errorf("second pass analyzed %d conversations, want 0", n)
+	}
+	worker.Stop()
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// windowsSpeechScript reads stdin aloud with System.Speech. The voice comes
+// from an environment variable so it needs no quoting.
+const windowsSpeechScript = "[Console]::InputEncoding = [Text.Encoding]::UTF8; " +
+	"Add-Type -AssemblyName System.Speech; " +
+	"$s = New-Object System.Speech.Synthesis.SpeechSynthesizer; " +
+	"if ($env:LLM_TTS_VOICE) { $s.SelectVoice($env:LLM_TTS_VOICE) }; " +
+	"$s.Speak([Console]::In.ReadToEnd())"
+
+// TextToSpeech reads text aloud with the default voice and returns when done
+func TextToSpeech(text string) error {
+	return SpeakText(context.Background(), text, "")
+}
+
+// SpeakText reads text aloud with the OS text-to-speech engine: say on
+// macOS, espeak on Linux and System.Speech on Windows. An empty voice uses
+// the system default. Cancelling ctx stops the speech and returns nil.
+func SpeakText(ctx context.Context, text, voice string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	cmd := ttsCommand(ctx, runtime.GOOS, voice)
+	// The text goes through stdin to avoid length limits and option parsing
+	cmd.Stdin = strings.NewReader(text)
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("text-to-speech failed: %w", err)
+	}
+	return nil
+}
+
+// ttsCommand builds the speech command for goos, reading the text from stdin
+func ttsCommand(ctx context.Context, goos, voice string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		args := []string{}
+		if voice != "" {
+			args = append(args, "-v", voice)
+		}
+		return exec.CommandContext(ctx, "say", args...)
+	case "windows":
+		cmd := exec.CommandContext(ctx, "powershell.exe", "-NoProfile", "-NonInteractive", "-Command", windowsSpeechScript)
+		cmd.Env = append(os.Environ(), "LLM_TTS_VOICE="+voice)
+		return cmd
+	default:
+		args := []string{"--stdin"}
+		if voice != "" {
+			args = append(args, "-v", voice)
+		}
+		return exec.CommandContext(ctx, "espeak", args...)
+	}
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTTSCommand(t *testing.T) {
+	tests := []struct {
+		goos     string
+		voice    string
+		wantArgs []string
+	}{
+		{"darwin", "", []string{"say"}},
+		{"darwin", "Ting-Ting", []string{"say", "-v", "Ting-Ting"}},
+		{"linux", "", []string{"espeak", "--stdin"}},
+		{"linux", "zh", []string{"espeak", "--stdin", "-v", "zh"}},
+		{"windows", "", []string{"powershell.exe", "-NoProfile", "-NonInteractive", "-Command", windowsSpeechScript}},
+	}
+	for _, tt := range tests {
+		cmd := ttsCommand(context.Background(), tt.goos, tt.voice)
+		if !reflect.DeepEqual(cmd.Args, tt.wantArgs) {
+			t.Errorf("ttsCommand(%s, %q) args = %q, want %q", tt.goos, tt.voice, cmd.Args, tt.wantArgs)
+		}
+	}
+
+	// The Windows voice is passed in the environment
+	cmd := ttsCommand(context.Background(), "windows", "Microsoft Huihui Desktop")
+	if env := cmd.Env[len(cmd.Env)-1]; env != "LLM_TTS_VOICE=Microsoft Huihui Desktop" {
+		t.Errorf("last env entry = %q", env)
+	}
+}
+
+func TestSpeakText_Empty(t *testing.T) {
+	// Blank text must not start a speech process
+	if err := SpeakText(context.Background(), "  \n", ""); err != nil {
+		t.Errorf("SpeakText with blank text = %v, want nil", err)
+	}
+}
+
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// githubAPIURL is the GitHub REST API endpoint
+const githubAPIURL = "https://api.github.com"
+
+// Release is a GitHub release
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	HTMLURL     string    `json:"html_url"` // Release notes page
+	Body        string    `json:"body"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// UpdateChecker looks up the latest GitHub release of the app
+type UpdateChecker struct {
+	RepoOwner      string
+	RepoName       string
+	CurrentVersion string
+	// APIURL overrides the GitHub API endpoint (tests)
+	APIURL string
+	// Client is used for the request; http.DefaultClient when nil
+	Client *http.Client
+}
+
+// CheckLatestRelease returns the latest release if it is newer than
+// CurrentVersion, and nil if the app is up to date. Drafts and pre-releases
+// are not reported by GitHub as the latest release.
+func (c *UpdateChecker) CheckLatestRelease(ctx context.Context) (*Release, error) {
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = githubAPIURL
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", strings.TrimRight(apiURL, "/"), c.RepoOwner, c.RepoName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// No release published yet
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("failed to fetch latest release: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+
+	newer, err := IsNewerVersion(release.TagName, c.CurrentVersion)
+	if err != nil {
+		return nil, err
+	}
+	if !newer {
+		return nil, nil
+	}
+	return &release, nil
+}
+
+// IsNewerVersion reports whether the semantic version candidate is newer
+// than current. A leading "v" is ignored and a pre-release (1.0.0-rc1) is
+// older than its release.
+func IsNewerVersion(candidate, current string) (bool, error) {
+	a, err := parseSemver(candidate)
+	if err != nil {
+		return false, err
+	}
+	b, err := parseSemver(current)
+	if err != nil {
+		return false, err
+	}
+
+	for i := 0; i < 3; i++ {
+		if a.numbers[i] != b.numbers[i] {
+			return a.numbers[i] > b.numbers[i], nil
+		}
+	}
+	// Same version number: a release is newer than any of its pre-releases
+	switch {
+	case a.preRelease == b.preRelease:
+		return false, nil
+	case a.preRelease == "":
+		return true, nil
+	case b.preRelease == "":
+		return false, nil
+	default:
+		return a.preRelease > b.preRelease, nil
+	}
+}
+
+// semver is a parsed MAJOR.MINOR.PATCH[-PRERELEASE] version
+type semver struct {
+	numbers    [3]int
+	preRelease string
+}
+
+// parseSemver parses a version like "v1.2.3", "1.2" or "1.2.3-beta.1".
+// Missing minor and patch numbers are 0; build metadata is ignored.
+func parseSemver(version string) (semver, error) {
+	var v semver
+	s := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, v.preRelease, _ = strings.Cut(s, "-")
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", version)
+		}
+		v.numbers[i] = n
+	}
+	return v, nil
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsNewerVersion(t *testing.T) {
+	tests := []struct {
+		candidate, current string
+		want               bool
+	}{
+		{"v0.2.0", "0.1.0", true},
+		{"v0.1.0", "0.1.0", false},
+		{"v0.1.0", "0.2.0", false},
+		{"v0.10.0", "0.9.9", true},
+		{"v1.0", "0.9.9", true},
+		{"v1.0.0", "1.0.0-rc1", true},
+		{"v1.0.0-rc2", "1.0.0-rc1", true},
+		{"v1.0.0-rc1", "1.0.0", false},
+		{"v1.0.1+build5", "1.0.0", true},
+	}
+	for _, tt := range tests {
+		got, err := IsNewerVersion(tt.candidate, tt.current)
+		if err != nil {
+			t.Errorf("IsNewerVersion(%q, %q) failed: %v", tt.candidate, tt.current, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("IsNewerVersion(%q, %q) = %v, want %v", tt.candidate, tt.current, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"", "latest", "1.2.3.4", "v1.x"} {
+		if _, err := IsNewerVersion(invalid, "1.0.0"); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func newReleaseServer(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases/latest" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckLatestRelease(t *testing.T) {
+	server := newReleaseServer(t, http.StatusOK, `{"tag_name": "v0.2.0", "html_url": "https://github.com/owner/repo/releases/tag/v0.2.0"}`)
+
+	checker := &UpdateChecker{RepoOwner: "owner", RepoName: "repo", CurrentVersion: "0.1.0", APIURL: server.URL}
+	release, err := checker.CheckLatestRelease(context.Background())
+	if err != nil {
+		t.Fatalf("CheckLatestRelease failed: %v", err)
+	}
+	if release == nil || release.TagName != "v0.2.0" || release.HTMLURL == "" {
+		t.Fatalf("unexpected release: %+v", release)
+	}
+
+	checker.CurrentVersion = "0.2.0"
+	release, err = checker.CheckLatestRelease(context.Background())
+	if err != nil || release != nil {
+		t.Errorf("expected no update, got %+v, %v", release, err)
+	}
+}
+
+func TestCheckLatestRelease_Errors(t *testing.T) {
+	notFound := newReleaseServer(t, http.StatusNotFound, `{"message": "Not Found"}`)
+	checker := &UpdateChecker{RepoOwner: "owner", RepoName: "repo", CurrentVersion: "0.1.0", APIURL: notFound.URL}
+	if release, err := checker.CheckLatestRelease(context.Background()); err != nil || release != nil {
+		t.Errorf("expected no release and no error without releases, got %+v, %v", release, err)
+	}
+
+	limited := newReleaseServer(t, http.StatusForbidden, `{"message": "API rate limit exceeded"}`)
+	checker.APIURL = limited.URL
+	if _, err := checker.CheckLatestRelease(context.Background()); err == nil {
+		t.Error("expected error for rate limited request")
+	}
+}
+
+func TestUpdateConfig_CheckDue(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		config UpdateConfig
+		want   bool
+	}{
+		{"never checked", UpdateConfig{UpdateCheckIntervalDays: 7}, true},
+		{"checked recently", UpdateConfig{UpdateCheckIntervalDays: 7, LastCheck: now.Add(-24 * time.Hour)}, false},
+		{"interval passed", UpdateConfig{UpdateCheckIntervalDays: 7, LastCheck: now.Add(-8 * 24 * time.Hour)}, true},
+		{"disabled", UpdateConfig{UpdateCheckIntervalDays: 0}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.config.CheckDue(now); got != tt.want {
+			t.Errorf("%s: CheckDue() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"fmt"
+	"light-llm-client/db"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Layout of the usage report pages
+const (
+	reportMargin       = 50.0
+	reportRowHeight    = 18.0
+	reportBodyTop      = 110.0
+	reportBodyBottom   = pdfPageHeight - 70
+	reportChartHeight  = 300.0
+	reportDaysPerChart = 31
+)
+
+// usageReport renders usage statistics into a PDF document
+type usageReport struct {
+	doc         *pdfDocument
+	stats       *db.UsageStats
+	dateRange   string
+	generatedAt time.Time
+}
+
+// ExportUsageReportToPDF writes a usage report for the date range: a title
+// page with the totals, provider and model breakdown tables and a bar chart
+// of the daily token usage
+func ExportUsageReportToPDF(stats *db.UsageStats, startDate, endDate time.Time, filePath string) error {
+	if stats == nil {
+		return fmt.Errorf("no usage statistics to export")
+	}
+
+	r := &usageReport{
+		doc:         newPDFDocument(),
+		stats:       stats,
+		dateRange:   startDate.Format("2006-01-02") + " - " + endDate.Format("2006-01-02"),
+		generatedAt: time.Now(),
+	}
+	r.titlePage()
+	r.providerTable()
+	r.modelTable()
+	r.dailyChart()
+	r.footers()
+
+	return r.doc.WriteFile(filePath)
+}
+
+// titlePage writes the report title and the overall totals
+func (r *usageReport) titlePage() {
+	r.doc.AddPage()
+	r.doc.Text(reportMargin, 200, 28, true, "Light LLM Client")
+	r.doc.Text(reportMargin, 240, 20, false, "Usage Report")
+	r.doc.Line(reportMargin, 260, pdfPageWidth-reportMargin, 260)
+	r.doc.Text(reportMargin, 290, 12, false, "Date Range: "+r.dateRange)
+
+	totalCost := 0.0
+	for _, ps := range r.stats.ProviderStats {
+		totalCost += ps.EstimatedCost
+	}
+	y := 340.0
+	for _, line := range []string{
+		"Total Tokens: " + formatReportNumber(r.stats.TotalTokens),
+		"Total Messages: " + formatReportNumber(r.stats.TotalMessages),
+		fmt.Sprintf("Estimated Total Cost: $%.4f", totalCost),
+		fmt.Sprintf("Providers: %d    Models: %d", len(r.stats.ProviderStats), len(r.stats.ModelStats)),
+	} {
+		r.doc.Text(reportMargin, y, 12, false, line)
+		y += 22
+	}
+}
+
+// bodyPage starts a page with the section title under the header
+func (r *usageReport) bodyPage(title string) float64 {
+	r.doc.AddPage()
+	r.doc.Text(reportMargin, 50, 10, true, "Usage Report")
+	r.doc.Text(pdfPageWidth-reportMargin-r.doc.TextWidth(10, r.dateRange), 50, 10, false, r.dateRange)
+	r.doc.Line(reportMargin, 58, pdfPageWidth-reportMargin, 58)
+	r.doc.Text(reportMargin, 90, 16, true, title)
+	return reportBodyTop
+}
+
+// table writes rows under a header row, continuing on new pages as needed.
+// columns holds the x offset of each column from the left margin.
+func (r *usageReport) table(title string, columns []float64, header []string, rows [][]string) {
+	drawHeader := func(y float64) float64 {
+		r.doc.Rect(reportMargin, y-13, pdfPageWidth-2*reportMargin, reportRowHeight, 220, 228, 245)
+		for i, text := range header {
+			r.doc.Text(reportMargin+columns[i]+4, y, 10, true, text)
+		}
+		return y + reportRowHeight
+	}
+
+	y := drawHeader(r.bodyPage(title))
+	if len(rows) == 0 {
+		r.doc.Text(reportMargin+4, y, 10, false, "No data available")
+		return
+	}
+	for _, row := range rows {
+		if y > reportBodyBottom {
+			y = drawHeader(r.bodyPage(title + " (continued)"))
+		}
+		for i, text := range row {
+			r.doc.Text(reportMargin+columns[i]+4, y, 10, false, text)
+		}
+		r.doc.Line(reportMargin, y+5, pdfPageWidth-reportMargin, y+5)
+		y += reportRowHeight
+	}
+}
+
+// providerTable lists the providers by token usage
+func (r *usageReport) providerTable() {
+	providers := make([]*db.ProviderUsageStats, 0, len(r.stats.ProviderStats))
+	for _, ps := range r.stats.ProviderStats {
+		providers = append(providers, ps)
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		if providers[i].TotalTokens != providers[j].TotalTokens {
+			return providers[i].TotalTokens > providers[j].TotalTokens
+		}
+		return providers[i].Provider < providers[j].Provider
+	})
+
+	rows := make([][]string, 0, len(providers))
+	for _, ps := range providers {
+		rows = append(rows, []string{
+			ps.Provider,
+			formatReportNumber(ps.TotalTokens),
+			formatReportNumber(ps.MessageCount),
+			fmt.Sprintf("$%.4f", ps.EstimatedCost),
+		})
+	}
+	r.table("Provider Breakdown", []float64{0, 200, 300, 400},
+		[]string{"Provider", "Tokens", "Messages", "Est. Cost"}, rows)
+}
+
+// modelTable lists the models by token usage
+func (r *usageReport) modelTable() {
+	models := make([]*db.ModelUsageStats, 0, len(r.stats.ModelStats))
+	for _, ms := range r.stats.ModelStats {
+		models = append(models, ms)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].TotalTokens != models[j].TotalTokens {
+			return models[i].TotalTokens > models[j].TotalTokens
+		}
+		return models[i].Model < models[j].Model
+	})
+
+	rows := make([][]string, 0, len(models))
+	for _, ms := range models {
+		rows = append(rows, []string{
+			truncateRunes(ms.Model, 28),
+			truncateRunes(ms.Provider, 14),
+			formatReportNumber(ms.TotalTokens),
+			formatReportNumber(ms.MessageCount),
+			fmt.Sprintf("$%.4f", ms.EstimatedCost),
+		})
+	}
+	r.table("Model Breakdown", []float64{0, 165, 255, 335, 415},
+		[]string{"Model", "Provider", "Tokens", "Messages", "Est. Cost"}, rows)
+}
+
+// dailyChart draws the daily token usage as bars, a month per page
+func (r *usageReport) dailyChart() {
+	days := r.stats.DailyStats
+	if len(days) == 0 {
+		y := r.bodyPage("Usage Over Time")
+		r.doc.Text(reportMargin, y, 10, false, "No data available for chart")
+		return
+	}
+
+	maxTokens := int64(1)
+	for _, day := range days {
+		if day.TotalTokens > maxTokens {
+			maxTokens = day.TotalTokens
+		}
+	}
+
+	chartWidth := pdfPageWidth - 2*reportMargin
+	slot := chartWidth / reportDaysPerChart
+	for start := 0; start < len(days); start += reportDaysPerChart {
+		title := "Usage Over Time"
+		if start > 0 {
+			title += " (continued)"
+		}
+		top := r.bodyPage(title) + 20
+		baseline := top + reportChartHeight
+
+		// Every chart page uses the same scale so bars stay comparable
+		r.doc.Text(reportMargin, top-6, 8, false, formatReportNumber(maxTokens)+" tokens")
+		r.doc.Line(reportMargin, top, reportMargin+chartWidth, top)
+		r.doc.Line(reportMargin, baseline, reportMargin+chartWidth, baseline)
+
+		end := min(start+reportDaysPerChart, len(days))
+		for i, day := range days[start:end] {
+			x := reportMargin + float64(i)*slot
+			height := float64(day.TotalTokens) / float64(maxTokens) * reportChartHeight
+			if height < 1 {
+				height = 1
+			}
+			r.doc.Rect(x+slot*0.15, baseline-height, slot*0.7, height, 100, 150, 255)
+			r.doc.Text(x+1, baseline+12, 6, false, day.Date.Format("01/02"))
+		}
+	}
+}
+
+// footers writes the generation time and page number on every page
+func (r *usageReport) footers() {
+	generated := "Generated " + r.generatedAt.Format("2006-01-02 15:04:05")
+	total := r.doc.PageCount()
+	for i := 0; i < total; i++ {
+		r.doc.SetPage(i)
+		page := fmt.Sprintf("Page %d of %d", i+1, total)
+		r.doc.Line(reportMargin, pdfPageHeight-45, pdfPageWidth-reportMargin, pdfPageHeight-45)
+		r.doc.Text(reportMargin, pdfPageHeight-30, 8, false, generated)
+		r.doc.Text(pdfPageWidth-reportMargin-r.doc.TextWidth(8, page), pdfPageHeight-30, 8, false, page)
+	}
+}
+
+// formatReportNumber formats a number with thousand separators
+func formatReportNumber(n int64) string {
+	if n < 0 {
+		return "-" + formatReportNumber(-n)
+	}
+	str := strconv.FormatInt(n, 10)
+	for i := len(str) - 3; i > 0; i -= 3 {
+		str = str[:i] + "," + str[i:]
+	}
+	return str
+}
+
+// truncateRunes shortens text to max characters with an ellipsis
+func truncateRunes(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"light-llm-client/db"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func TestExportUsageReportToPDF(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stats := &db.UsageStats{
+		TotalTokens:   3000,
+		TotalMessages: 40,
+		ProviderStats: map[string]*db.ProviderUsageStats{
+			"openai": {Provider: "openai", TotalTokens: 2000, MessageCount: 25, EstimatedCost: 0.02},
+			"ollama": {Provider: "ollama", TotalTokens: 1000, MessageCount: 15},
+		},
+		ModelStats: map[string]*db.ModelUsageStats{
+			"openai:gpt-4o":  {Model: "gpt-4o", Provider: "openai", TotalTokens: 2000, MessageCount: 25},
+			"ollama:qwen(2)": {Model: "qwen(2)", Provider: "ollama", TotalTokens: 1000, MessageCount: 15},
+		},
+	}
+	// 40 days need a second chart page
+	for i := 0; i < 40; i++ {
+		stats.DailyStats = append(stats.DailyStats, &db.DailyUsageStats{
+			Date:        start.AddDate(0, 0, i),
+			TotalTokens: int64(i * 10),
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	if err := ExportUsageReportToPDF(stats, start, start.AddDate(0, 0, 39), path); err != nil {
+		t.Fatalf("ExportUsageReportToPDF failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("output is not framed as a PDF")
+	}
+
+	// Title, provider table, model table and two chart pages
+	if !bytes.Contains(data, []byte("/Count 5")) {
+		t.Error("expected 5 pages")
+	}
+	content := pdfContent(t, data)
+	for _, want := range []string{
+		"2024-01-01 - 2024-02-09",
+		"Page 5 of 5",
+		"openai",
+		"qwen(2)",
+		"2,000",
+	} {
+		if !strings.Contains(content, pdfString(want)) {
+			t.Errorf("PDF does not contain %s", want)
+		}
+	}
+	if bars := countPDFRects(content, "0.392 0.588 1.000"); bars != 40 {
+		t.Errorf("drew %d bars, want 40", bars)
+	}
+
+	// Every xref entry points at its object
+	match := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(data)
+	if match == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(data[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if !bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))) {
+			t.Errorf("xref entry %d points at the wrong offset", i+1)
+		}
+	}
+}
+
+func TestExportUsageReportToPDF_Chinese(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stats := &db.UsageStats{
+		TotalTokens: 100,
+		ModelStats: map[string]*db.ModelUsageStats{
+			"本地:通义千问": {Model: "通义千问", Provider: "本地", TotalTokens: 100},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	if err := ExportUsageReportToPDF(stats, start, start, path); err != nil {
+		t.Fatalf("ExportUsageReportToPDF failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	content := pdfContent(t, data)
+	for _, want := range []string{"通义千问", "本地"} {
+		if !strings.Contains(content, pdfString(want)) {
+			t.Errorf("PDF does not contain %s", want)
+		}
+	}
+	if !bytes.Contains(data, []byte("/FontFile2")) {
+		t.Error("the font is not embedded")
+	}
+}
+
+// pdfContent returns the inflated streams of a PDF
+func pdfContent(t *testing.T, data []byte) string {
+	t.Helper()
+	var sb strings.Builder
+	for _, match := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(data, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(match[1]))
+		if err != nil {
+			sb.Write(match[1])
+			continue
+		}
+		inflated, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("failed to inflate a stream: %v", err)
+		}
+		sb.Write(inflated)
+	}
+	return sb.String()
+}
+
+// countPDFRects counts the rectangles filled with an RGB color, written as
+// PDF operands. gofpdf also repeats the fill color at the top of each page.
+func countPDFRects(content, color string) int {
+	return len(regexp.MustCompile(regexp.QuoteMeta(color)+` rg\n[-0-9. ]+ re f`).FindAllString(content, -1))
+}
+
+// pdfString returns text as gofpdf writes it in a string literal: UTF-16BE
+// with parentheses and backslashes escaped
+func pdfString(text string) string {
+	var sb strings.Builder
+	for _, unit := range utf16.Encode([]rune(text)) {
+		for _, b := range []byte{byte(unit >> 8), byte(unit)} {
+			if b == '(' || b == ')' || b == '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(b)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// RemoteFileInfo describes a file on a WebDAV server
+type RemoteFileInfo struct {
+	Exists       bool
+	ETag         string
+	LastModified time.Time
+}
+
+// Version identifies the remote file content, preferring the ETag
+func (r *RemoteFileInfo) Version() string {
+	if r.ETag != "" {
+		return r.ETag
+	}
+	if r.LastModified.IsZero() {
+		return ""
+	}
+	return r.LastModified.UTC().Format(time.RFC3339)
+}
+
+// WebDAVClient reads and writes a single file on a WebDAV server
+type WebDAVClient struct {
+	fileURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAVClient creates a client for the file at fileURL
+func NewWebDAVClient(fileURL, username, password string) *WebDAVClient {
+	return &WebDAVClient{
+		fileURL:  fileURL,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+func (c *WebDAVClient) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.fileURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return req, nil
+}
+
+// Stat returns the ETag and modification time of the remote file
+func (c *WebDAVClient) Stat(ctx context.Context) (*RemoteFileInfo, error) {
+	req, err := c.newRequest(ctx, http.MethodHead, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat remote file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &RemoteFileInfo{Exists: false}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to stat remote file: %s", resp.Status)
+	}
+
+	return remoteFileInfoFromHeader(resp.Header), nil
+}
+
+// Download writes the remote file to destPath
+func (c *WebDAVClient) Download(ctx context.Context, destPath string) (*RemoteFileInfo, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download remote file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download remote file: %s", resp.Status)
+	}
+
+	file, err := os.Create(destPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write downloaded file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close file: %w", err)
+	}
+
+	return remoteFileInfoFromHeader(resp.Header), nil
+}
+
+// Upload replaces the remote file with the contents of srcPath
+func (c *WebDAVClient) Upload(ctx context.Context, srcPath string) (*RemoteFileInfo, error) {
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPut, file)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = stat.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return nil, fmt.Errorf("failed to upload file: %s", resp.Status)
+	}
+
+	// Not all servers return the new ETag on PUT
+	info := remoteFileInfoFromHeader(resp.Header)
+	if info.ETag == "" {
+		return c.Stat(ctx)
+	}
+	return info, nil
+}
+
+func remoteFileInfoFromHeader(header http.Header) *RemoteFileInfo {
+	info := &RemoteFileInfo{
+		Exists: true,
+		ETag:   header.Get("ETag"),
+	}
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		if t, err := http.ParseTime(lastModified); err == nil {
+			info.LastModified = t
+		}
+	}
+	return info
+}